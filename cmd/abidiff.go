@@ -0,0 +1,124 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/elfviewer/elfviewer/elf"
+	"github.com/elfviewer/elfviewer/schema"
+)
+
+// runABIDiff implements "elfviewer abi-diff", which compares the interface
+// of two builds of a shared library. It exits with status 1 when there are
+// breaking changes, so that it can guard releases in CI, and 2 when the
+// builds cannot be compared.
+func runABIDiff(args []string) error {
+	breaking, err := compareABI(args)
+	switch {
+	case err != nil:
+		return &ExitError{Code: 2, Err: err}
+	case breaking > 0:
+		return &ExitError{Code: 1}
+	}
+	return nil
+}
+
+// compareABI compares the builds named by args and returns the number of
+// breaking changes.
+func compareABI(args []string) (int, error) {
+	fs := flag.NewFlagSet("abi-diff", flag.ContinueOnError)
+	fs.Usage = printABIDiffUsage
+	var format string
+	fs.StringVar(&format, "o", "text", "Output format: text, json or yaml")
+	fs.StringVar(&format, "output", "text", "Output format: text, json or yaml")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0, nil
+		}
+		return 0, err
+	}
+	if fs.NArg() != 2 {
+		printABIDiffUsage()
+		return 0, fmt.Errorf("expected an old and a new ELF file")
+	}
+
+	oldName, newName := fs.Arg(0), fs.Arg(1)
+	oldFile, err := openFile(oldName)
+	if err != nil {
+		return 0, err
+	}
+	newFile, err := openFile(newName)
+	if err != nil {
+		return 0, err
+	}
+
+	report := elf.CompareABI(oldFile, newFile)
+	for _, w := range report.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", w)
+	}
+	switch format {
+	case "text":
+		writeABIReport(os.Stdout, oldName, newName, report)
+	case "json":
+		err = schema.WriteJSON(os.Stdout, schema.NewABIReport(oldName, newName, report))
+	case "yaml":
+		err = schema.WriteYAML(os.Stdout, schema.NewABIReport(oldName, newName, report))
+	default:
+		return 0, fmt.Errorf("unknown output format %q", format)
+	}
+	return report.Breaking(), err
+}
+
+// writeABIReport prints the breaking changes of r, then the compatible ones
+// and a summary.
+func writeABIReport(w io.Writer, oldName, newName string, r *elf.ABIReport) {
+	fmt.Fprintf(w, "ABI changes from %s to %s:\n", oldName, newName)
+	for _, breaking := range []bool{true, false} {
+		title := "Breaking changes:"
+		if !breaking {
+			title = "Compatible changes:"
+		}
+		printed := false
+		for _, c := range r.Changes {
+			if c.Breaking != breaking {
+				continue
+			}
+			if !printed {
+				fmt.Fprintf(w, "\n%s\n", title)
+				printed = true
+			}
+			fmt.Fprintf(w, "  %s\n", c.Message)
+		}
+	}
+	breaking := r.Breaking()
+	compatible := len(r.Changes) - breaking
+	if len(r.Changes) == 0 {
+		fmt.Fprintf(w, "\nNo changes.\n")
+		return
+	}
+	fmt.Fprintf(w, "\n%d breaking %s, %d compatible %s\n",
+		breaking, plural(breaking, "change", "changes"), compatible, plural(compatible, "change", "changes"))
+}
+
+func plural(n int, one, many string) string {
+	if n == 1 {
+		return one
+	}
+	return many
+}
+
+func printABIDiffUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: elfviewer abi-diff [options] <old-elf-file> <new-elf-file>\n\n")
+	fmt.Fprintf(os.Stderr, "Compares the exported symbols, their versions, the signatures of exported\n")
+	fmt.Fprintf(os.Stderr, "functions and the layouts of the types they use. Signatures and layouts\n")
+	fmt.Fprintf(os.Stderr, "are taken from DWARF debugging information, so build both with -g.\n")
+	fmt.Fprintf(os.Stderr, "Exits with status 1 if any change is breaking and 2 if the files cannot\n")
+	fmt.Fprintf(os.Stderr, "be compared.\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	fmt.Fprintf(os.Stderr, "  -o, --output <format>  Output format: text (default), json or yaml\n\n")
+	fmt.Fprintf(os.Stderr, "Examples:\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer abi-diff libfoo.so.1.0 libfoo.so.1.1\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer abi-diff -o json old/libfoo.so new/libfoo.so > abi.json\n")
+}
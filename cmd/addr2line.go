@@ -0,0 +1,227 @@
+package cmd
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/elfviewer/elfviewer/elf"
+	"github.com/elfviewer/elfviewer/schema"
+)
+
+// runAddr2Line implements "elfviewer addr2line", which maps code addresses
+// to source locations the way binutils addr2line does. Addresses come from
+// the command line or, when none are given, from standard input, one or
+// more per line, so that a crash log can be piped through it.
+func runAddr2Line(args []string) error {
+	fs := flag.NewFlagSet("addr2line", flag.ContinueOnError)
+	fs.Usage = printAddr2LineUsage
+	var (
+		exe       string
+		functions bool
+		addresses bool
+		basenames bool
+		pretty    bool
+		format    string
+	)
+	fs.StringVar(&exe, "e", "", "ELF file to look addresses up in")
+	fs.StringVar(&exe, "exe", "", "ELF file to look addresses up in")
+	fs.BoolVar(&functions, "f", false, "Show function names")
+	fs.BoolVar(&functions, "functions", false, "Show function names")
+	fs.BoolVar(&addresses, "a", false, "Show addresses")
+	fs.BoolVar(&addresses, "addresses", false, "Show addresses")
+	fs.BoolVar(&basenames, "s", false, "Strip directories from file names")
+	fs.BoolVar(&basenames, "basenames", false, "Strip directories from file names")
+	fs.BoolVar(&pretty, "p", false, "Print each location on a single line")
+	fs.BoolVar(&pretty, "pretty-print", false, "Print each location on a single line")
+	fs.StringVar(&format, "o", "text", "Output format: text, json or yaml")
+	fs.StringVar(&format, "output", "text", "Output format: text, json or yaml")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+
+	rest := fs.Args()
+	if exe == "" {
+		if len(rest) == 0 {
+			printAddr2LineUsage()
+			return fmt.Errorf("no ELF file specified")
+		}
+		exe, rest = rest[0], rest[1:]
+	}
+	file, err := openFile(exe)
+	if err != nil {
+		return err
+	}
+
+	switch format {
+	case "text":
+	case "json", "yaml":
+		addrs, err := readAddresses(rest)
+		if err != nil {
+			return err
+		}
+		locs, err := schema.NewSourceLocations(file, addrs)
+		if err != nil {
+			return err
+		}
+		if format == "yaml" {
+			return schema.WriteYAML(os.Stdout, locs)
+		}
+		return schema.WriteJSON(os.Stdout, locs)
+	default:
+		return fmt.Errorf("unknown output format %q", format)
+	}
+
+	p := addr2linePrinter{
+		w:         os.Stdout,
+		file:      file,
+		functions: functions,
+		addresses: addresses,
+		basenames: basenames,
+		pretty:    pretty,
+	}
+	if len(rest) > 0 {
+		for _, arg := range rest {
+			p.lookup(arg)
+		}
+		return nil
+	}
+	sc := bufio.NewScanner(os.Stdin)
+	sc.Split(bufio.ScanWords)
+	for sc.Scan() {
+		p.lookup(sc.Text())
+	}
+	return sc.Err()
+}
+
+// readAddresses parses the addresses given as arguments, or read from
+// standard input if there are none.
+func readAddresses(args []string) ([]uint64, error) {
+	if len(args) == 0 {
+		sc := bufio.NewScanner(os.Stdin)
+		sc.Split(bufio.ScanWords)
+		for sc.Scan() {
+			args = append(args, sc.Text())
+		}
+		if err := sc.Err(); err != nil {
+			return nil, err
+		}
+	}
+	addrs := make([]uint64, 0, len(args))
+	for _, arg := range args {
+		a, err := parseAddress(arg)
+		if err != nil {
+			return nil, err
+		}
+		addrs = append(addrs, a)
+	}
+	return addrs, nil
+}
+
+// parseAddress parses a hexadecimal address with an optional 0x prefix.
+func parseAddress(s string) (uint64, error) {
+	hex := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	a, err := strconv.ParseUint(hex, 16, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid address %q", s)
+	}
+	return a, nil
+}
+
+type addr2linePrinter struct {
+	w         io.Writer
+	file      *elf.File
+	functions bool
+	addresses bool
+	basenames bool
+	pretty    bool
+	warned    bool
+}
+
+// lookup prints the location of one address. Like binutils, it prints an
+// unknown location for a malformed address, so that every input keeps its
+// record, and reports malformed line tables on standard error without
+// stopping the lookups that follow.
+func (p *addr2linePrinter) lookup(arg string) {
+	var loc elf.SourceLocation
+	addr, err := parseAddress(arg)
+	if err == nil {
+		loc, err = p.file.SourceLine(addr)
+		if err != nil && !p.warned {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+			p.warned = true
+		}
+	}
+
+	if p.addresses {
+		width := 16
+		if p.file.Class == elf.ELFCLASS32 {
+			width = 8
+		}
+		sep := "\n"
+		if p.pretty {
+			sep = ": "
+		}
+		fmt.Fprintf(p.w, "0x%0*x%s", width, addr, sep)
+	}
+	if p.functions {
+		switch {
+		case p.pretty && loc.Function == "":
+			fmt.Fprint(p.w, "?? ")
+		case p.pretty:
+			fmt.Fprintf(p.w, "%s at ", loc.Function)
+		case loc.Function == "":
+			fmt.Fprintln(p.w, "??")
+		default:
+			fmt.Fprintln(p.w, loc.Function)
+		}
+	}
+
+	// Like binutils, tell an address that falls in a known function
+	// without line information from one that is not known at all.
+	switch {
+	case loc.File == "" && loc.Function != "":
+		fmt.Fprint(p.w, "??:?")
+	case loc.File == "":
+		fmt.Fprint(p.w, "??:0")
+	default:
+		name := loc.File
+		if p.basenames {
+			name = path.Base(name)
+		}
+		if loc.Line == 0 {
+			fmt.Fprintf(p.w, "%s:?", name)
+		} else {
+			fmt.Fprintf(p.w, "%s:%d", name, loc.Line)
+		}
+	}
+	if loc.Discriminator != 0 {
+		fmt.Fprintf(p.w, " (discriminator %d)", loc.Discriminator)
+	}
+	fmt.Fprintln(p.w)
+}
+
+func printAddr2LineUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: elfviewer addr2line [options] <elf-file> [address...]\n")
+	fmt.Fprintf(os.Stderr, "       elfviewer addr2line [options] -e <elf-file> [address...]\n\n")
+	fmt.Fprintf(os.Stderr, "Translates hexadecimal addresses into file names and line numbers.\n")
+	fmt.Fprintf(os.Stderr, "Addresses are read from standard input when none are given.\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	fmt.Fprintf(os.Stderr, "  -e, --exe <elf-file>  ELF file to look addresses up in\n")
+	fmt.Fprintf(os.Stderr, "  -f, --functions   Show function names\n")
+	fmt.Fprintf(os.Stderr, "  -a, --addresses   Show addresses\n")
+	fmt.Fprintf(os.Stderr, "  -s, --basenames   Strip directories from file names\n")
+	fmt.Fprintf(os.Stderr, "  -p, --pretty-print  Print each location on a single line\n")
+	fmt.Fprintf(os.Stderr, "  -o, --output <format>  Output format: text (default), json or yaml\n\n")
+	fmt.Fprintf(os.Stderr, "Examples:\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer addr2line -f ./app 0x401136\n")
+	fmt.Fprintf(os.Stderr, "  grep -o '0x[0-9a-f]*' crash.log | elfviewer addr2line -a -f -e ./app\n")
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/elfviewer/elfviewer/elf"
+	"github.com/elfviewer/elfviewer/schema"
+)
+
+// runDiff implements "elfviewer diff", which compares the structure of two
+// ELF files. Like diff(1), it exits with status 1 when the files differ
+// and 2 when they cannot be compared.
+func runDiff(args []string) error {
+	differ, err := diffFiles(args)
+	switch {
+	case err != nil:
+		return &ExitError{Code: 2, Err: err}
+	case differ:
+		return &ExitError{Code: 1}
+	}
+	return nil
+}
+
+// diffFiles compares the files named by args and reports whether they
+// differ.
+func diffFiles(args []string) (bool, error) {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = printDiffUsage
+	var format string
+	fs.StringVar(&format, "o", "text", "Output format: text, json or yaml")
+	fs.StringVar(&format, "output", "text", "Output format: text, json or yaml")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return false, nil
+		}
+		return false, err
+	}
+	if fs.NArg() != 2 {
+		printDiffUsage()
+		return false, fmt.Errorf("expected two ELF files")
+	}
+
+	oldName, newName := fs.Arg(0), fs.Arg(1)
+	oldFile, err := openFile(oldName)
+	if err != nil {
+		return false, err
+	}
+	newFile, err := openFile(newName)
+	if err != nil {
+		return false, err
+	}
+
+	d := elf.Diff(oldFile, newFile)
+	switch format {
+	case "text":
+		writeDiff(os.Stdout, oldName, newName, d)
+	case "json":
+		err = schema.WriteJSON(os.Stdout, schema.NewFileDiff(oldName, newName, d))
+	case "yaml":
+		err = schema.WriteYAML(os.Stdout, schema.NewFileDiff(oldName, newName, d))
+	default:
+		return false, fmt.Errorf("unknown output format %q", format)
+	}
+	return !d.Empty(), err
+}
+
+// writeDiff prints the differences table by table. Entries are marked
+// with "+" when added, "-" when removed and "~" when changed.
+func writeDiff(w io.Writer, oldName, newName string, d *elf.FileDiff) {
+	fmt.Fprintf(w, "--- %s (%d bytes)\n", oldName, d.OldSize)
+	if d.NewSize != d.OldSize {
+		fmt.Fprintf(w, "+++ %s (%d bytes, %+d)\n", newName, d.NewSize, int64(d.NewSize-d.OldSize))
+	} else {
+		fmt.Fprintf(w, "+++ %s (%d bytes)\n", newName, d.NewSize)
+	}
+
+	if len(d.Header) > 0 {
+		fmt.Fprintf(w, "\nELF Header:\n")
+		for _, f := range d.Header {
+			fmt.Fprintf(w, "  %s: %s -> %s\n", f.Field, f.Old, f.New)
+		}
+	}
+	tables := []struct {
+		title   string
+		entries []elf.DiffEntry
+	}{
+		{"Sections", d.Sections},
+		{"Segments", d.Segments},
+		{"Symbols", d.Symbols},
+		{"Dynamic entries", d.Dynamic},
+		{"Notes", d.Notes},
+	}
+	for _, t := range tables {
+		if len(t.entries) == 0 {
+			continue
+		}
+		fmt.Fprintf(w, "\n%s:\n", t.title)
+		for _, e := range t.entries {
+			mark := map[string]string{elf.DiffAdded: "+", elf.DiffRemoved: "-", elf.DiffChanged: "~"}[e.Kind]
+			var fields []string
+			for _, f := range e.Fields {
+				fields = append(fields, fmt.Sprintf("%s %s -> %s", f.Field, f.Old, f.New))
+			}
+			line := fmt.Sprintf("  %s %s", mark, e.Name)
+			if len(fields) > 0 {
+				line += ": " + strings.Join(fields, ", ")
+			}
+			fmt.Fprintf(w, "%s%s\n", line, sizeDelta(e.SizeDelta))
+		}
+	}
+
+	if d.Empty() {
+		fmt.Fprintf(w, "\nNo differences.\n")
+		return
+	}
+	n := d.Count()
+	fmt.Fprintf(w, "\n%d %s\n", n, plural(n, "difference", "differences"))
+}
+
+func sizeDelta(delta int64) string {
+	if delta == 0 {
+		return ""
+	}
+	return fmt.Sprintf(" (%+d bytes)", delta)
+}
+
+func printDiffUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: elfviewer diff [options] <elf-file> <elf-file>\n\n")
+	fmt.Fprintf(os.Stderr, "Compares the header, sections, segments, symbols, dynamic entries and\n")
+	fmt.Fprintf(os.Stderr, "notes of two ELF files. Exits with status 1 if they differ and 2 if\n")
+	fmt.Fprintf(os.Stderr, "they cannot be compared.\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	fmt.Fprintf(os.Stderr, "  -o, --output <format>  Output format: text (default), json or yaml\n\n")
+	fmt.Fprintf(os.Stderr, "Examples:\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer diff build1/app build2/app\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer diff -o json old.elf new.elf\n")
+}
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/elfviewer/elfviewer/elf"
+)
+
+// parseDumpAddr parses the address:length argument of --dump-addr. The
+// address is hexadecimal like in addr2line; the length is decimal unless
+// prefixed with 0x.
+func parseDumpAddr(s string) (uint64, uint64, error) {
+	addrStr, lenStr, ok := strings.Cut(s, ":")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid memory range %q, expected address:length", s)
+	}
+	addr, err := parseAddress(addrStr)
+	if err != nil {
+		return 0, 0, err
+	}
+	n, err := strconv.ParseUint(lenStr, 0, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid length %q", lenStr)
+	}
+	if n > elf.MaxRead {
+		return 0, 0, fmt.Errorf("length %s exceeds the maximum of %d bytes", lenStr, elf.MaxRead)
+	}
+	return addr, n, nil
+}
+
+// attachExecutable lets a core file read the code it leaves out from the
+// executable, taken from exe or else from the path the core records. A
+// missing executable at the recorded path is not an error; ReadAt reports
+// which memory it could not read.
+func attachExecutable(file *elf.File, exe string) error {
+	core, err := file.CoreDump()
+	if err != nil {
+		return nil
+	}
+	path := core.Executable()
+	if path == "" {
+		return nil
+	}
+	if exe == "" {
+		data, err := os.ReadFile(path)
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		file.AttachMappedFile(path, data)
+		return nil
+	}
+	data, err := os.ReadFile(exe)
+	if err != nil {
+		return err
+	}
+	file.AttachMappedFile(path, data)
+	return nil
+}
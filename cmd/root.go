@@ -6,6 +6,8 @@ import (
 	"os"
 
 	"github.com/elfviewer/elfviewer/elf"
+	"github.com/elfviewer/elfviewer/elf/disasm"
+	"github.com/elfviewer/elfviewer/schema"
 )
 
 var (
@@ -14,8 +16,22 @@ var (
 	showSegments bool
 	showSymbols  bool
 	showDynamic  bool
+	showRelocs   bool
+	showNotes    bool
+	showVersions bool
+	showMinVers  bool
+	showArch     bool
+	showDebug    bool
+	showSecurity bool
+	showCore     bool
 	showAll      bool
 	hexDump      string
+	dumpAddr     string
+	exePath      string
+	decompress   bool
+	disassemble  string
+	asmSyntax    string
+	outputFormat string
 	help         bool
 )
 
@@ -30,14 +46,72 @@ func init() {
 	flag.BoolVar(&showSymbols, "symbols", false, "Show symbol table")
 	flag.BoolVar(&showDynamic, "d", false, "Show dynamic section")
 	flag.BoolVar(&showDynamic, "dynamic", false, "Show dynamic section")
+	flag.BoolVar(&showRelocs, "r", false, "Show relocations")
+	flag.BoolVar(&showRelocs, "relocs", false, "Show relocations")
+	flag.BoolVar(&showNotes, "n", false, "Show notes")
+	flag.BoolVar(&showNotes, "notes", false, "Show notes")
+	flag.BoolVar(&showVersions, "V", false, "Show symbol version information")
+	flag.BoolVar(&showVersions, "version-info", false, "Show symbol version information")
+	flag.BoolVar(&showMinVers, "m", false, "Show minimum required library versions")
+	flag.BoolVar(&showMinVers, "min-versions", false, "Show minimum required library versions")
+	flag.BoolVar(&showArch, "A", false, "Show architecture specific attributes")
+	flag.BoolVar(&showArch, "arch-specific", false, "Show architecture specific attributes")
+	flag.BoolVar(&showDebug, "w", false, "Show DWARF compile units, functions, variables and structure layouts")
+	flag.BoolVar(&showDebug, "debug-info", false, "Show DWARF compile units, functions, variables and structure layouts")
+	flag.BoolVar(&showSecurity, "c", false, "Show security hardening features")
+	flag.BoolVar(&showSecurity, "security", false, "Show security hardening features")
+	flag.BoolVar(&showCore, "C", false, "Show the threads, signal and mapped files of a core file")
+	flag.BoolVar(&showCore, "core", false, "Show the threads, signal and mapped files of a core file")
 	flag.BoolVar(&showAll, "a", false, "Show all information")
 	flag.BoolVar(&showAll, "all", false, "Show all information")
 	flag.StringVar(&hexDump, "x", "", "Dump section in hex")
 	flag.StringVar(&hexDump, "hex", "", "Dump section in hex")
+	flag.StringVar(&dumpAddr, "dump-addr", "", "Dump memory in hex, given as address:length")
+	flag.StringVar(&exePath, "exe", "", "Executable to read the code of a core file from")
+	flag.BoolVar(&decompress, "z", false, "Decompress sections before dumping them")
+	flag.BoolVar(&decompress, "decompress", false, "Decompress sections before dumping them")
+	flag.StringVar(&disassemble, "D", "", "Disassemble section or symbol")
+	flag.StringVar(&disassemble, "disassemble", "", "Disassemble section or symbol")
+	flag.StringVar(&asmSyntax, "M", "att", "Assembler syntax: att or intel")
+	flag.StringVar(&asmSyntax, "syntax", "att", "Assembler syntax: att or intel")
+	flag.StringVar(&outputFormat, "o", "text", "Output format: text, json or yaml")
+	flag.StringVar(&outputFormat, "output", "text", "Output format: text, json or yaml")
 	flag.BoolVar(&help, "help", false, "Show help message")
 }
 
+// ExitError asks for the program to exit with status Code. Err, if not
+// nil, is reported first; commands that find differences, as diff(1)
+// does, exit without one.
+type ExitError struct {
+	Code int
+	Err  error
+}
+
+func (e *ExitError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("exit status %d", e.Code)
+	}
+	return e.Err.Error()
+}
+
+func (e *ExitError) Unwrap() error {
+	return e.Err
+}
+
 func Execute() error {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "addr2line":
+			return runAddr2Line(os.Args[2:])
+		case "abi-diff":
+			return runABIDiff(os.Args[2:])
+		case "diff":
+			return runDiff(os.Args[2:])
+		case "size":
+			return runSize(os.Args[2:])
+		}
+	}
+
 	flag.Parse()
 
 	if help || flag.NArg() == 0 {
@@ -50,9 +124,9 @@ func Execute() error {
 
 	filename := flag.Arg(0)
 	
-	file, err := elf.Open(filename)
+	file, err := openFile(filename)
 	if err != nil {
-		return fmt.Errorf("failed to open %s: %w", filename, err)
+		return err
 	}
 
 	if showAll {
@@ -61,9 +135,28 @@ func Execute() error {
 		showSegments = true
 		showSymbols = true
 		showDynamic = true
+		showRelocs = true
+		showNotes = true
+		showVersions = true
+		showMinVers = true
+		showArch = true
+		showDebug = true
+		showSecurity = true
+		showCore = file.Type == elf.ET_CORE
 	}
 
-	if showHeader && hexDump == "" {
+	switch outputFormat {
+	case "text":
+	case "json", "yaml":
+		if disassemble != "" {
+			return fmt.Errorf("disassembly is only available as text output")
+		}
+		return writeStructured(file)
+	default:
+		return fmt.Errorf("unknown output format %q", outputFormat)
+	}
+
+	if showHeader && hexDump == "" && dumpAddr == "" && disassemble == "" {
 		file.DisplayHeader(os.Stdout)
 		fmt.Println()
 	}
@@ -88,8 +181,75 @@ func Execute() error {
 		fmt.Println()
 	}
 
+	if showRelocs {
+		file.DisplayRelocations(os.Stdout)
+		fmt.Println()
+	}
+
+	if showNotes {
+		file.DisplayNotes(os.Stdout)
+		fmt.Println()
+	}
+
+	if showVersions {
+		file.DisplayVersionInfo(os.Stdout)
+		fmt.Println()
+	}
+
+	if showMinVers {
+		file.DisplayVersionRequirements(os.Stdout)
+		fmt.Println()
+	}
+
+	if showArch {
+		file.DisplayAttributes(os.Stdout)
+		fmt.Println()
+	}
+
+	if showDebug {
+		if err := file.DisplayDebugInfo(os.Stdout); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	if showSecurity {
+		file.DisplaySecurity(os.Stdout)
+		fmt.Println()
+	}
+
+	if showCore {
+		file.DisplayCore(os.Stdout)
+		fmt.Println()
+	}
+
 	if hexDump != "" {
-		if err := file.DisplayHexDump(os.Stdout, hexDump); err != nil {
+		if err := file.DisplayHexDump(os.Stdout, hexDump, decompress); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	if dumpAddr != "" {
+		addr, n, err := parseDumpAddr(dumpAddr)
+		if err != nil {
+			return err
+		}
+		if err := attachExecutable(file, exePath); err != nil {
+			return err
+		}
+		if err := file.DisplayMemory(os.Stdout, addr, n); err != nil {
+			return err
+		}
+		fmt.Println()
+	}
+
+	if disassemble != "" {
+		syntax, err := disasm.ParseSyntax(asmSyntax)
+		if err != nil {
+			return err
+		}
+		if err := file.DisplayDisassembly(os.Stdout, disassemble, syntax); err != nil {
 			return err
 		}
 		fmt.Println()
@@ -98,20 +258,112 @@ func Execute() error {
 	return nil
 }
 
+// writeStructured emits the selected views using the versioned schema
+// shared with the WebAssembly build.
+func writeStructured(file *elf.File) error {
+	info := schema.New(file, schema.Views{
+		Sections:            showSections,
+		Segments:            showSegments,
+		Symbols:             showSymbols,
+		Dynamic:             showDynamic,
+		Relocations:         showRelocs,
+		Notes:               showNotes,
+		Versions:            showVersions,
+		VersionRequirements: showMinVers,
+		Attributes:          showArch,
+		DebugInfo:           showDebug,
+		Security:            showSecurity,
+		Core:                showCore,
+	})
+
+	if hexDump != "" {
+		dump, err := schema.NewHexDump(file, hexDump, decompress)
+		if err != nil {
+			return err
+		}
+		info.HexDump = dump
+	}
+
+	if dumpAddr != "" {
+		addr, n, err := parseDumpAddr(dumpAddr)
+		if err != nil {
+			return err
+		}
+		if err := attachExecutable(file, exePath); err != nil {
+			return err
+		}
+		dump, err := schema.NewMemoryDump(file, addr, n)
+		if err != nil {
+			return err
+		}
+		info.MemoryDump = dump
+	}
+
+	if outputFormat == "yaml" {
+		return schema.WriteYAML(os.Stdout, info)
+	}
+	return schema.WriteJSON(os.Stdout, info)
+}
+
+// openFile opens an ELF file and reports on stderr the parts of it that
+// could not be parsed.
+func openFile(filename string) (*elf.File, error) {
+	file, err := elf.Open(filename)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open %s: %w", filename, err)
+	}
+	for _, w := range file.Warnings {
+		fmt.Fprintf(os.Stderr, "Warning: %s: %s\n", filename, w)
+	}
+	return file, nil
+}
+
 func printUsage() {
-	fmt.Fprintf(os.Stderr, "Usage: elfviewer [options] <elf-file>\n\n")
+	fmt.Fprintf(os.Stderr, "Usage: elfviewer [options] <elf-file>\n")
+	fmt.Fprintf(os.Stderr, "       elfviewer <command> [options] <elf-file> ...\n\n")
+	fmt.Fprintf(os.Stderr, "Commands:\n")
+	fmt.Fprintf(os.Stderr, "  addr2line         Map addresses to source files and lines\n")
+	fmt.Fprintf(os.Stderr, "  abi-diff          Report ABI changes between two builds of a library\n")
+	fmt.Fprintf(os.Stderr, "  diff              Compare the structure of two ELF files\n")
+	fmt.Fprintf(os.Stderr, "  size              Break down file and memory size by section or symbol\n\n")
 	fmt.Fprintf(os.Stderr, "Options:\n")
 	fmt.Fprintf(os.Stderr, "  -h, --header      Show ELF header (default)\n")
 	fmt.Fprintf(os.Stderr, "  -S, --sections    Show section headers\n")
 	fmt.Fprintf(os.Stderr, "  -l, --segments    Show program headers\n")
 	fmt.Fprintf(os.Stderr, "  -s, --symbols     Show symbol table\n")
 	fmt.Fprintf(os.Stderr, "  -d, --dynamic     Show dynamic section\n")
+	fmt.Fprintf(os.Stderr, "  -r, --relocs      Show relocations\n")
+	fmt.Fprintf(os.Stderr, "  -n, --notes       Show notes\n")
+	fmt.Fprintf(os.Stderr, "  -V, --version-info  Show symbol version information\n")
+	fmt.Fprintf(os.Stderr, "  -m, --min-versions  Show minimum required library versions\n")
+	fmt.Fprintf(os.Stderr, "  -A, --arch-specific  Show architecture specific attributes\n")
+	fmt.Fprintf(os.Stderr, "  -w, --debug-info  Show compile units, functions, variables and structure layouts\n")
+	fmt.Fprintf(os.Stderr, "  -c, --security    Show RELRO, NX, PIE, canary, FORTIFY and other hardening\n")
+	fmt.Fprintf(os.Stderr, "  -C, --core        Show the threads, signal and mapped files of a core file\n")
 	fmt.Fprintf(os.Stderr, "  -a, --all         Show all information\n")
 	fmt.Fprintf(os.Stderr, "  -x, --hex <section>  Dump section in hex\n")
+	fmt.Fprintf(os.Stderr, "  --dump-addr <addr:len>  Dump memory at a virtual address in hex\n")
+	fmt.Fprintf(os.Stderr, "  --exe <file>      Executable of a core file, if not where the core says\n")
+	fmt.Fprintf(os.Stderr, "  -z, --decompress  Decompress the section before dumping it\n")
+	fmt.Fprintf(os.Stderr, "  -D, --disassemble <section|symbol>  Disassemble a section or function\n")
+	fmt.Fprintf(os.Stderr, "  -M, --syntax <syntax>  Assembler syntax: att (default) or intel\n")
+	fmt.Fprintf(os.Stderr, "  -o, --output <format>  Output format: text (default), json or yaml\n")
 	fmt.Fprintf(os.Stderr, "  --help           Show this help message\n\n")
 	fmt.Fprintf(os.Stderr, "Examples:\n")
 	fmt.Fprintf(os.Stderr, "  elfviewer /bin/ls               # Show ELF header\n")
 	fmt.Fprintf(os.Stderr, "  elfviewer -a /bin/ls            # Show all information\n")
 	fmt.Fprintf(os.Stderr, "  elfviewer -S /bin/ls            # Show section headers\n")
 	fmt.Fprintf(os.Stderr, "  elfviewer -x .text /bin/ls      # Hex dump of .text section\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer -z -x .debug_info a.o # Hex dump of compressed debug info\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer -D main /bin/ls         # Disassemble the main function\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer -D .text -M intel /bin/ls  # Disassemble .text in Intel syntax\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer -o json -S /bin/ls    # Section headers as JSON\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer -w ./app              # Functions and structure layouts from DWARF\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer -c /bin/ls            # Check hardening like checksec\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer -C core.1234           # Why and where a process crashed\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer --dump-addr 0x401136:64 core.1234  # Memory of a crashed process\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer addr2line -f ./app 0x401136  # Source line of an address\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer abi-diff old/libfoo.so new/libfoo.so  # Check for ABI breaks\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer diff build1/app build2/app  # What changed between two builds\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer size -d symbols ./app  # Largest symbols\n")
 }
\ No newline at end of file
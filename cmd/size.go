@@ -0,0 +1,213 @@
+package cmd
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/elfviewer/elfviewer/elf"
+	"github.com/elfviewer/elfviewer/schema"
+)
+
+// runSize implements "elfviewer size", which shows what takes up the space
+// of a file and of its memory image, in the manner of bloaty.
+func runSize(args []string) error {
+	fs := flag.NewFlagSet("size", flag.ContinueOnError)
+	fs.Usage = printSizeUsage
+	var (
+		by       string
+		sortBy   string
+		top      int
+		baseline string
+		format   string
+	)
+	fs.StringVar(&by, "d", elf.SizeBySections, "Break down by segments, sections or symbols")
+	fs.StringVar(&by, "data-source", elf.SizeBySections, "Break down by segments, sections or symbols")
+	fs.StringVar(&sortBy, "s", "both", "Sort by file, vm, both or name")
+	fs.StringVar(&sortBy, "sort", "both", "Sort by file, vm, both or name")
+	fs.IntVar(&top, "n", 20, "Show the largest N rows, or all with 0")
+	fs.IntVar(&top, "top", 20, "Show the largest N rows, or all with 0")
+	fs.StringVar(&baseline, "b", "", "Compare with a baseline file")
+	fs.StringVar(&baseline, "baseline", "", "Compare with a baseline file")
+	fs.StringVar(&format, "o", "text", "Output format: text, json or yaml")
+	fs.StringVar(&format, "output", "text", "Output format: text, json or yaml")
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return nil
+		}
+		return err
+	}
+	if fs.NArg() != 1 {
+		printSizeUsage()
+		return fmt.Errorf("expected one ELF file")
+	}
+	switch sortBy {
+	case "file", "vm", "both", "name":
+	default:
+		return fmt.Errorf("unknown sort order %q", sortBy)
+	}
+
+	filename := fs.Arg(0)
+	file, err := openFile(filename)
+	if err != nil {
+		return err
+	}
+	rows, err := file.SizeBreakdown(by)
+	if err != nil {
+		return err
+	}
+	if baseline != "" {
+		base, err := openFile(baseline)
+		if err != nil {
+			return err
+		}
+		baseRows, err := base.SizeBreakdown(by)
+		if err != nil {
+			return err
+		}
+		rows = elf.CompareSizes(rows, baseRows)
+	}
+
+	total := elf.SizeRow{Name: "TOTAL"}
+	for _, r := range rows {
+		total.FileSize += r.FileSize
+		total.VMSize += r.VMSize
+		total.BaseFileSize += r.BaseFileSize
+		total.BaseVMSize += r.BaseVMSize
+	}
+	rows = sortSizes(rows, sortBy, baseline != "")
+	rows = topSizes(rows, top)
+
+	switch format {
+	case "text":
+		writeSizes(os.Stdout, rows, total, baseline != "")
+		return nil
+	case "json":
+		return schema.WriteJSON(os.Stdout, schema.NewSizeReport(by, baseline, rows, total))
+	case "yaml":
+		return schema.WriteYAML(os.Stdout, schema.NewSizeReport(by, baseline, rows, total))
+	}
+	return fmt.Errorf("unknown output format %q", format)
+}
+
+// sortSizes orders rows largest first or by name. Compared with a
+// baseline, rows are ordered by how much they changed and those that did
+// not change are left out.
+func sortSizes(rows []elf.SizeRow, by string, compare bool) []elf.SizeRow {
+	key := func(r elf.SizeRow) (uint64, uint64) { return r.FileSize, r.VMSize }
+	if compare {
+		key = func(r elf.SizeRow) (uint64, uint64) {
+			return absDelta(r.FileSize, r.BaseFileSize), absDelta(r.VMSize, r.BaseVMSize)
+		}
+		changed := rows[:0]
+		for _, r := range rows {
+			if file, vm := key(r); file != 0 || vm != 0 {
+				changed = append(changed, r)
+			}
+		}
+		rows = changed
+	}
+	sort.SliceStable(rows, func(i, j int) bool {
+		fi, vi := key(rows[i])
+		fj, vj := key(rows[j])
+		switch by {
+		case "name":
+			return rows[i].Name < rows[j].Name
+		case "file":
+			return fi > fj
+		case "vm":
+			return vi > vj
+		}
+		return max(fi, vi) > max(fj, vj)
+	})
+	return rows
+}
+
+func absDelta(a, b uint64) uint64 {
+	if a > b {
+		return a - b
+	}
+	return b - a
+}
+
+// topSizes keeps the first n rows and sums up the rest in one row.
+func topSizes(rows []elf.SizeRow, n int) []elf.SizeRow {
+	if n <= 0 || len(rows) <= n {
+		return rows
+	}
+	others := elf.SizeRow{Name: fmt.Sprintf("[%d Others]", len(rows)-n)}
+	for _, r := range rows[n:] {
+		others.FileSize += r.FileSize
+		others.VMSize += r.VMSize
+		others.BaseFileSize += r.BaseFileSize
+		others.BaseVMSize += r.BaseVMSize
+	}
+	return append(rows[:n:n], others)
+}
+
+func writeSizes(w io.Writer, rows []elf.SizeRow, total elf.SizeRow, compare bool) {
+	if compare {
+		fmt.Fprintf(w, "%22s  %22s\n", "FILE SIZE", "VM SIZE")
+		fmt.Fprintf(w, "%22s  %22s\n", "----------------------", "----------------------")
+		line := func(r elf.SizeRow) {
+			fmt.Fprintf(w, "%+12d %9s  %+12d %9s  %s\n",
+				int64(r.FileSize-r.BaseFileSize), growth(r.FileSize, r.BaseFileSize),
+				int64(r.VMSize-r.BaseVMSize), growth(r.VMSize, r.BaseVMSize), r.Name)
+		}
+		for _, r := range rows {
+			line(r)
+		}
+		line(total)
+		return
+	}
+
+	fmt.Fprintf(w, "%19s  %19s\n", "FILE SIZE", "VM SIZE")
+	fmt.Fprintf(w, "%19s  %19s\n", "-------------------", "-------------------")
+	line := func(r elf.SizeRow) {
+		fmt.Fprintf(w, "%12d %6s  %12d %6s  %s\n",
+			r.FileSize, percent(r.FileSize, total.FileSize), r.VMSize, percent(r.VMSize, total.VMSize), r.Name)
+	}
+	for _, r := range rows {
+		line(r)
+	}
+	line(total)
+}
+
+func percent(n, total uint64) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%.1f%%", float64(n)*100/float64(total))
+}
+
+// growth returns the relative change from base to n.
+func growth(n, base uint64) string {
+	switch {
+	case n == base:
+		return ""
+	case base == 0:
+		return "[NEW]"
+	case n == 0:
+		return "[DEL]"
+	}
+	return fmt.Sprintf("%+.1f%%", (float64(n)-float64(base))*100/float64(base))
+}
+
+func printSizeUsage() {
+	fmt.Fprintf(os.Stderr, "Usage: elfviewer size [options] <elf-file>\n\n")
+	fmt.Fprintf(os.Stderr, "Shows how the size of the file and of its image in memory divides up\n")
+	fmt.Fprintf(os.Stderr, "among its segments, sections or symbols. Bytes that none of them covers\n")
+	fmt.Fprintf(os.Stderr, "are listed as headers, [LOAD #N] padding or [Unmapped].\n\n")
+	fmt.Fprintf(os.Stderr, "Options:\n")
+	fmt.Fprintf(os.Stderr, "  -d, --data-source <source>  segments, sections (default) or symbols\n")
+	fmt.Fprintf(os.Stderr, "  -s, --sort <order>  file, vm, both (default) or name\n")
+	fmt.Fprintf(os.Stderr, "  -n, --top <n>     Show the largest N rows, or all with 0 (default 20)\n")
+	fmt.Fprintf(os.Stderr, "  -b, --baseline <elf-file>  Show the changes from a baseline file\n")
+	fmt.Fprintf(os.Stderr, "  -o, --output <format>  Output format: text (default), json or yaml\n\n")
+	fmt.Fprintf(os.Stderr, "Examples:\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer size firmware.elf\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer size -d symbols -n 50 firmware.elf\n")
+	fmt.Fprintf(os.Stderr, "  elfviewer size -d symbols -b old/firmware.elf new/firmware.elf\n")
+}
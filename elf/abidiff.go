@@ -0,0 +1,516 @@
+package elf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Kinds of ABIChange.
+const (
+	ABISymbolRemoved     = "symbol-removed"
+	ABISymbolAdded       = "symbol-added"
+	ABISymbolType        = "symbol-type"
+	ABISymbolSize        = "symbol-size"
+	ABISymbolVersion     = "symbol-version"
+	ABISignature         = "signature"
+	ABIVariableType      = "variable-type"
+	ABITypeRemoved       = "type-removed"
+	ABITypeSize          = "type-size"
+	ABIMemberRemoved     = "member-removed"
+	ABIMemberAdded       = "member-added"
+	ABIMemberOffset      = "member-offset"
+	ABIMemberType        = "member-type"
+	ABIEnumeratorRemoved = "enumerator-removed"
+	ABIEnumeratorAdded   = "enumerator-added"
+	ABIEnumeratorValue   = "enumerator-value"
+)
+
+// ABIChange is a difference between the interfaces of two builds of a
+// library. Subject is the symbol or type the change concerns. A change is
+// breaking when programs built against the old library may fail or
+// misbehave with the new one.
+type ABIChange struct {
+	Kind     string
+	Breaking bool
+	Subject  string
+	Message  string
+}
+
+// ABIReport lists the changes between two builds of a library, symbols
+// first and then types, each in name order. Warnings explain what could
+// not be compared, such as type layouts when a file has no debugging
+// information.
+type ABIReport struct {
+	Changes  []ABIChange
+	Warnings []string
+}
+
+// Breaking returns the number of breaking changes.
+func (r *ABIReport) Breaking() int {
+	n := 0
+	for _, c := range r.Changes {
+		if c.Breaking {
+			n++
+		}
+	}
+	return n
+}
+
+func (r *ABIReport) add(kind string, breaking bool, subject, format string, args ...interface{}) {
+	r.Changes = append(r.Changes, ABIChange{
+		Kind:     kind,
+		Breaking: breaking,
+		Subject:  subject,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// ExportedSymbols returns the symbols the file defines for other modules
+// to use: the global and weak symbols of the dynamic symbol table with
+// default or protected visibility. Files without a dynamic symbol table,
+// such as relocatable objects, are described by their static one.
+func (f *File) ExportedSymbols() []Symbol {
+	var table *SectionHeader
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type == SHT_DYNSYM {
+			table = sh
+			break
+		}
+		if sh.Type == SHT_SYMTAB && table == nil {
+			table = sh
+		}
+	}
+	if table == nil {
+		return nil
+	}
+	syms, err := f.readSymbolTable(table)
+	if err != nil {
+		return nil
+	}
+
+	// Old linkers define a symbol for each version name.
+	versionNames := make(map[string]bool)
+	for _, def := range f.VersionDefs {
+		for _, name := range def.Names {
+			versionNames[name] = true
+		}
+	}
+	var out []Symbol
+	for _, s := range syms {
+		switch {
+		case s.Name == "" || s.Shndx == SHN_UNDEF:
+			continue
+		case s.Bind() != STB_GLOBAL && s.Bind() != STB_WEAK && s.Bind() != STB_GNU_UNIQUE:
+			continue
+		case s.Visibility() != STV_DEFAULT && s.Visibility() != STV_PROTECTED:
+			continue
+		case s.Type() == STT_SECTION || s.Type() == STT_FILE:
+			continue
+		case s.Shndx == SHN_ABS && versionNames[s.Name]:
+			continue
+		}
+		out = append(out, s)
+	}
+	return out
+}
+
+// symbolKey identifies a symbol across builds by its name and version.
+func symbolKey(s *Symbol) string {
+	if s.Version == "" {
+		return s.Name
+	}
+	return s.Name + "@" + s.Version
+}
+
+// CompareABI compares the interface that the library old exports with
+// that of new: the exported symbols and their versions, the signatures of
+// exported functions and the layouts of the types they use, which are
+// taken from the DWARF debugging information of both files.
+func CompareABI(old, new *File) *ABIReport {
+	r := &ABIReport{}
+
+	oldSyms := make(map[string]*Symbol)
+	newSyms := make(map[string]*Symbol)
+	newByName := make(map[string][]*Symbol)
+	oldExports, newExports := old.ExportedSymbols(), new.ExportedSymbols()
+	for i := range oldExports {
+		oldSyms[symbolKey(&oldExports[i])] = &oldExports[i]
+	}
+	for i := range newExports {
+		s := &newExports[i]
+		newSyms[symbolKey(s)] = s
+		newByName[s.Name] = append(newByName[s.Name], s)
+	}
+
+	var keys []string
+	for k := range oldSyms {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	matched := make(map[*Symbol]bool)
+	var pairs [][2]*Symbol
+	for _, k := range keys {
+		o := oldSyms[k]
+		n := newSyms[k]
+		if n == nil && o.Version == "" && len(newByName[o.Name]) > 0 {
+			// Programs linked against an unversioned symbol bind to
+			// whichever version the new library defines.
+			n = newByName[o.Name][0]
+			r.add(ABISymbolVersion, false, o.Name, "symbol %s is now versioned as %s", o.Name, n.VersionedName())
+		}
+		if n == nil {
+			r.add(ABISymbolRemoved, true, o.VersionedName(), "removed symbol %s", o.VersionedName())
+			continue
+		}
+		matched[n] = true
+		pairs = append(pairs, [2]*Symbol{o, n})
+
+		if o.Type() != n.Type() {
+			r.add(ABISymbolType, true, o.Name, "symbol %s changed type from %s to %s",
+				o.Name, SymbolTypeString(o.Type()), SymbolTypeString(n.Type()))
+		} else if o.Size != n.Size && (o.Type() == STT_OBJECT || o.Type() == STT_TLS || o.Type() == STT_COMMON) {
+			// Executables hold copies of the variables they use, sized
+			// when they were linked.
+			r.add(ABISymbolSize, true, o.Name, "variable %s changed size from %d to %d bytes", o.Name, o.Size, n.Size)
+		}
+		if o.Version != "" && o.VersionedName() != n.VersionedName() {
+			r.add(ABISymbolVersion, false, o.Name, "%s is no longer the default version of %s", o.VersionedName(), o.Name)
+		}
+	}
+	var added []string
+	for i := range newExports {
+		if s := &newExports[i]; !matched[s] {
+			added = append(added, s.VersionedName())
+		}
+	}
+	sort.Strings(added)
+	for _, name := range added {
+		r.add(ABISymbolAdded, false, name, "added symbol %s", name)
+	}
+
+	oldDI, err := old.DebugInfo()
+	if oldDI == nil || len(oldDI.Units) == 0 {
+		if err != nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("old file: %v", err))
+		}
+		r.Warnings = append(r.Warnings, "the old file has no debugging information; signatures and type layouts were not compared")
+		return r
+	}
+	newDI, err := new.DebugInfo()
+	if newDI == nil || len(newDI.Units) == 0 {
+		if err != nil {
+			r.Warnings = append(r.Warnings, fmt.Sprintf("new file: %v", err))
+		}
+		r.Warnings = append(r.Warnings, "the new file has no debugging information; signatures and type layouts were not compared")
+		return r
+	}
+	compareDebugInfo(r, pairs, oldDI, newDI)
+	return r
+}
+
+// debugEntities indexes the exported functions and variables of a file by
+// their symbol names.
+type debugEntities struct {
+	funcs map[string]*Function
+	vars  map[string]*Variable
+}
+
+func (di *DebugInfo) exportedEntities() debugEntities {
+	e := debugEntities{funcs: make(map[string]*Function), vars: make(map[string]*Variable)}
+	for _, u := range di.Units {
+		for _, fn := range di.Functions(u) {
+			name := fn.LinkageName
+			if name == "" {
+				name = fn.Name
+			}
+			if _, ok := e.funcs[name]; !ok && fn.External {
+				fn := fn
+				e.funcs[name] = &fn
+			}
+		}
+		for _, v := range di.Variables(u) {
+			name := v.LinkageName
+			if name == "" {
+				name = v.Name
+			}
+			if _, ok := e.vars[name]; !ok && v.External {
+				v := v
+				e.vars[name] = &v
+			}
+		}
+	}
+	return e
+}
+
+// compareDebugInfo compares the signatures of the exported functions and
+// the types of the exported variables in pairs, and then the layouts of
+// every type they can reach.
+func compareDebugInfo(r *ABIReport, pairs [][2]*Symbol, oldDI, newDI *DebugInfo) {
+	oldEnt, newEnt := oldDI.exportedEntities(), newDI.exportedEntities()
+	w := &typeWalker{
+		report:  r,
+		old:     oldDI,
+		new:     newDI,
+		oldDefs: oldDI.definedTypes(),
+		newDefs: newDI.definedTypes(),
+		seen:    make(map[string]bool),
+	}
+	var roots []*DIE
+	done := make(map[string]bool)
+	for _, p := range pairs {
+		name := p[0].Name
+		if done[name] {
+			continue
+		}
+		done[name] = true
+		if o, n := oldEnt.funcs[name], newEnt.funcs[name]; o != nil {
+			if n != nil && !sameSignature(o, n) {
+				r.add(ABISignature, true, o.Name, "function %s changed signature from %q to %q",
+					o.Name, o.Prototype(), n.Prototype())
+			}
+			roots = append(roots, oldDI.ref(o.Entry, DW_AT_type))
+			for _, c := range o.Entry.Children {
+				if c.Tag == DW_TAG_formal_parameter {
+					roots = append(roots, oldDI.ref(c, DW_AT_type))
+				}
+			}
+		}
+		if o, n := oldEnt.vars[name], newEnt.vars[name]; o != nil {
+			if n != nil && o.Type != n.Type {
+				r.add(ABIVariableType, true, o.Name, "variable %s changed type from %s to %s", o.Name, o.Type, n.Type)
+			}
+			roots = append(roots, oldDI.ref(o.Entry, DW_AT_type))
+		}
+	}
+
+	start := len(r.Changes)
+	for _, t := range roots {
+		w.walk(t, "", 0)
+	}
+	// Report types in name order rather than in the order they were
+	// reached.
+	types := r.Changes[start:]
+	sort.SliceStable(types, func(i, j int) bool {
+		return types[i].Subject < types[j].Subject
+	})
+}
+
+func sameSignature(a, b *Function) bool {
+	if a.ReturnType != b.ReturnType || a.Variadic != b.Variadic || len(a.Params) != len(b.Params) {
+		return false
+	}
+	for i := range a.Params {
+		if a.Params[i].Type != b.Params[i].Type {
+			return false
+		}
+	}
+	return true
+}
+
+// definedTypes indexes the structures, classes, unions and enumerations
+// that are defined, rather than only declared, by their names. Anonymous
+// types are indexed under the name of a typedef for them.
+func (di *DebugInfo) definedTypes() map[string]*DIE {
+	defs := make(map[string]*DIE)
+	for _, u := range di.Units {
+		walkDIEs(u.Root, func(d *DIE) bool {
+			switch d.Tag {
+			case DW_TAG_structure_type, DW_TAG_class_type, DW_TAG_union_type, DW_TAG_enumeration_type:
+				if d.Name() != "" && !d.Flag(DW_AT_declaration) {
+					if name := di.TypeName(d); defs[name] == nil {
+						defs[name] = d
+					}
+				}
+			case DW_TAG_typedef:
+				if t := di.Ref(d, DW_AT_type); isAnonymousAggregate(t) {
+					if name := di.QualifiedName(d); defs[name] == nil {
+						defs[name] = t
+					}
+				}
+			}
+			return true
+		})
+	}
+	return defs
+}
+
+func isAnonymousAggregate(d *DIE) bool {
+	if d == nil || d.Name() != "" || d.Flag(DW_AT_declaration) {
+		return false
+	}
+	switch d.Tag {
+	case DW_TAG_structure_type, DW_TAG_class_type, DW_TAG_union_type, DW_TAG_enumeration_type:
+		return true
+	}
+	return false
+}
+
+// typeWalker follows the types of the old library from the exported
+// functions and variables, comparing each structure and enumeration it
+// reaches with the type of the same name in the new library.
+type typeWalker struct {
+	report           *ABIReport
+	old, new         *DebugInfo
+	oldDefs, newDefs map[string]*DIE
+	seen             map[string]bool
+}
+
+// walk visits type d. alias is the name of the typedef d was reached
+// through, which names anonymous types.
+func (w *typeWalker) walk(d *DIE, alias string, depth int) {
+	for ; d != nil && depth < maxTypeDepth; depth++ {
+		switch d.Tag {
+		case DW_TAG_typedef:
+			alias = w.old.QualifiedName(d)
+			d = w.old.Ref(d, DW_AT_type)
+			continue
+		case DW_TAG_pointer_type, DW_TAG_reference_type, DW_TAG_rvalue_reference_type,
+			DW_TAG_const_type, DW_TAG_volatile_type, DW_TAG_restrict_type, DW_TAG_atomic_type,
+			DW_TAG_array_type, DW_TAG_ptr_to_member_type:
+			d = w.old.Ref(d, DW_AT_type)
+			alias = ""
+			continue
+		case DW_TAG_subroutine_type:
+			w.walk(w.old.Ref(d, DW_AT_type), "", depth+1)
+			for _, c := range d.Children {
+				if c.Tag == DW_TAG_formal_parameter {
+					w.walk(w.old.Ref(c, DW_AT_type), "", depth+1)
+				}
+			}
+			return
+		case DW_TAG_structure_type, DW_TAG_class_type, DW_TAG_union_type, DW_TAG_enumeration_type:
+			w.aggregate(d, alias, depth)
+		}
+		return
+	}
+}
+
+func (w *typeWalker) aggregate(d *DIE, alias string, depth int) {
+	name := alias
+	if d.Name() != "" {
+		name = w.old.TypeName(d)
+	}
+	if name == "" || w.seen[name] {
+		return
+	}
+	w.seen[name] = true
+	if d.Flag(DW_AT_declaration) {
+		// An opaque type; its definition may be in another unit.
+		if d = w.oldDefs[name]; d == nil {
+			return
+		}
+	}
+	n := w.newDefs[name]
+	if n == nil {
+		w.report.add(ABITypeRemoved, true, name, "%s is no longer defined", name)
+	} else if d.Tag == DW_TAG_enumeration_type {
+		w.compareEnums(name, d, n)
+	} else {
+		w.compareLayouts(name, w.old.StructLayout(d), w.new.StructLayout(n))
+	}
+	for _, c := range d.Children {
+		if c.Tag == DW_TAG_member || c.Tag == DW_TAG_inheritance {
+			w.walk(w.old.Ref(c, DW_AT_type), "", depth+1)
+		}
+	}
+}
+
+// memberKey identifies a member across builds. Base classes have no name
+// and are identified by their type.
+func memberKey(m *StructMember) string {
+	if m.Name == "" {
+		return "base " + m.Type
+	}
+	return m.Name
+}
+
+func memberOffset(m *StructMember) string {
+	if m.BitSize > 0 {
+		return fmt.Sprintf("%d:%d", m.Offset, m.BitOffset)
+	}
+	return fmt.Sprint(m.Offset)
+}
+
+func (w *typeWalker) compareLayouts(name string, o, n *StructLayout) {
+	r := w.report
+	if o.Size != n.Size {
+		r.add(ABITypeSize, true, name, "%s changed size from %d to %d bytes", name, o.Size, n.Size)
+	}
+	newMembers := make(map[string]*StructMember)
+	for i := range n.Members {
+		newMembers[memberKey(&n.Members[i])] = &n.Members[i]
+	}
+	oldMembers := make(map[string]bool)
+	for i := range o.Members {
+		om := &o.Members[i]
+		key := memberKey(om)
+		oldMembers[key] = true
+		nm := newMembers[key]
+		switch {
+		case nm == nil:
+			r.add(ABIMemberRemoved, true, name, "%s: member %s removed", name, key)
+			continue
+		case om.Type != nm.Type:
+			r.add(ABIMemberType, true, name, "%s: member %s changed type from %s to %s", name, key, om.Type, nm.Type)
+		case om.BitSize != nm.BitSize:
+			r.add(ABIMemberType, true, name, "%s: member %s changed width from %d to %d bits", name, key, om.BitSize, nm.BitSize)
+		}
+		if om.Offset != nm.Offset || om.BitOffset != nm.BitOffset {
+			r.add(ABIMemberOffset, true, name, "%s: member %s moved from offset %s to %s",
+				name, key, memberOffset(om), memberOffset(nm))
+		}
+	}
+	for i := range n.Members {
+		nm := &n.Members[i]
+		if key := memberKey(nm); !oldMembers[key] {
+			// Members that fit in what used to be padding leave the
+			// layout compatible; any other growth shows up as a change
+			// of size or offsets.
+			r.add(ABIMemberAdded, false, name, "%s: member %s added at offset %s", name, key, memberOffset(nm))
+		}
+	}
+}
+
+func (w *typeWalker) compareEnums(name string, o, n *DIE) {
+	r := w.report
+	if os, ok := w.old.TypeSize(o); ok {
+		if ns, ok := w.new.TypeSize(n); ok && os != ns {
+			r.add(ABITypeSize, true, name, "%s changed size from %d to %d bytes", name, os, ns)
+		}
+	}
+	newValues := make(map[string]*DIEAttr)
+	for _, c := range n.Children {
+		if c.Tag == DW_TAG_enumerator {
+			newValues[c.Name()] = c.Attr(DW_AT_const_value)
+		}
+	}
+	oldNames := make(map[string]bool)
+	for _, c := range o.Children {
+		if c.Tag != DW_TAG_enumerator {
+			continue
+		}
+		oldNames[c.Name()] = true
+		ov := c.Attr(DW_AT_const_value)
+		nv, ok := newValues[c.Name()]
+		switch {
+		case !ok:
+			r.add(ABIEnumeratorRemoved, true, name, "%s: enumerator %s removed", name, c.Name())
+		case ov != nil && nv != nil && ov.Val != nv.Val:
+			r.add(ABIEnumeratorValue, true, name, "%s: enumerator %s changed value from %s to %s",
+				name, c.Name(), enumValue(ov), enumValue(nv))
+		}
+	}
+	for _, c := range n.Children {
+		if c.Tag == DW_TAG_enumerator && !oldNames[c.Name()] {
+			r.add(ABIEnumeratorAdded, false, name, "%s: enumerator %s added", name, c.Name())
+		}
+	}
+}
+
+func enumValue(a *DIEAttr) string {
+	if a.Form == DW_FORM_sdata || a.Form == DW_FORM_implicit_const {
+		return fmt.Sprint(int64(a.Val))
+	}
+	return fmt.Sprint(a.Val)
+}
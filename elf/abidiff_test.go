@@ -0,0 +1,35 @@
+package elf
+
+import (
+	"testing"
+)
+
+func TestCompareABI(t *testing.T) {
+	r := CompareABI(testFile(t, "libv1.so"), testFile(t, "libv2.so"))
+
+	want := []ABIChange{
+		{ABISymbolRemoved, true, "lib_old", "removed symbol lib_old"},
+		{ABISymbolAdded, false, "lib_new", "added symbol lib_new"},
+		{ABISignature, true, "lib_scale", `function lib_scale changed signature from "int lib_scale(int v)" to "long int lib_scale(long int v, int by)"`},
+		{ABIEnumeratorValue, true, "enum color", "enum color: enumerator GREEN changed value from 1 to 2"},
+		{ABIEnumeratorAdded, false, "enum color", "enum color: enumerator ORANGE added"},
+		{ABITypeSize, true, "struct point", "struct point changed size from 8 to 12 bytes"},
+		{ABIMemberOffset, true, "struct point", "struct point: member y moved from offset 4 to 8"},
+		{ABIMemberAdded, false, "struct point", "struct point: member z added at offset 4"},
+	}
+	if len(r.Changes) != len(want) {
+		t.Fatalf("got %d changes, want %d: %+v", len(r.Changes), len(want), r.Changes)
+	}
+	for i := range want {
+		if r.Changes[i] != want[i] {
+			t.Errorf("change %d: got %+v, want %+v", i, r.Changes[i], want[i])
+		}
+	}
+	if r.Breaking() != 5 || len(r.Warnings) != 0 {
+		t.Errorf("got %d breaking changes and warnings %q, want 5 and none", r.Breaking(), r.Warnings)
+	}
+
+	if r := CompareABI(testFile(t, "libv1.so"), testFile(t, "libv1.so")); len(r.Changes) != 0 {
+		t.Errorf("got changes between a library and itself: %+v", r.Changes)
+	}
+}
@@ -0,0 +1,270 @@
+package elf
+
+import "sort"
+
+// SourceLocation is the source position of a code address. Line is zero
+// when the address is not covered by line information. Function names the
+// innermost function inlined at the address or else the function symbol
+// containing it, and is empty when there is neither.
+type SourceLocation struct {
+	Address       uint64
+	Function      string
+	File          string
+	Line          int
+	Column        int
+	Discriminator uint64
+}
+
+// lineRange maps the addresses [low, high) to a row of a line table.
+type lineRange struct {
+	low, high uint64
+	table     int
+	row       int
+}
+
+// funcRange is the extent [low, high) of a function symbol.
+type funcRange struct {
+	name      string
+	file      string
+	low, high uint64
+	global    bool
+	section   int
+	secEnd    uint64
+}
+
+// inlineRange is a range [low, high) of the code of an inlined function,
+// depth being the number of inlined instances that enclose it.
+type inlineRange struct {
+	name      string
+	low, high uint64
+	depth     int
+}
+
+// lineIndex holds the line tables of a file with their rows, the function
+// symbols and the inlined functions, sorted by address. It is built on the
+// first lookup.
+type lineIndex struct {
+	tables  []LineTable
+	ranges  []lineRange
+	funcs   []funcRange
+	inlines []inlineRange
+	err     error
+
+	// maxHigh[i], maxEnd[i] and maxInline[i] are the highest end
+	// addresses of ranges[0..i], funcs[0..i] and inlines[0..i], which
+	// bound how far back a lookup has to look.
+	maxHigh   []uint64
+	maxEnd    []uint64
+	maxInline []uint64
+}
+
+func (f *File) lines() *lineIndex {
+	if f.lineIndex != nil {
+		return f.lineIndex
+	}
+	idx := &lineIndex{}
+	idx.tables, idx.err = f.LineTables()
+	for ti := range idx.tables {
+		rows := idx.tables[ti].Rows
+		for i := 0; i+1 < len(rows); i++ {
+			// Rows sharing an address give an empty range, leaving the
+			// last of them to describe the address.
+			if rows[i].EndSequence || rows[i+1].Address <= rows[i].Address {
+				continue
+			}
+			idx.ranges = append(idx.ranges, lineRange{
+				low:   rows[i].Address,
+				high:  rows[i+1].Address,
+				table: ti,
+				row:   i,
+			})
+		}
+	}
+	sort.SliceStable(idx.ranges, func(i, j int) bool {
+		return idx.ranges[i].low < idx.ranges[j].low
+	})
+	idx.maxHigh = make([]uint64, len(idx.ranges))
+	for i, r := range idx.ranges {
+		idx.maxHigh[i] = r.high
+		if i > 0 {
+			idx.maxHigh[i] = max(r.high, idx.maxHigh[i-1])
+		}
+	}
+
+	// Local symbols follow the STT_FILE symbol of the source file that
+	// defines them, which names the file of functions without line
+	// information.
+	file := ""
+	for i := range f.Symbols {
+		sym := &f.Symbols[i]
+		switch {
+		case sym.Type() == STT_FILE:
+			file = sym.Name
+		case sym.Name == "" && sym.Info == 0 && sym.Shndx == SHN_UNDEF:
+			// The null symbol starting the next symbol table.
+			file = ""
+		}
+		ndx, ok := sym.SectionIndex()
+		if sym.Type() != STT_FUNC || !ok || ndx >= len(f.SectionHeaders) {
+			continue
+		}
+		sh := &f.SectionHeaders[ndx]
+		fn := funcRange{
+			name:    sym.Name,
+			low:     f.symbolAddr(sym),
+			section: ndx,
+			secEnd:  sh.Addr + sh.Size,
+			global:  sym.Bind() == STB_GLOBAL,
+		}
+		fn.high = fn.low + sym.Size
+		if sym.Bind() == STB_LOCAL {
+			fn.file = file
+		}
+		idx.funcs = append(idx.funcs, fn)
+	}
+	sort.SliceStable(idx.funcs, func(i, j int) bool {
+		return idx.funcs[i].low < idx.funcs[j].low
+	})
+	// As binutils does, let a function extend over any gap up to the next
+	// function in its section, which also gives symbols without a size,
+	// such as _init, an extent.
+	for i := range idx.funcs {
+		fn := &idx.funcs[i]
+		next := fn.secEnd
+		for j := i + 1; j < len(idx.funcs); j++ {
+			if g := &idx.funcs[j]; g.low > fn.low && g.section == fn.section {
+				next = min(next, g.low)
+				break
+			}
+		}
+		fn.high = max(fn.high, next, fn.low+1)
+	}
+	idx.maxEnd = make([]uint64, len(idx.funcs))
+	for i, fn := range idx.funcs {
+		idx.maxEnd[i] = fn.high
+		if i > 0 {
+			idx.maxEnd[i] = max(fn.high, idx.maxEnd[i-1])
+		}
+	}
+
+	// A damaged .debug_info only costs the names of inlined functions.
+	if di, _ := f.DebugInfo(); di != nil {
+		for _, u := range di.Units {
+			idx.addInlines(di, u.Root, 0)
+		}
+	}
+	sort.SliceStable(idx.inlines, func(i, j int) bool {
+		return idx.inlines[i].low < idx.inlines[j].low
+	})
+	idx.maxInline = make([]uint64, len(idx.inlines))
+	for i, in := range idx.inlines {
+		idx.maxInline[i] = in.high
+		if i > 0 {
+			idx.maxInline[i] = max(in.high, idx.maxInline[i-1])
+		}
+	}
+
+	f.lineIndex = idx
+	return idx
+}
+
+// addInlines adds the ranges of the inlined functions below d, which is
+// enclosed by depth inlined instances.
+func (idx *lineIndex) addInlines(di *DebugInfo, d *DIE, depth int) {
+	for _, c := range d.Children {
+		depth := depth
+		if c.Tag == DW_TAG_inlined_subroutine {
+			depth++
+			if name := di.name(c); name != "" {
+				for _, r := range di.Ranges(c) {
+					idx.inlines = append(idx.inlines, inlineRange{name: name, low: r[0], high: r[1], depth: depth})
+				}
+			}
+		}
+		idx.addInlines(di, c, depth)
+	}
+}
+
+// SourceLine maps a code address to the file, line and enclosing function
+// it was compiled from, using the DWARF line tables and the function
+// symbols. As in binutils, the function of code inlined into another is
+// the innermost inlined one. An address without line information is not
+// an error; its location is returned with a zero Line, and an empty File
+// unless the symbol table names the source file of its function.
+func (f *File) SourceLine(addr uint64) (SourceLocation, error) {
+	idx := f.lines()
+	loc := SourceLocation{Address: addr}
+	if fn := idx.function(addr); fn != nil {
+		loc.Function = fn.name
+		loc.File = fn.file
+	}
+	if in := idx.inline(addr); in != nil {
+		loc.Function = in.name
+	}
+
+	// Sequences do not overlap in linked files, but in relocatable ones
+	// those of every section start at zero. Look back past ranges that
+	// start lower without reaching addr and settle ties on the sequence
+	// that comes first, which belongs to the lowest section.
+	i := sort.Search(len(idx.ranges), func(i int) bool {
+		return idx.ranges[i].low > addr
+	})
+	var found *lineRange
+	for i--; i >= 0 && idx.maxHigh[i] > addr; i-- {
+		r := &idx.ranges[i]
+		if addr >= r.high {
+			continue
+		}
+		if found == nil || r.table < found.table || (r.table == found.table && r.row < found.row) {
+			found = r
+		}
+	}
+	if found != nil {
+		t := &idx.tables[found.table]
+		row := t.Rows[found.row]
+		loc.File = t.FileName(row.File)
+		loc.Line = row.Line
+		loc.Column = row.Column
+		loc.Discriminator = row.Discriminator
+	}
+	return loc, idx.err
+}
+
+// function returns the function symbol whose extent contains addr,
+// preferring global symbols over local aliases and, in relocatable files,
+// the lowest section. It returns nil if there is none.
+func (idx *lineIndex) function(addr uint64) *funcRange {
+	i := sort.Search(len(idx.funcs), func(i int) bool {
+		return idx.funcs[i].low > addr
+	})
+	var found *funcRange
+	for i--; i >= 0 && idx.maxEnd[i] > addr; i-- {
+		fn := &idx.funcs[i]
+		if addr >= fn.high {
+			continue
+		}
+		switch {
+		case found == nil,
+			fn.section < found.section,
+			fn.section == found.section && fn.global && !found.global:
+			found = fn
+		}
+	}
+	return found
+}
+
+// inline returns the innermost inlined function whose code contains addr,
+// or nil if there is none.
+func (idx *lineIndex) inline(addr uint64) *inlineRange {
+	i := sort.Search(len(idx.inlines), func(i int) bool {
+		return idx.inlines[i].low > addr
+	})
+	var found *inlineRange
+	for i--; i >= 0 && idx.maxInline[i] > addr; i-- {
+		in := &idx.inlines[i]
+		if addr < in.high && (found == nil || in.depth > found.depth) {
+			found = in
+		}
+	}
+	return found
+}
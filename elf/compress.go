@@ -0,0 +1,156 @@
+package elf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/elfviewer/elfviewer/elf/internal/zstd"
+)
+
+// Compression algorithms of an Elf_Chdr.
+const (
+	ELFCOMPRESS_ZLIB = 1
+	ELFCOMPRESS_ZSTD = 2
+)
+
+// Chdr32 and Chdr64 are the headers that start the data of a section with
+// SHF_COMPRESSED set.
+type Chdr32 struct {
+	Type      uint32
+	Size      uint32
+	AddrAlign uint32
+}
+
+type Chdr64 struct {
+	Type      uint32
+	Reserved  uint32
+	Size      uint64
+	AddrAlign uint64
+}
+
+// CompressionHeader describes how a section is compressed. Legacy .zdebug
+// sections are reported with Type ELFCOMPRESS_ZLIB.
+type CompressionHeader struct {
+	Type      uint32
+	Size      uint64
+	AddrAlign uint64
+
+	dataOff int
+}
+
+// CompressionTypeString names an Elf_Chdr ch_type.
+func CompressionTypeString(t uint32) string {
+	switch t {
+	case ELFCOMPRESS_ZLIB:
+		return "ZLIB"
+	case ELFCOMPRESS_ZSTD:
+		return "ZSTD"
+	default:
+		return fmt.Sprintf("Unknown (%#x)", t)
+	}
+}
+
+// CompressionHeader returns the compression header of sh, or nil if the
+// section is not compressed. Besides SHF_COMPRESSED sections this covers
+// the older GNU .zdebug sections, which start with "ZLIB" and a big-endian
+// 64-bit size.
+func (f *File) CompressionHeader(sh *SectionHeader) (*CompressionHeader, error) {
+	if sh.Type == SHT_NOBITS {
+		return nil, nil
+	}
+	data, err := f.GetSectionData(sh)
+	if err != nil {
+		return nil, err
+	}
+
+	if sh.Flags&SHF_COMPRESSED == 0 {
+		if !strings.HasPrefix(sh.Name, ".zdebug") || len(data) < 12 || string(data[:4]) != "ZLIB" {
+			return nil, nil
+		}
+		return &CompressionHeader{
+			Type:      ELFCOMPRESS_ZLIB,
+			Size:      binary.BigEndian.Uint64(data[4:]),
+			AddrAlign: sh.AddrAlign,
+			dataOff:   12,
+		}, nil
+	}
+
+	r := bytes.NewReader(data)
+	if f.Class == ELFCLASS32 {
+		var ch Chdr32
+		if err := binary.Read(r, f.ByteOrder, &ch); err != nil {
+			return nil, fmt.Errorf("section %s: truncated compression header", sh.Name)
+		}
+		return &CompressionHeader{
+			Type:      ch.Type,
+			Size:      uint64(ch.Size),
+			AddrAlign: uint64(ch.AddrAlign),
+			dataOff:   12,
+		}, nil
+	}
+	var ch Chdr64
+	if err := binary.Read(r, f.ByteOrder, &ch); err != nil {
+		return nil, fmt.Errorf("section %s: truncated compression header", sh.Name)
+	}
+	return &CompressionHeader{
+		Type:      ch.Type,
+		Size:      ch.Size,
+		AddrAlign: ch.AddrAlign,
+		dataOff:   24,
+	}, nil
+}
+
+// maxReserve is the most memory reserved for a decompressed section before
+// decompression shows how large it really is.
+const maxReserve = 16 << 20
+
+// DecompressedSectionData returns the contents of sh, decompressing them
+// if the section is compressed. Other sections are returned as
+// GetSectionData returns them.
+func (f *File) DecompressedSectionData(sh *SectionHeader) ([]byte, error) {
+	ch, err := f.CompressionHeader(sh)
+	if err != nil {
+		return nil, err
+	}
+	if ch == nil {
+		return f.GetSectionData(sh)
+	}
+	data, err := f.GetSectionData(sh)
+	if err != nil {
+		return nil, err
+	}
+	payload := data[ch.dataOff:]
+	// The uncompressed size comes from the file: it bounds the output but
+	// is not trusted for reserving memory up front.
+	reserve := min(ch.Size, maxReserve)
+
+	var out []byte
+	switch ch.Type {
+	case ELFCOMPRESS_ZLIB:
+		zr, err := zlib.NewReader(bytes.NewReader(payload))
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", sh.Name, err)
+		}
+		buf := bytes.NewBuffer(make([]byte, 0, reserve))
+		if _, err := io.Copy(buf, io.LimitReader(zr, int64(ch.Size)+1)); err != nil {
+			return nil, fmt.Errorf("section %s: %w", sh.Name, err)
+		}
+		out = buf.Bytes()
+	case ELFCOMPRESS_ZSTD:
+		out, err = zstd.Decompress(make([]byte, 0, reserve), payload, ch.Size)
+		if err != nil {
+			return nil, fmt.Errorf("section %s: %w", sh.Name, err)
+		}
+	default:
+		return nil, fmt.Errorf("section %s: unsupported compression type %s", sh.Name, CompressionTypeString(ch.Type))
+	}
+
+	if uint64(len(out)) != ch.Size {
+		return nil, fmt.Errorf("section %s: decompressed to %d bytes, expected %d", sh.Name, len(out), ch.Size)
+	}
+	return out, nil
+}
@@ -0,0 +1,519 @@
+package elf
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Auxiliary vector entry types.
+const (
+	AT_NULL              = 0
+	AT_IGNORE            = 1
+	AT_EXECFD            = 2
+	AT_PHDR              = 3
+	AT_PHENT             = 4
+	AT_PHNUM             = 5
+	AT_PAGESZ            = 6
+	AT_BASE              = 7
+	AT_FLAGS             = 8
+	AT_ENTRY             = 9
+	AT_NOTELF            = 10
+	AT_UID               = 11
+	AT_EUID              = 12
+	AT_GID               = 13
+	AT_EGID              = 14
+	AT_PLATFORM          = 15
+	AT_HWCAP             = 16
+	AT_CLKTCK            = 17
+	AT_SECURE            = 23
+	AT_BASE_PLATFORM     = 24
+	AT_RANDOM            = 25
+	AT_HWCAP2            = 26
+	AT_RSEQ_FEATURE_SIZE = 27
+	AT_RSEQ_ALIGN        = 28
+	AT_HWCAP3            = 29
+	AT_HWCAP4            = 30
+	AT_EXECFN            = 31
+	AT_SYSINFO           = 32
+	AT_SYSINFO_EHDR      = 33
+	AT_MINSIGSTKSZ       = 51
+)
+
+var auxvTypeNames = map[uint64]string{
+	AT_NULL:              "AT_NULL",
+	AT_IGNORE:            "AT_IGNORE",
+	AT_EXECFD:            "AT_EXECFD",
+	AT_PHDR:              "AT_PHDR",
+	AT_PHENT:             "AT_PHENT",
+	AT_PHNUM:             "AT_PHNUM",
+	AT_PAGESZ:            "AT_PAGESZ",
+	AT_BASE:              "AT_BASE",
+	AT_FLAGS:             "AT_FLAGS",
+	AT_ENTRY:             "AT_ENTRY",
+	AT_NOTELF:            "AT_NOTELF",
+	AT_UID:               "AT_UID",
+	AT_EUID:              "AT_EUID",
+	AT_GID:               "AT_GID",
+	AT_EGID:              "AT_EGID",
+	AT_PLATFORM:          "AT_PLATFORM",
+	AT_HWCAP:             "AT_HWCAP",
+	AT_CLKTCK:            "AT_CLKTCK",
+	AT_SECURE:            "AT_SECURE",
+	AT_BASE_PLATFORM:     "AT_BASE_PLATFORM",
+	AT_RANDOM:            "AT_RANDOM",
+	AT_HWCAP2:            "AT_HWCAP2",
+	AT_RSEQ_FEATURE_SIZE: "AT_RSEQ_FEATURE_SIZE",
+	AT_RSEQ_ALIGN:        "AT_RSEQ_ALIGN",
+	AT_HWCAP3:            "AT_HWCAP3",
+	AT_HWCAP4:            "AT_HWCAP4",
+	AT_EXECFN:            "AT_EXECFN",
+	AT_SYSINFO:           "AT_SYSINFO",
+	AT_SYSINFO_EHDR:      "AT_SYSINFO_EHDR",
+	AT_MINSIGSTKSZ:       "AT_MINSIGSTKSZ",
+}
+
+func AuxvTypeString(t uint64) string {
+	if name, ok := auxvTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("AT_<%#x>", t)
+}
+
+// Linux signal numbers, which are the same on x86 and AArch64.
+var signalNames = [...]string{
+	1: "SIGHUP", 2: "SIGINT", 3: "SIGQUIT", 4: "SIGILL", 5: "SIGTRAP", 6: "SIGABRT",
+	7: "SIGBUS", 8: "SIGFPE", 9: "SIGKILL", 10: "SIGUSR1", 11: "SIGSEGV", 12: "SIGUSR2",
+	13: "SIGPIPE", 14: "SIGALRM", 15: "SIGTERM", 16: "SIGSTKFLT", 17: "SIGCHLD", 18: "SIGCONT",
+	19: "SIGSTOP", 20: "SIGTSTP", 21: "SIGTTIN", 22: "SIGTTOU", 23: "SIGURG", 24: "SIGXCPU",
+	25: "SIGXFSZ", 26: "SIGVTALRM", 27: "SIGPROF", 28: "SIGWINCH", 29: "SIGIO", 30: "SIGPWR",
+	31: "SIGSYS",
+}
+
+func SignalString(signo int) string {
+	if signo > 0 && signo < len(signalNames) {
+		return signalNames[signo]
+	}
+	if signo >= 34 && signo <= 64 {
+		return fmt.Sprintf("SIGRTMIN+%d", signo-34)
+	}
+	return fmt.Sprintf("signal %d", signo)
+}
+
+// Signals whose siginfo carries the faulting address.
+const (
+	sigILL  = 4
+	sigTRAP = 5
+	sigBUS  = 7
+	sigFPE  = 8
+	sigSEGV = 11
+)
+
+var signalCodeNames = map[int]map[int32]string{
+	sigILL: {1: "ILL_ILLOPC", 2: "ILL_ILLOPN", 3: "ILL_ILLADR", 4: "ILL_ILLTRP",
+		5: "ILL_PRVOPC", 6: "ILL_PRVREG", 7: "ILL_COPROC", 8: "ILL_BADSTK"},
+	sigTRAP: {1: "TRAP_BRKPT", 2: "TRAP_TRACE", 3: "TRAP_BRANCH", 4: "TRAP_HWBKPT"},
+	sigBUS:  {1: "BUS_ADRALN", 2: "BUS_ADRERR", 3: "BUS_OBJERR", 4: "BUS_MCEERR_AR", 5: "BUS_MCEERR_AO"},
+	sigFPE: {1: "FPE_INTDIV", 2: "FPE_INTOVF", 3: "FPE_FLTDIV", 4: "FPE_FLTOVF",
+		5: "FPE_FLTUND", 6: "FPE_FLTRES", 7: "FPE_FLTINV", 8: "FPE_FLTSUB"},
+	sigSEGV: {1: "SEGV_MAPERR", 2: "SEGV_ACCERR", 3: "SEGV_BNDERR", 4: "SEGV_PKUERR",
+		5: "SEGV_ACCADI", 6: "SEGV_ADIDERR", 7: "SEGV_ADIPERR", 8: "SEGV_MTEAERR",
+		9: "SEGV_MTESERR", 10: "SEGV_CPERR"},
+}
+
+// SignalCodeString names the si_code of a signal: why the kernel sent it,
+// or who sent it for codes of zero and below.
+func SignalCodeString(signo int, code int32) string {
+	switch code {
+	case 0:
+		return "SI_USER"
+	case 0x80:
+		return "SI_KERNEL"
+	case -1:
+		return "SI_QUEUE"
+	case -2:
+		return "SI_TIMER"
+	case -3:
+		return "SI_MESGQ"
+	case -4:
+		return "SI_ASYNCIO"
+	case -5:
+		return "SI_SIGIO"
+	case -6:
+		return "SI_TKILL"
+	}
+	if name, ok := signalCodeNames[signo][code]; ok {
+		return name
+	}
+	return fmt.Sprintf("%d", code)
+}
+
+// Registers of the elf_gregset_t in NT_PRSTATUS notes, in the order the
+// kernel stores them.
+var (
+	x86_64Registers = []string{
+		"r15", "r14", "r13", "r12", "rbp", "rbx", "r11", "r10", "r9", "r8", "rax", "rcx", "rdx",
+		"rsi", "rdi", "orig_rax", "rip", "cs", "eflags", "rsp", "ss", "fs_base", "gs_base",
+		"ds", "es", "fs", "gs",
+	}
+	aarch64Registers = []string{
+		"x0", "x1", "x2", "x3", "x4", "x5", "x6", "x7", "x8", "x9", "x10", "x11", "x12", "x13",
+		"x14", "x15", "x16", "x17", "x18", "x19", "x20", "x21", "x22", "x23", "x24", "x25",
+		"x26", "x27", "x28", "x29", "x30", "sp", "pc", "pstate",
+	}
+)
+
+// CoreDump is the state of a crashed process recorded in the notes of a
+// core file.
+type CoreDump struct {
+	// Process comes from NT_PRPSINFO and Signal from NT_SIGINFO; either
+	// is nil when the note is missing.
+	Process *CoreProcess
+	Signal  *CoreSignal
+
+	// Threads has an entry per NT_PRSTATUS note. The kernel writes the
+	// thread that received the signal first.
+	Threads []CoreThread
+
+	Auxv []AuxvEntry
+
+	// Files are the file mappings of NT_FILE, which does not cover
+	// anonymous memory.
+	Files    []MappedFile
+	PageSize uint64
+}
+
+type CoreProcess struct {
+	PID   uint32
+	PPID  uint32
+	PGRP  uint32
+	SID   uint32
+	UID   uint32
+	GID   uint32
+	State byte // as in ps: R, S, D, T, Z...
+	Name  string
+	Args  string // the command line, truncated to 80 bytes
+}
+
+type CoreSignal struct {
+	Signo int
+	Errno int32
+	Code  int32
+
+	// Addr is the faulting address of SIGSEGV, SIGBUS, SIGILL, SIGFPE and
+	// SIGTRAP.
+	Addr    uint64
+	HasAddr bool
+
+	// SenderPID and SenderUID identify the process that sent the signal
+	// with kill or a similar call.
+	SenderPID uint32
+	SenderUID uint32
+	HasSender bool
+}
+
+type CoreThread struct {
+	PID       uint32
+	Signal    int
+	Registers []Register // only for x86-64 and AArch64
+}
+
+type Register struct {
+	Name  string
+	Value uint64
+}
+
+type AuxvEntry struct {
+	Type  uint64
+	Value uint64
+}
+
+type MappedFile struct {
+	Start  uint64
+	End    uint64
+	Offset uint64 // in bytes
+	Path   string
+}
+
+// CoreDump decodes the process state from the notes of a core file.
+func (f *File) CoreDump() (*CoreDump, error) {
+	if f.Type != ET_CORE {
+		return nil, fmt.Errorf("not a core file")
+	}
+	c := &CoreDump{}
+	for i := range f.Notes {
+		n := &f.Notes[i]
+		if n.Name != "CORE" {
+			continue
+		}
+		switch n.Type {
+		case NT_PRSTATUS:
+			if t, ok := f.prstatus(n.Desc); ok {
+				c.Threads = append(c.Threads, t)
+			}
+		case NT_PRPSINFO:
+			c.Process = f.prpsinfo(n.Desc)
+		case NT_SIGINFO:
+			c.Signal = f.siginfo(n.Desc)
+		case NT_AUXV:
+			c.Auxv = f.auxv(n.Desc)
+		case NT_FILE:
+			c.Files, c.PageSize = f.mappedFiles(n.Desc)
+		}
+	}
+	return c, nil
+}
+
+// word reads a C long, which is the size of an address.
+func (f *File) word(b []byte) uint64 {
+	if f.Class == ELFCLASS32 {
+		return uint64(f.ByteOrder.Uint32(b))
+	}
+	return f.ByteOrder.Uint64(b)
+}
+
+func (f *File) wordSize() int {
+	if f.Class == ELFCLASS32 {
+		return 4
+	}
+	return 8
+}
+
+// prstatus decodes a struct elf_prstatus: the siginfo header, the current
+// signal, the pending and held signal masks, four process IDs, four CPU
+// times and then the general-purpose registers.
+func (f *File) prstatus(desc []byte) (CoreThread, bool) {
+	ws := f.wordSize()
+	pid := 12 + 4 + 2*ws
+	regs := pid + 16 + 4*2*ws
+	if len(desc) < regs {
+		return CoreThread{}, false
+	}
+	t := CoreThread{
+		PID:    f.ByteOrder.Uint32(desc[pid:]),
+		Signal: int(f.ByteOrder.Uint16(desc[12:])),
+	}
+	var names []string
+	switch f.Machine {
+	case EM_X86_64:
+		names = x86_64Registers
+	case EM_AARCH64:
+		names = aarch64Registers
+	}
+	if f.Class == ELFCLASS64 && len(desc) >= regs+8*len(names) {
+		for i, name := range names {
+			t.Registers = append(t.Registers, Register{name, f.ByteOrder.Uint64(desc[regs+8*i:])})
+		}
+	}
+	return t, true
+}
+
+// prpsinfo decodes a struct elf_prpsinfo. Its user and group IDs are
+// 16 bits wide on i386 and 32-bit Arm, which makes it 124 bytes instead
+// of 128 there.
+func (f *File) prpsinfo(desc []byte) *CoreProcess {
+	ws := f.wordSize()
+	ids := 4 + ws
+	if f.Class == ELFCLASS64 {
+		ids = 16
+	}
+	p := &CoreProcess{}
+	idSize := 4
+	if f.Class == ELFCLASS32 && len(desc) == 124 {
+		idSize = 2
+	}
+	pid := ids + 2*idSize
+	if len(desc) < pid+16+16+80 {
+		return nil
+	}
+	p.State = desc[1]
+	if idSize == 2 {
+		p.UID = uint32(f.ByteOrder.Uint16(desc[ids:]))
+		p.GID = uint32(f.ByteOrder.Uint16(desc[ids+2:]))
+	} else {
+		p.UID = f.ByteOrder.Uint32(desc[ids:])
+		p.GID = f.ByteOrder.Uint32(desc[ids+4:])
+	}
+	p.PID = f.ByteOrder.Uint32(desc[pid:])
+	p.PPID = f.ByteOrder.Uint32(desc[pid+4:])
+	p.PGRP = f.ByteOrder.Uint32(desc[pid+8:])
+	p.SID = f.ByteOrder.Uint32(desc[pid+12:])
+	p.Name = cString(desc[pid+16 : pid+32])
+	p.Args = strings.TrimRight(cString(desc[pid+32:pid+112]), " ")
+	return p
+}
+
+func cString(b []byte) string {
+	if i := strings.IndexByte(string(b), 0); i >= 0 {
+		b = b[:i]
+	}
+	return string(b)
+}
+
+// siginfo decodes the start of a siginfo_t: signal number, errno and
+// code, followed by a union aligned to a word.
+func (f *File) siginfo(desc []byte) *CoreSignal {
+	union := 3 * 4
+	if f.Class == ELFCLASS64 {
+		union = 16
+	}
+	if len(desc) < union+f.wordSize() {
+		return nil
+	}
+	s := &CoreSignal{
+		Signo: int(int32(f.ByteOrder.Uint32(desc))),
+		Errno: int32(f.ByteOrder.Uint32(desc[4:])),
+		Code:  int32(f.ByteOrder.Uint32(desc[8:])),
+	}
+	switch {
+	case s.Code <= 0:
+		s.SenderPID = f.ByteOrder.Uint32(desc[union:])
+		s.SenderUID = f.ByteOrder.Uint32(desc[union+4:])
+		s.HasSender = true
+	case s.Signo == sigSEGV || s.Signo == sigBUS || s.Signo == sigILL || s.Signo == sigFPE || s.Signo == sigTRAP:
+		s.Addr = f.word(desc[union:])
+		s.HasAddr = true
+	}
+	return s
+}
+
+func (f *File) auxv(desc []byte) []AuxvEntry {
+	ws := f.wordSize()
+	var entries []AuxvEntry
+	for off := 0; off+2*ws <= len(desc); off += 2 * ws {
+		e := AuxvEntry{Type: f.word(desc[off:]), Value: f.word(desc[off+ws:])}
+		if e.Type == AT_NULL {
+			break
+		}
+		entries = append(entries, e)
+	}
+	return entries
+}
+
+// mappedFiles decodes an NT_FILE note: the number of mappings and the
+// page size, a start, end and page offset for each mapping, and then
+// their paths as consecutive C strings.
+func (f *File) mappedFiles(desc []byte) ([]MappedFile, uint64) {
+	ws := f.wordSize()
+	if len(desc) < 2*ws {
+		return nil, 0
+	}
+	count := f.word(desc)
+	pageSize := f.word(desc[ws:])
+	names := 2*ws + 3*ws*int(min(count, uint64(len(desc))))
+	if names > len(desc) {
+		return nil, pageSize
+	}
+	paths := strings.Split(string(desc[names:]), "\x00")
+	files := make([]MappedFile, 0, count)
+	for i := 0; i < int(count); i++ {
+		off := 2*ws + 3*ws*i
+		m := MappedFile{
+			Start:  f.word(desc[off:]),
+			End:    f.word(desc[off+ws:]),
+			Offset: f.word(desc[off+2*ws:]) * pageSize,
+		}
+		if i < len(paths) {
+			m.Path = paths[i]
+		}
+		files = append(files, m)
+	}
+	return files, pageSize
+}
+
+func (f *File) coreNoteDescription(n *Note) string {
+	switch n.Type {
+	case NT_PRSTATUS:
+		if t, ok := f.prstatus(n.Desc); ok {
+			return fmt.Sprintf("PID: %d, signal: %s", t.PID, SignalString(t.Signal))
+		}
+	case NT_PRPSINFO:
+		if p := f.prpsinfo(n.Desc); p != nil {
+			return fmt.Sprintf("PID: %d, name: %s, args: %s", p.PID, p.Name, p.Args)
+		}
+	case NT_SIGINFO:
+		if s := f.siginfo(n.Desc); s != nil {
+			return "Signal: " + s.String()
+		}
+	case NT_FILE:
+		files, pageSize := f.mappedFiles(n.Desc)
+		lines := []string{fmt.Sprintf("Page size: %d", pageSize)}
+		for _, m := range files {
+			lines = append(lines, fmt.Sprintf("%#x-%#x at %#x: %s", m.Start, m.End, m.Offset, m.Path))
+		}
+		return strings.Join(lines, "\n\t")
+	}
+	return ""
+}
+
+// DisplayCore prints the process, signal, threads, auxiliary vector and
+// mapped files of a core file.
+func (f *File) DisplayCore(w io.Writer) {
+	c, err := f.CoreDump()
+	if err != nil {
+		fmt.Fprintf(w, "\nThis is not a core file.\n")
+		return
+	}
+
+	if p := c.Process; p != nil {
+		fmt.Fprintf(w, "\nCore dump of process %d (%s):\n", p.PID, p.Name)
+		fmt.Fprintf(w, "  Command line: %s\n", p.Args)
+		fmt.Fprintf(w, "  State: %c, PPID: %d, PGRP: %d, SID: %d, UID: %d, GID: %d\n",
+			p.State, p.PPID, p.PGRP, p.SID, p.UID, p.GID)
+	} else {
+		fmt.Fprintf(w, "\nCore dump:\n")
+	}
+	if s := c.Signal; s != nil {
+		fmt.Fprintf(w, "  Signal: %s\n", s)
+	} else if len(c.Threads) > 0 && c.Threads[0].Signal != 0 {
+		fmt.Fprintf(w, "  Signal: %s\n", SignalString(c.Threads[0].Signal))
+	}
+
+	fmt.Fprintf(w, "\nThreads (%d):\n", len(c.Threads))
+	for i, t := range c.Threads {
+		fmt.Fprintf(w, "  Thread %d", t.PID)
+		if t.Signal != 0 {
+			fmt.Fprintf(w, " (%s)", SignalString(t.Signal))
+		}
+		fmt.Fprintln(w)
+		for j := 0; j < len(t.Registers); j += 3 {
+			fmt.Fprintf(w, "  ")
+			for _, r := range t.Registers[j:min(j+3, len(t.Registers))] {
+				fmt.Fprintf(w, "  %-8s 0x%016x", r.Name, r.Value)
+			}
+			fmt.Fprintln(w)
+		}
+		if i < len(c.Threads)-1 && len(t.Registers) > 0 {
+			fmt.Fprintln(w)
+		}
+	}
+
+	if len(c.Auxv) > 0 {
+		fmt.Fprintf(w, "\nAuxiliary vector:\n")
+		for _, e := range c.Auxv {
+			fmt.Fprintf(w, "  %-20s %#x\n", AuxvTypeString(e.Type), e.Value)
+		}
+	}
+
+	if len(c.Files) > 0 {
+		fmt.Fprintf(w, "\nMapped files (page size %d):\n", c.PageSize)
+		fmt.Fprintf(w, "  %-18s %-18s %-10s %s\n", "Start", "End", "Offset", "Path")
+		for _, m := range c.Files {
+			fmt.Fprintf(w, "  0x%016x 0x%016x 0x%08x %s\n", m.Start, m.End, m.Offset, m.Path)
+		}
+	}
+}
+
+func (s *CoreSignal) String() string {
+	str := fmt.Sprintf("%s (%d), %s", SignalString(s.Signo), s.Signo, SignalCodeString(s.Signo, s.Code))
+	switch {
+	case s.HasAddr:
+		str += fmt.Sprintf(", fault address %#x", s.Addr)
+	case s.HasSender:
+		str += fmt.Sprintf(", sent by PID %d, UID %d", s.SenderPID, s.SenderUID)
+	}
+	if s.Errno != 0 {
+		str += fmt.Sprintf(", errno %d", s.Errno)
+	}
+	return str
+}
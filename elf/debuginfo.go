@@ -0,0 +1,940 @@
+package elf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"path"
+	"strings"
+)
+
+// DWARF expression operations used in the locations of global variables
+// and members.
+const (
+	DW_OP_addr                 = 0x03
+	DW_OP_const1u              = 0x08
+	DW_OP_const2u              = 0x0a
+	DW_OP_const4u              = 0x0c
+	DW_OP_const8u              = 0x0e
+	DW_OP_constu               = 0x10
+	DW_OP_plus_uconst          = 0x23
+	DW_OP_form_tls_address     = 0x9b
+	DW_OP_addrx                = 0xa1
+	DW_OP_GNU_push_tls_address = 0xe0
+	DW_OP_GNU_addr_index       = 0xfb
+)
+
+// maxTypeDepth bounds the type chains followed when naming and sizing
+// types, which protects against cycles in malformed debugging information.
+const maxTypeDepth = 64
+
+// Function is a function with code in the file, as described by its
+// DW_TAG_subprogram DIE.
+type Function struct {
+	Name        string
+	LinkageName string
+	Low, High   uint64
+	External    bool
+	ReturnType  string
+	Params      []Parameter
+	Variadic    bool
+	DeclFile    string
+	DeclLine    int
+	Entry       *DIE
+}
+
+// Parameter is a formal parameter of a function.
+type Parameter struct {
+	Name string
+	Type string
+}
+
+// Prototype returns the declaration of the function in C syntax.
+func (fn *Function) Prototype() string {
+	var params []string
+	for _, p := range fn.Params {
+		if p.Name == "" {
+			params = append(params, p.Type)
+		} else {
+			params = append(params, declString(p.Type, p.Name))
+		}
+	}
+	if fn.Variadic {
+		params = append(params, "...")
+	}
+	if len(params) == 0 {
+		params = []string{"void"}
+	}
+	return declString(fn.ReturnType, fn.Name+"("+strings.Join(params, ", ")+")")
+}
+
+// Variable is a variable with static storage declared at file or
+// namespace scope.
+type Variable struct {
+	Name        string
+	LinkageName string
+	Type        string
+	Size        uint64
+	Address     uint64
+	HasAddress  bool
+	TLS         bool
+	External    bool
+	DeclFile    string
+	DeclLine    int
+	Entry       *DIE
+}
+
+// StructLayout is the memory layout of a structure, class or union.
+// Holes and padding are measured in bits, since bit-fields need not end
+// on a byte boundary. Typedef is set when the type itself is anonymous and
+// Name is that of a typedef for it.
+type StructLayout struct {
+	Kind        string
+	Name        string
+	Typedef     bool
+	Size        uint64
+	Members     []StructMember
+	PaddingBits uint64
+	DeclFile    string
+	DeclLine    int
+	Entry       *DIE
+}
+
+// StructMember is a data member of a StructLayout, or one of its base
+// classes, which have no name. BitSize is zero for members that are not
+// bit-fields. HoleBits is the unused space between the previous member
+// and this one.
+type StructMember struct {
+	Name      string
+	Type      string
+	Offset    uint64
+	Size      uint64
+	BitOffset uint64
+	BitSize   uint64
+	HoleBits  uint64
+}
+
+// Holes returns the number of holes in the layout and their total size in
+// bits.
+func (l *StructLayout) Holes() (int, uint64) {
+	n, bits := 0, uint64(0)
+	for _, m := range l.Members {
+		if m.HoleBits > 0 {
+			n++
+			bits += m.HoleBits
+		}
+	}
+	return n, bits
+}
+
+// declString places name in a C type, so that function and array types
+// read like declarations.
+func declString(typ, name string) string {
+	for _, ptr := range []string{"*)(", "&)("} {
+		if i := strings.Index(typ, ptr); i >= 0 {
+			return typ[:i+1] + name + typ[i+1:]
+		}
+	}
+	switch {
+	case strings.HasSuffix(typ, "]"):
+		i := strings.Index(typ, "[")
+		return declString(typ[:i], name) + typ[i:]
+	case strings.HasSuffix(typ, "*"), strings.HasSuffix(typ, "&"):
+		return typ + name
+	}
+	return typ + " " + name
+}
+
+// origin returns the DIE that d completes: the declaration named by
+// DW_AT_specification or the abstract instance named by
+// DW_AT_abstract_origin, or nil.
+func (di *DebugInfo) origin(d *DIE) *DIE {
+	if o := di.Ref(d, DW_AT_abstract_origin); o != nil {
+		return o
+	}
+	return di.Ref(d, DW_AT_specification)
+}
+
+// attr returns attribute at of d, looking through the DIEs it completes.
+func (di *DebugInfo) attr(d *DIE, at uint64) *DIEAttr {
+	for i := 0; d != nil && i < maxTypeDepth; i++ {
+		if a := d.Attr(at); a != nil {
+			return a
+		}
+		d = di.origin(d)
+	}
+	return nil
+}
+
+// ref returns the DIE that attribute at of d refers to, looking through
+// the DIEs it completes.
+func (di *DebugInfo) ref(d *DIE, at uint64) *DIE {
+	for i := 0; d != nil && i < maxTypeDepth; i++ {
+		if d.Attr(at) != nil {
+			return di.Ref(d, at)
+		}
+		d = di.origin(d)
+	}
+	return nil
+}
+
+func (di *DebugInfo) flag(d *DIE, at uint64) bool {
+	a := di.attr(d, at)
+	return a != nil && a.Val != 0
+}
+
+func (di *DebugInfo) name(d *DIE) string {
+	if a := di.attr(d, DW_AT_name); a != nil {
+		return a.Str
+	}
+	return ""
+}
+
+// QualifiedName returns the name of d prefixed with the namespaces and
+// classes that enclose it, such as "std::vector". Members defined outside
+// their class are qualified by the scope of their declaration.
+func (di *DebugInfo) QualifiedName(d *DIE) string {
+	name := di.name(d)
+	if name == "" {
+		return ""
+	}
+	scope := d
+	for i := 0; i < maxTypeDepth; i++ {
+		o := di.origin(scope)
+		if o == nil {
+			break
+		}
+		scope = o
+	}
+	for p := scope.Parent; p != nil; p = p.Parent {
+		switch p.Tag {
+		case DW_TAG_namespace:
+			if n := p.Name(); n != "" {
+				name = n + "::" + name
+			} else {
+				name = "(anonymous namespace)::" + name
+			}
+		case DW_TAG_structure_type, DW_TAG_class_type, DW_TAG_union_type:
+			if n := p.Name(); n != "" {
+				name = n + "::" + name
+			}
+		}
+	}
+	return name
+}
+
+func (di *DebugInfo) linkageName(d *DIE) string {
+	if a := di.attr(d, DW_AT_linkage_name); a != nil {
+		return a.Str
+	}
+	if a := di.attr(d, DW_AT_MIPS_linkage_name); a != nil {
+		return a.Str
+	}
+	return ""
+}
+
+// DeclFile returns the file and line d is declared at.
+func (di *DebugInfo) DeclFile(d *DIE) (string, int) {
+	file, line := "", 0
+	if a := di.attr(d, DW_AT_decl_file); a != nil && d.Unit.Lines != nil {
+		file = d.Unit.Lines.FileName(int(a.Val))
+	}
+	if a := di.attr(d, DW_AT_decl_line); a != nil {
+		line = int(a.Val)
+	}
+	return file, line
+}
+
+// TypeName returns the C spelling of the type d, such as "const char *".
+// A nil type is void.
+func (di *DebugInfo) TypeName(d *DIE) string {
+	return di.typeName(d, 0)
+}
+
+func (di *DebugInfo) typeName(d *DIE, depth int) string {
+	if d == nil {
+		return "void"
+	}
+	if depth > maxTypeDepth {
+		return "..."
+	}
+	target := di.Ref(d, DW_AT_type)
+	switch d.Tag {
+	case DW_TAG_structure_type, DW_TAG_class_type, DW_TAG_union_type, DW_TAG_enumeration_type:
+		kind := map[uint64]string{
+			DW_TAG_structure_type:   "struct",
+			DW_TAG_class_type:       "class",
+			DW_TAG_union_type:       "union",
+			DW_TAG_enumeration_type: "enum",
+		}[d.Tag]
+		if name := di.QualifiedName(d); name != "" {
+			return kind + " " + name
+		}
+		return kind + " {...}"
+	case DW_TAG_pointer_type, DW_TAG_reference_type, DW_TAG_rvalue_reference_type:
+		sigil := map[uint64]string{
+			DW_TAG_pointer_type:          "*",
+			DW_TAG_reference_type:        "&",
+			DW_TAG_rvalue_reference_type: "&&",
+		}[d.Tag]
+		if target != nil && target.Tag == DW_TAG_subroutine_type {
+			return di.functionType(target, "("+sigil+")", depth)
+		}
+		inner := di.typeName(target, depth+1)
+		// A pointer to a function pointer goes inside the parentheses.
+		if i := strings.Index(inner, "*)("); i >= 0 {
+			return inner[:i+1] + sigil + inner[i+1:]
+		}
+		if strings.HasSuffix(inner, "*") {
+			return inner + sigil
+		}
+		return inner + " " + sigil
+	case DW_TAG_const_type, DW_TAG_volatile_type, DW_TAG_restrict_type, DW_TAG_atomic_type:
+		qual := map[uint64]string{
+			DW_TAG_const_type:    "const",
+			DW_TAG_volatile_type: "volatile",
+			DW_TAG_restrict_type: "restrict",
+			DW_TAG_atomic_type:   "_Atomic",
+		}[d.Tag]
+		inner := di.typeName(target, depth+1)
+		// Qualifiers of pointers follow the asterisk.
+		if strings.HasSuffix(inner, "*") || d.Tag == DW_TAG_restrict_type {
+			return inner + " " + qual
+		}
+		return qual + " " + inner
+	case DW_TAG_array_type:
+		dims := ""
+		for _, c := range d.Children {
+			if c.Tag != DW_TAG_subrange_type && c.Tag != DW_TAG_generic_subrange {
+				continue
+			}
+			if n, ok := subrangeCount(c); ok {
+				dims += fmt.Sprintf("[%d]", n)
+			} else {
+				dims += "[]"
+			}
+		}
+		if dims == "" {
+			dims = "[]"
+		}
+		return di.typeName(target, depth+1) + dims
+	case DW_TAG_subroutine_type:
+		return di.functionType(d, "", depth)
+	case DW_TAG_ptr_to_member_type:
+		class := di.QualifiedName(di.Ref(d, DW_AT_containing_type))
+		if target != nil && target.Tag == DW_TAG_subroutine_type {
+			return di.functionType(target, "("+class+"::*)", depth)
+		}
+		return di.typeName(target, depth+1) + " " + class + "::*"
+	}
+	if name := di.QualifiedName(d); name != "" {
+		return name
+	}
+	return "<anonymous>"
+}
+
+// functionType spells a subroutine type, with ptr placed where the name
+// of a declared function would go.
+func (di *DebugInfo) functionType(d *DIE, ptr string, depth int) string {
+	var params []string
+	for _, c := range d.Children {
+		switch c.Tag {
+		case DW_TAG_formal_parameter:
+			params = append(params, di.typeName(di.Ref(c, DW_AT_type), depth+1))
+		case DW_TAG_unspecified_parameters:
+			params = append(params, "...")
+		}
+	}
+	if len(params) == 0 && d.Flag(DW_AT_prototyped) {
+		params = []string{"void"}
+	}
+	ret := di.typeName(di.Ref(d, DW_AT_type), depth+1)
+	return ret + " " + ptr + "(" + strings.Join(params, ", ") + ")"
+}
+
+// subrangeCount returns the number of elements of an array dimension.
+func subrangeCount(d *DIE) (uint64, bool) {
+	if a := d.Attr(DW_AT_count); a != nil && a.IsConstant() {
+		return a.Val, true
+	}
+	a := d.Attr(DW_AT_upper_bound)
+	if a == nil || !a.IsConstant() {
+		return 0, false
+	}
+	lower, _ := d.Val(DW_AT_lower_bound)
+	// An upper bound of -1 is how flexible array members are described.
+	if int64(a.Val) < int64(lower) {
+		return 0, true
+	}
+	return a.Val - lower + 1, true
+}
+
+// TypeSize returns the size in bytes of the type d, if it is known.
+func (di *DebugInfo) TypeSize(d *DIE) (uint64, bool) {
+	return di.typeSize(d, 0)
+}
+
+func (di *DebugInfo) typeSize(d *DIE, depth int) (uint64, bool) {
+	for ; d != nil && depth < maxTypeDepth; depth++ {
+		if a := d.Attr(DW_AT_byte_size); a != nil && a.IsConstant() {
+			return a.Val, true
+		}
+		switch d.Tag {
+		case DW_TAG_pointer_type, DW_TAG_reference_type, DW_TAG_rvalue_reference_type:
+			return uint64(d.Unit.AddrSize), true
+		case DW_TAG_ptr_to_member_type:
+			if t := di.Ref(d, DW_AT_type); t != nil && t.Tag == DW_TAG_subroutine_type {
+				return 2 * uint64(d.Unit.AddrSize), true
+			}
+			return uint64(d.Unit.AddrSize), true
+		case DW_TAG_array_type:
+			elem, ok := di.typeSize(di.Ref(d, DW_AT_type), depth+1)
+			if !ok {
+				return 0, false
+			}
+			n := uint64(1)
+			for _, c := range d.Children {
+				if c.Tag != DW_TAG_subrange_type {
+					continue
+				}
+				count, ok := subrangeCount(c)
+				if !ok {
+					return 0, false
+				}
+				n *= count
+			}
+			return elem * n, true
+		case DW_TAG_typedef, DW_TAG_const_type, DW_TAG_volatile_type, DW_TAG_restrict_type,
+			DW_TAG_atomic_type, DW_TAG_enumeration_type:
+			d = di.Ref(d, DW_AT_type)
+			continue
+		}
+		return 0, false
+	}
+	return 0, false
+}
+
+// memberLocation decodes a DW_AT_data_member_location, which is either a
+// constant or, before DWARF 4, an expression adding a constant to the
+// address of the structure.
+func (di *DebugInfo) memberLocation(d *DIE) (uint64, bool) {
+	a := d.Attr(DW_AT_data_member_location)
+	if a == nil {
+		return 0, false
+	}
+	if a.Block == nil {
+		return a.Val, true
+	}
+	b := &dwarfBuf{data: a.Block}
+	switch b.u8() {
+	case DW_OP_plus_uconst, DW_OP_constu:
+		v := b.uleb()
+		return v, b.err == nil
+	}
+	return 0, false
+}
+
+// StructLayout returns the layout of the structure, class or union d.
+func (di *DebugInfo) StructLayout(d *DIE) *StructLayout {
+	kind := map[uint64]string{
+		DW_TAG_structure_type: "struct",
+		DW_TAG_class_type:     "class",
+		DW_TAG_union_type:     "union",
+	}[d.Tag]
+	l := &StructLayout{Kind: kind, Name: di.QualifiedName(d), Entry: d}
+	l.Size, _ = d.Val(DW_AT_byte_size)
+	l.DeclFile, l.DeclLine = di.DeclFile(d)
+
+	var end uint64 // in bits
+	for _, c := range d.Children {
+		if c.Tag != DW_TAG_member && c.Tag != DW_TAG_inheritance {
+			continue
+		}
+		// Static data members are declarations without a location.
+		if c.Flag(DW_AT_declaration) || c.Flag(DW_AT_external) {
+			continue
+		}
+		t := di.Ref(c, DW_AT_type)
+		m := StructMember{Name: c.Name(), Type: di.TypeName(t)}
+		m.Size, _ = di.TypeSize(t)
+		byteOff, _ := di.memberLocation(c)
+		m.BitSize, _ = c.Val(DW_AT_bit_size)
+
+		start := byteOff * 8
+		switch {
+		case c.Attr(DW_AT_data_bit_offset) != nil:
+			start, _ = c.Val(DW_AT_data_bit_offset)
+		case m.BitSize > 0 && c.Attr(DW_AT_bit_offset) != nil:
+			// DWARF 2 and 3 count the bit offset from the most
+			// significant bit of the storage unit.
+			bitOff, _ := c.Val(DW_AT_bit_offset)
+			storage, ok := c.Val(DW_AT_byte_size)
+			if !ok {
+				storage = m.Size
+			}
+			if di.order == binary.BigEndian {
+				start = byteOff*8 + bitOff
+			} else {
+				start = byteOff*8 + storage*8 - bitOff - m.BitSize
+			}
+		}
+		m.Offset, m.BitOffset = start/8, start%8
+
+		width := m.Size * 8
+		if m.BitSize > 0 {
+			width = m.BitSize
+		}
+		if d.Tag != DW_TAG_union_type {
+			if start > end {
+				m.HoleBits = start - end
+			}
+			end = max(end, start+width)
+		} else {
+			end = max(end, width)
+		}
+		l.Members = append(l.Members, m)
+	}
+	if l.Size*8 > end {
+		l.PaddingBits = l.Size*8 - end
+	}
+	return l
+}
+
+// Functions returns the functions of unit u that have code in the file.
+func (di *DebugInfo) Functions(u *CompileUnit) []Function {
+	var fns []Function
+	walkDIEs(u.Root, func(d *DIE) bool {
+		if d.Tag != DW_TAG_subprogram {
+			// Functions nest in namespaces and classes, not in other
+			// functions, except as inlined instances.
+			return d.Tag != DW_TAG_lexical_block && d.Tag != DW_TAG_inlined_subroutine
+		}
+		low, high, ok := di.PCRange(d)
+		if !ok {
+			return false
+		}
+		fn := Function{
+			Name:        di.QualifiedName(d),
+			LinkageName: di.linkageName(d),
+			Low:         low,
+			High:        high,
+			External:    di.flag(d, DW_AT_external),
+			ReturnType:  di.TypeName(di.ref(d, DW_AT_type)),
+			Entry:       d,
+		}
+		fn.DeclFile, fn.DeclLine = di.DeclFile(d)
+		for _, c := range d.Children {
+			switch c.Tag {
+			case DW_TAG_formal_parameter:
+				fn.Params = append(fn.Params, Parameter{
+					Name: di.name(c),
+					Type: di.TypeName(di.ref(c, DW_AT_type)),
+				})
+			case DW_TAG_unspecified_parameters:
+				fn.Variadic = true
+			}
+		}
+		fns = append(fns, fn)
+		return false
+	})
+	return fns
+}
+
+// PCRange returns the addresses [low, high) of the code of a function or
+// unit given by DW_AT_low_pc and DW_AT_high_pc.
+func (di *DebugInfo) PCRange(d *DIE) (uint64, uint64, bool) {
+	low := d.Attr(DW_AT_low_pc)
+	high := d.Attr(DW_AT_high_pc)
+	if low == nil || high == nil {
+		return 0, 0, false
+	}
+	// Since DWARF 4 the high PC may be given as a length.
+	if high.IsConstant() {
+		return low.Val, low.Val + high.Val, true
+	}
+	return low.Val, high.Val, true
+}
+
+// DWARF 5 range list entry kinds.
+const (
+	DW_RLE_end_of_list   = 0x00
+	DW_RLE_base_addressx = 0x01
+	DW_RLE_startx_endx   = 0x02
+	DW_RLE_startx_length = 0x03
+	DW_RLE_offset_pair   = 0x04
+	DW_RLE_base_address  = 0x05
+	DW_RLE_start_end     = 0x06
+	DW_RLE_start_length  = 0x07
+)
+
+// Ranges returns the address ranges [low, high) covered by d, given by
+// DW_AT_low_pc and DW_AT_high_pc or by the range list that DW_AT_ranges
+// refers to in .debug_ranges or, since DWARF 5, .debug_rnglists.
+func (di *DebugInfo) Ranges(d *DIE) [][2]uint64 {
+	if low, high, ok := di.PCRange(d); ok {
+		return [][2]uint64{{low, high}}
+	}
+	a := d.Attr(DW_AT_ranges)
+	if a == nil {
+		return nil
+	}
+	u := d.Unit
+	// Offsets in range lists are relative to the low PC of the unit.
+	base, _ := u.Root.Val(DW_AT_low_pc)
+	if u.Version < 5 {
+		return di.debugRanges(u, a.Val, base)
+	}
+	off := a.Val
+	if a.Form == DW_FORM_rnglistx {
+		size := uint64(4)
+		if u.dwarf64 {
+			size = 8
+		}
+		pos := u.rnglistsBase + a.Val*size
+		if pos/size < a.Val || pos+size > uint64(len(di.rnglists)) {
+			return nil
+		}
+		b := &dwarfBuf{data: di.rnglists, off: int(pos), order: di.order}
+		off = u.rnglistsBase + b.offset(u.dwarf64)
+	}
+	return di.rangeList(u, off, base)
+}
+
+// debugRanges decodes the .debug_ranges list at off, which ends with a
+// pair of zeros. A pair starting with the largest address sets the base.
+func (di *DebugInfo) debugRanges(u *CompileUnit, off, base uint64) [][2]uint64 {
+	if off >= uint64(len(di.ranges)) {
+		return nil
+	}
+	maxAddr := ^uint64(0) >> (64 - 8*u.AddrSize)
+	var ranges [][2]uint64
+	b := &dwarfBuf{data: di.ranges, off: int(off), order: di.order}
+	for b.err == nil {
+		low, high := b.addr(u.AddrSize), b.addr(u.AddrSize)
+		switch {
+		case b.err != nil || low == 0 && high == 0:
+			return ranges
+		case low == maxAddr:
+			base = high
+		case low < high:
+			ranges = append(ranges, [2]uint64{base + low, base + high})
+		}
+	}
+	return ranges
+}
+
+// rangeList decodes the .debug_rnglists list at off.
+func (di *DebugInfo) rangeList(u *CompileUnit, off, base uint64) [][2]uint64 {
+	if off >= uint64(len(di.rnglists)) {
+		return nil
+	}
+	var ranges [][2]uint64
+	b := &dwarfBuf{data: di.rnglists, off: int(off), order: di.order}
+	add := func(low, high uint64, ok bool) {
+		if ok && b.err == nil && low < high {
+			ranges = append(ranges, [2]uint64{low, high})
+		}
+	}
+	for b.err == nil {
+		switch b.u8() {
+		case DW_RLE_end_of_list:
+			return ranges
+		case DW_RLE_base_addressx:
+			base, _ = di.addrIndex(u, b.uleb())
+		case DW_RLE_startx_endx:
+			low, ok1 := di.addrIndex(u, b.uleb())
+			high, ok2 := di.addrIndex(u, b.uleb())
+			add(low, high, ok1 && ok2)
+		case DW_RLE_startx_length:
+			low, ok := di.addrIndex(u, b.uleb())
+			add(low, low+b.uleb(), ok)
+		case DW_RLE_offset_pair:
+			low := b.uleb()
+			add(base+low, base+b.uleb(), true)
+		case DW_RLE_base_address:
+			base = b.addr(u.AddrSize)
+		case DW_RLE_start_end:
+			low := b.addr(u.AddrSize)
+			add(low, b.addr(u.AddrSize), true)
+		case DW_RLE_start_length:
+			low := b.addr(u.AddrSize)
+			add(low, low+b.uleb(), true)
+		default:
+			return ranges
+		}
+	}
+	return ranges
+}
+
+// Variables returns the variables with static storage declared at file or
+// namespace scope in unit u.
+func (di *DebugInfo) Variables(u *CompileUnit) []Variable {
+	var vars []Variable
+	walkDIEs(u.Root, func(d *DIE) bool {
+		switch d.Tag {
+		case DW_TAG_namespace, DW_TAG_module, DW_TAG_compile_unit, DW_TAG_partial_unit:
+			return true
+		case DW_TAG_variable:
+		default:
+			return false
+		}
+		if d.Flag(DW_AT_declaration) {
+			return false
+		}
+		t := di.ref(d, DW_AT_type)
+		v := Variable{
+			Name:        di.QualifiedName(d),
+			LinkageName: di.linkageName(d),
+			Type:        di.TypeName(t),
+			External:    di.flag(d, DW_AT_external),
+			Entry:       d,
+		}
+		v.Size, _ = di.TypeSize(t)
+		v.DeclFile, v.DeclLine = di.DeclFile(d)
+		if a := d.Attr(DW_AT_location); a != nil && a.Block != nil {
+			v.Address, v.HasAddress, v.TLS = di.staticLocation(d.Unit, a.Block)
+		}
+		// Variables optimized away entirely have neither a location nor
+		// a constant value and are left out.
+		if !v.HasAddress && d.Attr(DW_AT_const_value) == nil {
+			return false
+		}
+		vars = append(vars, v)
+		return false
+	})
+	return vars
+}
+
+// staticLocation decodes a location expression that gives a fixed address,
+// or the offset of a thread-local variable in its TLS block.
+func (di *DebugInfo) staticLocation(u *CompileUnit, expr []byte) (uint64, bool, bool) {
+	b := &dwarfBuf{data: expr, order: di.order}
+	var addr uint64
+	switch b.u8() {
+	case DW_OP_addr:
+		addr = b.addr(u.AddrSize)
+	case DW_OP_addrx, DW_OP_GNU_addr_index:
+		idx := b.uleb()
+		a, ok := di.addrIndex(u, idx)
+		if !ok {
+			return 0, false, false
+		}
+		addr = a
+	case DW_OP_const1u:
+		addr = uint64(b.u8())
+	case DW_OP_const2u:
+		addr = uint64(b.u16())
+	case DW_OP_const4u:
+		addr = uint64(b.u32())
+	case DW_OP_const8u:
+		addr = b.u64()
+	case DW_OP_constu:
+		addr = b.uleb()
+	default:
+		return 0, false, false
+	}
+	if b.err != nil {
+		return 0, false, false
+	}
+	if b.off == len(expr) {
+		return addr, true, false
+	}
+	switch b.u8() {
+	case DW_OP_form_tls_address, DW_OP_GNU_push_tls_address:
+		return addr, true, true
+	}
+	return 0, false, false
+}
+
+// Structs returns the layouts of the structures, classes and unions
+// defined in unit u. Anonymous types are included when a typedef names
+// them, under the typedef's name.
+func (di *DebugInfo) Structs(u *CompileUnit) []StructLayout {
+	var layouts []StructLayout
+	typedefs := make(map[*DIE]string)
+	walkDIEs(u.Root, func(d *DIE) bool {
+		if d.Tag == DW_TAG_typedef {
+			if t := di.Ref(d, DW_AT_type); t != nil && t.Name() == "" {
+				if _, ok := typedefs[t]; !ok {
+					typedefs[t] = di.QualifiedName(d)
+				}
+			}
+		}
+		return true
+	})
+	walkDIEs(u.Root, func(d *DIE) bool {
+		switch d.Tag {
+		case DW_TAG_structure_type, DW_TAG_class_type, DW_TAG_union_type:
+		default:
+			return true
+		}
+		if d.Flag(DW_AT_declaration) || d.Attr(DW_AT_byte_size) == nil {
+			return true
+		}
+		l := di.StructLayout(d)
+		if l.Name == "" {
+			l.Name, l.Typedef = typedefs[d], true
+		}
+		if l.Name != "" {
+			layouts = append(layouts, *l)
+		}
+		return true
+	})
+	return layouts
+}
+
+// walkDIEs calls fn for d and its descendants in order, skipping the
+// children of DIEs for which fn returns false.
+func walkDIEs(d *DIE, fn func(*DIE) bool) {
+	if d == nil || !fn(d) {
+		return
+	}
+	for _, c := range d.Children {
+		walkDIEs(c, fn)
+	}
+}
+
+// DisplayDebugInfo prints, for each compilation unit, its functions, its
+// global variables and the layouts of the structures it defines. A
+// structure defined the same way in several units is only printed once.
+func (f *File) DisplayDebugInfo(w io.Writer) error {
+	di, err := f.DebugInfo()
+	if err != nil && di == nil {
+		return err
+	}
+	if di == nil || len(di.Units) == 0 {
+		fmt.Fprintf(w, "There is no debugging information in this file.\n")
+		return nil
+	}
+
+	width := 16
+	if f.Class == ELFCLASS32 {
+		width = 8
+	}
+	seen := make(map[string]bool)
+	for _, u := range di.Units {
+		if u.IsTypeUnit() {
+			continue
+		}
+		fmt.Fprintf(w, "Compilation unit at offset %#x:\n", u.Offset)
+		fmt.Fprintf(w, "  Name:      %s\n", u.Name())
+		if dir := u.CompDir(); dir != "" {
+			fmt.Fprintf(w, "  Directory: %s\n", dir)
+		}
+		if p := u.Producer(); p != "" {
+			fmt.Fprintf(w, "  Producer:  %s\n", p)
+		}
+		if u.Root.Attr(DW_AT_language) != nil {
+			fmt.Fprintf(w, "  Language:  %s\n", LanguageString(u.Language()))
+		}
+		fmt.Fprintf(w, "  Version:   %d\n", u.Version)
+
+		if fns := di.Functions(u); len(fns) > 0 {
+			fmt.Fprintf(w, "\n  Functions:\n")
+			fmt.Fprintf(w, "    %-*s %8s  %s\n", width+2, "Address", "Size", "Prototype")
+			for _, fn := range fns {
+				fmt.Fprintf(w, "    0x%0*x %8d  %s%s%s\n", width, fn.Low, fn.High-fn.Low,
+					fn.Prototype(), declSuffix(fn.DeclFile, fn.DeclLine), staticSuffix(fn.External))
+			}
+		}
+
+		if vars := di.Variables(u); len(vars) > 0 {
+			fmt.Fprintf(w, "\n  Global variables:\n")
+			fmt.Fprintf(w, "    %-*s %8s  %s\n", width+2, "Address", "Size", "Declaration")
+			for _, v := range vars {
+				addr := strings.Repeat(" ", width+2)
+				switch {
+				case v.TLS:
+					addr = fmt.Sprintf("TLS+0x%0*x", width-4, v.Address)
+				case v.HasAddress:
+					addr = fmt.Sprintf("0x%0*x", width, v.Address)
+				}
+				fmt.Fprintf(w, "    %s %8d  %s%s%s\n", addr, v.Size,
+					declString(v.Type, v.Name), declSuffix(v.DeclFile, v.DeclLine), staticSuffix(v.External))
+			}
+		}
+
+		var structs []StructLayout
+		for _, l := range di.Structs(u) {
+			key := fmt.Sprintf("%s %s %d %d", l.Kind, l.Name, l.Size, len(l.Members))
+			if !seen[key] {
+				seen[key] = true
+				structs = append(structs, l)
+			}
+		}
+		if len(structs) > 0 {
+			fmt.Fprintf(w, "\n  Structure layouts:")
+			for i := range structs {
+				writeStructLayout(w, &structs[i])
+			}
+		}
+		fmt.Fprintln(w)
+	}
+	return err
+}
+
+func declSuffix(file string, line int) string {
+	if file == "" {
+		return ""
+	}
+	return fmt.Sprintf("  [%s:%d]", path.Base(file), line)
+}
+
+func staticSuffix(external bool) string {
+	if external {
+		return ""
+	}
+	return "  (static)"
+}
+
+// writeStructLayout prints a layout in the style of pahole: every member
+// with its offset and size, the holes between members and a summary.
+func writeStructLayout(w io.Writer, l *StructLayout) {
+	fmt.Fprintln(w)
+	if l.Typedef {
+		fmt.Fprintf(w, "    typedef %s {%s\n", l.Kind, declSuffix(l.DeclFile, l.DeclLine))
+	} else {
+		fmt.Fprintf(w, "    %s %s {%s\n", l.Kind, l.Name, declSuffix(l.DeclFile, l.DeclLine))
+	}
+	for _, m := range l.Members {
+		if m.HoleBits > 0 {
+			fmt.Fprintf(w, "\n        /* XXX %s hole */\n\n", bitsString(m.HoleBits))
+		}
+		decl := declString(m.Type, m.Name)
+		if m.Name == "" {
+			decl = m.Type + " (base)"
+		}
+		if m.BitSize > 0 {
+			decl += fmt.Sprintf(":%d", m.BitSize)
+			fmt.Fprintf(w, "        %-40s /* %5d:%d %5d */\n", decl+";", m.Offset, m.BitOffset, m.Size)
+		} else {
+			fmt.Fprintf(w, "        %-40s /* %5d   %5d */\n", decl+";", m.Offset, m.Size)
+		}
+	}
+	holes, holeBits := l.Holes()
+	fmt.Fprintf(w, "\n        /* size: %d, members: %d", l.Size, len(l.Members))
+	if holes > 0 {
+		fmt.Fprintf(w, ", holes: %d, sum holes: %s", holes, bitsString(holeBits))
+	}
+	if l.PaddingBits > 0 {
+		fmt.Fprintf(w, ", padding: %s", bitsString(l.PaddingBits))
+	}
+	fmt.Fprintf(w, " */\n")
+	if l.Typedef {
+		fmt.Fprintf(w, "    } %s;\n", l.Name)
+	} else {
+		fmt.Fprintf(w, "    };\n")
+	}
+}
+
+func bitsString(bits uint64) string {
+	switch {
+	case bits%8 != 0 && bits > 8:
+		return fmt.Sprintf("%d bytes %d bits", bits/8, bits%8)
+	case bits%8 != 0:
+		return fmt.Sprintf("%d bits", bits)
+	case bits == 8:
+		return "1 byte"
+	}
+	return fmt.Sprintf("%d bytes", bits/8)
+}
@@ -0,0 +1,300 @@
+package elf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"hash/fnv"
+)
+
+// Kinds of DiffEntry.
+const (
+	DiffAdded   = "added"
+	DiffRemoved = "removed"
+	DiffChanged = "changed"
+)
+
+// FileDiff is the structural difference between two ELF files. Sections
+// are matched by name, segments by type and position among segments of
+// that type, symbols by symbol table and versioned name, and dynamic
+// entries and notes by their tags and types.
+type FileDiff struct {
+	OldSize, NewSize uint64
+
+	Header   []FieldDiff
+	Sections []DiffEntry
+	Segments []DiffEntry
+	Symbols  []DiffEntry
+	Dynamic  []DiffEntry
+	Notes    []DiffEntry
+}
+
+// FieldDiff is a field whose value differs, formatted as in the text
+// views.
+type FieldDiff struct {
+	Field string
+	Old   string
+	New   string
+}
+
+// DiffEntry is an item that is only in one of the files, or whose fields
+// differ. SizeDelta is the growth in bytes of sections, symbols and the
+// memory image of segments; it is negative for items that shrink or are
+// removed.
+type DiffEntry struct {
+	Kind      string
+	Name      string
+	Fields    []FieldDiff
+	SizeDelta int64
+}
+
+// Empty reports whether the files are structurally identical.
+func (d *FileDiff) Empty() bool {
+	return d.OldSize == d.NewSize && len(d.Header) == 0 && len(d.Sections) == 0 && len(d.Segments) == 0 &&
+		len(d.Symbols) == 0 && len(d.Dynamic) == 0 && len(d.Notes) == 0
+}
+
+// Count returns the number of differences.
+func (d *FileDiff) Count() int {
+	n := len(d.Header) + len(d.Sections) + len(d.Segments) + len(d.Symbols) + len(d.Dynamic) + len(d.Notes)
+	if d.OldSize != d.NewSize {
+		n++
+	}
+	return n
+}
+
+// diffItem is the comparable form of an entry of one of the tables.
+type diffItem struct {
+	key    string
+	name   string
+	size   uint64
+	fields []FieldDiff // Old holds the value
+}
+
+// fieldList collects the fields of an item in display order.
+type fieldList []FieldDiff
+
+func (l *fieldList) add(field, format string, args ...interface{}) {
+	*l = append(*l, FieldDiff{Field: field, Old: fmt.Sprintf(format, args...)})
+}
+
+// Diff compares the files old and new.
+func Diff(old, new *File) *FileDiff {
+	d := &FileDiff{OldSize: uint64(len(old.Raw)), NewSize: uint64(len(new.Raw))}
+
+	oldHeader, newHeader := old.headerFields(), new.headerFields()
+	for i := range oldHeader {
+		if oldHeader[i].Old != newHeader[i].Old {
+			d.Header = append(d.Header, FieldDiff{Field: oldHeader[i].Field, Old: oldHeader[i].Old, New: newHeader[i].Old})
+		}
+	}
+	d.Sections = diffItems(old.sectionItems(), new.sectionItems())
+	d.Segments = diffItems(old.segmentItems(), new.segmentItems())
+	d.Symbols = diffItems(old.symbolItems(), new.symbolItems())
+	d.Dynamic = diffItems(old.dynamicItems(), new.dynamicItems())
+	d.Notes = diffItems(old.noteItems(), new.noteItems())
+	return d
+}
+
+// diffItems matches the items of two tables by key. Removed and changed
+// items are listed in the order of the old table, followed by the added
+// ones in the order of the new.
+func diffItems(old, new []diffItem) []DiffEntry {
+	newByKey := make(map[string]*diffItem, len(new))
+	for i := range new {
+		newByKey[new[i].key] = &new[i]
+	}
+	oldKeys := make(map[string]bool, len(old))
+	var out []DiffEntry
+	for i := range old {
+		o := &old[i]
+		oldKeys[o.key] = true
+		n := newByKey[o.key]
+		if n == nil {
+			out = append(out, DiffEntry{Kind: DiffRemoved, Name: o.name, SizeDelta: -int64(o.size)})
+			continue
+		}
+		var fields []FieldDiff
+		for j := range o.fields {
+			if j < len(n.fields) && o.fields[j].Old != n.fields[j].Old {
+				fields = append(fields, FieldDiff{Field: o.fields[j].Field, Old: o.fields[j].Old, New: n.fields[j].Old})
+			}
+		}
+		if len(fields) > 0 {
+			out = append(out, DiffEntry{Kind: DiffChanged, Name: o.name, Fields: fields, SizeDelta: int64(n.size - o.size)})
+		}
+	}
+	for i := range new {
+		if n := &new[i]; !oldKeys[n.key] {
+			out = append(out, DiffEntry{Kind: DiffAdded, Name: n.name, SizeDelta: int64(n.size)})
+		}
+	}
+	return out
+}
+
+// occurrences numbers repeated keys, so that the second ".text" of a
+// relocatable object is matched with the second one of the other file.
+type occurrences map[string]int
+
+func (o occurrences) key(k string) string {
+	n := o[k]
+	o[k]++
+	if n == 0 {
+		return k
+	}
+	return fmt.Sprintf("%s#%d", k, n+1)
+}
+
+func (f *File) headerFields() fieldList {
+	var l fieldList
+	l.add("Class", "%s", ClassString(f.Ident.Class))
+	l.add("Data", "%s", DataString(f.Ident.Data))
+	l.add("OS/ABI", "%s", OSABIString(f.Ident.OSABI, f.Machine))
+	l.add("ABI Version", "%d", f.Ident.ABIVersion)
+	l.add("Type", "%s", TypeString(f.Type))
+	l.add("Machine", "%s", MachineString(f.Machine))
+	l.add("Version", "%#x", f.Version)
+	l.add("Entry point address", "%#x", f.Entry)
+	l.add("Flags", "%#x %s", f.Flags, HeaderFlagsString(f.Machine, f.Flags))
+	l.add("Start of program headers", "%d", f.phoff)
+	l.add("Start of section headers", "%d", f.shoff)
+	l.add("Number of program headers", "%d", f.phnum)
+	l.add("Number of section headers", "%d", f.shnum)
+	l.add("Section header string table index", "%d", f.shstrndx)
+	return l
+}
+
+func (f *File) sectionItems() []diffItem {
+	var items []diffItem
+	seen := make(occurrences)
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if i == 0 && sh.Type == SHT_NULL {
+			continue
+		}
+		var l fieldList
+		l.add("type", "%s", SectionTypeString(f.Machine, f.Ident.OSABI, sh.Type))
+		l.add("flags", "%s", SectionFlagsString(f.Machine, f.Ident.OSABI, sh.Flags))
+		l.add("address", "%#x", sh.Addr)
+		l.add("size", "%#x", sh.Size)
+		l.add("alignment", "%d", sh.AddrAlign)
+		l.add("entry size", "%d", sh.EntSize)
+		// Sections of the same size can still differ, which is what
+		// breaks reproducible builds.
+		if sh.Type != SHT_NOBITS {
+			data, _ := f.GetSectionData(sh)
+			l.add("contents", "%s", shortHash(data))
+		}
+		items = append(items, diffItem{key: seen.key(sh.Name), name: sh.Name, size: sh.Size, fields: l})
+	}
+	return items
+}
+
+// shortHash returns a fingerprint of data for telling contents apart.
+func shortHash(data []byte) string {
+	h := fnv.New64a()
+	h.Write(data)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func (f *File) segmentItems() []diffItem {
+	var items []diffItem
+	seen := make(occurrences)
+	for _, ph := range f.ProgramHeaders {
+		name := SegmentTypeString(f.Machine, f.Ident.OSABI, ph.Type)
+		key := seen.key(name)
+		var l fieldList
+		l.add("offset", "%#x", ph.Offset)
+		l.add("vaddr", "%#x", ph.VAddr)
+		l.add("paddr", "%#x", ph.PAddr)
+		l.add("filesz", "%#x", ph.FileSz)
+		l.add("memsz", "%#x", ph.MemSz)
+		l.add("flags", "%s", SegmentFlagsString(ph.Flags))
+		l.add("align", "%#x", ph.Align)
+		items = append(items, diffItem{key: key, name: key, size: ph.MemSz, fields: l})
+	}
+	return items
+}
+
+// symbolItems lists the symbols of both symbol tables, leaving out the
+// section and file symbols. Values are not compared, since every symbol
+// after a change in size moves.
+func (f *File) symbolItems() []diffItem {
+	var items []diffItem
+	seen := make(occurrences)
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type != SHT_SYMTAB && sh.Type != SHT_DYNSYM {
+			continue
+		}
+		syms, err := f.readSymbolTable(sh)
+		if err != nil {
+			continue
+		}
+		for j := range syms {
+			s := &syms[j]
+			if s.Name == "" || s.Type() == STT_SECTION || s.Type() == STT_FILE {
+				continue
+			}
+			name := s.VersionedName()
+			if sh.Type == SHT_DYNSYM {
+				name += " (dynamic)"
+			}
+			section := SectionIndexString(s.Shndx)
+			if ndx, ok := s.SectionIndex(); ok && ndx < len(f.SectionHeaders) {
+				section = f.SectionHeaders[ndx].Name
+			}
+			var l fieldList
+			l.add("size", "%d", s.Size)
+			l.add("type", "%s", SymbolTypeString(s.Type()))
+			l.add("bind", "%s", SymbolBindString(s.Bind()))
+			l.add("visibility", "%s", SymbolVisibilityString(s.Visibility()))
+			l.add("section", "%s", section)
+			items = append(items, diffItem{key: seen.key(name), name: name, size: s.Size, fields: l})
+		}
+	}
+	return items
+}
+
+// dynamicItems keys entries whose values are names, such as DT_NEEDED, by
+// the name too, so that adding a library reads as one added entry rather
+// than as every following entry changing.
+func (f *File) dynamicItems() []diffItem {
+	var items []diffItem
+	seen := make(occurrences)
+	for _, d := range f.Dynamic {
+		if d.Tag == DT_NULL {
+			continue
+		}
+		tag := DynamicTagString(d.Tag)
+		value := DynamicValueString(d)
+		key, name := tag, tag
+		switch d.Tag {
+		case DT_NEEDED, DT_SONAME, DT_RPATH, DT_RUNPATH, DT_AUXILIARY, DT_FILTER:
+			key = tag + " " + value
+			name = key
+		}
+		var l fieldList
+		l.add("value", "%s", value)
+		items = append(items, diffItem{key: seen.key(key), name: name, fields: l})
+	}
+	return items
+}
+
+func (f *File) noteItems() []diffItem {
+	var items []diffItem
+	seen := make(occurrences)
+	for i := range f.Notes {
+		n := &f.Notes[i]
+		name := n.Name + " " + f.NoteTypeString(n)
+		var l fieldList
+		l.add("section", "%s", n.Section)
+		desc := f.NoteDescription(n)
+		if desc == "" {
+			desc = hex.EncodeToString(n.Desc)
+		}
+		l.add("description", "%s", desc)
+		key := seen.key(name)
+		items = append(items, diffItem{key: key, name: key, fields: l})
+	}
+	return items
+}
@@ -0,0 +1,58 @@
+package elf
+
+import (
+	"testing"
+)
+
+func TestDiffIdentical(t *testing.T) {
+	f := testFile(t, "libv1.so")
+	if d := Diff(f, testFile(t, "libv1.so")); !d.Empty() || d.Count() != 0 {
+		t.Errorf("got %d differences between a file and itself", d.Count())
+	}
+}
+
+func TestDiff(t *testing.T) {
+	d := Diff(testFile(t, "libv1.so"), testFile(t, "libv2.so"))
+	if d.OldSize != 6760 || d.NewSize != 6808 {
+		t.Errorf("got sizes %d and %d, want 6760 and 6808", d.OldSize, d.NewSize)
+	}
+
+	type symbol struct {
+		kind  string
+		name  string
+		delta int64
+	}
+	var got []symbol
+	for _, e := range d.Symbols {
+		got = append(got, symbol{e.Kind, e.Name, e.SizeDelta})
+	}
+	want := []symbol{
+		{DiffRemoved, "lib_old (dynamic)", -6},
+		{DiffChanged, "lib_scale (dynamic)", 4},
+		{DiffRemoved, "lib_old", -6},
+		{DiffChanged, "lib_scale", 4},
+		{DiffAdded, "lib_new (dynamic)", 6},
+		{DiffAdded, "lib_new", 6},
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got symbol differences %+v, want %+v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("symbol difference %d: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+
+	var text *DiffEntry
+	for i := range d.Sections {
+		if d.Sections[i].Name == ".text" {
+			text = &d.Sections[i]
+		}
+	}
+	if text == nil || text.Kind != DiffChanged || text.SizeDelta != 4 {
+		t.Errorf("got .text difference %+v, want a change of +4 bytes", text)
+	}
+	if len(d.Segments) != 1 || len(d.Header) != 1 {
+		t.Errorf("got %d segment and %d header differences, want 1 and 1", len(d.Segments), len(d.Header))
+	}
+}
@@ -0,0 +1,1283 @@
+package disasm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/bits"
+	"strings"
+)
+
+// a32 decodes a single A32 or T32 instruction. Both instruction sets share
+// register naming, shifts and the VFP encodings, so a single decoder state
+// is used for them.
+type a32 struct {
+	w      uint32
+	pc     uint64
+	opts   Options
+	thumb  bool
+	cond   uint32
+	inIT   bool
+	target uint64
+	hasTgt bool
+	// comment is appended after the operands, e.g. the address of a
+	// literal pool load.
+	comment string
+}
+
+func decodeARM(code []byte, pc uint64, opts Options) (Inst, error) {
+	if len(code) < 4 {
+		return Inst{Addr: pc, Len: len(code), Text: "(bad)"}, ErrBadInst
+	}
+	w := binary.LittleEndian.Uint32(code)
+	d := &a32{w: w, pc: pc, opts: opts, cond: w >> 28}
+	op, args := d.decodeA32()
+	if op == "" {
+		return Inst{Addr: pc, Len: 4, Text: fmt.Sprintf(".inst\t0x%08x", w)}, ErrBadInst
+	}
+	return d.inst(4, op, args), nil
+}
+
+func (d *a32) inst(n int, op string, args []string) Inst {
+	text := armText(op, args)
+	if d.comment != "" {
+		text += "\t@ " + d.comment
+	}
+	return Inst{Addr: d.pc, Len: n, Text: text, Target: d.target, HasTarget: d.hasTgt}
+}
+
+func (d *a32) bits(hi, lo uint) uint32 {
+	return (d.w >> lo) & (1<<(hi-lo+1) - 1)
+}
+
+func (d *a32) bit(n uint) bool {
+	return d.w>>n&1 != 0
+}
+
+// pcValue is the value read from the PC register by the instruction.
+func (d *a32) pcValue() uint64 {
+	if d.thumb {
+		return d.pc + 4
+	}
+	return d.pc + 8
+}
+
+// label records a branch destination and formats it.
+func (d *a32) label(addr uint64) string {
+	d.target = addr & 0xffffffff
+	d.hasTgt = true
+	return symbolic(d.target, d.opts)
+}
+
+// literal records the address of a PC-relative load for the comment.
+func (d *a32) literal(off int64) {
+	d.target = uint64(int64(d.pcValue()&^3)+off) & 0xffffffff
+	d.hasTgt = true
+	d.comment = symbolic(d.target, d.opts)
+}
+
+var armCond = [16]string{
+	"eq", "ne", "hs", "lo", "mi", "pl", "vs", "vc",
+	"hi", "ls", "ge", "lt", "gt", "le", "", "",
+}
+
+// op returns the mnemonic with the condition of the instruction.
+func (d *a32) op(base string) string {
+	return base + armCond[d.cond]
+}
+
+// ops is like op for instructions with an optional flag setting suffix.
+func (d *a32) ops(base string, s bool) string {
+	if s {
+		base += "s"
+	}
+	return base + armCond[d.cond]
+}
+
+var armRegs = [16]string{
+	"r0", "r1", "r2", "r3", "r4", "r5", "r6", "r7",
+	"r8", "r9", "r10", "r11", "r12", "sp", "lr", "pc",
+}
+
+func armReg(n uint32) string {
+	return armRegs[n&15]
+}
+
+var armShiftNames = [4]string{"lsl", "lsr", "asr", "ror"}
+
+// armShift formats an immediate shift. It returns "" for LSL #0.
+func armShift(typ, amount uint32) string {
+	switch {
+	case typ == 0 && amount == 0:
+		return ""
+	case typ == 3 && amount == 0:
+		return "rrx"
+	case amount == 0:
+		amount = 32
+	}
+	return fmt.Sprintf("%s #%d", armShiftNames[typ], amount)
+}
+
+func armRegList(mask uint32) string {
+	var regs []string
+	for i := uint32(0); i < 16; i++ {
+		if mask>>i&1 != 0 {
+			regs = append(regs, armReg(i))
+		}
+	}
+	return "{" + strings.Join(regs, ", ") + "}"
+}
+
+// armImm formats an immediate offset with its sign bit separate, so that
+// a negative zero offset prints as #-0.
+func armImm(v uint32, add bool) string {
+	if add {
+		return fmt.Sprintf("#%d", v)
+	}
+	return fmt.Sprintf("#-%d", v)
+}
+
+// armOff returns an offset given as magnitude and direction.
+func armOff(v uint32, add bool) int64 {
+	if add {
+		return int64(v)
+	}
+	return -int64(v)
+}
+
+// armMem formats a memory operand. off is the offset operand, including
+// its sign; it is omitted when empty.
+func armMem(rn uint32, off string, pre, wback bool) []string {
+	switch {
+	case !pre:
+		return []string{"[" + armReg(rn) + "]", off}
+	case off == "":
+		return []string{"[" + armReg(rn) + "]"}
+	case wback:
+		return []string{fmt.Sprintf("[%s, %s]!", armReg(rn), off)}
+	}
+	return []string{fmt.Sprintf("[%s, %s]", armReg(rn), off)}
+}
+
+var armBarriers = map[uint32]string{
+	1: "oshld", 2: "oshst", 3: "osh", 5: "nshld", 6: "nshst", 7: "nsh",
+	9: "ishld", 10: "ishst", 11: "ish", 13: "ld", 14: "st", 15: "sy",
+}
+
+func armBarrier(op string, opt uint32) (string, []string) {
+	if name, ok := armBarriers[opt]; ok {
+		return op, []string{name}
+	}
+	return op, []string{imm(int64(opt))}
+}
+
+var armHints = [6]string{"nop", "yield", "wfe", "wfi", "sev", "sevl"}
+
+func (d *a32) hint(n uint32) (string, []string) {
+	switch {
+	case n < uint32(len(armHints)):
+		return d.op(armHints[n]), nil
+	case n&0xf0 == 0xf0:
+		return d.op("dbg"), []string{imm(int64(n & 15))}
+	case n == 0x14:
+		return d.op("csdb"), nil
+	}
+	return d.op("hint"), []string{imm(int64(n))}
+}
+
+// psrFields formats the destination of MSR.
+func psrFields(spsr bool, mask uint32) string {
+	if !spsr {
+		switch mask {
+		case 8:
+			return "APSR_nzcvq"
+		case 4:
+			return "APSR_g"
+		case 12:
+			return "APSR_nzcvqg"
+		}
+	}
+	name := "CPSR_"
+	if spsr {
+		name = "SPSR_"
+	}
+	for i, c := range "fsxc" {
+		if mask>>(3-i)&1 != 0 {
+			name += string(c)
+		}
+	}
+	return name
+}
+
+func armExpandImm(imm12 uint32) uint32 {
+	return bits.RotateLeft32(imm12&0xff, -int(2*(imm12>>8)))
+}
+
+// armModImm formats a modified immediate. Values encoded with the
+// canonical rotation print as a single number; others print the 8-bit
+// value and the rotation separately so that the encoding round-trips.
+func armModImm(imm12 uint32, unsigned bool) string {
+	v := armExpandImm(imm12)
+	if armModImmEncode(v) != imm12 {
+		return fmt.Sprintf("#%d, #%d", imm12&0xff, 2*(imm12>>8))
+	}
+	if unsigned {
+		return fmt.Sprintf("#%d", v)
+	}
+	return imm(int64(int32(v)))
+}
+
+// armModImmEncode returns the canonical encoding of v, the one an
+// assembler would pick.
+func armModImmEncode(v uint32) uint32 {
+	rot := 0
+	if v&^0xff != 0 {
+		rot = bits.TrailingZeros32(v) &^ 1
+		if bits.RotateLeft32(v, -rot)&^0xff != 0 && v&63 != 0 {
+			if r := bits.TrailingZeros32(v&^63) &^ 1; bits.RotateLeft32(v, -r)&^0xff == 0 {
+				rot = r
+			}
+		}
+		rot = (32 - rot) & 31
+	}
+	return bits.RotateLeft32(v, rot) | uint32(rot/2)<<8
+}
+
+func (d *a32) decodeA32() (string, []string) {
+	if d.cond == 15 {
+		return d.a32Uncond()
+	}
+	switch d.bits(27, 25) {
+	case 0:
+		switch {
+		case d.bit(7) && d.bit(4):
+			return d.a32MulExtra()
+		case d.bits(24, 23) == 2 && !d.bit(20):
+			if d.bit(7) {
+				return d.a32HalfMul()
+			}
+			return d.a32Misc()
+		}
+		return d.a32DataProc(false)
+	case 1:
+		if d.bits(24, 23) == 2 && !d.bit(20) {
+			return d.a32ImmMisc()
+		}
+		return d.a32DataProc(true)
+	case 2:
+		return d.a32LoadStore(false)
+	case 3:
+		if d.bit(4) {
+			return d.a32Media()
+		}
+		return d.a32LoadStore(true)
+	case 4:
+		return d.a32Block()
+	case 5:
+		op := "b"
+		if d.bit(24) {
+			op = "bl"
+		}
+		off := sext(d.bits(23, 0), 24) << 2
+		return d.op(op), []string{d.label(uint64(int64(d.pcValue()) + off))}
+	}
+	if d.bits(27, 24) == 15 {
+		return d.op("svc"), []string{imm(int64(d.bits(23, 0)))}
+	}
+	if d.bits(11, 9) == 5 {
+		return d.vfp()
+	}
+	return d.coproc("")
+}
+
+var armDataOps = [16]string{
+	"and", "eor", "sub", "rsb", "add", "adc", "sbc", "rsc",
+	"tst", "teq", "cmp", "cmn", "orr", "mov", "bic", "mvn",
+}
+
+func (d *a32) a32DataProc(immediate bool) (string, []string) {
+	opc, s := d.bits(24, 21), d.bit(20)
+	rn, rd, rm := d.bits(19, 16), d.bits(15, 12), d.bits(3, 0)
+
+	var op2 []string
+	switch {
+	case immediate:
+		if v := armExpandImm(d.bits(11, 0)); (opc == 2 || opc == 4) && rn == 15 && !s {
+			if opc == 2 {
+				v = -v
+			}
+			d.literal(int64(int32(v)))
+		}
+		op2 = []string{armModImm(d.bits(11, 0), opc == 13 && rd == 15)}
+	case !d.bit(4):
+		typ, amount := d.bits(6, 5), d.bits(11, 7)
+		sh := armShift(typ, amount)
+		if opc == 13 {
+			switch {
+			case sh == "":
+				return d.ops("mov", s), []string{armReg(rd), armReg(rm)}
+			case sh == "rrx":
+				return d.ops("rrx", s), []string{armReg(rd), armReg(rm)}
+			}
+			return d.ops(armShiftNames[typ], s), []string{armReg(rd), armReg(rm), sh[4:]}
+		}
+		op2 = []string{armReg(rm)}
+		if sh != "" {
+			op2 = append(op2, sh)
+		}
+	default:
+		typ, rs := d.bits(6, 5), d.bits(11, 8)
+		if opc == 13 {
+			return d.ops(armShiftNames[typ], s), []string{armReg(rd), armReg(rm), armReg(rs)}
+		}
+		op2 = []string{armReg(rm), armShiftNames[typ] + " " + armReg(rs)}
+	}
+
+	switch opc {
+	case 8, 9, 10, 11:
+		return d.op(armDataOps[opc]), append([]string{armReg(rn)}, op2...)
+	case 13, 15:
+		return d.ops(armDataOps[opc], s), append([]string{armReg(rd)}, op2...)
+	}
+	return d.ops(armDataOps[opc], s), append([]string{armReg(rd), armReg(rn)}, op2...)
+}
+
+func (d *a32) a32ImmMisc() (string, []string) {
+	rd := d.bits(15, 12)
+	v := d.bits(19, 16)<<12 | d.bits(11, 0)
+	switch d.bits(22, 21) {
+	case 0:
+		return d.op("movw"), []string{armReg(rd), imm(int64(v))}
+	case 2:
+		return d.op("movt"), []string{armReg(rd), imm(int64(v))}
+	}
+	mask, r := d.bits(19, 16), d.bit(22)
+	if mask == 0 && !r {
+		return d.hint(d.bits(7, 0))
+	}
+	if mask == 0 {
+		return "", nil
+	}
+	return d.op("msr"), []string{psrFields(r, mask), armModImm(d.bits(11, 0), true)}
+}
+
+func (d *a32) a32Misc() (string, []string) {
+	op, rd, rm := d.bits(22, 21), d.bits(15, 12), d.bits(3, 0)
+	rn := d.bits(19, 16)
+	switch d.bits(6, 4) {
+	case 0:
+		if d.bit(9) {
+			return "", nil
+		}
+		if op&1 == 0 {
+			psr := "apsr"
+			if op == 2 {
+				psr = "spsr"
+			}
+			return d.op("mrs"), []string{armReg(rd), psr}
+		}
+		if rn == 0 {
+			return "", nil
+		}
+		return d.op("msr"), []string{psrFields(op == 3, rn), armReg(rm)}
+	case 1:
+		switch op {
+		case 1:
+			return d.op("bx"), []string{armReg(rm)}
+		case 3:
+			return d.op("clz"), []string{armReg(rd), armReg(rm)}
+		}
+	case 2:
+		if op == 1 {
+			return d.op("bxj"), []string{armReg(rm)}
+		}
+	case 3:
+		if op == 1 {
+			return d.op("blx"), []string{armReg(rm)}
+		}
+	case 5:
+		name := [4]string{"qadd", "qsub", "qdadd", "qdsub"}[op]
+		return d.op(name), []string{armReg(rd), armReg(rm), armReg(rn)}
+	case 6:
+		if op == 3 {
+			return d.op("eret"), nil
+		}
+	case 7:
+		v := d.bits(19, 8)<<4 | d.bits(3, 0)
+		switch op {
+		case 1:
+			if d.cond != 14 {
+				return "", nil
+			}
+			return "bkpt", []string{imm(int64(v))}
+		case 2:
+			if d.cond != 14 {
+				return "", nil
+			}
+			return "hvc", []string{imm(int64(v))}
+		case 3:
+			return d.op("smc"), []string{imm(int64(d.bits(3, 0)))}
+		}
+	}
+	return "", nil
+}
+
+func (d *a32) a32HalfMul() (string, []string) {
+	rd, ra, rm, rn := d.bits(19, 16), d.bits(15, 12), d.bits(11, 8), d.bits(3, 0)
+	x, y := "bt"[d.bits(5, 5)], "bt"[d.bits(6, 6)]
+	switch d.bits(22, 21) {
+	case 0:
+		return d.op(fmt.Sprintf("smla%c%c", x, y)), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+	case 1:
+		if d.bit(5) {
+			return d.op(fmt.Sprintf("smulw%c", y)), []string{armReg(rd), armReg(rn), armReg(rm)}
+		}
+		return d.op(fmt.Sprintf("smlaw%c", y)), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+	case 2:
+		return d.op(fmt.Sprintf("smlal%c%c", x, y)), []string{armReg(ra), armReg(rd), armReg(rn), armReg(rm)}
+	}
+	return d.op(fmt.Sprintf("smul%c%c", x, y)), []string{armReg(rd), armReg(rn), armReg(rm)}
+}
+
+// a32MulExtra decodes multiplies, synchronization primitives and the
+// halfword and doubleword loads and stores.
+func (d *a32) a32MulExtra() (string, []string) {
+	rn, rt := d.bits(19, 16), d.bits(15, 12)
+	if d.bits(6, 5) != 0 {
+		return d.a32ExtraLoadStore()
+	}
+	if !d.bit(24) {
+		rd, ra, rm, rn := d.bits(19, 16), d.bits(15, 12), d.bits(11, 8), d.bits(3, 0)
+		s := d.bit(20)
+		switch d.bits(23, 21) {
+		case 0:
+			return d.ops("mul", s), []string{armReg(rd), armReg(rn), armReg(rm)}
+		case 1:
+			return d.ops("mla", s), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+		case 2:
+			if s {
+				return "", nil
+			}
+			return d.op("umaal"), []string{armReg(ra), armReg(rd), armReg(rn), armReg(rm)}
+		case 3:
+			if s {
+				return "", nil
+			}
+			return d.op("mls"), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+		}
+		name := [4]string{"umull", "umlal", "smull", "smlal"}[d.bits(22, 21)]
+		return d.ops(name, s), []string{armReg(ra), armReg(rd), armReg(rn), armReg(rm)}
+	}
+
+	rm := d.bits(3, 0)
+	op := d.bits(23, 20)
+	if op&0xb == 0 {
+		name := "swp"
+		if d.bit(22) {
+			name = "swpb"
+		}
+		return d.op(name), []string{armReg(rt), armReg(rm), "[" + armReg(rn) + "]"}
+	}
+	if op < 8 {
+		return "", nil
+	}
+	l := op&1 != 0
+	var size string
+	switch op >> 1 & 3 {
+	case 1:
+		size = "d"
+	case 2:
+		size = "b"
+	case 3:
+		size = "h"
+	}
+	var name string
+	switch d.bits(9, 8) {
+	case 3:
+		name = [2]string{"strex", "ldrex"}[b2i(l)]
+	case 2:
+		name = [2]string{"stlex", "ldaex"}[b2i(l)]
+	case 0:
+		if size == "d" {
+			return "", nil
+		}
+		name = [2]string{"stl", "lda"}[b2i(l)]
+	default:
+		return "", nil
+	}
+	args := []string{}
+	if !l && d.bits(9, 8) != 0 {
+		args = append(args, armReg(rt))
+		rt = rm
+	}
+	args = append(args, armReg(rt))
+	if size == "d" {
+		args = append(args, armReg(rt+1))
+	}
+	return d.op(name + size), append(args, "["+armReg(rn)+"]")
+}
+
+func (d *a32) a32ExtraLoadStore() (string, []string) {
+	p, u, i, w := d.bit(24), d.bit(23), d.bit(22), d.bit(21)
+	rn, rt, rm := d.bits(19, 16), d.bits(15, 12), d.bits(3, 0)
+
+	var name string
+	dual := false
+	switch d.bits(6, 5)<<1 | d.bits(20, 20) {
+	case 2:
+		name = "strh"
+	case 3:
+		name = "ldrh"
+	case 4:
+		name, dual = "ldrd", true
+	case 5:
+		name = "ldrsb"
+	case 6:
+		name, dual = "strd", true
+	case 7:
+		name = "ldrsh"
+	}
+	if !p && w {
+		if dual {
+			return "", nil
+		}
+		name += "t"
+	}
+
+	var off string
+	if i {
+		v := d.bits(11, 8)<<4 | d.bits(3, 0)
+		if p && !w && u && v == 0 {
+			off = ""
+		} else {
+			off = armImm(v, u)
+		}
+		if rn == 15 && p && !w {
+			d.literal(armOff(v, u))
+		}
+	} else {
+		off = armReg(rm)
+		if !u {
+			off = "-" + off
+		}
+	}
+	args := []string{armReg(rt)}
+	if dual {
+		args = append(args, armReg(rt+1))
+	}
+	return d.op(name), append(args, armMem(rn, off, p, w)...)
+}
+
+func (d *a32) a32LoadStore(register bool) (string, []string) {
+	p, u, b, w, l := d.bit(24), d.bit(23), d.bit(22), d.bit(21), d.bit(20)
+	rn, rt := d.bits(19, 16), d.bits(15, 12)
+
+	name := [2][2]string{{"str", "strb"}, {"ldr", "ldrb"}}[b2i(l)][b2i(b)]
+	if !p && w {
+		name += "t"
+	}
+
+	var off string
+	if register {
+		off = armReg(d.bits(3, 0))
+		if !u {
+			off = "-" + off
+		}
+		if sh := armShift(d.bits(6, 5), d.bits(11, 7)); sh != "" {
+			off += ", " + sh
+		}
+	} else {
+		v := d.bits(11, 0)
+		if p && !w && u && v == 0 {
+			off = ""
+		} else {
+			off = armImm(v, u)
+		}
+		if rn == 15 && p && !w {
+			d.literal(armOff(v, u))
+		}
+	}
+	return d.op(name), append([]string{armReg(rt)}, armMem(rn, off, p, w)...)
+}
+
+func (d *a32) a32Block() (string, []string) {
+	p, u, s, w, l := d.bit(24), d.bit(23), d.bit(22), d.bit(21), d.bit(20)
+	rn, list := d.bits(19, 16), d.bits(15, 0)
+	if list == 0 {
+		return "", nil
+	}
+	switch {
+	case rn == 13 && w && !s && !l && p && !u && bits.OnesCount32(list) > 1:
+		return d.op("push"), []string{armRegList(list)}
+	case rn == 13 && w && !s && l && !p && u && bits.OnesCount32(list) > 1:
+		return d.op("pop"), []string{armRegList(list)}
+	}
+	name := "stm"
+	if l {
+		name = "ldm"
+	}
+	name += [2][2]string{{"da", ""}, {"db", "ib"}}[b2i(p)][b2i(u)]
+	base := armReg(rn)
+	if w {
+		base += "!"
+	}
+	regs := armRegList(list)
+	if s {
+		regs += " ^"
+	}
+	return d.op(name), []string{base, regs}
+}
+
+// a32Media decodes the media instructions: parallel arithmetic, packing,
+// saturation, extension, bit field and the signed multiplies.
+func (d *a32) a32Media() (string, []string) {
+	op1, op2 := d.bits(24, 20), d.bits(7, 5)
+	rn, rd, rs, rm := d.bits(19, 16), d.bits(15, 12), d.bits(11, 8), d.bits(3, 0)
+
+	switch {
+	case op1>>3 == 0:
+		return d.parallel(op1&4 != 0, d.bits(21, 20), op2, rd, rn, rm)
+	case op1>>3 == 1:
+		return d.packSat(op1&7, op2, rd, rn, rm)
+	case op1>>3 == 2:
+		return d.signedMul(op1&7, op2, rn, rd, rs, rm)
+	case op1 == 0x18 && op2 == 0:
+		if rd == 15 {
+			return d.op("usad8"), []string{armReg(rn), armReg(rm), armReg(rs)}
+		}
+		return d.op("usada8"), []string{armReg(rn), armReg(rm), armReg(rs), armReg(rd)}
+	case op1>>1 == 0xd && op2&3 == 2, op1>>1 == 0xf && op2&3 == 2:
+		name := "sbfx"
+		if op1>>1 == 0xf {
+			name = "ubfx"
+		}
+		lsb := d.bits(11, 7)
+		return d.op(name), []string{armReg(rd), armReg(rm), imm(int64(lsb)), imm(int64(d.bits(20, 16) + 1))}
+	case op1>>1 == 0xe && op2&3 == 0:
+		lsb, msb := d.bits(11, 7), d.bits(20, 16)
+		if msb < lsb {
+			return "", nil
+		}
+		if rm == 15 {
+			return d.op("bfc"), []string{armReg(rd), imm(int64(lsb)), imm(int64(msb - lsb + 1))}
+		}
+		return d.op("bfi"), []string{armReg(rd), armReg(rm), imm(int64(lsb)), imm(int64(msb - lsb + 1))}
+	case op1 == 0x1f && op2 == 7:
+		if d.cond != 14 {
+			return "", nil
+		}
+		return "udf", []string{imm(int64(d.bits(19, 8)<<4 | d.bits(3, 0)))}
+	}
+	return "", nil
+}
+
+var parallelOps = [8]string{"add16", "asx", "sax", "sub16", "add8", "", "", "sub8"}
+
+func (d *a32) parallel(unsigned bool, kind, op2, rd, rn, rm uint32) (string, []string) {
+	if kind == 0 || parallelOps[op2] == "" {
+		return "", nil
+	}
+	prefix := [2][4]string{{"", "s", "q", "sh"}, {"", "u", "uq", "uh"}}[b2i(unsigned)][kind]
+	return d.op(prefix + parallelOps[op2]), []string{armReg(rd), armReg(rn), armReg(rm)}
+}
+
+var extendOps = [8]string{"sxtb16", "", "sxtb", "sxth", "uxtb16", "", "uxtb", "uxth"}
+
+func (d *a32) packSat(op1, op2, rd, rn, rm uint32) (string, []string) {
+	switch {
+	case op1 == 0 && op2&1 == 0:
+		amount := d.bits(11, 7)
+		if op2&2 != 0 {
+			if amount == 0 {
+				amount = 32
+			}
+			return d.op("pkhtb"), []string{armReg(rd), armReg(rn), armReg(rm), fmt.Sprintf("asr #%d", amount)}
+		}
+		args := []string{armReg(rd), armReg(rn), armReg(rm)}
+		if amount != 0 {
+			args = append(args, fmt.Sprintf("lsl #%d", amount))
+		}
+		return d.op("pkhbt"), args
+	case op1&2 != 0 && op2&1 == 0:
+		name, sat := "ssat", d.bits(20, 16)+1
+		if op1&4 != 0 {
+			name, sat = "usat", d.bits(20, 16)
+		}
+		args := []string{armReg(rd), imm(int64(sat)), armReg(rm)}
+		if sh := armShift(d.bits(6, 5), d.bits(11, 7)); sh != "" && sh != "rrx" {
+			args = append(args, sh)
+		}
+		return d.op(name), args
+	case op1 == 2 && op2 == 1:
+		return d.op("ssat16"), []string{armReg(rd), imm(int64(d.bits(19, 16) + 1)), armReg(rm)}
+	case op1 == 6 && op2 == 1:
+		return d.op("usat16"), []string{armReg(rd), imm(int64(d.bits(19, 16))), armReg(rm)}
+	case op1 == 0 && op2 == 5:
+		return d.op("sel"), []string{armReg(rd), armReg(rn), armReg(rm)}
+	case op1 == 3 && op2 == 1:
+		return d.op("rev"), []string{armReg(rd), armReg(rm)}
+	case op1 == 3 && op2 == 5:
+		return d.op("rev16"), []string{armReg(rd), armReg(rm)}
+	case op1 == 7 && op2 == 1:
+		return d.op("rbit"), []string{armReg(rd), armReg(rm)}
+	case op1 == 7 && op2 == 5:
+		return d.op("revsh"), []string{armReg(rd), armReg(rm)}
+	case op2 == 3:
+		name := extendOps[op1]
+		if name == "" {
+			return "", nil
+		}
+		args := []string{armReg(rd)}
+		if rn != 15 {
+			name = name[:3] + "a" + name[3:]
+			args = append(args, armReg(rn))
+		}
+		args = append(args, armReg(rm))
+		if rot := d.bits(11, 10); rot != 0 {
+			args = append(args, fmt.Sprintf("ror #%d", rot*8))
+		}
+		return d.op(name), args
+	}
+	return "", nil
+}
+
+func (d *a32) signedMul(op1, op2, rd, ra, rm, rn uint32) (string, []string) {
+	x := ""
+	switch op1 {
+	case 0, 4:
+		if op2&4 != 0 {
+			return "", nil
+		}
+		if op2&1 != 0 {
+			x = "x"
+		}
+		long := op1 == 4
+		name := [2]string{"smlad", "smlsd"}[op2>>1&1]
+		if long {
+			name = [2]string{"smlald", "smlsld"}[op2>>1&1]
+			return d.op(name + x), []string{armReg(ra), armReg(rd), armReg(rn), armReg(rm)}
+		}
+		if ra == 15 {
+			name = [2]string{"smuad", "smusd"}[op2>>1&1]
+			return d.op(name + x), []string{armReg(rd), armReg(rn), armReg(rm)}
+		}
+		return d.op(name + x), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+	case 1, 3:
+		if op2 != 0 || ra != 15 {
+			return "", nil
+		}
+		name := "sdiv"
+		if op1 == 3 {
+			name = "udiv"
+		}
+		return d.op(name), []string{armReg(rd), armReg(rn), armReg(rm)}
+	case 5:
+		if op2&1 != 0 {
+			x = "r"
+		}
+		switch op2 >> 1 {
+		case 0:
+			if ra == 15 {
+				return d.op("smmul" + x), []string{armReg(rd), armReg(rn), armReg(rm)}
+			}
+			return d.op("smmla" + x), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+		case 3:
+			return d.op("smmls" + x), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+		}
+	}
+	return "", nil
+}
+
+// a32Uncond decodes the instructions with the condition field set to
+// 0b1111.
+func (d *a32) a32Uncond() (string, []string) {
+	w := d.w
+	switch {
+	case w&0xffffff00 == 0xf57ff000:
+		switch d.bits(7, 4) {
+		case 1:
+			if d.bits(3, 0) == 15 {
+				return "clrex", nil
+			}
+		case 4:
+			switch d.bits(3, 0) {
+			case 0:
+				return "ssbb", nil
+			case 4:
+				return "pssbb", nil
+			}
+			return armBarrier("dsb", d.bits(3, 0))
+		case 5:
+			return armBarrier("dmb", d.bits(3, 0))
+		case 6:
+			return armBarrier("isb", d.bits(3, 0))
+		case 7:
+			if d.bits(3, 0) == 0 {
+				return "sb", nil
+			}
+		}
+	case w&0xfffffdff == 0xf1010000:
+		return "setend", []string{[2]string{"le", "be"}[d.bits(9, 9)]}
+	case w&0xfff1fe20 == 0xf1000000:
+		return d.cps()
+	case w&0x0e000000 == 0x0a000000:
+		off := sext(d.bits(23, 0), 24)<<2 | int64(d.bits(24, 24))<<1
+		return "blx", []string{d.label(uint64(int64(d.pcValue()) + off))}
+	case w&0x0d700000 == 0x05500000, w&0x0d700010 == 0x07500000,
+		w&0x0d700000 == 0x05100000, w&0x0d700010 == 0x07100000,
+		w&0x0f700000 == 0x04500000, w&0x0f700010 == 0x06500000:
+		return d.preload()
+	case w&0x0e000000 == 0x0c000000, w&0x0f000000 == 0x0e000000:
+		if d.bits(11, 9) == 5 {
+			return d.vfpUncond()
+		}
+		return d.coproc("2")
+	}
+	return "", nil
+}
+
+func (d *a32) cps() (string, []string) {
+	imod, m := d.bits(19, 18), d.bit(17)
+	var args []string
+	name := "cps"
+	switch imod {
+	case 2, 3:
+		name = [2]string{"cpsie", "cpsid"}[imod-2]
+		flags := ""
+		for i, c := range "aif" {
+			if d.w>>(8-i)&1 != 0 {
+				flags += string(c)
+			}
+		}
+		if flags == "" {
+			flags = "none"
+		}
+		args = append(args, flags)
+	case 1:
+		return "", nil
+	default:
+		if !m {
+			return "", nil
+		}
+	}
+	if m {
+		args = append(args, imm(int64(d.bits(4, 0))))
+	}
+	return name, args
+}
+
+func (d *a32) preload() (string, []string) {
+	u, rn := d.bit(23), d.bits(19, 16)
+	name := "pli"
+	if d.bit(26) && d.bits(25, 24) != 0 || d.bits(27, 24) == 5 || d.bits(27, 24) == 7 {
+		name = "pld"
+		if !d.bit(22) {
+			name = "pldw"
+		}
+	}
+	var off string
+	if d.bit(25) {
+		off = armReg(d.bits(3, 0))
+		if !u {
+			off = "-" + off
+		}
+		if sh := armShift(d.bits(6, 5), d.bits(11, 7)); sh != "" {
+			off += ", " + sh
+		}
+	} else {
+		v := d.bits(11, 0)
+		off = armImm(v, u)
+		if u && v == 0 {
+			off = ""
+		}
+		if rn == 15 {
+			d.literal(armOff(v, u))
+		}
+	}
+	return name, armMem(rn, off, true, false)
+}
+
+// coproc decodes the generic coprocessor instructions. suffix is "2" for
+// the unconditional forms.
+func (d *a32) coproc(suffix string) (string, []string) {
+	cp := fmt.Sprintf("p%d", d.bits(11, 8))
+	rn, rt := d.bits(19, 16), d.bits(15, 12)
+	op := func(name string) string {
+		if suffix != "" {
+			return name + suffix
+		}
+		return d.op(name)
+	}
+	switch {
+	case d.bits(27, 24) == 14 && d.bit(4):
+		name, reg := "mcr", armReg(rt)
+		if d.bit(20) {
+			name = "mrc"
+			if rt == 15 {
+				reg = "apsr_nzcv"
+			}
+		}
+		return op(name), []string{cp, imm(int64(d.bits(23, 21))), reg, fmt.Sprintf("c%d", rn),
+			fmt.Sprintf("c%d", d.bits(3, 0)), imm(int64(d.bits(7, 5)))}
+	case d.bits(27, 24) == 14:
+		return op("cdp"), []string{cp, imm(int64(d.bits(23, 20))), fmt.Sprintf("c%d", rt), fmt.Sprintf("c%d", rn),
+			fmt.Sprintf("c%d", d.bits(3, 0)), imm(int64(d.bits(7, 5)))}
+	case d.bits(27, 21) == 0x62:
+		name := "mcrr"
+		if d.bit(20) {
+			name = "mrrc"
+		}
+		return op(name), []string{cp, imm(int64(d.bits(7, 4))), armReg(rt), armReg(rn), fmt.Sprintf("c%d", d.bits(3, 0))}
+	case d.bits(27, 25) == 6:
+		p, u, n, w := d.bit(24), d.bit(23), d.bit(22), d.bit(21)
+		if !p && !u && !w {
+			return "", nil
+		}
+		name := "stc"
+		if d.bit(20) {
+			name = "ldc"
+		}
+		if n {
+			name += "l"
+		}
+		if suffix != "" {
+			name = name[:3] + suffix + name[3:]
+		} else {
+			name = d.op(name)
+		}
+		v := d.bits(7, 0)
+		args := []string{cp, fmt.Sprintf("c%d", rt)}
+		if !p && !w {
+			return name, append(args, "["+armReg(rn)+"]", fmt.Sprintf("{%d}", v))
+		}
+		off := armImm(v*4, u)
+		if p && !w && u && v == 0 {
+			off = ""
+		}
+		return name, append(args, armMem(rn, off, p, w)...)
+	}
+	return "", nil
+}
+
+// vfp decodes the VFP floating-point instructions, which share their
+// encoding between A32 and T32.
+func (d *a32) vfp() (string, []string) {
+	dbl := d.bit(8)
+	vd := vfpReg(d.bits(15, 12), d.bits(22, 22), dbl)
+	vn := vfpReg(d.bits(19, 16), d.bits(7, 7), dbl)
+	vm := vfpReg(d.bits(3, 0), d.bits(5, 5), dbl)
+	dt := ".f32"
+	if dbl {
+		dt = ".f64"
+	}
+	op := func(name, dt string) string { return d.op(name) + dt }
+
+	switch {
+	case d.bits(27, 24) == 14 && !d.bit(4):
+		opc1 := d.bits(23, 23)<<2 | d.bits(21, 20)
+		o := d.bits(6, 6)
+		switch opc1 {
+		case 0:
+			return op([2]string{"vmla", "vmls"}[o], dt), []string{vd, vn, vm}
+		case 1:
+			return op([2]string{"vnmls", "vnmla"}[o], dt), []string{vd, vn, vm}
+		case 2:
+			return op([2]string{"vmul", "vnmul"}[o], dt), []string{vd, vn, vm}
+		case 3:
+			return op([2]string{"vadd", "vsub"}[o], dt), []string{vd, vn, vm}
+		case 4:
+			if o != 0 {
+				return "", nil
+			}
+			return op("vdiv", dt), []string{vd, vn, vm}
+		case 5:
+			return op([2]string{"vfnms", "vfnma"}[o], dt), []string{vd, vn, vm}
+		case 6:
+			return op([2]string{"vfma", "vfms"}[o], dt), []string{vd, vn, vm}
+		}
+		return d.vfpOther(dbl, dt, vd, vm)
+
+	case d.bits(27, 24) == 14:
+		return d.vfpTransfer()
+
+	case d.bits(27, 21) == 0x62:
+		if d.bits(7, 6) != 0 || !d.bit(4) {
+			return "", nil
+		}
+		rt, rt2 := armReg(d.bits(15, 12)), armReg(d.bits(19, 16))
+		var regs []string
+		if dbl {
+			regs = []string{vfpReg(d.bits(3, 0), d.bits(5, 5), true)}
+		} else {
+			m := d.bits(3, 0)<<1 | d.bits(5, 5)
+			if m == 31 {
+				return "", nil
+			}
+			regs = []string{fmt.Sprintf("s%d", m), fmt.Sprintf("s%d", m+1)}
+		}
+		if d.bit(20) {
+			return d.op("vmov"), append([]string{rt, rt2}, regs...)
+		}
+		return d.op("vmov"), append(regs, rt, rt2)
+
+	case d.bits(27, 25) == 6:
+		return d.vfpLoadStore(dbl, vd)
+	}
+	return "", nil
+}
+
+// vfpReg names S or D register n with its extra bit x.
+func vfpReg(n, x uint32, dbl bool) string {
+	if dbl {
+		return fmt.Sprintf("d%d", x<<4|n)
+	}
+	return fmt.Sprintf("s%d", n<<1|x)
+}
+
+// vfpImm expands the 8-bit floating-point immediate of VMOV.
+func vfpImm(imm8 uint32) string {
+	v := float64(16+imm8&15) / 16 * math.Pow(2, float64(int((imm8>>4)&7^4)-3))
+	if imm8&0x80 != 0 {
+		v = -v
+	}
+	return fmt.Sprintf("#%e", v)
+}
+
+func (d *a32) vfpOther(dbl bool, dt, vd, vm string) (string, []string) {
+	opc2, o7, o6 := d.bits(19, 16), d.bit(7), d.bit(6)
+	op := func(name, dt string) string { return d.op(name) + dt }
+	if !o6 {
+		if d.bits(7, 4) != 0 {
+			return "", nil
+		}
+		return op("vmov", dt), []string{vd, vfpImm(opc2<<4 | d.bits(3, 0))}
+	}
+	sd := vfpReg(d.bits(15, 12), d.bits(22, 22), false)
+	sm := vfpReg(d.bits(3, 0), d.bits(5, 5), false)
+	switch opc2 {
+	case 0:
+		return op([2]string{"vmov", "vabs"}[b2i(o7)], dt), []string{vd, vm}
+	case 1:
+		return op([2]string{"vneg", "vsqrt"}[b2i(o7)], dt), []string{vd, vm}
+	case 2, 3:
+		name := [2]string{"vcvtb", "vcvtt"}[b2i(o7)]
+		if dbl {
+			if opc2 == 2 {
+				return op(name, ".f64.f16"), []string{vd, sm}
+			}
+			return op(name, ".f16.f64"), []string{sd, vm}
+		}
+		if opc2 == 2 {
+			return op(name, ".f32.f16"), []string{vd, vm}
+		}
+		return op(name, ".f16.f32"), []string{vd, vm}
+	case 4, 5:
+		name := [2]string{"vcmp", "vcmpe"}[b2i(o7)]
+		if opc2 == 5 {
+			if d.bits(5, 5) != 0 || d.bits(3, 0) != 0 {
+				return "", nil
+			}
+			return op(name, dt), []string{vd, "#0"}
+		}
+		return op(name, dt), []string{vd, vm}
+	case 6:
+		return op([2]string{"vrintr", "vrintz"}[b2i(o7)], dt), []string{vd, vm}
+	case 7:
+		if o7 {
+			if dbl {
+				return op("vcvt", ".f32.f64"), []string{sd, vm}
+			}
+			return op("vcvt", ".f64.f32"), []string{vfpReg(d.bits(15, 12), d.bits(22, 22), true), vm}
+		}
+		return op("vrintx", dt), []string{vd, vm}
+	case 8:
+		src := [2]string{".u32", ".s32"}[b2i(o7)]
+		return op("vcvt", dt+src), []string{vd, sm}
+	case 10, 11, 14, 15:
+		sign := [2]string{".s", ".u"}[opc2&1]
+		size := uint32(16)
+		if o7 {
+			size = 32
+		}
+		fbits := int64(size) - int64(d.bits(3, 0)<<1|d.bits(5, 5))
+		fixed := fmt.Sprintf("%s%d", sign, size)
+		if opc2 >= 14 {
+			return op("vcvt", fixed+dt), []string{vd, vd, imm(fbits)}
+		}
+		return op("vcvt", dt+fixed), []string{vd, vd, imm(fbits)}
+	case 9:
+		if !o7 || !dbl {
+			return "", nil
+		}
+		return op("vjcvt", ".s32.f64"), []string{sd, vm}
+	case 12, 13:
+		dst := [2]string{".u32", ".s32"}[opc2&1]
+		name := "vcvtr"
+		if o7 {
+			name = "vcvt"
+		}
+		return op(name, dst+dt), []string{sd, vm}
+	}
+	return "", nil
+}
+
+var vfpSysRegs = map[uint32]string{
+	0: "fpsid", 1: "fpscr", 5: "mvfr2", 6: "mvfr1", 7: "mvfr0", 8: "fpexc",
+	9: "fpinst", 10: "fpinst2",
+}
+
+func (d *a32) vfpTransfer() (string, []string) {
+	l, a := d.bit(20), d.bits(23, 21)
+	rt := d.bits(15, 12)
+	if !d.bit(8) {
+		switch a {
+		case 0:
+			sn := vfpReg(d.bits(19, 16), d.bits(7, 7), false)
+			if l {
+				return d.op("vmov"), []string{armReg(rt), sn}
+			}
+			return d.op("vmov"), []string{sn, armReg(rt)}
+		case 7:
+			reg, ok := vfpSysRegs[d.bits(19, 16)]
+			if !ok {
+				return "", nil
+			}
+			if !l {
+				return d.op("vmsr"), []string{reg, armReg(rt)}
+			}
+			if rt == 15 && reg == "fpscr" {
+				return d.op("vmrs"), []string{"APSR_nzcv", reg}
+			}
+			return d.op("vmrs"), []string{armReg(rt), reg}
+		}
+		return "", nil
+	}
+	// Moves between a core register and a D register lane.
+	dn := d.bits(7, 7)<<4 | d.bits(19, 16)
+	opc := d.bits(22, 21)<<2 | d.bits(6, 5)
+	var size string
+	var idx uint32
+	switch {
+	case opc&8 != 0:
+		size, idx = "8", opc&7
+	case opc&1 != 0:
+		size, idx = "16", opc>>1&3
+	case opc&3 == 0:
+		size, idx = "32", opc>>2&1
+	default:
+		return "", nil
+	}
+	lane := fmt.Sprintf("d%d[%d]", dn, idx)
+	if l {
+		sign := ".s"
+		if d.bit(23) {
+			sign = ".u"
+		}
+		if size == "32" {
+			if d.bit(23) {
+				return "", nil
+			}
+			sign = "."
+		}
+		return d.op("vmov") + sign + size, []string{armReg(rt), lane}
+	}
+	if d.bit(23) {
+		return "", nil
+	}
+	return d.op("vmov") + "." + size, []string{lane, armReg(rt)}
+}
+
+func (d *a32) vfpLoadStore(dbl bool, vd string) (string, []string) {
+	p, u, w, l := d.bit(24), d.bit(23), d.bit(21), d.bit(20)
+	rn, v := d.bits(19, 16), d.bits(7, 0)
+
+	if p && !w {
+		name := "vstr"
+		if l {
+			name = "vldr"
+		}
+		off := armImm(v*4, u)
+		if u && v == 0 {
+			off = ""
+		}
+		if rn == 15 {
+			d.literal(armOff(v*4, u))
+		}
+		return d.op(name), append([]string{vd}, armMem(rn, off, true, false)...)
+	}
+	if p == u || (p && !w) {
+		return "", nil
+	}
+
+	// Register lists running past the last register are unpredictable;
+	// they are cut short the way other disassemblers print them.
+	first, last := d.bits(15, 12)<<1|d.bits(22, 22), uint32(32)
+	n, prefix, x := v, "s", ""
+	if dbl {
+		first, n, prefix = d.bits(22, 22)<<4|d.bits(15, 12), v/2, "d"
+		last = min(first+16, 32)
+		if v&1 != 0 {
+			x = "x"
+		}
+	}
+	n = max(n, 1)
+	var regs []string
+	for i := first; i < first+n && i < last; i++ {
+		regs = append(regs, fmt.Sprintf("%s%d", prefix, i))
+	}
+	list := "{" + strings.Join(regs, ", ") + "}"
+	if rn == 13 && w && x == "" && (p && !l || !p && l) {
+		name := "vpush"
+		if l {
+			name = "vpop"
+		}
+		return d.op(name), []string{list}
+	}
+	name := "vstm"
+	if l {
+		name = "vldm"
+	}
+	if x != "" {
+		name = "f" + name[1:]
+	}
+	name = d.op(name + [2]string{"ia", "db"}[b2i(p)] + x)
+	base := armReg(rn)
+	if w {
+		base += "!"
+	}
+	return name, []string{base, list}
+}
+
+// vfpUncond decodes the ARMv8 floating-point instructions that have no
+// condition field: VSEL, VMAXNM, VMINNM and the directed roundings.
+func (d *a32) vfpUncond() (string, []string) {
+	if d.bits(27, 24) != 14 || d.bit(4) {
+		return "", nil
+	}
+	dbl := d.bit(8)
+	vd := vfpReg(d.bits(15, 12), d.bits(22, 22), dbl)
+	vn := vfpReg(d.bits(19, 16), d.bits(7, 7), dbl)
+	vm := vfpReg(d.bits(3, 0), d.bits(5, 5), dbl)
+	dt := ".f32"
+	if dbl {
+		dt = ".f64"
+	}
+	switch {
+	case !d.bit(23) && !d.bit(6):
+		cc := [4]string{"eq", "vs", "ge", "gt"}[d.bits(21, 20)]
+		return "vsel" + cc + dt, []string{vd, vn, vm}
+	case d.bits(21, 20) == 0:
+		return [2]string{"vmaxnm", "vminnm"}[d.bits(6, 6)] + dt, []string{vd, vn, vm}
+	case d.bits(21, 18) == 0xe && d.bit(6):
+		rm := "anpm"[d.bits(17, 16)]
+		if d.bit(7) {
+			return "", nil
+		}
+		return fmt.Sprintf("vrint%c%s", rm, dt), []string{vd, vm}
+	case d.bits(21, 18) == 0xf && d.bit(6):
+		rm := "anpm"[d.bits(17, 16)]
+		sign := [2]string{".u32", ".s32"}[d.bits(7, 7)]
+		return fmt.Sprintf("vcvt%c%s%s", rm, sign, dt), []string{vfpReg(d.bits(15, 12), d.bits(22, 22), false), vm}
+	}
+	return "", nil
+}
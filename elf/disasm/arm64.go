@@ -0,0 +1,967 @@
+package disasm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// a64 decodes a single A64 instruction. The output follows the LLVM
+// assembler syntax, which is also what GNU objdump prints for AArch64
+// apart from a few immediate formats.
+type a64 struct {
+	w      uint32
+	pc     uint64
+	opts   Options
+	target uint64
+	hasTgt bool
+}
+
+func decodeARM64(code []byte, pc uint64, opts Options) (Inst, error) {
+	if len(code) < 4 {
+		return Inst{Addr: pc, Len: len(code), Text: "(bad)"}, ErrBadInst
+	}
+	d := &a64{w: binary.LittleEndian.Uint32(code), pc: pc, opts: opts}
+	op, args := d.decode()
+	if op == "" {
+		return Inst{Addr: pc, Len: 4, Text: fmt.Sprintf(".inst\t0x%08x", d.w)}, ErrBadInst
+	}
+	return Inst{
+		Addr:      pc,
+		Len:       4,
+		Text:      armText(op, args),
+		Target:    d.target,
+		HasTarget: d.hasTgt,
+	}, nil
+}
+
+// armText joins a mnemonic and its operands the way ARM disassemblers
+// print them.
+func armText(op string, args []string) string {
+	if len(args) == 0 {
+		return op
+	}
+	return op + "\t" + strings.Join(args, ", ")
+}
+
+func (d *a64) bits(hi, lo uint) uint32 {
+	return (d.w >> lo) & (1<<(hi-lo+1) - 1)
+}
+
+func (d *a64) bit(n uint) bool {
+	return d.w>>n&1 != 0
+}
+
+// label formats a PC-relative code or data address.
+func (d *a64) label(off int64) string {
+	d.target = d.pc + uint64(off)
+	d.hasTgt = true
+	return symbolic(d.target, d.opts)
+}
+
+// sext sign extends the low n bits of v.
+func sext(v uint32, n uint) int64 {
+	return int64(int32(v<<(32-n)) >> (32 - n))
+}
+
+func xr(n uint32, sf bool) string {
+	switch {
+	case n == 31 && sf:
+		return "xzr"
+	case n == 31:
+		return "wzr"
+	case sf:
+		return fmt.Sprintf("x%d", n)
+	}
+	return fmt.Sprintf("w%d", n)
+}
+
+func xrsp(n uint32, sf bool) string {
+	switch {
+	case n == 31 && sf:
+		return "sp"
+	case n == 31:
+		return "wsp"
+	}
+	return xr(n, sf)
+}
+
+func imm(v int64) string {
+	return fmt.Sprintf("#%d", v)
+}
+
+func hexImm(v uint64) string {
+	if v == 0 {
+		return "#0"
+	}
+	return fmt.Sprintf("#0x%x", v)
+}
+
+var a64Cond = [16]string{
+	"eq", "ne", "hs", "lo", "mi", "pl", "vs", "vc",
+	"hi", "ls", "ge", "lt", "gt", "le", "al", "nv",
+}
+
+var a64Shift = [4]string{"lsl", "lsr", "asr", "ror"}
+
+var a64Extend = [8]string{"uxtb", "uxth", "uxtw", "uxtx", "sxtb", "sxth", "sxtw", "sxtx"}
+
+func (d *a64) decode() (string, []string) {
+	switch d.bits(28, 25) {
+	case 0b0000:
+		if d.bits(31, 16) == 0 {
+			return "udf", []string{imm(int64(d.bits(15, 0)))}
+		}
+	case 0b1000, 0b1001:
+		return d.dpImm()
+	case 0b1010, 0b1011:
+		return d.branchSys()
+	case 0b0100, 0b0110, 0b1100, 0b1110:
+		return d.loadStore()
+	case 0b0101, 0b1101:
+		return d.dpReg()
+	case 0b0111, 0b1111:
+		return d.simd()
+	}
+	return "", nil
+}
+
+// dpImm decodes the data processing (immediate) group.
+func (d *a64) dpImm() (string, []string) {
+	sf := d.bit(31)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+	size := uint(32)
+	if sf {
+		size = 64
+	}
+
+	switch d.bits(25, 23) {
+	case 0b000, 0b001:
+		off := sext(d.bits(23, 5)<<2|d.bits(30, 29), 21)
+		if !d.bit(31) {
+			return "adr", []string{xr(rd, true), d.label(off)}
+		}
+		page := int64(d.pc&^0xfff) - int64(d.pc)
+		return "adrp", []string{xr(rd, true), d.label(page + off<<12)}
+
+	case 0b010:
+		sub, s := d.bit(30), d.bit(29)
+		v := int64(d.bits(21, 10))
+		args := []string{imm(v)}
+		if d.bit(22) {
+			args = append(args, "lsl #12")
+		}
+		switch {
+		case !sub && !s && v == 0 && !d.bit(22) && (rd == 31 || rn == 31):
+			return "mov", []string{xrsp(rd, sf), xrsp(rn, sf)}
+		case s && rd == 31:
+			op := "cmn"
+			if sub {
+				op = "cmp"
+			}
+			return op, append([]string{xrsp(rn, sf)}, args...)
+		}
+		op := map[bool]string{false: "add", true: "sub"}[sub]
+		dst := xrsp(rd, sf)
+		if s {
+			op += "s"
+			dst = xr(rd, sf)
+		}
+		return op, append([]string{dst, xrsp(rn, sf)}, args...)
+
+	case 0b100:
+		n := d.bits(22, 22)
+		if !sf && n != 0 {
+			return "", nil
+		}
+		v, ok := decodeBitMask(n, d.bits(15, 10), d.bits(21, 16), size)
+		if !ok {
+			return "", nil
+		}
+		opc := d.bits(30, 29)
+		switch {
+		case opc == 3 && rd == 31:
+			return "tst", []string{xr(rn, sf), hexImm(v)}
+		case opc == 1 && rn == 31 && !movzAlias(v, size) && !movnAlias(v, size):
+			return "mov", []string{xrsp(rd, sf), signedImm(v, sf)}
+		}
+		op := [4]string{"and", "orr", "eor", "ands"}[opc]
+		dst := xrsp(rd, sf)
+		if opc == 3 {
+			dst = xr(rd, sf)
+		}
+		return op, []string{dst, xr(rn, sf), hexImm(v)}
+
+	case 0b101:
+		hw := d.bits(22, 21)
+		if !sf && hw >= 2 {
+			return "", nil
+		}
+		v := uint64(d.bits(20, 5))
+		shift := hw * 16
+		var args []string
+		switch d.bits(30, 29) {
+		case 0:
+			if !(v == 0 && hw != 0) && (sf || v != 0xffff) {
+				return "mov", []string{xr(rd, sf), signedImm(^(v << shift), sf)}
+			}
+			args = []string{xr(rd, sf), imm(int64(v))}
+			if shift != 0 {
+				args = append(args, fmt.Sprintf("lsl #%d", shift))
+			}
+			return "movn", args
+		case 2:
+			if !(v == 0 && hw != 0) {
+				return "mov", []string{xr(rd, sf), signedImm(v<<shift, sf)}
+			}
+			return "movz", []string{xr(rd, sf), imm(int64(v)), fmt.Sprintf("lsl #%d", shift)}
+		case 3:
+			args = []string{xr(rd, sf), imm(int64(v))}
+			if shift != 0 {
+				args = append(args, fmt.Sprintf("lsl #%d", shift))
+			}
+			return "movk", args
+		}
+
+	case 0b110:
+		if d.bit(22) != sf {
+			return "", nil
+		}
+		immr, imms := d.bits(21, 16), d.bits(15, 10)
+		if !sf && (immr >= 32 || imms >= 32) {
+			return "", nil
+		}
+		return d.bitfield(d.bits(30, 29), sf, rd, rn, immr, imms, uint32(size))
+
+	case 0b111:
+		if d.bits(30, 29) != 0 || d.bit(22) != sf || d.bit(21) {
+			return "", nil
+		}
+		rm, lsb := d.bits(20, 16), d.bits(15, 10)
+		if !sf && lsb >= 32 {
+			return "", nil
+		}
+		if rn == rm {
+			return "ror", []string{xr(rd, sf), xr(rn, sf), imm(int64(lsb))}
+		}
+		return "extr", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf), imm(int64(lsb))}
+	}
+	return "", nil
+}
+
+func (d *a64) bitfield(opc uint32, sf bool, rd, rn, immr, imms, size uint32) (string, []string) {
+	ops := func(op string, a, b int64) (string, []string) {
+		return op, []string{xr(rd, sf), xr(rn, sf), imm(a), imm(b)}
+	}
+	switch opc {
+	case 0:
+		switch {
+		case imms == size-1:
+			return "asr", []string{xr(rd, sf), xr(rn, sf), imm(int64(immr))}
+		case immr == 0 && imms == 7:
+			return "sxtb", []string{xr(rd, sf), xr(rn, false)}
+		case immr == 0 && imms == 15:
+			return "sxth", []string{xr(rd, sf), xr(rn, false)}
+		case immr == 0 && imms == 31 && sf:
+			return "sxtw", []string{xr(rd, sf), xr(rn, false)}
+		case imms < immr:
+			return ops("sbfiz", int64(size-immr), int64(imms+1))
+		}
+		return ops("sbfx", int64(immr), int64(imms-immr+1))
+	case 1:
+		if rn == 31 && (imms < immr || immr == 0) {
+			return "bfc", []string{xr(rd, sf), imm(int64((size - immr) % size)), imm(int64(imms + 1))}
+		}
+		if imms < immr {
+			return ops("bfi", int64(size-immr), int64(imms+1))
+		}
+		return ops("bfxil", int64(immr), int64(imms-immr+1))
+	case 2:
+		switch {
+		case imms == size-1:
+			return "lsr", []string{xr(rd, sf), xr(rn, sf), imm(int64(immr))}
+		case imms+1 == immr:
+			return "lsl", []string{xr(rd, sf), xr(rn, sf), imm(int64(size - 1 - imms))}
+		case immr == 0 && imms == 7 && !sf:
+			return "uxtb", []string{xr(rd, sf), xr(rn, false)}
+		case immr == 0 && imms == 15 && !sf:
+			return "uxth", []string{xr(rd, sf), xr(rn, false)}
+		case imms < immr:
+			return ops("ubfiz", int64(size-immr), int64(imms+1))
+		}
+		return ops("ubfx", int64(immr), int64(imms-immr+1))
+	}
+	return "", nil
+}
+
+// decodeBitMask expands the N:immr:imms encoding of a logical immediate.
+func decodeBitMask(n, imms, immr uint32, size uint) (uint64, bool) {
+	combined := n<<6 | (^imms & 0x3f)
+	if combined == 0 {
+		return 0, false
+	}
+	length := bits.Len32(combined) - 1
+	if length < 1 {
+		return 0, false
+	}
+	esize := uint(1) << length
+	levels := uint32(esize - 1)
+	s, r := imms&levels, immr&levels
+	if s == levels {
+		return 0, false
+	}
+	elem := uint64(1)<<(s+1) - 1
+	if r != 0 {
+		mask := uint64(1)<<esize - 1
+		if esize == 64 {
+			mask = ^uint64(0)
+		}
+		elem = (elem>>r | elem<<(esize-uint(r))) & mask
+	}
+	for e := esize; e < size; e *= 2 {
+		elem |= elem << e
+	}
+	if size == 32 {
+		elem &= 0xffffffff
+	}
+	return elem, true
+}
+
+// movzAlias reports whether v can be built by a single MOVZ, in which
+// case a MOV alias of ORR is not used.
+func movzAlias(v uint64, size uint) bool {
+	for shift := uint(0); shift < size; shift += 16 {
+		if v&^(0xffff<<shift) == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func movnAlias(v uint64, size uint) bool {
+	if size == 32 {
+		v = ^v & 0xffffffff
+	} else {
+		v = ^v
+	}
+	return movzAlias(v, size)
+}
+
+func signedImm(v uint64, sf bool) string {
+	if sf {
+		return imm(int64(v))
+	}
+	return imm(int64(int32(uint32(v))))
+}
+
+// branchSys decodes branches, exception generation and system
+// instructions.
+func (d *a64) branchSys() (string, []string) {
+	w := d.w
+	switch {
+	case w&0xff000010 == 0x54000000:
+		return "b." + a64Cond[d.bits(3, 0)], []string{d.label(sext(d.bits(23, 5), 19) << 2)}
+
+	case w&0xff000000 == 0xd4000000:
+		return d.exception()
+
+	case w&0xffc00000 == 0xd5000000:
+		return d.system()
+
+	case w&0xfe000000 == 0xd6000000:
+		return d.branchReg()
+
+	case w&0x7c000000 == 0x14000000:
+		op := "b"
+		if d.bit(31) {
+			op = "bl"
+		}
+		return op, []string{d.label(sext(d.bits(25, 0), 26) << 2)}
+
+	case w&0x7e000000 == 0x34000000:
+		op := "cbz"
+		if d.bit(24) {
+			op = "cbnz"
+		}
+		return op, []string{xr(d.bits(4, 0), d.bit(31)), d.label(sext(d.bits(23, 5), 19) << 2)}
+
+	case w&0x7e000000 == 0x36000000:
+		op := "tbz"
+		if d.bit(24) {
+			op = "tbnz"
+		}
+		b := d.bits(31, 31)<<5 | d.bits(23, 19)
+		return op, []string{xr(d.bits(4, 0), d.bit(31)), imm(int64(b)), d.label(sext(d.bits(18, 5), 14) << 2)}
+	}
+	return "", nil
+}
+
+func (d *a64) exception() (string, []string) {
+	v := d.bits(20, 5)
+	if d.bits(4, 2) != 0 {
+		return "", nil
+	}
+	ll := d.bits(1, 0)
+	switch d.bits(23, 21) {
+	case 0:
+		if ll == 0 {
+			return "", nil
+		}
+		return [4]string{"", "svc", "hvc", "smc"}[ll], []string{hexImm(uint64(v))}
+	case 1:
+		if ll == 0 {
+			return "brk", []string{hexImm(uint64(v))}
+		}
+	case 2:
+		if ll == 0 {
+			return "hlt", []string{hexImm(uint64(v))}
+		}
+	case 5:
+		if ll != 0 {
+			op := fmt.Sprintf("dcps%d", ll)
+			if v == 0 {
+				return op, nil
+			}
+			return op, []string{hexImm(uint64(v))}
+		}
+	}
+	return "", nil
+}
+
+var a64Hints = map[uint32]string{
+	0: "nop", 1: "yield", 2: "wfe", 3: "wfi", 4: "sev", 5: "sevl",
+	7: "xpaclri", 8: "pacia1716", 10: "pacib1716", 12: "autia1716", 14: "autib1716",
+	16: "esb", 17: "psb csync", 18: "tsb csync", 20: "csdb",
+	24: "paciaz", 25: "paciasp", 26: "pacibz", 27: "pacibsp",
+	28: "autiaz", 29: "autiasp", 30: "autibz", 31: "autibsp",
+	32: "bti", 34: "bti c", 36: "bti j", 38: "bti jc",
+}
+
+var a64Barriers = map[uint32]string{
+	1: "oshld", 2: "oshst", 3: "osh", 5: "nshld", 6: "nshst", 7: "nsh",
+	9: "ishld", 10: "ishst", 11: "ish", 13: "ld", 14: "st", 15: "sy",
+}
+
+func (d *a64) system() (string, []string) {
+	l := d.bit(21)
+	op0, op1 := d.bits(20, 19), d.bits(18, 16)
+	crn, crm, op2, rt := d.bits(15, 12), d.bits(11, 8), d.bits(7, 5), d.bits(4, 0)
+
+	switch {
+	case !l && op0 == 0 && crn == 2 && op1 == 3 && rt == 31:
+		hint := crm<<3 | op2
+		if name, ok := a64Hints[hint]; ok {
+			if i := strings.IndexByte(name, ' '); i > 0 {
+				return name[:i], []string{name[i+1:]}
+			}
+			return name, nil
+		}
+		return "hint", []string{imm(int64(hint))}
+
+	case !l && op0 == 0 && crn == 3 && op1 == 3 && rt == 31:
+		switch op2 {
+		case 2:
+			if crm == 15 {
+				return "clrex", nil
+			}
+			return "clrex", []string{imm(int64(crm))}
+		case 4:
+			switch crm {
+			case 0:
+				return "ssbb", nil
+			case 4:
+				return "pssbb", nil
+			}
+			return "dsb", []string{barrier(crm)}
+		case 5:
+			return "dmb", []string{barrier(crm)}
+		case 6:
+			if crm == 15 {
+				return "isb", nil
+			}
+			return "isb", []string{imm(int64(crm))}
+		case 7:
+			if crm == 0 {
+				return "sb", nil
+			}
+		}
+
+	case !l && op0 == 0 && crn == 4 && rt == 31:
+		field := map[uint32]string{
+			0x05: "spsel", 0x1e: "daifset", 0x1f: "daifclr", 0x03: "uao",
+			0x04: "pan", 0x19: "ssbs", 0x1a: "dit", 0x1c: "tco",
+		}[op1<<3|op2]
+		if field == "" {
+			break
+		}
+		return "msr", []string{field, imm(int64(crm))}
+
+	case op0 == 1:
+		if !l {
+			if name, ok := a64SysAlias(op1, crn, crm, op2); ok {
+				if rt == 31 && strings.HasPrefix(name, "ic i") && name != "ic ivau" {
+					return "ic", []string{name[3:]}
+				}
+				i := strings.IndexByte(name, ' ')
+				return name[:i], []string{name[i+1:], xr(rt, true)}
+			}
+			args := []string{imm(int64(op1)), fmt.Sprintf("c%d", crn), fmt.Sprintf("c%d", crm), imm(int64(op2))}
+			if rt != 31 {
+				args = append(args, xr(rt, true))
+			}
+			return "sys", args
+		}
+		return "sysl", []string{xr(rt, true), imm(int64(op1)), fmt.Sprintf("c%d", crn), fmt.Sprintf("c%d", crm), imm(int64(op2))}
+
+	}
+	if op0 == 1 {
+		return "", nil
+	}
+	reg := a64SysReg(op0, op1, crn, crm, op2)
+	if l {
+		return "mrs", []string{xr(rt, true), reg}
+	}
+	return "msr", []string{reg, xr(rt, true)}
+}
+
+func barrier(crm uint32) string {
+	if name, ok := a64Barriers[crm]; ok {
+		return name
+	}
+	return imm(int64(crm))
+}
+
+func a64SysAlias(op1, crn, crm, op2 uint32) (string, bool) {
+	if crn != 7 {
+		return "", false
+	}
+	name, ok := map[uint32]string{
+		0x0061: "dc ivac", 0x0062: "dc isw", 0x00a2: "dc csw", 0x00e2: "dc cisw",
+		0x3041: "dc zva", 0x30a1: "dc cvac", 0x30b1: "dc cvau", 0x30e1: "dc civac",
+		0x30c1: "dc cvap", 0x30d1: "dc cvadp",
+		0x0010: "ic ialluis", 0x0050: "ic iallu", 0x3051: "ic ivau",
+	}[op1<<12|crm<<4|op2]
+	return name, ok
+}
+
+var a64SysRegs = map[uint32]string{
+	0xc000: "MIDR_EL1", 0xc005: "MPIDR_EL1", 0xc006: "REVIDR_EL1",
+	0xc020: "ID_AA64PFR0_EL1", 0xc021: "ID_AA64PFR1_EL1", 0xc024: "ID_AA64ZFR0_EL1",
+	0xc028: "ID_AA64DFR0_EL1", 0xc030: "ID_AA64ISAR0_EL1", 0xc031: "ID_AA64ISAR1_EL1",
+	0xc038: "ID_AA64MMFR0_EL1", 0xc039: "ID_AA64MMFR1_EL1", 0xc03a: "ID_AA64MMFR2_EL1",
+	0xc080: "SCTLR_EL1", 0xc082: "CPACR_EL1",
+	0xc100: "TTBR0_EL1", 0xc101: "TTBR1_EL1", 0xc102: "TCR_EL1",
+	0xc200: "SPSR_EL1", 0xc201: "ELR_EL1", 0xc208: "SP_EL0", 0xc212: "CurrentEL",
+	0xc290: "ESR_EL1", 0xc300: "FAR_EL1", 0xc3a0: "PAR_EL1",
+	0xc510: "MAIR_EL1", 0xc600: "VBAR_EL1",
+	0xc681: "CONTEXTIDR_EL1", 0xc684: "TPIDR_EL1", 0xc708: "CNTKCTL_EL1",
+	0xd801: "CTR_EL0", 0xd807: "DCZID_EL0",
+	0xd920: "RNDR", 0xd921: "RNDRRS",
+	0xda10: "NZCV", 0xda11: "DAIF", 0xda15: "DIT", 0xda16: "SSBS", 0xda17: "TCO",
+	0xda20: "FPCR", 0xda21: "FPSR",
+	0xdce8: "PMCCNTR_EL0",
+	0xde82: "TPIDR_EL0", 0xde83: "TPIDRRO_EL0",
+	0xdf00: "CNTFRQ_EL0", 0xdf01: "CNTPCT_EL0", 0xdf02: "CNTVCT_EL0",
+	0xdf19: "CNTV_CTL_EL0", 0xdf1a: "CNTV_CVAL_EL0",
+	0x8012: "MDSCR_EL1",
+}
+
+func a64SysReg(op0, op1, crn, crm, op2 uint32) string {
+	key := op0<<14 | op1<<11 | crn<<7 | crm<<3 | op2
+	if name, ok := a64SysRegs[key]; ok {
+		return name
+	}
+	return fmt.Sprintf("S%d_%d_C%d_C%d_%d", op0, op1, crn, crm, op2)
+}
+
+func (d *a64) branchReg() (string, []string) {
+	opc, op2, op3 := d.bits(24, 21), d.bits(20, 16), d.bits(15, 10)
+	rn, op4 := d.bits(9, 5), d.bits(4, 0)
+	if op2 != 31 {
+		return "", nil
+	}
+	switch {
+	case op3 == 0 && op4 == 0:
+		switch opc {
+		case 0:
+			return "br", []string{xr(rn, true)}
+		case 1:
+			return "blr", []string{xr(rn, true)}
+		case 2:
+			if rn == 30 {
+				return "ret", nil
+			}
+			return "ret", []string{xr(rn, true)}
+		case 4:
+			if rn == 31 {
+				return "eret", nil
+			}
+		case 5:
+			if rn == 31 {
+				return "drps", nil
+			}
+		}
+	case (op3 == 2 || op3 == 3) && op4 == 31:
+		key := "a"
+		if op3 == 3 {
+			key = "b"
+		}
+		switch opc {
+		case 0:
+			return "bra" + key + "z", []string{xr(rn, true)}
+		case 1:
+			return "blra" + key + "z", []string{xr(rn, true)}
+		case 2:
+			if rn == 31 {
+				return "reta" + key, nil
+			}
+		case 4:
+			if rn == 31 {
+				return "ereta" + key, nil
+			}
+		case 8:
+			return "bra" + key, []string{xr(rn, true), xrsp(op4, true)}
+		case 9:
+			return "blra" + key, []string{xr(rn, true), xrsp(op4, true)}
+		}
+	case op3 == 2 || op3 == 3:
+		key := "a"
+		if op3 == 3 {
+			key = "b"
+		}
+		switch opc {
+		case 8:
+			return "bra" + key, []string{xr(rn, true), xrsp(op4, true)}
+		case 9:
+			return "blra" + key, []string{xr(rn, true), xrsp(op4, true)}
+		}
+	}
+	return "", nil
+}
+
+// dpReg decodes the data processing (register) group.
+func (d *a64) dpReg() (string, []string) {
+	sf := d.bit(31)
+	rd, rn, rm := d.bits(4, 0), d.bits(9, 5), d.bits(20, 16)
+
+	if !d.bit(28) {
+		if !d.bit(24) {
+			return d.logicalReg(sf, rd, rn, rm)
+		}
+		if !d.bit(21) {
+			return d.addSubShifted(sf, rd, rn, rm)
+		}
+		return d.addSubExtended(sf, rd, rn, rm)
+	}
+
+	switch op2 := d.bits(24, 21); {
+	case op2 == 0:
+		switch op3 := d.bits(15, 10); {
+		case op3&0x1f == 1 && d.bits(31, 29) == 5 && d.bit(4) == false:
+			return "rmif", []string{xr(rn, true), imm(int64(d.bits(20, 15))), imm(int64(d.bits(3, 0)))}
+		case op3&0xf == 2 && d.bits(31, 29) == 1 && d.bits(20, 15) == 0 && d.bits(4, 0) == 13:
+			return [2]string{"setf8", "setf16"}[d.bits(14, 14)], []string{xr(rn, false)}
+		case op3 != 0:
+			return "", nil
+		}
+		op := [4]string{"adc", "adcs", "sbc", "sbcs"}[d.bits(30, 29)]
+		if d.bit(30) && rn == 31 {
+			return "ngc" + op[3:], []string{xr(rd, sf), xr(rm, sf)}
+		}
+		return op, []string{xr(rd, sf), xr(rn, sf), xr(rm, sf)}
+
+	case op2 == 2:
+		if !d.bit(29) || d.bit(10) || d.bit(4) {
+			return "", nil
+		}
+		op := "ccmn"
+		if d.bit(30) {
+			op = "ccmp"
+		}
+		second := xr(rm, sf)
+		if d.bit(11) {
+			second = imm(int64(rm))
+		}
+		return op, []string{xr(rn, sf), second, imm(int64(d.bits(3, 0))), a64Cond[d.bits(15, 12)]}
+
+	case op2 == 4:
+		if d.bit(29) || d.bit(11) {
+			return "", nil
+		}
+		return d.condSelect(sf, rd, rn, rm)
+
+	case op2 == 6:
+		if d.bit(29) {
+			return "", nil
+		}
+		if d.bit(30) {
+			return d.dp1(sf, rd, rn)
+		}
+		return d.dp2(sf, rd, rn, rm)
+
+	case op2 >= 8:
+		return d.dp3(sf, rd, rn, rm)
+	}
+	return "", nil
+}
+
+func (d *a64) shiftArg(shift, amount uint32) []string {
+	if shift == 0 && amount == 0 {
+		return nil
+	}
+	return []string{fmt.Sprintf("%s #%d", a64Shift[shift], amount)}
+}
+
+func (d *a64) logicalReg(sf bool, rd, rn, rm uint32) (string, []string) {
+	amount := d.bits(15, 10)
+	if !sf && amount >= 32 {
+		return "", nil
+	}
+	shift := d.bits(23, 22)
+	opc, n := d.bits(30, 29), d.bit(21)
+	sh := d.shiftArg(shift, amount)
+	switch {
+	case opc == 1 && !n && rn == 31 && shift == 0 && amount == 0:
+		return "mov", []string{xr(rd, sf), xr(rm, sf)}
+	case opc == 1 && n && rn == 31:
+		return "mvn", append([]string{xr(rd, sf), xr(rm, sf)}, sh...)
+	case opc == 3 && !n && rd == 31:
+		return "tst", append([]string{xr(rn, sf), xr(rm, sf)}, sh...)
+	}
+	ops := [2][4]string{{"and", "orr", "eor", "ands"}, {"bic", "orn", "eon", "bics"}}
+	i := 0
+	if n {
+		i = 1
+	}
+	return ops[i][opc], append([]string{xr(rd, sf), xr(rn, sf), xr(rm, sf)}, sh...)
+}
+
+func (d *a64) addSubShifted(sf bool, rd, rn, rm uint32) (string, []string) {
+	shift, amount := d.bits(23, 22), d.bits(15, 10)
+	if shift == 3 || (!sf && amount >= 32) {
+		return "", nil
+	}
+	sub, s := d.bit(30), d.bit(29)
+	sh := d.shiftArg(shift, amount)
+	switch {
+	case s && rd == 31:
+		op := "cmn"
+		if sub {
+			op = "cmp"
+		}
+		return op, append([]string{xr(rn, sf), xr(rm, sf)}, sh...)
+	case sub && rn == 31:
+		op := "neg"
+		if s {
+			op = "negs"
+		}
+		return op, append([]string{xr(rd, sf), xr(rm, sf)}, sh...)
+	}
+	op := [2][2]string{{"add", "adds"}, {"sub", "subs"}}[b2i(sub)][b2i(s)]
+	return op, append([]string{xr(rd, sf), xr(rn, sf), xr(rm, sf)}, sh...)
+}
+
+func b2i(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func (d *a64) addSubExtended(sf bool, rd, rn, rm uint32) (string, []string) {
+	if d.bits(23, 22) != 0 {
+		return "", nil
+	}
+	option, amount := d.bits(15, 13), d.bits(12, 10)
+	if amount > 4 {
+		return "", nil
+	}
+	sub, s := d.bit(30), d.bit(29)
+
+	ext := a64Extend[option]
+	lslOption := uint32(2)
+	if sf {
+		lslOption = 3
+	}
+	if option == lslOption && (rn == 31 || (rd == 31 && !s)) {
+		ext = "lsl"
+	}
+	var extArg []string
+	switch {
+	case ext == "lsl" && amount == 0:
+	case amount == 0:
+		extArg = []string{ext}
+	default:
+		extArg = []string{fmt.Sprintf("%s #%d", ext, amount)}
+	}
+	m := xr(rm, sf && option&3 == 3)
+
+	if s && rd == 31 {
+		op := "cmn"
+		if sub {
+			op = "cmp"
+		}
+		return op, append([]string{xrsp(rn, sf), m}, extArg...)
+	}
+	op := [2][2]string{{"add", "adds"}, {"sub", "subs"}}[b2i(sub)][b2i(s)]
+	dst := xrsp(rd, sf)
+	if s {
+		dst = xr(rd, sf)
+	}
+	return op, append([]string{dst, xrsp(rn, sf), m}, extArg...)
+}
+
+func (d *a64) condSelect(sf bool, rd, rn, rm uint32) (string, []string) {
+	cond := d.bits(15, 12)
+	inv := a64Cond[cond^1]
+	switch d.bits(30, 30)<<1 | d.bits(10, 10) {
+	case 0:
+		return "csel", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf), a64Cond[cond]}
+	case 1:
+		switch {
+		case rm == 31 && rn == 31 && cond < 14:
+			return "cset", []string{xr(rd, sf), inv}
+		case rm != 31 && rn != 31 && rn == rm && cond < 14:
+			return "cinc", []string{xr(rd, sf), xr(rn, sf), inv}
+		}
+		return "csinc", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf), a64Cond[cond]}
+	case 2:
+		switch {
+		case rm == 31 && rn == 31 && cond < 14:
+			return "csetm", []string{xr(rd, sf), inv}
+		case rm != 31 && rn != 31 && rn == rm && cond < 14:
+			return "cinv", []string{xr(rd, sf), xr(rn, sf), inv}
+		}
+		return "csinv", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf), a64Cond[cond]}
+	}
+	if rn == rm && cond < 14 {
+		return "cneg", []string{xr(rd, sf), xr(rn, sf), inv}
+	}
+	return "csneg", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf), a64Cond[cond]}
+}
+
+func (d *a64) dp1(sf bool, rd, rn uint32) (string, []string) {
+	opc2, opc := d.bits(20, 16), d.bits(15, 10)
+	switch opc2 {
+	case 0:
+		var op string
+		switch opc {
+		case 0:
+			op = "rbit"
+		case 1:
+			op = "rev16"
+		case 2:
+			op = "rev"
+			if sf {
+				op = "rev32"
+			}
+		case 3:
+			if !sf {
+				return "", nil
+			}
+			op = "rev"
+		case 4:
+			op = "clz"
+		case 5:
+			op = "cls"
+		default:
+			return "", nil
+		}
+		return op, []string{xr(rd, sf), xr(rn, sf)}
+	case 1:
+		if !sf {
+			return "", nil
+		}
+		names := [8]string{"pacia", "pacib", "pacda", "pacdb", "autia", "autib", "autda", "autdb"}
+		switch {
+		case opc < 8:
+			return names[opc], []string{xr(rd, true), xrsp(rn, true)}
+		case opc < 16 && rn == 31:
+			return names[opc-8][:4] + "z" + names[opc-8][4:], []string{xr(rd, true)}
+		case opc == 16 && rn == 31:
+			return "xpaci", []string{xr(rd, true)}
+		case opc == 17 && rn == 31:
+			return "xpacd", []string{xr(rd, true)}
+		}
+	}
+	return "", nil
+}
+
+func (d *a64) dp2(sf bool, rd, rn, rm uint32) (string, []string) {
+	opc := d.bits(15, 10)
+	var op string
+	switch opc {
+	case 2:
+		op = "udiv"
+	case 3:
+		op = "sdiv"
+	case 8, 9, 10, 11:
+		op = a64Shift[opc-8]
+	case 12:
+		if !sf {
+			return "", nil
+		}
+		return "pacga", []string{xr(rd, true), xr(rn, true), xrsp(rm, true)}
+	case 16, 17, 18, 19, 20, 21, 22, 23:
+		sz := opc & 3
+		if (sz == 3) != sf {
+			return "", nil
+		}
+		op = "crc32" + string("bhwx"[sz])
+		if opc >= 20 {
+			op = "crc32c" + string("bhwx"[sz])
+		}
+		return op, []string{xr(rd, false), xr(rn, false), xr(rm, sz == 3)}
+	default:
+		return "", nil
+	}
+	return op, []string{xr(rd, sf), xr(rn, sf), xr(rm, sf)}
+}
+
+func (d *a64) dp3(sf bool, rd, rn, rm uint32) (string, []string) {
+	if d.bits(30, 29) != 0 {
+		return "", nil
+	}
+	ra := d.bits(14, 10)
+	o0 := d.bit(15)
+	switch op31 := d.bits(23, 21); op31 {
+	case 0:
+		switch {
+		case ra == 31 && !o0:
+			return "mul", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf)}
+		case ra == 31:
+			return "mneg", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf)}
+		case !o0:
+			return "madd", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf), xr(ra, sf)}
+		}
+		return "msub", []string{xr(rd, sf), xr(rn, sf), xr(rm, sf), xr(ra, sf)}
+	case 1, 5:
+		if !sf {
+			return "", nil
+		}
+		sign := "s"
+		if op31 == 5 {
+			sign = "u"
+		}
+		switch {
+		case ra == 31 && !o0:
+			return sign + "mull", []string{xr(rd, true), xr(rn, false), xr(rm, false)}
+		case ra == 31:
+			return sign + "mnegl", []string{xr(rd, true), xr(rn, false), xr(rm, false)}
+		case !o0:
+			return sign + "maddl", []string{xr(rd, true), xr(rn, false), xr(rm, false), xr(ra, true)}
+		}
+		return sign + "msubl", []string{xr(rd, true), xr(rn, false), xr(rm, false), xr(ra, true)}
+	case 2, 6:
+		if !sf || o0 {
+			return "", nil
+		}
+		op := "smulh"
+		if op31 == 6 {
+			op = "umulh"
+		}
+		return op, []string{xr(rd, true), xr(rn, true), xr(rm, true)}
+	}
+	return "", nil
+}
@@ -0,0 +1,527 @@
+package disasm
+
+import "fmt"
+
+// loadStore decodes the load and store group.
+func (d *a64) loadStore() (string, []string) {
+	switch {
+	case d.bits(29, 24) == 0b001000:
+		if d.bit(26) {
+			return "", nil
+		}
+		return d.exclusive()
+	case d.bits(29, 27) == 0b011 && !d.bit(24):
+		return d.loadLiteral()
+	case d.bits(29, 27) == 0b101:
+		return d.loadStorePair()
+	case d.bits(29, 24) == 0b011001 && !d.bit(21) && d.bits(11, 10) == 0:
+		return d.loadStoreRCpc()
+	case d.bits(29, 27) == 0b111:
+		return d.loadStoreReg()
+	case !d.bit(31) && d.bits(29, 24) == 0b001100:
+		if d.bit(21) {
+			return "", nil
+		}
+		if d.bit(23) {
+			return d.simdStructPost(false)
+		}
+		if d.bits(21, 16) != 0 {
+			return "", nil
+		}
+		return d.simdStruct(false)
+	case !d.bit(31) && d.bits(29, 24) == 0b001101:
+		return d.simdStructPost(true)
+	}
+	return "", nil
+}
+
+func memBase(rn uint32) string {
+	return "[" + xrsp(rn, true) + "]"
+}
+
+// memOff formats an immediate-offset addressing mode: mode 0 is a plain
+// offset, 1 post-index and 3 pre-index.
+func memOff(rn uint32, off int64, mode uint32) []string {
+	base := xrsp(rn, true)
+	switch mode {
+	case 1:
+		return []string{"[" + base + "]", imm(off)}
+	case 3:
+		return []string{fmt.Sprintf("[%s, %s]!", base, imm(off))}
+	}
+	if off == 0 {
+		return []string{"[" + base + "]"}
+	}
+	return []string{fmt.Sprintf("[%s, %s]", base, imm(off))}
+}
+
+func (d *a64) exclusive() (string, []string) {
+	size := d.bits(31, 30)
+	o2, l, o1, o0 := d.bit(23), d.bit(22), d.bit(21), d.bit(15)
+	rs, rt2, rn, rt := d.bits(20, 16), d.bits(14, 10), d.bits(9, 5), d.bits(4, 0)
+	suffix := [4]string{"b", "h", "", ""}[size]
+	sf := size == 3
+
+	switch {
+	case !o2 && !o1:
+		op := "stxr"
+		switch {
+		case l && o0:
+			op = "ldaxr"
+		case l:
+			op = "ldxr"
+		case o0:
+			op = "stlxr"
+		}
+		op += suffix
+		if l {
+			return op, []string{xr(rt, sf), memBase(rn)}
+		}
+		return op, []string{xr(rs, false), xr(rt, sf), memBase(rn)}
+
+	case !o2 && o1 && size >= 2:
+		op := "stxp"
+		switch {
+		case l && o0:
+			op = "ldaxp"
+		case l:
+			op = "ldxp"
+		case o0:
+			op = "stlxp"
+		}
+		if l {
+			return op, []string{xr(rt, sf), xr(rt2, sf), memBase(rn)}
+		}
+		return op, []string{xr(rs, false), xr(rt, sf), xr(rt2, sf), memBase(rn)}
+
+	case rt2 != 31 && o1:
+		return "", nil
+
+	case !o2 && o1:
+		if rs&1 != 0 || rt&1 != 0 {
+			return "", nil
+		}
+		sf = size == 1
+		op := "casp" + acqRel(l, o0)
+		return op, []string{xr(rs, sf), xr(rs+1, sf), xr(rt, sf), xr(rt+1, sf), memBase(rn)}
+
+	case o2 && !o1:
+		op := "stllr"
+		switch {
+		case l && o0:
+			op = "ldar"
+		case l:
+			op = "ldlar"
+		case o0:
+			op = "stlr"
+		}
+		return op + suffix, []string{xr(rt, sf), memBase(rn)}
+	}
+	return "cas" + acqRel(l, o0) + suffix, []string{xr(rs, sf), xr(rt, sf), memBase(rn)}
+}
+
+func acqRel(acquire, release bool) string {
+	switch {
+	case acquire && release:
+		return "al"
+	case acquire:
+		return "a"
+	case release:
+		return "l"
+	}
+	return ""
+}
+
+func (d *a64) loadLiteral() (string, []string) {
+	opc, rt := d.bits(31, 30), d.bits(4, 0)
+	label := d.label(sext(d.bits(23, 5), 19) << 2)
+	if d.bit(26) {
+		if opc == 3 {
+			return "", nil
+		}
+		return "ldr", []string{fpReg(2+opc, rt), label}
+	}
+	switch opc {
+	case 0:
+		return "ldr", []string{xr(rt, false), label}
+	case 1:
+		return "ldr", []string{xr(rt, true), label}
+	case 2:
+		return "ldrsw", []string{xr(rt, true), label}
+	}
+	return "prfm", []string{prfOp(rt), label}
+}
+
+// fpReg names a SIMD&FP register viewed as 1 << size bytes.
+func fpReg(size, n uint32) string {
+	return fmt.Sprintf("%c%d", "bhsdq"[size], n)
+}
+
+var a64PrfOps = [3]string{"pld", "pli", "pst"}
+
+func prfOp(rt uint32) string {
+	typ, target, policy := rt>>3, (rt>>1)&3, rt&1
+	if typ > 2 || target > 2 {
+		return imm(int64(rt))
+	}
+	return fmt.Sprintf("%sl%d%s", a64PrfOps[typ], target+1, [2]string{"keep", "strm"}[policy])
+}
+
+func (d *a64) loadStorePair() (string, []string) {
+	opc, v, l := d.bits(31, 30), d.bit(26), d.bit(22)
+	mode := d.bits(24, 23)
+	rt2, rn, rt := d.bits(14, 10), d.bits(9, 5), d.bits(4, 0)
+
+	var op string
+	var reg func(uint32) string
+	var scale uint32
+	switch {
+	case v && opc != 3:
+		scale = 2 + opc
+		reg = func(n uint32) string { return fpReg(scale, n) }
+	case !v && opc == 0:
+		scale = 2
+		reg = func(n uint32) string { return xr(n, false) }
+	case !v && opc == 2:
+		scale = 3
+		reg = func(n uint32) string { return xr(n, true) }
+	case !v && opc == 1 && l && mode != 0:
+		scale = 2
+		op = "ldpsw"
+		reg = func(n uint32) string { return xr(n, true) }
+	default:
+		return "", nil
+	}
+	if op == "" {
+		op = [2][2]string{{"stp", "ldp"}, {"stnp", "ldnp"}}[b2i(mode == 0)][b2i(l)]
+	}
+	off := sext(d.bits(21, 15), 7) << scale
+	m := mode
+	if m == 2 || m == 0 {
+		m = 0
+	}
+	return op, append([]string{reg(rt), reg(rt2)}, memOff(rn, off, m)...)
+}
+
+// ldstName returns the mnemonic stem and transfer register of a single
+// register load or store. It reports false for unallocated encodings.
+func ldstName(size uint32, v bool, opc uint32, rt uint32) (op, reg string, ok bool) {
+	if v {
+		if opc >= 2 && size != 0 {
+			return "", "", false
+		}
+		op = "str"
+		if opc&1 != 0 {
+			op = "ldr"
+		}
+		if opc >= 2 {
+			return op, fpReg(4, rt), true
+		}
+		return op, fpReg(size, rt), true
+	}
+	switch opc {
+	case 0:
+		op = "str"
+	case 1:
+		op = "ldr"
+	case 2:
+		switch size {
+		case 2:
+			return "ldrsw", xr(rt, true), true
+		case 3:
+			return "prfm", prfOp(rt), true
+		}
+		op = "ldrs"
+		return op + [2]string{"b", "h"}[size], xr(rt, true), true
+	case 3:
+		if size >= 2 {
+			return "", "", false
+		}
+		return "ldrs" + [2]string{"b", "h"}[size], xr(rt, false), true
+	}
+	switch size {
+	case 0:
+		return op + "b", xr(rt, false), true
+	case 1:
+		return op + "h", xr(rt, false), true
+	}
+	return op, xr(rt, size == 3), true
+}
+
+func (d *a64) loadStoreReg() (string, []string) {
+	size, v, opc := d.bits(31, 30), d.bit(26), d.bits(23, 22)
+	rn, rt := d.bits(9, 5), d.bits(4, 0)
+
+	if d.bit(24) {
+		op, reg, ok := ldstName(size, v, opc, rt)
+		if !ok {
+			return "", nil
+		}
+		scale := size
+		if v && opc >= 2 {
+			scale = 4
+		}
+		return op, append([]string{reg}, memOff(rn, int64(d.bits(21, 10))<<scale, 0)...)
+	}
+	if v && d.bits(25, 24) != 0 {
+		return "", nil
+	}
+
+	if !d.bit(21) {
+		mode := d.bits(11, 10)
+		off := sext(d.bits(20, 12), 9)
+		op, reg, ok := ldstName(size, v, opc, rt)
+		if !ok {
+			return "", nil
+		}
+		switch mode {
+		case 0:
+			// Unscaled: ldr -> ldur, ldrb -> ldurb, prfm -> prfum.
+			if op == "prfm" {
+				return "prfum", append([]string{reg}, memOff(rn, off, 0)...)
+			}
+			return op[:2] + "u" + op[2:], append([]string{reg}, memOff(rn, off, 0)...)
+		case 2:
+			if v || op == "prfm" {
+				return "", nil
+			}
+			return op[:2] + "t" + op[2:], append([]string{reg}, memOff(rn, off, 0)...)
+		}
+		if op == "prfm" {
+			return "", nil
+		}
+		return op, append([]string{reg}, memOff(rn, off, mode)...)
+	}
+
+	switch d.bits(11, 10) {
+	case 2:
+		op, reg, ok := ldstName(size, v, opc, rt)
+		if !ok {
+			return "", nil
+		}
+		option, s := d.bits(15, 13), d.bit(12)
+		if option&2 == 0 {
+			return "", nil
+		}
+		scale := size
+		if v && opc >= 2 {
+			scale = 4
+		}
+		index := xr(d.bits(20, 16), option&1 != 0)
+		ext := a64Extend[option]
+		if option == 3 {
+			ext = "lsl"
+		}
+		var mem string
+		switch {
+		case option == 3 && !s:
+			mem = fmt.Sprintf("[%s, %s]", xrsp(rn, true), index)
+		case s:
+			mem = fmt.Sprintf("[%s, %s, %s #%d]", xrsp(rn, true), index, ext, scale)
+		default:
+			mem = fmt.Sprintf("[%s, %s, %s]", xrsp(rn, true), index, ext)
+		}
+		return op, []string{reg, mem}
+	case 0:
+		if v {
+			return "", nil
+		}
+		return d.atomic(size, rn, rt)
+	}
+	if v || size != 3 {
+		return "", nil
+	}
+	op := "ldraa"
+	if d.bit(23) {
+		op = "ldrab"
+	}
+	off := sext(d.bits(22, 22)<<9|d.bits(20, 12), 10) << 3
+	mode := uint32(0)
+	if d.bit(11) {
+		mode = 3
+	}
+	return op, append([]string{xr(rt, true)}, memOff(rn, off, mode)...)
+}
+
+// loadStoreRCpc decodes the unscaled load-acquire and store-release
+// instructions.
+func (d *a64) loadStoreRCpc() (string, []string) {
+	size, opc := d.bits(31, 30), d.bits(23, 22)
+	rn, rt := d.bits(9, 5), d.bits(4, 0)
+	suffix := [4]string{"b", "h", "", ""}[size]
+	var op, reg string
+	switch {
+	case opc == 0:
+		op, reg = "stlur"+suffix, xr(rt, size == 3)
+	case opc == 1:
+		op, reg = "ldapur"+suffix, xr(rt, size == 3)
+	case size == 2 && opc == 2:
+		op, reg = "ldapursw", xr(rt, true)
+	case size < 2:
+		op, reg = "ldapurs"+suffix, xr(rt, opc == 2)
+	default:
+		return "", nil
+	}
+	return op, append([]string{reg}, memOff(rn, sext(d.bits(20, 12), 9), 0)...)
+}
+
+var a64AtomicOps = [8]string{"add", "clr", "eor", "set", "smax", "smin", "umax", "umin"}
+
+func (d *a64) atomic(size, rn, rt uint32) (string, []string) {
+	a, r := d.bit(23), d.bit(22)
+	rs := d.bits(20, 16)
+	o3, opc := d.bit(15), d.bits(14, 12)
+	suffix := [4]string{"b", "h", "", ""}[size]
+	sf := size == 3
+
+	switch {
+	case !o3:
+		if rt == 31 && !a {
+			return "st" + a64AtomicOps[opc] + acqRel(false, r) + suffix, []string{xr(rs, sf), memBase(rn)}
+		}
+		return "ld" + a64AtomicOps[opc] + acqRel(a, r) + suffix, []string{xr(rs, sf), xr(rt, sf), memBase(rn)}
+	case opc == 0:
+		return "swp" + acqRel(a, r) + suffix, []string{xr(rs, sf), xr(rt, sf), memBase(rn)}
+	case opc == 4 && a && !r && rs == 31:
+		return "ldapr" + suffix, []string{xr(rt, sf), memBase(rn)}
+	}
+	return "", nil
+}
+
+// vecArrangement returns the arrangement specifier for an element size
+// (0-3) and the Q bit.
+func vecArrangement(size uint32, q bool) string {
+	return [4][2]string{{"8b", "16b"}, {"4h", "8h"}, {"2s", "4s"}, {"1d", "2d"}}[size][b2i(q)]
+}
+
+func vecList(rt, n uint32, suffix string) string {
+	regs := make([]string, n)
+	for i := range regs {
+		regs[i] = fmt.Sprintf("v%d.%s", (rt+uint32(i))%32, suffix)
+	}
+	return "{ " + joinArgs(regs) + " }"
+}
+
+func joinArgs(args []string) string {
+	s := ""
+	for i, a := range args {
+		if i > 0 {
+			s += ", "
+		}
+		s += a
+	}
+	return s
+}
+
+// simdStruct decodes the multiple-structure SIMD loads and stores. With
+// post set, the base register is written back.
+func (d *a64) simdStruct(post bool) (string, []string) {
+	q, l := d.bit(30), d.bit(22)
+	size, rn, rt := d.bits(11, 10), d.bits(9, 5), d.bits(4, 0)
+
+	var sel, regs uint32
+	switch d.bits(15, 12) {
+	case 0b0000:
+		sel, regs = 4, 4
+	case 0b0010:
+		sel, regs = 1, 4
+	case 0b0100:
+		sel, regs = 3, 3
+	case 0b0110:
+		sel, regs = 1, 3
+	case 0b0111:
+		sel, regs = 1, 1
+	case 0b1000:
+		sel, regs = 2, 2
+	case 0b1010:
+		sel, regs = 1, 2
+	default:
+		return "", nil
+	}
+	if size == 3 && !q && sel != 1 {
+		return "", nil
+	}
+	op := fmt.Sprintf("st%d", sel)
+	if l {
+		op = fmt.Sprintf("ld%d", sel)
+	}
+	args := []string{vecList(rt, regs, vecArrangement(size, q)), memBase(rn)}
+	if post {
+		args = append(args, d.postIndex(regs*uint32(8<<b2i(q))))
+	}
+	return op, args
+}
+
+func (d *a64) postIndex(n uint32) string {
+	if rm := d.bits(20, 16); rm != 31 {
+		return xr(rm, true)
+	}
+	return imm(int64(n))
+}
+
+// simdStructPost decodes the post-indexed multiple-structure forms and
+// both single-structure forms.
+func (d *a64) simdStructPost(single bool) (string, []string) {
+	if !single {
+		return d.simdStruct(true)
+	}
+	post := d.bit(23)
+	if !post && d.bits(20, 16) != 0 {
+		return "", nil
+	}
+	q, l, r := d.bit(30), d.bit(22), d.bits(21, 21)
+	opcode, s, size := d.bits(15, 13), d.bits(12, 12), d.bits(11, 10)
+	rn, rt := d.bits(9, 5), d.bits(4, 0)
+	sel := (opcode&1)<<1 | r + 1
+
+	var suffix string
+	var index, esize uint32
+	switch opcode >> 1 {
+	case 0:
+		suffix, esize = "b", 1
+		index = b2u(q)<<3 | s<<2 | size
+	case 1:
+		if size&1 != 0 {
+			return "", nil
+		}
+		suffix, esize = "h", 2
+		index = b2u(q)<<2 | s<<1 | size>>1
+	case 2:
+		switch {
+		case size == 0:
+			suffix, esize = "s", 4
+			index = b2u(q)<<1 | s
+		case size == 1 && s == 0:
+			suffix, esize = "d", 8
+			index = b2u(q)
+		default:
+			return "", nil
+		}
+	case 3:
+		if !l || s != 0 {
+			return "", nil
+		}
+		op := fmt.Sprintf("ld%dr", sel)
+		args := []string{vecList(rt, sel, vecArrangement(size, q)), memBase(rn)}
+		if post {
+			args = append(args, d.postIndex(sel<<size))
+		}
+		return op, args
+	}
+	op := fmt.Sprintf("st%d", sel)
+	if l {
+		op = fmt.Sprintf("ld%d", sel)
+	}
+	args := []string{fmt.Sprintf("%s[%d]", vecList(rt, sel, suffix), index), memBase(rn)}
+	if post {
+		args = append(args, d.postIndex(sel*esize))
+	}
+	return op, args
+}
+
+func b2u(b bool) uint32 {
+	if b {
+		return 1
+	}
+	return 0
+}
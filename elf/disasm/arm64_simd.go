@@ -0,0 +1,1008 @@
+package disasm
+
+import (
+	"fmt"
+	"math/bits"
+)
+
+// simd decodes the scalar floating-point and Advanced SIMD group. Only
+// the base instruction set and the AES/SHA1/SHA256 extensions are
+// covered; other encodings decode as undefined.
+func (d *a64) simd() (string, []string) {
+	w := d.w
+	switch {
+	case d.bit(28) && !d.bit(30):
+		if d.bit(29) {
+			return "", nil
+		}
+		return d.fp()
+	case w&0xff3e0c00 == 0x4e280800:
+		return d.aes()
+	case w&0xff208c00 == 0x5e000000:
+		return d.sha3()
+	case w&0xff3e0c00 == 0x5e280800:
+		return d.sha2()
+	case w&0xdfe08400 == 0x5e000400:
+		return d.scalarCopy()
+	case w&0xdf200400 == 0x5e200400:
+		return d.scalarThreeSame()
+	case w&0xdf3e0c00 == 0x5e200800:
+		return d.scalarTwoMisc()
+	case w&0xff200c00 == 0x5e200000:
+		return d.scalarThreeDiff()
+	case w&0xdf3e0c00 == 0x5e300800:
+		return d.scalarPairwise()
+	case w&0xdf800400 == 0x5f000400 && d.bits(22, 19) != 0:
+		return d.shiftImm(true)
+	case w&0xdf000400 == 0x5f000000:
+		return d.indexed(true)
+	case w&0xbfe08c00 == 0x0e000000:
+		return d.table()
+	case w&0xbf208c00 == 0x0e000800:
+		return d.permute()
+	case w&0xbfe08400 == 0x2e000000:
+		return d.extract()
+	case w&0x9fe08400 == 0x0e000400:
+		return d.vecCopy()
+	case w&0x9f200400 == 0x0e200400:
+		return d.threeSame()
+	case w&0x9f3e0c00 == 0x0e200800:
+		return d.twoMisc()
+	case w&0x9f3e0c00 == 0x0e300800:
+		return d.acrossLanes()
+	case w&0x9f200c00 == 0x0e200000:
+		return d.threeDiff()
+	case w&0x9ff80400 == 0x0f000400:
+		return d.modImm()
+	case w&0x9f800400 == 0x0f000400:
+		return d.shiftImm(false)
+	case w&0x9f000400 == 0x0f000000:
+		return d.indexed(false)
+	}
+	return "", nil
+}
+
+// ftypeSize maps the ftype field of a floating-point instruction to a
+// register size as used by fpReg.
+func ftypeSize(ftype uint32) (uint32, bool) {
+	switch ftype {
+	case 0:
+		return 2, true
+	case 1:
+		return 3, true
+	case 3:
+		return 1, true
+	}
+	return 0, false
+}
+
+// fpImm expands the 8-bit floating-point immediate of FMOV.
+func fpImm(imm8 uint32) string {
+	v := float64(16+imm8&15) / 16
+	for e := int((imm8>>4)&7^4) - 3; e != 0; {
+		if e > 0 {
+			v *= 2
+			e--
+		} else {
+			v /= 2
+			e++
+		}
+	}
+	if imm8&0x80 != 0 {
+		v = -v
+	}
+	return fmt.Sprintf("#%.8f", v)
+}
+
+func (d *a64) fp() (string, []string) {
+	sf := d.bit(31)
+	rd, rn, rm := d.bits(4, 0), d.bits(9, 5), d.bits(20, 16)
+	fsz, ok := ftypeSize(d.bits(23, 22))
+
+	if d.bit(24) {
+		if !ok || sf {
+			return "", nil
+		}
+		op := [4]string{"fmadd", "fmsub", "fnmadd", "fnmsub"}[d.bits(21, 21)<<1|d.bits(15, 15)]
+		return op, []string{fpReg(fsz, rd), fpReg(fsz, rn), fpReg(fsz, rm), fpReg(fsz, d.bits(14, 10))}
+	}
+	if !d.bit(21) {
+		return d.fpFixed(sf)
+	}
+	if d.bits(15, 10) == 0 {
+		return d.fpConvert(sf)
+	}
+	if !ok || sf {
+		return "", nil
+	}
+
+	switch {
+	case d.bits(14, 10) == 0b10000:
+		opc := d.bits(20, 15)
+		var op string
+		switch opc {
+		case 0, 1, 2, 3:
+			op = [4]string{"fmov", "fabs", "fneg", "fsqrt"}[opc]
+		case 4, 5, 7:
+			dst := map[uint32]uint32{4: 2, 5: 3, 7: 1}[opc]
+			if dst == fsz {
+				return "", nil
+			}
+			return "fcvt", []string{fpReg(dst, rd), fpReg(fsz, rn)}
+		case 8, 9, 10, 11, 12, 14, 15:
+			op = "frint" + string("npmza?xi"[opc-8])
+		case 16, 17, 18, 19:
+			if fsz == 1 {
+				return "", nil
+			}
+			op = [4]string{"frint32z", "frint32x", "frint64z", "frint64x"}[opc-16]
+		default:
+			return "", nil
+		}
+		return op, []string{fpReg(fsz, rd), fpReg(fsz, rn)}
+
+	case d.bits(13, 10) == 0b1000:
+		if d.bits(15, 14) != 0 || d.bits(2, 0) != 0 {
+			return "", nil
+		}
+		op := "fcmp"
+		if d.bit(4) {
+			op = "fcmpe"
+		}
+		if d.bit(3) {
+			return op, []string{fpReg(fsz, rn), "#0.0"}
+		}
+		return op, []string{fpReg(fsz, rn), fpReg(fsz, rm)}
+
+	case d.bits(12, 10) == 0b100:
+		if d.bits(9, 5) != 0 {
+			return "", nil
+		}
+		return "fmov", []string{fpReg(fsz, rd), fpImm(d.bits(20, 13))}
+
+	case d.bits(11, 10) == 1:
+		op := "fccmp"
+		if d.bit(4) {
+			op = "fccmpe"
+		}
+		return op, []string{fpReg(fsz, rn), fpReg(fsz, rm), imm(int64(d.bits(3, 0))), a64Cond[d.bits(15, 12)]}
+
+	case d.bits(11, 10) == 2:
+		opc := d.bits(15, 12)
+		if opc > 8 {
+			return "", nil
+		}
+		op := [9]string{"fmul", "fdiv", "fadd", "fsub", "fmax", "fmin", "fmaxnm", "fminnm", "fnmul"}[opc]
+		return op, []string{fpReg(fsz, rd), fpReg(fsz, rn), fpReg(fsz, rm)}
+
+	case d.bits(11, 10) == 3:
+		return "fcsel", []string{fpReg(fsz, rd), fpReg(fsz, rn), fpReg(fsz, rm), a64Cond[d.bits(15, 12)]}
+	}
+	return "", nil
+}
+
+// fpFixed decodes conversions between floating-point and fixed-point.
+func (d *a64) fpFixed(sf bool) (string, []string) {
+	fsz, ok := ftypeSize(d.bits(23, 22))
+	scale := d.bits(15, 10)
+	if !ok || (!sf && scale < 32) {
+		return "", nil
+	}
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+	fbits := imm(int64(64 - scale))
+	switch d.bits(20, 16) {
+	case 0b00010:
+		return "scvtf", []string{fpReg(fsz, rd), xr(rn, sf), fbits}
+	case 0b00011:
+		return "ucvtf", []string{fpReg(fsz, rd), xr(rn, sf), fbits}
+	case 0b11000:
+		return "fcvtzs", []string{xr(rd, sf), fpReg(fsz, rn), fbits}
+	case 0b11001:
+		return "fcvtzu", []string{xr(rd, sf), fpReg(fsz, rn), fbits}
+	}
+	return "", nil
+}
+
+// fpConvert decodes conversions between floating-point and integer
+// registers, including FMOV between the register files.
+func (d *a64) fpConvert(sf bool) (string, []string) {
+	ftype, rmode, opc := d.bits(23, 22), d.bits(20, 19), d.bits(18, 16)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+
+	switch {
+	case ftype == 2 && sf && rmode == 1 && opc == 6:
+		return "fmov", []string{xr(rd, true), fmt.Sprintf("v%d.d[1]", rn)}
+	case ftype == 2 && sf && rmode == 1 && opc == 7:
+		return "fmov", []string{fmt.Sprintf("v%d.d[1]", rd), xr(rn, true)}
+	case ftype == 1 && !sf && rmode == 3 && opc == 6:
+		return "fjcvtzs", []string{xr(rd, false), fpReg(3, rn)}
+	}
+	fsz, ok := ftypeSize(ftype)
+	if !ok {
+		return "", nil
+	}
+	switch {
+	case opc < 2:
+		op := "fcvt" + string("npmz"[rmode]) + string("su"[opc])
+		return op, []string{xr(rd, sf), fpReg(fsz, rn)}
+	case rmode != 0:
+	case opc == 2 || opc == 3:
+		return [2]string{"scvtf", "ucvtf"}[opc-2], []string{fpReg(fsz, rd), xr(rn, sf)}
+	case opc == 4 || opc == 5:
+		return [2]string{"fcvtas", "fcvtau"}[opc-4], []string{xr(rd, sf), fpReg(fsz, rn)}
+	case fsz == 1 || (fsz == 3) == sf:
+		if opc == 6 {
+			return "fmov", []string{xr(rd, sf), fpReg(fsz, rn)}
+		}
+		return "fmov", []string{fpReg(fsz, rd), xr(rn, sf)}
+	}
+	return "", nil
+}
+
+func (d *a64) aes() (string, []string) {
+	opc := d.bits(16, 12)
+	if d.bits(23, 22) != 0 || opc < 4 || opc > 7 {
+		return "", nil
+	}
+	op := [4]string{"aese", "aesd", "aesmc", "aesimc"}[opc-4]
+	return op, []string{vreg(d.bits(4, 0), "16b"), vreg(d.bits(9, 5), "16b")}
+}
+
+func (d *a64) sha3() (string, []string) {
+	rd, rn, rm := d.bits(4, 0), d.bits(9, 5), d.bits(20, 16)
+	if d.bits(23, 22) != 0 {
+		return "", nil
+	}
+	switch opc := d.bits(14, 12); opc {
+	case 0, 1, 2:
+		op := [3]string{"sha1c", "sha1p", "sha1m"}[opc]
+		return op, []string{fpReg(4, rd), fpReg(2, rn), vreg(rm, "4s")}
+	case 3:
+		return "sha1su0", []string{vreg(rd, "4s"), vreg(rn, "4s"), vreg(rm, "4s")}
+	case 4, 5:
+		op := [2]string{"sha256h", "sha256h2"}[opc-4]
+		return op, []string{fpReg(4, rd), fpReg(4, rn), vreg(rm, "4s")}
+	case 6:
+		return "sha256su1", []string{vreg(rd, "4s"), vreg(rn, "4s"), vreg(rm, "4s")}
+	}
+	return "", nil
+}
+
+func (d *a64) sha2() (string, []string) {
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+	if d.bits(23, 22) != 0 {
+		return "", nil
+	}
+	switch d.bits(16, 12) {
+	case 0:
+		return "sha1h", []string{fpReg(2, rd), fpReg(2, rn)}
+	case 1:
+		return "sha1su1", []string{vreg(rd, "4s"), vreg(rn, "4s")}
+	case 2:
+		return "sha256su0", []string{vreg(rd, "4s"), vreg(rn, "4s")}
+	}
+	return "", nil
+}
+
+func vreg(n uint32, arr string) string {
+	return fmt.Sprintf("v%d.%s", n, arr)
+}
+
+// Arrangement masks for the vector tables below, indexed by size<<1|Q:
+// 8b, 16b, 4h, 8h, 2s, 4s, 1d, 2d.
+const (
+	arrAll   = 0xbf // every arrangement but 1d
+	arrNo64  = 0x3f
+	arrBytes = 0x03
+	arrHS    = 0x3c
+	arrBH    = 0x0f
+)
+
+type vecOp struct {
+	name string
+	arr  uint8
+}
+
+func (v vecOp) ok(size uint32, q bool) bool {
+	return v.name != "" && v.arr>>(size<<1|b2u(q))&1 != 0
+}
+
+var threeSameOps = [2][24]vecOp{{
+	{"shadd", arrNo64}, {"sqadd", arrAll}, {"srhadd", arrNo64}, {},
+	{"shsub", arrNo64}, {"sqsub", arrAll}, {"cmgt", arrAll}, {"cmge", arrAll},
+	{"sshl", arrAll}, {"sqshl", arrAll}, {"srshl", arrAll}, {"sqrshl", arrAll},
+	{"smax", arrNo64}, {"smin", arrNo64}, {"sabd", arrNo64}, {"saba", arrNo64},
+	{"add", arrAll}, {"cmtst", arrAll}, {"mla", arrNo64}, {"mul", arrNo64},
+	{"smaxp", arrNo64}, {"sminp", arrNo64}, {"sqdmulh", arrHS}, {"addp", arrAll},
+}, {
+	{"uhadd", arrNo64}, {"uqadd", arrAll}, {"urhadd", arrNo64}, {},
+	{"uhsub", arrNo64}, {"uqsub", arrAll}, {"cmhi", arrAll}, {"cmhs", arrAll},
+	{"ushl", arrAll}, {"uqshl", arrAll}, {"urshl", arrAll}, {"uqrshl", arrAll},
+	{"umax", arrNo64}, {"umin", arrNo64}, {"uabd", arrNo64}, {"uaba", arrNo64},
+	{"sub", arrAll}, {"cmeq", arrAll}, {"mls", arrNo64}, {"pmul", arrBytes},
+	{"umaxp", arrNo64}, {"uminp", arrNo64}, {"sqrdmulh", arrHS}, {},
+}}
+
+// threeSameFP holds the floating-point three-same operations indexed by
+// U, size<1> and opcode-24.
+var threeSameFP = [2][2][8]string{{
+	{"fmaxnm", "fmla", "fadd", "fmulx", "fcmeq", "", "fmax", "frecps"},
+	{"fminnm", "fmls", "fsub", "", "", "", "fmin", "frsqrts"},
+}, {
+	{"fmaxnmp", "", "faddp", "fmul", "fcmge", "facge", "fmaxp", "fdiv"},
+	{"fminnmp", "", "fabd", "", "fcmgt", "facgt", "fminp", ""},
+}}
+
+// fpArr returns the arrangement of a vector floating-point operation.
+func fpArr(sz uint32, q bool) (string, bool) {
+	if sz == 1 && !q {
+		return "", false
+	}
+	return vecArrangement(2+sz, q), true
+}
+
+func (d *a64) threeSame() (string, []string) {
+	q, u := d.bit(30), d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(15, 11)
+	rd, rn, rm := d.bits(4, 0), d.bits(9, 5), d.bits(20, 16)
+
+	if opc == 3 {
+		arr := vecArrangement(0, q)
+		op := [2][4]string{{"and", "bic", "orr", "orn"}, {"eor", "bsl", "bit", "bif"}}[u][size]
+		if op == "orr" && rn == rm {
+			return "mov", []string{vreg(rd, arr), vreg(rn, arr)}
+		}
+		return op, []string{vreg(rd, arr), vreg(rn, arr), vreg(rm, arr)}
+	}
+	if opc >= 24 {
+		op := threeSameFP[u][size>>1][opc-24]
+		arr, ok := fpArr(size&1, q)
+		if op == "" || !ok {
+			return "", nil
+		}
+		return op, []string{vreg(rd, arr), vreg(rn, arr), vreg(rm, arr)}
+	}
+	v := threeSameOps[u][opc]
+	if !v.ok(size, q) {
+		return "", nil
+	}
+	arr := vecArrangement(size, q)
+	return v.name, []string{vreg(rd, arr), vreg(rn, arr), vreg(rm, arr)}
+}
+
+// twoMiscOps holds the integer two-register miscellaneous operations.
+// A name ending in "#0" compares against zero.
+var twoMiscOps = [2][12]vecOp{{
+	{"rev64", arrNo64}, {"rev16", arrBytes}, {"saddlp", arrNo64}, {"suqadd", arrAll},
+	{"cls", arrNo64}, {"cnt", arrBytes}, {"sadalp", arrNo64}, {"sqabs", arrAll},
+	{"cmgt #0", arrAll}, {"cmeq #0", arrAll}, {"cmlt #0", arrAll}, {"abs", arrAll},
+}, {
+	{"rev32", arrBH}, {}, {"uaddlp", arrNo64}, {"usqadd", arrAll},
+	{"clz", arrNo64}, {"mvn", arrBytes}, {"uadalp", arrNo64}, {"sqneg", arrAll},
+	{"cmge #0", arrAll}, {"cmle #0", arrAll}, {}, {"neg", arrAll},
+}}
+
+// twoMiscFP holds the floating-point two-register miscellaneous
+// operations indexed by U, size<1> and opcode-12.
+var twoMiscFP = [2][2][20]string{{
+	{12: "frintn", 13: "frintm", 14: "fcvtns", 15: "fcvtms", 16: "fcvtas", 17: "scvtf", 18: "frint32z", 19: "frint64z"},
+	{"fcmgt #0.0", "fcmeq #0.0", "fcmlt #0.0", "fabs", 12: "frintp", 13: "frintz", 14: "fcvtps", 15: "fcvtzs", 16: "urecpe", 17: "frecpe"},
+}, {
+	{12: "frinta", 13: "frintx", 14: "fcvtnu", 15: "fcvtmu", 16: "fcvtau", 17: "ucvtf", 18: "frint32x", 19: "frint64x"},
+	{"fcmge #0.0", "fcmle #0.0", "", "fneg", 13: "frinti", 14: "fcvtpu", 15: "fcvtzu", 16: "ursqrte", 17: "frsqrte", 19: "fsqrt"},
+}}
+
+// splitZero separates the "#0" operand from a compare-with-zero name.
+func splitZero(name string) (string, []string) {
+	for i := 0; i < len(name); i++ {
+		if name[i] == ' ' {
+			return name[:i], []string{name[i+1:]}
+		}
+	}
+	return name, nil
+}
+
+func (d *a64) twoMisc() (string, []string) {
+	q, u := d.bit(30), d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(16, 12)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+	suffix := ""
+	if q {
+		suffix = "2"
+	}
+
+	switch {
+	case opc < 12:
+		v := twoMiscOps[u][opc]
+		if u == 1 && opc == 5 && size == 1 {
+			v = vecOp{"rbit", 0x0c}
+			size = 0
+		}
+		if !v.ok(size, q) {
+			return "", nil
+		}
+		arr := vecArrangement(size, q)
+		op, zero := splitZero(v.name)
+		src := arr
+		if opc == 2 || opc == 6 {
+			// Pairwise long additions widen the destination.
+			arr = vecArrangement(size+1, q)
+		}
+		return op, append([]string{vreg(rd, arr), vreg(rn, src)}, zero...)
+
+	case opc == 18 || opc == 20:
+		if size == 3 {
+			return "", nil
+		}
+		op := [2][2]string{{"xtn", "sqxtn"}, {"sqxtun", "uqxtn"}}[u][(opc-18)/2]
+		return op + suffix, []string{vreg(rd, vecArrangement(size, q)), vreg(rn, vecArrangement(size+1, true))}
+
+	case opc == 19 && u == 1:
+		if size == 3 {
+			return "", nil
+		}
+		return "shll" + suffix, []string{vreg(rd, vecArrangement(size+1, true)), vreg(rn, vecArrangement(size, q)), imm(8 << size)}
+
+	case opc == 22 && size>>1 == 0:
+		if u == 1 && size == 0 {
+			return "", nil
+		}
+		op := [2]string{"fcvtn", "fcvtxn"}[u]
+		return op + suffix, []string{vreg(rd, vecArrangement(1+size, q)), vreg(rn, vecArrangement(2+size, true))}
+
+	case opc == 23 && u == 0 && size>>1 == 0:
+		return "fcvtl" + suffix, []string{vreg(rd, vecArrangement(2+size, true)), vreg(rn, vecArrangement(1+size, q))}
+	}
+
+	if opc < 12 {
+		return "", nil
+	}
+	name := twoMiscFP[u][size>>1][opc-12]
+	arr, ok := fpArr(size&1, q)
+	if name == "" || !ok {
+		return "", nil
+	}
+	if (name == "urecpe" || name == "ursqrte") && size&1 != 0 {
+		return "", nil
+	}
+	if name == "urecpe" || name == "ursqrte" {
+		arr = vecArrangement(2, q)
+	}
+	op, zero := splitZero(name)
+	return op, append([]string{vreg(rd, arr), vreg(rn, arr)}, zero...)
+}
+
+func (d *a64) acrossLanes() (string, []string) {
+	q, u := d.bit(30), d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(16, 12)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+
+	if opc == 12 || opc == 15 {
+		if u == 0 || size&1 != 0 || !q {
+			return "", nil
+		}
+		op := [2][2]string{{"fmaxnmv", "fminnmv"}, {"fmaxv", "fminv"}}[(opc-12)/3][size>>1]
+		return op, []string{fpReg(2, rd), vreg(rn, "4s")}
+	}
+	var op string
+	dst := size
+	switch opc {
+	case 3:
+		op, dst = [2]string{"saddlv", "uaddlv"}[u], size+1
+	case 10:
+		op = [2]string{"smaxv", "umaxv"}[u]
+	case 26:
+		op = [2]string{"sminv", "uminv"}[u]
+	case 27:
+		if u == 1 {
+			return "", nil
+		}
+		op = "addv"
+	default:
+		return "", nil
+	}
+	if size == 3 || (size == 2 && !q) {
+		return "", nil
+	}
+	return op, []string{fpReg(dst, rd), vreg(rn, vecArrangement(size, q))}
+}
+
+// threeDiffOps holds the three-register operations with mixed element
+// sizes. The suffix of each name gives the shape: 'l' for long, 'w' for
+// wide and 'n' for narrow.
+var threeDiffOps = [2][15]string{
+	{"saddl", "saddw", "ssubl", "ssubw", "addhn", "sabal", "subhn", "sabdl",
+		"smlal", "sqdmlal", "smlsl", "sqdmlsl", "smull", "sqdmull", "pmull"},
+	{"uaddl", "uaddw", "usubl", "usubw", "raddhn", "uabal", "rsubhn", "uabdl",
+		"umlal", "", "umlsl", "", "umull", "", ""},
+}
+
+func (d *a64) threeDiff() (string, []string) {
+	q, u := d.bit(30), d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(15, 12)
+	rd, rn, rm := d.bits(4, 0), d.bits(9, 5), d.bits(20, 16)
+	if opc == 15 {
+		return "", nil
+	}
+	op := threeDiffOps[u][opc]
+	switch {
+	case op == "":
+		return "", nil
+	case op == "pmull":
+		if size == 3 {
+			return op + map[bool]string{true: "2"}[q], []string{vreg(rd, "1q"), vreg(rn, vecArrangement(3, q)), vreg(rm, vecArrangement(3, q))}
+		}
+		if size != 0 {
+			return "", nil
+		}
+	case size == 3:
+		return "", nil
+	case (opc == 9 || opc == 11 || opc == 13) && (size == 0):
+		return "", nil
+	}
+	if q {
+		op += "2"
+	}
+	wide, narrow := vecArrangement(size+1, true), vecArrangement(size, q)
+	switch opc {
+	case 1, 3:
+		return op, []string{vreg(rd, wide), vreg(rn, wide), vreg(rm, narrow)}
+	case 4, 6:
+		return op, []string{vreg(rd, narrow), vreg(rn, wide), vreg(rm, wide)}
+	}
+	return op, []string{vreg(rd, wide), vreg(rn, narrow), vreg(rm, narrow)}
+}
+
+func (d *a64) permute() (string, []string) {
+	q, size := d.bit(30), d.bits(23, 22)
+	opc := d.bits(14, 12)
+	if size == 3 && !q || opc&3 == 0 {
+		return "", nil
+	}
+	op := [8]string{"", "uzp1", "trn1", "zip1", "", "uzp2", "trn2", "zip2"}[opc]
+	arr := vecArrangement(size, q)
+	return op, []string{vreg(d.bits(4, 0), arr), vreg(d.bits(9, 5), arr), vreg(d.bits(20, 16), arr)}
+}
+
+func (d *a64) extract() (string, []string) {
+	q, idx := d.bit(30), d.bits(14, 11)
+	if !q && idx >= 8 {
+		return "", nil
+	}
+	arr := vecArrangement(0, q)
+	return "ext", []string{vreg(d.bits(4, 0), arr), vreg(d.bits(9, 5), arr), vreg(d.bits(20, 16), arr), imm(int64(idx))}
+}
+
+func (d *a64) table() (string, []string) {
+	q, n := d.bit(30), d.bits(14, 13)+1
+	op := "tbl"
+	if d.bit(12) {
+		op = "tbx"
+	}
+	arr := vecArrangement(0, q)
+	return op, []string{vreg(d.bits(4, 0), arr), vecList(d.bits(9, 5), n, "16b"), vreg(d.bits(20, 16), arr)}
+}
+
+// elemIndex decodes the imm5 field that selects a vector element. It
+// returns the element size (0-3) and index.
+func elemIndex(imm5 uint32) (uint32, uint32, bool) {
+	for size := uint32(0); size < 4; size++ {
+		if imm5>>size&1 != 0 {
+			return size, imm5 >> (size + 1), true
+		}
+	}
+	return 0, 0, false
+}
+
+func elem(n, size, idx uint32) string {
+	return fmt.Sprintf("v%d.%c[%d]", n, "bhsd"[size], idx)
+}
+
+func (d *a64) vecCopy() (string, []string) {
+	q, op := d.bit(30), d.bit(29)
+	imm4 := d.bits(14, 11)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+	size, idx, ok := elemIndex(d.bits(20, 16))
+	if !ok {
+		return "", nil
+	}
+
+	if op {
+		if !q {
+			return "", nil
+		}
+		return "mov", []string{elem(rd, size, idx), elem(rn, size, imm4>>size)}
+	}
+	switch imm4 {
+	case 0:
+		if size == 3 && !q {
+			return "", nil
+		}
+		return "dup", []string{vreg(rd, vecArrangement(size, q)), elem(rn, size, idx)}
+	case 1:
+		if size == 3 && !q {
+			return "", nil
+		}
+		return "dup", []string{vreg(rd, vecArrangement(size, q)), xr(rn, size == 3)}
+	case 3:
+		if !q {
+			return "", nil
+		}
+		return "mov", []string{elem(rd, size, idx), xr(rn, size == 3)}
+	case 5:
+		if size >= 2+b2u(q) || (size == 2 && !q) {
+			return "", nil
+		}
+		return "smov", []string{xr(rd, q), elem(rn, size, idx)}
+	case 7:
+		switch {
+		case q && size == 3, !q && size == 2:
+			return "mov", []string{xr(rd, q), elem(rn, size, idx)}
+		case !q && size < 2:
+			return "umov", []string{xr(rd, false), elem(rn, size, idx)}
+		}
+	}
+	return "", nil
+}
+
+func (d *a64) modImm() (string, []string) {
+	q, op := d.bit(30), d.bit(29)
+	cmode, rd := d.bits(15, 12), d.bits(4, 0)
+	imm8 := d.bits(18, 16)<<5 | d.bits(9, 5)
+	if d.bit(11) {
+		return "", nil
+	}
+
+	name := [2]string{"movi", "mvni"}[b2i(op)]
+	switch {
+	case cmode < 8:
+		arr := vecArrangement(2, q)
+		if cmode&1 != 0 {
+			name = [2]string{"orr", "bic"}[b2i(op)]
+		}
+		args := []string{vreg(rd, arr), imm(int64(imm8))}
+		if s := cmode >> 1 * 8; s != 0 {
+			args = append(args, fmt.Sprintf("lsl #%d", s))
+		}
+		return name, args
+	case cmode < 12:
+		arr := vecArrangement(1, q)
+		if cmode&1 != 0 {
+			name = [2]string{"orr", "bic"}[b2i(op)]
+		}
+		args := []string{vreg(rd, arr), imm(int64(imm8))}
+		if cmode&2 != 0 {
+			args = append(args, "lsl #8")
+		}
+		return name, args
+	case cmode < 14:
+		return name, []string{vreg(rd, vecArrangement(2, q)), imm(int64(imm8)), fmt.Sprintf("msl #%d", 8<<(cmode&1))}
+	case cmode == 14 && !op:
+		return "movi", []string{vreg(rd, vecArrangement(0, q)), imm(int64(imm8))}
+	case cmode == 14:
+		var v uint64
+		for i := 0; i < 8; i++ {
+			if imm8>>i&1 != 0 {
+				v |= 0xff << (8 * i)
+			}
+		}
+		if !q {
+			return "movi", []string{fpReg(3, rd), fmt.Sprintf("#0x%014x", v)}
+		}
+		if v == 0 {
+			return "movi", []string{vreg(rd, "2d"), "#0000000000000000"}
+		}
+		return "movi", []string{vreg(rd, "2d"), fmt.Sprintf("#0x%014x", v)}
+	case !op:
+		return "fmov", []string{vreg(rd, vecArrangement(2, q)), fpImm(imm8)}
+	case q:
+		return "fmov", []string{vreg(rd, "2d"), fpImm(imm8)}
+	}
+	return "", nil
+}
+
+// shiftImm decodes the shift by immediate groups. In scalar form the
+// operands are single registers.
+func (d *a64) shiftImm(scalar bool) (string, []string) {
+	q, u := d.bit(30), d.bits(29, 29)
+	immh, immhb := d.bits(22, 19), d.bits(22, 16)
+	opc := d.bits(15, 11)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+
+	size := uint32(31 - bits.LeadingZeros32(immh))
+	esize := uint32(8) << size
+	right, left := 2*esize-immhb, immhb-esize
+
+	reg := func(n, size uint32, q bool) string {
+		if scalar {
+			return fpReg(size, n)
+		}
+		return vreg(n, vecArrangement(size, q))
+	}
+	suffix := ""
+	if q && !scalar {
+		suffix = "2"
+	}
+
+	var op string
+	var amount uint32
+	switch opc {
+	case 0, 2, 4, 6:
+		op = [2][4]string{{"sshr", "ssra", "srshr", "srsra"}, {"ushr", "usra", "urshr", "ursra"}}[u][opc/2]
+		amount = right
+	case 8:
+		if u == 0 {
+			return "", nil
+		}
+		op, amount = "sri", right
+	case 10:
+		op, amount = [2]string{"shl", "sli"}[u], left
+	case 12:
+		if u == 0 {
+			return "", nil
+		}
+		op, amount = "sqshlu", left
+	case 14:
+		op, amount = [2]string{"sqshl", "uqshl"}[u], left
+	case 16, 17, 18, 19:
+		if size == 3 {
+			return "", nil
+		}
+		op = [2][4]string{{"shrn", "rshrn", "sqshrn", "sqrshrn"}, {"sqshrun", "sqrshrun", "uqshrn", "uqrshrn"}}[u][opc-16]
+		if scalar && u == 0 && opc < 18 {
+			return "", nil
+		}
+		return op + suffix, []string{reg(rd, size, q), reg(rn, size+1, true), imm(int64(right))}
+	case 20:
+		if size == 3 || scalar {
+			return "", nil
+		}
+		op = [2]string{"sshll", "ushll"}[u] + suffix
+		return op, []string{reg(rd, size+1, true), reg(rn, size, q), imm(int64(left))}
+	case 28, 31:
+		if size < 2 {
+			return "", nil
+		}
+		if opc == 28 {
+			op = [2]string{"scvtf", "ucvtf"}[u]
+		} else {
+			op = [2]string{"fcvtzs", "fcvtzu"}[u]
+		}
+		amount = right
+	default:
+		return "", nil
+	}
+	switch {
+	case scalar && opc <= 10 && size != 3:
+		return "", nil
+	case !scalar && size == 3 && !q:
+		return "", nil
+	}
+	return op, []string{reg(rd, size, q), reg(rn, size, q), imm(int64(amount))}
+}
+
+// indexedOps holds the vector by element operations indexed by U and
+// opcode. 'F' marks floating-point operations and 'L' long ones.
+var indexedOps = [2][16]struct {
+	name string
+	kind byte
+}{{
+	1: {"fmla", 'F'}, 2: {"smlal", 'L'}, 3: {"sqdmlal", 'L'}, 5: {"fmls", 'F'}, 6: {"smlsl", 'L'},
+	7: {"sqdmlsl", 'L'}, 8: {"mul", 0}, 9: {"fmul", 'F'}, 10: {"smull", 'L'}, 11: {"sqdmull", 'L'},
+	12: {"sqdmulh", 0}, 13: {"sqrdmulh", 0},
+}, {
+	0: {"mla", 0}, 2: {"umlal", 'L'}, 4: {"mls", 0}, 6: {"umlsl", 'L'}, 9: {"fmulx", 'F'},
+	10: {"umull", 'L'},
+}}
+
+func (d *a64) indexed(scalar bool) (string, []string) {
+	q, u := d.bit(30), d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(15, 12)
+	h, l, m := d.bits(11, 11), d.bits(21, 21), d.bits(20, 20)
+	rd, rn, rm := d.bits(4, 0), d.bits(9, 5), d.bits(19, 16)
+	e := indexedOps[u][opc]
+	if e.name == "" {
+		return "", nil
+	}
+
+	if e.kind == 'F' {
+		sz := size & 1
+		if size < 2 || (sz == 1 && l == 1) {
+			return "", nil
+		}
+		idx := h<<1 | l
+		if sz == 1 {
+			idx = h
+		}
+		esz := 2 + sz
+		rm |= m << 4
+		if scalar {
+			return e.name, []string{fpReg(esz, rd), fpReg(esz, rn), elem(rm, esz, idx)}
+		}
+		arr, ok := fpArr(sz, q)
+		if !ok {
+			return "", nil
+		}
+		return e.name, []string{vreg(rd, arr), vreg(rn, arr), elem(rm, esz, idx)}
+	}
+
+	var idx uint32
+	switch size {
+	case 1:
+		idx = h<<2 | l<<1 | m
+	case 2:
+		idx = h<<1 | l
+		rm |= m << 4
+	default:
+		return "", nil
+	}
+	switch {
+	case scalar && e.name != "sqdmulh" && e.name != "sqrdmulh" && e.name != "sqdmull" &&
+		e.name != "sqdmlal" && e.name != "sqdmlsl":
+		return "", nil
+	case scalar && e.kind == 'L':
+		return e.name, []string{fpReg(size+1, rd), fpReg(size, rn), elem(rm, size, idx)}
+	case scalar:
+		return e.name, []string{fpReg(size, rd), fpReg(size, rn), elem(rm, size, idx)}
+	case e.kind == 'L':
+		op := e.name
+		if q {
+			op += "2"
+		}
+		return op, []string{vreg(rd, vecArrangement(size+1, true)), vreg(rn, vecArrangement(size, q)), elem(rm, size, idx)}
+	}
+	arr := vecArrangement(size, q)
+	return e.name, []string{vreg(rd, arr), vreg(rn, arr), elem(rm, size, idx)}
+}
+
+func (d *a64) scalarCopy() (string, []string) {
+	if d.bit(29) || d.bits(14, 11) != 0 {
+		return "", nil
+	}
+	size, idx, ok := elemIndex(d.bits(20, 16))
+	if !ok {
+		return "", nil
+	}
+	return "mov", []string{fpReg(size, d.bits(4, 0)), elem(d.bits(9, 5), size, idx)}
+}
+
+func (d *a64) scalarThreeSame() (string, []string) {
+	u := d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(15, 11)
+	rd, rn, rm := d.bits(4, 0), d.bits(9, 5), d.bits(20, 16)
+
+	if opc >= 24 {
+		var op string
+		switch u<<6 | size>>1<<5 | opc {
+		case 27:
+			op = "fmulx"
+		case 28:
+			op = "fcmeq"
+		case 31:
+			op = "frecps"
+		case 32 | 31:
+			op = "frsqrts"
+		case 64 | 28:
+			op = "fcmge"
+		case 64 | 29:
+			op = "facge"
+		case 64 | 32 | 26:
+			op = "fabd"
+		case 64 | 32 | 28:
+			op = "fcmgt"
+		case 64 | 32 | 29:
+			op = "facgt"
+		default:
+			return "", nil
+		}
+		sz := 2 + size&1
+		return op, []string{fpReg(sz, rd), fpReg(sz, rn), fpReg(sz, rm)}
+	}
+	v := threeSameOps[u][opc]
+	switch opc {
+	case 1, 5, 9, 11:
+	case 22:
+		if size != 1 && size != 2 {
+			return "", nil
+		}
+	case 6, 7, 8, 10, 16, 17:
+		if size != 3 {
+			return "", nil
+		}
+	default:
+		return "", nil
+	}
+	return v.name, []string{fpReg(size, rd), fpReg(size, rn), fpReg(size, rm)}
+}
+
+func (d *a64) scalarThreeDiff() (string, []string) {
+	size, opc := d.bits(23, 22), d.bits(15, 12)
+	if size != 1 && size != 2 || (opc != 9 && opc != 11 && opc != 13) {
+		return "", nil
+	}
+	return threeDiffOps[0][opc], []string{fpReg(size+1, d.bits(4, 0)), fpReg(size, d.bits(9, 5)), fpReg(size, d.bits(20, 16))}
+}
+
+func (d *a64) scalarTwoMisc() (string, []string) {
+	u := d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(16, 12)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+
+	switch opc {
+	case 3, 7:
+		op := twoMiscOps[u][opc].name
+		return op, []string{fpReg(size, rd), fpReg(size, rn)}
+	case 8, 9, 10, 11:
+		v := twoMiscOps[u][opc]
+		if v.name == "" || size != 3 {
+			return "", nil
+		}
+		op, zero := splitZero(v.name)
+		return op, append([]string{fpReg(3, rd), fpReg(3, rn)}, zero...)
+	case 18, 20:
+		if size == 3 {
+			return "", nil
+		}
+		op := [2][2]string{{"", "sqxtn"}, {"sqxtun", "uqxtn"}}[u][(opc-18)/2]
+		if op == "" {
+			return "", nil
+		}
+		return op, []string{fpReg(size, rd), fpReg(size+1, rn)}
+	case 22:
+		if u == 0 || size != 1 {
+			return "", nil
+		}
+		return "fcvtxn", []string{fpReg(2, rd), fpReg(3, rn)}
+	case 31:
+		if u == 1 || size < 2 {
+			return "", nil
+		}
+		return "frecpx", []string{fpReg(size, rd), fpReg(size, rn)}
+	}
+	if opc < 12 {
+		return "", nil
+	}
+	name := twoMiscFP[u][size>>1][opc-12]
+	switch name {
+	case "", "urecpe", "ursqrte", "fabs", "fneg", "fsqrt":
+		return "", nil
+	}
+	if len(name) > 5 && name[:5] == "frint" {
+		return "", nil
+	}
+	sz := 2 + size&1
+	op, zero := splitZero(name)
+	if op == "frecpe" || op == "frsqrte" || zero != nil || op[:4] == "fcvt" || op[1:] == "cvtf" {
+		return op, append([]string{fpReg(sz, rd), fpReg(sz, rn)}, zero...)
+	}
+	return "", nil
+}
+
+func (d *a64) scalarPairwise() (string, []string) {
+	u := d.bits(29, 29)
+	size, opc := d.bits(23, 22), d.bits(16, 12)
+	rd, rn := d.bits(4, 0), d.bits(9, 5)
+
+	if u == 0 {
+		if opc != 27 || size != 3 {
+			return "", nil
+		}
+		return "addp", []string{fpReg(3, rd), vreg(rn, "2d")}
+	}
+	var op string
+	switch opc {
+	case 12:
+		op = [2]string{"fmaxnmp", "fminnmp"}[size>>1]
+	case 13:
+		if size>>1 != 0 {
+			return "", nil
+		}
+		op = "faddp"
+	case 15:
+		op = [2]string{"fmaxp", "fminp"}[size>>1]
+	default:
+		return "", nil
+	}
+	sz := size & 1
+	return op, []string{fpReg(2+sz, rd), vreg(rn, vecArrangement(2+sz, sz == 1))}
+}
@@ -0,0 +1,39 @@
+package disasm
+
+import "testing"
+
+// The expected text matches llvm-mc --disassemble, with branch targets
+// resolved against the address.
+func TestDecodeARM64(t *testing.T) {
+	testDecode(t, ArchARM64, Options{}, []decodeTest{
+		{0x1000, "1f2003d5", "nop"},
+		{0x1000, "c0035fd6", "ret"},
+		{0x1000, "fd7bbfa9", "stp\tx29, x30, [sp, #-16]!"},
+		{0x1000, "fd7bc1a8", "ldp\tx29, x30, [sp], #16"},
+		{0x1000, "fd030091", "mov\tx29, sp"},
+		{0x1000, "e00301aa", "mov\tx0, x1"},
+		{0x1000, "00008052", "mov\tw0, #0"},
+		{0x1000, "804682d2", "mov\tx0, #4660"},
+		{0x1000, "ff4300d1", "sub\tsp, sp, #16"},
+		{0x1000, "2000028b", "add\tx0, x1, x2"},
+		{0x1000, "1f0001eb", "cmp\tx0, x1"},
+		{0x1000, "1f0400f1", "cmp\tx0, #1"},
+		{0x1000, "e0179f9a", "cset\tx0, eq"},
+		{0x1000, "0008c01a", "udiv\tw0, w0, w0"},
+		{0x1000, "2000c0da", "rbit\tx0, x1"},
+		{0x1000, "e00b40f9", "ldr\tx0, [sp, #16]"},
+		{0x1000, "007861b8", "ldr\tw0, [x0, x1, lsl #2]"},
+		{0x1000, "00fc5fc8", "ldaxr\tx0, [x0]"},
+		{0x1000, "207c9f08", "stllrb\tw0, [x1]"},
+		{0x1000, "40000054", "b.eq\t0x1008"},
+		{0x1000, "e0ffff17", "b\t0xf80"},
+		{0x1000, "600000b4", "cbz\tx0, 0x100c"},
+		{0x1000, "00000094", "bl\t0x1000"},
+		{0x1234, "00000090", "adrp\tx0, 0x1000"},
+		{0x1000, "2028621e", "fadd\td0, d1, d2"},
+		{0x1000, "0084204e", "add\tv0.16b, v0.16b, v0.16b"},
+		{0x1000, "00e4006f", "movi\tv0.2d, #0000000000000000"},
+		{0x1000, "00000000", "udf\t#0"},
+		{0x1000, "2004c11a", ".inst\t0x1ac10420"},
+	})
+}
@@ -0,0 +1,50 @@
+package disasm
+
+import "testing"
+
+// The expected text matches llvm-mc --disassemble, with branch targets
+// resolved against the address and objdump's comment for PC-relative
+// loads.
+func TestDecodeARM(t *testing.T) {
+	testDecode(t, ArchARM, Options{}, []decodeTest{
+		{0x1000, "00f020e3", "nop"},
+		{0x1000, "1eff2fe1", "bx\tlr"},
+		{0x1000, "1eff2f01", "bxeq\tlr"},
+		{0x1000, "30482de9", "push\t{r4, r5, r11, lr}"},
+		{0x1000, "3088bde8", "pop\t{r4, r5, r11, pc}"},
+		{0x1000, "04e02de5", "str\tlr, [sp, #-4]!"},
+		{0x1000, "04f09de4", "ldr\tpc, [sp], #4"},
+		{0x1000, "000090e5", "ldr\tr0, [r0]"},
+		{0x1000, "0c009fe5", "ldr\tr0, [pc, #12]\t@ 0x1014"},
+		{0x1000, "0000a0e3", "mov\tr0, #0"},
+		{0x1000, "010080e0", "add\tr0, r0, r1"},
+		{0x1000, "04b08de2", "add\tr11, sp, #4"},
+		{0x1000, "000051e3", "cmp\tr1, #0"},
+		{0x1000, "910002e0", "mul\tr2, r1, r0"},
+		{0x1000, "0a00000a", "beq\t0x1030"},
+		{0x1000, "feffffeb", "bl\t0x1000"},
+		{0x1000, "020a31ee", "vadd.f32\ts0, s2, s4"},
+		{0x1000, "f000f0e7", "udf\t#0"},
+		{0x1000, "9000b0e1", ".inst\t0xe1b00090"},
+	})
+}
+
+func TestDecodeThumb(t *testing.T) {
+	testDecode(t, ArchThumb, Options{}, []decodeTest{
+		{0x1000, "00bf", "nop"},
+		{0x1000, "7047", "bx\tlr"},
+		{0x1000, "80b5", "push\t{r7, lr}"},
+		{0x1000, "80bd", "pop\t{r7, pc}"},
+		{0x1000, "0020", "movs\tr0, #0"},
+		{0x1000, "4018", "adds\tr0, r0, r1"},
+		{0x1000, "0028", "cmp\tr0, #0"},
+		{0x1000, "01d0", "beq\t0x1006"},
+		{0x1000, "1ab1", "cbz\tr2, 0x100a"},
+		{0x1000, "00de", "udf\t#0"},
+		{0x1000, "fff7feff", "bl\t0x1000"},
+		{0x1000, "00f000b8", "b.w\t0x1004"},
+		{0x1000, "4ff00000", "mov.w\tr0, #0"},
+		{0x1000, "2de9f041", "push.w\t{r4, r5, r6, r7, r8, lr}"},
+		{0x1000, "bde8f081", "pop.w\t{r4, r5, r6, r7, r8, pc}"},
+	})
+}
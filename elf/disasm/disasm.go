@@ -0,0 +1,175 @@
+// Package disasm decodes machine code into human readable instructions.
+//
+// It is a small, dependency free disassembler intended for quickly looking
+// at code next to its symbols. The output follows the conventions of GNU
+// objdump closely so that listings can be compared side by side.
+package disasm
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Arch selects the instruction set to decode.
+type Arch int
+
+const (
+	ArchUnknown Arch = iota
+	ArchX86_64
+	ArchI386
+	ArchARM64
+	ArchARM
+	// ArchThumb decodes the T32 instruction set (Thumb-2) of 32-bit ARM.
+	ArchThumb
+	ArchRISCV32
+	ArchRISCV64
+)
+
+func (a Arch) String() string {
+	switch a {
+	case ArchX86_64:
+		return "x86-64"
+	case ArchI386:
+		return "i386"
+	case ArchARM64:
+		return "aarch64"
+	case ArchARM:
+		return "arm"
+	case ArchThumb:
+		return "thumb"
+	case ArchRISCV32:
+		return "riscv32"
+	case ArchRISCV64:
+		return "riscv64"
+	}
+	return "unknown"
+}
+
+// Syntax selects the assembler syntax used to print x86 instructions.
+// Other architectures have a single syntax and ignore it.
+type Syntax int
+
+const (
+	SyntaxATT Syntax = iota
+	SyntaxIntel
+)
+
+// ParseSyntax converts a syntax name as accepted on the command line.
+func ParseSyntax(s string) (Syntax, error) {
+	switch s {
+	case "att", "at&t", "":
+		return SyntaxATT, nil
+	case "intel":
+		return SyntaxIntel, nil
+	}
+	return SyntaxATT, fmt.Errorf("unknown syntax %q (want att or intel)", s)
+}
+
+// Symbolizer maps an address to the symbol containing it. It returns the
+// symbol name and the offset of addr from the start of the symbol.
+type Symbolizer func(addr uint64) (name string, off uint64, ok bool)
+
+// Options controls how instructions are printed.
+type Options struct {
+	Syntax    Syntax
+	Symbolize Symbolizer
+	// IT carries the state of Thumb IT blocks from one instruction to the
+	// next. Callers decoding consecutive Thumb instructions with Decode
+	// should pass the same state for all of them; when nil, instructions
+	// are decoded as if outside any IT block. Disassemble tracks it on
+	// its own.
+	IT *ITState
+}
+
+// Inst is a single decoded instruction.
+type Inst struct {
+	Addr uint64
+	Len  int
+	// Text is the mnemonic and operands, including symbolic branch
+	// targets and a trailing comment for PC-relative memory operands.
+	Text string
+	// Target is the destination of a direct branch or the address of a
+	// PC-relative memory operand. It is only valid if HasTarget is set.
+	Target    uint64
+	HasTarget bool
+}
+
+// ErrBadInst is returned for byte sequences that do not form a valid
+// instruction. The accompanying Inst covers the undefined instruction, or a
+// single byte on x86 where its length is unknown, so that callers can keep
+// going.
+var ErrBadInst = errors.New("invalid instruction")
+
+// Decode decodes the instruction at the start of code, which is located
+// at addr.
+func Decode(arch Arch, code []byte, addr uint64, opts Options) (Inst, error) {
+	if len(code) == 0 {
+		return Inst{}, errors.New("no code to decode")
+	}
+
+	var inst Inst
+	var err error
+	switch arch {
+	case ArchX86_64:
+		inst, err = decodeX86(code, addr, 64, opts)
+	case ArchI386:
+		inst, err = decodeX86(code, addr, 32, opts)
+	case ArchARM64:
+		inst, err = decodeARM64(code, addr, opts)
+	case ArchARM:
+		inst, err = decodeARM(code, addr, opts)
+	case ArchThumb:
+		inst, err = decodeThumb(code, addr, opts)
+	case ArchRISCV32:
+		inst, err = decodeRISCV(code, addr, false, opts)
+	case ArchRISCV64:
+		inst, err = decodeRISCV(code, addr, true, opts)
+	default:
+		return Inst{}, fmt.Errorf("disassembly not supported for %s", arch)
+	}
+	if err != nil {
+		// Fixed width instruction sets report undefined encodings
+		// with their full length.
+		if inst.Len == 0 {
+			inst = Inst{Addr: addr, Len: 1, Text: "(bad)"}
+		}
+		return inst, err
+	}
+	return inst, nil
+}
+
+// Disassemble decodes all of code, which is located at addr. Invalid bytes
+// are decoded as "(bad)", ".inst" or ".insn" rather than stopping it.
+// Thumb IT blocks are tracked across instructions unless opts.IT is set.
+func Disassemble(arch Arch, code []byte, addr uint64, opts Options) ([]Inst, error) {
+	if arch == ArchThumb && opts.IT == nil {
+		opts.IT = new(ITState)
+	}
+	var insts []Inst
+	for off := 0; off < len(code); {
+		inst, err := Decode(arch, code[off:], addr+uint64(off), opts)
+		if err != nil && !errors.Is(err, ErrBadInst) {
+			return insts, err
+		}
+		insts = append(insts, inst)
+		off += inst.Len
+	}
+	return insts, nil
+}
+
+// symbolic formats a code address the way objdump does: "1020 <_init+0x20>"
+// when a symbol is known. Without a symbolizer the address is printed as a
+// plain hex number.
+func symbolic(addr uint64, opts Options) string {
+	if opts.Symbolize == nil {
+		return fmt.Sprintf("0x%x", addr)
+	}
+	name, off, ok := opts.Symbolize(addr)
+	switch {
+	case !ok:
+		return fmt.Sprintf("%x", addr)
+	case off == 0:
+		return fmt.Sprintf("%x <%s>", addr, name)
+	}
+	return fmt.Sprintf("%x <%s+0x%x>", addr, name, off)
+}
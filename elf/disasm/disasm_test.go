@@ -0,0 +1,52 @@
+package disasm
+
+import (
+	"encoding/hex"
+	"errors"
+	"testing"
+)
+
+// decodeTest is an instruction at addr and the text expected for it.
+// Invalid encodings expect the placeholder that Decode returns with
+// ErrBadInst.
+type decodeTest struct {
+	addr uint64
+	code string // hex, in memory order
+	text string
+}
+
+func testDecode(t *testing.T, arch Arch, opts Options, tests []decodeTest) {
+	t.Helper()
+	for _, tt := range tests {
+		code, err := hex.DecodeString(tt.code)
+		if err != nil {
+			t.Fatalf("bad test code %q: %v", tt.code, err)
+		}
+		inst, err := Decode(arch, code, tt.addr, opts)
+		if err != nil && !errors.Is(err, ErrBadInst) {
+			t.Errorf("%s %s: %v", arch, tt.code, err)
+			continue
+		}
+		if inst.Text != tt.text || inst.Len != len(code) {
+			t.Errorf("%s %s: got %q (%d bytes), want %q (%d bytes)", arch, tt.code, inst.Text, inst.Len, tt.text, len(code))
+		}
+	}
+}
+
+func TestDisassembleThumbIT(t *testing.T) {
+	// ite eq; moveq r0, #1; movne r0, #0
+	code, _ := hex.DecodeString("0cbf01200020")
+	insts, err := Disassemble(ArchThumb, code, 0x1000, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"ite\teq", "moveq\tr0, #1", "movne\tr0, #0"}
+	if len(insts) != len(want) {
+		t.Fatalf("got %d instructions, want %d", len(insts), len(want))
+	}
+	for i, inst := range insts {
+		if inst.Text != want[i] {
+			t.Errorf("instruction %d: got %q, want %q", i, inst.Text, want[i])
+		}
+	}
+}
@@ -0,0 +1,693 @@
+package disasm
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// rv decodes a single RISC-V instruction of the RV32GC or RV64GC
+// instruction sets, including Zicsr and Zifencei. Compressed instructions
+// are expanded to the 32-bit instruction they stand for, and both are
+// printed in the LLVM syntax with the standard pseudo-instructions, which
+// is what objdump shows by default.
+type rv struct {
+	w      uint32
+	pc     uint64
+	rv64   bool
+	opts   Options
+	target uint64
+	hasTgt bool
+}
+
+func decodeRISCV(code []byte, pc uint64, rv64 bool, opts Options) (Inst, error) {
+	if len(code) < 2 {
+		return Inst{Addr: pc, Len: len(code), Text: "(bad)"}, ErrBadInst
+	}
+	d := &rv{pc: pc, rv64: rv64, opts: opts}
+	h := uint32(binary.LittleEndian.Uint16(code))
+	n := 2
+	if h&3 == 3 {
+		if h&0x1c == 0x1c || len(code) < 4 {
+			// 48-bit and longer encodings are not part of any
+			// ratified extension.
+			return Inst{Addr: pc, Len: 2, Text: fmt.Sprintf(".insn\t2, 0x%04x", h)}, ErrBadInst
+		}
+		n = 4
+		d.w = binary.LittleEndian.Uint32(code)
+	} else {
+		w, ok := d.expand(h)
+		if !ok {
+			return Inst{Addr: pc, Len: 2, Text: fmt.Sprintf(".insn\t2, 0x%04x", h)}, ErrBadInst
+		}
+		d.w = w
+	}
+
+	op, args := d.decode()
+	if op == "" {
+		if n == 2 {
+			return Inst{Addr: pc, Len: 2, Text: fmt.Sprintf(".insn\t2, 0x%04x", h)}, ErrBadInst
+		}
+		return Inst{Addr: pc, Len: 4, Text: fmt.Sprintf(".insn\t4, 0x%08x", d.w)}, ErrBadInst
+	}
+	return Inst{
+		Addr:      pc,
+		Len:       n,
+		Text:      armText(op, args),
+		Target:    d.target,
+		HasTarget: d.hasTgt,
+	}, nil
+}
+
+func (d *rv) bits(hi, lo uint) uint32 {
+	return (d.w >> lo) & (1<<(hi-lo+1) - 1)
+}
+
+func (d *rv) rd() uint32  { return d.bits(11, 7) }
+func (d *rv) rs1() uint32 { return d.bits(19, 15) }
+func (d *rv) rs2() uint32 { return d.bits(24, 20) }
+func (d *rv) rs3() uint32 { return d.bits(31, 27) }
+func (d *rv) f3() uint32  { return d.bits(14, 12) }
+func (d *rv) f7() uint32  { return d.bits(31, 25) }
+
+func (d *rv) immI() int64 {
+	return sext(d.w>>20, 12)
+}
+
+func (d *rv) immS() int64 {
+	return sext(d.bits(31, 25)<<5|d.bits(11, 7), 12)
+}
+
+func (d *rv) immB() int64 {
+	return sext(d.bits(31, 31)<<12|d.bits(7, 7)<<11|d.bits(30, 25)<<5|d.bits(11, 8)<<1, 13)
+}
+
+func (d *rv) immJ() int64 {
+	return sext(d.bits(31, 31)<<20|d.bits(19, 12)<<12|d.bits(20, 20)<<11|d.bits(30, 21)<<1, 21)
+}
+
+// label records a branch destination and formats it.
+func (d *rv) label(off int64) string {
+	d.target = d.pc + uint64(off)
+	if !d.rv64 {
+		d.target &= 0xffffffff
+	}
+	d.hasTgt = true
+	return symbolic(d.target, d.opts)
+}
+
+var rvReg = [32]string{
+	"zero", "ra", "sp", "gp", "tp", "t0", "t1", "t2",
+	"s0", "s1", "a0", "a1", "a2", "a3", "a4", "a5",
+	"a6", "a7", "s2", "s3", "s4", "s5", "s6", "s7",
+	"s8", "s9", "s10", "s11", "t3", "t4", "t5", "t6",
+}
+
+var rvFReg = [32]string{
+	"ft0", "ft1", "ft2", "ft3", "ft4", "ft5", "ft6", "ft7",
+	"fs0", "fs1", "fa0", "fa1", "fa2", "fa3", "fa4", "fa5",
+	"fa6", "fa7", "fs2", "fs3", "fs4", "fs5", "fs6", "fs7",
+	"fs8", "fs9", "fs10", "fs11", "ft8", "ft9", "ft10", "ft11",
+}
+
+func rvMem(off int64, base uint32) string {
+	return fmt.Sprintf("%d(%s)", off, rvReg[base])
+}
+
+func rvImm(v int64) string {
+	return fmt.Sprintf("%d", v)
+}
+
+func (d *rv) decode() (string, []string) {
+	rd, rs2 := rvReg[d.rd()], rvReg[d.rs2()]
+	switch d.bits(6, 0) {
+	case 0x37:
+		return "lui", []string{rd, rvImm(int64(d.w >> 12))}
+	case 0x17:
+		return "auipc", []string{rd, rvImm(int64(d.w >> 12))}
+	case 0x6f:
+		target := d.label(d.immJ())
+		switch d.rd() {
+		case 0:
+			return "j", []string{target}
+		case 1:
+			return "jal", []string{target}
+		}
+		return "jal", []string{rd, target}
+	case 0x67:
+		return d.jalr()
+	case 0x63:
+		return d.branch()
+	case 0x03:
+		if op := [8]string{"lb", "lh", "lw", "ld", "lbu", "lhu", "lwu"}[d.f3()]; op != "" && (d.rv64 || (op != "ld" && op != "lwu")) {
+			return op, []string{rd, rvMem(d.immI(), d.rs1())}
+		}
+	case 0x23:
+		if op := [8]string{"sb", "sh", "sw", "sd"}[d.f3()]; op != "" && (d.rv64 || op != "sd") {
+			return op, []string{rs2, rvMem(d.immS(), d.rs1())}
+		}
+	case 0x13:
+		return d.opImm()
+	case 0x1b:
+		if d.rv64 {
+			return d.opImm32()
+		}
+	case 0x33:
+		return d.op(false)
+	case 0x3b:
+		if d.rv64 {
+			return d.op(true)
+		}
+	case 0x0f:
+		return d.miscMem()
+	case 0x73:
+		return d.system()
+	case 0x2f:
+		return d.amo()
+	case 0x07:
+		if op := d.fpWidth("fl"); op != "" {
+			return op, []string{rvFReg[d.rd()], rvMem(d.immI(), d.rs1())}
+		}
+	case 0x27:
+		if op := d.fpWidth("fs"); op != "" {
+			return op, []string{rvFReg[d.rs2()], rvMem(d.immS(), d.rs1())}
+		}
+	case 0x43, 0x47, 0x4b, 0x4f:
+		return d.fma()
+	case 0x53:
+		return d.opFP()
+	}
+	return "", nil
+}
+
+func (d *rv) jalr() (string, []string) {
+	if d.f3() != 0 {
+		return "", nil
+	}
+	rs1 := rvReg[d.rs1()]
+	off := d.immI()
+	switch {
+	case d.rd() == 0 && d.rs1() == 1 && off == 0:
+		return "ret", nil
+	case d.rd() == 0 && off == 0:
+		return "jr", []string{rs1}
+	case d.rd() == 1 && off == 0:
+		return "jalr", []string{rs1}
+	case d.rd() == 0:
+		return "jr", []string{rvMem(off, d.rs1())}
+	case d.rd() == 1:
+		return "jalr", []string{rvMem(off, d.rs1())}
+	}
+	return "jalr", []string{rvReg[d.rd()], rvMem(off, d.rs1())}
+}
+
+func (d *rv) branch() (string, []string) {
+	op := [8]string{"beq", "bne", "", "", "blt", "bge", "bltu", "bgeu"}[d.f3()]
+	if op == "" {
+		return "", nil
+	}
+	rs1, rs2 := rvReg[d.rs1()], rvReg[d.rs2()]
+	target := d.label(d.immB())
+	switch {
+	case d.rs2() == 0 && op == "beq":
+		return "beqz", []string{rs1, target}
+	case d.rs2() == 0 && op == "bne":
+		return "bnez", []string{rs1, target}
+	case d.rs2() == 0 && op == "blt":
+		return "bltz", []string{rs1, target}
+	case d.rs2() == 0 && op == "bge":
+		return "bgez", []string{rs1, target}
+	case d.rs1() == 0 && op == "blt":
+		return "bgtz", []string{rs2, target}
+	case d.rs1() == 0 && op == "bge":
+		return "blez", []string{rs2, target}
+	}
+	return op, []string{rs1, rs2, target}
+}
+
+func (d *rv) opImm() (string, []string) {
+	rd, rs1 := rvReg[d.rd()], rvReg[d.rs1()]
+	imm := d.immI()
+	switch d.f3() {
+	case 0:
+		switch {
+		case d.rd() == 0 && d.rs1() == 0 && imm == 0:
+			return "nop", nil
+		case d.rs1() == 0:
+			return "li", []string{rd, rvImm(imm)}
+		case imm == 0:
+			return "mv", []string{rd, rs1}
+		}
+		return "addi", []string{rd, rs1, rvImm(imm)}
+	case 2:
+		return "slti", []string{rd, rs1, rvImm(imm)}
+	case 3:
+		if imm == 1 {
+			return "seqz", []string{rd, rs1}
+		}
+		return "sltiu", []string{rd, rs1, rvImm(imm)}
+	case 4:
+		if imm == -1 {
+			return "not", []string{rd, rs1}
+		}
+		return "xori", []string{rd, rs1, rvImm(imm)}
+	case 6:
+		return "ori", []string{rd, rs1, rvImm(imm)}
+	case 7:
+		return "andi", []string{rd, rs1, rvImm(imm)}
+	}
+
+	// Shifts by an immediate take 5 bits of shift amount on RV32 and 6
+	// on RV64; the remaining upper bits select the shift.
+	shamt, funct := d.bits(25, 20), d.bits(31, 26)
+	if !d.rv64 {
+		shamt, funct = d.bits(24, 20), d.bits(31, 25)>>1
+		if d.bits(25, 25) != 0 {
+			return "", nil
+		}
+	}
+	switch {
+	case d.f3() == 1 && funct == 0:
+		return "slli", []string{rd, rs1, rvImm(int64(shamt))}
+	case d.f3() == 5 && funct == 0:
+		return "srli", []string{rd, rs1, rvImm(int64(shamt))}
+	case d.f3() == 5 && funct == 0x10:
+		return "srai", []string{rd, rs1, rvImm(int64(shamt))}
+	}
+	return "", nil
+}
+
+func (d *rv) opImm32() (string, []string) {
+	rd, rs1 := rvReg[d.rd()], rvReg[d.rs1()]
+	shamt := rvImm(int64(d.bits(24, 20)))
+	switch {
+	case d.f3() == 0 && d.immI() == 0:
+		return "sext.w", []string{rd, rs1}
+	case d.f3() == 0:
+		return "addiw", []string{rd, rs1, rvImm(d.immI())}
+	case d.f3() == 1 && d.f7() == 0:
+		return "slliw", []string{rd, rs1, shamt}
+	case d.f3() == 5 && d.f7() == 0:
+		return "srliw", []string{rd, rs1, shamt}
+	case d.f3() == 5 && d.f7() == 0x20:
+		return "sraiw", []string{rd, rs1, shamt}
+	}
+	return "", nil
+}
+
+// rvOps names the register-register operations by funct7 and funct3.
+var rvOps = map[uint32][8]string{
+	0x00: {"add", "sll", "slt", "sltu", "xor", "srl", "or", "and"},
+	0x20: {"sub", "", "", "", "", "sra"},
+	0x01: {"mul", "mulh", "mulhsu", "mulhu", "div", "divu", "rem", "remu"},
+}
+
+var rvOps32 = map[uint32][8]string{
+	0x00: {"addw", "sllw", "", "", "", "srlw"},
+	0x20: {"subw", "", "", "", "", "sraw"},
+	0x01: {"mulw", "", "", "", "divw", "divuw", "remw", "remuw"},
+}
+
+// op decodes the OP and, for word, the RV64 OP-32 major opcodes.
+func (d *rv) op(word bool) (string, []string) {
+	ops := rvOps
+	if word {
+		ops = rvOps32
+	}
+	op := ops[d.f7()][d.f3()]
+	if op == "" {
+		return "", nil
+	}
+	rd, rs1, rs2 := rvReg[d.rd()], rvReg[d.rs1()], rvReg[d.rs2()]
+	switch {
+	case (op == "sub" || op == "subw") && d.rs1() == 0:
+		return "neg" + op[3:], []string{rd, rs2}
+	case op == "sltu" && d.rs1() == 0:
+		return "snez", []string{rd, rs2}
+	case op == "slt" && d.rs2() == 0:
+		return "sltz", []string{rd, rs1}
+	case op == "slt" && d.rs1() == 0:
+		return "sgtz", []string{rd, rs2}
+	}
+	return op, []string{rd, rs1, rs2}
+}
+
+// rvFence formats the predecessor or successor set of a fence.
+func rvFence(v uint32) string {
+	if v == 0 {
+		return "0"
+	}
+	var s []byte
+	for i, c := range "iorw" {
+		if v&(8>>i) != 0 {
+			s = append(s, byte(c))
+		}
+	}
+	return string(s)
+}
+
+func (d *rv) miscMem() (string, []string) {
+	switch {
+	case d.f3() == 1 && d.w>>15 == 0x1 && d.rd() == 0:
+		return "fence.i", nil
+	case d.f3() != 0 || d.rs1() != 0 || d.rd() != 0:
+		return "", nil
+	case d.bits(31, 20) == 0x833:
+		return "fence.tso", nil
+	case d.bits(31, 28) != 0:
+		return "", nil
+	case d.bits(27, 20) == 0xff:
+		return "fence", nil
+	}
+	return "fence", []string{rvFence(d.bits(27, 24)), rvFence(d.bits(23, 20))}
+}
+
+func (d *rv) system() (string, []string) {
+	if d.f3() == 0 {
+		switch d.w {
+		case 0x00000073:
+			return "ecall", nil
+		case 0x00100073:
+			return "ebreak", nil
+		case 0x10200073:
+			return "sret", nil
+		case 0x30200073:
+			return "mret", nil
+		case 0x10500073:
+			return "wfi", nil
+		}
+		if d.f7() == 0x09 && d.rd() == 0 {
+			switch {
+			case d.rs1() == 0 && d.rs2() == 0:
+				return "sfence.vma", nil
+			case d.rs2() == 0:
+				return "sfence.vma", []string{rvReg[d.rs1()]}
+			}
+			return "sfence.vma", []string{rvReg[d.rs1()], rvReg[d.rs2()]}
+		}
+		return "", nil
+	}
+	return d.csr()
+}
+
+func (d *rv) csr() (string, []string) {
+	num := d.w >> 20
+	csr := rvCSRName(num, d.rv64)
+	rd := rvReg[d.rd()]
+	src := rvReg[d.rs1()]
+	if d.f3() >= 5 {
+		src = rvImm(int64(d.rs1()))
+	}
+	zeroRd, zeroSrc := d.rd() == 0, d.rs1() == 0
+
+	// The floating-point CSRs and the counters have dedicated
+	// pseudo-instructions.
+	fp := map[uint32]string{1: "flags", 2: "rm", 3: "csr"}[num]
+	switch {
+	case d.w == 0xc0001073:
+		return "unimp", nil
+	case fp != "" && d.f3() == 2 && zeroSrc:
+		return "fr" + fp, []string{rd}
+	case fp != "" && (d.f3() == 1 || d.f3() == 5 && fp != "csr"):
+		op := "fs" + fp
+		if d.f3() == 5 {
+			op += "i"
+		}
+		if zeroRd {
+			return op, []string{src}
+		}
+		return op, []string{rd, src}
+	case d.f3() == 2 && zeroSrc:
+		counters := map[uint32]string{0xc00: "rdcycle", 0xc01: "rdtime", 0xc02: "rdinstret"}
+		if !d.rv64 {
+			counters[0xc80] = "rdcycleh"
+			counters[0xc81] = "rdtimeh"
+			counters[0xc82] = "rdinstreth"
+		}
+		if op, ok := counters[num]; ok {
+			return op, []string{rd}
+		}
+		return "csrr", []string{rd, csr}
+	case zeroRd:
+		if op := [8]string{1: "csrw", 2: "csrs", 3: "csrc", 5: "csrwi", 6: "csrsi", 7: "csrci"}[d.f3()]; op != "" {
+			return op, []string{csr, src}
+		}
+	}
+	if op := [8]string{1: "csrrw", 2: "csrrs", 3: "csrrc", 5: "csrrwi", 6: "csrrsi", 7: "csrrci"}[d.f3()]; op != "" {
+		return op, []string{rd, csr, src}
+	}
+	return "", nil
+}
+
+// rvCSRs names the control and status registers of the unprivileged and
+// privileged specifications.
+var rvCSRs = map[uint32]string{
+	0x001: "fflags", 0x002: "frm", 0x003: "fcsr",
+	0xc00: "cycle", 0xc01: "time", 0xc02: "instret",
+	0x008: "vstart", 0x009: "vxsat", 0x00a: "vxrm", 0x00f: "vcsr",
+	0xc20: "vl", 0xc21: "vtype", 0xc22: "vlenb", 0x015: "seed",
+
+	0x100: "sstatus", 0x104: "sie", 0x105: "stvec", 0x106: "scounteren",
+	0x10a: "senvcfg", 0x140: "sscratch", 0x141: "sepc", 0x142: "scause",
+	0x143: "stval", 0x144: "sip", 0x14d: "stimecmp", 0x180: "satp",
+	0x5a8: "scontext",
+
+	0x600: "hstatus", 0x602: "hedeleg", 0x603: "hideleg", 0x604: "hie",
+	0x605: "htimedelta", 0x606: "hcounteren", 0x607: "hgeie",
+	0x60a: "henvcfg", 0x643: "htval", 0x644: "hip", 0x645: "hvip",
+	0x64a: "htinst", 0x680: "hgatp", 0x6a8: "hcontext", 0xe12: "hgeip",
+	0x200: "vsstatus", 0x204: "vsie", 0x205: "vstvec", 0x240: "vsscratch",
+	0x241: "vsepc", 0x242: "vscause", 0x243: "vstval", 0x244: "vsip",
+	0x24d: "vstimecmp", 0x280: "vsatp",
+
+	0xf11: "mvendorid", 0xf12: "marchid", 0xf13: "mimpid", 0xf14: "mhartid",
+	0xf15: "mconfigptr",
+	0x300: "mstatus", 0x301: "misa", 0x302: "medeleg", 0x303: "mideleg",
+	0x304: "mie", 0x305: "mtvec", 0x306: "mcounteren", 0x30a: "menvcfg",
+	0x320: "mcountinhibit", 0x340: "mscratch", 0x341: "mepc",
+	0x342: "mcause", 0x343: "mtval", 0x344: "mip", 0x34a: "mtinst",
+	0x34b: "mtval2", 0x747: "mseccfg", 0xb00: "mcycle", 0xb02: "minstret",
+
+	0x7a0: "tselect", 0x7a1: "tdata1", 0x7a2: "tdata2", 0x7a3: "tdata3",
+	0x7a8: "mcontext", 0x7b0: "dcsr", 0x7b1: "dpc", 0x7b2: "dscratch0",
+	0x7b3: "dscratch1",
+}
+
+// rvCSRName names a CSR, falling back to its number. The upper halves of
+// 64-bit CSRs only exist on RV32.
+func rvCSRName(num uint32, rv64 bool) string {
+	if name, ok := rvCSRs[num]; ok {
+		return name
+	}
+	switch {
+	case num >= 0xc03 && num <= 0xc1f:
+		return fmt.Sprintf("hpmcounter%d", num-0xc00)
+	case num >= 0xb03 && num <= 0xb1f:
+		return fmt.Sprintf("mhpmcounter%d", num-0xb00)
+	case num >= 0x323 && num <= 0x33f:
+		return fmt.Sprintf("mhpmevent%d", num-0x320)
+	case num >= 0x3b0 && num <= 0x3ef:
+		return fmt.Sprintf("pmpaddr%d", num-0x3b0)
+	case num >= 0x3a0 && num <= 0x3af && (!rv64 || num%2 == 0):
+		return fmt.Sprintf("pmpcfg%d", num-0x3a0)
+	case num >= 0x10c && num <= 0x10f:
+		return fmt.Sprintf("sstateen%d", num-0x10c)
+	case num >= 0x30c && num <= 0x30f:
+		return fmt.Sprintf("mstateen%d", num-0x30c)
+	case num >= 0x60c && num <= 0x60f:
+		return fmt.Sprintf("hstateen%d", num-0x60c)
+	}
+	if !rv64 {
+		high := map[uint32]string{
+			0x310: "mstatush", 0x31a: "menvcfgh", 0x615: "htimedeltah",
+			0x61a: "henvcfgh", 0x757: "mseccfgh", 0x15d: "stimecmph",
+			0x25d: "vstimecmph",
+		}
+		if name, ok := high[num]; ok {
+			return name
+		}
+		// The counters mirror their lower halves 0x80 higher.
+		if name, ok := rvCSRs[num-0x80]; ok && (num >= 0xc80 && num <= 0xc82 || num == 0xb80 || num == 0xb82) {
+			return name + "h"
+		}
+		switch {
+		case num >= 0xc83 && num <= 0xc9f:
+			return fmt.Sprintf("hpmcounter%dh", num-0xc80)
+		case num >= 0xb83 && num <= 0xb9f:
+			return fmt.Sprintf("mhpmcounter%dh", num-0xb80)
+		case num >= 0x723 && num <= 0x73f:
+			return fmt.Sprintf("mhpmevent%dh", num-0x720)
+		case num >= 0x31c && num <= 0x31f:
+			return fmt.Sprintf("mstateen%dh", num-0x31c)
+		case num >= 0x61c && num <= 0x61f:
+			return fmt.Sprintf("hstateen%dh", num-0x61c)
+		}
+	}
+	return rvImm(int64(num))
+}
+
+func (d *rv) amo() (string, []string) {
+	var suffix string
+	switch d.f3() {
+	case 2:
+		suffix = ".w"
+	case 3:
+		if !d.rv64 {
+			return "", nil
+		}
+		suffix = ".d"
+	default:
+		return "", nil
+	}
+	suffix += [4]string{"", ".rl", ".aq", ".aqrl"}[d.bits(26, 25)]
+
+	rd, rs2 := rvReg[d.rd()], rvReg[d.rs2()]
+	addr := "(" + rvReg[d.rs1()] + ")"
+	switch d.bits(31, 27) {
+	case 0x02:
+		if d.rs2() != 0 {
+			return "", nil
+		}
+		return "lr" + suffix, []string{rd, addr}
+	case 0x03:
+		return "sc" + suffix, []string{rd, rs2, addr}
+	}
+	op := map[uint32]string{
+		0x00: "amoadd", 0x01: "amoswap", 0x04: "amoxor", 0x08: "amoor",
+		0x0c: "amoand", 0x10: "amomin", 0x14: "amomax", 0x18: "amominu",
+		0x1c: "amomaxu",
+	}[d.bits(31, 27)]
+	if op == "" {
+		return "", nil
+	}
+	return op + suffix, []string{rd, rs2, addr}
+}
+
+// fpWidth names a floating-point load or store, which encode the width in
+// funct3.
+func (d *rv) fpWidth(prefix string) string {
+	switch d.f3() {
+	case 2:
+		return prefix + "w"
+	case 3:
+		return prefix + "d"
+	}
+	return ""
+}
+
+// fpFmt returns the suffix for the format field of a floating-point
+// operation. Only single and double precision are supported.
+func (d *rv) fpFmt() string {
+	return [4]string{"s", "d"}[d.bits(26, 25)]
+}
+
+// rm appends the rounding mode to args unless it is the dynamic one, and
+// reports whether the mode is valid.
+func (d *rv) rm(args []string) ([]string, bool) {
+	switch m := d.f3(); m {
+	case 7:
+		return args, true
+	case 5, 6:
+		return nil, false
+	default:
+		return append(args, [5]string{"rne", "rtz", "rdn", "rup", "rmm"}[m]), true
+	}
+}
+
+func (d *rv) fma() (string, []string) {
+	f := d.fpFmt()
+	if f == "" {
+		return "", nil
+	}
+	op := map[uint32]string{0x43: "fmadd", 0x47: "fmsub", 0x4b: "fnmsub", 0x4f: "fnmadd"}[d.bits(6, 0)]
+	args, ok := d.rm([]string{rvFReg[d.rd()], rvFReg[d.rs1()], rvFReg[d.rs2()], rvFReg[d.rs3()]})
+	if !ok {
+		return "", nil
+	}
+	return op + "." + f, args
+}
+
+func (d *rv) opFP() (string, []string) {
+	f := d.fpFmt()
+	if f == "" {
+		return "", nil
+	}
+	fd, fs1, fs2 := rvFReg[d.rd()], rvFReg[d.rs1()], rvFReg[d.rs2()]
+	xd, xs1 := rvReg[d.rd()], rvReg[d.rs1()]
+	// Integer formats of the conversions, selected by rs2.
+	ints := [4]string{"w", "wu", "l", "lu"}
+	withRM := func(op string, args ...string) (string, []string) {
+		args, ok := d.rm(args)
+		if !ok {
+			return "", nil
+		}
+		return op, args
+	}
+
+	switch funct5 := d.bits(31, 27); funct5 {
+	case 0x00, 0x01, 0x02, 0x03:
+		op := [4]string{"fadd", "fsub", "fmul", "fdiv"}[funct5]
+		return withRM(op+"."+f, fd, fs1, fs2)
+	case 0x0b:
+		if d.rs2() == 0 {
+			return withRM("fsqrt."+f, fd, fs1)
+		}
+	case 0x04:
+		if d.f3() > 2 {
+			break
+		}
+		if d.rs1() == d.rs2() {
+			return [3]string{"fmv", "fneg", "fabs"}[d.f3()] + "." + f, []string{fd, fs1}
+		}
+		return [3]string{"fsgnj", "fsgnjn", "fsgnjx"}[d.f3()] + "." + f, []string{fd, fs1, fs2}
+	case 0x05:
+		if d.f3() <= 1 {
+			return [2]string{"fmin", "fmax"}[d.f3()] + "." + f, []string{fd, fs1, fs2}
+		}
+	case 0x08:
+		// Widening is exact and has no rounding mode.
+		switch {
+		case f == "s" && d.rs2() == 1:
+			return withRM("fcvt.s.d", fd, fs1)
+		case f == "d" && d.rs2() == 0 && d.f3() == 0:
+			return "fcvt.d.s", []string{fd, fs1}
+		}
+	case 0x14:
+		if d.f3() <= 2 {
+			return [3]string{"fle", "flt", "feq"}[d.f3()] + "." + f, []string{xd, fs1, fs2}
+		}
+	case 0x18:
+		if d.rs2() < 2 || d.rs2() < 4 && d.rv64 {
+			return withRM("fcvt."+ints[d.rs2()]+"."+f, xd, fs1)
+		}
+	case 0x1a:
+		switch {
+		case d.rs2() >= 4 || d.rs2() >= 2 && !d.rv64:
+		case f == "d" && d.rs2() < 2:
+			// Every 32-bit integer is exact in double precision.
+			if d.f3() == 0 {
+				return "fcvt.d." + ints[d.rs2()], []string{fd, xs1}
+			}
+		default:
+			return withRM("fcvt."+f+"."+ints[d.rs2()], fd, xs1)
+		}
+	case 0x1c:
+		switch {
+		case d.rs2() != 0:
+		case d.f3() == 0 && f == "s":
+			return "fmv.x.w", []string{xd, fs1}
+		case d.f3() == 0 && d.rv64:
+			return "fmv.x.d", []string{xd, fs1}
+		case d.f3() == 1:
+			return "fclass." + f, []string{xd, fs1}
+		}
+	case 0x1e:
+		switch {
+		case d.rs2() != 0 || d.f3() != 0:
+		case f == "s":
+			return "fmv.w.x", []string{fd, xs1}
+		case d.rv64:
+			return "fmv.d.x", []string{fd, xs1}
+		}
+	}
+	return "", nil
+}
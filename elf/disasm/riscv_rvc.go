@@ -0,0 +1,197 @@
+package disasm
+
+// Encoders for the 32-bit instruction formats, used to expand compressed
+// instructions.
+
+func rvEncI(op, rd, f3, rs1 uint32, imm int64) uint32 {
+	return uint32(imm)<<20 | rs1<<15 | f3<<12 | rd<<7 | op
+}
+
+func rvEncR(op, rd, f3, rs1, rs2, f7 uint32) uint32 {
+	return f7<<25 | rs2<<20 | rs1<<15 | f3<<12 | rd<<7 | op
+}
+
+func rvEncS(op, f3, rs1, rs2 uint32, imm int64) uint32 {
+	v := uint32(imm)
+	return (v>>5&0x7f)<<25 | rs2<<20 | rs1<<15 | f3<<12 | (v&0x1f)<<7 | op
+}
+
+func rvEncB(f3, rs1, rs2 uint32, imm int64) uint32 {
+	v := uint32(imm)
+	return (v>>12&1)<<31 | (v>>5&0x3f)<<25 | rs2<<20 | rs1<<15 | f3<<12 |
+		(v>>1&0xf)<<8 | (v>>11&1)<<7 | 0x63
+}
+
+func rvEncJ(rd uint32, imm int64) uint32 {
+	v := uint32(imm)
+	return (v>>20&1)<<31 | (v>>1&0x3ff)<<21 | (v>>11&1)<<20 | (v>>12&0xff)<<12 | rd<<7 | 0x6f
+}
+
+// expand returns the 32-bit instruction that the compressed instruction h
+// stands for. It reports false for reserved encodings.
+func (d *rv) expand(h uint32) (uint32, bool) {
+	bit := func(n uint) uint32 { return h >> n & 1 }
+	field := func(hi, lo uint) uint32 { return (h >> lo) & (1<<(hi-lo+1) - 1) }
+
+	rd := field(11, 7)
+	rs2 := field(6, 2)
+	// Registers x8-x15 encoded in three bits.
+	rdp := 8 + field(4, 2)
+	rs1p := 8 + field(9, 7)
+	imm6 := sext(bit(12)<<5|field(6, 2), 6)
+	shamt := bit(12)<<5 | field(6, 2)
+	// Scaled offsets of the word and doubleword loads and stores.
+	offW := int64(field(12, 10)<<3 | bit(6)<<2 | bit(5)<<6)
+	offD := int64(field(12, 10)<<3 | field(6, 5)<<6)
+	spW := int64(bit(12)<<5 | field(6, 4)<<2 | field(3, 2)<<6)
+	spD := int64(bit(12)<<5 | field(6, 5)<<3 | field(4, 2)<<6)
+	sspW := int64(field(12, 9)<<2 | field(8, 7)<<6)
+	sspD := int64(field(12, 10)<<3 | field(9, 7)<<6)
+
+	switch h&3<<3 | field(15, 13) {
+	// Quadrant 0.
+	case 0:
+		if h == 0 {
+			// The all-zero halfword is defined to be illegal.
+			return 0xc0001073, true
+		}
+		imm := int64(field(10, 7)<<6 | field(12, 11)<<4 | bit(5)<<3 | bit(6)<<2)
+		if imm == 0 {
+			return 0, false
+		}
+		return rvEncI(0x13, rdp, 0, 2, imm), true
+	case 1:
+		return rvEncI(0x07, rdp, 3, rs1p, offD), true
+	case 2:
+		return rvEncI(0x03, rdp, 2, rs1p, offW), true
+	case 3:
+		if d.rv64 {
+			return rvEncI(0x03, rdp, 3, rs1p, offD), true
+		}
+		return rvEncI(0x07, rdp, 2, rs1p, offW), true
+	case 5:
+		return rvEncS(0x27, 3, rs1p, rdp, offD), true
+	case 6:
+		return rvEncS(0x23, 2, rs1p, rdp, offW), true
+	case 7:
+		if d.rv64 {
+			return rvEncS(0x23, 3, rs1p, rdp, offD), true
+		}
+		return rvEncS(0x27, 2, rs1p, rdp, offW), true
+
+	// Quadrant 1.
+	case 8:
+		return rvEncI(0x13, rd, 0, rd, imm6), true
+	case 9:
+		if !d.rv64 {
+			return rvEncJ(1, d.cjOffset(h)), true
+		}
+		if rd == 0 {
+			return 0, false
+		}
+		return rvEncI(0x1b, rd, 0, rd, imm6), true
+	case 10:
+		return rvEncI(0x13, rd, 0, 0, imm6), true
+	case 11:
+		if rd == 2 {
+			imm := sext(bit(12)<<9|field(4, 3)<<7|bit(5)<<6|bit(2)<<5|bit(6)<<4, 10)
+			if imm == 0 {
+				return 0, false
+			}
+			return rvEncI(0x13, 2, 0, 2, imm), true
+		}
+		if imm6 == 0 {
+			return 0, false
+		}
+		return uint32(imm6)<<12 | rd<<7 | 0x37, true
+	case 12:
+		return d.expandArith(h, rs1p, shamt, imm6)
+	case 13:
+		return rvEncJ(0, d.cjOffset(h)), true
+	case 14, 15:
+		imm := sext(bit(12)<<8|field(11, 10)<<3|field(6, 5)<<6|field(4, 3)<<1|bit(2)<<5, 9)
+		return rvEncB(field(15, 13)-6, rs1p, 0, imm), true
+
+	// Quadrant 2.
+	case 16:
+		if !d.rv64 && bit(12) != 0 {
+			return 0, false
+		}
+		return rvEncI(0x13, rd, 1, rd, int64(shamt)), true
+	case 17:
+		return rvEncI(0x07, rd, 3, 2, spD), true
+	case 18:
+		if rd == 0 {
+			return 0, false
+		}
+		return rvEncI(0x03, rd, 2, 2, spW), true
+	case 19:
+		if !d.rv64 {
+			return rvEncI(0x07, rd, 2, 2, spW), true
+		}
+		if rd == 0 {
+			return 0, false
+		}
+		return rvEncI(0x03, rd, 3, 2, spD), true
+	case 20:
+		switch {
+		case bit(12) == 0 && rs2 == 0:
+			if rd == 0 {
+				return 0, false
+			}
+			return rvEncI(0x67, 0, 0, rd, 0), true
+		case bit(12) == 0:
+			// c.mv is printed as the mv pseudo-instruction.
+			return rvEncI(0x13, rd, 0, rs2, 0), true
+		case rd == 0 && rs2 == 0:
+			return 0x00100073, true
+		case rs2 == 0:
+			return rvEncI(0x67, 1, 0, rd, 0), true
+		}
+		return rvEncR(0x33, rd, 0, rd, rs2, 0), true
+	case 21:
+		return rvEncS(0x27, 3, 2, rs2, sspD), true
+	case 22:
+		return rvEncS(0x23, 2, 2, rs2, sspW), true
+	case 23:
+		if d.rv64 {
+			return rvEncS(0x23, 3, 2, rs2, sspD), true
+		}
+		return rvEncS(0x27, 2, 2, rs2, sspW), true
+	}
+	return 0, false
+}
+
+// cjOffset decodes the jump offset of c.j and c.jal.
+func (d *rv) cjOffset(h uint32) int64 {
+	bit := func(n uint) uint32 { return h >> n & 1 }
+	return sext(bit(12)<<11|bit(11)<<4|(h>>9&3)<<8|bit(8)<<10|bit(7)<<6|bit(6)<<7|(h>>3&7)<<1|bit(2)<<5, 12)
+}
+
+// expandArith expands the shifts and logical operations of quadrant 1,
+// which all operate on registers x8-x15.
+func (d *rv) expandArith(h, rs1p, shamt uint32, imm6 int64) (uint32, bool) {
+	rs2p := 8 + (h >> 2 & 7)
+	switch h >> 10 & 3 {
+	case 0, 1:
+		if !d.rv64 && shamt >= 32 {
+			return 0, false
+		}
+		return rvEncI(0x13, rs1p, 5, rs1p, int64(shamt|(h>>10&1)<<10)), true
+	case 2:
+		return rvEncI(0x13, rs1p, 7, rs1p, imm6), true
+	}
+	funct := h >> 5 & 3
+	if h>>12&1 == 0 {
+		f3 := [4]uint32{0, 4, 6, 7}[funct]
+		f7 := uint32(0)
+		if funct == 0 {
+			f7 = 0x20
+		}
+		return rvEncR(0x33, rs1p, f3, rs1p, rs2p, f7), true
+	}
+	if !d.rv64 || funct >= 2 {
+		return 0, false
+	}
+	return rvEncR(0x3b, rs1p, 0, rs1p, rs2p, 0x20*(1-funct)), true
+}
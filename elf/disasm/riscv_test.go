@@ -0,0 +1,62 @@
+package disasm
+
+import "testing"
+
+// The vectors were assembled with llvm-mc and the expected text is its
+// disassembly, with branch targets resolved against the address.
+func TestDecodeRISCV64(t *testing.T) {
+	testDecode(t, ArchRISCV64, Options{}, []decodeTest{
+		{0x1000, "13000000", "nop"},
+		{0x1000, "67800000", "ret"},
+		{0x1000, "130101fe", "addi\tsp, sp, -32"},
+		{0x1000, "233c1100", "sd\tra, 24(sp)"},
+		{0x1000, "83308101", "ld\tra, 24(sp)"},
+		{0x1000, "93050500", "mv\ta1, a0"},
+		{0x1000, "63040500", "beqz\ta0, 0x1008"},
+		{0x1000, "ef00c000", "jal\t0x100c"},
+		{0x1000, "97050000", "auipc\ta1, 0"},
+		{0x1000, "b7120000", "lui\tt0, 1"},
+		{0x1000, "3b05b500", "addw\ta0, a0, a1"},
+		{0x1000, "1b850500", "sext.w\ta0, a1"},
+		{0x1000, "13b51500", "seqz\ta0, a1"},
+		{0x1000, "3335b000", "snez\ta0, a1"},
+		{0x1000, "3305b040", "neg\ta0, a1"},
+		{0x1000, "13153500", "slli\ta0, a0, 3"},
+		{0x1000, "3305b502", "mul\ta0, a0, a1"},
+		{0x1000, "3355b502", "divu\ta0, a0, a1"},
+		{0x1000, "2fb50510", "lr.d\ta0, (a1)"},
+		{0x1000, "2f25b606", "amoadd.w.aqrl\ta0, a1, (a2)"},
+		{0x1000, "5305b502", "fadd.d\tfa0, fa0, fa1, rne"},
+		{0x1000, "07358100", "fld\tfa0, 8(sp)"},
+		{0x1000, "73250030", "csrr\ta0, mstatus"},
+		{0x1000, "0f00f00f", "fence"},
+		{0x1000, "73000000", "ecall"},
+
+		// Compressed instructions.
+		{0x1000, "0100", "nop"},
+		{0x1000, "8280", "ret"},
+		{0x1000, "7d71", "addi\tsp, sp, -16"},
+		{0x1000, "06e4", "sd\tra, 8(sp)"},
+		{0x1000, "a260", "ld\tra, 8(sp)"},
+		{0x1000, "0505", "addi\ta0, a0, 1"},
+		{0x1000, "2d45", "li\ta0, 11"},
+		{0x1000, "2e85", "mv\ta0, a1"},
+		{0x1000, "2e95", "add\ta0, a0, a1"},
+		{0x1000, "19c1", "beqz\ta0, 0x1006"},
+		{0x1000, "fdbf", "j\t0xffe"},
+		{0x1000, "c841", "lw\ta0, 4(a1)"},
+		{0x1000, "0a05", "slli\ta0, a0, 2"},
+		{0x1000, "0000", "unimp"},
+	})
+}
+
+func TestDecodeRISCV32(t *testing.T) {
+	testDecode(t, ArchRISCV32, Options{}, []decodeTest{
+		{0x1000, "130101fe", "addi\tsp, sp, -32"},
+		{0x1000, "232e1100", "sw\tra, 28(sp)"},
+		{0x1000, "8320c101", "lw\tra, 28(sp)"},
+		{0x1000, "3355b540", "sra\ta0, a0, a1"},
+		// c.sdsp does not exist on RV32; the encoding is c.fswsp there.
+		{0x1000, "06e4", "fsw\tft1, 8(sp)"},
+	})
+}
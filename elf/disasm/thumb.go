@@ -0,0 +1,1001 @@
+package disasm
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math/bits"
+	"strings"
+)
+
+// ITState tracks the If-Then block opened by a Thumb IT instruction, which
+// makes up to four following instructions conditional. The zero value is
+// outside of any IT block.
+type ITState struct {
+	bits uint8
+}
+
+// cond returns the condition of the next instruction and whether it is
+// inside an IT block.
+func (s *ITState) cond() (uint32, bool) {
+	if s == nil || s.bits&15 == 0 {
+		return 14, false
+	}
+	return uint32(s.bits >> 4), true
+}
+
+func (s *ITState) advance() {
+	if s == nil {
+		return
+	}
+	if s.bits&7 == 0 {
+		s.bits = 0
+		return
+	}
+	s.bits = s.bits&0xe0 | s.bits<<1&0x1f
+}
+
+func decodeThumb(code []byte, pc uint64, opts Options) (Inst, error) {
+	if len(code) < 2 {
+		return Inst{Addr: pc, Len: len(code), Text: "(bad)"}, ErrBadInst
+	}
+	hw1 := uint32(binary.LittleEndian.Uint16(code))
+	d := &a32{pc: pc, opts: opts, thumb: true}
+	d.cond, d.inIT = opts.IT.cond()
+
+	if hw1>>11 < 0x1d {
+		d.w = hw1
+		op, args := d.thumb16()
+		if op == "" {
+			opts.IT.advance()
+			return Inst{Addr: pc, Len: 2, Text: fmt.Sprintf(".inst.n\t0x%04x", hw1)}, ErrBadInst
+		}
+		if hw1&0xff00 == 0xbf00 && hw1&15 != 0 {
+			if opts.IT != nil {
+				opts.IT.bits = uint8(hw1)
+			}
+		} else {
+			opts.IT.advance()
+		}
+		return d.inst(2, op, args), nil
+	}
+
+	if len(code) < 4 {
+		return Inst{Addr: pc, Len: len(code), Text: "(bad)"}, ErrBadInst
+	}
+	d.w = hw1<<16 | uint32(binary.LittleEndian.Uint16(code[2:]))
+	opts.IT.advance()
+	op, args := d.thumb32()
+	if op == "" {
+		return Inst{Addr: pc, Len: 4, Text: fmt.Sprintf(".inst.w\t0x%08x", d.w)}, ErrBadInst
+	}
+	return d.inst(4, op, args), nil
+}
+
+// opw returns the mnemonic of a 32-bit Thumb instruction that also has a
+// 16-bit encoding, marked with the .w qualifier.
+func (d *a32) opw(base string, s bool) string {
+	return d.ops(base, s) + ".w"
+}
+
+// lo names one of the low registers r0-r7 held in three bits at n.
+func (d *a32) lo(n uint) string {
+	return armReg(d.bits(n+2, n))
+}
+
+// flags16 reports whether a 16-bit data processing instruction sets the
+// flags, which it only does outside of IT blocks.
+func (d *a32) flags16() bool {
+	return !d.inIT
+}
+
+var thumbDataOps = [16]string{
+	"and", "eor", "lsl", "lsr", "asr", "adc", "sbc", "ror",
+	"tst", "rsb", "cmp", "cmn", "orr", "mul", "bic", "mvn",
+}
+
+func (d *a32) thumb16() (string, []string) {
+	w := d.w
+	switch {
+	case w>>13 == 0:
+		rd, rm := d.lo(0), d.lo(3)
+		if w>>11 == 3 {
+			op := [2]string{"add", "sub"}[d.bits(9, 9)]
+			if d.bit(10) {
+				return d.ops(op, d.flags16()), []string{rd, rm, imm(int64(d.bits(8, 6)))}
+			}
+			return d.ops(op, d.flags16()), []string{rd, rm, d.lo(6)}
+		}
+		typ, amount := d.bits(12, 11), d.bits(10, 6)
+		if typ == 0 && amount == 0 {
+			if d.inIT {
+				return "", nil
+			}
+			return "movs", []string{rd, rm}
+		}
+		if amount == 0 {
+			amount = 32
+		}
+		return d.ops(armShiftNames[typ], d.flags16()), []string{rd, rm, imm(int64(amount))}
+
+	case w>>13 == 1:
+		rd, v := d.lo(8), imm(int64(d.bits(7, 0)))
+		switch d.bits(12, 11) {
+		case 0:
+			return d.ops("mov", d.flags16()), []string{rd, v}
+		case 1:
+			return d.op("cmp"), []string{rd, v}
+		case 2:
+			return d.ops("add", d.flags16()), []string{rd, v}
+		}
+		return d.ops("sub", d.flags16()), []string{rd, v}
+
+	case w>>10 == 0x10:
+		op := d.bits(9, 6)
+		rd, rm := d.lo(0), d.lo(3)
+		switch op {
+		case 8, 10, 11:
+			return d.op(thumbDataOps[op]), []string{rd, rm}
+		case 9:
+			return d.ops("rsb", d.flags16()), []string{rd, rm, "#0"}
+		case 13:
+			return d.ops("mul", d.flags16()), []string{rd, rm, rd}
+		}
+		return d.ops(thumbDataOps[op], d.flags16()), []string{rd, rm}
+
+	case w>>10 == 0x11:
+		rd := armReg(d.bits(7, 7)<<3 | d.bits(2, 0))
+		rm := armReg(d.bits(6, 3))
+		switch d.bits(9, 8) {
+		case 0:
+			if rd == "sp" || rd == rm && rm == "sp" {
+				return d.op("add"), []string{rd, rm}
+			}
+			if rm == "sp" {
+				return d.op("add"), []string{rd, "sp", rd}
+			}
+			return d.op("add"), []string{rd, rm}
+		case 1:
+			if d.bits(7, 6) == 0 {
+				return "", nil
+			}
+			return d.op("cmp"), []string{rd, rm}
+		case 2:
+			return d.op("mov"), []string{rd, rm}
+		}
+		if d.bits(2, 0) != 0 {
+			return "", nil
+		}
+		if d.bit(7) {
+			return d.op("blx"), []string{rm}
+		}
+		return d.op("bx"), []string{rm}
+
+	case w>>11 == 9:
+		v := d.bits(7, 0) * 4
+		d.literal(int64(v))
+		return d.op("ldr"), []string{d.lo(8), fmt.Sprintf("[pc, #%d]", v)}
+
+	case w>>12 == 5:
+		name := [8]string{"str", "strh", "strb", "ldrsb", "ldr", "ldrh", "ldrb", "ldrsh"}[d.bits(11, 9)]
+		return d.op(name), []string{d.lo(0), fmt.Sprintf("[%s, %s]", d.lo(3), d.lo(6))}
+
+	case w>>13 == 3, w>>12 == 8:
+		var name string
+		var scale uint32
+		switch w >> 11 {
+		case 0xc:
+			name, scale = "str", 4
+		case 0xd:
+			name, scale = "ldr", 4
+		case 0xe:
+			name, scale = "strb", 1
+		case 0xf:
+			name, scale = "ldrb", 1
+		case 0x10:
+			name, scale = "strh", 2
+		default:
+			name, scale = "ldrh", 2
+		}
+		return d.op(name), append([]string{d.lo(0)}, armMem(d.bits(5, 3), thumbOff(d.bits(10, 6)*scale), true, false)...)
+
+	case w>>12 == 9:
+		name := [2]string{"str", "ldr"}[d.bits(11, 11)]
+		return d.op(name), append([]string{d.lo(8)}, armMem(13, thumbOff(d.bits(7, 0)*4), true, false)...)
+
+	case w>>11 == 0x14:
+		v := d.bits(7, 0) * 4
+		d.literal(int64(v))
+		return d.op("adr"), []string{d.lo(8), imm(int64(v))}
+
+	case w>>11 == 0x15:
+		return d.op("add"), []string{d.lo(8), "sp", imm(int64(d.bits(7, 0) * 4))}
+
+	case w>>12 == 0xb:
+		return d.thumbMisc()
+
+	case w>>12 == 0xc:
+		rn, list := d.bits(10, 8), d.bits(7, 0)
+		if list == 0 {
+			return "", nil
+		}
+		if d.bit(11) {
+			base := armReg(rn)
+			if list>>rn&1 == 0 {
+				base += "!"
+			}
+			return d.op("ldm"), []string{base, armRegList(list)}
+		}
+		return d.op("stm"), []string{armReg(rn) + "!", armRegList(list)}
+
+	case w>>12 == 0xd:
+		switch cond := d.bits(11, 8); cond {
+		case 14:
+			return d.op("udf"), []string{imm(int64(d.bits(7, 0)))}
+		case 15:
+			return d.op("svc"), []string{imm(int64(d.bits(7, 0)))}
+		default:
+			if d.inIT {
+				return "", nil
+			}
+			d.cond = cond
+			return d.op("b"), []string{d.label(d.pcValue() + uint64(sext(d.bits(7, 0), 8)<<1))}
+		}
+
+	case w>>11 == 0x1c:
+		return d.op("b"), []string{d.label(d.pcValue() + uint64(sext(d.bits(10, 0), 11)<<1))}
+	}
+	return "", nil
+}
+
+// thumbOff formats an immediate offset that is omitted when zero.
+func thumbOff(v uint32) string {
+	if v == 0 {
+		return ""
+	}
+	return imm(int64(v))
+}
+
+func (d *a32) thumbMisc() (string, []string) {
+	w := d.w
+	switch {
+	case w>>7 == 0x160:
+		return d.op("add"), []string{"sp", imm(int64(d.bits(6, 0) * 4))}
+	case w>>7 == 0x161:
+		return d.op("sub"), []string{"sp", imm(int64(d.bits(6, 0) * 4))}
+	case w>>8&5 == 1:
+		if d.inIT {
+			return "", nil
+		}
+		name := [2]string{"cbz", "cbnz"}[d.bits(11, 11)]
+		off := d.bits(9, 9)<<6 | d.bits(7, 3)<<1
+		return name, []string{d.lo(0), d.label(d.pcValue() + uint64(off))}
+	case w>>8 == 0xb2:
+		name := [4]string{"sxth", "sxtb", "uxth", "uxtb"}[d.bits(7, 6)]
+		return d.op(name), []string{d.lo(0), d.lo(3)}
+	case w>>9&3 == 2:
+		list := d.bits(7, 0)
+		if d.bit(8) {
+			list |= 1 << [2]uint32{14, 15}[d.bits(11, 11)]
+		}
+		if list == 0 {
+			return "", nil
+		}
+		return d.op([2]string{"push", "pop"}[d.bits(11, 11)]), []string{armRegList(list)}
+	case w&0xffe8 == 0xb660:
+		if d.inIT {
+			return "", nil
+		}
+		flags := ""
+		for i, c := range "aif" {
+			if w>>(2-i)&1 != 0 {
+				flags += string(c)
+			}
+		}
+		if flags == "" {
+			flags = "none"
+		}
+		return [2]string{"cpsie", "cpsid"}[d.bits(4, 4)], []string{flags}
+	case w&0xfff7 == 0xb650:
+		return "setend", []string{[2]string{"le", "be"}[d.bits(3, 3)]}
+	case w>>8 == 0xba:
+		rd, rm := d.lo(0), d.lo(3)
+		switch d.bits(7, 6) {
+		case 0:
+			return d.op("rev"), []string{rd, rm}
+		case 1:
+			return d.op("rev16"), []string{rd, rm}
+		case 2:
+			return "hlt", []string{imm(int64(d.bits(5, 0)))}
+		}
+		return d.op("revsh"), []string{rd, rm}
+	case w>>8 == 0xbe:
+		return "bkpt", []string{imm(int64(d.bits(7, 0)))}
+	case w>>8 == 0xbf:
+		if mask := d.bits(3, 0); mask != 0 {
+			return d.it(d.bits(7, 4), mask)
+		}
+		return d.hint(d.bits(7, 4))
+	}
+	return "", nil
+}
+
+// it formats an IT instruction. The then/else pattern of the following
+// instructions is encoded in the mask relative to the low bit of the
+// first condition.
+func (d *a32) it(first, mask uint32) (string, []string) {
+	if first == 15 || first == 14 && bits.OnesCount32(mask) != 1 || d.inIT {
+		return "", nil
+	}
+	var b strings.Builder
+	b.WriteString("it")
+	for i := uint(3); mask&(1<<i-1) != 0; i-- {
+		if mask>>i&1 == first&1 {
+			b.WriteByte('t')
+		} else {
+			b.WriteByte('e')
+		}
+	}
+	return b.String(), []string{[16]string{
+		"eq", "ne", "hs", "lo", "mi", "pl", "vs", "vc",
+		"hi", "ls", "ge", "lt", "gt", "le", "al", "",
+	}[first]}
+}
+
+func (d *a32) thumb32() (string, []string) {
+	switch d.bits(28, 27) {
+	case 1:
+		switch {
+		case d.bits(26, 25) == 0 && !d.bit(22):
+			return d.t32Block()
+		case d.bits(26, 25) == 0:
+			return d.t32Dual()
+		case d.bits(26, 25) == 1:
+			return d.t32ShiftedReg()
+		}
+		return d.t32Coproc()
+	case 2:
+		switch {
+		case d.bit(15):
+			return d.t32Branch()
+		case d.bit(25):
+			return d.t32PlainImm()
+		}
+		return d.t32ModImm()
+	case 3:
+		switch {
+		case d.bits(26, 24) == 0 && d.bits(22, 20) != 7 && !(d.bit(24) && !d.bit(20)):
+			return d.t32LoadStore()
+		case d.bits(26, 24) == 1 && !d.bit(20):
+			return "", nil
+		case d.bits(26, 24) == 1:
+			return d.t32LoadStore()
+		case d.bits(26, 24) == 2:
+			return d.t32DataReg()
+		case d.bits(26, 23) == 6:
+			return d.t32Mul()
+		case d.bits(26, 23) == 7:
+			return d.t32LongMul()
+		case d.bit(26):
+			return d.t32Coproc()
+		}
+	}
+	return "", nil
+}
+
+func (d *a32) t32Block() (string, []string) {
+	op, w, l := d.bits(24, 23), d.bit(21), d.bit(20)
+	rn, list := d.bits(19, 16), d.bits(15, 0)
+	if op == 0 || op == 3 {
+		return d.t32RFE()
+	}
+	switch {
+	case rn == 13 && w && op == 2 && !l:
+		return d.opw("push", false), []string{armRegList(list)}
+	case rn == 13 && w && op == 1 && l:
+		return d.opw("pop", false), []string{armRegList(list)}
+	}
+	name := [2]string{"stm", "ldm"}[b2i(l)]
+	base := armReg(rn)
+	if w {
+		base += "!"
+	}
+	if op == 2 {
+		return d.op(name + "db"), []string{base, armRegList(list)}
+	}
+	return d.opw(name, false), []string{base, armRegList(list)}
+}
+
+// t32RFE decodes the system mode instructions that share their encoding
+// with the load and store multiple instructions.
+func (d *a32) t32RFE() (string, []string) {
+	rn := d.bits(19, 16)
+	switch {
+	case d.bit(20) && d.bits(15, 0) != 0xc000:
+		return "", nil
+	case !d.bit(20) && (rn != 13 || d.bits(15, 5) != 0x600):
+		return "", nil
+	}
+	mode := [4]string{"db", "", "", "ia"}[d.bits(24, 23)]
+	base := armReg(rn)
+	if d.bit(21) {
+		base += "!"
+	}
+	if d.bit(20) {
+		return d.op("rfe" + mode), []string{base}
+	}
+	args := []string{}
+	if rn == 13 && d.bit(21) {
+		args = append(args, "sp!")
+	} else {
+		args = append(args, "sp")
+	}
+	return d.op("srs" + mode), append(args, imm(int64(d.bits(4, 0))))
+}
+
+func (d *a32) t32Dual() (string, []string) {
+	p, u, w, l := d.bit(24), d.bit(23), d.bit(21), d.bit(20)
+	rn, rt, rt2 := d.bits(19, 16), d.bits(15, 12), d.bits(11, 8)
+	switch {
+	case p || w:
+		name := [2]string{"strd", "ldrd"}[b2i(l)]
+		v := d.bits(7, 0) * 4
+		off := armImm(v, u)
+		if p && !w && u && v == 0 {
+			off = ""
+		}
+		if rn == 15 && p && !w {
+			d.literal(armOff(v, u))
+		}
+		return d.op(name), append([]string{armReg(rt), armReg(rt2)}, armMem(rn, off, p, w)...)
+	case !u:
+		v := d.bits(7, 0) * 4
+		mem := armMem(rn, thumbOff(v), true, false)
+		if l {
+			return d.op("ldrex"), append([]string{armReg(rt)}, mem...)
+		}
+		return d.op("strex"), append([]string{armReg(rt2), armReg(rt)}, mem...)
+	}
+
+	op3, rd := d.bits(7, 4), d.bits(3, 0)
+	mem := "[" + armReg(rn) + "]"
+	if l {
+		switch op3 {
+		case 0:
+			return d.op("tbb"), []string{fmt.Sprintf("[%s, %s]", armReg(rn), armReg(rd))}
+		case 1:
+			return d.op("tbh"), []string{fmt.Sprintf("[%s, %s, lsl #1]", armReg(rn), armReg(rd))}
+		case 4, 5:
+			return d.op("ldrex" + "bh"[op3-4:op3-3]), []string{armReg(rt), mem}
+		case 7:
+			return d.op("ldrexd"), []string{armReg(rt), armReg(rt2), mem}
+		case 8, 9, 10:
+			return d.op("lda" + []string{"b", "h", ""}[op3-8]), []string{armReg(rt), mem}
+		case 12, 13, 14:
+			return d.op("ldaex" + []string{"b", "h", ""}[op3-12]), []string{armReg(rt), mem}
+		case 15:
+			return d.op("ldaexd"), []string{armReg(rt), armReg(rt2), mem}
+		}
+		return "", nil
+	}
+	switch op3 {
+	case 4, 5:
+		return d.op("strex" + "bh"[op3-4:op3-3]), []string{armReg(rd), armReg(rt), mem}
+	case 7:
+		return d.op("strexd"), []string{armReg(rd), armReg(rt), armReg(rt2), mem}
+	case 8, 9, 10:
+		return d.op("stl" + []string{"b", "h", ""}[op3-8]), []string{armReg(rt), mem}
+	case 12, 13, 14:
+		return d.op("stlex" + []string{"b", "h", ""}[op3-12]), []string{armReg(rd), armReg(rt), mem}
+	case 15:
+		return d.op("stlexd"), []string{armReg(rd), armReg(rt), armReg(rt2), mem}
+	}
+	return "", nil
+}
+
+// t32DataOp formats the data processing instructions shared by the
+// shifted register and modified immediate encodings. op2 is the second
+// operand with its shift, wide the instructions printed with .w because
+// a 16-bit encoding of them exists.
+func (d *a32) t32DataOp(op2 []string, wide map[string]bool) (string, []string) {
+	opc, s := d.bits(24, 21), d.bit(20)
+	rn, rd := d.bits(19, 16), d.bits(11, 8)
+	name := t32DataOps[opc]
+	args := []string{armReg(rd), armReg(rn)}
+	switch {
+	case name == "":
+		return "", nil
+	case rd == 15 && s && t32Compare[name] != "":
+		name, s = t32Compare[name], false
+		args = args[1:]
+	case rn == 15 && name == "orr":
+		name, args = "mov", args[:1]
+	case rn == 15 && name == "orn":
+		name, args = "mvn", args[:1]
+	}
+	if wide[name] {
+		return d.opw(name, s), append(args, op2...)
+	}
+	return d.ops(name, s), append(args, op2...)
+}
+
+var t32DataOps = [16]string{
+	"and", "bic", "orr", "orn", "eor", "", "", "",
+	"add", "", "adc", "sbc", "", "sub", "rsb", "",
+}
+
+// t32Compare maps the data processing instructions to the comparison
+// they turn into when the result is discarded.
+var t32Compare = map[string]string{"and": "tst", "eor": "teq", "add": "cmn", "sub": "cmp"}
+
+var (
+	t32ShiftedWide = map[string]bool{
+		"and": true, "bic": true, "orr": true, "eor": true, "add": true, "adc": true, "sbc": true,
+		"sub": true, "mvn": true, "mov": true, "tst": true, "teq": true, "cmn": true, "cmp": true,
+	}
+	t32ImmWide = map[string]bool{
+		"add": true, "sub": true, "rsb": true, "mov": true, "tst": true, "cmn": true, "cmp": true,
+	}
+)
+
+func (d *a32) t32ShiftedReg() (string, []string) {
+	rn, rd, rm := d.bits(19, 16), d.bits(11, 8), d.bits(3, 0)
+	typ, amount := d.bits(5, 4), d.bits(14, 12)<<2|d.bits(7, 6)
+	s := d.bit(20)
+	switch d.bits(24, 21) {
+	case 2:
+		if rn != 15 {
+			break
+		}
+		switch sh := armShift(typ, amount); sh {
+		case "":
+			return d.opw("mov", s), []string{armReg(rd), armReg(rm)}
+		case "rrx":
+			return d.ops("rrx", s), []string{armReg(rd), armReg(rm)}
+		default:
+			return d.opw(armShiftNames[typ], s), []string{armReg(rd), armReg(rm), sh[4:]}
+		}
+	case 6:
+		if s || typ&1 != 0 {
+			return "", nil
+		}
+		args := []string{armReg(rd), armReg(rn), armReg(rm)}
+		if typ == 2 {
+			if amount == 0 {
+				amount = 32
+			}
+			return d.op("pkhtb"), append(args, fmt.Sprintf("asr #%d", amount))
+		}
+		if amount != 0 {
+			args = append(args, fmt.Sprintf("lsl #%d", amount))
+		}
+		return d.op("pkhbt"), args
+	}
+	op2 := []string{armReg(rm)}
+	if sh := armShift(typ, amount); sh != "" {
+		op2 = append(op2, sh)
+	}
+	return d.t32DataOp(op2, t32ShiftedWide)
+}
+
+// thumbExpandImm expands the modified immediate constant of T32.
+func thumbExpandImm(imm12 uint32) uint32 {
+	v := imm12 & 0xff
+	if imm12>>10 == 0 {
+		switch imm12 >> 8 & 3 {
+		case 1:
+			return v<<16 | v
+		case 2:
+			return v<<24 | v<<8
+		case 3:
+			return v<<24 | v<<16 | v<<8 | v
+		}
+		return v
+	}
+	return bits.RotateLeft32(0x80|imm12&0x7f, -int(imm12>>7))
+}
+
+func (d *a32) t32ModImm() (string, []string) {
+	if d.bits(24, 21) == 6 {
+		return "", nil
+	}
+	v := thumbExpandImm(d.bits(26, 26)<<11 | d.bits(14, 12)<<8 | d.bits(7, 0))
+	return d.t32DataOp([]string{imm(int64(v))}, t32ImmWide)
+}
+
+func (d *a32) t32PlainImm() (string, []string) {
+	rn, rd := d.bits(19, 16), d.bits(11, 8)
+	imm12 := d.bits(26, 26)<<11 | d.bits(14, 12)<<8 | d.bits(7, 0)
+	lsb := d.bits(14, 12)<<2 | d.bits(7, 6)
+	switch d.bits(24, 20) {
+	case 0, 10:
+		sub := d.bit(23)
+		if rn == 15 {
+			off := int64(imm12)
+			if sub {
+				off = -off
+			}
+			d.literal(off)
+			return d.op("adr") + ".w", []string{armReg(rd), imm(off)}
+		}
+		return d.op([2]string{"addw", "subw"}[b2i(sub)]), []string{armReg(rd), armReg(rn), imm(int64(imm12))}
+	case 4, 12:
+		v := d.bits(19, 16)<<12 | imm12
+		return d.op([2]string{"movw", "movt"}[d.bits(23, 23)]), []string{armReg(rd), imm(int64(v))}
+	case 16, 18, 24, 26:
+		unsigned := d.bit(23)
+		sat := d.bits(4, 0)
+		if d.bit(21) && lsb == 0 {
+			if unsigned {
+				return d.op("usat16"), []string{armReg(rd), imm(int64(sat)), armReg(rn)}
+			}
+			return d.op("ssat16"), []string{armReg(rd), imm(int64(sat + 1)), armReg(rn)}
+		}
+		name := "ssat"
+		if unsigned {
+			name = "usat"
+		} else {
+			sat++
+		}
+		args := []string{armReg(rd), imm(int64(sat)), armReg(rn)}
+		switch {
+		case d.bit(21):
+			args = append(args, fmt.Sprintf("asr #%d", lsb))
+		case lsb != 0:
+			args = append(args, fmt.Sprintf("lsl #%d", lsb))
+		}
+		return d.op(name), args
+	case 20, 28:
+		name := [2]string{"sbfx", "ubfx"}[d.bits(23, 23)]
+		return d.op(name), []string{armReg(rd), armReg(rn), imm(int64(lsb)), imm(int64(d.bits(4, 0) + 1))}
+	case 22:
+		msb := d.bits(4, 0)
+		if msb < lsb {
+			return "", nil
+		}
+		if rn == 15 {
+			return d.op("bfc"), []string{armReg(rd), imm(int64(lsb)), imm(int64(msb - lsb + 1))}
+		}
+		return d.op("bfi"), []string{armReg(rd), armReg(rn), imm(int64(lsb)), imm(int64(msb - lsb + 1))}
+	}
+	return "", nil
+}
+
+func (d *a32) t32Branch() (string, []string) {
+	op1, op := d.bits(14, 12), d.bits(26, 20)
+	s := d.bits(26, 26)
+	j1, j2 := d.bits(13, 13), d.bits(11, 11)
+	switch {
+	case op1&5 == 0 && op>>3&7 != 7:
+		if d.inIT {
+			return "", nil
+		}
+		off := sext(s<<20|j2<<19|j1<<18|d.bits(21, 16)<<12|d.bits(10, 0)<<1, 21)
+		d.cond = d.bits(25, 22)
+		return d.op("b") + ".w", []string{d.label(uint64(int64(d.pcValue()) + off))}
+	case op1&5 == 0:
+		return d.t32BranchMisc()
+	}
+	i1, i2 := (j1^s)^1, (j2^s)^1
+	off := sext(s<<24|i1<<23|i2<<22|d.bits(25, 16)<<12|d.bits(10, 0)<<1, 25)
+	switch op1 & 5 {
+	case 1:
+		return d.op("b") + ".w", []string{d.label(uint64(int64(d.pcValue()) + off))}
+	case 4:
+		if d.bit(0) {
+			return "", nil
+		}
+		return d.op("blx"), []string{d.label(uint64(int64(d.pcValue()&^3) + off))}
+	}
+	return d.op("bl"), []string{d.label(uint64(int64(d.pcValue()) + off))}
+}
+
+func (d *a32) t32BranchMisc() (string, []string) {
+	op, op1 := d.bits(26, 20), d.bits(14, 12)
+	rn := d.bits(19, 16)
+	switch {
+	case op1 == 2 && op == 0x7f:
+		return d.op("udf") + ".w", []string{imm(int64(d.bits(19, 16)<<12 | d.bits(11, 0)))}
+	case op1 != 0:
+		return "", nil
+	case op&0x7e == 0x38:
+		if d.bit(5) {
+			return "", nil
+		}
+		mask := d.bits(11, 8)
+		if mask == 0 {
+			return "", nil
+		}
+		return d.op("msr"), []string{psrFields(d.bit(20), mask), armReg(rn)}
+	case op == 0x3a:
+		if d.bits(10, 8) != 0 {
+			return d.t32CPS()
+		}
+		return d.hint(d.bits(7, 0))
+	case op == 0x3b:
+		switch d.bits(7, 4) {
+		case 2:
+			return d.op("clrex"), nil
+		case 4:
+			switch d.bits(3, 0) {
+			case 0:
+				return "ssbb", nil
+			case 4:
+				return "pssbb", nil
+			}
+			name, args := armBarrier("dsb", d.bits(3, 0))
+			return d.op(name), args
+		case 5:
+			name, args := armBarrier("dmb", d.bits(3, 0))
+			return d.op(name), args
+		case 6:
+			name, args := armBarrier("isb", d.bits(3, 0))
+			return d.op(name), args
+		case 7:
+			return "sb", nil
+		}
+	case op == 0x3c:
+		return d.op("bxj"), []string{armReg(rn)}
+	case op == 0x3d:
+		if rn == 14 && d.bits(7, 0) == 0 {
+			return d.op("eret"), nil
+		}
+		return d.op("subs"), []string{"pc", "lr", imm(int64(d.bits(7, 0)))}
+	case op&0x7e == 0x3e:
+		if d.bit(5) {
+			return "", nil
+		}
+		return d.op("mrs"), []string{armReg(d.bits(11, 8)), [2]string{"apsr", "spsr"}[d.bits(20, 20)]}
+	case op == 0x7e:
+		return "hvc.w", []string{imm(int64(d.bits(19, 16)<<12 | d.bits(11, 0)))}
+	case op == 0x7f:
+		return d.op("smc"), []string{imm(int64(d.bits(19, 16)))}
+	}
+	return "", nil
+}
+
+func (d *a32) t32CPS() (string, []string) {
+	imod, m := d.bits(10, 9), d.bit(8)
+	var args []string
+	name := "cps"
+	switch imod {
+	case 2, 3:
+		name = [2]string{"cpsie", "cpsid"}[imod-2]
+		flags := ""
+		for i, c := range "aif" {
+			if d.w>>(7-i)&1 != 0 {
+				flags += string(c)
+			}
+		}
+		if flags == "" {
+			flags = "none"
+		}
+		args = append(args, flags)
+		name += ".w"
+	case 1:
+		return "", nil
+	default:
+		if !m {
+			return "", nil
+		}
+	}
+	if m {
+		args = append(args, imm(int64(d.bits(4, 0))))
+	}
+	return name, args
+}
+
+func (d *a32) t32LoadStore() (string, []string) {
+	signed, size, l := d.bit(24), d.bits(22, 21), d.bit(20)
+	rn, rt := d.bits(19, 16), d.bits(15, 12)
+	if !l && signed || size == 3 || signed && size == 2 {
+		return "", nil
+	}
+	name := [2][3]string{{"strb", "strh", "str"}, {"ldrb", "ldrh", "ldr"}}[b2i(l)][size]
+	if signed {
+		name = [2]string{"ldrsb", "ldrsh"}[size]
+	}
+
+	var mem []string
+	wide, writeback := true, false
+	switch {
+	case rn == 15:
+		if !l {
+			return "", nil
+		}
+		v, u := d.bits(11, 0), d.bit(23)
+		d.literal(armOff(v, u))
+		mem = []string{fmt.Sprintf("[pc, %s]", armImm(v, u))}
+	case d.bit(23):
+		mem = armMem(rn, thumbOff(d.bits(11, 0)), true, false)
+	case d.bits(11, 6) == 0:
+		off := armReg(d.bits(3, 0))
+		if sh := d.bits(5, 4); sh != 0 {
+			off += fmt.Sprintf(", lsl #%d", sh)
+		}
+		mem = []string{fmt.Sprintf("[%s, %s]", armReg(rn), off)}
+	case d.bits(11, 8) == 14:
+		return d.op(name + "t"), append([]string{armReg(rt)}, armMem(rn, thumbOff(d.bits(7, 0)), true, false)...)
+	case d.bit(11) && d.bits(10, 8)&5 != 0:
+		p, u, w := d.bit(10), d.bit(9), d.bit(8)
+		mem = armMem(rn, armImm(d.bits(7, 0), u), p, w)
+		wide, writeback = false, w
+	default:
+		return "", nil
+	}
+
+	// Byte and halfword loads into the PC are preload hints.
+	if l && rt == 15 && size < 2 && !writeback {
+		switch {
+		case signed && size == 0:
+			name = "pli"
+		case size == 0:
+			name = "pld"
+		case !signed && rn != 15:
+			name = "pldw"
+		case !signed:
+			name = "pld"
+		default:
+			return "", nil
+		}
+		return d.op(name), mem
+	}
+	if wide {
+		return d.op(name) + ".w", append([]string{armReg(rt)}, mem...)
+	}
+	return d.op(name), append([]string{armReg(rt)}, mem...)
+}
+
+func (d *a32) t32DataReg() (string, []string) {
+	op1, op2 := d.bits(23, 20), d.bits(7, 4)
+	rn, rd, rm := d.bits(19, 16), d.bits(11, 8), d.bits(3, 0)
+	if d.bits(15, 12) != 15 {
+		return "", nil
+	}
+	switch {
+	case op1>>3 == 0 && op2 == 0:
+		return d.opw(armShiftNames[op1>>1], op1&1 != 0), []string{armReg(rd), armReg(rn), armReg(rm)}
+	case op1 < 6 && op2&8 != 0:
+		name := [6]string{"sxth", "uxth", "sxtb16", "uxtb16", "sxtb", "uxtb"}[op1]
+		args := []string{armReg(rd)}
+		if rn != 15 {
+			name = name[:3] + "a" + name[3:]
+			args = append(args, armReg(rn))
+		}
+		args = append(args, armReg(rm))
+		if rot := d.bits(5, 4); rot != 0 {
+			args = append(args, fmt.Sprintf("ror #%d", rot*8))
+		}
+		if rn == 15 && op1 != 2 && op1 != 3 {
+			return d.opw(name, false), args
+		}
+		return d.op(name), args
+	case op1&8 != 0 && op2&8 == 0:
+		if op1&7 == 3 || op1&7 == 7 || op2&3 == 3 {
+			return "", nil
+		}
+		arith := [8]uint32{4, 0, 1, 0, 7, 3, 2, 0}[op1&7]
+		return d.parallel(op2&4 != 0, op2&3+1, arith, rd, rn, rm)
+	case op1>>2 == 2 && op2>>2 == 2:
+		switch op1&3<<2 | op2&3 {
+		case 0, 1, 2, 3:
+			name := [4]string{"qadd", "qdadd", "qsub", "qdsub"}[op2&3]
+			return d.op(name), []string{armReg(rd), armReg(rm), armReg(rn)}
+		case 4:
+			return d.opw("rev", false), []string{armReg(rd), armReg(rm)}
+		case 5:
+			return d.opw("rev16", false), []string{armReg(rd), armReg(rm)}
+		case 6:
+			return d.op("rbit"), []string{armReg(rd), armReg(rm)}
+		case 7:
+			return d.opw("revsh", false), []string{armReg(rd), armReg(rm)}
+		case 8:
+			return d.op("sel"), []string{armReg(rd), armReg(rn), armReg(rm)}
+		case 12:
+			return d.op("clz"), []string{armReg(rd), armReg(rm)}
+		}
+	}
+	return "", nil
+}
+
+func (d *a32) t32Mul() (string, []string) {
+	op1, op2 := d.bits(22, 20), d.bits(5, 4)
+	rn, ra, rd, rm := d.bits(19, 16), d.bits(15, 12), d.bits(11, 8), d.bits(3, 0)
+	if d.bits(7, 6) != 0 {
+		return "", nil
+	}
+	switch op1 {
+	case 0:
+		switch {
+		case op2 == 0 && ra == 15:
+			return d.op("mul"), []string{armReg(rd), armReg(rn), armReg(rm)}
+		case op2 == 0:
+			return d.op("mla"), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+		case op2 == 1:
+			return d.op("mls"), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+		}
+	case 1:
+		x, y := "bt"[op2>>1], "bt"[op2&1]
+		if ra == 15 {
+			return d.op(fmt.Sprintf("smul%c%c", x, y)), []string{armReg(rd), armReg(rn), armReg(rm)}
+		}
+		return d.op(fmt.Sprintf("smla%c%c", x, y)), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+	case 2, 4:
+		if op2 > 1 {
+			return "", nil
+		}
+		return d.signedMul(0, (op1>>2)<<1|op2, rd, ra, rm, rn)
+	case 3:
+		if op2 > 1 {
+			return "", nil
+		}
+		y := "bt"[op2]
+		if ra == 15 {
+			return d.op(fmt.Sprintf("smulw%c", y)), []string{armReg(rd), armReg(rn), armReg(rm)}
+		}
+		return d.op(fmt.Sprintf("smlaw%c", y)), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+	case 5, 6:
+		if op2 > 1 {
+			return "", nil
+		}
+		if op1 == 6 {
+			return d.signedMul(5, 6|op2, rd, ra, rm, rn)
+		}
+		return d.signedMul(5, op2, rd, ra, rm, rn)
+	case 7:
+		if op2 != 0 {
+			return "", nil
+		}
+		if ra == 15 {
+			return d.op("usad8"), []string{armReg(rd), armReg(rn), armReg(rm)}
+		}
+		return d.op("usada8"), []string{armReg(rd), armReg(rn), armReg(rm), armReg(ra)}
+	}
+	return "", nil
+}
+
+func (d *a32) t32LongMul() (string, []string) {
+	op1, op2 := d.bits(22, 20), d.bits(7, 4)
+	rn, lo, hi, rm := d.bits(19, 16), d.bits(15, 12), d.bits(11, 8), d.bits(3, 0)
+	long := []string{armReg(lo), armReg(hi), armReg(rn), armReg(rm)}
+	switch {
+	case op1 == 0 && op2 == 0:
+		return d.op("smull"), long
+	case op1 == 1 && op2 == 15, op1 == 3 && op2 == 15:
+		if lo != 15 {
+			return "", nil
+		}
+		return d.op([2]string{"sdiv", "udiv"}[op1>>1]), []string{armReg(hi), armReg(rn), armReg(rm)}
+	case op1 == 2 && op2 == 0:
+		return d.op("umull"), long
+	case op1 == 4 && op2 == 0:
+		return d.op("smlal"), long
+	case op1 == 4 && op2>>2 == 2:
+		return d.op(fmt.Sprintf("smlal%c%c", "bt"[op2>>1&1], "bt"[op2&1])), long
+	case op1 == 4 && op2>>1 == 6, op1 == 5 && op2>>1 == 6:
+		name := [2]string{"smlald", "smlsld"}[op1&1]
+		if op2&1 != 0 {
+			name += "x"
+		}
+		return d.op(name), long
+	case op1 == 6 && op2 == 0:
+		return d.op("umlal"), long
+	case op1 == 6 && op2 == 6:
+		return d.op("umaal"), long
+	}
+	return "", nil
+}
+
+// t32Coproc decodes the coprocessor and VFP instructions of T32, which
+// use the A32 layout with the condition field replaced.
+func (d *a32) t32Coproc() (string, []string) {
+	if d.bits(11, 9) == 5 {
+		if d.bit(28) {
+			return d.vfpUncond()
+		}
+		return d.vfp()
+	}
+	if d.bit(28) {
+		return d.coproc("2")
+	}
+	return d.coproc("")
+}
@@ -0,0 +1,1982 @@
+package disasm
+
+import (
+	"strings"
+)
+
+type x86ArgKind int
+
+const (
+	argReg x86ArgKind = iota
+	argMem
+	argImm
+	argRel
+	argFar
+)
+
+// x86Arg is a decoded operand.
+type x86Arg struct {
+	kind x86ArgKind
+	reg  string
+	mem  x86Mem
+	imm  uint64
+	seg  uint64 // segment of a far pointer
+
+	size   int  // size in bytes of a memory operand, 0 if untyped
+	intMem bool // memory operand of an integer instruction
+	gpr    bool // general purpose or segment register
+	io     bool // port number in dx, printed as (%dx)
+	hidden bool // implicit operand printed in Intel syntax only
+
+	// EVEX decorations.
+	mask      int    // opmask register k1-k7 of the destination, 0 if none
+	zero      bool   // zeroing rather than merging masking
+	bcst      int    // number of elements a broadcast memory operand fills
+	bcstCount bool   // print bcst in Intel syntax too, where the size is ambiguous
+	sae       string // rounding control or suppress all exceptions
+}
+
+type x86Mem struct {
+	seg     string
+	base    string
+	index   string
+	scale   int
+	disp    int64
+	hasDisp bool
+	rip     bool
+	moffs   bool
+	str     bool // string operand, segment always printed
+	mask    uint64
+}
+
+// x86Inst is a decoded instruction before formatting.
+type x86Inst struct {
+	prefixes []string
+	name     string
+	att      string
+	args     []x86Arg // Intel order
+	flags    x86Flags
+
+	opSize  int // operand size in bits
+	vexL    bool
+	zmm     bool // 512-bit EVEX vector length
+	isVEX   bool
+	target  uint64
+	hasTgt  bool
+	ripArg  int // index of a RIP-relative memory operand, -1 if none
+	isSIMD  bool
+	hasMem  bool
+	noAttSz bool // AT&T mnemonic already encodes the size
+}
+
+type x86Decoder struct {
+	mode int
+	code []byte
+	pos  int
+	pc   uint64
+
+	prefixes []byte
+	rex      byte
+	lock     bool
+	rep      byte
+	seg      byte
+	has66    bool
+	has67    bool
+
+	vex    bool
+	vexW   bool
+	vexL   bool
+	vexV   int
+	vexMap int
+	vexPP  int
+
+	// EVEX extends VEX with a second bit for the ModRM.reg and ModRM.r/m
+	// vector registers, a vector length of up to 512 bits, an opmask and
+	// embedded broadcast or rounding.
+	evex     bool
+	evexR2   int // 16 if EVEX.R' selects xmm16-31 for ModRM.reg
+	evexX    int // 16 if EVEX.X selects xmm16-31 for ModRM.r/m
+	evexLL   int
+	evexZ    bool
+	evexB    bool
+	evexMask int
+	dispN    int // EVEX disp8 scale, if not the size of the memory operand
+	vsib     int // vector length of a VSIB index register, 0 if none
+	badLen   int // length of an invalid instruction, if known
+
+	opSize   int
+	addrSize int
+
+	modrm    byte
+	hasModRM bool
+
+	used66  bool
+	usedRep bool
+	usedSeg bool
+	usedRex bool
+}
+
+var errTrunc = ErrBadInst
+
+func (d *x86Decoder) byte1() (byte, error) {
+	if d.pos >= len(d.code) || d.pos >= 15 {
+		return 0, errTrunc
+	}
+	b := d.code[d.pos]
+	d.pos++
+	return b, nil
+}
+
+func (d *x86Decoder) imm(n int) (uint64, error) {
+	if d.pos+n > len(d.code) || d.pos+n > 15 {
+		return 0, errTrunc
+	}
+	var v uint64
+	for i := n - 1; i >= 0; i-- {
+		v = v<<8 | uint64(d.code[d.pos+i])
+	}
+	d.pos += n
+	return v, nil
+}
+
+func (d *x86Decoder) getModRM() (byte, error) {
+	if !d.hasModRM {
+		b, err := d.byte1()
+		if err != nil {
+			return 0, err
+		}
+		d.modrm = b
+		d.hasModRM = true
+		d.usedRex = true
+	}
+	return d.modrm, nil
+}
+
+func (d *x86Decoder) rexW() bool { return d.rex&8 != 0 }
+func (d *x86Decoder) rexR() int {
+	if d.rex&4 != 0 {
+		return 8
+	}
+	return 0
+}
+func (d *x86Decoder) rexX() int {
+	if d.rex&2 != 0 {
+		return 8
+	}
+	return 0
+}
+func (d *x86Decoder) rexB() int {
+	if d.rex&1 != 0 {
+		return 8
+	}
+	return 0
+}
+
+// wide reports REX.W or, for VEX encoded instructions, VEX.W.
+func (d *x86Decoder) wide() bool {
+	if d.mode != 64 {
+		return false
+	}
+	if d.vex {
+		return d.vexW
+	}
+	return d.rexW()
+}
+
+var segNames = map[byte]string{0x26: "es", 0x2e: "cs", 0x36: "ss", 0x3e: "ds", 0x64: "fs", 0x65: "gs"}
+
+func decodeX86(code []byte, pc uint64, mode int, opts Options) (Inst, error) {
+	d := &x86Decoder{mode: mode, code: code, pc: pc}
+	inst, err := d.decode()
+	if err != nil {
+		if d.badLen > 0 {
+			return Inst{Addr: pc, Len: d.badLen, Text: "(bad)"}, err
+		}
+		return Inst{}, err
+	}
+	return inst.format(d, opts), nil
+}
+
+func (d *x86Decoder) decode() (*x86Inst, error) {
+	var b byte
+	for {
+		var err error
+		b, err = d.byte1()
+		if err != nil {
+			return nil, err
+		}
+		switch b {
+		case 0xf0:
+			d.lock = true
+		case 0xf2, 0xf3:
+			d.rep = b
+		case 0x26, 0x2e, 0x36, 0x3e, 0x64, 0x65:
+			d.seg = b
+		case 0x66:
+			d.has66 = true
+		case 0x67:
+			d.has67 = true
+		default:
+			if d.mode == 64 && b&0xf0 == 0x40 {
+				d.rex = b
+				next, err := d.byte1()
+				if err != nil {
+					return nil, err
+				}
+				if isLegacyPrefix(next) {
+					// A REX prefix followed by another prefix is ignored.
+					d.prefixes = append(d.prefixes, b)
+					d.rex = 0
+					d.pos--
+					continue
+				}
+				b = next
+			}
+			goto opcode
+		}
+		d.prefixes = append(d.prefixes, b)
+	}
+
+opcode:
+	d.opSize = 32
+	d.addrSize = d.mode
+	if d.has67 {
+		d.addrSize = d.mode / 2
+	}
+
+	inst := &x86Inst{ripArg: -1}
+
+	switch {
+	case b == 0x0f:
+		return d.decode0F(inst)
+	case (b == 0xc4 || b == 0xc5) && d.vexAllowed():
+		return d.decodeVEX(inst, b)
+	case b == 0x62 && (d.mode == 64 || d.peekMod3()):
+		return d.decodeEVEX(inst)
+	case b >= 0xd8 && b <= 0xdf:
+		return d.decodeX87(inst, b)
+	}
+
+	o := x86OneByte[b]
+	if o.name == "" || (d.mode == 64 && o.flags&xInv64 != 0) {
+		if d.mode == 64 && b == 0x63 {
+			o = op("movsxd", "Gv,Ed")
+		} else {
+			return nil, ErrBadInst
+		}
+	}
+
+	switch b {
+	case 0x90:
+		if d.rexB() == 0 {
+			if d.rep == 0xf3 {
+				d.usedRep = true
+				o = op("pause", "")
+			} else if !d.has66 {
+				o = op("nop", "")
+			}
+		}
+	case 0xc6, 0xc7:
+		m, err := d.getModRM()
+		if err != nil {
+			return nil, err
+		}
+		if m == 0xf8 {
+			if b == 0xc6 {
+				o = op("xabort", "Ib")
+			} else {
+				o = op("xbegin", "Jz", xBranch)
+			}
+		}
+	}
+
+	if o.flags&xGroup != 0 {
+		m, err := d.getModRM()
+		if err != nil {
+			return nil, err
+		}
+		reg := (m >> 3) & 7
+		switch o.name {
+		case "grp3":
+			args := o.args
+			if reg < 2 {
+				if b == 0xf6 {
+					args += ",Ib"
+				} else {
+					args += ",Iz"
+				}
+			}
+			o = op(x86Grp3[reg], args)
+		case "grp5":
+			o = x86Grp5[reg]
+			if b == 0xfe {
+				o = x86Groups["grp4"][reg]
+				o.args = "Eb"
+			}
+			if o.name == "" {
+				return nil, ErrBadInst
+			}
+		default:
+			g := x86Groups[o.name][reg]
+			if g.name == "" {
+				return nil, ErrBadInst
+			}
+			if g.args == "" {
+				g.args = o.args
+			}
+			g.flags |= o.flags &^ xGroup
+			o = g
+		}
+		if b == 0xfe && o.name == "" {
+			return nil, ErrBadInst
+		}
+	}
+	if b == 0xfe {
+		m, _ := d.getModRM()
+		if (m>>3)&7 > 1 {
+			return nil, ErrBadInst
+		}
+	}
+
+	return d.finish(inst, o, b)
+}
+
+func isLegacyPrefix(b byte) bool {
+	switch b {
+	case 0xf0, 0xf2, 0xf3, 0x26, 0x2e, 0x36, 0x3e, 0x64, 0x65, 0x66, 0x67:
+		return true
+	}
+	return false
+}
+
+func (d *x86Decoder) peekMod3() bool {
+	return d.pos < len(d.code) && d.code[d.pos]&0xc0 == 0xc0
+}
+
+// vexAllowed reports whether C4/C5 start a VEX prefix rather than LES/LDS.
+func (d *x86Decoder) vexAllowed() bool {
+	if d.mode == 64 {
+		return true
+	}
+	return d.peekMod3()
+}
+
+func (d *x86Decoder) decodeVEX(inst *x86Inst, b byte) (*x86Inst, error) {
+	if d.rex != 0 || d.has66 || d.rep != 0 || d.lock {
+		return nil, ErrBadInst
+	}
+	b1, err := d.byte1()
+	if err != nil {
+		return nil, err
+	}
+	var rex byte
+	if b == 0xc5 {
+		if b1&0x80 == 0 {
+			rex |= 4
+		}
+		d.vexMap = 1
+		d.vexV = int(^b1>>3) & 15
+		d.vexL = b1&4 != 0
+		d.vexPP = int(b1 & 3)
+	} else {
+		b2, err := d.byte1()
+		if err != nil {
+			return nil, err
+		}
+		if b1&0x80 == 0 {
+			rex |= 4
+		}
+		if b1&0x40 == 0 {
+			rex |= 2
+		}
+		if b1&0x20 == 0 {
+			rex |= 1
+		}
+		d.vexMap = int(b1 & 0x1f)
+		d.vexW = b2&0x80 != 0
+		d.vexV = int(^b2>>3) & 15
+		d.vexL = b2&4 != 0
+		d.vexPP = int(b2 & 3)
+	}
+	if d.mode != 64 {
+		rex = 0
+		d.vexV &= 7
+	}
+	d.rex = rex | 0x40
+	if rex == 0 {
+		d.rex = 0
+	}
+	d.vex = true
+	inst.isVEX = true
+	inst.vexL = d.vexL
+
+	opc, err := d.byte1()
+	if err != nil {
+		return nil, err
+	}
+
+	if d.vexMap == 1 {
+		switch {
+		case opc >= 0x90 && opc <= 0x93:
+			return d.decodeKmov(inst, opc)
+		case opc >= 0x41 && opc <= 0x4b, opc == 0x98, opc == 0x99:
+			return d.decodeKop(inst, opc)
+		case opc == 0x77 && d.vexPP == 0:
+			if d.vexL {
+				return d.finish(inst, op("vzeroall", "", xVEX), opc)
+			}
+			return d.finish(inst, op("vzeroupper", "", xVEX), opc)
+		}
+	}
+	if d.vexMap == 3 && opc >= 0x30 && opc <= 0x33 {
+		return d.decodeKop(inst, opc)
+	}
+	if d.vexMap == 2 && opc >= 0x90 && opc <= 0x93 {
+		return d.decodeGather(inst, opc)
+	}
+	o, err := d.vexOp(opc)
+	if err != nil {
+		return nil, err
+	}
+	return d.finish(inst, o, opc)
+}
+
+// vexOp looks up a VEX encoded opcode in the map selected by VEX.mmmmm.
+// Legacy SSE opcodes gain their "v" prefix.
+func (d *x86Decoder) vexOp(opc byte) (x86Op, error) {
+	var table map[byte]x86Variants
+	switch d.vexMap {
+	case 1:
+		table = x86TwoByte
+	case 2:
+		table = x86ThreeByte38
+	case 3:
+		table = x86ThreeByte3A
+	default:
+		return x86Op{}, ErrBadInst
+	}
+
+	// VEX.pp encodes the mandatory prefix: none, 66, F3, F2.
+	variants, ok := table[opc]
+	if !ok {
+		return x86Op{}, ErrBadInst
+	}
+	o := variants[d.vexPP]
+	if variants[0].flags&xAnyPrefix != 0 {
+		o = variants[0]
+	}
+	if o.name == "" && o.args == "" && o.flags&xGroup == 0 {
+		return x86Op{}, ErrBadInst
+	}
+	o, err := d.resolve0F(o, opc, d.vexMap)
+	if err != nil {
+		return x86Op{}, err
+	}
+	if o.flags&xVEX == 0 && !vexCapable(o.args) {
+		return x86Op{}, ErrBadInst
+	}
+	if o.flags&xVEX == 0 {
+		o.name = "v" + o.name
+	}
+
+	// Scalar moves between registers take a second source in VEX form.
+	if d.vexMap == 1 && (opc == 0x10 || opc == 0x11) && (d.vexPP == pfxF3 || d.vexPP == pfxF2) {
+		m, err := d.getModRM()
+		if err != nil {
+			return x86Op{}, err
+		}
+		if m>>6 == 3 {
+			if opc == 0x10 {
+				o.args = "Vdq,Hdq,Udq"
+			} else {
+				o.args = "Udq,Hdq,Vdq"
+			}
+		}
+	}
+	return o, nil
+}
+
+// decodeEVEX decodes an EVEX encoded AVX-512 instruction. Opcodes that
+// only exist in EVEX form come from x86EVEX; the others are their VEX
+// counterparts, widened to 32 registers and 512 bits.
+func (d *x86Decoder) decodeEVEX(inst *x86Inst) (*x86Inst, error) {
+	if d.rex != 0 || d.has66 || d.rep != 0 || d.lock {
+		return nil, ErrBadInst
+	}
+	p, err := d.imm(3)
+	if err != nil {
+		return nil, err
+	}
+	p0, p1, p2 := byte(p), byte(p>>8), byte(p>>16)
+	if p0&0x08 != 0 || p1&0x04 == 0 {
+		return nil, ErrBadInst
+	}
+	var rex byte
+	if p0&0x80 == 0 {
+		rex |= 4
+	}
+	if p0&0x40 == 0 {
+		rex |= 2
+	}
+	if p0&0x20 == 0 {
+		rex |= 1
+	}
+	d.vexMap = int(p0 & 7)
+	d.vexW = p1&0x80 != 0
+	d.vexV = int(^p1>>3) & 15
+	d.vexPP = int(p1 & 3)
+	d.evexZ = p2&0x80 != 0
+	d.evexLL = int(p2>>5) & 3
+	d.evexB = p2&0x10 != 0
+	d.evexMask = int(p2 & 7)
+	if d.mode == 64 {
+		if p0&0x10 == 0 {
+			d.evexR2 = 16
+		}
+		if p0&0x40 == 0 {
+			d.evexX = 16
+		}
+		if p2&0x08 == 0 {
+			d.vexV |= 16
+		}
+	} else {
+		rex = 0
+		d.vexV &= 7
+	}
+	d.rex = rex | 0x40
+	if rex == 0 {
+		d.rex = 0
+	}
+	d.vex, d.evex = true, true
+	d.vexL = d.evexLL != 0
+	inst.isVEX = true
+
+	opc, err := d.byte1()
+	if err != nil {
+		return nil, err
+	}
+	// Like objdump, skip the prefix and opcode of an invalid instruction
+	// to keep the listing in sync.
+	d.badLen = d.pos
+	m, err := d.getModRM()
+	if err != nil {
+		return nil, err
+	}
+	// With a register operand, EVEX.b turns L'L into a rounding mode and
+	// the vector length into 512 bits.
+	rc := -1
+	if d.evexB && m>>6 == 3 {
+		rc, d.evexLL, d.vexL = d.evexLL, 2, true
+	} else if d.evexLL == 3 {
+		return nil, ErrBadInst
+	}
+	inst.vexL = d.vexL
+
+	if d.vexMap == 2 && (opc >= 0x90 && opc <= 0x93 || opc >= 0xa0 && opc <= 0xa3) {
+		if _, err := d.decodeGather(inst, opc); err != nil {
+			return nil, err
+		}
+	} else {
+		o, ok := x86EVEX[evexKey{d.vexMap, opc, d.vexPP, d.vexW}]
+		if !ok {
+			if o, err = d.evexOp(opc); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := d.finish(inst, o, opc); err != nil {
+			return nil, err
+		}
+	}
+	// The xXYSuffix instructions narrow xmm or ymm to xmm, so a wider
+	// register operand or a broadcast already tells the vector length
+	// apart in AT&T syntax. Intel syntax gives the element count.
+	inst.zmm = d.vecLen() == 64
+	for _, a := range inst.args {
+		if a.kind == argReg && (strings.HasPrefix(a.reg, "ymm") || strings.HasPrefix(a.reg, "zmm")) {
+			inst.flags &^= xXYSuffix
+		}
+	}
+	for i := range inst.args {
+		if a := &inst.args[i]; a.bcst != 0 && inst.flags&xXYSuffix != 0 {
+			a.bcstCount = true
+			inst.flags &^= xXYSuffix
+		}
+	}
+
+	if rc >= 0 {
+		i := len(inst.args) - 1
+		for i > 0 && inst.args[i].kind == argImm {
+			i--
+		}
+		inst.args[i].sae = evexRounding(inst.name, rc)
+	}
+	if len(inst.args) > 0 {
+		inst.args[0].mask = d.evexMask
+		inst.args[0].zero = d.evexZ
+	}
+	return inst, nil
+}
+
+// decodeGather decodes the AVX2 and AVX-512 gathers, 0F38 90-93, and the
+// AVX-512 scatters, A0-A3, whose memory operand is indexed by a vector
+// register (VSIB). The low opcode bit selects qword rather than dword
+// indices, bit 1 floating point elements, and VEX.W the element size.
+func (d *x86Decoder) decodeGather(inst *x86Inst, opc byte) (*x86Inst, error) {
+	m, err := d.getModRM()
+	if err != nil {
+		return nil, err
+	}
+	scatter := opc >= 0xa0
+	if d.vexPP != pfx66 || m>>6 == 3 || m&7 != 4 || (scatter && !d.evex) || (d.evex && d.evexMask == 0) {
+		return nil, ErrBadInst
+	}
+	elem, vl := 4, d.vecLen()
+	if d.vexW {
+		elem = 8
+	}
+	index, data := vl, vl
+	switch {
+	case opc&1 == 0 && d.vexW:
+		index = vl / 2
+	case opc&1 != 0 && !d.vexW:
+		data = vl / 2
+	}
+
+	name := "vpgather"
+	if scatter {
+		name = "vpscatter"
+	}
+	if opc&2 != 0 {
+		name = "v" + name[2:]
+	}
+	name += map[bool]string{false: "d", true: "q"}[opc&1 != 0]
+	switch {
+	case opc&2 != 0:
+		name += map[bool]string{false: "ps", true: "pd"}[d.vexW]
+	default:
+		name += map[bool]string{false: "d", true: "q"}[d.vexW]
+	}
+
+	d.vsib = index
+	mem, err := d.memArg(elem)
+	d.vsib = 0
+	if err != nil {
+		return nil, err
+	}
+	reg := x86Arg{kind: argReg, reg: vecReg(int((m>>3)&7)+d.rexR()+d.evexR2, data)}
+	inst.name = name
+	inst.isSIMD, inst.hasMem = true, true
+	switch {
+	case scatter:
+		inst.args = []x86Arg{mem, reg}
+	case d.evex:
+		inst.args = []x86Arg{reg, mem}
+	default:
+		inst.args = []x86Arg{reg, mem, {kind: argReg, reg: vecReg(d.vexV, data)}}
+	}
+	return d.done(inst)
+}
+
+// evexRounding returns the rounding annotation of an instruction with a
+// register operand and EVEX.b set. Floating point arithmetic and
+// conversions take the rounding mode rc; comparisons and other operations
+// that do not round only suppress exceptions. Like objdump, other
+// instructions are marked bad.
+func evexRounding(name string, rc int) string {
+	n := strings.TrimPrefix(name, "v")
+	for _, p := range []string{"max", "min", "cmp", "comi", "ucomi", "cvtt", "cvtps2pd", "cvtss2sd", "cvtph2ps",
+		"getexp", "getmant", "rndscale", "reduce", "range", "fixupimm"} {
+		if strings.HasPrefix(n, p) {
+			return "sae"
+		}
+	}
+	mode := []string{"rn", "rd", "ru", "rz"}[rc]
+	for _, p := range []string{"add", "sub", "mul", "div", "sqrt", "scalef", "fmadd", "fmsub", "fnmadd", "fnmsub", "cvt"} {
+		if strings.HasPrefix(n, p) {
+			return mode + "-sae"
+		}
+	}
+	return mode + "-bad"
+}
+
+// evexOp returns the EVEX form of a VEX opcode. Some change their name or
+// write an opmask rather than a vector register.
+func (d *x86Decoder) evexOp(opc byte) (x86Op, error) {
+	if d.vexMap == 1 && opc == 0x72 && d.vexPP == pfx66 && (d.modrm>>3)&7 < 2 {
+		o := op("vpror", "Hx,Wx,Ib", xVEX, xWName)
+		if d.modrm&0x08 != 0 {
+			o.name = "vprol"
+		}
+		return o, nil
+	}
+	o, err := d.vexOp(opc)
+	if err != nil {
+		return x86Op{}, err
+	}
+	name := strings.TrimPrefix(o.name, "v")
+	if x86NoEVEX[name] || strings.Contains(o.args, "L") {
+		return x86Op{}, ErrBadInst
+	}
+	if d.vexMap == 1 && opc >= 0x71 && opc <= 0x73 {
+		// The shifts by an immediate also take a memory operand.
+		o.args = "Hx,Wx,Ib"
+	}
+	switch name {
+	case "pand", "pandn", "por", "pxor":
+		o.flags |= xWName
+	case "pcmpeqb", "pcmpeqw", "pcmpeqd", "pcmpeqq", "pcmpgtb", "pcmpgtw", "pcmpgtd", "pcmpgtq",
+		"cmpps", "cmppd", "cmpss", "cmpsd":
+		o.args = "K" + o.args[strings.IndexByte(o.args, ','):]
+	}
+	if d.vexW {
+		if w1, ok := x86EVEXW1[name]; ok {
+			o.name = "v" + w1
+		}
+	}
+	return o, nil
+}
+
+// decodeKmov decodes the AVX-512 opmask moves, VEX 0F 90-93, which move a
+// k register to or from another k register, memory or a general register.
+// VEX.pp and VEX.W select the width: kmovw, kmovb, kmovq and kmovd.
+func (d *x86Decoder) decodeKmov(inst *x86Inst, opc byte) (*x86Inst, error) {
+	if d.vexL || d.vexV != 0 {
+		return nil, ErrBadInst
+	}
+	var name string
+	switch {
+	case opc >= 0x92 && d.vexPP == pfxF2:
+		name = map[bool]string{false: "kmovd", true: "kmovq"}[d.vexW]
+	case opc >= 0x92 && d.vexW:
+		return nil, ErrBadInst
+	case d.vexPP == pfxNone:
+		name = map[bool]string{false: "kmovw", true: "kmovq"}[d.vexW]
+	case d.vexPP == pfx66:
+		name = map[bool]string{false: "kmovb", true: "kmovd"}[d.vexW]
+	default:
+		return nil, ErrBadInst
+	}
+	size := map[string]int{"kmovb": 1, "kmovw": 2, "kmovd": 4, "kmovq": 8}[name]
+
+	m, err := d.getModRM()
+	if err != nil {
+		return nil, err
+	}
+	k := x86Arg{kind: argReg, reg: "k" + itoa(int(m>>3)&7)}
+	var rm x86Arg
+	switch {
+	case m>>6 != 3:
+		if opc >= 0x92 {
+			return nil, ErrBadInst
+		}
+		if rm, err = d.memArg(size); err != nil {
+			return nil, err
+		}
+		inst.hasMem = true
+		if rm.mem.rip {
+			inst.ripArg = 0
+			if opc == 0x90 {
+				inst.ripArg = 1
+			}
+		}
+	case opc <= 0x91:
+		if opc == 0x91 {
+			return nil, ErrBadInst
+		}
+		rm = x86Arg{kind: argReg, reg: "k" + itoa(int(m&7))}
+	case opc == 0x92:
+		rm = x86Arg{kind: argReg, reg: gprName(int(m&7)+d.rexB(), max(size, 4), false), gpr: true}
+	default:
+		// kmov to a general register takes it from ModRM.reg.
+		k = x86Arg{kind: argReg, reg: "k" + itoa(int(m&7))}
+		rm = x86Arg{kind: argReg, reg: gprName(int(m>>3)&7+d.rexR(), max(size, 4), false), gpr: true}
+	}
+
+	inst.name = name
+	inst.opSize = size * 8
+	inst.noAttSz = true
+	if opc == 0x90 || opc == 0x92 {
+		inst.args = []x86Arg{k, rm}
+	} else {
+		inst.args = []x86Arg{rm, k}
+	}
+	return d.done(inst)
+}
+
+// decodeKop decodes the other VEX encoded opmask instructions: logic,
+// kadd, kunpck and the tests in map 1 and the shifts in map 3. Like kmov,
+// VEX.pp and VEX.W select the width.
+func (d *x86Decoder) decodeKop(inst *x86Inst, opc byte) (*x86Inst, error) {
+	width := map[[2]bool]string{{false, false}: "w", {false, true}: "q", {true, false}: "b", {true, true}: "d"}
+	var name string
+	unary := false
+	switch {
+	case d.vexMap == 3:
+		if d.vexPP != pfx66 {
+			return nil, ErrBadInst
+		}
+		dir := "r"
+		if opc >= 0x32 {
+			dir = "l"
+		}
+		w := map[[2]bool]string{{false, false}: "b", {false, true}: "w", {true, false}: "d", {true, true}: "q"}
+		name = "kshift" + dir + w[[2]bool{opc&1 != 0, d.vexW}]
+		unary = true
+	case d.vexPP != pfxNone && d.vexPP != pfx66:
+		return nil, ErrBadInst
+	case opc == 0x4b:
+		names := map[[2]bool]string{{false, false}: "kunpckwd", {false, true}: "kunpckdq", {true, false}: "kunpckbw"}
+		if name = names[[2]bool{d.vexPP == pfx66, d.vexW}]; name == "" {
+			return nil, ErrBadInst
+		}
+	default:
+		base := map[byte]string{0x41: "kand", 0x42: "kandn", 0x44: "knot", 0x45: "kor", 0x46: "kxnor",
+			0x47: "kxor", 0x4a: "kadd", 0x98: "kortest", 0x99: "ktest"}[opc]
+		if base == "" {
+			return nil, ErrBadInst
+		}
+		name = base + width[[2]bool{d.vexPP == pfx66, d.vexW}]
+		unary = opc == 0x44 || opc >= 0x98
+	}
+	if unary && (d.vexL || d.vexV != 0) || !unary && (!d.vexL || d.vexV > 7) {
+		return nil, ErrBadInst
+	}
+
+	m, err := d.getModRM()
+	if err != nil {
+		return nil, err
+	}
+	if m>>6 != 3 {
+		return nil, ErrBadInst
+	}
+	inst.name = name
+	inst.args = []x86Arg{{kind: argReg, reg: "k" + itoa(int(m>>3)&7)}}
+	if !unary {
+		inst.args = append(inst.args, x86Arg{kind: argReg, reg: "k" + itoa(d.vexV)})
+	}
+	inst.args = append(inst.args, x86Arg{kind: argReg, reg: "k" + itoa(int(m&7))})
+	if d.vexMap == 3 {
+		v, err := d.imm(1)
+		if err != nil {
+			return nil, err
+		}
+		inst.args = append(inst.args, x86Arg{kind: argImm, imm: v})
+	}
+	return d.done(inst)
+}
+
+// vexCapable reports whether a legacy SSE opcode has a VEX form: it must
+// operate on xmm registers rather than MMX or general registers only.
+func vexCapable(args string) bool {
+	if strings.ContainsAny(args, "PQN") || strings.Contains(args, "XMM0") {
+		return false
+	}
+	return strings.ContainsAny(args, "VWUH")
+}
+
+func (d *x86Decoder) decode0F(inst *x86Inst) (*x86Inst, error) {
+	b, err := d.byte1()
+	if err != nil {
+		return nil, err
+	}
+	table, mapNum := x86TwoByte, 1
+	switch b {
+	case 0x38:
+		table, mapNum = x86ThreeByte38, 2
+	case 0x3a:
+		table, mapNum = x86ThreeByte3A, 3
+	}
+	if mapNum != 1 {
+		if b, err = d.byte1(); err != nil {
+			return nil, err
+		}
+	}
+
+	variants, ok := table[b]
+	if !ok {
+		return nil, ErrBadInst
+	}
+
+	o := variants[pfxNone]
+	if variants[0].flags&xAnyPrefix == 0 {
+		switch {
+		case d.rep == 0xf3 && !variants[pfxF3].empty():
+			o = variants[pfxF3]
+			d.usedRep = true
+		case d.rep == 0xf2 && !variants[pfxF2].empty():
+			o = variants[pfxF2]
+			d.usedRep = true
+		case d.has66 && !variants[pfx66].empty():
+			o = variants[pfx66]
+			d.used66 = true
+		}
+	}
+	if o.empty() {
+		return nil, ErrBadInst
+	}
+	if o.flags&xVEX != 0 {
+		return nil, ErrBadInst
+	}
+
+	// Special forms selected by ModRM.
+	if mapNum == 1 {
+		switch b {
+		case 0x1e:
+			m, err := d.getModRM()
+			if err != nil {
+				return nil, err
+			}
+			if d.rep == 0xf3 {
+				switch {
+				case m == 0xfa:
+					d.usedRep = true
+					o = op("endbr64", "")
+				case m == 0xfb:
+					d.usedRep = true
+					o = op("endbr32", "")
+				case m>>6 == 3 && (m>>3)&7 == 1:
+					d.usedRep = true
+					o = op("rdssp", "Ry", xWName)
+				}
+			}
+		case 0x12, 0x16:
+			m, err := d.getModRM()
+			if err != nil {
+				return nil, err
+			}
+			if m>>6 == 3 && !d.usedRep && o.name == "movlps" {
+				o = op("movhlps", "Vdq,Hdq,Udq")
+			} else if m>>6 == 3 && !d.usedRep && o.name == "movhps" {
+				o = op("movlhps", "Vdq,Hdq,Udq")
+			}
+		}
+	}
+
+	o, err = d.resolve0F(o, b, mapNum)
+	if err != nil {
+		return nil, err
+	}
+	return d.finish(inst, o, b)
+}
+
+func (o x86Op) empty() bool { return o.name == "" && o.args == "" && o.flags == 0 }
+
+// resolve0F expands groups in the 0F opcode maps.
+func (d *x86Decoder) resolve0F(o x86Op, b byte, mapNum int) (x86Op, error) {
+	if mapNum == 1 && (b == 0x71 || b == 0x72 || b == 0x73) {
+		m, err := d.getModRM()
+		if err != nil {
+			return o, err
+		}
+		if m>>6 != 3 && !d.evex {
+			return o, ErrBadInst
+		}
+		name := x86ShiftImm[b][(m>>3)&7]
+		if name == "" || (!d.used66 && !d.vex && (name == "psrldq" || name == "pslldq")) {
+			return o, ErrBadInst
+		}
+		o.name = name
+		return o, nil
+	}
+	if o.flags&xGroup == 0 {
+		return o, nil
+	}
+
+	m, err := d.getModRM()
+	if err != nil {
+		return o, err
+	}
+	mod, reg := m>>6, (m>>3)&7
+	switch o.name {
+	case "grp7":
+		if mod == 3 {
+			switch reg {
+			case 4:
+				return op("smsw", "Rv"), nil
+			case 6:
+				return op("lmsw", "Rw"), nil
+			}
+			r, ok := x86Grp7Reg[m]
+			if !ok {
+				return o, ErrBadInst
+			}
+			return r, nil
+		}
+	case "grp9":
+		switch {
+		case mod == 3 && reg == 6:
+			return op("rdrand", "Rv"), nil
+		case mod == 3 && reg == 7:
+			return op("rdseed", "Rv"), nil
+		case mod != 3 && reg == 1 && d.rexW():
+			return op("cmpxchg16b", "Mdq", xNoSuffix), nil
+		}
+	case "grp15":
+		if mod == 3 {
+			if d.rep == 0xf3 && reg < 4 {
+				d.usedRep = true
+				return op([]string{"rdfsbase", "rdgsbase", "wrfsbase", "wrgsbase"}[reg], "Ry"), nil
+			}
+			if d.rep == 0xf3 && reg == 5 {
+				d.usedRep = true
+				return op("incssp", "Ry", xWName), nil
+			}
+			switch reg {
+			case 5:
+				return op("lfence", ""), nil
+			case 6:
+				return op("mfence", ""), nil
+			case 7:
+				return op("sfence", ""), nil
+			}
+			return o, ErrBadInst
+		}
+		if d.has66 && reg == 6 {
+			d.used66 = true
+			return op("clwb", "Mb", xNoSuffix), nil
+		}
+		if d.has66 && reg == 7 {
+			d.used66 = true
+			return op("clflushopt", "Mb", xNoSuffix), nil
+		}
+	case "grp16":
+		if mod == 3 {
+			return op("nop", "Ev"), nil
+		}
+	}
+
+	g := x86Groups[o.name][reg]
+	if g.name == "" {
+		return o, ErrBadInst
+	}
+	if g.args == "" {
+		g.args = o.args
+	}
+	g.flags |= o.flags &^ (xGroup | xAnyPrefix)
+	if g.flags&xVEX != 0 && !d.vex {
+		return o, ErrBadInst
+	}
+	return g, nil
+}
+
+func (d *x86Decoder) decodeX87(inst *x86Inst, b byte) (*x86Inst, error) {
+	m, err := d.getModRM()
+	if err != nil {
+		return nil, err
+	}
+	esc := b - 0xd8
+	reg := (m >> 3) & 7
+	inst.flags |= xNoSuffix
+
+	if m>>6 != 3 {
+		mo := x87MemOps[esc][reg]
+		if mo.name == "" {
+			return nil, ErrBadInst
+		}
+		arg, err := d.memArg(mo.size)
+		if err != nil {
+			return nil, err
+		}
+		inst.name = mo.name
+		inst.att = mo.att
+		inst.noAttSz = true
+		inst.args = []x86Arg{arg}
+		inst.hasMem = true
+		if arg.mem.rip {
+			inst.ripArg = 0
+		}
+		return d.done(inst)
+	}
+
+	if name, ok := x87Special[[2]byte{b, m}]; ok {
+		inst.name = name
+		return d.done(inst)
+	}
+	if b == 0xdf && m == 0xe0 {
+		inst.name = "fnstsw"
+		inst.args = []x86Arg{{kind: argReg, reg: "ax", gpr: true}}
+		return d.done(inst)
+	}
+
+	ro := x87RegOps[esc][reg]
+	if ro.name == "" {
+		return nil, ErrBadInst
+	}
+	inst.name = ro.name
+	inst.att = ro.att
+	sti := x86Arg{kind: argReg, reg: x87Name(int(m & 7))}
+	st := x86Arg{kind: argReg, reg: "st"}
+	switch ro.args {
+	case "st,sti":
+		inst.args = []x86Arg{st, sti}
+	case "sti,st":
+		inst.args = []x86Arg{sti, st}
+	case "sti":
+		inst.args = []x86Arg{sti}
+	}
+	return d.done(inst)
+}
+
+func x87Name(i int) string {
+	return "st(" + string(rune('0'+i)) + ")"
+}
+
+// finish decodes the operands of a table entry and fills in the
+// instruction.
+func (d *x86Decoder) finish(inst *x86Inst, o x86Op, opc byte) (*x86Inst, error) {
+	if o.flags&xVEX != 0 && !d.vex {
+		return nil, ErrBadInst
+	}
+	inst.flags |= o.flags
+	inst.name = o.name
+	if i := strings.IndexByte(o.name, '|'); i >= 0 {
+		inst.name, inst.att = o.name[:i], o.name[i+1:]
+	}
+
+	// Operand size.
+	switch {
+	case d.mode == 64 && d.rexW() && !d.vex:
+		d.opSize = 64
+	case d.mode == 64 && o.flags&xDef64 != 0:
+		d.opSize = 64
+		if d.has66 {
+			d.opSize = 16
+		}
+	case d.has66 && !d.used66:
+		d.opSize = 16
+	}
+	inst.opSize = d.opSize
+
+	if o.args != "" {
+		for _, tok := range strings.Split(o.args, ",") {
+			if strings.HasPrefix(tok, "H") && !d.vex {
+				continue
+			}
+			if tok == "B" || strings.HasPrefix(tok, "By") {
+				if !d.vex {
+					continue
+				}
+			}
+			arg, err := d.operand(tok, inst, len(inst.args))
+			if err != nil {
+				return nil, err
+			}
+			inst.args = append(inst.args, arg)
+		}
+	}
+	if strings.ContainsAny(o.args, "VWUHPQN") {
+		inst.isSIMD = true
+	}
+
+	d.fixNames(inst, o, opc)
+	return d.done(inst)
+}
+
+// operand decodes a single operand described by tok.
+func (d *x86Decoder) operand(tok string, inst *x86Inst, idx int) (x86Arg, error) {
+	switch tok {
+	case "AL":
+		return x86Arg{kind: argReg, reg: "al", gpr: true}, nil
+	case "CL":
+		return x86Arg{kind: argReg, reg: "cl", gpr: true}, nil
+	case "rAX":
+		d.markOpSize()
+		return x86Arg{kind: argReg, reg: gprName(0, d.opSize/8, false), gpr: true}, nil
+	case "eAX":
+		d.markOpSize()
+		size := 4
+		if d.opSize == 16 {
+			size = 2
+		}
+		return x86Arg{kind: argReg, reg: gprName(0, size, false), gpr: true}, nil
+	case "DXio":
+		return x86Arg{kind: argReg, reg: "dx", io: true}, nil
+	case "1":
+		return x86Arg{kind: argImm, imm: 1, hidden: true}, nil
+	case "ES", "CS", "SS", "DS", "FS", "GS":
+		return x86Arg{kind: argReg, reg: strings.ToLower(tok), gpr: true}, nil
+	case "XMM0":
+		return x86Arg{kind: argReg, reg: "xmm0"}, nil
+	case "XLAT":
+		d.usedSeg = d.usedSeg || d.seg != 0
+		return d.stringArg("bx", "ds", 1, true), nil
+	case "MONITOR":
+		return x86Arg{}, nil
+	case "MWAIT":
+		return x86Arg{}, nil
+	}
+
+	if strings.HasPrefix(tok, "sI") {
+		v, err := d.imm(1)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		d.markOpSize()
+		return x86Arg{kind: argImm, imm: signExtend(v, 8) & sizeMask(d.opSize)}, nil
+	}
+
+	mode, size := tok[0], tok[1:]
+	switch mode {
+	case 'I':
+		return d.immArg(size)
+	case 'J':
+		n := 4
+		if size == "b" {
+			n = 1
+		} else if d.opSize == 16 && d.mode != 64 {
+			n = 2
+		}
+		v, err := d.imm(n)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		// The target is relative to the end of the instruction, which
+		// for all branches is the end of this immediate.
+		tgt := d.pc + uint64(d.pos) + signExtend(v, n*8)
+		if d.mode != 64 {
+			tgt &= 0xffffffff
+		}
+		inst.target, inst.hasTgt = tgt, true
+		return x86Arg{kind: argRel, imm: tgt}, nil
+	case 'A':
+		n := 4
+		if d.opSize == 16 {
+			n = 2
+		}
+		off, err := d.imm(n)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		seg, err := d.imm(2)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		return x86Arg{kind: argFar, imm: off, seg: seg}, nil
+	case 'O':
+		v, err := d.imm(d.addrSize / 8)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		if size == "v" {
+			d.markOpSize()
+		}
+		m := x86Mem{disp: int64(v), moffs: true, mask: sizeMask(d.addrSize)}
+		d.applySeg(&m)
+		return x86Arg{kind: argMem, mem: m, size: d.sizeOf(size), intMem: true}, nil
+	case 'X', 'Y':
+		if size == "v" || size == "z" {
+			d.markOpSize()
+		}
+		reg, seg := "si", "ds"
+		if mode == 'Y' {
+			reg, seg = "di", "es"
+		}
+		return d.stringArg(reg, seg, d.sizeOf(size), mode == 'X'), nil
+	case 'Z':
+		r := int(d.code[d.pos-1]&7) + d.rexB()
+		d.usedRex = true
+		sz := d.sizeOf(size)
+		return x86Arg{kind: argReg, reg: gprName(r, sz, d.rex != 0), gpr: true}, nil
+	case 'G':
+		m, err := d.getModRM()
+		if err != nil {
+			return x86Arg{}, err
+		}
+		r := int((m>>3)&7) + d.rexR()
+		return x86Arg{kind: argReg, reg: gprName(r, d.sizeOf(size), d.rex != 0), gpr: true}, nil
+	case 'B':
+		return x86Arg{kind: argReg, reg: gprName(d.vexV, d.sizeOf(size), true), gpr: true}, nil
+	case 'S':
+		m, err := d.getModRM()
+		if err != nil {
+			return x86Arg{}, err
+		}
+		r := (m >> 3) & 7
+		if r > 5 {
+			return x86Arg{}, ErrBadInst
+		}
+		return x86Arg{kind: argReg, reg: []string{"es", "cs", "ss", "ds", "fs", "gs"}[r], gpr: true}, nil
+	case 'C', 'D':
+		m, err := d.getModRM()
+		if err != nil {
+			return x86Arg{}, err
+		}
+		r := int((m>>3)&7) + d.rexR()
+		if mode == 'C' {
+			return x86Arg{kind: argReg, reg: "cr" + itoa(r)}, nil
+		}
+		return x86Arg{kind: argReg, reg: "db" + itoa(r)}, nil
+	case 'V':
+		m, err := d.getModRM()
+		if err != nil {
+			return x86Arg{}, err
+		}
+		r := int((m>>3)&7) + d.rexR() + d.evexR2
+		return x86Arg{kind: argReg, reg: d.vecName(r, size)}, nil
+	case 'K':
+		m, err := d.getModRM()
+		if err != nil {
+			return x86Arg{}, err
+		}
+		return x86Arg{kind: argReg, reg: "k" + itoa(int(m>>3)&7)}, nil
+	case 'H':
+		return x86Arg{kind: argReg, reg: d.vecName(d.vexV, size)}, nil
+	case 'L':
+		v, err := d.imm(1)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		r := int(v >> 4)
+		if d.mode != 64 {
+			r &= 7
+		}
+		return x86Arg{kind: argReg, reg: d.vecName(r, size)}, nil
+	case 'P':
+		m, err := d.getModRM()
+		if err != nil {
+			return x86Arg{}, err
+		}
+		return x86Arg{kind: argReg, reg: "mm" + itoa(int((m>>3)&7))}, nil
+	}
+
+	// The remaining methods address ModRM.r/m.
+	m, err := d.getModRM()
+	if err != nil {
+		return x86Arg{}, err
+	}
+	if m>>6 == 3 {
+		r := int(m&7) + d.rexB()
+		switch mode {
+		case 'M':
+			return x86Arg{}, ErrBadInst
+		case 'E', 'R':
+			sz := d.sizeOf(size)
+			switch size {
+			case "db", "dw":
+				sz = 4
+			case "cr":
+				sz = d.mode / 8
+			}
+			if sz == 0 {
+				sz = 4
+			}
+			return x86Arg{kind: argReg, reg: gprName(r, sz, d.rex != 0), gpr: true}, nil
+		case 'W', 'U':
+			return x86Arg{kind: argReg, reg: d.vecName(r+d.evexX, size)}, nil
+		case 'k':
+			return x86Arg{kind: argReg, reg: "k" + itoa(int(m&7))}, nil
+		case 'Q', 'N':
+			return x86Arg{kind: argReg, reg: "mm" + itoa(int(m&7))}, nil
+		}
+		return x86Arg{}, ErrBadInst
+	}
+
+	switch mode {
+	case 'R', 'U', 'N', 'k':
+		return x86Arg{}, ErrBadInst
+	}
+	sz := d.sizeOf(size)
+	switch size {
+	case "db":
+		sz = 1
+	case "dw":
+		sz = 2
+	}
+	// An EVEX broadcast reads a single element and repeats it.
+	bcst, elem := 0, 4
+	if d.vexW {
+		elem = 8
+	}
+	if d.evexB && mode == 'W' && (size == "x" || size == "h") {
+		bcst, sz = sz/elem, elem
+	}
+	if inst.flags&xElemDisp != 0 {
+		d.dispN = elem
+	}
+	arg, err := d.memArg(sz)
+	if err != nil {
+		return x86Arg{}, err
+	}
+	arg.bcst = bcst
+	arg.intMem = mode == 'E' || (mode == 'M' && size != "" && size != "x" && size != "dq" && size != "qq")
+	if arg.mem.rip {
+		inst.ripArg = idx
+	}
+	inst.hasMem = true
+	return arg, nil
+}
+
+func (d *x86Decoder) stringArg(reg, seg string, size int, overridable bool) x86Arg {
+	if overridable && d.seg != 0 {
+		seg = segNames[d.seg]
+		d.usedSeg = true
+	}
+	m := x86Mem{seg: seg, base: gprName(regIndex(reg), d.addrSize/8, false), str: true}
+	return x86Arg{kind: argMem, mem: m, size: size, intMem: true}
+}
+
+func regIndex(name string) int {
+	switch name {
+	case "si":
+		return 6
+	case "di":
+		return 7
+	case "bx":
+		return 3
+	}
+	return 0
+}
+
+func (d *x86Decoder) immArg(size string) (x86Arg, error) {
+	var n int
+	switch size {
+	case "b":
+		n = 1
+	case "w":
+		n = 2
+	case "z":
+		n = 4
+		d.markOpSize()
+		if d.opSize == 16 {
+			n = 2
+		}
+	case "v":
+		d.markOpSize()
+		n = d.opSize / 8
+	default:
+		return x86Arg{}, ErrBadInst
+	}
+	v, err := d.imm(n)
+	if err != nil {
+		return x86Arg{}, err
+	}
+	if size == "z" {
+		v = signExtend(v, n*8) & sizeMask(d.opSize)
+	}
+	return x86Arg{kind: argImm, imm: v}, nil
+}
+
+func (d *x86Decoder) markOpSize() {
+	if d.has66 && d.opSize == 16 {
+		d.used66 = true
+	}
+	d.usedRex = true
+}
+
+// sizeOf returns the size in bytes of an operand size code.
+func (d *x86Decoder) sizeOf(code string) int {
+	switch code {
+	case "b":
+		return 1
+	case "w":
+		return 2
+	case "d":
+		return 4
+	case "q":
+		return 8
+	case "v":
+		d.markOpSize()
+		return d.opSize / 8
+	case "z":
+		d.markOpSize()
+		if d.opSize == 16 {
+			return 2
+		}
+		return 4
+	case "y":
+		if d.wide() {
+			return 8
+		}
+		return 4
+	case "x":
+		return d.vecLen()
+	case "dq":
+		return 16
+	case "qq":
+		return 32
+	case "ss":
+		return 4
+	case "sd":
+		return 8
+	case "h":
+		return d.vecLen() / 2
+	case "qx":
+		return d.vecLen() / 4
+	case "ex":
+		return d.vecLen() / 8
+	case "xq":
+		if n := d.vecLen(); n > 16 {
+			return n
+		}
+		return 8
+	case "fs":
+		if d.vexW {
+			return 8
+		}
+		return 4
+	case "t":
+		return 10
+	case "p":
+		d.markOpSize()
+		return d.opSize/8 + 2
+	}
+	return 0
+}
+
+// vecLen returns the vector length in bytes selected by VEX.L or EVEX.L'L.
+func (d *x86Decoder) vecLen() int {
+	switch {
+	case d.evex:
+		return 16 << d.evexLL
+	case d.vexL:
+		return 32
+	}
+	return 16
+}
+
+func (d *x86Decoder) vecName(r int, size string) string {
+	n := 16
+	switch size {
+	case "qq":
+		n = 32
+	case "x", "xq":
+		n = d.vecLen()
+	case "h":
+		n = d.vecLen() / 2
+	}
+	return vecReg(r, n)
+}
+
+// vecReg names vector register r holding n bytes.
+func vecReg(r, n int) string {
+	switch n {
+	case 64:
+		return "zmm" + itoa(r)
+	case 32:
+		return "ymm" + itoa(r)
+	}
+	return "xmm" + itoa(r)
+}
+
+var (
+	gpr8     = []string{"al", "cl", "dl", "bl", "ah", "ch", "dh", "bh"}
+	gpr8Rex  = []string{"al", "cl", "dl", "bl", "spl", "bpl", "sil", "dil"}
+	gpr16    = []string{"ax", "cx", "dx", "bx", "sp", "bp", "si", "di"}
+	gpr32    = []string{"eax", "ecx", "edx", "ebx", "esp", "ebp", "esi", "edi"}
+	gpr64    = []string{"rax", "rcx", "rdx", "rbx", "rsp", "rbp", "rsi", "rdi"}
+	x86Sizes = map[int]string{1: "BYTE", 2: "WORD", 4: "DWORD", 6: "FWORD", 8: "QWORD", 10: "TBYTE", 16: "XMMWORD", 32: "YMMWORD", 64: "ZMMWORD"}
+)
+
+// gprName names general register r of the given size in bytes.
+func gprName(r, size int, rex bool) string {
+	if r >= 8 {
+		n := "r" + itoa(r)
+		switch size {
+		case 1:
+			return n + "b"
+		case 2:
+			return n + "w"
+		case 4:
+			return n + "d"
+		}
+		return n
+	}
+	switch size {
+	case 1:
+		if rex {
+			return gpr8Rex[r]
+		}
+		return gpr8[r]
+	case 2:
+		return gpr16[r]
+	case 8:
+		return gpr64[r]
+	}
+	return gpr32[r]
+}
+
+// memArg decodes the memory operand addressed by ModRM (and SIB).
+func (d *x86Decoder) memArg(size int) (x86Arg, error) {
+	m, err := d.getModRM()
+	if err != nil {
+		return x86Arg{}, err
+	}
+	mod, rm := m>>6, int(m&7)
+	mem := x86Mem{scale: 1, mask: sizeMask(d.addrSize)}
+	d.usedRex = true
+
+	if d.addrSize == 16 {
+		bases := [8][2]string{{"bx", "si"}, {"bx", "di"}, {"bp", "si"}, {"bp", "di"}, {"si", ""}, {"di", ""}, {"bp", ""}, {"bx", ""}}
+		mem.base, mem.index, mem.scale = bases[rm][0], bases[rm][1], 0
+		switch {
+		case mod == 0 && rm == 6:
+			v, err := d.imm(2)
+			if err != nil {
+				return x86Arg{}, err
+			}
+			mem.base = ""
+			mem.disp = int64(v)
+			mem.hasDisp = true
+		case mod == 1:
+			v, err := d.imm(1)
+			if err != nil {
+				return x86Arg{}, err
+			}
+			mem.disp, mem.hasDisp = d.disp8(v, size), true
+		case mod == 2:
+			v, err := d.imm(2)
+			if err != nil {
+				return x86Arg{}, err
+			}
+			mem.disp, mem.hasDisp = int64(signExtend(v, 16)), true
+		}
+		d.applySeg(&mem)
+		return x86Arg{kind: argMem, mem: mem, size: size}, nil
+	}
+
+	asz := d.addrSize / 8
+	if rm == 4 {
+		sib, err := d.byte1()
+		if err != nil {
+			return x86Arg{}, err
+		}
+		scale, index, base := sib>>6, int((sib>>3)&7)+d.rexX(), int(sib&7)
+		mem.scale = 1 << scale
+		switch {
+		case d.vsib != 0:
+			// EVEX.V' extends a VSIB index to 32 registers.
+			mem.index = vecReg(index+(d.vexV&16), d.vsib)
+		case index != 4:
+			mem.index = gprName(index, asz, false)
+		}
+		if base == 5 && mod == 0 {
+			v, err := d.imm(4)
+			if err != nil {
+				return x86Arg{}, err
+			}
+			mem.disp, mem.hasDisp = int64(signExtend(v, 32)), true
+		} else {
+			mem.base = gprName(base+d.rexB(), asz, false)
+		}
+		if d.vsib != 0 {
+			// Index 4 is xmm4 rather than none.
+		} else if index == 4 && mem.base == "" {
+			// No base and no index: an absolute address.
+			mem.index = ""
+		} else if index == 4 && scale == 0 {
+			mem.scale = 1
+		} else if index == 4 {
+			mem.index = "riz"
+			if asz == 4 {
+				mem.index = "eiz"
+			}
+		}
+	} else if rm == 5 && mod == 0 {
+		v, err := d.imm(4)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		mem.disp, mem.hasDisp = int64(signExtend(v, 32)), true
+		if d.mode == 64 {
+			mem.rip = true
+			mem.base = "rip"
+			if asz == 4 {
+				mem.base = "eip"
+			}
+		}
+	} else {
+		mem.base = gprName(rm+d.rexB(), asz, false)
+	}
+
+	switch mod {
+	case 1:
+		v, err := d.imm(1)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		mem.disp, mem.hasDisp = d.disp8(v, size), true
+	case 2:
+		v, err := d.imm(4)
+		if err != nil {
+			return x86Arg{}, err
+		}
+		mem.disp, mem.hasDisp = int64(signExtend(v, 32)), true
+	}
+	d.applySeg(&mem)
+	return x86Arg{kind: argMem, mem: mem, size: size}, nil
+}
+
+// disp8 sign extends an 8-bit displacement. EVEX scales it by the size of
+// the memory access.
+func (d *x86Decoder) disp8(v uint64, size int) int64 {
+	disp := int64(signExtend(v, 8))
+	if d.dispN != 0 {
+		size = d.dispN
+	}
+	if d.evex && size > 0 {
+		disp *= int64(size)
+	}
+	return disp
+}
+
+// applySeg attaches a segment override prefix to a memory operand. In long
+// mode only fs and gs have an effect; the others stay visible as prefixes.
+func (d *x86Decoder) applySeg(m *x86Mem) {
+	if d.seg == 0 {
+		return
+	}
+	if d.mode == 64 && d.seg != 0x64 && d.seg != 0x65 {
+		return
+	}
+	m.seg = segNames[d.seg]
+	d.usedSeg = true
+}
+
+func signExtend(v uint64, bits int) uint64 {
+	shift := 64 - bits
+	return uint64(int64(v<<shift) >> shift)
+}
+
+func sizeMask(bits int) uint64 {
+	if bits >= 64 {
+		return ^uint64(0)
+	}
+	return 1<<bits - 1
+}
+
+func itoa(i int) string {
+	if i < 10 {
+		return string(rune('0' + i))
+	}
+	return string(rune('0'+i/10)) + string(rune('0'+i%10))
+}
+
+// fixNames adjusts mnemonics that depend on operand size, prefixes or an
+// immediate.
+func (d *x86Decoder) fixNames(inst *x86Inst, o x86Op, opc byte) {
+	if inst.isVEX || inst.flags&xVEX != 0 {
+		inst.opSize = 32
+		if d.vexW && d.mode == 64 {
+			inst.opSize = 64
+		}
+	}
+
+	switch {
+	case o.flags&xMovdq != 0:
+		if d.wide() {
+			inst.name = strings.Replace(inst.name, "movd", "movq", 1)
+		}
+	case o.flags&xWName != 0:
+		if strings.HasPrefix(inst.name, "vf") {
+			if d.vexW {
+				inst.name += "d"
+			} else {
+				inst.name += "s"
+			}
+		} else if d.wide() {
+			inst.name += "q"
+		} else {
+			inst.name += "d"
+		}
+	case o.flags&xCondCmp != 0:
+		last := len(inst.args) - 1
+		pred := int(inst.args[last].imm)
+		if strings.Contains(inst.name, "pclmul") {
+			names := map[int]string{0x00: "lqlq", 0x01: "hqlq", 0x10: "lqhq", 0x11: "hqhq"}
+			if n, ok := names[pred]; ok {
+				inst.name = strings.Replace(inst.name, "pclmulqdq", "pclmul"+n+"dq", 1)
+				inst.args = inst.args[:last]
+			}
+		} else if limit := map[bool]int{false: 8, true: 32}[d.vex]; pred < limit {
+			inst.name = strings.Replace(inst.name, "cmp", "cmp"+x86CmpPred[pred], 1)
+			inst.args = inst.args[:last]
+		}
+	case o.flags&xIntCmp != 0:
+		last := len(inst.args) - 1
+		if pred := inst.args[last].imm; pred < 8 && x86IntCmpPred[pred] != "" {
+			inst.name = strings.Replace(inst.name, "cmp", "cmp"+x86IntCmpPred[pred], 1)
+			inst.args = inst.args[:last]
+		}
+	}
+
+	if d.vex || o.flags&xVEX != 0 {
+		return
+	}
+
+	switch inst.name {
+	case "cbw", "cwd":
+		d.markOpSize()
+		names := map[string][3]string{"cbw": {"cbw", "cwde", "cdqe"}, "cwd": {"cwd", "cdq", "cqo"}}
+		atts := map[string][3]string{"cbw": {"cbtw", "cwtl", "cltq"}, "cwd": {"cwtd", "cltd", "cqto"}}
+		i := map[int]int{16: 0, 32: 1, 64: 2}[d.opSize]
+		inst.att = atts[inst.name][i]
+		inst.name = names[inst.name][i]
+	case "movsxd":
+		if d.opSize == 64 {
+			inst.att = "movslq"
+		}
+		inst.noAttSz = true
+	case "movzx", "movsx":
+		s := "b"
+		if opc == 0xb7 || opc == 0xbf {
+			s = "w"
+		}
+		inst.att = "movz"
+		if inst.name == "movsx" {
+			inst.att = "movs"
+		}
+		inst.att += s + attSuffix(d.opSize/8)
+		inst.noAttSz = true
+	case "mov":
+		// Moves with a 64-bit immediate or absolute address.
+		if d.mode == 64 && (opc >= 0xa0 && opc <= 0xa3 || opc >= 0xb8 && opc <= 0xbf && d.opSize == 64) {
+			inst.name = "movabs"
+		}
+	case "jrcxz":
+		switch {
+		case d.mode == 64 && d.has67:
+			inst.name = "jecxz"
+		case d.mode == 32 && !d.has67:
+			inst.name = "jecxz"
+		case d.mode == 32:
+			inst.name = "jcxz"
+		}
+	case "iret", "pushf", "popf", "pusha", "popa":
+		d.markOpSize()
+		switch {
+		case d.opSize == 16:
+			inst.name += "w"
+		case d.opSize == 64 && inst.name == "iret":
+			inst.name += "q"
+		}
+	case "sysret":
+		if d.mode == 64 {
+			if d.rexW() {
+				inst.name += "q"
+			} else {
+				inst.name += "l"
+			}
+			d.usedRex = true
+		}
+	case "ret", "retf":
+		if d.has66 {
+			d.used66 = true
+			inst.name += "w"
+			if inst.att != "" {
+				inst.att += "w"
+			}
+		}
+	case "rdssp":
+		if d.wide() {
+			inst.name = "rdsspq"
+		} else {
+			inst.name = "rdsspd"
+		}
+	}
+}
+
+// done finalises prefixes and the RIP-relative target.
+func (d *x86Decoder) done(inst *x86Inst) (*x86Inst, error) {
+	next := d.pc + uint64(d.pos)
+	if inst.ripArg >= 0 {
+		a := &inst.args[inst.ripArg]
+		inst.target = next + uint64(a.mem.disp)
+		if d.addrSize == 32 {
+			inst.target &= 0xffffffff
+		}
+		inst.hasTgt = true
+	}
+
+	// Prefixes that had no effect are printed by name, in encoding order.
+	var names []string
+	last66 := -1
+	for i, p := range d.prefixes {
+		if p == 0x66 {
+			last66 = i
+		}
+	}
+	for i, p := range d.prefixes {
+		switch {
+		case p == 0x66:
+			if i == last66 && (d.used66 || inst.isVEX) {
+				continue
+			}
+			names = append(names, "data16")
+		case p == 0x67:
+			if d.addrSize != d.mode && (inst.hasMem || inst.flags&(xString|xCmpString) != 0 || inst.name == "jecxz" || inst.name == "jcxz" || inst.name == "xlat") {
+				continue
+			}
+			if d.mode == 64 {
+				names = append(names, "addr32")
+			} else {
+				names = append(names, "addr16")
+			}
+		case p == 0xf0:
+			names = append(names, "lock")
+		case p == 0xf2 || p == 0xf3:
+			if p != d.rep {
+				continue
+			}
+			if d.usedRep {
+				continue
+			}
+			switch {
+			case inst.flags&xBranch != 0 && p == 0xf2:
+				names = append(names, "bnd")
+			case inst.flags&xString != 0 && p == 0xf3:
+				names = append(names, "rep")
+			case p == 0xf3:
+				names = append(names, "repz")
+			default:
+				names = append(names, "repnz")
+			}
+		case p >= 0x40 && p <= 0x4f:
+			names = append(names, rexName(p))
+		default:
+			if p != d.seg {
+				continue
+			}
+			if d.usedSeg {
+				continue
+			}
+			if p == 0x3e && inst.flags&xIndirect != 0 {
+				names = append(names, "notrack")
+				continue
+			}
+			names = append(names, segNames[p])
+		}
+	}
+	if d.rex != 0 && !d.vex && !d.usedRex && !d.rexW() {
+		names = append(names, rexName(d.rex))
+	}
+	inst.prefixes = names
+	return inst, nil
+}
+
+func rexName(p byte) string {
+	s := "rex"
+	if p&0xf == 0 {
+		return s
+	}
+	s += "."
+	for i, c := range "WRXB" {
+		if p&(8>>i) != 0 {
+			s += string(c)
+		}
+	}
+	return s
+}
+
+func attSuffix(size int) string {
+	switch size {
+	case 1:
+		return "b"
+	case 2:
+		return "w"
+	case 4:
+		return "l"
+	case 8:
+		return "q"
+	}
+	return ""
+}
@@ -0,0 +1,232 @@
+package disasm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// format renders a decoded instruction in the requested syntax.
+func (inst *x86Inst) format(d *x86Decoder, opts Options) Inst {
+	intel := opts.Syntax == SyntaxIntel
+
+	name := inst.name
+	if !intel {
+		if inst.att != "" {
+			name = inst.att
+		}
+		name += inst.attSuffix()
+	}
+
+	var args []string
+	for _, a := range inst.args {
+		if a.kind == argReg && a.reg == "" {
+			continue
+		}
+		if a.hidden && !intel {
+			continue
+		}
+		if intel {
+			args = append(args, a.intel(opts)+a.decoration(intel))
+			continue
+		}
+		args = append(args, a.att(opts)+a.decoration(intel))
+		if a.sae != "" {
+			// AT&T lists rounding as an operand of its own, before the
+			// register it applies to.
+			args = append(args, "{"+a.sae+"}")
+		}
+	}
+	if !intel {
+		for i, j := 0, len(args)-1; i < j; i, j = i+1, j-1 {
+			args[i], args[j] = args[j], args[i]
+		}
+		if inst.flags&xIndirect != 0 && len(args) > 0 {
+			args[0] = "*" + args[0]
+		}
+	}
+
+	text := strings.Join(append(append([]string{}, inst.prefixes...), name), " ")
+	if len(args) > 0 {
+		text = fmt.Sprintf("%-6s %s", text, strings.Join(args, ","))
+	}
+	if inst.ripArg >= 0 {
+		text += "        # " + symbolic(inst.target, opts)
+	}
+
+	return Inst{
+		Addr:      d.pc,
+		Len:       d.pos,
+		Text:      text,
+		Target:    inst.target,
+		HasTarget: inst.hasTgt,
+	}
+}
+
+// attSuffix returns the AT&T operand size suffix. It is only needed when
+// no register operand determines the size of a memory access.
+func (inst *x86Inst) attSuffix() string {
+	var mem *x86Arg
+	hasReg := false
+	for i := range inst.args {
+		a := &inst.args[i]
+		switch {
+		case a.kind == argMem && a.intMem && mem == nil:
+			mem = a
+		case a.kind == argReg && a.gpr:
+			hasReg = true
+		}
+	}
+
+	switch {
+	case inst.flags&xXYSuffix != 0:
+		if inst.isVEX && inst.hasMem {
+			if inst.zmm {
+				return "z"
+			}
+			if inst.vexL {
+				return "y"
+			}
+			return "x"
+		}
+		return ""
+	case mem == nil || mem.size == 0:
+		return ""
+	case inst.flags&xSuffix != 0:
+		return attSuffix(mem.size)
+	case inst.noAttSz || inst.flags&xNoSuffix != 0 || inst.isSIMD || hasReg:
+		return ""
+	}
+	return attSuffix(mem.size)
+}
+
+// decoration returns the EVEX broadcast, opmask and rounding annotations
+// that follow an operand.
+func (a *x86Arg) decoration(intel bool) string {
+	var s string
+	if a.bcst != 0 && (!intel || a.bcstCount) {
+		s += fmt.Sprintf("{1to%d}", a.bcst)
+	}
+	if a.mask != 0 {
+		if intel {
+			s += "{k" + itoa(a.mask) + "}"
+		} else {
+			s += "{%k" + itoa(a.mask) + "}"
+		}
+	}
+	if a.zero {
+		s += "{z}"
+	}
+	if a.sae != "" && intel {
+		s += "{" + a.sae + "}"
+	}
+	return s
+}
+
+func (a *x86Arg) att(opts Options) string {
+	switch a.kind {
+	case argReg:
+		if a.io {
+			return "(%" + a.reg + ")"
+		}
+		return "%" + a.reg
+	case argImm:
+		return fmt.Sprintf("$0x%x", a.imm)
+	case argRel:
+		return symbolic(a.imm, opts)
+	case argFar:
+		return fmt.Sprintf("$0x%x,$0x%x", a.seg, a.imm)
+	}
+
+	m := &a.mem
+	var b strings.Builder
+	if m.seg != "" {
+		b.WriteString("%" + m.seg + ":")
+	}
+	if m.moffs || (m.base == "" && m.index == "") {
+		fmt.Fprintf(&b, "0x%x", uint64(m.disp)&m.mask)
+		return b.String()
+	}
+	if m.hasDisp {
+		b.WriteString(signedHex(m.disp))
+	}
+	b.WriteString("(")
+	if m.base != "" {
+		b.WriteString("%" + m.base)
+	}
+	if m.index != "" {
+		b.WriteString(",%" + m.index)
+		if m.scale != 0 {
+			fmt.Fprintf(&b, ",%d", m.scale)
+		}
+	}
+	b.WriteString(")")
+	return b.String()
+}
+
+func (a *x86Arg) intel(opts Options) string {
+	switch a.kind {
+	case argReg:
+		return a.reg
+	case argImm:
+		if a.hidden {
+			return "1"
+		}
+		return fmt.Sprintf("0x%x", a.imm)
+	case argRel:
+		return symbolic(a.imm, opts)
+	case argFar:
+		return fmt.Sprintf("0x%x:0x%x", a.seg, a.imm)
+	}
+
+	m := &a.mem
+	var b strings.Builder
+	switch {
+	case a.bcst != 0:
+		b.WriteString(x86Sizes[a.size] + " BCST ")
+	case a.size != 0 && !m.moffs:
+		b.WriteString(x86Sizes[a.size] + " PTR ")
+	}
+	seg := m.seg
+	abs := m.moffs || (m.base == "" && m.index == "")
+	if abs && seg == "" {
+		seg = "ds"
+	}
+	if seg != "" {
+		b.WriteString(seg + ":")
+	}
+	if abs {
+		fmt.Fprintf(&b, "0x%x", uint64(m.disp)&m.mask)
+		return b.String()
+	}
+	b.WriteString("[")
+	b.WriteString(m.base)
+	if m.index != "" {
+		if m.base != "" {
+			b.WriteString("+")
+		}
+		b.WriteString(m.index)
+		if m.scale != 0 {
+			fmt.Fprintf(&b, "*%d", m.scale)
+		}
+	}
+	switch {
+	case m.rip:
+		// objdump prints RIP-relative displacements unsigned.
+		fmt.Fprintf(&b, "+0x%x", uint64(m.disp))
+	case m.hasDisp:
+		d := signedHex(m.disp)
+		if !strings.HasPrefix(d, "-") {
+			d = "+" + d
+		}
+		b.WriteString(d)
+	}
+	b.WriteString("]")
+	return b.String()
+}
+
+func signedHex(v int64) string {
+	if v < 0 {
+		return fmt.Sprintf("-0x%x", uint64(-v))
+	}
+	return fmt.Sprintf("0x%x", v)
+}
@@ -0,0 +1,915 @@
+package disasm
+
+// x86Op describes one opcode. Operands are listed in Intel order using a
+// notation close to the opcode maps of the Intel SDM: an addressing method
+// letter followed by an operand size, e.g. "Ev,Gv" or "Vx,Hx,Wx".
+//
+// Addressing methods:
+//
+//	E  ModRM r/m, general register or memory
+//	G  ModRM reg, general register
+//	R  ModRM r/m, general register only
+//	M  ModRM r/m, memory only
+//	I  immediate (sI: 8-bit immediate sign extended to the operand size)
+//	J  relative branch target
+//	O  memory offset without ModRM (moffs)
+//	Z  general register from the low three opcode bits
+//	S  segment register, C control register, D debug register
+//	X  ds:[rSI], Y es:[rDI] string operands
+//	V  ModRM reg, vector register
+//	W  ModRM r/m, vector register or memory
+//	U  ModRM r/m, vector register only
+//	H  VEX.vvvv vector register, dropped for legacy encodings
+//	L  vector register from the upper four bits of an 8-bit immediate
+//	B  VEX.vvvv general register
+//	K  ModRM reg, opmask register; k ModRM r/m, opmask register only
+//	P  ModRM reg, MMX register; Q ModRM r/m, MMX register or memory;
+//	N  ModRM r/m, MMX register only
+//
+// Sizes: b byte, w word, d dword, q qword, v word/dword/qword by operand
+// size, z word/dword, y dword/qword, x xmm/ymm by VEX.L, dq and qq 128 and
+// 256 bits, ss and sd scalar float, h half of x, qx quarter of x, ex eighth
+// of x, xq qword for xmm and 256 bits for ymm, t 80-bit float, p far pointer.
+// The pseudo sizes db and dw denote a dword register or a byte or word in
+// memory.
+//
+// A name of the form "intel|att" gives differing mnemonics for the two
+// syntaxes.
+type x86Op struct {
+	name  string
+	args  string
+	flags x86Flags
+}
+
+type x86Flags uint32
+
+const (
+	xDef64     x86Flags = 1 << iota // operand size defaults to 64 bits in long mode
+	xInv64                          // invalid in long mode
+	xNoSuffix                       // never print an AT&T size suffix
+	xSuffix                         // print an AT&T size suffix for memory operands
+	xBranch                         // near branch: F2 prints as bnd
+	xIndirect                       // AT&T prints '*' before the target
+	xString                         // string instruction, F3 prints as rep
+	xCmpString                      // compare string instruction, F3 prints as repz
+	xAnyPrefix                      // mandatory prefix variants do not apply
+	xVEX                            // only valid with a VEX prefix
+	xMovdq                          // movd, or movq when REX.W/VEX.W is set
+	xWName                          // name ends in d or q chosen by REX.W/VEX.W
+	xXYSuffix                       // AT&T suffix x/y for VEX memory operands
+	xGroup                          // name is a key of x86Groups
+	xCondCmp                        // cmpps family, predicate folded into the name
+	xIntCmp                         // vpcmp family, integer predicate folded into the name
+	xElemDisp                       // EVEX disp8 scaled by the element size
+)
+
+func op(name, args string, flags ...x86Flags) x86Op {
+	o := x86Op{name: name, args: args}
+	for _, f := range flags {
+		o.flags |= f
+	}
+	return o
+}
+
+func grp(name, args string, flags ...x86Flags) x86Op {
+	o := op(name, args, flags...)
+	o.flags |= xGroup
+	return o
+}
+
+// Prefixed opcode variants, indexed by mandatory prefix: none, 66, F3, F2.
+type x86Variants [4]x86Op
+
+const (
+	pfxNone = iota
+	pfx66
+	pfxF3
+	pfxF2
+)
+
+// anyPfx is used for opcodes whose meaning does not depend on a mandatory
+// prefix; 66 then selects the operand size as usual.
+func anyPfx(o x86Op) x86Variants {
+	o.flags |= xAnyPrefix
+	return x86Variants{o}
+}
+
+func sse(none, p66, f3, f2 x86Op) x86Variants {
+	return x86Variants{none, p66, f3, f2}
+}
+
+// mmx describes the common pattern of an MMX instruction with a 66
+// prefixed SSE2 counterpart taking a VEX source operand.
+func mmx(name string) x86Variants {
+	return x86Variants{op(name, "Pq,Qq"), op(name, "Vx,Hx,Wx")}
+}
+
+// shift describes the MMX and SSE2 shifts by a count register, which is an
+// xmm register or 128-bit memory operand for any vector length.
+func shift(name string) x86Variants {
+	return x86Variants{op(name, "Pq,Qq"), op(name, "Vx,Hx,Wdq")}
+}
+
+// packed describes the ps/pd/ss/sd arithmetic family.
+func packed(name string) x86Variants {
+	return x86Variants{
+		op(name+"ps", "Vx,Hx,Wx"),
+		op(name+"pd", "Vx,Hx,Wx"),
+		op(name+"ss", "Vss,Hss,Wss"),
+		op(name+"sd", "Vsd,Hsd,Wsd"),
+	}
+}
+
+func p66(o x86Op) x86Variants { return x86Variants{pfx66: o} }
+
+var x86Cond = [16]string{"o", "no", "b", "ae", "e", "ne", "be", "a", "s", "ns", "p", "np", "l", "ge", "le", "g"}
+
+var x86OneByte [256]x86Op
+
+func init() {
+	alu := []string{"add", "or", "adc", "sbb", "and", "sub", "xor", "cmp"}
+	for i, name := range alu {
+		b := i * 8
+		x86OneByte[b+0] = op(name, "Eb,Gb")
+		x86OneByte[b+1] = op(name, "Ev,Gv")
+		x86OneByte[b+2] = op(name, "Gb,Eb")
+		x86OneByte[b+3] = op(name, "Gv,Ev")
+		x86OneByte[b+4] = op(name, "AL,Ib")
+		x86OneByte[b+5] = op(name, "rAX,Iz")
+	}
+	x86OneByte[0x06] = op("push", "ES", xInv64)
+	x86OneByte[0x07] = op("pop", "ES", xInv64)
+	x86OneByte[0x0e] = op("push", "CS", xInv64)
+	x86OneByte[0x16] = op("push", "SS", xInv64)
+	x86OneByte[0x17] = op("pop", "SS", xInv64)
+	x86OneByte[0x1e] = op("push", "DS", xInv64)
+	x86OneByte[0x1f] = op("pop", "DS", xInv64)
+	x86OneByte[0x27] = op("daa", "", xInv64)
+	x86OneByte[0x2f] = op("das", "", xInv64)
+	x86OneByte[0x37] = op("aaa", "", xInv64)
+	x86OneByte[0x3f] = op("aas", "", xInv64)
+
+	for r := 0; r < 8; r++ {
+		x86OneByte[0x40+r] = op("inc", "Zv", xInv64)
+		x86OneByte[0x48+r] = op("dec", "Zv", xInv64)
+		x86OneByte[0x50+r] = op("push", "Zv", xDef64)
+		x86OneByte[0x58+r] = op("pop", "Zv", xDef64)
+		x86OneByte[0x90+r] = op("xchg", "Zv,rAX")
+		x86OneByte[0xb0+r] = op("mov", "Zb,Ib")
+		x86OneByte[0xb8+r] = op("mov", "Zv,Iv")
+	}
+	x86OneByte[0x60] = op("pusha", "", xInv64)
+	x86OneByte[0x61] = op("popa", "", xInv64)
+	x86OneByte[0x62] = op("bound", "Gv,Mq", xInv64)
+	x86OneByte[0x63] = op("arpl", "Ew,Gw", xInv64)
+	x86OneByte[0x68] = op("push", "Iz", xDef64)
+	x86OneByte[0x69] = op("imul", "Gv,Ev,Iz")
+	x86OneByte[0x6a] = op("push", "sIb", xDef64)
+	x86OneByte[0x6b] = op("imul", "Gv,Ev,sIb")
+	x86OneByte[0x6c] = op("ins", "Yb,DXio", xString)
+	x86OneByte[0x6d] = op("ins", "Yz,DXio", xString)
+	x86OneByte[0x6e] = op("outs", "DXio,Xb", xString)
+	x86OneByte[0x6f] = op("outs", "DXio,Xz", xString)
+	for c := 0; c < 16; c++ {
+		x86OneByte[0x70+c] = op("j"+x86Cond[c], "Jb", xBranch)
+	}
+	x86OneByte[0x80] = grp("grp1", "Eb,Ib")
+	x86OneByte[0x81] = grp("grp1", "Ev,Iz")
+	x86OneByte[0x82] = grp("grp1", "Eb,Ib", xInv64)
+	x86OneByte[0x83] = grp("grp1", "Ev,sIb")
+	x86OneByte[0x84] = op("test", "Eb,Gb")
+	x86OneByte[0x85] = op("test", "Ev,Gv")
+	x86OneByte[0x86] = op("xchg", "Eb,Gb")
+	x86OneByte[0x87] = op("xchg", "Ev,Gv")
+	x86OneByte[0x88] = op("mov", "Eb,Gb")
+	x86OneByte[0x89] = op("mov", "Ev,Gv")
+	x86OneByte[0x8a] = op("mov", "Gb,Eb")
+	x86OneByte[0x8b] = op("mov", "Gv,Ev")
+	x86OneByte[0x8c] = op("mov", "Ev,Sw", xNoSuffix)
+	x86OneByte[0x8d] = op("lea", "Gv,M")
+	x86OneByte[0x8e] = op("mov", "Sw,Ew", xNoSuffix)
+	x86OneByte[0x8f] = grp("grp1a", "Ev", xDef64, xNoSuffix)
+	x86OneByte[0x98] = op("cbw", "")
+	x86OneByte[0x99] = op("cwd", "")
+	x86OneByte[0x9a] = op("call|lcall", "Ap", xInv64)
+	x86OneByte[0x9b] = op("fwait", "")
+	x86OneByte[0x9c] = op("pushf", "", xDef64)
+	x86OneByte[0x9d] = op("popf", "", xDef64)
+	x86OneByte[0x9e] = op("sahf", "")
+	x86OneByte[0x9f] = op("lahf", "")
+	x86OneByte[0xa0] = op("mov", "AL,Ob")
+	x86OneByte[0xa1] = op("mov", "rAX,Ov")
+	x86OneByte[0xa2] = op("mov", "Ob,AL")
+	x86OneByte[0xa3] = op("mov", "Ov,rAX")
+	x86OneByte[0xa4] = op("movs", "Yb,Xb", xString)
+	x86OneByte[0xa5] = op("movs", "Yv,Xv", xString)
+	x86OneByte[0xa6] = op("cmps", "Xb,Yb", xCmpString)
+	x86OneByte[0xa7] = op("cmps", "Xv,Yv", xCmpString)
+	x86OneByte[0xa8] = op("test", "AL,Ib")
+	x86OneByte[0xa9] = op("test", "rAX,Iz")
+	x86OneByte[0xaa] = op("stos", "Yb,AL", xString)
+	x86OneByte[0xab] = op("stos", "Yv,rAX", xString)
+	x86OneByte[0xac] = op("lods", "AL,Xb", xString)
+	x86OneByte[0xad] = op("lods", "rAX,Xv", xString)
+	x86OneByte[0xae] = op("scas", "AL,Yb", xCmpString)
+	x86OneByte[0xaf] = op("scas", "rAX,Yv", xCmpString)
+	x86OneByte[0xc0] = grp("grp2", "Eb,Ib")
+	x86OneByte[0xc1] = grp("grp2", "Ev,Ib")
+	x86OneByte[0xc2] = op("ret", "Iw", xDef64|xBranch)
+	x86OneByte[0xc3] = op("ret", "", xDef64|xBranch)
+	x86OneByte[0xc4] = op("les", "Gz,Mp", xInv64)
+	x86OneByte[0xc5] = op("lds", "Gz,Mp", xInv64)
+	x86OneByte[0xc6] = grp("grp11", "Eb,Ib")
+	x86OneByte[0xc7] = grp("grp11", "Ev,Iz")
+	x86OneByte[0xc8] = op("enter", "Iw,Ib", xDef64)
+	x86OneByte[0xc9] = op("leave", "", xDef64)
+	x86OneByte[0xca] = op("retf|lret", "Iw")
+	x86OneByte[0xcb] = op("retf|lret", "")
+	x86OneByte[0xcc] = op("int3", "")
+	x86OneByte[0xcd] = op("int", "Ib")
+	x86OneByte[0xce] = op("into", "", xInv64)
+	x86OneByte[0xcf] = op("iret", "")
+	x86OneByte[0xd0] = grp("grp2", "Eb,1")
+	x86OneByte[0xd1] = grp("grp2", "Ev,1")
+	x86OneByte[0xd2] = grp("grp2", "Eb,CL")
+	x86OneByte[0xd3] = grp("grp2", "Ev,CL")
+	x86OneByte[0xd4] = op("aam", "Ib", xInv64)
+	x86OneByte[0xd5] = op("aad", "Ib", xInv64)
+	x86OneByte[0xd7] = op("xlat", "XLAT", xNoSuffix)
+	x86OneByte[0xe0] = op("loopne", "Jb", xBranch)
+	x86OneByte[0xe1] = op("loope", "Jb", xBranch)
+	x86OneByte[0xe2] = op("loop", "Jb", xBranch)
+	x86OneByte[0xe3] = op("jrcxz", "Jb", xBranch)
+	x86OneByte[0xe4] = op("in", "AL,Ib")
+	x86OneByte[0xe5] = op("in", "eAX,Ib")
+	x86OneByte[0xe6] = op("out", "Ib,AL")
+	x86OneByte[0xe7] = op("out", "Ib,eAX")
+	x86OneByte[0xe8] = op("call", "Jz", xDef64|xBranch)
+	x86OneByte[0xe9] = op("jmp", "Jz", xDef64|xBranch)
+	x86OneByte[0xea] = op("jmp|ljmp", "Ap", xInv64)
+	x86OneByte[0xeb] = op("jmp", "Jb", xDef64|xBranch)
+	x86OneByte[0xec] = op("in", "AL,DXio")
+	x86OneByte[0xed] = op("in", "eAX,DXio")
+	x86OneByte[0xee] = op("out", "DXio,AL")
+	x86OneByte[0xef] = op("out", "DXio,eAX")
+	x86OneByte[0xf1] = op("int1", "")
+	x86OneByte[0xf4] = op("hlt", "")
+	x86OneByte[0xf5] = op("cmc", "")
+	x86OneByte[0xf6] = grp("grp3", "Eb")
+	x86OneByte[0xf7] = grp("grp3", "Ev")
+	x86OneByte[0xf8] = op("clc", "")
+	x86OneByte[0xf9] = op("stc", "")
+	x86OneByte[0xfa] = op("cli", "")
+	x86OneByte[0xfb] = op("sti", "")
+	x86OneByte[0xfc] = op("cld", "")
+	x86OneByte[0xfd] = op("std", "")
+	x86OneByte[0xfe] = grp("grp4", "Eb")
+	x86OneByte[0xff] = grp("grp5", "Ev")
+}
+
+// x86Groups holds opcode extensions selected by ModRM.reg. Operands left
+// empty are inherited from the opcode entry.
+var x86Groups = map[string][8]x86Op{
+	"grp1":  {op("add", ""), op("or", ""), op("adc", ""), op("sbb", ""), op("and", ""), op("sub", ""), op("xor", ""), op("cmp", "")},
+	"grp1a": {op("pop", "")},
+	"grp2":  {op("rol", ""), op("ror", ""), op("rcl", ""), op("rcr", ""), op("shl", ""), op("shr", ""), op("shl", ""), op("sar", "")},
+	"grp4":  {op("inc", ""), op("dec", "")},
+	"grp11": {op("mov", "")},
+	"grp6": {
+		op("sldt", "Ew", xNoSuffix), op("str", "Ew", xNoSuffix), op("lldt", "Ew", xNoSuffix), op("ltr", "Ew", xNoSuffix),
+		op("verr", "Ew", xNoSuffix), op("verw", "Ew", xNoSuffix),
+	},
+	"grp7": {
+		op("sgdt", "M"), op("sidt", "M"), op("lgdt", "M"), op("lidt", "M"),
+		op("smsw", "Ew", xNoSuffix), {}, op("lmsw", "Ew", xNoSuffix), op("invlpg", "Mb", xNoSuffix),
+	},
+	"grp8": {{}, {}, {}, {}, op("bt", ""), op("bts", ""), op("btr", ""), op("btc", "")},
+	"grp9": {{}, op("cmpxchg8b", "Mq", xNoSuffix)},
+	"grp15": {
+		op("fxsave", "M"), op("fxrstor", "M"), op("ldmxcsr", "Md", xNoSuffix), op("stmxcsr", "Md", xNoSuffix),
+		op("xsave", "M"), op("xrstor", "M"), op("xsaveopt", "M"), op("clflush", "Mb", xNoSuffix),
+	},
+	"grp16": {
+		op("prefetchnta", "Mb", xNoSuffix), op("prefetcht0", "Mb", xNoSuffix),
+		op("prefetcht1", "Mb", xNoSuffix), op("prefetcht2", "Mb", xNoSuffix),
+		op("nop", "Ev"), op("nop", "Ev"), op("nop", "Ev"), op("nop", "Ev"),
+	},
+	"grpP": {
+		op("prefetch", "Mb", xNoSuffix), op("prefetchw", "Mb", xNoSuffix), op("prefetchwt1", "Mb", xNoSuffix),
+		op("prefetch", "Mb", xNoSuffix), op("prefetch", "Mb", xNoSuffix), op("prefetch", "Mb", xNoSuffix),
+		op("prefetch", "Mb", xNoSuffix), op("prefetch", "Mb", xNoSuffix),
+	},
+	"grp17": {{}, op("blsr", "By,Ey", xVEX), op("blsmsk", "By,Ey", xVEX), op("blsi", "By,Ey", xVEX)},
+}
+
+// x86Grp5 is kept apart because its entries have differing operands.
+var x86Grp5 = [8]x86Op{
+	op("inc", "Ev"), op("dec", "Ev"),
+	op("call", "Ev", xDef64|xBranch|xIndirect|xNoSuffix), op("call|lcall", "Mp", xIndirect|xNoSuffix),
+	op("jmp", "Ev", xDef64|xBranch|xIndirect|xNoSuffix), op("jmp|ljmp", "Mp", xIndirect|xNoSuffix),
+	op("push", "Ev", xDef64|xNoSuffix),
+}
+
+var x86Grp3 = [8]string{"test", "test", "not", "neg", "mul", "imul", "div", "idiv"}
+
+// Register forms (mod == 3) of 0F 01, indexed by the ModRM byte.
+var x86Grp7Reg = map[byte]x86Op{
+	0xc1: op("vmcall", ""), 0xc2: op("vmlaunch", ""), 0xc3: op("vmresume", ""), 0xc4: op("vmxoff", ""),
+	0xc8: op("monitor", "MONITOR"), 0xc9: op("mwait", "MWAIT"), 0xca: op("clac", ""), 0xcb: op("stac", ""),
+	0xd0: op("xgetbv", ""), 0xd1: op("xsetbv", ""), 0xd5: op("xend", ""), 0xd6: op("xtest", ""),
+	0xee: op("rdpkru", ""), 0xef: op("wrpkru", ""),
+	0xf8: op("swapgs", ""), 0xf9: op("rdtscp", ""),
+}
+
+// Shift-by-immediate groups 0F 71, 0F 72 and 0F 73.
+var x86ShiftImm = map[byte][8]string{
+	0x71: {2: "psrlw", 4: "psraw", 6: "psllw"},
+	0x72: {2: "psrld", 4: "psrad", 6: "pslld"},
+	0x73: {2: "psrlq", 3: "psrldq", 6: "psllq", 7: "pslldq"},
+}
+
+var x86TwoByte = map[byte]x86Variants{
+	0x00: anyPfx(grp("grp6", "")),
+	0x01: anyPfx(grp("grp7", "")),
+	0x02: anyPfx(op("lar", "Gv,Ew")),
+	0x03: anyPfx(op("lsl", "Gv,Ew")),
+	0x05: anyPfx(op("syscall", "")),
+	0x06: anyPfx(op("clts", "")),
+	0x07: anyPfx(op("sysret", "")),
+	0x08: anyPfx(op("invd", "")),
+	0x09: anyPfx(op("wbinvd", "")),
+	0x0b: anyPfx(op("ud2", "")),
+	0x0d: anyPfx(grp("grpP", "")),
+	0x10: sse(op("movups", "Vx,Wx"), op("movupd", "Vx,Wx"), op("movss", "Vx,Wss"), op("movsd", "Vx,Wsd")),
+	0x11: sse(op("movups", "Wx,Vx"), op("movupd", "Wx,Vx"), op("movss", "Wss,Vx"), op("movsd", "Wsd,Vx")),
+	0x12: sse(op("movlps", "Vdq,Hdq,Mq"), op("movlpd", "Vdq,Hdq,Mq"), op("movsldup", "Vx,Wx"), op("movddup", "Vx,Wxq")),
+	0x13: sse(op("movlps", "Mq,Vdq"), op("movlpd", "Mq,Vdq"), x86Op{}, x86Op{}),
+	0x14: sse(op("unpcklps", "Vx,Hx,Wx"), op("unpcklpd", "Vx,Hx,Wx"), x86Op{}, x86Op{}),
+	0x15: sse(op("unpckhps", "Vx,Hx,Wx"), op("unpckhpd", "Vx,Hx,Wx"), x86Op{}, x86Op{}),
+	0x16: sse(op("movhps", "Vdq,Hdq,Mq"), op("movhpd", "Vdq,Hdq,Mq"), op("movshdup", "Vx,Wx"), x86Op{}),
+	0x17: sse(op("movhps", "Mq,Vdq"), op("movhpd", "Mq,Vdq"), x86Op{}, x86Op{}),
+	0x18: anyPfx(grp("grp16", "")),
+	0x19: anyPfx(op("nop", "Ev")),
+	0x1a: anyPfx(op("nop", "Ev")),
+	0x1b: anyPfx(op("nop", "Ev")),
+	0x1c: anyPfx(op("nop", "Ev")),
+	0x1d: anyPfx(op("nop", "Ev")),
+	0x1e: anyPfx(op("nop", "Ev")),
+	0x1f: anyPfx(op("nop", "Ev")),
+	0x20: anyPfx(op("mov", "Rcr,Cd", xNoSuffix)),
+	0x21: anyPfx(op("mov", "Rcr,Dd", xNoSuffix)),
+	0x22: anyPfx(op("mov", "Cd,Rcr", xNoSuffix)),
+	0x23: anyPfx(op("mov", "Dd,Rcr", xNoSuffix)),
+	0x28: sse(op("movaps", "Vx,Wx"), op("movapd", "Vx,Wx"), x86Op{}, x86Op{}),
+	0x29: sse(op("movaps", "Wx,Vx"), op("movapd", "Wx,Vx"), x86Op{}, x86Op{}),
+	0x2a: sse(op("cvtpi2ps", "Vdq,Qq"), op("cvtpi2pd", "Vdq,Qq"), op("cvtsi2ss", "Vss,Hss,Ey", xSuffix), op("cvtsi2sd", "Vsd,Hsd,Ey", xSuffix)),
+	0x2b: sse(op("movntps", "Mx,Vx"), op("movntpd", "Mx,Vx"), x86Op{}, x86Op{}),
+	0x2c: sse(op("cvttps2pi", "Pq,Wq"), op("cvttpd2pi", "Pq,Wdq"), op("cvttss2si", "Gy,Wss"), op("cvttsd2si", "Gy,Wsd")),
+	0x2d: sse(op("cvtps2pi", "Pq,Wq"), op("cvtpd2pi", "Pq,Wdq"), op("cvtss2si", "Gy,Wss"), op("cvtsd2si", "Gy,Wsd")),
+	0x2e: sse(op("ucomiss", "Vss,Wss"), op("ucomisd", "Vsd,Wsd"), x86Op{}, x86Op{}),
+	0x2f: sse(op("comiss", "Vss,Wss"), op("comisd", "Vsd,Wsd"), x86Op{}, x86Op{}),
+	0x30: anyPfx(op("wrmsr", "")),
+	0x31: anyPfx(op("rdtsc", "")),
+	0x32: anyPfx(op("rdmsr", "")),
+	0x33: anyPfx(op("rdpmc", "")),
+	0x34: anyPfx(op("sysenter", "")),
+	0x35: anyPfx(op("sysexit", "")),
+	0x37: anyPfx(op("getsec", "")),
+	0x50: sse(op("movmskps", "Gd,Ux"), op("movmskpd", "Gd,Ux"), x86Op{}, x86Op{}),
+	0x51: sse(op("sqrtps", "Vx,Wx"), op("sqrtpd", "Vx,Wx"), op("sqrtss", "Vss,Hss,Wss"), op("sqrtsd", "Vsd,Hsd,Wsd")),
+	0x52: sse(op("rsqrtps", "Vx,Wx"), x86Op{}, op("rsqrtss", "Vss,Hss,Wss"), x86Op{}),
+	0x53: sse(op("rcpps", "Vx,Wx"), x86Op{}, op("rcpss", "Vss,Hss,Wss"), x86Op{}),
+	0x54: sse(op("andps", "Vx,Hx,Wx"), op("andpd", "Vx,Hx,Wx"), x86Op{}, x86Op{}),
+	0x55: sse(op("andnps", "Vx,Hx,Wx"), op("andnpd", "Vx,Hx,Wx"), x86Op{}, x86Op{}),
+	0x56: sse(op("orps", "Vx,Hx,Wx"), op("orpd", "Vx,Hx,Wx"), x86Op{}, x86Op{}),
+	0x57: sse(op("xorps", "Vx,Hx,Wx"), op("xorpd", "Vx,Hx,Wx"), x86Op{}, x86Op{}),
+	0x58: packed("add"),
+	0x59: packed("mul"),
+	0x5a: sse(op("cvtps2pd", "Vx,Wh"), op("cvtpd2ps", "Vh,Wx", xXYSuffix), op("cvtss2sd", "Vsd,Hsd,Wss"), op("cvtsd2ss", "Vss,Hss,Wsd")),
+	0x5b: sse(op("cvtdq2ps", "Vx,Wx"), op("cvtps2dq", "Vx,Wx"), op("cvttps2dq", "Vx,Wx"), x86Op{}),
+	0x5c: packed("sub"),
+	0x5d: packed("min"),
+	0x5e: packed("div"),
+	0x5f: packed("max"),
+	0x60: mmx("punpcklbw"),
+	0x61: mmx("punpcklwd"),
+	0x62: mmx("punpckldq"),
+	0x63: mmx("packsswb"),
+	0x64: mmx("pcmpgtb"),
+	0x65: mmx("pcmpgtw"),
+	0x66: mmx("pcmpgtd"),
+	0x67: mmx("packuswb"),
+	0x68: mmx("punpckhbw"),
+	0x69: mmx("punpckhwd"),
+	0x6a: mmx("punpckhdq"),
+	0x6b: mmx("packssdw"),
+	0x6c: p66(op("punpcklqdq", "Vx,Hx,Wx")),
+	0x6d: p66(op("punpckhqdq", "Vx,Hx,Wx")),
+	0x6e: sse(op("movd", "Pq,Ey", xMovdq), op("movd", "Vdq,Ey", xMovdq), x86Op{}, x86Op{}),
+	0x6f: sse(op("movq", "Pq,Qq"), op("movdqa", "Vx,Wx"), op("movdqu", "Vx,Wx"), x86Op{}),
+	0x70: sse(op("pshufw", "Pq,Qq,Ib"), op("pshufd", "Vx,Wx,Ib"), op("pshufhw", "Vx,Wx,Ib"), op("pshuflw", "Vx,Wx,Ib")),
+	0x71: sse(op("", "Nq,Ib"), op("", "Hx,Ux,Ib"), x86Op{}, x86Op{}),
+	0x72: sse(op("", "Nq,Ib"), op("", "Hx,Ux,Ib"), x86Op{}, x86Op{}),
+	0x73: sse(op("", "Nq,Ib"), op("", "Hx,Ux,Ib"), x86Op{}, x86Op{}),
+	0x74: mmx("pcmpeqb"),
+	0x75: mmx("pcmpeqw"),
+	0x76: mmx("pcmpeqd"),
+	0x77: sse(op("emms", ""), x86Op{}, x86Op{}, x86Op{}),
+	0x7c: sse(x86Op{}, op("haddpd", "Vx,Hx,Wx"), x86Op{}, op("haddps", "Vx,Hx,Wx")),
+	0x7d: sse(x86Op{}, op("hsubpd", "Vx,Hx,Wx"), x86Op{}, op("hsubps", "Vx,Hx,Wx")),
+	0x7e: sse(op("movd", "Ey,Pq", xMovdq), op("movd", "Ey,Vdq", xMovdq), op("movq", "Vdq,Wq"), x86Op{}),
+	0x7f: sse(op("movq", "Qq,Pq"), op("movdqa", "Wx,Vx"), op("movdqu", "Wx,Vx"), x86Op{}),
+	0xa0: anyPfx(op("push", "FS", xDef64)),
+	0xa1: anyPfx(op("pop", "FS", xDef64)),
+	0xa2: anyPfx(op("cpuid", "")),
+	0xa3: anyPfx(op("bt", "Ev,Gv")),
+	0xa4: anyPfx(op("shld", "Ev,Gv,Ib")),
+	0xa5: anyPfx(op("shld", "Ev,Gv,CL")),
+	0xa8: anyPfx(op("push", "GS", xDef64)),
+	0xa9: anyPfx(op("pop", "GS", xDef64)),
+	0xaa: anyPfx(op("rsm", "")),
+	0xab: anyPfx(op("bts", "Ev,Gv")),
+	0xac: anyPfx(op("shrd", "Ev,Gv,Ib")),
+	0xad: anyPfx(op("shrd", "Ev,Gv,CL")),
+	0xae: anyPfx(grp("grp15", "")),
+	0xaf: anyPfx(op("imul", "Gv,Ev")),
+	0xb0: anyPfx(op("cmpxchg", "Eb,Gb")),
+	0xb1: anyPfx(op("cmpxchg", "Ev,Gv")),
+	0xb2: anyPfx(op("lss", "Gv,Mp")),
+	0xb3: anyPfx(op("btr", "Ev,Gv")),
+	0xb4: anyPfx(op("lfs", "Gv,Mp")),
+	0xb5: anyPfx(op("lgs", "Gv,Mp")),
+	0xb6: anyPfx(op("movzx", "Gv,Eb")),
+	0xb7: anyPfx(op("movzx", "Gv,Ew")),
+	0xb8: sse(x86Op{}, x86Op{}, op("popcnt", "Gv,Ev"), x86Op{}),
+	0xb9: anyPfx(op("ud1", "Gv,Ev")),
+	0xba: anyPfx(grp("grp8", "Ev,Ib")),
+	0xbb: anyPfx(op("btc", "Ev,Gv")),
+	0xbc: sse(op("bsf", "Gv,Ev"), x86Op{}, op("tzcnt", "Gv,Ev"), x86Op{}),
+	0xbd: sse(op("bsr", "Gv,Ev"), x86Op{}, op("lzcnt", "Gv,Ev"), x86Op{}),
+	0xbe: anyPfx(op("movsx", "Gv,Eb")),
+	0xbf: anyPfx(op("movsx", "Gv,Ew")),
+	0xc0: anyPfx(op("xadd", "Eb,Gb")),
+	0xc1: anyPfx(op("xadd", "Ev,Gv")),
+	0xc2: sse(op("cmpps", "Vx,Hx,Wx,Ib", xCondCmp), op("cmppd", "Vx,Hx,Wx,Ib", xCondCmp), op("cmpss", "Vss,Hss,Wss,Ib", xCondCmp), op("cmpsd", "Vsd,Hsd,Wsd,Ib", xCondCmp)),
+	0xc3: sse(op("movnti", "My,Gy"), x86Op{}, x86Op{}, x86Op{}),
+	0xc4: sse(op("pinsrw", "Pq,Edw,Ib"), op("pinsrw", "Vdq,Hdq,Edw,Ib"), x86Op{}, x86Op{}),
+	0xc5: sse(op("pextrw", "Gd,Nq,Ib"), op("pextrw", "Gd,Udq,Ib"), x86Op{}, x86Op{}),
+	0xc6: sse(op("shufps", "Vx,Hx,Wx,Ib"), op("shufpd", "Vx,Hx,Wx,Ib"), x86Op{}, x86Op{}),
+	0xc7: anyPfx(grp("grp9", "")),
+	0xd0: sse(x86Op{}, op("addsubpd", "Vx,Hx,Wx"), x86Op{}, op("addsubps", "Vx,Hx,Wx")),
+	0xd1: shift("psrlw"),
+	0xd2: shift("psrld"),
+	0xd3: shift("psrlq"),
+	0xd4: mmx("paddq"),
+	0xd5: mmx("pmullw"),
+	0xd6: sse(x86Op{}, op("movq", "Wq,Vdq"), op("movq2dq", "Vdq,Nq"), op("movdq2q", "Pq,Udq")),
+	0xd7: sse(op("pmovmskb", "Gd,Nq"), op("pmovmskb", "Gd,Ux"), x86Op{}, x86Op{}),
+	0xd8: mmx("psubusb"),
+	0xd9: mmx("psubusw"),
+	0xda: mmx("pminub"),
+	0xdb: mmx("pand"),
+	0xdc: mmx("paddusb"),
+	0xdd: mmx("paddusw"),
+	0xde: mmx("pmaxub"),
+	0xdf: mmx("pandn"),
+	0xe0: mmx("pavgb"),
+	0xe1: shift("psraw"),
+	0xe2: shift("psrad"),
+	0xe3: mmx("pavgw"),
+	0xe4: mmx("pmulhuw"),
+	0xe5: mmx("pmulhw"),
+	0xe6: sse(x86Op{}, op("cvttpd2dq", "Vh,Wx", xXYSuffix), op("cvtdq2pd", "Vx,Wh"), op("cvtpd2dq", "Vh,Wx", xXYSuffix)),
+	0xe7: sse(op("movntq", "Mq,Pq"), op("movntdq", "Mx,Vx"), x86Op{}, x86Op{}),
+	0xe8: mmx("psubsb"),
+	0xe9: mmx("psubsw"),
+	0xea: mmx("pminsw"),
+	0xeb: mmx("por"),
+	0xec: mmx("paddsb"),
+	0xed: mmx("paddsw"),
+	0xee: mmx("pmaxsw"),
+	0xef: mmx("pxor"),
+	0xf0: sse(x86Op{}, x86Op{}, x86Op{}, op("lddqu", "Vx,Mx")),
+	0xf1: shift("psllw"),
+	0xf2: shift("pslld"),
+	0xf3: shift("psllq"),
+	0xf4: mmx("pmuludq"),
+	0xf5: mmx("pmaddwd"),
+	0xf6: mmx("psadbw"),
+	0xf7: sse(op("maskmovq", "Pq,Nq"), op("maskmovdqu", "Vdq,Udq"), x86Op{}, x86Op{}),
+	0xf8: mmx("psubb"),
+	0xf9: mmx("psubw"),
+	0xfa: mmx("psubd"),
+	0xfb: mmx("psubq"),
+	0xfc: mmx("paddb"),
+	0xfd: mmx("paddw"),
+	0xfe: mmx("paddd"),
+	0xff: anyPfx(op("ud0", "Gv,Ev")),
+}
+
+func init() {
+	for c := byte(0); c < 16; c++ {
+		x86TwoByte[0x40+c] = anyPfx(op("cmov"+x86Cond[c], "Gv,Ev"))
+		x86TwoByte[0x80+c] = anyPfx(op("j"+x86Cond[c], "Jz", xDef64|xBranch))
+		x86TwoByte[0x90+c] = anyPfx(op("set"+x86Cond[c], "Eb", xNoSuffix))
+	}
+	for r := byte(0); r < 8; r++ {
+		x86TwoByte[0xc8+r] = anyPfx(op("bswap", "Zy"))
+	}
+}
+
+var x86ThreeByte38 = map[byte]x86Variants{
+	0x0c: p66(op("vpermilps", "Vx,Hx,Wx", xVEX)),
+	0x0d: p66(op("vpermilpd", "Vx,Hx,Wx", xVEX)),
+	0x0e: p66(op("vtestps", "Vx,Wx", xVEX)),
+	0x0f: p66(op("vtestpd", "Vx,Wx", xVEX)),
+	0x10: p66(op("pblendvb", "Vdq,Wdq,XMM0")),
+	0x13: p66(op("vcvtph2ps", "Vx,Wh", xVEX)),
+	0x14: p66(op("blendvps", "Vdq,Wdq,XMM0")),
+	0x15: p66(op("blendvpd", "Vdq,Wdq,XMM0")),
+	0x16: p66(op("vpermps", "Vqq,Hqq,Wqq", xVEX)),
+	0x17: p66(op("ptest", "Vx,Wx")),
+	0x18: p66(op("vbroadcastss", "Vx,Wd", xVEX)),
+	0x19: p66(op("vbroadcastsd", "Vqq,Wq", xVEX)),
+	0x1a: p66(op("vbroadcastf128", "Vqq,Mdq", xVEX)),
+	0x20: p66(op("pmovsxbw", "Vx,Wh")),
+	0x21: p66(op("pmovsxbd", "Vx,Wqx")),
+	0x22: p66(op("pmovsxbq", "Vx,Wex")),
+	0x23: p66(op("pmovsxwd", "Vx,Wh")),
+	0x24: p66(op("pmovsxwq", "Vx,Wqx")),
+	0x25: p66(op("pmovsxdq", "Vx,Wh")),
+	0x28: p66(op("pmuldq", "Vx,Hx,Wx")),
+	0x29: p66(op("pcmpeqq", "Vx,Hx,Wx")),
+	0x2a: p66(op("movntdqa", "Vx,Mx")),
+	0x2b: p66(op("packusdw", "Vx,Hx,Wx")),
+	0x2c: p66(op("vmaskmovps", "Vx,Hx,Mx", xVEX)),
+	0x2d: p66(op("vmaskmovpd", "Vx,Hx,Mx", xVEX)),
+	0x2e: p66(op("vmaskmovps", "Mx,Hx,Vx", xVEX)),
+	0x2f: p66(op("vmaskmovpd", "Mx,Hx,Vx", xVEX)),
+	0x30: p66(op("pmovzxbw", "Vx,Wh")),
+	0x31: p66(op("pmovzxbd", "Vx,Wqx")),
+	0x32: p66(op("pmovzxbq", "Vx,Wex")),
+	0x33: p66(op("pmovzxwd", "Vx,Wh")),
+	0x34: p66(op("pmovzxwq", "Vx,Wqx")),
+	0x35: p66(op("pmovzxdq", "Vx,Wh")),
+	0x36: p66(op("vpermd", "Vqq,Hqq,Wqq", xVEX)),
+	0x37: p66(op("pcmpgtq", "Vx,Hx,Wx")),
+	0x38: p66(op("pminsb", "Vx,Hx,Wx")),
+	0x39: p66(op("pminsd", "Vx,Hx,Wx")),
+	0x3a: p66(op("pminuw", "Vx,Hx,Wx")),
+	0x3b: p66(op("pminud", "Vx,Hx,Wx")),
+	0x3c: p66(op("pmaxsb", "Vx,Hx,Wx")),
+	0x3d: p66(op("pmaxsd", "Vx,Hx,Wx")),
+	0x3e: p66(op("pmaxuw", "Vx,Hx,Wx")),
+	0x3f: p66(op("pmaxud", "Vx,Hx,Wx")),
+	0x40: p66(op("pmulld", "Vx,Hx,Wx")),
+	0x41: p66(op("phminposuw", "Vdq,Wdq")),
+	0x45: p66(op("vpsrlv", "Vx,Hx,Wx", xVEX, xWName)),
+	0x46: p66(op("vpsravd", "Vx,Hx,Wx", xVEX)),
+	0x47: p66(op("vpsllv", "Vx,Hx,Wx", xVEX, xWName)),
+	0x58: p66(op("vpbroadcastd", "Vx,Wd", xVEX)),
+	0x59: p66(op("vpbroadcastq", "Vx,Wq", xVEX)),
+	0x5a: p66(op("vbroadcasti128", "Vqq,Mdq", xVEX)),
+	0x78: p66(op("vpbroadcastb", "Vx,Wb", xVEX)),
+	0x79: p66(op("vpbroadcastw", "Vx,Ww", xVEX)),
+	0x8c: p66(op("vpmaskmov", "Vx,Hx,Mx", xVEX, xWName)),
+	0x8e: p66(op("vpmaskmov", "Mx,Hx,Vx", xVEX, xWName)),
+	0xdb: p66(op("aesimc", "Vdq,Wdq")),
+	0xdc: p66(op("aesenc", "Vx,Hx,Wx")),
+	0xdd: p66(op("aesenclast", "Vx,Hx,Wx")),
+	0xde: p66(op("aesdec", "Vx,Hx,Wx")),
+	0xdf: p66(op("aesdeclast", "Vx,Hx,Wx")),
+	0xf0: sse(op("movbe", "Gv,Mv"), x86Op{}, x86Op{}, op("crc32", "Gy,Eb", xSuffix)),
+	0xf1: sse(op("movbe", "Mv,Gv"), x86Op{}, x86Op{}, op("crc32", "Gy,Ev", xSuffix)),
+	0xf2: sse(op("andn", "Gy,By,Ey", xVEX), x86Op{}, x86Op{}, x86Op{}),
+	0xf3: sse(grp("grp17", "", xVEX), x86Op{}, x86Op{}, x86Op{}),
+	0xf5: sse(op("bzhi", "Gy,Ey,By", xVEX), x86Op{}, op("pext", "Gy,By,Ey", xVEX), op("pdep", "Gy,By,Ey", xVEX)),
+	0xf6: sse(x86Op{}, x86Op{}, x86Op{}, op("mulx", "Gy,By,Ey", xVEX)),
+	0xf7: sse(op("bextr", "Gy,Ey,By", xVEX), op("shlx", "Gy,Ey,By", xVEX), op("sarx", "Gy,Ey,By", xVEX), op("shrx", "Gy,Ey,By", xVEX)),
+}
+
+var x86ThreeByte3A = map[byte]x86Variants{
+	0x00: p66(op("vpermq", "Vqq,Wqq,Ib", xVEX)),
+	0x01: p66(op("vpermpd", "Vqq,Wqq,Ib", xVEX)),
+	0x02: p66(op("vpblendd", "Vx,Hx,Wx,Ib", xVEX)),
+	0x04: p66(op("vpermilps", "Vx,Wx,Ib", xVEX)),
+	0x05: p66(op("vpermilpd", "Vx,Wx,Ib", xVEX)),
+	0x06: p66(op("vperm2f128", "Vqq,Hqq,Wqq,Ib", xVEX)),
+	0x08: p66(op("roundps", "Vx,Wx,Ib")),
+	0x09: p66(op("roundpd", "Vx,Wx,Ib")),
+	0x0a: p66(op("roundss", "Vss,Hss,Wss,Ib")),
+	0x0b: p66(op("roundsd", "Vsd,Hsd,Wsd,Ib")),
+	0x0c: p66(op("blendps", "Vx,Hx,Wx,Ib")),
+	0x0d: p66(op("blendpd", "Vx,Hx,Wx,Ib")),
+	0x0e: p66(op("pblendw", "Vx,Hx,Wx,Ib")),
+	0x0f: sse(op("palignr", "Pq,Qq,Ib"), op("palignr", "Vx,Hx,Wx,Ib"), x86Op{}, x86Op{}),
+	0x14: p66(op("pextrb", "Edb,Vdq,Ib")),
+	0x15: p66(op("pextrw", "Edw,Vdq,Ib")),
+	0x16: p66(op("pextr", "Ey,Vdq,Ib", xWName)),
+	0x17: p66(op("extractps", "Ed,Vdq,Ib")),
+	0x18: p66(op("vinsertf128", "Vqq,Hqq,Wdq,Ib", xVEX)),
+	0x19: p66(op("vextractf128", "Wdq,Vqq,Ib", xVEX)),
+	0x1d: p66(op("vcvtps2ph", "Wh,Vx,Ib", xVEX)),
+	0x20: p66(op("pinsrb", "Vdq,Hdq,Edb,Ib")),
+	0x21: p66(op("insertps", "Vdq,Hdq,Wd,Ib")),
+	0x22: p66(op("pinsr", "Vdq,Hdq,Ey,Ib", xWName)),
+	0x38: p66(op("vinserti128", "Vqq,Hqq,Wdq,Ib", xVEX)),
+	0x39: p66(op("vextracti128", "Wdq,Vqq,Ib", xVEX)),
+	0x40: p66(op("dpps", "Vx,Hx,Wx,Ib")),
+	0x41: p66(op("dppd", "Vdq,Hdq,Wdq,Ib")),
+	0x42: p66(op("mpsadbw", "Vx,Hx,Wx,Ib")),
+	0x44: p66(op("pclmulqdq", "Vx,Hx,Wx,Ib", xCondCmp)),
+	0x46: p66(op("vperm2i128", "Vqq,Hqq,Wqq,Ib", xVEX)),
+	0x4a: p66(op("vblendvps", "Vx,Hx,Wx,Lx", xVEX)),
+	0x4b: p66(op("vblendvpd", "Vx,Hx,Wx,Lx", xVEX)),
+	0x4c: p66(op("vpblendvb", "Vx,Hx,Wx,Lx", xVEX)),
+	0x60: p66(op("pcmpestrm", "Vdq,Wdq,Ib")),
+	0x61: p66(op("pcmpestri", "Vdq,Wdq,Ib")),
+	0x62: p66(op("pcmpistrm", "Vdq,Wdq,Ib")),
+	0x63: p66(op("pcmpistri", "Vdq,Wdq,Ib")),
+	0xdf: p66(op("aeskeygenassist", "Vdq,Wdq,Ib")),
+	0xf0: sse(x86Op{}, x86Op{}, x86Op{}, op("rorx", "Gy,Ey,Ib", xVEX)),
+}
+
+func init() {
+	ssse3 := []string{"pshufb", "phaddw", "phaddd", "phaddsw", "pmaddubsw", "phsubw", "phsubd", "phsubsw", "psignb", "psignw", "psignd", "pmulhrsw"}
+	for i, name := range ssse3 {
+		x86ThreeByte38[byte(i)] = mmx(name)
+	}
+	for i, name := range []string{"pabsb", "pabsw", "pabsd"} {
+		x86ThreeByte38[byte(0x1c+i)] = x86Variants{op(name, "Pq,Qq"), op(name, "Vx,Wx")}
+	}
+
+	// FMA3: the low nibble selects the operation, the high nibble the
+	// operand order and VEX.W the element type.
+	fma := map[byte]string{
+		0x6: "fmaddsub", 0x7: "fmsubadd", 0x8: "fmadd", 0x9: "fmadd",
+		0xa: "fmsub", 0xb: "fmsub", 0xc: "fnmadd", 0xd: "fnmadd", 0xe: "fnmsub", 0xf: "fnmsub",
+	}
+	for hi, order := range map[byte]string{0x90: "132", 0xa0: "213", 0xb0: "231"} {
+		for lo, name := range fma {
+			args, typ := "Vx,Hx,Wx", "p"
+			if lo >= 0x8 && lo%2 == 1 {
+				args, typ = "Vdq,Hdq,Wfs", "s"
+			}
+			x86ThreeByte38[hi+lo] = p66(op("v"+name+order+typ, args, xVEX, xWName))
+		}
+	}
+}
+
+// evexKey selects an opcode that only exists in EVEX form by opcode map,
+// opcode, mandatory prefix and EVEX.W.
+type evexKey struct {
+	mapNum int
+	opc    byte
+	pp     int
+	w      bool
+}
+
+// x86EVEX holds the EVEX-only opcodes, and those whose EVEX form differs
+// from the VEX one in more than register width.
+var x86EVEX = map[evexKey]x86Op{}
+
+// evexW adds an EVEX opcode whose EVEX.W selects between two mnemonics. An
+// empty mnemonic leaves that form invalid.
+func evexW(mapNum int, opc byte, pp int, w0, w1, args string, flags ...x86Flags) {
+	if w0 != "" {
+		x86EVEX[evexKey{mapNum, opc, pp, false}] = op(w0, args, append(flags, xVEX)...)
+	}
+	if w1 != "" {
+		x86EVEX[evexKey{mapNum, opc, pp, true}] = op(w1, args, append(flags, xVEX)...)
+	}
+}
+
+func init() {
+	for _, m := range []struct {
+		pp     int
+		w0, w1 string
+	}{{pfx66, "vmovdqa32", "vmovdqa64"}, {pfxF3, "vmovdqu32", "vmovdqu64"}, {pfxF2, "vmovdqu8", "vmovdqu16"}} {
+		evexW(1, 0x6f, m.pp, m.w0, m.w1, "Vx,Wx")
+		evexW(1, 0x7f, m.pp, m.w0, m.w1, "Wx,Vx")
+	}
+	// Conversions between dwords and qwords: the dword vector is half as
+	// long.
+	evexW(1, 0x5b, pfxNone, "", "vcvtqq2ps", "Vh,Wx", xXYSuffix)
+	evexW(1, 0x78, pfxNone, "vcvttps2udq", "", "Vx,Wx")
+	evexW(1, 0x78, pfxNone, "", "vcvttpd2udq", "Vh,Wx", xXYSuffix)
+	evexW(1, 0x78, pfx66, "vcvttps2uqq", "", "Vx,Wh")
+	evexW(1, 0x78, pfx66, "", "vcvttpd2uqq", "Vx,Wx")
+	evexW(1, 0x78, pfxF3, "vcvttss2usi", "vcvttss2usi", "Gy,Wss")
+	evexW(1, 0x78, pfxF2, "vcvttsd2usi", "vcvttsd2usi", "Gy,Wsd")
+	evexW(1, 0x79, pfxNone, "vcvtps2udq", "", "Vx,Wx")
+	evexW(1, 0x79, pfxNone, "", "vcvtpd2udq", "Vh,Wx", xXYSuffix)
+	evexW(1, 0x79, pfx66, "vcvtps2uqq", "", "Vx,Wh")
+	evexW(1, 0x79, pfx66, "", "vcvtpd2uqq", "Vx,Wx")
+	evexW(1, 0x79, pfxF3, "vcvtss2usi", "vcvtss2usi", "Gy,Wss")
+	evexW(1, 0x79, pfxF2, "vcvtsd2usi", "vcvtsd2usi", "Gy,Wsd")
+	evexW(1, 0x7a, pfx66, "vcvttps2qq", "", "Vx,Wh")
+	evexW(1, 0x7a, pfx66, "", "vcvttpd2qq", "Vx,Wx")
+	evexW(1, 0x7a, pfxF3, "vcvtudq2pd", "", "Vx,Wh")
+	evexW(1, 0x7a, pfxF3, "", "vcvtuqq2pd", "Vx,Wx")
+	evexW(1, 0x7a, pfxF2, "vcvtudq2ps", "", "Vx,Wx")
+	evexW(1, 0x7a, pfxF2, "", "vcvtuqq2ps", "Vh,Wx", xXYSuffix)
+	evexW(1, 0x7b, pfx66, "vcvtps2qq", "", "Vx,Wh")
+	evexW(1, 0x7b, pfx66, "", "vcvtpd2qq", "Vx,Wx")
+	evexW(1, 0x7b, pfxF3, "vcvtusi2ss", "vcvtusi2ss", "Vss,Hss,Ey", xSuffix)
+	evexW(1, 0x7b, pfxF2, "vcvtusi2sd", "vcvtusi2sd", "Vsd,Hsd,Ey", xSuffix)
+	evexW(1, 0xe6, pfxF3, "", "vcvtqq2pd", "Vx,Wx")
+
+	// Down conversions of 16-, 32- and 64-bit elements to narrower ones,
+	// plain, with signed and with unsigned saturation.
+	for i, n := range []string{"wb", "db", "qb", "dw", "qw", "qd"} {
+		size := []string{"h", "qx", "ex", "h", "qx", "h"}[i]
+		for j, sat := range []string{"us", "s", ""} {
+			evexW(2, byte(0x10+0x10*j+i), pfxF3, "vpmov"+sat+n, "", "W"+size+",Vx")
+		}
+	}
+
+	evexW(2, 0x16, pfx66, "vpermps", "vpermpd", "Vx,Hx,Wx")
+	evexW(2, 0x19, pfx66, "vbroadcastf32x2", "vbroadcastsd", "Vx,Wq")
+	evexW(2, 0x1a, pfx66, "vbroadcastf32x4", "vbroadcastf64x2", "Vx,Mdq")
+	evexW(2, 0x1b, pfx66, "vbroadcastf32x8", "vbroadcastf64x4", "Vx,Mqq")
+	evexW(2, 0x1f, pfx66, "", "vpabsq", "Vx,Wx")
+	evexW(2, 0x10, pfx66, "", "vpsrlvw", "Vx,Hx,Wx")
+	evexW(2, 0x11, pfx66, "", "vpsravw", "Vx,Hx,Wx")
+	evexW(2, 0x12, pfx66, "", "vpsllvw", "Vx,Hx,Wx")
+	evexW(2, 0x14, pfx66, "vprorvd", "vprorvq", "Vx,Hx,Wx")
+	evexW(2, 0x15, pfx66, "vprolvd", "vprolvq", "Vx,Hx,Wx")
+	evexW(2, 0x26, pfx66, "vptestmb", "vptestmw", "K,Hx,Wx")
+	evexW(2, 0x26, pfxF3, "vptestnmb", "vptestnmw", "K,Hx,Wx")
+	evexW(2, 0x27, pfx66, "vptestmd", "vptestmq", "K,Hx,Wx")
+	evexW(2, 0x27, pfxF3, "vptestnmd", "vptestnmq", "K,Hx,Wx")
+	evexW(2, 0x28, pfxF3, "vpmovm2b", "vpmovm2w", "Vx,k")
+	evexW(2, 0x2a, pfxF3, "", "vpbroadcastmb2q", "Vx,k")
+	evexW(2, 0x2c, pfx66, "vscalefps", "vscalefpd", "Vx,Hx,Wx")
+	evexW(2, 0x2d, pfx66, "vscalefss", "vscalefsd", "Vdq,Hdq,Wfs")
+	evexW(2, 0x29, pfxF3, "vpmovb2m", "vpmovw2m", "K,Ux")
+	evexW(2, 0x36, pfx66, "vpermd", "vpermq", "Vx,Hx,Wx")
+	evexW(2, 0x38, pfxF3, "vpmovm2d", "vpmovm2q", "Vx,k")
+	evexW(2, 0x39, pfxF3, "vpmovd2m", "vpmovq2m", "K,Ux")
+	evexW(2, 0x3a, pfxF3, "vpbroadcastmw2d", "", "Vx,k")
+	evexW(2, 0x42, pfx66, "vgetexpps", "vgetexppd", "Vx,Wx")
+	evexW(2, 0x43, pfx66, "vgetexpss", "vgetexpsd", "Vdq,Hdq,Wfs")
+	evexW(2, 0x44, pfx66, "vplzcntd", "vplzcntq", "Vx,Wx")
+	evexW(2, 0x4c, pfx66, "vrcp14ps", "vrcp14pd", "Vx,Wx")
+	evexW(2, 0x4d, pfx66, "vrcp14ss", "vrcp14sd", "Vdq,Hdq,Wfs")
+	evexW(2, 0x50, pfx66, "vpdpbusd", "", "Vx,Hx,Wx")
+	evexW(2, 0x51, pfx66, "vpdpbusds", "", "Vx,Hx,Wx")
+	evexW(2, 0x52, pfx66, "vpdpwssd", "", "Vx,Hx,Wx")
+	evexW(2, 0x53, pfx66, "vpdpwssds", "", "Vx,Hx,Wx")
+	evexW(2, 0x54, pfx66, "vpopcntb", "vpopcntw", "Vx,Wx")
+	evexW(2, 0x55, pfx66, "vpopcntd", "vpopcntq", "Vx,Wx")
+	evexW(2, 0x4e, pfx66, "vrsqrt14ps", "vrsqrt14pd", "Vx,Wx")
+	evexW(2, 0x4f, pfx66, "vrsqrt14ss", "vrsqrt14sd", "Vdq,Hdq,Wfs")
+	evexW(2, 0x59, pfx66, "vbroadcasti32x2", "vpbroadcastq", "Vx,Wq")
+	evexW(2, 0x5a, pfx66, "vbroadcasti32x4", "vbroadcasti64x2", "Vx,Mdq")
+	evexW(2, 0x5b, pfx66, "vbroadcasti32x8", "vbroadcasti64x4", "Vx,Mqq")
+	evexW(2, 0x64, pfx66, "vpblendmd", "vpblendmq", "Vx,Hx,Wx")
+	evexW(2, 0x65, pfx66, "vblendmps", "vblendmpd", "Vx,Hx,Wx")
+	evexW(2, 0x66, pfx66, "vpblendmb", "vpblendmw", "Vx,Hx,Wx")
+	evexW(2, 0x75, pfx66, "vpermi2b", "vpermi2w", "Vx,Hx,Wx")
+	evexW(2, 0x76, pfx66, "vpermi2d", "vpermi2q", "Vx,Hx,Wx")
+	evexW(2, 0x77, pfx66, "vpermi2ps", "vpermi2pd", "Vx,Hx,Wx")
+	evexW(2, 0x7a, pfx66, "vpbroadcastb", "", "Vx,Rd")
+	evexW(2, 0x7b, pfx66, "vpbroadcastw", "", "Vx,Rd")
+	evexW(2, 0x7c, pfx66, "vpbroadcastd", "vpbroadcastq", "Vx,Ry")
+	evexW(2, 0x7d, pfx66, "vpermt2b", "vpermt2w", "Vx,Hx,Wx")
+	evexW(2, 0x7e, pfx66, "vpermt2d", "vpermt2q", "Vx,Hx,Wx")
+	evexW(2, 0x70, pfx66, "", "vpshldvw", "Vx,Hx,Wx")
+	evexW(2, 0x71, pfx66, "vpshldvd", "vpshldvq", "Vx,Hx,Wx")
+	evexW(2, 0x72, pfx66, "", "vpshrdvw", "Vx,Hx,Wx")
+	evexW(2, 0x73, pfx66, "vpshrdvd", "vpshrdvq", "Vx,Hx,Wx")
+	evexW(2, 0x7f, pfx66, "vpermt2ps", "vpermt2pd", "Vx,Hx,Wx")
+	evexW(2, 0x83, pfx66, "", "vpmultishiftqb", "Vx,Hx,Wx")
+	evexW(2, 0x88, pfx66, "vexpandps", "vexpandpd", "Vx,Wx", xElemDisp)
+	evexW(2, 0x89, pfx66, "vpexpandd", "vpexpandq", "Vx,Wx", xElemDisp)
+	evexW(2, 0x8a, pfx66, "vcompressps", "vcompresspd", "Wx,Vx", xElemDisp)
+	evexW(2, 0x8b, pfx66, "vpcompressd", "vpcompressq", "Wx,Vx", xElemDisp)
+	evexW(2, 0x8d, pfx66, "vpermb", "vpermw", "Vx,Hx,Wx")
+	evexW(2, 0xb4, pfx66, "", "vpmadd52luq", "Vx,Hx,Wx")
+	evexW(2, 0xb5, pfx66, "", "vpmadd52huq", "Vx,Hx,Wx")
+	evexW(2, 0xc4, pfx66, "vpconflictd", "vpconflictq", "Vx,Wx")
+	evexW(2, 0xc8, pfx66, "vexp2ps", "vexp2pd", "Vx,Wx")
+	evexW(2, 0xca, pfx66, "vrcp28ps", "vrcp28pd", "Vx,Wx")
+	evexW(2, 0xcb, pfx66, "vrcp28ss", "vrcp28sd", "Vdq,Hdq,Wfs")
+	evexW(2, 0xcc, pfx66, "vrsqrt28ps", "vrsqrt28pd", "Vx,Wx")
+	evexW(2, 0xcd, pfx66, "vrsqrt28ss", "vrsqrt28sd", "Vdq,Hdq,Wfs")
+
+	evexW(3, 0x00, pfx66, "", "vpermq", "Vx,Wx,Ib")
+	evexW(3, 0x01, pfx66, "", "vpermpd", "Vx,Wx,Ib")
+	evexW(3, 0x03, pfx66, "valignd", "valignq", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x08, pfx66, "vrndscaleps", "", "Vx,Wx,Ib")
+	evexW(3, 0x09, pfx66, "", "vrndscalepd", "Vx,Wx,Ib")
+	evexW(3, 0x0a, pfx66, "vrndscaless", "", "Vdq,Hdq,Wss,Ib")
+	evexW(3, 0x0b, pfx66, "", "vrndscalesd", "Vdq,Hdq,Wsd,Ib")
+	evexW(3, 0x18, pfx66, "vinsertf32x4", "vinsertf64x2", "Vx,Hx,Wdq,Ib")
+	evexW(3, 0x19, pfx66, "vextractf32x4", "vextractf64x2", "Wdq,Vx,Ib")
+	evexW(3, 0x1a, pfx66, "vinsertf32x8", "vinsertf64x4", "Vx,Hx,Wqq,Ib")
+	evexW(3, 0x1b, pfx66, "vextractf32x8", "vextractf64x4", "Wqq,Vx,Ib")
+	evexW(3, 0x1e, pfx66, "vpcmpud", "vpcmpuq", "K,Hx,Wx,Ib", xIntCmp)
+	evexW(3, 0x1f, pfx66, "vpcmpd", "vpcmpq", "K,Hx,Wx,Ib", xIntCmp)
+	evexW(3, 0x23, pfx66, "vshuff32x4", "vshuff64x2", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x25, pfx66, "vpternlogd", "vpternlogq", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x26, pfx66, "vgetmantps", "vgetmantpd", "Vx,Wx,Ib")
+	evexW(3, 0x27, pfx66, "vgetmantss", "vgetmantsd", "Vdq,Hdq,Wfs,Ib")
+	evexW(3, 0x38, pfx66, "vinserti32x4", "vinserti64x2", "Vx,Hx,Wdq,Ib")
+	evexW(3, 0x39, pfx66, "vextracti32x4", "vextracti64x2", "Wdq,Vx,Ib")
+	evexW(3, 0x3a, pfx66, "vinserti32x8", "vinserti64x4", "Vx,Hx,Wqq,Ib")
+	evexW(3, 0x3b, pfx66, "vextracti32x8", "vextracti64x4", "Wqq,Vx,Ib")
+	evexW(3, 0x3e, pfx66, "vpcmpub", "vpcmpuw", "K,Hx,Wx,Ib", xIntCmp)
+	evexW(3, 0x3f, pfx66, "vpcmpb", "vpcmpw", "K,Hx,Wx,Ib", xIntCmp)
+	evexW(3, 0x42, pfx66, "vdbpsadbw", "", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x43, pfx66, "vshufi32x4", "vshufi64x2", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x50, pfx66, "vrangeps", "vrangepd", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x51, pfx66, "vrangess", "vrangesd", "Vdq,Hdq,Wfs,Ib")
+	evexW(3, 0x54, pfx66, "vfixupimmps", "vfixupimmpd", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x55, pfx66, "vfixupimmss", "vfixupimmsd", "Vdq,Hdq,Wfs,Ib")
+	evexW(3, 0x56, pfx66, "vreduceps", "vreducepd", "Vx,Wx,Ib")
+	evexW(3, 0x57, pfx66, "vreducess", "vreducesd", "Vdq,Hdq,Wfs,Ib")
+	evexW(3, 0x66, pfx66, "vfpclassps", "vfpclasspd", "K,Wx,Ib", xXYSuffix)
+	evexW(3, 0x67, pfx66, "vfpclassss", "vfpclasssd", "K,Wfs,Ib")
+	evexW(3, 0x70, pfx66, "", "vpshldw", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x71, pfx66, "vpshldd", "vpshldq", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x72, pfx66, "", "vpshrdw", "Vx,Hx,Wx,Ib")
+	evexW(3, 0x73, pfx66, "vpshrdd", "vpshrdq", "Vx,Hx,Wx,Ib")
+}
+
+// VEX opcodes without an EVEX form, by mnemonic without the "v".
+var x86NoEVEX = map[string]bool{
+	"addsubpd": true, "addsubps": true, "aesimc": true, "aeskeygenassist": true, "blendpd": true,
+	"blendps": true, "dppd": true, "dpps": true, "haddpd": true, "haddps": true, "hsubpd": true,
+	"hsubps": true, "lddqu": true, "maskmovdqu": true, "maskmovpd": true, "maskmovps": true,
+	"movmskpd": true, "movmskps": true, "mpsadbw": true, "pblendd": true, "pblendvb": true,
+	"pblendw": true, "pcmpestri": true, "pcmpestrm": true, "pcmpistri": true, "pcmpistrm": true,
+	"perm2f128": true, "perm2i128": true, "phaddd": true, "phaddsw": true, "phaddw": true,
+	"phminposuw": true, "phsubd": true, "phsubsw": true, "phsubw": true, "pmaskmov": true,
+	"pmovmskb": true, "psignb": true, "psignd": true, "psignw": true,
+	"ptest": true, "rcpps": true, "rcpss": true, "roundpd": true, "roundps": true, "roundsd": true,
+	"roundss": true, "rsqrtps": true, "rsqrtss": true, "testpd": true, "testps": true,
+	"zeroall": true, "zeroupper": true,
+}
+
+// VEX mnemonics that EVEX.W turns into a qword form.
+var x86EVEXW1 = map[string]string{
+	"pmaxsd": "pmaxsq", "pmaxud": "pmaxuq", "pminsd": "pminsq", "pminud": "pminuq",
+	"pmulld": "pmullq", "psrad": "psraq", "psravd": "psravq",
+}
+
+// Integer predicates folded into the vpcmp family of mnemonics. 3 and 7
+// (always false and always true) keep the immediate.
+var x86IntCmpPred = []string{"eq", "lt", "le", "", "neq", "nlt", "nle", ""}
+
+// x87 memory forms, indexed by escape opcode (D8-DF) and ModRM.reg. The
+// second string is the AT&T mnemonic, the size is that of the operand.
+type x87Mem struct {
+	name, att string
+	size      int
+}
+
+var x87MemOps = [8][8]x87Mem{
+	{{"fadd", "fadds", 4}, {"fmul", "fmuls", 4}, {"fcom", "fcoms", 4}, {"fcomp", "fcomps", 4}, {"fsub", "fsubs", 4}, {"fsubr", "fsubrs", 4}, {"fdiv", "fdivs", 4}, {"fdivr", "fdivrs", 4}},
+	{{"fld", "flds", 4}, {}, {"fst", "fsts", 4}, {"fstp", "fstps", 4}, {"fldenv", "fldenv", 0}, {"fldcw", "fldcw", 2}, {"fnstenv", "fnstenv", 0}, {"fnstcw", "fnstcw", 2}},
+	{{"fiadd", "fiaddl", 4}, {"fimul", "fimull", 4}, {"ficom", "ficoml", 4}, {"ficomp", "ficompl", 4}, {"fisub", "fisubl", 4}, {"fisubr", "fisubrl", 4}, {"fidiv", "fidivl", 4}, {"fidivr", "fidivrl", 4}},
+	{{"fild", "fildl", 4}, {"fisttp", "fisttpl", 4}, {"fist", "fistl", 4}, {"fistp", "fistpl", 4}, {}, {"fld", "fldt", 10}, {}, {"fstp", "fstpt", 10}},
+	{{"fadd", "faddl", 8}, {"fmul", "fmull", 8}, {"fcom", "fcoml", 8}, {"fcomp", "fcompl", 8}, {"fsub", "fsubl", 8}, {"fsubr", "fsubrl", 8}, {"fdiv", "fdivl", 8}, {"fdivr", "fdivrl", 8}},
+	{{"fld", "fldl", 8}, {"fisttp", "fisttpll", 8}, {"fst", "fstl", 8}, {"fstp", "fstpl", 8}, {"frstor", "frstor", 0}, {}, {"fnsave", "fnsave", 0}, {"fnstsw", "fnstsw", 2}},
+	{{"fiadd", "fiadds", 2}, {"fimul", "fimuls", 2}, {"ficom", "ficoms", 2}, {"ficomp", "ficomps", 2}, {"fisub", "fisubs", 2}, {"fisubr", "fisubrs", 2}, {"fidiv", "fidivs", 2}, {"fidivr", "fidivrs", 2}},
+	{{"fild", "filds", 2}, {"fisttp", "fisttps", 2}, {"fist", "fists", 2}, {"fistp", "fistps", 2}, {"fbld", "fbld", 10}, {"fild", "fildll", 8}, {"fbstp", "fbstp", 10}, {"fistp", "fistpll", 8}},
+}
+
+// x87 register forms. args is "st,sti" (st(0) first in Intel order),
+// "sti,st", "sti" or "" for no operands.
+type x87Reg struct {
+	name, att, args string
+}
+
+var x87RegOps = [8][8]x87Reg{
+	{{"fadd", "", "st,sti"}, {"fmul", "", "st,sti"}, {"fcom", "", "sti"}, {"fcomp", "", "sti"}, {"fsub", "", "st,sti"}, {"fsubr", "", "st,sti"}, {"fdiv", "", "st,sti"}, {"fdivr", "", "st,sti"}},
+	{{"fld", "", "sti"}, {"fxch", "", "sti"}, {}, {}, {}, {}, {}, {}},
+	{{"fcmovb", "", "st,sti"}, {"fcmove", "", "st,sti"}, {"fcmovbe", "", "st,sti"}, {"fcmovu", "", "st,sti"}, {}, {}, {}, {}},
+	{{"fcmovnb", "", "st,sti"}, {"fcmovne", "", "st,sti"}, {"fcmovnbe", "", "st,sti"}, {"fcmovnu", "", "st,sti"}, {}, {"fucomi", "", "st,sti"}, {"fcomi", "", "st,sti"}, {}},
+	{{"fadd", "", "sti,st"}, {"fmul", "", "sti,st"}, {}, {}, {"fsubr", "fsub", "sti,st"}, {"fsub", "fsubr", "sti,st"}, {"fdivr", "fdiv", "sti,st"}, {"fdiv", "fdivr", "sti,st"}},
+	{{"ffree", "", "sti"}, {}, {"fst", "", "sti"}, {"fstp", "", "sti"}, {"fucom", "", "sti"}, {"fucomp", "", "sti"}, {}, {}},
+	{{"faddp", "", "sti,st"}, {"fmulp", "", "sti,st"}, {}, {}, {"fsubrp", "fsubp", "sti,st"}, {"fsubp", "fsubrp", "sti,st"}, {"fdivrp", "fdivp", "sti,st"}, {"fdivp", "fdivrp", "sti,st"}},
+	{{}, {}, {}, {}, {}, {"fucomip", "", "st,sti"}, {"fcomip", "", "st,sti"}, {}},
+}
+
+// x87 register forms without operands, indexed by the full opcode pair.
+var x87Special = map[[2]byte]string{
+	{0xd9, 0xd0}: "fnop", {0xd9, 0xe0}: "fchs", {0xd9, 0xe1}: "fabs", {0xd9, 0xe4}: "ftst",
+	{0xd9, 0xe5}: "fxam", {0xd9, 0xe8}: "fld1", {0xd9, 0xe9}: "fldl2t", {0xd9, 0xea}: "fldl2e",
+	{0xd9, 0xeb}: "fldpi", {0xd9, 0xec}: "fldlg2", {0xd9, 0xed}: "fldln2", {0xd9, 0xee}: "fldz",
+	{0xd9, 0xf0}: "f2xm1", {0xd9, 0xf1}: "fyl2x", {0xd9, 0xf2}: "fptan", {0xd9, 0xf3}: "fpatan",
+	{0xd9, 0xf4}: "fxtract", {0xd9, 0xf5}: "fprem1", {0xd9, 0xf6}: "fdecstp", {0xd9, 0xf7}: "fincstp",
+	{0xd9, 0xf8}: "fprem", {0xd9, 0xf9}: "fyl2xp1", {0xd9, 0xfa}: "fsqrt", {0xd9, 0xfb}: "fsincos",
+	{0xd9, 0xfc}: "frndint", {0xd9, 0xfd}: "fscale", {0xd9, 0xfe}: "fsin", {0xd9, 0xff}: "fcos",
+	{0xda, 0xe9}: "fucompp", {0xdb, 0xe2}: "fnclex", {0xdb, 0xe3}: "fninit", {0xde, 0xd9}: "fcompp",
+}
+
+// Predicates folded into the cmpps family of mnemonics.
+var x86CmpPred = []string{
+	"eq", "lt", "le", "unord", "neq", "nlt", "nle", "ord",
+	"eq_uq", "nge", "ngt", "false", "neq_oq", "ge", "gt", "true",
+	"eq_os", "lt_oq", "le_oq", "unord_s", "neq_us", "nlt_uq", "nle_uq", "ord_s",
+	"eq_us", "nge_uq", "ngt_uq", "false_os", "neq_os", "ge_oq", "gt_oq", "true_us",
+}
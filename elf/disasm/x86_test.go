@@ -0,0 +1,105 @@
+package disasm
+
+import "testing"
+
+// The x86 vectors were assembled with GNU as; the expected text is what
+// objdump -d prints, in AT&T and in Intel syntax.
+type x86Test struct {
+	addr       uint64
+	code       string
+	att, intel string
+}
+
+func testX86(t *testing.T, arch Arch, tests []x86Test) {
+	t.Helper()
+	att := make([]decodeTest, len(tests))
+	intel := make([]decodeTest, len(tests))
+	for i, tt := range tests {
+		att[i] = decodeTest{tt.addr, tt.code, tt.att}
+		intel[i] = decodeTest{tt.addr, tt.code, tt.intel}
+	}
+	testDecode(t, arch, Options{Syntax: SyntaxATT}, att)
+	testDecode(t, arch, Options{Syntax: SyntaxIntel}, intel)
+}
+
+func TestDecodeX86_64(t *testing.T) {
+	testX86(t, ArchX86_64, []x86Test{
+		{0x0, "55", "push   %rbp", "push   rbp"},
+		{0x1, "4889e5", "mov    %rsp,%rbp", "mov    rbp,rsp"},
+		{0x4, "4883ec10", "sub    $0x10,%rsp", "sub    rsp,0x10"},
+		{0x8, "897dfc", "mov    %edi,-0x4(%rbp)", "mov    DWORD PTR [rbp-0x4],edi"},
+		{0xb, "488b45f8", "mov    -0x8(%rbp),%rax", "mov    rax,QWORD PTR [rbp-0x8]"},
+		{0xf, "488d3d10000000", "lea    0x10(%rip),%rdi        # 0x26", "lea    rdi,[rip+0x10]        # 0x26"},
+		{0x16, "0fb600", "movzbl (%rax),%eax", "movzx  eax,BYTE PTR [rax]"},
+		{0x19, "4863d7", "movslq %edi,%rdx", "movsxd rdx,edi"},
+		{0x1c, "480faffe", "imul   %rsi,%rdi", "imul   rdi,rsi"},
+		{0x20, "31c0", "xor    %eax,%eax", "xor    eax,eax"},
+		{0x22, "84c0", "test   %al,%al", "test   al,al"},
+		{0x24, "4983f97f", "cmp    $0x7f,%r9", "cmp    r9,0x7f"},
+		{0x28, "48c1e103", "shl    $0x3,%rcx", "shl    rcx,0x3"},
+		{0x2c, "48b88877665544332211", "movabs $0x1122334455667788,%rax", "movabs rax,0x1122334455667788"},
+		{0x36, "e81b000000", "call   0x56", "call   0x56"},
+		{0x3b, "ebee", "jmp    0x2b", "jmp    0x2b"},
+		{0x3d, "7504", "jne    0x43", "jne    0x43"},
+		{0x3f, "c3", "ret", "ret"},
+		{0x40, "c9", "leave", "leave"},
+		{0x41, "90", "nop", "nop"},
+		{0x42, "660f1f0400", "nopw   (%rax,%rax,1)", "nop    WORD PTR [rax+rax*1]"},
+		{0x47, "f30f1efa", "endbr64", "endbr64"},
+		{0x4b, "0f05", "syscall", "syscall"},
+		{0x4d, "f3aa", "rep stos %al,%es:(%rdi)", "rep stos BYTE PTR es:[rdi],al"},
+		{0x4f, "f0480fb10a", "lock cmpxchg %rcx,(%rdx)", "lock cmpxchg QWORD PTR [rdx],rcx"},
+		{0x54, "f20f2ac0", "cvtsi2sd %eax,%xmm0", "cvtsi2sd xmm0,eax"},
+		{0x58, "0f28c1", "movaps %xmm1,%xmm0", "movaps xmm0,xmm1"},
+		{0x5b, "660fefc0", "pxor   %xmm0,%xmm0", "pxor   xmm0,xmm0"},
+		{0x5f, "c5f458c2", "vaddps %ymm2,%ymm1,%ymm0", "vaddps ymm0,ymm1,ymm2"},
+		{0x63, "c4e27d58c8", "vpbroadcastd %xmm0,%ymm1", "vpbroadcastd ymm1,xmm0"},
+		{0x68, "c4e271b8c2", "vfmadd231ps %xmm2,%xmm1,%xmm0", "vfmadd231ps xmm0,xmm1,xmm2"},
+		{0x6d, "c5f890ca", "kmovw  %k2,%k1", "kmovw  k1,k2"},
+		{0x71, "c4c1fb92e2", "kmovq  %r10,%k4", "kmovq  k4,r10"},
+		{0x76, "c5fb93d3", "kmovd  %k3,%edx", "kmovd  edx,k3"},
+		{0x7a, "c5f9910d20000000", "kmovb  %k1,0x20(%rip)        # 0xa2", "kmovb  BYTE PTR [rip+0x20],k1        # 0xa2"},
+		{0x82, "dd442408", "fldl   0x8(%rsp)", "fld    QWORD PTR [rsp+0x8]"},
+		{0x86, "dec1", "faddp  %st,%st(1)", "faddp  st(1),st"},
+		{0x0, "db2d10000000", "fldt   0x10(%rip)        # 0x16", "fld    TBYTE PTR [rip+0x10]        # 0x16"},
+		{0x88, "64488b042528000000", "mov    %fs:0x28,%rax", "mov    rax,QWORD PTR fs:0x28"},
+		{0x91, "ffe0", "jmp    *%rax", "jmp    rax"},
+		{0x93, "3effe0", "notrack jmp *%rax", "notrack jmp rax"},
+		{0x96, "0f45c1", "cmovne %ecx,%eax", "cmovne eax,ecx"},
+		{0x99, "0fc8", "bswap  %eax", "bswap  eax"},
+		{0x9b, "62e1fd087ec1", "vmovq  %xmm16,%rcx", "vmovq  rcx,xmm16"},
+		{0xa1, "62f174c958c2", "vaddps %zmm2,%zmm1,%zmm0{%k1}{z}", "vaddps zmm0{k1}{z},zmm1,zmm2"},
+		{0xa7, "62f174585810", "vaddps (%rax){1to16},%zmm1,%zmm2", "vaddps zmm2,zmm1,DWORD BCST [rax]"},
+		{0xad, "62f1ed1858d9", "vaddpd {rn-sae},%zmm1,%zmm2,%zmm3", "vaddpd zmm3,zmm2,zmm1{rn-sae}"},
+		{0xb3, "62f16c185fd9", "vmaxps {sae},%zmm1,%zmm2,%zmm3", "vmaxps zmm3,zmm2,zmm1{sae}"},
+		{0xb9, "62e1fe486f4f01", "vmovdqu64 0x40(%rdi),%zmm17", "vmovdqu64 zmm17,ZMMWORD PTR [rdi+0x40]"},
+		{0xc0, "62f36d4c1ed901", "vpcmpltud %zmm1,%zmm2,%k3{%k4}", "vpcmpltud k3{k4},zmm2,zmm1"},
+		{0xc7, "62f16d4874c9", "vpcmpeqb %zmm1,%zmm2,%k1", "vpcmpeqb k1,zmm2,zmm1"},
+		{0xcd, "62f27d4990548802", "vpgatherdd 0x8(%rax,%zmm1,4),%zmm2{%k1}", "vpgatherdd zmm2{k1},DWORD PTR [rax+zmm1*4+0x8]"},
+		{0xd5, "62f37d4825c0ff", "vpternlogd $0xff,%zmm0,%zmm0,%zmm0", "vpternlogd zmm0,zmm0,zmm0,0xff"},
+		{0xdc, "62f37d58660801", "vfpclassps $0x1,(%rax){1to16},%k1", "vfpclassps k1,DWORD BCST [rax]{1to16},0x1"},
+		{0xe3, "c4e1f998d1", "kortestd %k1,%k2", "kortestd k2,k1"},
+		{0xe8, "62a16520efe2", "vpxord %ymm18,%ymm19,%ymm20", "vpxord ymm20,ymm19,ymm18"},
+		// An invalid EVEX instruction spans its prefix and opcode. A
+		// truncated EVEX prefix, and PUSH ES in 64-bit mode, are invalid.
+		{0x0, "62f17c4800", "(bad)", "(bad)"},
+		{0x0, "62", "(bad)", "(bad)"},
+		{0x0, "06", "(bad)", "(bad)"},
+	})
+}
+
+func TestDecodeI386(t *testing.T) {
+	testX86(t, ArchI386, []x86Test{
+		{0x0, "55", "push   %ebp", "push   ebp"},
+		{0x1, "89e5", "mov    %esp,%ebp", "mov    ebp,esp"},
+		{0x3, "8b4508", "mov    0x8(%ebp),%eax", "mov    eax,DWORD PTR [ebp+0x8]"},
+		{0x6, "ff5310", "call   *0x10(%ebx)", "call   DWORD PTR [ebx+0x10]"},
+		{0x9, "8d36", "lea    (%esi),%esi", "lea    esi,[esi]"},
+		{0xb, "40", "inc    %eax", "inc    eax"},
+		{0xc, "60", "pusha", "pusha"},
+		{0xd, "c410", "les    (%eax),%edx", "les    edx,FWORD PTR [eax]"},
+		{0xf, "cd80", "int    $0x80", "int    0x80"},
+		{0x11, "c7042401000000", "movl   $0x1,(%esp)", "mov    DWORD PTR [esp],0x1"},
+		{0x18, "c20800", "ret    $0x8", "ret    0x8"},
+	})
+}
@@ -0,0 +1,487 @@
+package elf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math/bits"
+	"sort"
+	"strings"
+
+	"github.com/elfviewer/elfviewer/elf/disasm"
+)
+
+// codeSymbol is a symbol that can label an address in a disassembly.
+type codeSymbol struct {
+	Name  string
+	Value uint64
+	rank  int
+}
+
+// DisasmArch returns the instruction set of the file's machine, or
+// disasm.ArchUnknown if it cannot be disassembled.
+func (f *File) DisasmArch() disasm.Arch {
+	switch f.Machine {
+	case EM_X86_64:
+		return disasm.ArchX86_64
+	case EM_386:
+		return disasm.ArchI386
+	case EM_AARCH64:
+		return disasm.ArchARM64
+	case EM_ARM:
+		return disasm.ArchARM
+	case EM_RISCV:
+		if f.Class == ELFCLASS32 {
+			return disasm.ArchRISCV32
+		}
+		return disasm.ArchRISCV64
+	}
+	return disasm.ArchUnknown
+}
+
+// symbolAddr returns the address labelled by a symbol. ARM marks Thumb
+// functions by setting bit 0 of their address.
+func (f *File) symbolAddr(s *Symbol) uint64 {
+	if f.Machine == EM_ARM && s.Type() == STT_FUNC {
+		return s.Value &^ 1
+	}
+	return s.Value
+}
+
+// isMappingSymbol reports whether name is one of the ARM, AArch64 and
+// RISC-V mapping symbols, which mark the start of code ($a, $t, $x) or data
+// ($d) and may carry a ".suffix". RISC-V may also append the ISA string of
+// the code that follows to $x, as in "$xrv64i2p1_c2p0".
+func isMappingSymbol(name string) bool {
+	if len(name) < 2 || name[0] != '$' || !strings.ContainsRune("atdx", rune(name[1])) {
+		return false
+	}
+	return len(name) == 2 || name[2] == '.' || strings.HasPrefix(name, "$xrv")
+}
+
+// codeMapping records that the contents of a section switch to the
+// instruction set Arch at Value. ArchUnknown marks data, such as literal
+// pools between functions.
+type codeMapping struct {
+	Value uint64
+	Arch  disasm.Arch
+}
+
+// codeMappings returns the mapping symbols of section ndx sorted by
+// address. ARM files without mapping symbols fall back to the function
+// symbols, whose low bit tells Thumb code from ARM code.
+func (f *File) codeMappings(ndx int) []codeMapping {
+	if f.Machine != EM_ARM && f.Machine != EM_AARCH64 && f.Machine != EM_RISCV {
+		return nil
+	}
+	var maps, funcs []codeMapping
+	for i := range f.Symbols {
+		s := &f.Symbols[i]
+		if i, ok := s.SectionIndex(); !ok || i != ndx {
+			continue
+		}
+		switch {
+		case isMappingSymbol(s.Name):
+			m := codeMapping{Value: s.Value, Arch: f.DisasmArch()}
+			switch s.Name[1] {
+			case 't':
+				m.Arch = disasm.ArchThumb
+			case 'd':
+				m.Arch = disasm.ArchUnknown
+			}
+			maps = append(maps, m)
+		case f.Machine == EM_ARM && s.Type() == STT_FUNC:
+			m := codeMapping{Value: s.Value &^ 1, Arch: disasm.ArchARM}
+			if s.Value&1 != 0 {
+				m.Arch = disasm.ArchThumb
+			}
+			funcs = append(funcs, m)
+		}
+	}
+	if len(maps) == 0 {
+		maps = funcs
+	}
+	sort.SliceStable(maps, func(i, j int) bool { return maps[i].Value < maps[j].Value })
+	return maps
+}
+
+// sectionSymbols returns the symbols defined in section ndx that are useful
+// as labels, sorted by address. When several symbols share an address the
+// preferred label comes first: functions before other types, and global
+// symbols before local ones.
+func (f *File) sectionSymbols(ndx int) []codeSymbol {
+	var syms []codeSymbol
+	seen := make(map[string]bool)
+	for i := range f.Symbols {
+		s := &f.Symbols[i]
+		if i, ok := s.SectionIndex(); !ok || i != ndx || s.Name == "" {
+			continue
+		}
+		if isMappingSymbol(s.Name) {
+			continue
+		}
+		rank := 0
+		switch s.Type() {
+		case STT_FUNC:
+		case STT_OBJECT, STT_NOTYPE:
+			rank = 2
+		default:
+			continue
+		}
+		if s.Bind() == STB_LOCAL {
+			rank++
+		}
+		addr := f.symbolAddr(s)
+		key := fmt.Sprintf("%s@%x", s.Name, addr)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		syms = append(syms, codeSymbol{Name: s.Name, Value: addr, rank: rank})
+	}
+	for _, s := range f.pltSymbols(ndx) {
+		key := fmt.Sprintf("%s@%x", s.Name, s.Value)
+		if !seen[key] {
+			seen[key] = true
+			syms = append(syms, s)
+		}
+	}
+	sort.SliceStable(syms, func(i, j int) bool {
+		if syms[i].Value != syms[j].Value {
+			return syms[i].Value < syms[j].Value
+		}
+		return syms[i].rank < syms[j].rank
+	})
+	return syms
+}
+
+// pltSymbols synthesizes "name@plt" labels for the stubs of a PLT
+// section. Each stub jumps through a GOT slot, and the relocation of that
+// slot names the function the stub calls.
+func (f *File) pltSymbols(ndx int) []codeSymbol {
+	sh := &f.SectionHeaders[ndx]
+	arch := f.DisasmArch()
+	if !strings.HasPrefix(sh.Name, ".plt") || arch == disasm.ArchUnknown {
+		return nil
+	}
+	data, err := f.GetSectionData(sh)
+	if err != nil || len(data) == 0 {
+		return nil
+	}
+
+	slots := make(map[uint64]string)
+	for i := range f.Relocations {
+		for _, r := range f.Relocations[i].Entries {
+			if r.SymName != "" {
+				slots[r.Offset] = r.SymName
+			}
+		}
+	}
+	if len(slots) == 0 {
+		return nil
+	}
+
+	// i386 PIC stubs address the GOT relative to %ebx, which holds the
+	// address of .got.plt.
+	var gotBase uint64
+	if got := f.GetSection(".got.plt"); got != nil {
+		gotBase = got.Addr
+	}
+
+	entSize := sh.EntSize
+	if entSize == 0 {
+		entSize = 16
+	}
+	var stub pltStub
+	var syms []codeSymbol
+	for off := 0; off < len(data); {
+		addr := sh.Addr + uint64(off)
+		inst, _ := disasm.Decode(arch, data[off:], addr, disasm.Options{})
+		b := data[off : off+inst.Len]
+		slot, ok := inst.Target, inst.HasTarget
+		entry := addr - uint64(off)%entSize
+		switch arch {
+		case disasm.ArchI386:
+			slot, ok = i386PLTSlot(b, gotBase)
+		case disasm.ArchARM64:
+			slot, ok = stub.arm64(b, inst)
+			entry = stub.start
+		case disasm.ArchARM:
+			slot, ok = stub.arm(b, inst)
+			entry = stub.start
+		case disasm.ArchRISCV32, disasm.ArchRISCV64:
+			slot, ok = stub.riscv(b, inst)
+			entry = stub.start
+		}
+		if name, found := slots[slot]; found && ok {
+			syms = append(syms, codeSymbol{Name: name + "@plt", Value: entry, rank: 4})
+		}
+		off += inst.Len
+	}
+	return syms
+}
+
+// pltStub follows the instructions of an ARM, AArch64 or RISC-V PLT stub,
+// which builds the address of its GOT slot over several instructions.
+// start is the address of the stub and base the partial slot address.
+type pltStub struct {
+	start, base uint64
+}
+
+// arm64 follows the "adrp x16, page; ldr x17, [x16, #off]" sequence of
+// AArch64 stubs and returns the GOT slot of the load.
+func (s *pltStub) arm64(b []byte, inst disasm.Inst) (uint64, bool) {
+	if len(b) != 4 {
+		return 0, false
+	}
+	w := binary.LittleEndian.Uint32(b)
+	switch {
+	case w&0x9f00001f == 0x90000010:
+		s.start, s.base = inst.Addr, inst.Target
+	case w&0xffc003ff == 0xf9400211:
+		return s.base + uint64(w>>10&0xfff)*8, true
+	}
+	return 0, false
+}
+
+// arm follows the "add ip, pc, #a; add ip, ip, #b; ldr pc, [ip, #c]!"
+// sequence of ARM stubs and returns the GOT slot of the load.
+func (s *pltStub) arm(b []byte, inst disasm.Inst) (uint64, bool) {
+	if len(b) != 4 {
+		return 0, false
+	}
+	w := binary.LittleEndian.Uint32(b)
+	switch w & 0x0ffff000 {
+	case 0x028fc000:
+		s.start, s.base = inst.Addr, inst.Target
+	case 0x028cc000:
+		s.base += uint64(bits.RotateLeft32(w&0xff, -int(w>>8&15)*2))
+	case 0x05bcf000:
+		return (s.base + uint64(w&0xfff)) & 0xffffffff, true
+	}
+	return 0, false
+}
+
+// riscv follows the "auipc t3, hi; l[wd] t3, lo(t3)" sequence of RISC-V
+// stubs and returns the GOT slot of the load.
+func (s *pltStub) riscv(b []byte, inst disasm.Inst) (uint64, bool) {
+	if len(b) != 4 {
+		return 0, false
+	}
+	w := binary.LittleEndian.Uint32(b)
+	switch {
+	case w&0xfff == 0xe17:
+		s.start, s.base = inst.Addr, inst.Addr+uint64(int64(int32(w&0xfffff000)))
+	case w&0xfffff == 0xe2e03, w&0xfffff == 0xe3e03:
+		return s.base + uint64(int64(int32(w))>>20), true
+	}
+	return 0, false
+}
+
+// i386PLTSlot returns the GOT slot an i386 PLT jump goes through, for
+// both the absolute "jmp *addr" and the PIC "jmp *disp(%ebx)" forms.
+func i386PLTSlot(b []byte, gotBase uint64) (uint64, bool) {
+	if len(b) != 6 || b[0] != 0xff {
+		return 0, false
+	}
+	v := uint64(binary.LittleEndian.Uint32(b[2:]))
+	switch b[1] {
+	case 0x25:
+		return v, true
+	case 0xa3:
+		return (gotBase + v) & 0xffffffff, true
+	}
+	return 0, false
+}
+
+// symbolizer resolves addresses to the nearest preceding symbol of the
+// allocated section that contains them, like objdump does for branch
+// targets.
+func (f *File) symbolizer() disasm.Symbolizer {
+	bySection := make(map[int][]codeSymbol)
+	return func(addr uint64) (string, uint64, bool) {
+		for i := range f.SectionHeaders {
+			sh := &f.SectionHeaders[i]
+			if sh.Flags&SHF_ALLOC == 0 || addr < sh.Addr || addr >= sh.Addr+sh.Size {
+				continue
+			}
+			syms, ok := bySection[i]
+			if !ok {
+				syms = f.sectionSymbols(i)
+				bySection[i] = syms
+			}
+			// Find the last symbol at or before addr and take the
+			// preferred one among those sharing its address.
+			n := sort.Search(len(syms), func(j int) bool { return syms[j].Value > addr })
+			if n == 0 {
+				return "", 0, false
+			}
+			v := syms[n-1].Value
+			for n > 1 && syms[n-2].Value == v {
+				n--
+			}
+			return syms[n-1].Name, addr - v, true
+		}
+		return "", 0, false
+	}
+}
+
+// DisplayDisassembly disassembles a section, or a single function when
+// target names a symbol, in the style of objdump -d.
+func (f *File) DisplayDisassembly(w io.Writer, target string, syntax disasm.Syntax) error {
+	arch := f.DisasmArch()
+	if arch == disasm.ArchUnknown {
+		return fmt.Errorf("disassembly not supported for machine %s", MachineString(f.Machine))
+	}
+
+	ndx, start, end, err := f.disassemblyRange(target)
+	if err != nil {
+		return err
+	}
+	sh := &f.SectionHeaders[ndx]
+	if sh.Type == SHT_NOBITS {
+		return fmt.Errorf("section %s has no data", sh.Name)
+	}
+	data, err := f.GetSectionData(sh)
+	if err != nil {
+		return err
+	}
+
+	opts := disasm.Options{Syntax: syntax, Symbolize: f.symbolizer(), IT: new(disasm.ITState)}
+	labels := f.sectionSymbols(ndx)
+	maps := f.codeMappings(ndx)
+	labelWidth := 16
+	if f.Class == ELFCLASS32 {
+		labelWidth = 8
+	}
+
+	fmt.Fprintf(w, "\nDisassembly of section %s:\n", sh.Name)
+
+	mode := arch
+	next := sort.Search(len(labels), func(i int) bool { return labels[i].Value >= start })
+	nextMap := 0
+	for addr := start; addr < end; {
+		if next < len(labels) && labels[next].Value <= addr {
+			fmt.Fprintf(w, "\n%0*x <%s>:\n", labelWidth, labels[next].Value, labels[next].Name)
+			for next < len(labels) && labels[next].Value <= addr {
+				next++
+			}
+		}
+		for nextMap < len(maps) && maps[nextMap].Value <= addr {
+			if mode != maps[nextMap].Arch {
+				*opts.IT = disasm.ITState{}
+			}
+			mode = maps[nextMap].Arch
+			nextMap++
+		}
+
+		off := addr - sh.Addr
+		limit := end - sh.Addr
+		if next < len(labels) && labels[next].Value < end {
+			// Do not decode across the next symbol.
+			limit = labels[next].Value - sh.Addr
+		}
+		if nextMap < len(maps) && maps[nextMap].Value-sh.Addr < limit {
+			limit = maps[nextMap].Value - sh.Addr
+		}
+
+		var inst disasm.Inst
+		if mode == disasm.ArchUnknown {
+			inst = f.dataInst(data[off:limit], addr)
+		} else {
+			inst, err = disasm.Decode(mode, data[off:limit], addr, opts)
+			if err != nil && !errors.Is(err, disasm.ErrBadInst) {
+				return err
+			}
+		}
+		writeInst(w, inst, data[off:off+uint64(inst.Len)], mode)
+		addr += uint64(inst.Len)
+	}
+
+	return nil
+}
+
+// dataInst formats data embedded in code, such as an ARM literal pool, as
+// a .word directive, or a .short or .byte one for an unaligned tail.
+func (f *File) dataInst(b []byte, addr uint64) disasm.Inst {
+	switch {
+	case len(b) >= 4 && addr%4 == 0:
+		return disasm.Inst{Addr: addr, Len: 4, Text: fmt.Sprintf(".word\t0x%08x", f.ByteOrder.Uint32(b))}
+	case len(b) >= 2 && addr%2 == 0:
+		return disasm.Inst{Addr: addr, Len: 2, Text: fmt.Sprintf(".short\t0x%04x", f.ByteOrder.Uint16(b))}
+	}
+	return disasm.Inst{Addr: addr, Len: 1, Text: fmt.Sprintf(".byte\t0x%02x", b[0])}
+}
+
+// disassemblyRange resolves target to a section index and the address
+// range to disassemble. A section name selects the whole section, a
+// symbol name the bytes of that symbol.
+func (f *File) disassemblyRange(target string) (int, uint64, uint64, error) {
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Name == target {
+			return i, sh.Addr, sh.Addr + sh.Size, nil
+		}
+	}
+
+	for i := range f.Symbols {
+		s := &f.Symbols[i]
+		ndx, ok := s.SectionIndex()
+		if s.Name != target || !ok || ndx >= len(f.SectionHeaders) {
+			continue
+		}
+		sh := &f.SectionHeaders[ndx]
+		addr := f.symbolAddr(s)
+		if sh.Flags&SHF_EXECINSTR == 0 || addr < sh.Addr || addr >= sh.Addr+sh.Size {
+			continue
+		}
+		end := addr + s.Size
+		if s.Size == 0 || end > sh.Addr+sh.Size {
+			end = sh.Addr + sh.Size
+		}
+		return ndx, addr, end, nil
+	}
+
+	return 0, 0, 0, fmt.Errorf("section or symbol %s not found", target)
+}
+
+// writeInst prints one instruction as objdump does. x86 instructions show
+// up to seven bytes on the first line and the remaining bytes on
+// continuation lines. Fixed width instruction sets and data show their
+// bytes as words instead: one per instruction for ARM, AArch64 and RISC-V,
+// and one per halfword for Thumb.
+func writeInst(w io.Writer, inst disasm.Inst, b []byte, arch disasm.Arch) {
+	switch arch {
+	case disasm.ArchARM64, disasm.ArchARM, disasm.ArchThumb, disasm.ArchRISCV32, disasm.ArchRISCV64, disasm.ArchUnknown:
+		unit := len(b)
+		if arch == disasm.ArchThumb {
+			unit = 2
+		}
+		var words []string
+		for i := 0; i+unit <= len(b); i += unit {
+			var v uint64
+			for j := unit - 1; j >= 0; j-- {
+				v = v<<8 | uint64(b[i+j])
+			}
+			words = append(words, fmt.Sprintf("%0*x", unit*2, v))
+		}
+		fmt.Fprintf(w, "%8x:\t%-9s\t%s\n", inst.Addr, strings.Join(words, " "), inst.Text)
+		return
+	}
+
+	const perLine = 7
+	for i := 0; i < len(b); i += perLine {
+		n := min(len(b)-i, perLine)
+		hex := make([]string, n)
+		for j := range hex {
+			hex[j] = fmt.Sprintf("%02x", b[i+j])
+		}
+		addr := inst.Addr + uint64(i)
+		if i == 0 {
+			fmt.Fprintf(w, "%8x:\t%-*s\t%s\n", addr, perLine*3, strings.Join(hex, " ")+" ", inst.Text)
+		} else {
+			fmt.Fprintf(w, "%8x:\t%s\n", addr, strings.Join(hex, " ")+" ")
+		}
+	}
+}
@@ -3,6 +3,7 @@ package elf
 import (
 	"fmt"
 	"io"
+	"strings"
 	"text/tabwriter"
 )
 
@@ -14,38 +15,40 @@ func (f *File) DisplayHeader(w io.Writer) {
 	}
 	fmt.Fprintf(w, "\n")
 	
-	fmt.Fprintf(w, "  Class:                             ")
-	switch f.Ident.Class {
-	case ELFCLASS32:
-		fmt.Fprintf(w, "ELF32\n")
-	case ELFCLASS64:
-		fmt.Fprintf(w, "ELF64\n")
-	default:
-		fmt.Fprintf(w, "Invalid (%d)\n", f.Ident.Class)
-	}
-	
-	fmt.Fprintf(w, "  Data:                              ")
-	switch f.Ident.Data {
-	case ELFDATA2LSB:
-		fmt.Fprintf(w, "2's complement, little endian\n")
-	case ELFDATA2MSB:
-		fmt.Fprintf(w, "2's complement, big endian\n")
-	default:
-		fmt.Fprintf(w, "Invalid (%d)\n", f.Ident.Data)
-	}
+	fmt.Fprintf(w, "  Class:                             %s\n", ClassString(f.Ident.Class))
+	fmt.Fprintf(w, "  Data:                              %s\n", DataString(f.Ident.Data))
 	
 	fmt.Fprintf(w, "  Version:                           %d (current)\n", f.Ident.Version)
-	fmt.Fprintf(w, "  OS/ABI:                            %d\n", f.Ident.OSABI)
+	fmt.Fprintf(w, "  OS/ABI:                            %s\n", OSABIString(f.Ident.OSABI, f.Machine))
+	fmt.Fprintf(w, "  ABI Version:                       %d\n", f.Ident.ABIVersion)
 	fmt.Fprintf(w, "  Type:                              %s\n", TypeString(f.Type))
 	fmt.Fprintf(w, "  Machine:                           %s\n", MachineString(f.Machine))
+	fmt.Fprintf(w, "  Version:                           0x%x\n", f.Version)
 	fmt.Fprintf(w, "  Entry point address:               0x%x\n", f.Entry)
 	fmt.Fprintf(w, "  Start of program headers:          %d (bytes into file)\n", f.phoff)
 	fmt.Fprintf(w, "  Start of section headers:          %d (bytes into file)\n", f.shoff)
+	if names := HeaderFlagsString(f.Machine, f.Flags); names != "" {
+		fmt.Fprintf(w, "  Flags:                             0x%x, %s\n", f.Flags, names)
+	} else {
+		fmt.Fprintf(w, "  Flags:                             0x%x\n", f.Flags)
+	}
 	fmt.Fprintf(w, "  Size of program headers:           %d (bytes)\n", f.phentsize)
-	fmt.Fprintf(w, "  Number of program headers:         %d\n", f.phnum)
+	if f.phnum >= PN_XNUM {
+		fmt.Fprintf(w, "  Number of program headers:         %d (%d)\n", PN_XNUM, f.phnum)
+	} else {
+		fmt.Fprintf(w, "  Number of program headers:         %d\n", f.phnum)
+	}
 	fmt.Fprintf(w, "  Size of section headers:           %d (bytes)\n", f.shentsize)
-	fmt.Fprintf(w, "  Number of section headers:         %d\n", f.shnum)
-	fmt.Fprintf(w, "  Section header string table index: %d\n", f.shstrndx)
+	if f.shnum >= SHN_LORESERVE {
+		fmt.Fprintf(w, "  Number of section headers:         0 (%d)\n", f.shnum)
+	} else {
+		fmt.Fprintf(w, "  Number of section headers:         %d\n", f.shnum)
+	}
+	if f.shstrndx >= SHN_LORESERVE {
+		fmt.Fprintf(w, "  Section header string table index: %d (%d)\n", SHN_XINDEX, f.shstrndx)
+	} else {
+		fmt.Fprintf(w, "  Section header string table index: %d\n", f.shstrndx)
+	}
 }
 
 func (f *File) DisplaySectionHeaders(w io.Writer) {
@@ -55,16 +58,23 @@ func (f *File) DisplaySectionHeaders(w io.Writer) {
 	
 	for i, sh := range f.SectionHeaders {
 		fmt.Fprintf(w, "  [%2d] %-16s %-15s %016x %08x\n",
-			i, sh.Name, SectionTypeString(sh.Type), sh.Addr, sh.Offset)
+			i, sh.Name, SectionTypeString(f.Machine, f.Ident.OSABI, sh.Type), sh.Addr, sh.Offset)
 		fmt.Fprintf(w, "       %016x %016x %3s %5d %5d %5d\n",
-			sh.Size, sh.EntSize, formatFlags(sh.Flags), sh.Link, sh.Info, sh.AddrAlign)
+			sh.Size, sh.EntSize, f.formatFlags(sh.Flags), sh.Link, sh.Info, sh.AddrAlign)
 	}
 	
 	fmt.Fprintf(w, "\nKey to Flags:\n")
 	fmt.Fprintf(w, "  W (write), A (alloc), X (execute), M (merge), S (strings), I (info),\n")
 	fmt.Fprintf(w, "  L (link order), O (extra OS processing required), G (group), T (TLS),\n")
 	fmt.Fprintf(w, "  C (compressed), x (unknown), o (OS specific), E (exclude),\n")
-	fmt.Fprintf(w, "  D (mbind), l (large), p (processor specific)\n")
+	switch f.Machine {
+	case EM_X86_64:
+		fmt.Fprintf(w, "  R (retain), D (mbind), l (large), p (processor specific)\n")
+	case EM_ARM:
+		fmt.Fprintf(w, "  R (retain), D (mbind), y (purecode), p (processor specific)\n")
+	default:
+		fmt.Fprintf(w, "  R (retain), D (mbind), p (processor specific)\n")
+	}
 }
 
 func (f *File) DisplayProgramHeaders(w io.Writer) {
@@ -74,7 +84,7 @@ func (f *File) DisplayProgramHeaders(w io.Writer) {
 	
 	for _, ph := range f.ProgramHeaders {
 		fmt.Fprintf(w, "  %-14s 0x%016x 0x%016x 0x%016x\n",
-			SegmentTypeString(ph.Type), ph.Offset, ph.VAddr, ph.PAddr)
+			SegmentTypeString(f.Machine, f.Ident.OSABI, ph.Type), ph.Offset, ph.VAddr, ph.PAddr)
 		fmt.Fprintf(w, "                 0x%016x 0x%016x  %s 0x%x\n",
 			ph.FileSz, ph.MemSz, formatSegmentFlags(ph.Flags), ph.Align)
 	}
@@ -113,11 +123,11 @@ func (f *File) DisplaySymbols(w io.Writer) {
 			i,
 			sym.Value,
 			sym.Size,
-			symbolTypeString(symType),
-			symbolBindString(symBind),
-			symbolVisString(symVis),
-			sectionIndexString(sym.Shndx),
-			sym.Name)
+			SymbolTypeString(symType),
+			SymbolBindString(symBind),
+			SymbolVisibilityString(symVis),
+			symbolSectionIndexString(&sym),
+			sym.VersionedName())
 	}
 	tw.Flush()
 }
@@ -129,41 +139,269 @@ func (f *File) DisplayDynamic(w io.Writer) {
 		return
 	}
 	
-	fmt.Fprintf(w, "\nDynamic section at offset 0x%x contains entries:\n", dynSec.Offset)
+	fmt.Fprintf(w, "\nDynamic section at offset 0x%x contains %d entries:\n", dynSec.Offset, len(f.Dynamic))
+	fmt.Fprintf(w, "  Tag        Type                         Name/Value\n")
+
+	for _, d := range f.Dynamic {
+		name := "(" + DynamicTagString(d.Tag) + ")"
+		if f.Class == ELFCLASS32 {
+			fmt.Fprintf(w, " 0x%08x %-28s %s\n", uint32(d.Tag), name, DynamicValueString(d))
+		} else {
+			fmt.Fprintf(w, " 0x%016x %-20s %s\n", uint64(d.Tag), name, DynamicValueString(d))
+		}
+	}
+}
+
+func (f *File) DisplayRelocations(w io.Writer) {
+	if len(f.Relocations) == 0 {
+		fmt.Fprintf(w, "\nThere are no relocations in this file.\n")
+		return
+	}
+
+	for _, rs := range f.Relocations {
+		unit := "entries"
+		if rs.Type == SHT_RELR {
+			unit = "relative relocations"
+		}
+		fmt.Fprintf(w, "\nRelocation section '%s' at offset 0x%x contains %d %s:\n",
+			rs.Name, rs.Offset, len(rs.Entries), unit)
+		if target := f.RelocationTarget(&rs); target != "" {
+			fmt.Fprintf(w, "  Applies to section '%s'\n", target)
+		}
+
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		if rs.Type == SHT_RELR {
+			fmt.Fprintf(tw, "  Offset\tType\n")
+			for _, r := range rs.Entries {
+				if f.Class == ELFCLASS32 {
+					fmt.Fprintf(tw, "  %08x\t%s\n", r.Offset, RelocationTypeString(f.Machine, r.Type))
+				} else {
+					fmt.Fprintf(tw, "  %012x\t%s\n", r.Offset, RelocationTypeString(f.Machine, r.Type))
+				}
+			}
+			tw.Flush()
+			continue
+		}
+
+		if rs.HasAddend() {
+			fmt.Fprintf(tw, "  Offset\tInfo\tType\tSym. Value\tSym. Name + Addend\n")
+		} else {
+			fmt.Fprintf(tw, "  Offset\tInfo\tType\tSym. Value\tSym. Name\n")
+		}
+
+		for _, r := range rs.Entries {
+			var offset, info, value string
+			if f.Class == ELFCLASS32 {
+				offset = fmt.Sprintf("%08x", r.Offset)
+				info = fmt.Sprintf("%08x", r.Info)
+				value = fmt.Sprintf("%08x", r.SymValue)
+			} else {
+				offset = fmt.Sprintf("%012x", r.Offset)
+				info = fmt.Sprintf("%012x", r.Info)
+				value = fmt.Sprintf("%016x", r.SymValue)
+			}
+
+			name := r.SymName
+			if r.Sym == 0 {
+				value = ""
+			}
+			if rs.HasAddend() {
+				if name == "" {
+					name = fmt.Sprintf("%x", r.Addend)
+				} else {
+					name += " " + formatAddend(r.Addend)
+				}
+			}
+
+			fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n",
+				offset, info, RelocationTypeString(f.Machine, r.Type), value, name)
+		}
+		tw.Flush()
+	}
 }
 
-func formatFlags(flags uint64) string {
-	s := ""
-	if flags&SHF_WRITE != 0 {
-		s += "W"
+func (f *File) DisplayNotes(w io.Writer) {
+	if len(f.Notes) == 0 {
+		fmt.Fprintf(w, "\nThere are no notes in this file.\n")
+		return
 	}
-	if flags&SHF_ALLOC != 0 {
-		s += "A"
+
+	source := ""
+	for i := range f.Notes {
+		n := &f.Notes[i]
+		if n.Section != source {
+			source = n.Section
+			fmt.Fprintf(w, "\nDisplaying notes found in: %s\n", source)
+			fmt.Fprintf(w, "  Owner                Data size        Description\n")
+		}
+
+		fmt.Fprintf(w, "  %-20s 0x%08x       %s\n", n.Name, len(n.Desc), f.NoteTypeString(n))
+		if desc := f.NoteDescription(n); desc != "" {
+			for _, line := range strings.Split(desc, "\n") {
+				fmt.Fprintf(w, "    %s\n", strings.TrimPrefix(line, "\t"))
+			}
+		}
 	}
-	if flags&SHF_EXECINSTR != 0 {
-		s += "X"
+}
+
+func (f *File) DisplayAttributes(w io.Writer) {
+	if len(f.Attributes) == 0 {
+		fmt.Fprintf(w, "\nThere are no architecture specific attributes in this file.\n")
+		return
 	}
+
+	vendor := ""
+	for _, a := range f.Attributes {
+		if a.Vendor != vendor {
+			vendor = a.Vendor
+			fmt.Fprintf(w, "Attribute Section: %s\n", vendor)
+			fmt.Fprintf(w, "File Attributes\n")
+		}
+
+		value := AttributeValueString(a)
+		if a.IsString() {
+			value = fmt.Sprintf("\"%s\"", value)
+		}
+		fmt.Fprintf(w, "  %s: %s\n", AttributeTagString(a), value)
+	}
+}
+
+func (f *File) DisplayVersionInfo(w io.Writer) {
+	found := false
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		switch sh.Type {
+		case SHT_GNU_versym:
+			found = true
+			fmt.Fprintf(w, "\nVersion symbols section '%s' contains %d entries:\n", sh.Name, len(f.versym))
+			f.displaySectionLink(w, sh)
+			for j, v := range f.versym {
+				if j%4 == 0 {
+					if j != 0 {
+						fmt.Fprintf(w, "\n")
+					}
+					fmt.Fprintf(w, "  %03x:", j)
+				}
+				hidden := ' '
+				if v&versymHidden != 0 {
+					hidden = 'h'
+				}
+				fmt.Fprintf(w, "%4x%c%-13s", v&^versymHidden, hidden, "("+versionIndexString(f, v)+")")
+			}
+			fmt.Fprintf(w, "\n")
+		case SHT_GNU_verdef:
+			found = true
+			fmt.Fprintf(w, "\nVersion definition section '%s' contains %d entries:\n", sh.Name, len(f.VersionDefs))
+			f.displaySectionLink(w, sh)
+			for _, def := range f.VersionDefs {
+				name := ""
+				if len(def.Names) > 0 {
+					name = def.Names[0]
+				}
+				fmt.Fprintf(w, "  Index: %d  Flags: %s  Cnt: %d  Name: %s\n",
+					def.Index, versionFlagsString(def.Flags), len(def.Names), name)
+				for j := 1; j < len(def.Names); j++ {
+					fmt.Fprintf(w, "    Parent %d: %s\n", j, def.Names[j])
+				}
+			}
+		case SHT_GNU_verneed:
+			found = true
+			fmt.Fprintf(w, "\nVersion needs section '%s' contains %d entries:\n", sh.Name, len(f.VersionNeeds))
+			f.displaySectionLink(w, sh)
+			for _, need := range f.VersionNeeds {
+				fmt.Fprintf(w, "  File: %s  Cnt: %d\n", need.File, len(need.Versions))
+				for _, v := range need.Versions {
+					fmt.Fprintf(w, "    Name: %s  Flags: %s  Version: %d\n",
+						v.Name, versionFlagsString(v.Flags), v.Index)
+				}
+			}
+		}
+	}
+
+	if !found {
+		fmt.Fprintf(w, "\nNo version information found in this file.\n")
+	}
+}
+
+func (f *File) displaySectionLink(w io.Writer, sh *SectionHeader) {
+	link := ""
+	if sh.Link < uint32(len(f.SectionHeaders)) {
+		link = f.SectionHeaders[sh.Link].Name
+	}
+	fmt.Fprintf(w, " Addr: 0x%016x  Offset: 0x%08x  Link: %d (%s)\n", sh.Addr, sh.Offset, sh.Link, link)
+}
+
+func versionIndexString(f *File, v uint16) string {
+	switch v &^ versymHidden {
+	case VER_NDX_LOCAL:
+		return "*local*"
+	case VER_NDX_GLOBAL:
+		return "*global*"
+	}
+	name, _ := f.versionName(v)
+	return name
+}
+
+func versionFlagsString(flags uint16) string {
+	if flags == 0 {
+		return "none"
+	}
+	var parts []string
+	if flags&VER_FLG_BASE != 0 {
+		parts = append(parts, "BASE")
+	}
+	if flags&VER_FLG_WEAK != 0 {
+		parts = append(parts, "WEAK")
+	}
+	if flags&VER_FLG_INFO != 0 {
+		parts = append(parts, "INFO")
+	}
+	if rest := flags &^ (VER_FLG_BASE | VER_FLG_WEAK | VER_FLG_INFO); rest != 0 {
+		parts = append(parts, fmt.Sprintf("%#x", rest))
+	}
+	return strings.Join(parts, " | ")
+}
+
+func (f *File) DisplayVersionRequirements(w io.Writer) {
+	reqs := f.MinimumVersions()
+	if len(reqs) == 0 {
+		fmt.Fprintf(w, "\nNo library version requirements found.\n")
+		return
+	}
+
+	fmt.Fprintf(w, "\nMinimum library versions required:\n")
+	for _, r := range reqs {
+		if r.Version == "" {
+			fmt.Fprintf(w, "  %s: (unversioned)\n", r.Library)
+			continue
+		}
+		fmt.Fprintf(w, "  %s: %s\n", r.Library, r.Version)
+		for _, name := range r.Symbols {
+			fmt.Fprintf(w, "    %s\n", name)
+		}
+	}
+}
+
+func (f *File) formatFlags(flags uint64) string {
+	s := SectionFlagsString(f.Machine, f.Ident.OSABI, flags)
 	if s == "" {
 		s = "  "
 	}
 	return fmt.Sprintf("%3s", s)
 }
 
-func formatSegmentFlags(flags uint32) string {
-	s := ""
-	if flags&0x4 != 0 {
-		s += "R"
-	}
-	if flags&0x2 != 0 {
-		s += "W"
+func formatAddend(a int64) string {
+	if a < 0 {
+		return fmt.Sprintf("- %x", uint64(-a))
 	}
-	if flags&0x1 != 0 {
-		s += "E"
-	}
-	return fmt.Sprintf("%-3s", s)
+	return fmt.Sprintf("+ %x", a)
 }
 
-func symbolTypeString(t uint8) string {
+func formatSegmentFlags(flags uint32) string {
+	return fmt.Sprintf("%-3s", SegmentFlagsString(flags))
+}
+
+func SymbolTypeString(t uint8) string {
 	switch t {
 	case 0:
 		return "NOTYPE"
@@ -179,12 +417,14 @@ func symbolTypeString(t uint8) string {
 		return "COMMON"
 	case 6:
 		return "TLS"
+	case STT_GNU_IFUNC:
+		return "IFUNC"
 	default:
 		return fmt.Sprintf("<%d>", t)
 	}
 }
 
-func symbolBindString(b uint8) string {
+func SymbolBindString(b uint8) string {
 	switch b {
 	case 0:
 		return "LOCAL"
@@ -192,12 +432,14 @@ func symbolBindString(b uint8) string {
 		return "GLOBAL"
 	case 2:
 		return "WEAK"
+	case STB_GNU_UNIQUE:
+		return "UNIQUE"
 	default:
 		return fmt.Sprintf("<%d>", b)
 	}
 }
 
-func symbolVisString(v uint8) string {
+func SymbolVisibilityString(v uint8) string {
 	switch v {
 	case 0:
 		return "DEFAULT"
@@ -212,7 +454,16 @@ func symbolVisString(v uint8) string {
 	}
 }
 
-func sectionIndexString(ndx uint16) string {
+// symbolSectionIndexString is SectionIndexString for a symbol, printing the
+// real index of symbols that go through SHT_SYMTAB_SHNDX.
+func symbolSectionIndexString(sym *Symbol) string {
+	if ndx, ok := sym.SectionIndex(); ok {
+		return fmt.Sprintf("%d", ndx)
+	}
+	return SectionIndexString(sym.Shndx)
+}
+
+func SectionIndexString(ndx uint16) string {
 	switch ndx {
 	case 0:
 		return "UND"
@@ -225,44 +476,35 @@ func sectionIndexString(ndx uint16) string {
 	}
 }
 
-func (f *File) DisplayHexDump(w io.Writer, sectionName string) error {
+// DisplayHexDump dumps the contents of a section. With decompress set,
+// compressed sections are dumped as their uncompressed contents.
+func (f *File) DisplayHexDump(w io.Writer, sectionName string, decompress bool) error {
 	sh := f.GetSection(sectionName)
 	if sh == nil {
 		return fmt.Errorf("section %s not found", sectionName)
 	}
 	
-	data, err := f.GetSectionData(sh)
+	ch, err := f.CompressionHeader(sh)
+	if err != nil {
+		return err
+	}
+	var data []byte
+	if decompress {
+		data, err = f.DecompressedSectionData(sh)
+	} else {
+		data, err = f.GetSectionData(sh)
+	}
 	if err != nil {
 		return err
 	}
 	
 	fmt.Fprintf(w, "\nHex dump of section '%s':\n", sectionName)
-	
-	for i := 0; i < len(data); i += 16 {
-		fmt.Fprintf(w, "  0x%08x ", sh.Addr+uint64(i))
-		
-		for j := 0; j < 16; j++ {
-			if i+j < len(data) {
-				fmt.Fprintf(w, "%02x", data[i+j])
-			} else {
-				fmt.Fprintf(w, "  ")
-			}
-			if j%4 == 3 {
-				fmt.Fprintf(w, " ")
-			}
-		}
-		
-		fmt.Fprintf(w, " ")
-		for j := 0; j < 16 && i+j < len(data); j++ {
-			c := data[i+j]
-			if c >= 32 && c < 127 {
-				fmt.Fprintf(w, "%c", c)
-			} else {
-				fmt.Fprintf(w, ".")
-			}
-		}
-		fmt.Fprintf(w, "\n")
+	if ch != nil && !decompress {
+		fmt.Fprintf(w, " NOTE: This section is compressed (%s), use --decompress to dump its contents.\n",
+			CompressionTypeString(ch.Type))
 	}
 	
+	writeHexLines(w, sh.Addr, data)
+	
 	return nil
 }
\ No newline at end of file
@@ -0,0 +1,599 @@
+package elf
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// DWARF attribute forms.
+const (
+	DW_FORM_addr           = 0x01
+	DW_FORM_block2         = 0x03
+	DW_FORM_block4         = 0x04
+	DW_FORM_data2          = 0x05
+	DW_FORM_data4          = 0x06
+	DW_FORM_data8          = 0x07
+	DW_FORM_string         = 0x08
+	DW_FORM_block          = 0x09
+	DW_FORM_block1         = 0x0a
+	DW_FORM_data1          = 0x0b
+	DW_FORM_flag           = 0x0c
+	DW_FORM_sdata          = 0x0d
+	DW_FORM_strp           = 0x0e
+	DW_FORM_udata          = 0x0f
+	DW_FORM_ref_addr       = 0x10
+	DW_FORM_ref1           = 0x11
+	DW_FORM_ref2           = 0x12
+	DW_FORM_ref4           = 0x13
+	DW_FORM_ref8           = 0x14
+	DW_FORM_ref_udata      = 0x15
+	DW_FORM_indirect       = 0x16
+	DW_FORM_sec_offset     = 0x17
+	DW_FORM_exprloc        = 0x18
+	DW_FORM_flag_present   = 0x19
+	DW_FORM_strx           = 0x1a
+	DW_FORM_addrx          = 0x1b
+	DW_FORM_ref_sup4       = 0x1c
+	DW_FORM_strp_sup       = 0x1d
+	DW_FORM_data16         = 0x1e
+	DW_FORM_line_strp      = 0x1f
+	DW_FORM_ref_sig8       = 0x20
+	DW_FORM_implicit_const = 0x21
+	DW_FORM_loclistx       = 0x22
+	DW_FORM_rnglistx       = 0x23
+	DW_FORM_ref_sup8       = 0x24
+	DW_FORM_strx1          = 0x25
+	DW_FORM_strx2          = 0x26
+	DW_FORM_strx3          = 0x27
+	DW_FORM_strx4          = 0x28
+	DW_FORM_addrx1         = 0x29
+	DW_FORM_addrx2         = 0x2a
+	DW_FORM_addrx3         = 0x2b
+	DW_FORM_addrx4         = 0x2c
+	DW_FORM_GNU_addr_index = 0x1f01
+	DW_FORM_GNU_str_index  = 0x1f02
+	DW_FORM_GNU_ref_alt    = 0x1f20
+	DW_FORM_GNU_strp_alt   = 0x1f21
+)
+
+var errDwarfTruncated = errors.New("truncated DWARF data")
+
+// dwarfBuf reads DWARF encoded values from a section. The first read past
+// the end sets err and every read after that returns zero values.
+type dwarfBuf struct {
+	data  []byte
+	off   int
+	order binary.ByteOrder
+	err   error
+}
+
+func (b *dwarfBuf) need(n int) bool {
+	if b.err != nil {
+		return false
+	}
+	if n < 0 || b.off+n > len(b.data) {
+		b.err = errDwarfTruncated
+		b.off = len(b.data)
+		return false
+	}
+	return true
+}
+
+func (b *dwarfBuf) u8() uint8 {
+	if !b.need(1) {
+		return 0
+	}
+	v := b.data[b.off]
+	b.off++
+	return v
+}
+
+func (b *dwarfBuf) u16() uint16 {
+	if !b.need(2) {
+		return 0
+	}
+	v := b.order.Uint16(b.data[b.off:])
+	b.off += 2
+	return v
+}
+
+func (b *dwarfBuf) u24() uint32 {
+	if !b.need(3) {
+		return 0
+	}
+	p := b.data[b.off:]
+	b.off += 3
+	if b.order == binary.BigEndian {
+		return uint32(p[0])<<16 | uint32(p[1])<<8 | uint32(p[2])
+	}
+	return uint32(p[0]) | uint32(p[1])<<8 | uint32(p[2])<<16
+}
+
+func (b *dwarfBuf) u32() uint32 {
+	if !b.need(4) {
+		return 0
+	}
+	v := b.order.Uint32(b.data[b.off:])
+	b.off += 4
+	return v
+}
+
+func (b *dwarfBuf) u64() uint64 {
+	if !b.need(8) {
+		return 0
+	}
+	v := b.order.Uint64(b.data[b.off:])
+	b.off += 8
+	return v
+}
+
+func (b *dwarfBuf) uleb() uint64 {
+	var v uint64
+	for shift := uint(0); ; shift += 7 {
+		c := b.u8()
+		if b.err != nil {
+			return 0
+		}
+		if shift < 64 {
+			v |= uint64(c&0x7f) << shift
+		}
+		if c&0x80 == 0 {
+			return v
+		}
+	}
+}
+
+func (b *dwarfBuf) sleb() int64 {
+	var v int64
+	shift := uint(0)
+	for {
+		c := b.u8()
+		if b.err != nil {
+			return 0
+		}
+		if shift < 64 {
+			v |= int64(c&0x7f) << shift
+		}
+		shift += 7
+		if c&0x80 == 0 {
+			if shift < 64 && c&0x40 != 0 {
+				v |= -1 << shift
+			}
+			return v
+		}
+	}
+}
+
+// str reads a NUL-terminated string.
+func (b *dwarfBuf) str() string {
+	if b.err != nil {
+		return ""
+	}
+	for i := b.off; i < len(b.data); i++ {
+		if b.data[i] == 0 {
+			s := string(b.data[b.off:i])
+			b.off = i + 1
+			return s
+		}
+	}
+	b.err = errDwarfTruncated
+	b.off = len(b.data)
+	return ""
+}
+
+func (b *dwarfBuf) bytes(n int) []byte {
+	if !b.need(n) {
+		return nil
+	}
+	v := b.data[b.off : b.off+n]
+	b.off += n
+	return v
+}
+
+func (b *dwarfBuf) skip(n int) {
+	if b.need(n) {
+		b.off += n
+	}
+}
+
+// addr reads a target address of the given size.
+func (b *dwarfBuf) addr(size int) uint64 {
+	switch size {
+	case 1:
+		return uint64(b.u8())
+	case 2:
+		return uint64(b.u16())
+	case 4:
+		return uint64(b.u32())
+	case 8:
+		return b.u64()
+	}
+	b.err = errors.New("unsupported DWARF address size")
+	return 0
+}
+
+// offset reads a section offset, 8 bytes wide in the 64-bit DWARF format.
+func (b *dwarfBuf) offset(dwarf64 bool) uint64 {
+	if dwarf64 {
+		return b.u64()
+	}
+	return uint64(b.u32())
+}
+
+// unitLength reads the initial length of a unit and reports whether the
+// unit uses the 64-bit DWARF format.
+func (b *dwarfBuf) unitLength() (uint64, bool) {
+	n := uint64(b.u32())
+	if n == 0xffffffff {
+		return b.u64(), true
+	}
+	return n, false
+}
+
+// cstring returns the NUL-terminated string at off in data.
+func cstring(data []byte, off uint64) string {
+	if off >= uint64(len(data)) {
+		return ""
+	}
+	return getString(data[off:], 0)
+}
+
+// Unit types of a DWARF 5 unit header.
+const (
+	DW_UT_compile       = 0x01
+	DW_UT_type          = 0x02
+	DW_UT_partial       = 0x03
+	DW_UT_skeleton      = 0x04
+	DW_UT_split_compile = 0x05
+	DW_UT_split_type    = 0x06
+)
+
+// abbrev is an entry of .debug_abbrev, describing the layout of DIEs that
+// refer to its code.
+type abbrev struct {
+	tag      uint64
+	children bool
+	attrs    []abbrevAttr
+}
+
+type abbrevAttr struct {
+	attr     uint64
+	form     uint64
+	implicit int64
+}
+
+// parseAbbrevs decodes the abbreviation table at off.
+func parseAbbrevs(data []byte, off uint64, order binary.ByteOrder) (map[uint64]*abbrev, error) {
+	if off >= uint64(len(data)) {
+		return nil, fmt.Errorf(".debug_abbrev: offset %#x out of range", off)
+	}
+	b := &dwarfBuf{data: data, off: int(off), order: order}
+	table := make(map[uint64]*abbrev)
+	for b.err == nil {
+		code := b.uleb()
+		if code == 0 {
+			break
+		}
+		a := &abbrev{tag: b.uleb(), children: b.u8() != 0}
+		for b.err == nil {
+			at := abbrevAttr{attr: b.uleb(), form: b.uleb()}
+			if at.form == DW_FORM_implicit_const {
+				at.implicit = b.sleb()
+			}
+			if at.attr == 0 && at.form == 0 {
+				break
+			}
+			a.attrs = append(a.attrs, at)
+		}
+		table[code] = a
+	}
+	if b.err != nil {
+		return nil, fmt.Errorf(".debug_abbrev: %w", b.err)
+	}
+	return table, nil
+}
+
+// unitHeader is the header of a unit in .debug_info. die is the offset of
+// its first DIE and end the offset just past the unit. Type units also
+// carry the signature of their type and the offset of its DIE.
+type unitHeader struct {
+	offset     uint64
+	version    uint16
+	unitType   uint8
+	dwarf64    bool
+	addrSize   int
+	abbrevOff  uint64
+	signature  uint64
+	typeOffset uint64
+	die        int
+	end        int
+}
+
+// readUnitHeader decodes the header of the unit starting at b.off, leaving
+// b positioned at its first DIE and limited to the unit.
+func readUnitHeader(b *dwarfBuf) (*unitHeader, error) {
+	u := &unitHeader{offset: uint64(b.off)}
+	length, dwarf64 := b.unitLength()
+	if b.err != nil || length > uint64(len(b.data)-b.off) {
+		return nil, fmt.Errorf("bad unit length at offset %#x", u.offset)
+	}
+	u.dwarf64 = dwarf64
+	u.end = b.off + int(length)
+	b.data = b.data[:u.end]
+
+	u.version = b.u16()
+	switch {
+	case u.version >= 2 && u.version <= 4:
+		u.unitType = DW_UT_compile
+		u.abbrevOff = b.offset(dwarf64)
+		u.addrSize = int(b.u8())
+	case u.version == 5:
+		u.unitType = b.u8()
+		u.addrSize = int(b.u8())
+		u.abbrevOff = b.offset(dwarf64)
+		switch u.unitType {
+		case DW_UT_skeleton, DW_UT_split_compile:
+			b.skip(8) // dwo_id
+		case DW_UT_type, DW_UT_split_type:
+			u.signature = b.u64()
+			u.typeOffset = u.offset + b.offset(dwarf64)
+		}
+	default:
+		return nil, fmt.Errorf("unit at offset %#x: unsupported version %d", u.offset, u.version)
+	}
+	if b.err != nil {
+		return nil, fmt.Errorf("unit at offset %#x: %w", u.offset, b.err)
+	}
+	u.die = b.off
+	return u, nil
+}
+
+// attrValue is the raw value of an attribute. Constants, addresses,
+// references, section offsets and indices are held in val, signed
+// constants in sval, strings in str and blocks in block. Strings given by
+// index (DW_FORM_strx*) are resolved by the caller, which knows the unit's
+// string offsets base.
+type attrValue struct {
+	form  uint64
+	val   uint64
+	sval  int64
+	str   string
+	block []byte
+}
+
+// dwarfStrings holds the string sections that string forms refer to.
+type dwarfStrings struct {
+	str     []byte
+	lineStr []byte
+}
+
+// attr reads a value of the given form.
+func (b *dwarfBuf) attr(form uint64, implicit int64, u *unitHeader, strs *dwarfStrings) attrValue {
+	v := attrValue{form: form}
+	switch form {
+	case DW_FORM_addr:
+		v.val = b.addr(u.addrSize)
+	case DW_FORM_block1:
+		v.block = b.bytes(int(b.u8()))
+	case DW_FORM_block2:
+		v.block = b.bytes(int(b.u16()))
+	case DW_FORM_block4:
+		v.block = b.bytes(int(b.u32()))
+	case DW_FORM_block, DW_FORM_exprloc:
+		n := b.uleb()
+		if n > uint64(len(b.data)) {
+			b.err = errDwarfTruncated
+			break
+		}
+		v.block = b.bytes(int(n))
+	case DW_FORM_data1, DW_FORM_ref1, DW_FORM_flag, DW_FORM_strx1, DW_FORM_addrx1:
+		v.val = uint64(b.u8())
+	case DW_FORM_data2, DW_FORM_ref2, DW_FORM_strx2, DW_FORM_addrx2:
+		v.val = uint64(b.u16())
+	case DW_FORM_strx3, DW_FORM_addrx3:
+		v.val = uint64(b.u24())
+	case DW_FORM_data4, DW_FORM_ref4, DW_FORM_ref_sup4, DW_FORM_strx4, DW_FORM_addrx4:
+		v.val = uint64(b.u32())
+	case DW_FORM_data8, DW_FORM_ref8, DW_FORM_ref_sig8, DW_FORM_ref_sup8:
+		v.val = b.u64()
+	case DW_FORM_data16:
+		v.block = b.bytes(16)
+	case DW_FORM_sdata:
+		v.sval = b.sleb()
+		v.val = uint64(v.sval)
+	case DW_FORM_implicit_const:
+		v.sval = implicit
+		v.val = uint64(implicit)
+	case DW_FORM_udata, DW_FORM_ref_udata, DW_FORM_strx, DW_FORM_addrx,
+		DW_FORM_loclistx, DW_FORM_rnglistx, DW_FORM_GNU_addr_index, DW_FORM_GNU_str_index:
+		v.val = b.uleb()
+	case DW_FORM_string:
+		v.str = b.str()
+	case DW_FORM_strp:
+		v.val = b.offset(u.dwarf64)
+		v.str = cstring(strs.str, v.val)
+	case DW_FORM_line_strp:
+		v.val = b.offset(u.dwarf64)
+		v.str = cstring(strs.lineStr, v.val)
+	case DW_FORM_ref_addr:
+		if u.version == 2 {
+			v.val = b.addr(u.addrSize)
+		} else {
+			v.val = b.offset(u.dwarf64)
+		}
+	case DW_FORM_sec_offset, DW_FORM_strp_sup, DW_FORM_GNU_ref_alt, DW_FORM_GNU_strp_alt:
+		v.val = b.offset(u.dwarf64)
+	case DW_FORM_flag_present:
+		v.val = 1
+	case DW_FORM_indirect:
+		return b.attr(b.uleb(), implicit, u, strs)
+	default:
+		b.err = fmt.Errorf("unsupported attribute form %#x", form)
+	}
+	return v
+}
+
+// DebugSectionData returns the contents of the named DWARF section, found
+// under its .zdebug name as well, decompressed and, in relocatable files,
+// with the relocations against it applied so that addresses and string
+// offsets read the same as in a linked file. It returns nil if the section
+// does not exist.
+func (f *File) DebugSectionData(name string) ([]byte, error) {
+	ndx := -1
+	zname := ".z" + strings.TrimPrefix(name, ".")
+	for i := range f.SectionHeaders {
+		if n := f.SectionHeaders[i].Name; n == name || n == zname {
+			ndx = i
+			break
+		}
+	}
+	if ndx < 0 {
+		return nil, nil
+	}
+	data, err := f.DecompressedSectionData(&f.SectionHeaders[ndx])
+	if err != nil {
+		return nil, err
+	}
+	if f.Type == ET_REL {
+		data = f.relocateDebugData(ndx, data)
+	}
+	return data, nil
+}
+
+// relocateDebugData applies the relocations against section ndx to a copy
+// of data. Only the absolute and RISC-V add/sub relocations that DWARF
+// producers use are handled; others are left alone.
+func (f *File) relocateDebugData(ndx int, data []byte) []byte {
+	copied := false
+	for _, rs := range f.Relocations {
+		if rs.Target != uint32(ndx) || (rs.Type != SHT_REL && rs.Type != SHT_RELA) {
+			continue
+		}
+		if !copied {
+			data = append([]byte(nil), data...)
+			copied = true
+		}
+		for _, r := range rs.Entries {
+			size, op := debugRelocation(f.Machine, r.Type)
+			if size == 0 || size > len(data) || r.Offset > uint64(len(data)-size) {
+				continue
+			}
+			loc := data[r.Offset : r.Offset+uint64(size)]
+			old := f.readWord(loc, size)
+			addend := uint64(r.Addend)
+			if !rs.HasAddend() {
+				addend = old
+			}
+			v := r.SymValue + addend
+			switch op {
+			case relocAdd:
+				v = old + r.SymValue + uint64(r.Addend)
+			case relocSub:
+				v = old - r.SymValue - uint64(r.Addend)
+			}
+			f.writeWord(loc, size, v)
+		}
+	}
+	return data
+}
+
+type relocOp int
+
+const (
+	relocAbs relocOp = iota
+	relocAdd
+	relocSub
+)
+
+// debugRelocation returns the width and operation of the relocation types
+// found in DWARF sections, and a zero width for any other type.
+func debugRelocation(machine uint16, t uint32) (int, relocOp) {
+	switch machine {
+	case EM_X86_64:
+		switch t {
+		case 1, 17: // R_X86_64_64, R_X86_64_DTPOFF64
+			return 8, relocAbs
+		case 10, 11, 21: // R_X86_64_32, R_X86_64_32S, R_X86_64_DTPOFF32
+			return 4, relocAbs
+		}
+	case EM_386:
+		if t == 1 { // R_386_32
+			return 4, relocAbs
+		}
+	case EM_AARCH64:
+		switch t {
+		case 257: // R_AARCH64_ABS64
+			return 8, relocAbs
+		case 258: // R_AARCH64_ABS32
+			return 4, relocAbs
+		}
+	case EM_ARM:
+		if t == 2 { // R_ARM_ABS32
+			return 4, relocAbs
+		}
+	case EM_RISCV:
+		switch t {
+		case 1: // R_RISCV_32
+			return 4, relocAbs
+		case 2: // R_RISCV_64
+			return 8, relocAbs
+		case 33, 34, 35, 36: // R_RISCV_ADD8 .. R_RISCV_ADD64
+			return 1 << (t - 33), relocAdd
+		case 37, 38, 39, 40: // R_RISCV_SUB8 .. R_RISCV_SUB64
+			return 1 << (t - 37), relocSub
+		}
+	case EM_LOONGARCH:
+		switch t {
+		case 1: // R_LARCH_32
+			return 4, relocAbs
+		case 2: // R_LARCH_64
+			return 8, relocAbs
+		}
+	case EM_PPC64:
+		switch t {
+		case 1: // R_PPC64_ADDR32
+			return 4, relocAbs
+		case 38: // R_PPC64_ADDR64
+			return 8, relocAbs
+		}
+	case EM_S390:
+		switch t {
+		case 4: // R_390_32
+			return 4, relocAbs
+		case 22: // R_390_64
+			return 8, relocAbs
+		}
+	case EM_MIPS:
+		if t == 2 { // R_MIPS_32
+			return 4, relocAbs
+		}
+	}
+	return 0, relocAbs
+}
+
+func (f *File) readWord(p []byte, size int) uint64 {
+	switch size {
+	case 1:
+		return uint64(p[0])
+	case 2:
+		return uint64(f.ByteOrder.Uint16(p))
+	case 4:
+		return uint64(f.ByteOrder.Uint32(p))
+	}
+	return f.ByteOrder.Uint64(p)
+}
+
+func (f *File) writeWord(p []byte, size int, v uint64) {
+	switch size {
+	case 1:
+		p[0] = byte(v)
+	case 2:
+		f.ByteOrder.PutUint16(p, uint16(v))
+	case 4:
+		f.ByteOrder.PutUint32(p, uint32(v))
+	default:
+		f.ByteOrder.PutUint64(p, v)
+	}
+}
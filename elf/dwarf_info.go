@@ -0,0 +1,653 @@
+package elf
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// DWARF tags.
+const (
+	DW_TAG_array_type               = 0x01
+	DW_TAG_class_type               = 0x02
+	DW_TAG_entry_point              = 0x03
+	DW_TAG_enumeration_type         = 0x04
+	DW_TAG_formal_parameter         = 0x05
+	DW_TAG_imported_declaration     = 0x08
+	DW_TAG_label                    = 0x0a
+	DW_TAG_lexical_block            = 0x0b
+	DW_TAG_member                   = 0x0d
+	DW_TAG_pointer_type             = 0x0f
+	DW_TAG_reference_type           = 0x10
+	DW_TAG_compile_unit             = 0x11
+	DW_TAG_string_type              = 0x12
+	DW_TAG_structure_type           = 0x13
+	DW_TAG_subroutine_type          = 0x15
+	DW_TAG_typedef                  = 0x16
+	DW_TAG_union_type               = 0x17
+	DW_TAG_unspecified_parameters   = 0x18
+	DW_TAG_variant                  = 0x19
+	DW_TAG_common_block             = 0x1a
+	DW_TAG_common_inclusion         = 0x1b
+	DW_TAG_inheritance              = 0x1c
+	DW_TAG_inlined_subroutine       = 0x1d
+	DW_TAG_module                   = 0x1e
+	DW_TAG_ptr_to_member_type       = 0x1f
+	DW_TAG_set_type                 = 0x20
+	DW_TAG_subrange_type            = 0x21
+	DW_TAG_with_stmt                = 0x22
+	DW_TAG_access_declaration       = 0x23
+	DW_TAG_base_type                = 0x24
+	DW_TAG_catch_block              = 0x25
+	DW_TAG_const_type               = 0x26
+	DW_TAG_constant                 = 0x27
+	DW_TAG_enumerator               = 0x28
+	DW_TAG_file_type                = 0x29
+	DW_TAG_friend                   = 0x2a
+	DW_TAG_namelist                 = 0x2b
+	DW_TAG_namelist_item            = 0x2c
+	DW_TAG_packed_type              = 0x2d
+	DW_TAG_subprogram               = 0x2e
+	DW_TAG_template_type_parameter  = 0x2f
+	DW_TAG_template_value_parameter = 0x30
+	DW_TAG_thrown_type              = 0x31
+	DW_TAG_try_block                = 0x32
+	DW_TAG_variant_part             = 0x33
+	DW_TAG_variable                 = 0x34
+	DW_TAG_volatile_type            = 0x35
+	DW_TAG_dwarf_procedure          = 0x36
+	DW_TAG_restrict_type            = 0x37
+	DW_TAG_interface_type           = 0x38
+	DW_TAG_namespace                = 0x39
+	DW_TAG_imported_module          = 0x3a
+	DW_TAG_unspecified_type         = 0x3b
+	DW_TAG_partial_unit             = 0x3c
+	DW_TAG_imported_unit            = 0x3d
+	DW_TAG_condition                = 0x3f
+	DW_TAG_shared_type              = 0x40
+	DW_TAG_type_unit                = 0x41
+	DW_TAG_rvalue_reference_type    = 0x42
+	DW_TAG_template_alias           = 0x43
+	DW_TAG_coarray_type             = 0x44
+	DW_TAG_generic_subrange         = 0x45
+	DW_TAG_dynamic_type             = 0x46
+	DW_TAG_atomic_type              = 0x47
+	DW_TAG_call_site                = 0x48
+	DW_TAG_call_site_parameter      = 0x49
+	DW_TAG_skeleton_unit            = 0x4a
+	DW_TAG_immutable_type           = 0x4b
+	DW_TAG_GNU_call_site            = 0x4109
+	DW_TAG_GNU_call_site_parameter  = 0x410a
+)
+
+// DWARF attributes.
+const (
+	DW_AT_sibling              = 0x01
+	DW_AT_location             = 0x02
+	DW_AT_name                 = 0x03
+	DW_AT_ordering             = 0x09
+	DW_AT_byte_size            = 0x0b
+	DW_AT_bit_offset           = 0x0c
+	DW_AT_bit_size             = 0x0d
+	DW_AT_stmt_list            = 0x10
+	DW_AT_low_pc               = 0x11
+	DW_AT_high_pc              = 0x12
+	DW_AT_language             = 0x13
+	DW_AT_discr                = 0x15
+	DW_AT_discr_value          = 0x16
+	DW_AT_visibility           = 0x17
+	DW_AT_import               = 0x18
+	DW_AT_string_length        = 0x19
+	DW_AT_common_reference     = 0x1a
+	DW_AT_comp_dir             = 0x1b
+	DW_AT_const_value          = 0x1c
+	DW_AT_containing_type      = 0x1d
+	DW_AT_default_value        = 0x1e
+	DW_AT_inline               = 0x20
+	DW_AT_is_optional          = 0x21
+	DW_AT_lower_bound          = 0x22
+	DW_AT_producer             = 0x25
+	DW_AT_prototyped           = 0x27
+	DW_AT_return_addr          = 0x2a
+	DW_AT_start_scope          = 0x2c
+	DW_AT_bit_stride           = 0x2e
+	DW_AT_upper_bound          = 0x2f
+	DW_AT_abstract_origin      = 0x31
+	DW_AT_accessibility        = 0x32
+	DW_AT_address_class        = 0x33
+	DW_AT_artificial           = 0x34
+	DW_AT_base_types           = 0x35
+	DW_AT_calling_convention   = 0x36
+	DW_AT_count                = 0x37
+	DW_AT_data_member_location = 0x38
+	DW_AT_decl_column          = 0x39
+	DW_AT_decl_file            = 0x3a
+	DW_AT_decl_line            = 0x3b
+	DW_AT_declaration          = 0x3c
+	DW_AT_discr_list           = 0x3d
+	DW_AT_encoding             = 0x3e
+	DW_AT_external             = 0x3f
+	DW_AT_frame_base           = 0x40
+	DW_AT_friend               = 0x41
+	DW_AT_identifier_case      = 0x42
+	DW_AT_macro_info           = 0x43
+	DW_AT_namelist_item        = 0x44
+	DW_AT_priority             = 0x45
+	DW_AT_segment              = 0x46
+	DW_AT_specification        = 0x47
+	DW_AT_static_link          = 0x48
+	DW_AT_type                 = 0x49
+	DW_AT_use_location         = 0x4a
+	DW_AT_variable_parameter   = 0x4b
+	DW_AT_virtuality           = 0x4c
+	DW_AT_vtable_elem_location = 0x4d
+	DW_AT_allocated            = 0x4e
+	DW_AT_associated           = 0x4f
+	DW_AT_data_location        = 0x50
+	DW_AT_byte_stride          = 0x51
+	DW_AT_entry_pc             = 0x52
+	DW_AT_use_UTF8             = 0x53
+	DW_AT_extension            = 0x54
+	DW_AT_ranges               = 0x55
+	DW_AT_trampoline           = 0x56
+	DW_AT_call_column          = 0x57
+	DW_AT_call_file            = 0x58
+	DW_AT_call_line            = 0x59
+	DW_AT_description          = 0x5a
+	DW_AT_binary_scale         = 0x5b
+	DW_AT_decimal_scale        = 0x5c
+	DW_AT_small                = 0x5d
+	DW_AT_decimal_sign         = 0x5e
+	DW_AT_digit_count          = 0x5f
+	DW_AT_picture_string       = 0x60
+	DW_AT_mutable              = 0x61
+	DW_AT_threads_scaled       = 0x62
+	DW_AT_explicit             = 0x63
+	DW_AT_object_pointer       = 0x64
+	DW_AT_endianity            = 0x65
+	DW_AT_elemental            = 0x66
+	DW_AT_pure                 = 0x67
+	DW_AT_recursive            = 0x68
+	DW_AT_signature            = 0x69
+	DW_AT_main_subprogram      = 0x6a
+	DW_AT_data_bit_offset      = 0x6b
+	DW_AT_const_expr           = 0x6c
+	DW_AT_enum_class           = 0x6d
+	DW_AT_linkage_name         = 0x6e
+	DW_AT_str_offsets_base     = 0x72
+	DW_AT_addr_base            = 0x73
+	DW_AT_rnglists_base        = 0x74
+	DW_AT_dwo_name             = 0x76
+	DW_AT_noreturn             = 0x87
+	DW_AT_alignment            = 0x88
+	DW_AT_export_symbols       = 0x89
+	DW_AT_loclists_base        = 0x8c
+	DW_AT_MIPS_linkage_name    = 0x2007
+	DW_AT_GNU_addr_base        = 0x2133
+)
+
+// Source languages of a compilation unit.
+const (
+	DW_LANG_C89            = 0x01
+	DW_LANG_C              = 0x02
+	DW_LANG_Ada83          = 0x03
+	DW_LANG_C_plus_plus    = 0x04
+	DW_LANG_Cobol74        = 0x05
+	DW_LANG_Cobol85        = 0x06
+	DW_LANG_Fortran77      = 0x07
+	DW_LANG_Fortran90      = 0x08
+	DW_LANG_Pascal83       = 0x09
+	DW_LANG_Modula2        = 0x0a
+	DW_LANG_Java           = 0x0b
+	DW_LANG_C99            = 0x0c
+	DW_LANG_Ada95          = 0x0d
+	DW_LANG_Fortran95      = 0x0e
+	DW_LANG_PLI            = 0x0f
+	DW_LANG_ObjC           = 0x10
+	DW_LANG_ObjC_plus_plus = 0x11
+	DW_LANG_UPC            = 0x12
+	DW_LANG_D              = 0x13
+	DW_LANG_Python         = 0x14
+	DW_LANG_OpenCL         = 0x15
+	DW_LANG_Go             = 0x16
+	DW_LANG_Modula3        = 0x17
+	DW_LANG_Haskell        = 0x18
+	DW_LANG_C_plus_plus_03 = 0x19
+	DW_LANG_C_plus_plus_11 = 0x1a
+	DW_LANG_OCaml          = 0x1b
+	DW_LANG_Rust           = 0x1c
+	DW_LANG_C11            = 0x1d
+	DW_LANG_Swift          = 0x1e
+	DW_LANG_Julia          = 0x1f
+	DW_LANG_Dylan          = 0x20
+	DW_LANG_C_plus_plus_14 = 0x21
+	DW_LANG_Fortran03      = 0x22
+	DW_LANG_Fortran08      = 0x23
+	DW_LANG_RenderScript   = 0x24
+	DW_LANG_BLISS          = 0x25
+	DW_LANG_Mips_Assembler = 0x8001
+)
+
+var languageNames = map[uint64]string{
+	DW_LANG_C89:            "C89",
+	DW_LANG_C:              "C",
+	DW_LANG_Ada83:          "Ada83",
+	DW_LANG_C_plus_plus:    "C++",
+	DW_LANG_Cobol74:        "Cobol74",
+	DW_LANG_Cobol85:        "Cobol85",
+	DW_LANG_Fortran77:      "Fortran77",
+	DW_LANG_Fortran90:      "Fortran90",
+	DW_LANG_Pascal83:       "Pascal83",
+	DW_LANG_Modula2:        "Modula2",
+	DW_LANG_Java:           "Java",
+	DW_LANG_C99:            "C99",
+	DW_LANG_Ada95:          "Ada95",
+	DW_LANG_Fortran95:      "Fortran95",
+	DW_LANG_PLI:            "PLI",
+	DW_LANG_ObjC:           "ObjC",
+	DW_LANG_ObjC_plus_plus: "ObjC++",
+	DW_LANG_UPC:            "UPC",
+	DW_LANG_D:              "D",
+	DW_LANG_Python:         "Python",
+	DW_LANG_OpenCL:         "OpenCL",
+	DW_LANG_Go:             "Go",
+	DW_LANG_Modula3:        "Modula3",
+	DW_LANG_Haskell:        "Haskell",
+	DW_LANG_C_plus_plus_03: "C++03",
+	DW_LANG_C_plus_plus_11: "C++11",
+	DW_LANG_OCaml:          "OCaml",
+	DW_LANG_Rust:           "Rust",
+	DW_LANG_C11:            "C11",
+	DW_LANG_Swift:          "Swift",
+	DW_LANG_Julia:          "Julia",
+	DW_LANG_Dylan:          "Dylan",
+	DW_LANG_C_plus_plus_14: "C++14",
+	DW_LANG_Fortran03:      "Fortran03",
+	DW_LANG_Fortran08:      "Fortran08",
+	DW_LANG_RenderScript:   "RenderScript",
+	DW_LANG_BLISS:          "BLISS",
+	DW_LANG_Mips_Assembler: "MIPS assembler",
+}
+
+// LanguageString names a DW_AT_language value.
+func LanguageString(lang uint64) string {
+	if name, ok := languageNames[lang]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%#x)", lang)
+}
+
+// DIE is a debugging information entry. The DIEs of a unit form a tree
+// rooted at the unit DIE.
+type DIE struct {
+	Offset   uint64
+	Tag      uint64
+	Attrs    []DIEAttr
+	Parent   *DIE
+	Children []*DIE
+	Unit     *CompileUnit
+}
+
+// DIEAttr is an attribute of a DIE. Constants, flags, addresses and
+// section offsets are held in Val, with signed constants sign-extended.
+// References within .debug_info hold the offset of the DIE they refer to,
+// and type signatures (DW_FORM_ref_sig8) the signature. Strings are
+// resolved into Str, whatever their form, and blocks and expressions kept
+// in Block.
+type DIEAttr struct {
+	Attr  uint64
+	Form  uint64
+	Val   uint64
+	Str   string
+	Block []byte
+}
+
+// IsReference reports whether the attribute refers to another DIE.
+func (a *DIEAttr) IsReference() bool {
+	switch a.Form {
+	case DW_FORM_ref1, DW_FORM_ref2, DW_FORM_ref4, DW_FORM_ref8, DW_FORM_ref_udata,
+		DW_FORM_ref_addr, DW_FORM_ref_sig8:
+		return true
+	}
+	return false
+}
+
+// IsConstant reports whether the attribute holds a constant, as opposed
+// to an address, reference or section offset.
+func (a *DIEAttr) IsConstant() bool {
+	switch a.Form {
+	case DW_FORM_data1, DW_FORM_data2, DW_FORM_data4, DW_FORM_data8,
+		DW_FORM_sdata, DW_FORM_udata, DW_FORM_implicit_const:
+		return true
+	}
+	return false
+}
+
+// Attr returns the attribute at of d, or nil if d does not have it.
+func (d *DIE) Attr(at uint64) *DIEAttr {
+	for i := range d.Attrs {
+		if d.Attrs[i].Attr == at {
+			return &d.Attrs[i]
+		}
+	}
+	return nil
+}
+
+// Val returns the value of attribute at and whether d has it.
+func (d *DIE) Val(at uint64) (uint64, bool) {
+	if a := d.Attr(at); a != nil {
+		return a.Val, true
+	}
+	return 0, false
+}
+
+// Flag reports whether the flag attribute at is set.
+func (d *DIE) Flag(at uint64) bool {
+	a := d.Attr(at)
+	return a != nil && a.Val != 0
+}
+
+// Name returns the DW_AT_name of d.
+func (d *DIE) Name() string {
+	if a := d.Attr(DW_AT_name); a != nil {
+		return a.Str
+	}
+	return ""
+}
+
+// CompileUnit is a unit of .debug_info. Type units are included too; they
+// hold a single type that other units refer to by its signature.
+type CompileUnit struct {
+	Offset   uint64
+	Version  uint16
+	UnitType uint8
+	AddrSize int
+	Root     *DIE
+
+	// Lines is the line table of the unit, if it has one.
+	Lines *LineTable
+
+	addrBase     uint64
+	rnglistsBase uint64
+	dwarf64      bool
+}
+
+// Name returns the name of the unit's primary source file.
+func (u *CompileUnit) Name() string {
+	return u.Root.Name()
+}
+
+// CompDir returns the directory the unit was compiled in.
+func (u *CompileUnit) CompDir() string {
+	if a := u.Root.Attr(DW_AT_comp_dir); a != nil {
+		return a.Str
+	}
+	return ""
+}
+
+// Producer returns the compiler that produced the unit.
+func (u *CompileUnit) Producer() string {
+	if a := u.Root.Attr(DW_AT_producer); a != nil {
+		return a.Str
+	}
+	return ""
+}
+
+// Language returns the DW_LANG_* source language of the unit.
+func (u *CompileUnit) Language() uint64 {
+	v, _ := u.Root.Val(DW_AT_language)
+	return v
+}
+
+// IsTypeUnit reports whether u is a type unit.
+func (u *CompileUnit) IsTypeUnit() bool {
+	return u.UnitType == DW_UT_type || u.UnitType == DW_UT_split_type
+}
+
+// DebugInfo is the decoded .debug_info of a file.
+type DebugInfo struct {
+	Units []*CompileUnit
+
+	entries  map[uint64]*DIE
+	types    map[uint64]*DIE
+	order    binary.ByteOrder
+	addrs    []byte
+	ranges   []byte
+	rnglists []byte
+}
+
+// Entry returns the DIE at offset off of .debug_info, or nil.
+func (di *DebugInfo) Entry(off uint64) *DIE {
+	return di.entries[off]
+}
+
+// Ref returns the DIE that attribute at of d refers to, or nil if d has no
+// such attribute or the DIE it refers to is not in the file.
+func (di *DebugInfo) Ref(d *DIE, at uint64) *DIE {
+	a := d.Attr(at)
+	if a == nil || !a.IsReference() {
+		return nil
+	}
+	if a.Form == DW_FORM_ref_sig8 {
+		return di.types[a.Val]
+	}
+	return di.entries[a.Val]
+}
+
+// DebugInfo decodes the DIEs of every unit in .debug_info. It returns nil
+// if the file has no debugging information. The result is cached.
+func (f *File) DebugInfo() (*DebugInfo, error) {
+	if f.debugInfo != nil || f.debugInfoErr != nil {
+		return f.debugInfo, f.debugInfoErr
+	}
+	f.debugInfo, f.debugInfoErr = f.parseDebugInfo()
+	return f.debugInfo, f.debugInfoErr
+}
+
+func (f *File) parseDebugInfo() (*DebugInfo, error) {
+	data, err := f.DebugSectionData(".debug_info")
+	if err != nil || data == nil {
+		return nil, err
+	}
+	abbrevData, err := f.DebugSectionData(".debug_abbrev")
+	if err != nil {
+		return nil, err
+	}
+	var strs dwarfStrings
+	if strs.str, err = f.DebugSectionData(".debug_str"); err != nil {
+		return nil, err
+	}
+	if strs.lineStr, err = f.DebugSectionData(".debug_line_str"); err != nil {
+		return nil, err
+	}
+	strOffsets, err := f.DebugSectionData(".debug_str_offsets")
+	if err != nil {
+		return nil, err
+	}
+	addrs, err := f.DebugSectionData(".debug_addr")
+	if err != nil {
+		return nil, err
+	}
+	ranges, err := f.DebugSectionData(".debug_ranges")
+	if err != nil {
+		return nil, err
+	}
+	rnglists, err := f.DebugSectionData(".debug_rnglists")
+	if err != nil {
+		return nil, err
+	}
+	// A damaged line table only costs the file names of declarations.
+	tables, _ := f.LineTables()
+	lines := make(map[uint64]*LineTable, len(tables))
+	for i := range tables {
+		lines[tables[i].Offset] = &tables[i]
+	}
+
+	di := &DebugInfo{
+		entries:  make(map[uint64]*DIE),
+		types:    make(map[uint64]*DIE),
+		order:    f.ByteOrder,
+		addrs:    addrs,
+		ranges:   ranges,
+		rnglists: rnglists,
+	}
+	abbrevCache := make(map[uint64]map[uint64]*abbrev)
+	for off := 0; off < len(data); {
+		b := &dwarfBuf{data: data, off: off, order: f.ByteOrder}
+		uh, err := readUnitHeader(b)
+		if err != nil {
+			return di, fmt.Errorf(".debug_info: %w", err)
+		}
+		off = uh.end
+
+		abbrevs, ok := abbrevCache[uh.abbrevOff]
+		if !ok {
+			if abbrevs, err = parseAbbrevs(abbrevData, uh.abbrevOff, f.ByteOrder); err != nil {
+				return di, err
+			}
+			abbrevCache[uh.abbrevOff] = abbrevs
+		}
+
+		u := &CompileUnit{
+			Offset:   uh.offset,
+			Version:  uh.version,
+			UnitType: uh.unitType,
+			AddrSize: uh.addrSize,
+		}
+		dies, err := di.readEntries(b, uh, u, abbrevs, &strs)
+		if err != nil {
+			return di, fmt.Errorf(".debug_info: unit at offset %#x: %w", uh.offset, err)
+		}
+		if u.Root == nil {
+			continue
+		}
+		di.resolveIndexed(u, uh, dies, strOffsets, strs.str)
+		if v, ok := u.Root.Val(DW_AT_stmt_list); ok {
+			u.Lines = lines[v]
+		}
+		if u.IsTypeUnit() {
+			if t := di.entries[uh.typeOffset]; t != nil {
+				di.types[uh.signature] = t
+			}
+		}
+		di.Units = append(di.Units, u)
+	}
+	return di, nil
+}
+
+// readEntries decodes the DIE tree of a unit, returning its DIEs in the
+// order they appear.
+func (di *DebugInfo) readEntries(b *dwarfBuf, uh *unitHeader, u *CompileUnit, abbrevs map[uint64]*abbrev, strs *dwarfStrings) ([]*DIE, error) {
+	var dies []*DIE
+	var parent *DIE
+	for b.off < len(b.data) && b.err == nil {
+		off := uint64(b.off)
+		code := b.uleb()
+		if code == 0 {
+			// The end of a list of siblings.
+			if parent != nil {
+				parent = parent.Parent
+			}
+			continue
+		}
+		a := abbrevs[code]
+		if a == nil {
+			return dies, fmt.Errorf("DIE at offset %#x: unknown abbreviation code %d", off, code)
+		}
+
+		d := &DIE{Offset: off, Tag: a.tag, Parent: parent, Unit: u}
+		d.Attrs = make([]DIEAttr, 0, len(a.attrs))
+		for _, at := range a.attrs {
+			v := b.attr(at.form, at.implicit, uh, strs)
+			attr := DIEAttr{Attr: at.attr, Form: v.form, Val: v.val, Str: v.str, Block: v.block}
+			switch v.form {
+			case DW_FORM_ref1, DW_FORM_ref2, DW_FORM_ref4, DW_FORM_ref8, DW_FORM_ref_udata:
+				attr.Val += uh.offset
+			}
+			d.Attrs = append(d.Attrs, attr)
+		}
+		if b.err != nil {
+			break
+		}
+
+		switch {
+		case parent != nil:
+			parent.Children = append(parent.Children, d)
+		case u.Root == nil:
+			u.Root = d
+		default:
+			// A unit has a single top-level DIE.
+			return dies, nil
+		}
+		di.entries[off] = d
+		dies = append(dies, d)
+		if a.children {
+			parent = d
+		}
+	}
+	return dies, b.err
+}
+
+// resolveIndexed fills in the strings and addresses that DWARF 5 units
+// refer to by index into .debug_str_offsets and .debug_addr, relative to
+// the bases given by the unit DIE, and records the base of the unit's
+// range lists.
+func (di *DebugInfo) resolveIndexed(u *CompileUnit, uh *unitHeader, dies []*DIE, strOffsets, str []byte) {
+	// Without an explicit base, the tables are taken to start right after
+	// the header of a single contribution.
+	strBase, addrBase := uint64(8), uint64(8)
+	if uh.dwarf64 {
+		strBase, addrBase = 16, 16
+	}
+	if v, ok := u.Root.Val(DW_AT_str_offsets_base); ok {
+		strBase = v
+	}
+	if v, ok := u.Root.Val(DW_AT_addr_base); ok {
+		addrBase = v
+	} else if v, ok := u.Root.Val(DW_AT_GNU_addr_base); ok {
+		addrBase = v
+	}
+	u.addrBase = addrBase
+	u.rnglistsBase = 12
+	if uh.dwarf64 {
+		u.rnglistsBase = 20
+	}
+	if v, ok := u.Root.Val(DW_AT_rnglists_base); ok {
+		u.rnglistsBase = v
+	}
+	u.dwarf64 = uh.dwarf64
+	offSize := uint64(4)
+	if uh.dwarf64 {
+		offSize = 8
+	}
+
+	offsets := &dwarfBuf{data: strOffsets, order: di.order}
+	for _, d := range dies {
+		for i := range d.Attrs {
+			a := &d.Attrs[i]
+			switch a.Form {
+			case DW_FORM_strx, DW_FORM_strx1, DW_FORM_strx2, DW_FORM_strx3, DW_FORM_strx4:
+				pos := strBase + a.Val*offSize
+				if pos+offSize > uint64(len(strOffsets)) {
+					continue
+				}
+				offsets.off, offsets.err = int(pos), nil
+				a.Str = cstring(str, offsets.offset(uh.dwarf64))
+			case DW_FORM_addrx, DW_FORM_addrx1, DW_FORM_addrx2, DW_FORM_addrx3, DW_FORM_addrx4:
+				if addr, ok := di.addrIndex(u, a.Val); ok {
+					a.Val = addr
+				}
+			}
+		}
+	}
+}
+
+// addrIndex returns entry idx of the .debug_addr contribution of unit u.
+func (di *DebugInfo) addrIndex(u *CompileUnit, idx uint64) (uint64, bool) {
+	size := uint64(u.AddrSize)
+	pos := u.addrBase + idx*size
+	if size == 0 || pos/size < idx || pos+size > uint64(len(di.addrs)) {
+		return 0, false
+	}
+	b := &dwarfBuf{data: di.addrs, off: int(pos), order: di.order}
+	return b.addr(u.AddrSize), b.err == nil
+}
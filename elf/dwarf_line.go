@@ -0,0 +1,426 @@
+package elf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Standard line number opcodes.
+const (
+	DW_LNS_copy               = 0x01
+	DW_LNS_advance_pc         = 0x02
+	DW_LNS_advance_line       = 0x03
+	DW_LNS_set_file           = 0x04
+	DW_LNS_set_column         = 0x05
+	DW_LNS_negate_stmt        = 0x06
+	DW_LNS_set_basic_block    = 0x07
+	DW_LNS_const_add_pc       = 0x08
+	DW_LNS_fixed_advance_pc   = 0x09
+	DW_LNS_set_prologue_end   = 0x0a
+	DW_LNS_set_epilogue_begin = 0x0b
+	DW_LNS_set_isa            = 0x0c
+)
+
+// Extended line number opcodes.
+const (
+	DW_LNE_end_sequence      = 0x01
+	DW_LNE_set_address       = 0x02
+	DW_LNE_define_file       = 0x03
+	DW_LNE_set_discriminator = 0x04
+)
+
+// Line number header entry content types (DWARF 5).
+const (
+	DW_LNCT_path            = 0x1
+	DW_LNCT_directory_index = 0x2
+	DW_LNCT_timestamp       = 0x3
+	DW_LNCT_size            = 0x4
+	DW_LNCT_MD5             = 0x5
+)
+
+// LineTable is one line number program of .debug_line: the file table of
+// its header and the rows produced by running the program.
+type LineTable struct {
+	Offset  uint64
+	Version uint16
+
+	// Files holds the path of each file the program refers to, indexed by
+	// DWARF file number. DWARF 5 numbers files from 0, earlier versions
+	// from 1 and leave Files[0] empty.
+	Files []string
+
+	Rows []LineRow
+}
+
+// LineRow is a row of the line number matrix. A row with EndSequence set
+// marks the first address past the end of a sequence.
+type LineRow struct {
+	Address       uint64
+	File          int
+	Line          int
+	Column        int
+	IsStmt        bool
+	Discriminator uint64
+	EndSequence   bool
+}
+
+// FileName returns the path of file number i, or "" if it is not known.
+func (t *LineTable) FileName(i int) string {
+	if i < 0 || i >= len(t.Files) {
+		return ""
+	}
+	return t.Files[i]
+}
+
+// LineTables decodes every line number program in .debug_line. It returns
+// nil if the file has no line information.
+func (f *File) LineTables() ([]LineTable, error) {
+	data, err := f.DebugSectionData(".debug_line")
+	if err != nil || data == nil {
+		return nil, err
+	}
+	var strs dwarfStrings
+	if strs.str, err = f.DebugSectionData(".debug_str"); err != nil {
+		return nil, err
+	}
+	if strs.lineStr, err = f.DebugSectionData(".debug_line_str"); err != nil {
+		return nil, err
+	}
+	compDirs, err := f.compDirs(&strs)
+	if err != nil {
+		return nil, err
+	}
+
+	var tables []LineTable
+	for off := 0; off < len(data); {
+		b := &dwarfBuf{data: data, off: off, order: f.ByteOrder}
+		length, dwarf64 := b.unitLength()
+		if b.err != nil || length > uint64(len(data)-b.off) {
+			return tables, fmt.Errorf(".debug_line: bad unit length at offset %#x", off)
+		}
+		end := b.off + int(length)
+		b.data = data[:end]
+
+		t, err := f.parseLineProgram(b, dwarf64, compDirs[uint64(off)], &strs)
+		if err != nil {
+			return tables, fmt.Errorf(".debug_line: unit at offset %#x: %w", off, err)
+		}
+		t.Offset = uint64(off)
+		tables = append(tables, *t)
+		off = end
+	}
+	return tables, nil
+}
+
+// lineHeader holds the fields of a line program header that drive the
+// state machine.
+type lineHeader struct {
+	version        uint16
+	addrSize       int
+	minInstLength  uint8
+	maxOpsPerInst  uint8
+	defaultIsStmt  bool
+	lineBase       int8
+	lineRange      uint8
+	opcodeBase     uint8
+	standardLength []uint8
+	dirs           []string
+}
+
+// parseLineProgram decodes the header following the unit length already
+// read from b and runs the program up to the end of b. compDir is the
+// compilation directory of the unit that refers to the program.
+func (f *File) parseLineProgram(b *dwarfBuf, dwarf64 bool, compDir string, strs *dwarfStrings) (*LineTable, error) {
+	h := lineHeader{addrSize: 8}
+	if f.Class == ELFCLASS32 {
+		h.addrSize = 4
+	}
+	h.version = b.u16()
+	if h.version < 2 || h.version > 5 {
+		return nil, fmt.Errorf("unsupported version %d", h.version)
+	}
+	if h.version >= 5 {
+		h.addrSize = int(b.u8())
+		b.u8() // segment_selector_size
+	}
+	headerLength := b.offset(dwarf64)
+	if b.err != nil || headerLength > uint64(len(b.data)-b.off) {
+		return nil, errDwarfTruncated
+	}
+	program := b.off + int(headerLength)
+
+	h.minInstLength = b.u8()
+	h.maxOpsPerInst = 1
+	if h.version >= 4 {
+		h.maxOpsPerInst = b.u8()
+	}
+	h.defaultIsStmt = b.u8() != 0
+	h.lineBase = int8(b.u8())
+	h.lineRange = b.u8()
+	h.opcodeBase = b.u8()
+	if h.opcodeBase > 0 {
+		h.standardLength = b.bytes(int(h.opcodeBase) - 1)
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+	if h.lineRange == 0 {
+		return nil, fmt.Errorf("line_range is zero")
+	}
+	if h.maxOpsPerInst == 0 {
+		h.maxOpsPerInst = 1
+	}
+
+	t := &LineTable{Version: h.version}
+	if h.version >= 5 {
+		u := &unitHeader{version: h.version, dwarf64: dwarf64, addrSize: h.addrSize}
+		h.readEntries5(b, t, u, strs)
+	} else {
+		// Directory 0 is the compilation directory, which the header does
+		// not record, and the others may be relative to it.
+		h.dirs = []string{compDir}
+		for {
+			dir := b.str()
+			if dir == "" || b.err != nil {
+				break
+			}
+			h.dirs = append(h.dirs, joinPath(compDir, dir))
+		}
+		t.Files = []string{""}
+		for b.err == nil {
+			name := b.str()
+			if name == "" {
+				break
+			}
+			t.Files = append(t.Files, h.readFileEntry(b, name))
+		}
+	}
+	if b.err != nil {
+		return nil, b.err
+	}
+
+	b.off = program
+	h.run(b, t)
+	return t, b.err
+}
+
+// readFileEntry reads the rest of a DWARF 2-4 file entry after its name.
+func (h *lineHeader) readFileEntry(b *dwarfBuf, name string) string {
+	dir := b.uleb()
+	b.uleb() // modification time
+	b.uleb() // length
+	if dir < uint64(len(h.dirs)) {
+		return joinPath(h.dirs[dir], name)
+	}
+	return name
+}
+
+// readEntries5 reads the self-describing directory and file tables of a
+// DWARF 5 header. Directory 0 is the compilation directory, which the
+// other directories may be relative to.
+func (h *lineHeader) readEntries5(b *dwarfBuf, t *LineTable, u *unitHeader, strs *dwarfStrings) {
+	type entry struct {
+		path string
+		dir  uint64
+	}
+	readTable := func() []entry {
+		formats := make([][2]uint64, b.u8())
+		for i := range formats {
+			formats[i] = [2]uint64{b.uleb(), b.uleb()}
+		}
+		count := b.uleb()
+		if b.err != nil || count > uint64(len(b.data)) {
+			b.err = errDwarfTruncated
+			return nil
+		}
+		entries := make([]entry, 0, count)
+		for i := uint64(0); i < count && b.err == nil; i++ {
+			var e entry
+			for _, fm := range formats {
+				v := b.attr(fm[1], 0, u, strs)
+				switch fm[0] {
+				case DW_LNCT_path:
+					e.path = v.str
+				case DW_LNCT_directory_index:
+					e.dir = v.val
+				}
+			}
+			entries = append(entries, e)
+		}
+		return entries
+	}
+
+	for i, d := range readTable() {
+		if i > 0 {
+			d.path = joinPath(h.dirs[0], d.path)
+		}
+		h.dirs = append(h.dirs, d.path)
+	}
+	for _, e := range readTable() {
+		if e.dir < uint64(len(h.dirs)) {
+			e.path = joinPath(h.dirs[e.dir], e.path)
+		}
+		t.Files = append(t.Files, e.path)
+	}
+}
+
+// lineState is the line number state machine's registers.
+type lineState struct {
+	address       uint64
+	opIndex       uint64
+	file          int
+	line          int
+	column        int
+	isStmt        bool
+	discriminator uint64
+}
+
+func (h *lineHeader) reset() lineState {
+	return lineState{file: 1, line: 1, isStmt: h.defaultIsStmt}
+}
+
+// advance moves the address and op_index by an operation advance.
+func (h *lineHeader) advance(s *lineState, n uint64) {
+	if h.maxOpsPerInst == 1 {
+		s.address += uint64(h.minInstLength) * n
+		return
+	}
+	ops := s.opIndex + n
+	s.address += uint64(h.minInstLength) * (ops / uint64(h.maxOpsPerInst))
+	s.opIndex = ops % uint64(h.maxOpsPerInst)
+}
+
+// run executes the line number program in b, appending a row to t for
+// every row the program emits.
+func (h *lineHeader) run(b *dwarfBuf, t *LineTable) {
+	s := h.reset()
+	emit := func(end bool) {
+		t.Rows = append(t.Rows, LineRow{
+			Address:       s.address,
+			File:          s.file,
+			Line:          s.line,
+			Column:        s.column,
+			IsStmt:        s.isStmt,
+			Discriminator: s.discriminator,
+			EndSequence:   end,
+		})
+		s.discriminator = 0
+	}
+
+	for b.off < len(b.data) && b.err == nil {
+		op := b.u8()
+		switch {
+		case op >= h.opcodeBase:
+			adj := uint64(op - h.opcodeBase)
+			h.advance(&s, adj/uint64(h.lineRange))
+			s.line += int(h.lineBase) + int(adj%uint64(h.lineRange))
+			emit(false)
+		case op == 0:
+			n := b.uleb()
+			if n == 0 || n > uint64(len(b.data)-b.off) {
+				b.err = errDwarfTruncated
+				return
+			}
+			next := b.off + int(n)
+			switch b.u8() {
+			case DW_LNE_end_sequence:
+				emit(true)
+				s = h.reset()
+			case DW_LNE_set_address:
+				s.address = b.addr(int(n) - 1)
+				s.opIndex = 0
+			case DW_LNE_define_file:
+				t.Files = append(t.Files, h.readFileEntry(b, b.str()))
+			case DW_LNE_set_discriminator:
+				s.discriminator = b.uleb()
+			}
+			b.off = next
+		case op == DW_LNS_copy:
+			emit(false)
+		case op == DW_LNS_advance_pc:
+			h.advance(&s, b.uleb())
+		case op == DW_LNS_advance_line:
+			s.line += int(b.sleb())
+		case op == DW_LNS_set_file:
+			s.file = int(b.uleb())
+		case op == DW_LNS_set_column:
+			s.column = int(b.uleb())
+		case op == DW_LNS_negate_stmt:
+			s.isStmt = !s.isStmt
+		case op == DW_LNS_set_basic_block:
+		case op == DW_LNS_const_add_pc:
+			h.advance(&s, uint64(255-h.opcodeBase)/uint64(h.lineRange))
+		case op == DW_LNS_fixed_advance_pc:
+			s.address += uint64(b.u16())
+			s.opIndex = 0
+		case op == DW_LNS_set_prologue_end, op == DW_LNS_set_epilogue_begin:
+		case op == DW_LNS_set_isa:
+			b.uleb()
+		default:
+			// An opcode this decoder does not know; the header says how
+			// many ULEB128 operands to skip.
+			for i := uint8(0); i < h.standardLength[op-1]; i++ {
+				b.uleb()
+			}
+		}
+	}
+}
+
+// compDirs maps the .debug_line offset of each compilation unit's line
+// program to the unit's compilation directory, which DWARF 2-4 line
+// programs leave out. Only the unit DIEs are decoded.
+func (f *File) compDirs(strs *dwarfStrings) (map[uint64]string, error) {
+	data, err := f.DebugSectionData(".debug_info")
+	if err != nil || data == nil {
+		return nil, err
+	}
+	abbrevData, err := f.DebugSectionData(".debug_abbrev")
+	if err != nil {
+		return nil, err
+	}
+
+	dirs := make(map[uint64]string)
+	for off := 0; off < len(data); {
+		b := &dwarfBuf{data: data, off: off, order: f.ByteOrder}
+		u, err := readUnitHeader(b)
+		if err != nil {
+			return nil, fmt.Errorf(".debug_info: %w", err)
+		}
+		off = u.end
+		if u.version >= 5 {
+			continue
+		}
+		abbrevs, err := parseAbbrevs(abbrevData, u.abbrevOff, f.ByteOrder)
+		if err != nil {
+			return nil, err
+		}
+		a := abbrevs[b.uleb()]
+		if a == nil || (a.tag != DW_TAG_compile_unit && a.tag != DW_TAG_partial_unit) {
+			continue
+		}
+		var stmtList uint64
+		var dir string
+		hasLines := false
+		for _, at := range a.attrs {
+			v := b.attr(at.form, at.implicit, u, strs)
+			switch at.attr {
+			case DW_AT_stmt_list:
+				stmtList, hasLines = v.val, true
+			case DW_AT_comp_dir:
+				dir = v.str
+			}
+		}
+		if b.err == nil && hasLines {
+			dirs[stmtList] = dir
+		}
+	}
+	return dirs, nil
+}
+
+// joinPath prefixes a relative file name with its directory.
+func joinPath(dir, name string) string {
+	if dir == "" || strings.HasPrefix(name, "/") {
+		return name
+	}
+	return strings.TrimSuffix(dir, "/") + "/" + name
+}
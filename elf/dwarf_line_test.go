@@ -0,0 +1,110 @@
+package elf
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLineTables(t *testing.T) {
+	f := testFile(t, "prog")
+	tables, err := f.LineTables()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tables) != 1 {
+		t.Fatalf("got %d line tables, want 1", len(tables))
+	}
+	lt := tables[0]
+	if lt.Version != 5 {
+		t.Errorf("got version %d, want 5", lt.Version)
+	}
+
+	// The rows as readelf --debug-dump=decodedline,rawline shows them.
+	want := []LineRow{
+		{Address: 0x4000e8, Line: 24, Column: 1, IsStmt: true},
+		{Address: 0x4000e8, Line: 25, Column: 2, IsStmt: true},
+		{Address: 0x4000e8, Line: 17, Column: 50, IsStmt: true},
+		{Address: 0x4000e8, Line: 19, Column: 2, IsStmt: true},
+		{Address: 0x4000e8, Line: 19, Column: 10},
+		{Address: 0x4000ee, Line: 20, Column: 2, IsStmt: true},
+		{Address: 0x4000ee, Line: 20, Column: 2},
+		{Address: 0x4000ee, Line: 25, Column: 18},
+		{Address: 0x4000f1, Line: 26, Column: 1},
+		{Address: 0x4000f2, Line: 37, Column: 1, IsStmt: true},
+		{Address: 0x4000f6, Line: 38, Column: 2, IsStmt: true},
+		{Address: 0x4000f6, Line: 40, Column: 2, IsStmt: true},
+		{Address: 0x4000f6, Line: 40, Column: 7, IsStmt: true},
+		{Address: 0x4000f6, Line: 40, Column: 20, IsStmt: true},
+		{Address: 0x4000f6, Line: 37, Column: 1},
+		{Address: 0x4000fb, Line: 41, Column: 20},
+		{Address: 0x400102, Line: 41, Column: 3, IsStmt: true, Discriminator: 3},
+		{Address: 0x400102, Line: 41, Column: 12, Discriminator: 3},
+		{Address: 0x400109, Line: 40, Column: 27, IsStmt: true, Discriminator: 3},
+		{Address: 0x400109, Line: 40, Column: 20, IsStmt: true, Discriminator: 3},
+		{Address: 0x40010d, Line: 40, Column: 20, Discriminator: 3},
+		{Address: 0x400113, Line: 42, Column: 2, IsStmt: true},
+		{Address: 0x400113, Line: 42, Column: 12},
+		{Address: 0x400121, Line: 42, Column: 10},
+		{Address: 0x400127, Line: 43, Column: 2, IsStmt: true, Discriminator: 1},
+		{Address: 0x400127, Line: 43, Column: 2, IsStmt: true, Discriminator: 1},
+		{Address: 0x400129, Line: 43, Column: 2, IsStmt: true, EndSequence: true},
+	}
+	if len(lt.Rows) != len(want) {
+		t.Fatalf("got %d rows, want %d", len(lt.Rows), len(want))
+	}
+	for i, r := range lt.Rows {
+		w := want[i]
+		w.File = r.File
+		if r != w {
+			t.Errorf("row %d: got %+v, want %+v", i, r, w)
+		}
+		if name := filepath.Base(lt.FileName(r.File)); name != "prog.c" {
+			t.Errorf("row %d: got file %q, want prog.c", i, name)
+		}
+	}
+}
+
+func TestSourceLine(t *testing.T) {
+	f := testFile(t, "prog")
+
+	// What addr2line -f prints for the same addresses. twice is inlined
+	// into add, and addr2line names the innermost inlined function.
+	tests := []struct {
+		addr          uint64
+		function      string
+		line          int
+		discriminator uint64
+	}{
+		{0x4000e8, "twice", 19, 0},
+		{0x4000eb, "twice", 19, 0},
+		{0x4000ee, "add", 25, 0},
+		{0x4000f1, "add", 26, 0},
+		{0x4000f2, "_start", 37, 0},
+		{0x4000fb, "_start", 41, 0},
+		{0x400102, "_start", 41, 3},
+		{0x400109, "_start", 40, 3},
+		{0x400113, "_start", 42, 0},
+		{0x400128, "_start", 43, 1},
+	}
+	for _, tt := range tests {
+		loc, err := f.SourceLine(tt.addr)
+		if err != nil {
+			t.Errorf("%#x: %v", tt.addr, err)
+			continue
+		}
+		if loc.Function != tt.function || loc.Line != tt.line || loc.Discriminator != tt.discriminator ||
+			filepath.Base(loc.File) != "prog.c" {
+			t.Errorf("%#x: got %s %s:%d (discriminator %d), want %s prog.c:%d (discriminator %d)", tt.addr,
+				loc.Function, loc.File, loc.Line, loc.Discriminator, tt.function, tt.line, tt.discriminator)
+		}
+	}
+
+	// Addresses outside any function have no location, which addr2line
+	// prints as ??:0.
+	for _, addr := range []uint64{0x4000e0, 0x400129} {
+		loc, err := f.SourceLine(addr)
+		if err != nil || loc.Function != "" || loc.File != "" || loc.Line != 0 {
+			t.Errorf("%#x: got %s %s:%d, %v; want no location", addr, loc.Function, loc.File, loc.Line, err)
+		}
+	}
+}
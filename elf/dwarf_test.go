@@ -0,0 +1,27 @@
+package elf
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestRelocateDebugData(t *testing.T) {
+	f := &File{Type: ET_REL, Machine: EM_X86_64, ByteOrder: binary.LittleEndian}
+	f.Relocations = []RelocationSection{{
+		Type:   SHT_RELA,
+		Target: 1,
+		Entries: []Relocation{
+			{Offset: 4, Type: 10, Addend: 0x10, SymValue: 0x1000}, // R_X86_64_32
+			{Offset: 6, Type: 10},             // runs past the end
+			{Offset: ^uint64(0) - 2, Type: 1}, // wraps around
+		},
+	}}
+	data := make([]byte, 8)
+	got := f.relocateDebugData(1, data)
+	if v := binary.LittleEndian.Uint32(got[4:]); v != 0x1010 {
+		t.Errorf("got %#x at offset 4, want 0x1010", v)
+	}
+	if binary.LittleEndian.Uint64(data) != 0 {
+		t.Errorf("the section data was modified in place")
+	}
+}
@@ -0,0 +1,469 @@
+package elf
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	DT_NULL            = 0
+	DT_NEEDED          = 1
+	DT_PLTRELSZ        = 2
+	DT_PLTGOT          = 3
+	DT_HASH            = 4
+	DT_STRTAB          = 5
+	DT_SYMTAB          = 6
+	DT_RELA            = 7
+	DT_RELASZ          = 8
+	DT_RELAENT         = 9
+	DT_STRSZ           = 10
+	DT_SYMENT          = 11
+	DT_INIT            = 12
+	DT_FINI            = 13
+	DT_SONAME          = 14
+	DT_RPATH           = 15
+	DT_SYMBOLIC        = 16
+	DT_REL             = 17
+	DT_RELSZ           = 18
+	DT_RELENT          = 19
+	DT_PLTREL          = 20
+	DT_DEBUG           = 21
+	DT_TEXTREL         = 22
+	DT_JMPREL          = 23
+	DT_BIND_NOW        = 24
+	DT_INIT_ARRAY      = 25
+	DT_FINI_ARRAY      = 26
+	DT_INIT_ARRAYSZ    = 27
+	DT_FINI_ARRAYSZ    = 28
+	DT_RUNPATH         = 29
+	DT_FLAGS           = 30
+	DT_PREINIT_ARRAY   = 32
+	DT_PREINIT_ARRAYSZ = 33
+	DT_SYMTAB_SHNDX    = 34
+	DT_RELRSZ          = 35
+	DT_RELR            = 36
+	DT_RELRENT         = 37
+
+	DT_GNU_PRELINKED  = 0x6ffffdf5
+	DT_GNU_CONFLICTSZ = 0x6ffffdf6
+	DT_GNU_LIBLISTSZ  = 0x6ffffdf7
+	DT_CHECKSUM       = 0x6ffffdf8
+	DT_PLTPADSZ       = 0x6ffffdf9
+	DT_MOVEENT        = 0x6ffffdfa
+	DT_MOVESZ         = 0x6ffffdfb
+	DT_FEATURE_1      = 0x6ffffdfc
+	DT_POSFLAG_1      = 0x6ffffdfd
+	DT_SYMINSZ        = 0x6ffffdfe
+	DT_SYMINENT       = 0x6ffffdff
+	DT_GNU_HASH       = 0x6ffffef5
+	DT_TLSDESC_PLT    = 0x6ffffef6
+	DT_TLSDESC_GOT    = 0x6ffffef7
+	DT_GNU_CONFLICT   = 0x6ffffef8
+	DT_GNU_LIBLIST    = 0x6ffffef9
+	DT_CONFIG         = 0x6ffffefa
+	DT_DEPAUDIT       = 0x6ffffefb
+	DT_AUDIT          = 0x6ffffefc
+	DT_PLTPAD         = 0x6ffffefd
+	DT_MOVETAB        = 0x6ffffefe
+	DT_SYMINFO        = 0x6ffffeff
+	DT_VERSYM         = 0x6ffffff0
+	DT_RELACOUNT      = 0x6ffffff9
+	DT_RELCOUNT       = 0x6ffffffa
+	DT_FLAGS_1        = 0x6ffffffb
+	DT_VERDEF         = 0x6ffffffc
+	DT_VERDEFNUM      = 0x6ffffffd
+	DT_VERNEED        = 0x6ffffffe
+	DT_VERNEEDNUM     = 0x6fffffff
+	DT_AUXILIARY      = 0x7ffffffd
+	DT_FILTER         = 0x7fffffff
+)
+
+// DT_FLAGS values.
+const (
+	DF_ORIGIN     = 0x1
+	DF_SYMBOLIC   = 0x2
+	DF_TEXTREL    = 0x4
+	DF_BIND_NOW   = 0x8
+	DF_STATIC_TLS = 0x10
+)
+
+// DT_FLAGS_1 values.
+const (
+	DF_1_NOW        = 0x1
+	DF_1_GLOBAL     = 0x2
+	DF_1_GROUP      = 0x4
+	DF_1_NODELETE   = 0x8
+	DF_1_LOADFLTR   = 0x10
+	DF_1_INITFIRST  = 0x20
+	DF_1_NOOPEN     = 0x40
+	DF_1_ORIGIN     = 0x80
+	DF_1_DIRECT     = 0x100
+	DF_1_TRANS      = 0x200
+	DF_1_INTERPOSE  = 0x400
+	DF_1_NODEFLIB   = 0x800
+	DF_1_NODUMP     = 0x1000
+	DF_1_CONFALT    = 0x2000
+	DF_1_ENDFILTEE  = 0x4000
+	DF_1_DISPRELDNE = 0x8000
+	DF_1_DISPRELPND = 0x10000
+	DF_1_NODIRECT   = 0x20000
+	DF_1_IGNMULDEF  = 0x40000
+	DF_1_NOKSYMS    = 0x80000
+	DF_1_NOHDR      = 0x100000
+	DF_1_EDITED     = 0x200000
+	DF_1_NORELOC    = 0x400000
+	DF_1_SYMINTPOSE = 0x800000
+	DF_1_GLOBAUDIT  = 0x1000000
+	DF_1_SINGLETON  = 0x2000000
+	DF_1_STUB       = 0x4000000
+	DF_1_PIE        = 0x8000000
+	DF_1_KMOD       = 0x10000000
+	DF_1_WEAKFILTER = 0x20000000
+	DF_1_NOCOMMON   = 0x40000000
+)
+
+type Dyn32 struct {
+	Tag int32
+	Val uint32
+}
+
+type Dyn64 struct {
+	Tag int64
+	Val uint64
+}
+
+// DynamicEntry is a single entry of the dynamic section. Str holds the
+// resolved string for tags whose value is an offset into the dynamic
+// string table (DT_NEEDED, DT_SONAME, DT_RPATH, DT_RUNPATH, ...).
+type DynamicEntry struct {
+	Tag   int64
+	Value uint64
+	Str   string
+}
+
+var dynamicTagNames = map[int64]string{
+	DT_NULL:            "NULL",
+	DT_NEEDED:          "NEEDED",
+	DT_PLTRELSZ:        "PLTRELSZ",
+	DT_PLTGOT:          "PLTGOT",
+	DT_HASH:            "HASH",
+	DT_STRTAB:          "STRTAB",
+	DT_SYMTAB:          "SYMTAB",
+	DT_RELA:            "RELA",
+	DT_RELASZ:          "RELASZ",
+	DT_RELAENT:         "RELAENT",
+	DT_STRSZ:           "STRSZ",
+	DT_SYMENT:          "SYMENT",
+	DT_INIT:            "INIT",
+	DT_FINI:            "FINI",
+	DT_SONAME:          "SONAME",
+	DT_RPATH:           "RPATH",
+	DT_SYMBOLIC:        "SYMBOLIC",
+	DT_REL:             "REL",
+	DT_RELSZ:           "RELSZ",
+	DT_RELENT:          "RELENT",
+	DT_PLTREL:          "PLTREL",
+	DT_DEBUG:           "DEBUG",
+	DT_TEXTREL:         "TEXTREL",
+	DT_JMPREL:          "JMPREL",
+	DT_BIND_NOW:        "BIND_NOW",
+	DT_INIT_ARRAY:      "INIT_ARRAY",
+	DT_FINI_ARRAY:      "FINI_ARRAY",
+	DT_INIT_ARRAYSZ:    "INIT_ARRAYSZ",
+	DT_FINI_ARRAYSZ:    "FINI_ARRAYSZ",
+	DT_RUNPATH:         "RUNPATH",
+	DT_FLAGS:           "FLAGS",
+	DT_PREINIT_ARRAY:   "PREINIT_ARRAY",
+	DT_PREINIT_ARRAYSZ: "PREINIT_ARRAYSZ",
+	DT_SYMTAB_SHNDX:    "SYMTAB_SHNDX",
+	DT_RELRSZ:          "RELRSZ",
+	DT_RELR:            "RELR",
+	DT_RELRENT:         "RELRENT",
+	DT_GNU_PRELINKED:   "GNU_PRELINKED",
+	DT_GNU_CONFLICTSZ:  "GNU_CONFLICTSZ",
+	DT_GNU_LIBLISTSZ:   "GNU_LIBLISTSZ",
+	DT_CHECKSUM:        "CHECKSUM",
+	DT_PLTPADSZ:        "PLTPADSZ",
+	DT_MOVEENT:         "MOVEENT",
+	DT_MOVESZ:          "MOVESZ",
+	DT_FEATURE_1:       "FEATURE_1",
+	DT_POSFLAG_1:       "POSFLAG_1",
+	DT_SYMINSZ:         "SYMINSZ",
+	DT_SYMINENT:        "SYMINENT",
+	DT_GNU_HASH:        "GNU_HASH",
+	DT_TLSDESC_PLT:     "TLSDESC_PLT",
+	DT_TLSDESC_GOT:     "TLSDESC_GOT",
+	DT_GNU_CONFLICT:    "GNU_CONFLICT",
+	DT_GNU_LIBLIST:     "GNU_LIBLIST",
+	DT_CONFIG:          "CONFIG",
+	DT_DEPAUDIT:        "DEPAUDIT",
+	DT_AUDIT:           "AUDIT",
+	DT_PLTPAD:          "PLTPAD",
+	DT_MOVETAB:         "MOVETAB",
+	DT_SYMINFO:         "SYMINFO",
+	DT_VERSYM:          "VERSYM",
+	DT_RELACOUNT:       "RELACOUNT",
+	DT_RELCOUNT:        "RELCOUNT",
+	DT_FLAGS_1:         "FLAGS_1",
+	DT_VERDEF:          "VERDEF",
+	DT_VERDEFNUM:       "VERDEFNUM",
+	DT_VERNEED:         "VERNEED",
+	DT_VERNEEDNUM:      "VERNEEDNUM",
+	DT_AUXILIARY:       "AUXILIARY",
+	DT_FILTER:          "FILTER",
+}
+
+var dynamicFlagNames = []struct {
+	bit  uint64
+	name string
+}{
+	{DF_ORIGIN, "ORIGIN"},
+	{DF_SYMBOLIC, "SYMBOLIC"},
+	{DF_TEXTREL, "TEXTREL"},
+	{DF_BIND_NOW, "BIND_NOW"},
+	{DF_STATIC_TLS, "STATIC_TLS"},
+}
+
+var dynamicFlag1Names = []struct {
+	bit  uint64
+	name string
+}{
+	{DF_1_NOW, "NOW"},
+	{DF_1_GLOBAL, "GLOBAL"},
+	{DF_1_GROUP, "GROUP"},
+	{DF_1_NODELETE, "NODELETE"},
+	{DF_1_LOADFLTR, "LOADFLTR"},
+	{DF_1_INITFIRST, "INITFIRST"},
+	{DF_1_NOOPEN, "NOOPEN"},
+	{DF_1_ORIGIN, "ORIGIN"},
+	{DF_1_DIRECT, "DIRECT"},
+	{DF_1_TRANS, "TRANS"},
+	{DF_1_INTERPOSE, "INTERPOSE"},
+	{DF_1_NODEFLIB, "NODEFLIB"},
+	{DF_1_NODUMP, "NODUMP"},
+	{DF_1_CONFALT, "CONFALT"},
+	{DF_1_ENDFILTEE, "ENDFILTEE"},
+	{DF_1_DISPRELDNE, "DISPRELDNE"},
+	{DF_1_DISPRELPND, "DISPRELPND"},
+	{DF_1_NODIRECT, "NODIRECT"},
+	{DF_1_IGNMULDEF, "IGNMULDEF"},
+	{DF_1_NOKSYMS, "NOKSYMS"},
+	{DF_1_NOHDR, "NOHDR"},
+	{DF_1_EDITED, "EDITED"},
+	{DF_1_NORELOC, "NORELOC"},
+	{DF_1_SYMINTPOSE, "SYMINTPOSE"},
+	{DF_1_GLOBAUDIT, "GLOBAUDIT"},
+	{DF_1_SINGLETON, "SINGLETON"},
+	{DF_1_STUB, "STUB"},
+	{DF_1_PIE, "PIE"},
+	{DF_1_KMOD, "KMOD"},
+	{DF_1_WEAKFILTER, "WEAKFILTER"},
+	{DF_1_NOCOMMON, "NOCOMMON"},
+}
+
+func DynamicTagString(tag int64) string {
+	if name, ok := dynamicTagNames[tag]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%#x)", uint64(tag))
+}
+
+// DynamicFlagsString decodes a DT_FLAGS value into space separated names.
+func DynamicFlagsString(v uint64) string {
+	return decodeBits(v, dynamicFlagNames)
+}
+
+// DynamicFlags1String decodes a DT_FLAGS_1 value into space separated names.
+func DynamicFlags1String(v uint64) string {
+	return decodeBits(v, dynamicFlag1Names)
+}
+
+func decodeBits(v uint64, names []struct {
+	bit  uint64
+	name string
+}) string {
+	var parts []string
+	for _, n := range names {
+		if v&n.bit != 0 {
+			parts = append(parts, n.name)
+			v &^= n.bit
+		}
+	}
+	if v != 0 {
+		parts = append(parts, fmt.Sprintf("%#x", v))
+	}
+	return strings.Join(parts, " ")
+}
+
+// DynamicValueString formats the value of a dynamic entry the way readelf
+// does: resolved library names, decoded flags, sizes in bytes, or hex.
+func DynamicValueString(d DynamicEntry) string {
+	switch d.Tag {
+	case DT_NEEDED:
+		return fmt.Sprintf("Shared library: [%s]", d.Str)
+	case DT_SONAME:
+		return fmt.Sprintf("Library soname: [%s]", d.Str)
+	case DT_RPATH:
+		return fmt.Sprintf("Library rpath: [%s]", d.Str)
+	case DT_RUNPATH:
+		return fmt.Sprintf("Library runpath: [%s]", d.Str)
+	case DT_AUXILIARY:
+		return fmt.Sprintf("Auxiliary library: [%s]", d.Str)
+	case DT_FILTER:
+		return fmt.Sprintf("Filter library: [%s]", d.Str)
+	case DT_CONFIG, DT_DEPAUDIT, DT_AUDIT:
+		return d.Str
+	case DT_FLAGS:
+		return DynamicFlagsString(d.Value)
+	case DT_FLAGS_1:
+		return "Flags: " + DynamicFlags1String(d.Value)
+	case DT_PLTREL:
+		return DynamicTagString(int64(d.Value))
+	case DT_PLTRELSZ, DT_RELASZ, DT_RELAENT, DT_STRSZ, DT_SYMENT,
+		DT_RELSZ, DT_RELENT, DT_INIT_ARRAYSZ, DT_FINI_ARRAYSZ,
+		DT_PREINIT_ARRAYSZ, DT_RELRSZ, DT_RELRENT, DT_SYMINSZ,
+		DT_SYMINENT, DT_MOVEENT, DT_MOVESZ, DT_PLTPADSZ,
+		DT_GNU_CONFLICTSZ, DT_GNU_LIBLISTSZ:
+		return fmt.Sprintf("%d (bytes)", d.Value)
+	case DT_VERDEFNUM, DT_VERNEEDNUM, DT_RELACOUNT, DT_RELCOUNT:
+		return fmt.Sprintf("%d", d.Value)
+	default:
+		return fmt.Sprintf("0x%x", d.Value)
+	}
+}
+
+// dynamicTagHasString reports whether the value of tag is an offset into
+// the dynamic string table.
+func dynamicTagHasString(tag int64) bool {
+	switch tag {
+	case DT_NEEDED, DT_SONAME, DT_RPATH, DT_RUNPATH,
+		DT_AUXILIARY, DT_FILTER, DT_CONFIG, DT_DEPAUDIT, DT_AUDIT:
+		return true
+	}
+	return false
+}
+
+// DynamicValue returns the value of the first dynamic entry with the given
+// tag.
+func (f *File) DynamicValue(tag int64) (uint64, bool) {
+	for _, d := range f.Dynamic {
+		if d.Tag == tag {
+			return d.Value, true
+		}
+	}
+	return 0, false
+}
+
+// DynamicStrings returns the resolved strings of all dynamic entries with
+// the given tag, e.g. the DT_NEEDED libraries in load order.
+func (f *File) DynamicStrings(tag int64) []string {
+	var out []string
+	for _, d := range f.Dynamic {
+		if d.Tag == tag {
+			out = append(out, d.Str)
+		}
+	}
+	return out
+}
+
+// dynamicData locates the raw dynamic array, preferring the SHT_DYNAMIC
+// section and falling back to the PT_DYNAMIC segment for files without
+// section headers. The returned link is the section index of the string
+// table, or -1 when it has to be located through DT_STRTAB.
+func (f *File) dynamicData() ([]byte, int, error) {
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type != SHT_DYNAMIC {
+			continue
+		}
+		data, err := f.GetSectionData(sh)
+		if err != nil {
+			return nil, 0, err
+		}
+		link := -1
+		if sh.Link != 0 && sh.Link < uint32(len(f.SectionHeaders)) {
+			link = int(sh.Link)
+		}
+		return data, link, nil
+	}
+
+	for _, ph := range f.ProgramHeaders {
+		if ph.Type != PT_DYNAMIC {
+			continue
+		}
+		if ph.Offset+ph.FileSz > uint64(len(f.Raw)) {
+			return nil, 0, fmt.Errorf("dynamic segment out of bounds")
+		}
+		return f.Raw[ph.Offset : ph.Offset+ph.FileSz], -1, nil
+	}
+
+	return nil, -1, nil
+}
+
+// parseDynamic reads the dynamic section. A dynamic section that cannot
+// be read is left out with a warning rather than making the whole file
+// unreadable.
+func (f *File) parseDynamic() {
+	data, link, err := f.dynamicData()
+	if err != nil {
+		f.Warnings = append(f.Warnings, fmt.Sprintf("dynamic section skipped: %v", err))
+		return
+	}
+	if data == nil {
+		return
+	}
+
+	entSize := 16
+	if f.Class == ELFCLASS32 {
+		entSize = 8
+	}
+
+	for off := 0; off+entSize <= len(data); off += entSize {
+		var d DynamicEntry
+		if f.Class == ELFCLASS32 {
+			d.Tag = int64(int32(f.ByteOrder.Uint32(data[off:])))
+			d.Value = uint64(f.ByteOrder.Uint32(data[off+4:]))
+		} else {
+			d.Tag = int64(f.ByteOrder.Uint64(data[off:]))
+			d.Value = f.ByteOrder.Uint64(data[off+8:])
+		}
+		f.Dynamic = append(f.Dynamic, d)
+		if d.Tag == DT_NULL {
+			break
+		}
+	}
+
+	var strTab []byte
+	if link >= 0 {
+		strTab, err = f.GetSectionData(&f.SectionHeaders[link])
+		if err != nil {
+			f.Warnings = append(f.Warnings, fmt.Sprintf("dynamic string table skipped: %v", err))
+		}
+	} else if addr, ok := f.DynamicValue(DT_STRTAB); ok {
+		size, _ := f.DynamicValue(DT_STRSZ)
+		if off, ok := f.vaddrToOffset(addr); ok && off+size <= uint64(len(f.Raw)) {
+			strTab = f.Raw[off : off+size]
+		}
+	}
+
+	for i := range f.Dynamic {
+		d := &f.Dynamic[i]
+		if dynamicTagHasString(d.Tag) && d.Value < uint64(len(strTab)) {
+			d.Str = getString(strTab, uint32(d.Value))
+		}
+	}
+}
+
+// vaddrToOffset translates a virtual address to a file offset using the
+// PT_LOAD segments.
+func (f *File) vaddrToOffset(addr uint64) (uint64, bool) {
+	for _, ph := range f.ProgramHeaders {
+		if ph.Type != PT_LOAD {
+			continue
+		}
+		if addr >= ph.VAddr && addr < ph.VAddr+ph.FileSz {
+			return ph.Offset + (addr - ph.VAddr), true
+		}
+	}
+	return 0, false
+}
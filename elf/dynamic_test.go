@@ -0,0 +1,58 @@
+package elf
+
+import (
+	"encoding/binary"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDynamicOutOfBounds(t *testing.T) {
+	data, err := os.ReadFile("testdata/libv1.so")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(f.Dynamic) == 0 || len(f.Warnings) != 0 {
+		t.Fatalf("got %d dynamic entries and warnings %q, want entries and no warnings", len(f.Dynamic), f.Warnings)
+	}
+
+	// A .dynamic section that runs past the end of the file.
+	section := append([]byte(nil), data...)
+	for i, sh := range f.SectionHeaders {
+		if sh.Type == SHT_DYNAMIC {
+			binary.LittleEndian.PutUint64(section[f.shoff+uint64(i)*64+0x20:], 1<<20) // sh_size
+		}
+	}
+
+	// A PT_DYNAMIC segment that does, in a file without section headers.
+	segment := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(segment[0x28:], 0) // e_shoff
+	binary.LittleEndian.PutUint16(segment[0x3c:], 0) // e_shnum
+	binary.LittleEndian.PutUint16(segment[0x3e:], 0) // e_shstrndx
+	for i, ph := range f.ProgramHeaders {
+		if ph.Type == PT_DYNAMIC {
+			binary.LittleEndian.PutUint64(segment[f.phoff+uint64(i)*56+0x20:], 1<<20) // p_filesz
+		}
+	}
+
+	for name, data := range map[string][]byte{"section": section, "segment": segment} {
+		f, err := Parse(data)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if len(f.Dynamic) != 0 {
+			t.Errorf("%s: got %d dynamic entries, want none", name, len(f.Dynamic))
+		}
+		if len(f.Warnings) != 1 || !strings.HasPrefix(f.Warnings[0], "dynamic section skipped") {
+			t.Errorf("%s: got warnings %q, want one about the dynamic section", name, f.Warnings)
+		}
+		if len(f.ProgramHeaders) == 0 {
+			t.Errorf("%s: got no program headers", name)
+		}
+	}
+}
@@ -0,0 +1,326 @@
+package zstd
+
+import "math/bits"
+
+// backwardReader reads a bitstream from its end towards its start, the
+// way FSE and Huffman streams are written. Reads past the start return
+// zero bits and are reported by overflow.
+type backwardReader struct {
+	data []byte
+	pos  int // number of unread bits
+}
+
+func newBackwardReader(data []byte) (*backwardReader, error) {
+	if len(data) == 0 || data[len(data)-1] == 0 {
+		return nil, errCorrupt
+	}
+	// The highest set bit of the last byte marks the end of the stream.
+	pad := bits.LeadingZeros8(data[len(data)-1]) + 1
+	return &backwardReader{data: data, pos: len(data)*8 - pad}, nil
+}
+
+// peek returns the next n bits, n <= 56, without consuming them.
+func (r *backwardReader) peek(n uint) uint64 {
+	if n == 0 {
+		return 0
+	}
+	start := r.pos - int(n)
+	if start < 0 {
+		if r.pos <= 0 {
+			return 0
+		}
+		return r.load(0, uint(r.pos)) << uint(-start)
+	}
+	return r.load(start, n)
+}
+
+// load returns the n bits starting at bit offset start.
+func (r *backwardReader) load(start int, n uint) uint64 {
+	var v uint64
+	i := start >> 3
+	for j := 0; j < 8 && i+j < len(r.data); j++ {
+		v |= uint64(r.data[i+j]) << (8 * j)
+	}
+	return v >> (start & 7) & (1<<n - 1)
+}
+
+func (r *backwardReader) read(n uint) uint64 {
+	v := r.peek(n)
+	r.pos -= int(n)
+	return v
+}
+
+func (r *backwardReader) overflow() bool {
+	return r.pos < 0
+}
+
+// fseEntry is a state of an FSE decoding table: the symbol it emits and how
+// to compute the next state.
+type fseEntry struct {
+	symbol uint8
+	bits   uint8
+	base   uint16
+}
+
+type fseTable struct {
+	log     uint
+	entries []fseEntry
+}
+
+// readNormalized decodes an FSE table description, returning the
+// normalized counts, the accuracy log and the number of bytes read.
+func readNormalized(src []byte, maxSym int, maxLog uint) ([]int16, uint, int, error) {
+	var pos uint // forward bit position
+	get := func(n uint) uint32 {
+		var v uint32
+		i := int(pos >> 3)
+		for j := 0; j < 4 && i+j < len(src); j++ {
+			v |= uint32(src[i+j]) << (8 * j)
+		}
+		return v >> (pos & 7) & (1<<n - 1)
+	}
+	if len(src) == 0 {
+		return nil, 0, 0, errCorrupt
+	}
+	log := uint(get(4)) + 5
+	pos = 4
+	if log > maxLog {
+		return nil, 0, 0, errCorrupt
+	}
+
+	norm := make([]int16, 0, maxSym+1)
+	remaining := int32(1<<log) + 1
+	threshold := int32(1 << log)
+	nbBits := log + 1
+	for remaining > 1 {
+		if len(norm) > maxSym || int(pos>>3) >= len(src) {
+			return nil, 0, 0, errCorrupt
+		}
+		max := 2*threshold - 1 - remaining
+		v := int32(get(nbBits))
+		var count int32
+		if v&(threshold-1) < max {
+			count = v & (threshold - 1)
+			pos += nbBits - 1
+		} else {
+			count = v & (2*threshold - 1)
+			if count >= threshold {
+				count -= max
+			}
+			pos += nbBits
+		}
+		count--
+		if count < 0 {
+			remaining--
+		} else {
+			remaining -= count
+		}
+		norm = append(norm, int16(count))
+		if count == 0 {
+			// A zero count is followed by 2-bit repeat flags giving the
+			// number of further zero counts, 3 meaning more follow.
+			for {
+				if int(pos>>3) >= len(src) {
+					return nil, 0, 0, errCorrupt
+				}
+				repeat := get(2)
+				pos += 2
+				for i := uint32(0); i < repeat; i++ {
+					norm = append(norm, 0)
+				}
+				if repeat != 3 {
+					break
+				}
+			}
+		}
+		for remaining < threshold && nbBits > 1 {
+			nbBits--
+			threshold >>= 1
+		}
+	}
+	if remaining != 1 || len(norm) > maxSym+1 {
+		return nil, 0, 0, errCorrupt
+	}
+	return norm, log, int((pos + 7) >> 3), nil
+}
+
+// build fills the decoding table for the normalized counts norm, following
+// the symbol spreading of RFC 8878 section 4.1.1.
+func (t *fseTable) build(norm []int16, log uint) {
+	size := 1 << log
+	t.log = log
+	if cap(t.entries) < size {
+		t.entries = make([]fseEntry, size)
+	}
+	t.entries = t.entries[:size]
+
+	high := size - 1
+	next := make([]uint16, len(norm))
+	for s, c := range norm {
+		if c == -1 {
+			t.entries[high].symbol = uint8(s)
+			high--
+			next[s] = 1
+		} else {
+			next[s] = uint16(c)
+		}
+	}
+	step := size>>1 + size>>3 + 3
+	mask := size - 1
+	pos := 0
+	for s, c := range norm {
+		for i := 0; i < int(c); i++ {
+			t.entries[pos].symbol = uint8(s)
+			pos = (pos + step) & mask
+			for pos > high {
+				pos = (pos + step) & mask
+			}
+		}
+	}
+	for i := range t.entries {
+		e := &t.entries[i]
+		n := next[e.symbol]
+		next[e.symbol]++
+		e.bits = uint8(log - uint(bits.Len16(n)-1))
+		e.base = n<<e.bits - uint16(size)
+	}
+}
+
+// rle sets up a table that always yields sym without reading any bits.
+func (t *fseTable) rle(sym byte) {
+	t.log = 0
+	t.entries = append(t.entries[:0], fseEntry{symbol: sym})
+}
+
+const maxHuffBits = 11
+
+type huffEntry struct {
+	symbol uint8
+	bits   uint8
+}
+
+// huffTable decodes literals by looking up the next maxBits bits of the
+// stream.
+type huffTable struct {
+	maxBits uint
+	entries []huffEntry
+}
+
+// read decodes a Huffman tree description and returns its size.
+func (h *huffTable) read(src []byte) (int, error) {
+	if len(src) == 0 {
+		return 0, errCorrupt
+	}
+	var weights []uint8
+	var n int
+	if hb := int(src[0]); hb < 128 {
+		if 1+hb > len(src) {
+			return 0, errCorrupt
+		}
+		data := src[1 : 1+hb]
+		norm, log, m, err := readNormalized(data, 255, 6)
+		if err != nil {
+			return 0, err
+		}
+		var t fseTable
+		t.build(norm, log)
+		br, err := newBackwardReader(data[m:])
+		if err != nil {
+			return 0, err
+		}
+		// Two interleaved states share the stream until it runs out.
+		s1, s2 := br.read(log), br.read(log)
+		for {
+			if len(weights) > 254 {
+				return 0, errCorrupt
+			}
+			e := t.entries[s1]
+			weights = append(weights, e.symbol)
+			s1 = uint64(e.base) + br.read(uint(e.bits))
+			if br.overflow() {
+				weights = append(weights, t.entries[s2].symbol)
+				break
+			}
+			e = t.entries[s2]
+			weights = append(weights, e.symbol)
+			s2 = uint64(e.base) + br.read(uint(e.bits))
+			if br.overflow() {
+				weights = append(weights, t.entries[s1].symbol)
+				break
+			}
+		}
+		n = 1 + hb
+	} else {
+		count := hb - 127
+		n = 1 + (count+1)/2
+		if n > len(src) {
+			return 0, errCorrupt
+		}
+		for i := 0; i < count; i++ {
+			b := src[1+i/2]
+			if i%2 == 0 {
+				weights = append(weights, b>>4)
+			} else {
+				weights = append(weights, b&0xf)
+			}
+		}
+	}
+
+	// The weight of the last symbol is implied: it brings the total up to
+	// the next power of two.
+	var total uint32
+	for _, w := range weights {
+		if w > maxHuffBits {
+			return 0, errCorrupt
+		}
+		if w > 0 {
+			total += 1 << (w - 1)
+		}
+	}
+	if total == 0 {
+		return 0, errCorrupt
+	}
+	maxBits := uint(bits.Len32(total))
+	rest := uint32(1)<<maxBits - total
+	if maxBits > maxHuffBits || rest&(rest-1) != 0 || len(weights) > 255 {
+		return 0, errCorrupt
+	}
+	weights = append(weights, uint8(bits.Len32(rest)))
+
+	h.maxBits = maxBits
+	h.entries = make([]huffEntry, 1<<maxBits)
+	pos := 0
+	for w := uint(1); w <= maxBits; w++ {
+		for s, sw := range weights {
+			if uint(sw) != w {
+				continue
+			}
+			e := huffEntry{symbol: uint8(s), bits: uint8(maxBits + 1 - w)}
+			for i := 0; i < 1<<(w-1); i++ {
+				h.entries[pos] = e
+				pos++
+			}
+		}
+	}
+	return n, nil
+}
+
+// decode appends n literals decoded from the stream src to dst.
+func (h *huffTable) decode(dst, src []byte, n int) ([]byte, error) {
+	if n == 0 {
+		return dst, nil
+	}
+	br, err := newBackwardReader(src)
+	if err != nil {
+		return nil, err
+	}
+	for i := 0; i < n; i++ {
+		e := h.entries[br.peek(h.maxBits)]
+		dst = append(dst, e.symbol)
+		br.pos -= int(e.bits)
+	}
+	if br.pos != 0 {
+		return nil, errCorrupt
+	}
+	return dst, nil
+}
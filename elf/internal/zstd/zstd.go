@@ -0,0 +1,542 @@
+// Package zstd decompresses Zstandard frames (RFC 8878). It covers what
+// toolchains emit for compressed ELF sections: single or concatenated
+// frames without a dictionary. Content checksums are not verified.
+package zstd
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+const (
+	frameMagic         = 0xfd2fb528
+	skippableMagicMask = 0xfffffff0
+	skippableMagic     = 0x184d2a50
+
+	maxBlockSize = 128 << 10
+)
+
+var (
+	errCorrupt  = errors.New("zstd: corrupt input")
+	errTooLarge = errors.New("zstd: output exceeds the size limit")
+)
+
+// Decompress appends the decompressed contents of every frame in src to dst
+// and returns the result. It fails as soon as the output would grow past
+// limit bytes, so that input from a file cannot exhaust memory.
+func Decompress(dst, src []byte, limit uint64) ([]byte, error) {
+	end := uint64(len(dst)) + limit
+	for len(src) > 0 {
+		if len(src) < 4 {
+			return nil, errCorrupt
+		}
+		magic := binary.LittleEndian.Uint32(src)
+		if magic&skippableMagicMask == skippableMagic {
+			if len(src) < 8 {
+				return nil, errCorrupt
+			}
+			size := uint64(binary.LittleEndian.Uint32(src[4:]))
+			if size > uint64(len(src)-8) {
+				return nil, errCorrupt
+			}
+			src = src[8+size:]
+			continue
+		}
+		if magic != frameMagic {
+			return nil, fmt.Errorf("zstd: bad magic %#x", magic)
+		}
+		d := decoder{end: end}
+		n, err := d.frame(src[4:], &dst)
+		if err != nil {
+			return nil, err
+		}
+		src = src[4+n:]
+	}
+	return dst, nil
+}
+
+// decoder holds the state carried from one block to the next within a
+// frame.
+type decoder struct {
+	out   []byte
+	start int    // where the current frame begins in out
+	end   uint64 // the length out may not exceed
+	reps  [3]uint64
+
+	huf       huffTable
+	llTable   fseTable
+	ofTable   fseTable
+	mlTable   fseTable
+	literals  []byte
+	hasHuf    bool
+	hasTables [3]bool
+}
+
+// frame decodes a frame whose magic number has been consumed and returns
+// the number of bytes read.
+func (d *decoder) frame(src []byte, dst *[]byte) (int, error) {
+	if len(src) < 1 {
+		return 0, errCorrupt
+	}
+	desc := src[0]
+	pos := 1
+	fcsFlag := desc >> 6
+	single := desc&0x20 != 0
+	checksum := desc&0x04 != 0
+	if desc&0x08 != 0 {
+		return 0, errCorrupt
+	}
+	if !single {
+		pos++ // window descriptor
+	}
+	if n := [4]int{0, 1, 2, 4}[desc&3]; n > 0 {
+		if pos+n > len(src) {
+			return 0, errCorrupt
+		}
+		var id uint32
+		for i := n - 1; i >= 0; i-- {
+			id = id<<8 | uint32(src[pos+i])
+		}
+		if id != 0 {
+			return 0, errors.New("zstd: dictionaries are not supported")
+		}
+		pos += n
+	}
+	fcsSize := [4]int{0, 2, 4, 8}[fcsFlag]
+	if fcsFlag == 0 && single {
+		fcsSize = 1
+	}
+	var contentSize uint64
+	if pos+fcsSize > len(src) {
+		return 0, errCorrupt
+	}
+	for i := fcsSize - 1; i >= 0; i-- {
+		contentSize = contentSize<<8 | uint64(src[pos+i])
+	}
+	if fcsSize == 2 {
+		contentSize += 256
+	}
+	pos += fcsSize
+
+	d.out = *dst
+	d.start = len(d.out)
+	d.reps = [3]uint64{1, 4, 8}
+	// The content size is only a hint; cap the reservation so a corrupt
+	// header cannot force a huge allocation.
+	if fcsSize > 0 && contentSize <= uint64(len(src))*64 && contentSize <= d.end-uint64(len(d.out)) {
+		d.out = append(make([]byte, 0, len(d.out)+int(contentSize)), d.out...)
+	}
+
+	for {
+		if pos+3 > len(src) {
+			return 0, errCorrupt
+		}
+		hdr := uint32(src[pos]) | uint32(src[pos+1])<<8 | uint32(src[pos+2])<<16
+		pos += 3
+		last := hdr&1 != 0
+		size := int(hdr >> 3)
+		switch hdr >> 1 & 3 {
+		case 0:
+			if pos+size > len(src) {
+				return 0, errCorrupt
+			}
+			if err := d.grow(size); err != nil {
+				return 0, err
+			}
+			d.out = append(d.out, src[pos:pos+size]...)
+			pos += size
+		case 1:
+			if pos >= len(src) || size > maxBlockSize {
+				return 0, errCorrupt
+			}
+			if err := d.grow(size); err != nil {
+				return 0, err
+			}
+			for i := 0; i < size; i++ {
+				d.out = append(d.out, src[pos])
+			}
+			pos++
+		case 2:
+			if pos+size > len(src) || size > maxBlockSize {
+				return 0, errCorrupt
+			}
+			if err := d.block(src[pos : pos+size]); err != nil {
+				return 0, err
+			}
+			pos += size
+		default:
+			return 0, errCorrupt
+		}
+		if last {
+			break
+		}
+	}
+	if checksum {
+		if pos+4 > len(src) {
+			return 0, errCorrupt
+		}
+		pos += 4
+	}
+	if fcsSize > 0 && uint64(len(d.out)-d.start) != contentSize {
+		return 0, fmt.Errorf("zstd: frame size %d does not match header size %d", len(d.out)-d.start, contentSize)
+	}
+	*dst = d.out
+	return pos, nil
+}
+
+// grow checks that n more bytes of output stay within the limit.
+func (d *decoder) grow(n int) error {
+	if uint64(len(d.out))+uint64(n) > d.end {
+		return errTooLarge
+	}
+	return nil
+}
+
+// block decodes a compressed block: a literals section followed by a
+// sequences section.
+func (d *decoder) block(src []byte) error {
+	n, err := d.readLiterals(src)
+	if err != nil {
+		return err
+	}
+	return d.sequences(src[n:])
+}
+
+// readLiterals decodes the literals section into d.literals and returns its
+// size.
+func (d *decoder) readLiterals(src []byte) (int, error) {
+	if len(src) < 1 {
+		return 0, errCorrupt
+	}
+	typ := src[0] & 3
+	sizeFormat := src[0] >> 2 & 3
+
+	if typ < 2 {
+		var size, hdr int
+		switch sizeFormat {
+		case 0, 2:
+			size, hdr = int(src[0]>>3), 1
+		case 1:
+			if len(src) < 2 {
+				return 0, errCorrupt
+			}
+			size, hdr = int(src[0]>>4)|int(src[1])<<4, 2
+		case 3:
+			if len(src) < 3 {
+				return 0, errCorrupt
+			}
+			size, hdr = int(src[0]>>4)|int(src[1])<<4|int(src[2])<<12, 3
+		}
+		if size > maxBlockSize {
+			return 0, errCorrupt
+		}
+		d.literals = d.literals[:0]
+		if typ == 0 {
+			if hdr+size > len(src) {
+				return 0, errCorrupt
+			}
+			d.literals = append(d.literals, src[hdr:hdr+size]...)
+			return hdr + size, nil
+		}
+		if hdr >= len(src) {
+			return 0, errCorrupt
+		}
+		for i := 0; i < size; i++ {
+			d.literals = append(d.literals, src[hdr])
+		}
+		return hdr + 1, nil
+	}
+
+	hdr, bits, streams := 3, 10, 4
+	switch sizeFormat {
+	case 0:
+		streams = 1
+	case 2:
+		hdr, bits = 4, 14
+	case 3:
+		hdr, bits = 5, 18
+	}
+	if len(src) < hdr {
+		return 0, errCorrupt
+	}
+	var v uint64
+	for i := hdr - 1; i >= 0; i-- {
+		v = v<<8 | uint64(src[i])
+	}
+	mask := uint64(1)<<bits - 1
+	regen := int(v >> 4 & mask)
+	comp := int(v >> (4 + bits) & mask)
+	if regen > maxBlockSize || hdr+comp > len(src) {
+		return 0, errCorrupt
+	}
+	data := src[hdr : hdr+comp]
+
+	if typ == 2 {
+		n, err := d.huf.read(data)
+		if err != nil {
+			return 0, err
+		}
+		data = data[n:]
+		d.hasHuf = true
+	} else if !d.hasHuf {
+		return 0, errCorrupt
+	}
+
+	d.literals = d.literals[:0]
+	if streams == 1 {
+		lits, err := d.huf.decode(d.literals, data, regen)
+		if err != nil {
+			return 0, err
+		}
+		d.literals = lits
+		return hdr + comp, nil
+	}
+
+	if len(data) < 6 {
+		return 0, errCorrupt
+	}
+	var sizes [4]int
+	sizes[0] = int(binary.LittleEndian.Uint16(data))
+	sizes[1] = int(binary.LittleEndian.Uint16(data[2:]))
+	sizes[2] = int(binary.LittleEndian.Uint16(data[4:]))
+	data = data[6:]
+	sizes[3] = len(data) - sizes[0] - sizes[1] - sizes[2]
+	if sizes[3] < 0 {
+		return 0, errCorrupt
+	}
+	per := (regen + 3) / 4
+	lits := d.literals
+	for i, size := range sizes {
+		want := per
+		if i == 3 {
+			want = regen - 3*per
+		}
+		if want < 0 {
+			return 0, errCorrupt
+		}
+		var err error
+		if lits, err = d.huf.decode(lits, data[:size], want); err != nil {
+			return 0, err
+		}
+		data = data[size:]
+	}
+	d.literals = lits
+	return hdr + comp, nil
+}
+
+// sequences decodes the sequences section and executes it, appending
+// literals and matches to the output.
+func (d *decoder) sequences(src []byte) error {
+	if len(src) < 1 {
+		return errCorrupt
+	}
+	var count, pos int
+	switch b := int(src[0]); {
+	case b < 128:
+		count, pos = b, 1
+	case b < 255:
+		if len(src) < 2 {
+			return errCorrupt
+		}
+		count, pos = (b-128)<<8|int(src[1]), 2
+	default:
+		if len(src) < 3 {
+			return errCorrupt
+		}
+		count, pos = int(src[1])|int(src[2])<<8+0x7f00, 3
+	}
+	if count == 0 {
+		if err := d.grow(len(d.literals)); err != nil {
+			return err
+		}
+		d.out = append(d.out, d.literals...)
+		return nil
+	}
+
+	if pos >= len(src) {
+		return errCorrupt
+	}
+	modes := src[pos]
+	pos++
+	if modes&3 != 0 {
+		return errCorrupt
+	}
+	tables := [3]struct {
+		t      *fseTable
+		mode   byte
+		def    []int16
+		defLog uint
+		maxSym int
+		maxLog uint
+	}{
+		{&d.llTable, modes >> 6, llDefault, 6, 35, 9},
+		{&d.ofTable, modes >> 4 & 3, ofDefault, 5, 31, 8},
+		{&d.mlTable, modes >> 2 & 3, mlDefault, 6, 52, 9},
+	}
+	for i, t := range tables {
+		switch t.mode {
+		case 0:
+			t.t.build(t.def, t.defLog)
+		case 1:
+			if pos >= len(src) || int(src[pos]) > t.maxSym {
+				return errCorrupt
+			}
+			t.t.rle(src[pos])
+			pos++
+		case 2:
+			norm, log, n, err := readNormalized(src[pos:], t.maxSym, t.maxLog)
+			if err != nil {
+				return err
+			}
+			t.t.build(norm, log)
+			pos += n
+		case 3:
+			if !d.hasTables[i] {
+				return errCorrupt
+			}
+		}
+		d.hasTables[i] = true
+	}
+
+	br, err := newBackwardReader(src[pos:])
+	if err != nil {
+		return err
+	}
+	llState := br.read(d.llTable.log)
+	ofState := br.read(d.ofTable.log)
+	mlState := br.read(d.mlTable.log)
+
+	lits := d.literals
+	for i := 0; i < count; i++ {
+		if llState >= uint64(len(d.llTable.entries)) ||
+			ofState >= uint64(len(d.ofTable.entries)) ||
+			mlState >= uint64(len(d.mlTable.entries)) {
+			return errCorrupt
+		}
+		ll := d.llTable.entries[llState]
+		of := d.ofTable.entries[ofState]
+		ml := d.mlTable.entries[mlState]
+
+		if of.symbol > 31 {
+			return errCorrupt
+		}
+		offset := uint64(1)<<of.symbol + br.read(uint(of.symbol))
+		mlCode := ml.symbol
+		if int(mlCode) >= len(mlCodes) {
+			return errCorrupt
+		}
+		matchLen := uint64(mlCodes[mlCode].base) + br.read(uint(mlCodes[mlCode].bits))
+		llCode := ll.symbol
+		if int(llCode) >= len(llCodes) {
+			return errCorrupt
+		}
+		litLen := uint64(llCodes[llCode].base) + br.read(uint(llCodes[llCode].bits))
+
+		if i != count-1 {
+			llState = uint64(ll.base) + br.read(uint(ll.bits))
+			mlState = uint64(ml.base) + br.read(uint(ml.bits))
+			ofState = uint64(of.base) + br.read(uint(of.bits))
+		}
+		if br.overflow() {
+			return errCorrupt
+		}
+
+		offset = d.repeatOffset(offset, litLen)
+
+		if litLen > uint64(len(lits)) {
+			return errCorrupt
+		}
+		if err := d.grow(int(litLen)); err != nil {
+			return err
+		}
+		d.out = append(d.out, lits[:litLen]...)
+		lits = lits[litLen:]
+
+		if offset == 0 || offset > uint64(len(d.out)-d.start) || matchLen > maxBlockSize {
+			return errCorrupt
+		}
+		if err := d.grow(int(matchLen)); err != nil {
+			return err
+		}
+		from := len(d.out) - int(offset)
+		for j := 0; j < int(matchLen); j++ {
+			d.out = append(d.out, d.out[from+j])
+		}
+	}
+	if err := d.grow(len(lits)); err != nil {
+		return err
+	}
+	d.out = append(d.out, lits...)
+	return nil
+}
+
+// repeatOffset turns an offset value into an actual offset, resolving the
+// repeat codes 1-3 and updating the repeat history.
+func (d *decoder) repeatOffset(value, litLen uint64) uint64 {
+	if value > 3 {
+		d.reps[2], d.reps[1], d.reps[0] = d.reps[1], d.reps[0], value-3
+		return d.reps[0]
+	}
+	if litLen == 0 {
+		value++
+	}
+	var offset uint64
+	switch value {
+	case 1:
+		return d.reps[0]
+	case 2:
+		offset = d.reps[1]
+		d.reps[1] = d.reps[0]
+	case 3:
+		offset = d.reps[2]
+		d.reps[2], d.reps[1] = d.reps[1], d.reps[0]
+	default:
+		offset = d.reps[0] - 1
+		d.reps[2], d.reps[1] = d.reps[1], d.reps[0]
+	}
+	d.reps[0] = offset
+	return offset
+}
+
+type lengthCode struct {
+	base uint32
+	bits uint8
+}
+
+var llCodes = [36]lengthCode{
+	{0, 0}, {1, 0}, {2, 0}, {3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0},
+	{8, 0}, {9, 0}, {10, 0}, {11, 0}, {12, 0}, {13, 0}, {14, 0}, {15, 0},
+	{16, 1}, {18, 1}, {20, 1}, {22, 1}, {24, 2}, {28, 2}, {32, 3}, {40, 3},
+	{48, 4}, {64, 6}, {128, 7}, {256, 8}, {512, 9}, {1024, 10}, {2048, 11}, {4096, 12},
+	{8192, 13}, {16384, 14}, {32768, 15}, {65536, 16},
+}
+
+var mlCodes = [53]lengthCode{
+	{3, 0}, {4, 0}, {5, 0}, {6, 0}, {7, 0}, {8, 0}, {9, 0}, {10, 0},
+	{11, 0}, {12, 0}, {13, 0}, {14, 0}, {15, 0}, {16, 0}, {17, 0}, {18, 0},
+	{19, 0}, {20, 0}, {21, 0}, {22, 0}, {23, 0}, {24, 0}, {25, 0}, {26, 0},
+	{27, 0}, {28, 0}, {29, 0}, {30, 0}, {31, 0}, {32, 0}, {33, 0}, {34, 0},
+	{35, 1}, {37, 1}, {39, 1}, {41, 1}, {43, 2}, {47, 2}, {51, 3}, {59, 3},
+	{67, 4}, {83, 4}, {99, 5}, {131, 7}, {259, 8}, {515, 9}, {1027, 10}, {2051, 11},
+	{4099, 12}, {8195, 13}, {16387, 14}, {32771, 15}, {65539, 16},
+}
+
+// Predefined distributions, used when a block selects mode 0.
+var (
+	llDefault = []int16{
+		4, 3, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 2, 1, 1, 1,
+		2, 2, 2, 2, 2, 2, 2, 2, 2, 3, 2, 1, 1, 1, 1, 1,
+		-1, -1, -1, -1,
+	}
+	mlDefault = []int16{
+		1, 4, 3, 2, 2, 2, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 1, -1, -1,
+		-1, -1, -1, -1, -1,
+	}
+	ofDefault = []int16{
+		1, 1, 1, 1, 1, 1, 2, 2, 2, 1, 1, 1, 1, 1, 1, 1,
+		1, 1, 1, 1, 1, 1, 1, 1, -1, -1, -1, -1, -1,
+	}
+)
@@ -0,0 +1,146 @@
+package zstd
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+)
+
+// frame builds a frame with a one byte content size from the given blocks.
+func frame(size int, blocks ...[]byte) []byte {
+	out := []byte{0x28, 0xb5, 0x2f, 0xfd, 0x20, byte(size)}
+	for _, b := range blocks {
+		out = append(out, b...)
+	}
+	return out
+}
+
+// block builds a block header followed by its contents. size is the
+// regenerated size for RLE blocks and the length of data otherwise.
+func block(last bool, typ, size int, data string) []byte {
+	hdr := typ<<1 | size<<3
+	if last {
+		hdr |= 1
+	}
+	return append([]byte{byte(hdr), byte(hdr >> 8), byte(hdr >> 16)}, data...)
+}
+
+func TestDecompressBlocks(t *testing.T) {
+	skippable := []byte{0x5a, 0x2a, 0x4d, 0x18, 3, 0, 0, 0, 'x', 'y', 'z'}
+
+	tests := []struct {
+		name string
+		src  []byte
+		want string
+	}{
+		{"raw", frame(5, block(true, 0, 5, "hello")), "hello"},
+		{"rle", frame(7, block(true, 1, 7, "z")), "zzzzzzz"},
+		{"empty", frame(0, block(true, 0, 0, "")), ""},
+		{"raw and rle", frame(8, block(false, 0, 3, "abc"), block(false, 1, 4, "-"), block(true, 0, 1, "d")), "abc----d"},
+		{"concatenated", append(frame(2, block(true, 0, 2, "ab")), frame(3, block(true, 1, 3, "c"))...), "abccc"},
+		{"skippable", append(skippable, frame(2, block(true, 0, 2, "ok"))...), "ok"},
+		// Compressed block with a raw literals section of "ab" and no
+		// sequences.
+		{"compressed", frame(2, block(true, 2, 4, "\x10ab\x00")), "ab"},
+		// Compressed block with an RLE literals section of three 'q's.
+		{"rle literals", frame(3, block(true, 2, 3, "\x19q\x00")), "qqq"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decompress([]byte("prefix:"), tt.src, 1<<20)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := "prefix:" + tt.want; string(got) != want {
+				t.Errorf("got %q, want %q", got, want)
+			}
+		})
+	}
+}
+
+func TestDecompressErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		src   []byte
+		limit uint64
+		want  error
+	}{
+		{"bad magic", []byte{1, 2, 3, 4}, 100, nil},
+		{"truncated", frame(5, block(true, 0, 5, "hel")), 100, errCorrupt},
+		{"reserved block", frame(1, block(true, 3, 1, "\x00")), 100, errCorrupt},
+		{"size mismatch", frame(4, block(true, 0, 3, "abc")), 100, nil},
+		{"raw over limit", frame(5, block(true, 0, 5, "hello")), 4, errTooLarge},
+		{"rle over limit", frame(200, block(true, 1, 200, "a")), 100, errTooLarge},
+		{"frames over limit", append(frame(3, block(true, 1, 3, "a")), frame(3, block(true, 1, 3, "b"))...), 5, errTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Decompress(nil, tt.src, tt.limit)
+			if err == nil {
+				t.Fatal("no error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("got error %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+// words returns the text compressed in testdata/words*.zst: n words from
+// a small vocabulary, picked by a linear congruential generator, ten to a
+// line.
+func words(n int) []byte {
+	vocab := []string{"alpha", "beta", "gamma", "delta", "epsilon", "zeta", "eta", "theta"}
+	var buf bytes.Buffer
+	x := uint32(1)
+	for i := 0; i < n; i++ {
+		x = (x*1103515245 + 12345) & 0x7fffffff
+		buf.WriteString(vocab[(x>>16)%8])
+		if i%10 == 9 {
+			buf.WriteByte('\n')
+		} else {
+			buf.WriteByte(' ')
+		}
+	}
+	return buf.Bytes()
+}
+
+// The testdata frames were written by the zstd command line tool.
+func TestDecompressFiles(t *testing.T) {
+	var fox bytes.Buffer
+	for i := 0; i < 40; i++ {
+		fmt.Fprintf(&fox, "the quick brown fox %d jumps over the lazy dog\n", i%7)
+	}
+
+	tests := []struct {
+		file string
+		want []byte
+	}{
+		// Raw literals, FSE compressed sequences and a checksum.
+		{"fox.zst", fox.Bytes()},
+		// Huffman compressed literals.
+		{"words.zst", words(600)},
+		// Two blocks, the second reusing the Huffman table of the first.
+		{"words-blocks.zst", words(40000)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			src, err := os.ReadFile("testdata/" + tt.file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := Decompress(nil, src, uint64(len(tt.want)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Equal(got, tt.want) {
+				t.Errorf("got %d bytes, want %d", len(got), len(tt.want))
+			}
+			if _, err := Decompress(nil, src, uint64(len(tt.want)-1)); !errors.Is(err, errTooLarge) {
+				t.Errorf("with a limit one byte short: got error %v, want %v", err, errTooLarge)
+			}
+		})
+	}
+}
@@ -0,0 +1,735 @@
+package elf
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Machine types for e_machine.
+const (
+	EM_NONE            = 0
+	EM_M32             = 1
+	EM_SPARC           = 2
+	EM_386             = 3
+	EM_68K             = 4
+	EM_88K             = 5
+	EM_IAMCU           = 6
+	EM_860             = 7
+	EM_MIPS            = 8
+	EM_S370            = 9
+	EM_MIPS_RS3_LE     = 10
+	EM_PARISC          = 15
+	EM_VPP500          = 17
+	EM_SPARC32PLUS     = 18
+	EM_960             = 19
+	EM_PPC             = 20
+	EM_PPC64           = 21
+	EM_S390            = 22
+	EM_SPU             = 23
+	EM_V800            = 36
+	EM_FR20            = 37
+	EM_RH32            = 38
+	EM_MCORE           = 39
+	EM_ARM             = 40
+	EM_OLD_ALPHA       = 41
+	EM_SH              = 42
+	EM_SPARCV9         = 43
+	EM_TRICORE         = 44
+	EM_ARC             = 45
+	EM_H8_300          = 46
+	EM_H8_300H         = 47
+	EM_H8S             = 48
+	EM_H8_500          = 49
+	EM_IA_64           = 50
+	EM_MIPS_X          = 51
+	EM_COLDFIRE        = 52
+	EM_68HC12          = 53
+	EM_MMA             = 54
+	EM_PCP             = 55
+	EM_NCPU            = 56
+	EM_NDR1            = 57
+	EM_STARCORE        = 58
+	EM_ME16            = 59
+	EM_ST100           = 60
+	EM_TINYJ           = 61
+	EM_X86_64          = 62
+	EM_PDSP            = 63
+	EM_PDP10           = 64
+	EM_PDP11           = 65
+	EM_FX66            = 66
+	EM_ST9PLUS         = 67
+	EM_ST7             = 68
+	EM_68HC16          = 69
+	EM_68HC11          = 70
+	EM_68HC08          = 71
+	EM_68HC05          = 72
+	EM_SVX             = 73
+	EM_ST19            = 74
+	EM_VAX             = 75
+	EM_CRIS            = 76
+	EM_JAVELIN         = 77
+	EM_FIREPATH        = 78
+	EM_ZSP             = 79
+	EM_MMIX            = 80
+	EM_HUANY           = 81
+	EM_PRISM           = 82
+	EM_AVR             = 83
+	EM_FR30            = 84
+	EM_D10V            = 85
+	EM_D30V            = 86
+	EM_V850            = 87
+	EM_M32R            = 88
+	EM_MN10300         = 89
+	EM_MN10200         = 90
+	EM_PJ              = 91
+	EM_OPENRISC        = 92
+	EM_ARC_COMPACT     = 93
+	EM_XTENSA          = 94
+	EM_VIDEOCORE       = 95
+	EM_TMM_GPP         = 96
+	EM_NS32K           = 97
+	EM_TPC             = 98
+	EM_SNP1K           = 99
+	EM_ST200           = 100
+	EM_IP2K            = 101
+	EM_MAX             = 102
+	EM_CR              = 103
+	EM_F2MC16          = 104
+	EM_MSP430          = 105
+	EM_BLACKFIN        = 106
+	EM_SE_C33          = 107
+	EM_SEP             = 108
+	EM_ARCA            = 109
+	EM_UNICORE         = 110
+	EM_EXCESS          = 111
+	EM_DXP             = 112
+	EM_ALTERA_NIOS2    = 113
+	EM_CRX             = 114
+	EM_XGATE           = 115
+	EM_C166            = 116
+	EM_M16C            = 117
+	EM_DSPIC30F        = 118
+	EM_CE              = 119
+	EM_M32C            = 120
+	EM_TSK3000         = 131
+	EM_RS08            = 132
+	EM_SHARC           = 133
+	EM_ECOG2           = 134
+	EM_SCORE           = 135
+	EM_DSP24           = 136
+	EM_VIDEOCORE3      = 137
+	EM_LATTICEMICO32   = 138
+	EM_SE_C17          = 139
+	EM_TI_C6000        = 140
+	EM_TI_C2000        = 141
+	EM_TI_C5500        = 142
+	EM_TI_ARP32        = 143
+	EM_TI_PRU          = 144
+	EM_MMDSP_PLUS      = 160
+	EM_CYPRESS_M8C     = 161
+	EM_R32C            = 162
+	EM_TRIMEDIA        = 163
+	EM_QDSP6           = 164
+	EM_8051            = 165
+	EM_STXP7X          = 166
+	EM_NDS32           = 167
+	EM_ECOG1X          = 168
+	EM_MAXQ30          = 169
+	EM_XIMO16          = 170
+	EM_MANIK           = 171
+	EM_CRAYNV2         = 172
+	EM_RX              = 173
+	EM_METAG           = 174
+	EM_MCST_ELBRUS     = 175
+	EM_ECOG16          = 176
+	EM_CR16            = 177
+	EM_ETPU            = 178
+	EM_SLE9X           = 179
+	EM_L1OM            = 180
+	EM_K1OM            = 181
+	EM_AARCH64         = 183
+	EM_AVR32           = 185
+	EM_STM8            = 186
+	EM_TILE64          = 187
+	EM_TILEPRO         = 188
+	EM_MICROBLAZE      = 189
+	EM_CUDA            = 190
+	EM_TILEGX          = 191
+	EM_CLOUDSHIELD     = 192
+	EM_COREA_1ST       = 193
+	EM_COREA_2ND       = 194
+	EM_ARC_COMPACT2    = 195
+	EM_OPEN8           = 196
+	EM_RL78            = 197
+	EM_VIDEOCORE5      = 198
+	EM_78KOR           = 199
+	EM_56800EX         = 200
+	EM_BA1             = 201
+	EM_BA2             = 202
+	EM_XCORE           = 203
+	EM_MCHP_PIC        = 204
+	EM_INTELGT         = 205
+	EM_KM32            = 210
+	EM_KMX32           = 211
+	EM_KMX16           = 212
+	EM_KMX8            = 213
+	EM_KVARC           = 214
+	EM_CDP             = 215
+	EM_COGE            = 216
+	EM_COOL            = 217
+	EM_NORC            = 218
+	EM_CSR_KALIMBA     = 219
+	EM_Z80             = 220
+	EM_VISIUM          = 221
+	EM_FT32            = 222
+	EM_MOXIE           = 223
+	EM_AMDGPU          = 224
+	EM_RISCV           = 243
+	EM_LANAI           = 244
+	EM_CEVA            = 245
+	EM_CEVA_X2         = 246
+	EM_BPF             = 247
+	EM_GRAPHCORE_IPU   = 248
+	EM_IMG1            = 249
+	EM_NFP             = 250
+	EM_VE              = 251
+	EM_CSKY            = 252
+	EM_ARC_COMPACT3_64 = 253
+	EM_MCS6502         = 254
+	EM_ARC_COMPACT3    = 255
+	EM_KVX             = 256
+	EM_65816           = 257
+	EM_LOONGARCH       = 258
+	EM_KF32            = 259
+	EM_ALPHA           = 0x9026
+)
+
+var machineNames = map[uint16]string{
+	EM_NONE:            "None",
+	EM_M32:             "WE32100",
+	EM_SPARC:           "Sparc",
+	EM_386:             "Intel 80386",
+	EM_68K:             "MC68000",
+	EM_88K:             "MC88000",
+	EM_IAMCU:           "Intel MCU",
+	EM_860:             "Intel 80860",
+	EM_MIPS:            "MIPS R3000",
+	EM_S370:            "IBM System/370",
+	EM_MIPS_RS3_LE:     "MIPS R4000 big-endian",
+	EM_PARISC:          "HPPA",
+	EM_VPP500:          "Fujitsu VPP500",
+	EM_SPARC32PLUS:     "Sparc v8+",
+	EM_960:             "Intel 80960",
+	EM_PPC:             "PowerPC",
+	EM_PPC64:           "PowerPC64",
+	EM_S390:            "IBM S/390",
+	EM_SPU:             "SPU",
+	EM_V800:            "Renesas V850 (using RH850 ABI)",
+	EM_FR20:            "Fujitsu FR20",
+	EM_RH32:            "TRW RH32",
+	EM_MCORE:           "MCORE",
+	EM_ARM:             "ARM",
+	EM_OLD_ALPHA:       "Digital Alpha (old)",
+	EM_SH:              "Renesas / SuperH SH",
+	EM_SPARCV9:         "Sparc v9",
+	EM_TRICORE:         "Siemens Tricore",
+	EM_ARC:             "ARC",
+	EM_H8_300:          "Renesas H8/300",
+	EM_H8_300H:         "Renesas H8/300H",
+	EM_H8S:             "Renesas H8S",
+	EM_H8_500:          "Renesas H8/500",
+	EM_IA_64:           "Intel IA-64",
+	EM_MIPS_X:          "Stanford MIPS-X",
+	EM_COLDFIRE:        "Motorola Coldfire",
+	EM_68HC12:          "Motorola MC68HC12 Microcontroller",
+	EM_MMA:             "Fujitsu Multimedia Accelerator",
+	EM_PCP:             "Siemens PCP",
+	EM_NCPU:            "Sony nCPU embedded RISC processor",
+	EM_NDR1:            "Denso NDR1 microprocessor",
+	EM_STARCORE:        "Motorola Star*Core processor",
+	EM_ME16:            "Toyota ME16 processor",
+	EM_ST100:           "STMicroelectronics ST100 processor",
+	EM_TINYJ:           "Advanced Logic Corp. TinyJ embedded processor",
+	EM_X86_64:          "AMD x86-64",
+	EM_PDSP:            "Sony DSP processor",
+	EM_PDP10:           "Digital Equipment Corp. PDP-10",
+	EM_PDP11:           "Digital Equipment Corp. PDP-11",
+	EM_FX66:            "Siemens FX66 microcontroller",
+	EM_ST9PLUS:         "STMicroelectronics ST9+ 8/16 bit microcontroller",
+	EM_ST7:             "STMicroelectronics ST7 8-bit microcontroller",
+	EM_68HC16:          "Motorola MC68HC16 Microcontroller",
+	EM_68HC11:          "Motorola MC68HC11 Microcontroller",
+	EM_68HC08:          "Motorola MC68HC08 Microcontroller",
+	EM_68HC05:          "Motorola MC68HC05 Microcontroller",
+	EM_SVX:             "Silicon Graphics SVx",
+	EM_ST19:            "STMicroelectronics ST19 8-bit microcontroller",
+	EM_VAX:             "Digital VAX",
+	EM_CRIS:            "Axis Communications 32-bit embedded processor",
+	EM_JAVELIN:         "Infineon Technologies 32-bit embedded cpu",
+	EM_FIREPATH:        "Element 14 64-bit DSP processor",
+	EM_ZSP:             "LSI Logic's 16-bit DSP processor",
+	EM_MMIX:            "Donald Knuth's educational 64-bit processor",
+	EM_HUANY:           "Harvard Universitys's machine-independent object format",
+	EM_PRISM:           "Vitesse Prism",
+	EM_AVR:             "Atmel AVR 8-bit microcontroller",
+	EM_FR30:            "Fujitsu FR30",
+	EM_D10V:            "d10v",
+	EM_D30V:            "d30v",
+	EM_V850:            "Renesas V850",
+	EM_M32R:            "Renesas M32R (formerly Mitsubishi M32r)",
+	EM_MN10300:         "mn10300",
+	EM_MN10200:         "mn10200",
+	EM_PJ:              "picoJava",
+	EM_OPENRISC:        "OpenRISC 1000",
+	EM_ARC_COMPACT:     "ARCompact",
+	EM_XTENSA:          "Tensilica Xtensa Processor",
+	EM_VIDEOCORE:       "Alphamosaic VideoCore processor",
+	EM_TMM_GPP:         "Thompson Multimedia General Purpose Processor",
+	EM_NS32K:           "National Semiconductor 32000 series",
+	EM_TPC:             "Tenor Network TPC processor",
+	EM_SNP1K:           "Trebia SNP 1000 processor",
+	EM_ST200:           "STMicroelectronics ST200 microcontroller",
+	EM_IP2K:            "Ubicom IP2xxx 8-bit microcontrollers",
+	EM_MAX:             "MAX Processor",
+	EM_CR:              "National Semiconductor CompactRISC",
+	EM_F2MC16:          "Fujitsu F2MC16",
+	EM_MSP430:          "Texas Instruments msp430 microcontroller",
+	EM_BLACKFIN:        "Analog Devices Blackfin",
+	EM_SE_C33:          "S1C33 Family of Seiko Epson processors",
+	EM_SEP:             "Sharp embedded microprocessor",
+	EM_ARCA:            "Arca RISC microprocessor",
+	EM_UNICORE:         "Unicore",
+	EM_EXCESS:          "eXcess 16/32/64-bit configurable embedded CPU",
+	EM_DXP:             "Icera Semiconductor Inc. Deep Execution Processor",
+	EM_ALTERA_NIOS2:    "Altera Nios II",
+	EM_CRX:             "National Semiconductor CRX microprocessor",
+	EM_XGATE:           "Motorola XGATE embedded processor",
+	EM_C166:            "Infineon Technologies xc16x",
+	EM_M16C:            "Renesas M16C series microprocessors",
+	EM_DSPIC30F:        "Microchip Technology dsPIC30F Digital Signal Controller",
+	EM_CE:              "Freescale Communication Engine RISC core",
+	EM_M32C:            "Renesas M32c",
+	EM_TSK3000:         "Altium TSK3000 core",
+	EM_RS08:            "Freescale RS08 embedded processor",
+	EM_SHARC:           "Analog Devices SHARC family",
+	EM_ECOG2:           "Cyan Technology eCOG2 microprocessor",
+	EM_SCORE:           "SUNPLUS S+Core",
+	EM_DSP24:           "New Japan Radio (NJR) 24-bit DSP Processor",
+	EM_VIDEOCORE3:      "Broadcom VideoCore III processor",
+	EM_LATTICEMICO32:   "Lattice Mico32",
+	EM_SE_C17:          "Seiko Epson C17 family",
+	EM_TI_C6000:        "Texas Instruments TMS320C6000 DSP family",
+	EM_TI_C2000:        "Texas Instruments TMS320C2000 DSP family",
+	EM_TI_C5500:        "Texas Instruments TMS320C55x DSP family",
+	EM_TI_ARP32:        "Texas Instruments Application Specific RISC Processor, 32bit fetch",
+	EM_TI_PRU:          "TI PRU I/O processor",
+	EM_MMDSP_PLUS:      "STMicroelectronics 64bit VLIW Data Signal Processor",
+	EM_CYPRESS_M8C:     "Cypress M8C microprocessor",
+	EM_R32C:            "Renesas R32C series microprocessors",
+	EM_TRIMEDIA:        "NXP Semiconductors TriMedia architecture family",
+	EM_QDSP6:           "QUALCOMM DSP6 Processor",
+	EM_8051:            "Intel 8051 and variants",
+	EM_STXP7X:          "STMicroelectronics STxP7x family",
+	EM_NDS32:           "Andes Technology compact code size embedded RISC processor family",
+	EM_ECOG1X:          "Cyan Technology eCOG1X family",
+	EM_MAXQ30:          "Dallas Semiconductor MAXQ30 Core microcontrollers",
+	EM_XIMO16:          "New Japan Radio (NJR) 16-bit DSP Processor",
+	EM_MANIK:           "M2000 Reconfigurable RISC Microprocessor",
+	EM_CRAYNV2:         "Cray Inc. NV2 vector architecture",
+	EM_RX:              "Renesas RX",
+	EM_METAG:           "Imagination Technologies Meta processor architecture",
+	EM_MCST_ELBRUS:     "MCST Elbrus general purpose hardware architecture",
+	EM_ECOG16:          "Cyan Technology eCOG16 family",
+	EM_CR16:            "Xilinx CR16",
+	EM_ETPU:            "Freescale Extended Time Processing Unit",
+	EM_SLE9X:           "Infineon Technologies SLE9X core",
+	EM_L1OM:            "Intel L1OM",
+	EM_K1OM:            "Intel K1OM",
+	EM_AARCH64:         "ARM AARCH64",
+	EM_AVR32:           "Atmel Corporation 32-bit microprocessor family",
+	EM_STM8:            "STMicroeletronics STM8 8-bit microcontroller",
+	EM_TILE64:          "Tilera TILE64 multicore architecture family",
+	EM_TILEPRO:         "Tilera TILEPro multicore architecture family",
+	EM_MICROBLAZE:      "Xilinx MicroBlaze",
+	EM_CUDA:            "NVIDIA CUDA architecture",
+	EM_TILEGX:          "Tilera TILE-Gx multicore architecture family",
+	EM_CLOUDSHIELD:     "CloudShield architecture family",
+	EM_COREA_1ST:       "KIPO-KAIST Core-A 1st generation processor family",
+	EM_COREA_2ND:       "KIPO-KAIST Core-A 2nd generation processor family",
+	EM_ARC_COMPACT2:    "ARCv2",
+	EM_OPEN8:           "Open8 8-bit RISC soft processor core",
+	EM_RL78:            "Renesas RL78",
+	EM_VIDEOCORE5:      "Broadcom VideoCore V processor",
+	EM_78KOR:           "Renesas 78K0R",
+	EM_56800EX:         "Freescale 56800EX Digital Signal Controller (DSC)",
+	EM_BA1:             "Beyond BA1 CPU architecture",
+	EM_BA2:             "Beyond BA2 CPU architecture",
+	EM_XCORE:           "XMOS xCORE processor family",
+	EM_MCHP_PIC:        "Microchip 8-bit PIC(r) family",
+	EM_INTELGT:         "Intel Graphics Technology",
+	EM_KM32:            "KM211 KM32 32-bit processor",
+	EM_KMX32:           "KM211 KMX32 32-bit processor",
+	EM_KMX16:           "KM211 KMX16 16-bit processor",
+	EM_KMX8:            "KM211 KMX8 8-bit processor",
+	EM_KVARC:           "KM211 KVARC processor",
+	EM_CDP:             "Paneve CDP architecture family",
+	EM_COGE:            "Cognitive Smart Memory Processor",
+	EM_COOL:            "Bluechip Systems CoolEngine",
+	EM_NORC:            "Nanoradio Optimized RISC",
+	EM_CSR_KALIMBA:     "CSR Kalimba architecture family",
+	EM_Z80:             "Zilog Z80",
+	EM_VISIUM:          "CDS VISIUMcore processor",
+	EM_FT32:            "FTDI Chip FT32",
+	EM_MOXIE:           "Moxie",
+	EM_AMDGPU:          "AMD GPU",
+	EM_RISCV:           "RISC-V",
+	EM_LANAI:           "Lanai 32-bit processor",
+	EM_CEVA:            "CEVA Processor Architecture Family",
+	EM_CEVA_X2:         "CEVA X2 Processor Family",
+	EM_BPF:             "Linux BPF",
+	EM_GRAPHCORE_IPU:   "Graphcore Intelligent Processing Unit",
+	EM_IMG1:            "Imagination Technologies",
+	EM_NFP:             "Netronome Flow Processor",
+	EM_VE:              "NEC Vector Engine",
+	EM_CSKY:            "C-SKY",
+	EM_ARC_COMPACT3_64: "Synopsys ARCv2.3 64-bit",
+	EM_MCS6502:         "MOS Technology MCS 6502 processor",
+	EM_ARC_COMPACT3:    "Synopsys ARCv2.3 32-bit",
+	EM_KVX:             "Kalray VLIW core of the MPPA processor family",
+	EM_65816:           "WDC 65816/65C816",
+	EM_LOONGARCH:       "LoongArch",
+	EM_KF32:            "ChipON KungFu32",
+	EM_ALPHA:           "Alpha",
+}
+
+func MachineString(m uint16) string {
+	if name, ok := machineNames[m]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", m)
+}
+
+// HeaderFlagsString decodes the processor specific e_flags of the ELF
+// header into comma separated names the way readelf does, or returns ""
+// for machines whose flags are not known.
+func HeaderFlagsString(machine uint16, flags uint32) string {
+	var parts []string
+	switch machine {
+	case EM_ARM:
+		parts = armFlags(flags)
+	case EM_MIPS, EM_MIPS_RS3_LE:
+		parts = mipsFlags(flags)
+	case EM_PPC:
+		parts = flagNames(flags, ppcFlagNames)
+	case EM_PPC64:
+		if abi := flags & EF_PPC64_ABI; abi != 0 {
+			parts = append(parts, fmt.Sprintf("abiv%d", abi))
+		}
+		if rest := flags &^ EF_PPC64_ABI; rest != 0 {
+			parts = append(parts, fmt.Sprintf("unknown flags %#x", rest))
+		}
+	case EM_SPARCV9, EM_SPARC32PLUS:
+		parts = sparcFlags(flags)
+	case EM_S390:
+		parts = flagNames(flags, s390FlagNames)
+	case EM_LOONGARCH:
+		parts = loongarchFlags(flags)
+	case EM_AVR:
+		parts = avrFlags(flags)
+	case EM_RISCV:
+		return riscvFlagsString(flags)
+	}
+	return strings.Join(parts, ", ")
+}
+
+// flagBit names a single bit of e_flags.
+type flagBit struct {
+	bit  uint32
+	name string
+}
+
+// flagNames names the bits of flags that are set. Unknown bits are
+// reported together as a hex number.
+func flagNames(flags uint32, names []flagBit) []string {
+	var parts []string
+	for _, n := range names {
+		if flags&n.bit != 0 {
+			parts = append(parts, n.name)
+			flags &^= n.bit
+		}
+	}
+	if flags != 0 {
+		parts = append(parts, fmt.Sprintf("unknown flags %#x", flags))
+	}
+	return parts
+}
+
+// ARM e_flags.
+const (
+	EF_ARM_RELEXEC        = 0x00000001
+	EF_ARM_HASENTRY       = 0x00000002
+	EF_ARM_EABIMASK       = 0xff000000
+	EF_ARM_EABI_UNKNOWN   = 0x00000000
+	EF_ARM_EABI_VER1      = 0x01000000
+	EF_ARM_EABI_VER2      = 0x02000000
+	EF_ARM_EABI_VER3      = 0x03000000
+	EF_ARM_EABI_VER4      = 0x04000000
+	EF_ARM_EABI_VER5      = 0x05000000
+	EF_ARM_BE8            = 0x00800000
+	EF_ARM_LE8            = 0x00400000
+	EF_ARM_ABI_FLOAT_SOFT = 0x00000200
+	EF_ARM_ABI_FLOAT_HARD = 0x00000400
+)
+
+// armLegacyFlagNames are the flags of objects predating the EABI, as
+// written by old GNU toolchains.
+var armLegacyFlagNames = []flagBit{
+	{0x004, "interworking enabled"},
+	{0x008, "uses APCS/26"},
+	{0x010, "uses APCS/float"},
+	{0x020, "position independent"},
+	{0x040, "8 bit structure alignment"},
+	{0x080, "uses new ABI"},
+	{0x100, "uses old ABI"},
+	{0x200, "software FP"},
+	{0x400, "VFP"},
+	{0x800, "Maverick FP"},
+}
+
+func armFlags(flags uint32) []string {
+	var parts []string
+	if flags&EF_ARM_RELEXEC != 0 {
+		parts = append(parts, "relocatable executable")
+	}
+	if flags&EF_ARM_HASENTRY != 0 {
+		parts = append(parts, "has entry point")
+	}
+	rest := flags &^ (EF_ARM_EABIMASK | EF_ARM_RELEXEC | EF_ARM_HASENTRY)
+	switch flags & EF_ARM_EABIMASK {
+	case EF_ARM_EABI_UNKNOWN:
+		parts = append(parts, "GNU EABI")
+		return append(parts, flagNames(rest, armLegacyFlagNames)...)
+	case EF_ARM_EABI_VER1:
+		parts = append(parts, "Version1 EABI")
+		return append(parts, flagNames(rest, []flagBit{{0x04, "sorted symbol tables"}})...)
+	case EF_ARM_EABI_VER2:
+		parts = append(parts, "Version2 EABI")
+		return append(parts, flagNames(rest, []flagBit{
+			{0x04, "sorted symbol tables"},
+			{0x08, "dynamic symbols use segment index"},
+			{0x10, "mapping symbols precede others"},
+		})...)
+	case EF_ARM_EABI_VER3:
+		parts = append(parts, "Version3 EABI")
+	case EF_ARM_EABI_VER4:
+		parts = append(parts, "Version4 EABI")
+	case EF_ARM_EABI_VER5:
+		parts = append(parts, "Version5 EABI")
+	default:
+		return append(parts, fmt.Sprintf("unknown EABI %d", flags>>24))
+	}
+	return append(parts, flagNames(rest, []flagBit{
+		{EF_ARM_BE8, "BE8"},
+		{EF_ARM_LE8, "LE8"},
+		{EF_ARM_ABI_FLOAT_SOFT, "soft-float ABI"},
+		{EF_ARM_ABI_FLOAT_HARD, "hard-float ABI"},
+	})...)
+}
+
+// MIPS e_flags.
+const (
+	EF_MIPS_NOREORDER     = 0x00000001
+	EF_MIPS_PIC           = 0x00000002
+	EF_MIPS_CPIC          = 0x00000004
+	EF_MIPS_UCODE         = 0x00000010
+	EF_MIPS_ABI2          = 0x00000020
+	EF_MIPS_OPTIONS_FIRST = 0x00000080
+	EF_MIPS_32BITMODE     = 0x00000100
+	EF_MIPS_FP64          = 0x00000200
+	EF_MIPS_NAN2008       = 0x00000400
+	EF_MIPS_ABI           = 0x0000f000
+	EF_MIPS_MACH          = 0x00ff0000
+	EF_MIPS_ARCH_ASE      = 0x0f000000
+	EF_MIPS_ARCH          = 0xf0000000
+)
+
+var mipsFlagNames = []flagBit{
+	{EF_MIPS_NOREORDER, "noreorder"},
+	{EF_MIPS_PIC, "pic"},
+	{EF_MIPS_CPIC, "cpic"},
+	{EF_MIPS_UCODE, "ugen_reserved"},
+	{EF_MIPS_ABI2, "abi2"},
+	{EF_MIPS_OPTIONS_FIRST, "odk first"},
+	{EF_MIPS_32BITMODE, "32bitmode"},
+	{EF_MIPS_NAN2008, "nan2008"},
+	{EF_MIPS_FP64, "fp64"},
+}
+
+var mipsMachNames = map[uint32]string{
+	0x00810000: "3900",
+	0x00820000: "4010",
+	0x00830000: "4100",
+	0x00850000: "4650",
+	0x00870000: "4120",
+	0x00880000: "4111",
+	0x008a0000: "sb1",
+	0x008b0000: "octeon",
+	0x008c0000: "xlr",
+	0x008d0000: "octeon2",
+	0x008e0000: "octeon3",
+	0x00910000: "5400",
+	0x00920000: "5900",
+	0x00980000: "5500",
+	0x00990000: "9000",
+	0x00a00000: "loongson-2e",
+	0x00a10000: "loongson-2f",
+	0x00a20000: "gs464",
+	0x00a30000: "gs464e",
+	0x00a40000: "gs264e",
+}
+
+var mipsABINames = map[uint32]string{
+	0x1000: "o32",
+	0x2000: "o64",
+	0x3000: "eabi32",
+	0x4000: "eabi64",
+}
+
+var mipsASENames = []flagBit{
+	{0x08000000, "mdmx"},
+	{0x04000000, "mips16"},
+	{0x02000000, "micromips"},
+}
+
+var mipsArchNames = [16]string{
+	"mips1", "mips2", "mips3", "mips4", "mips5", "mips32", "mips64",
+	"mips32r2", "mips64r2", "mips32r6", "mips64r6",
+}
+
+func mipsFlags(flags uint32) []string {
+	var parts []string
+	for _, n := range mipsFlagNames {
+		if flags&n.bit != 0 {
+			parts = append(parts, n.name)
+		}
+	}
+	if mach := flags & EF_MIPS_MACH; mach != 0 {
+		if name, ok := mipsMachNames[mach]; ok {
+			parts = append(parts, name)
+		} else {
+			parts = append(parts, "unknown CPU")
+		}
+	}
+	if abi := flags & EF_MIPS_ABI; abi != 0 {
+		if name, ok := mipsABINames[abi]; ok {
+			parts = append(parts, name)
+		} else {
+			parts = append(parts, "unknown ABI")
+		}
+	}
+	for _, n := range mipsASENames {
+		if flags&n.bit != 0 {
+			parts = append(parts, n.name)
+		}
+	}
+	if name := mipsArchNames[flags>>28]; name != "" {
+		parts = append(parts, name)
+	} else {
+		parts = append(parts, "unknown ISA")
+	}
+	return parts
+}
+
+// PowerPC e_flags.
+const (
+	EF_PPC_EMB             = 0x80000000
+	EF_PPC_RELOCATABLE     = 0x00010000
+	EF_PPC_RELOCATABLE_LIB = 0x00008000
+	EF_PPC64_ABI           = 0x3
+)
+
+var ppcFlagNames = []flagBit{
+	{EF_PPC_EMB, "emb"},
+	{EF_PPC_RELOCATABLE, "relocatable"},
+	{EF_PPC_RELOCATABLE_LIB, "relocatable-lib"},
+}
+
+// SPARC e_flags.
+const (
+	EF_SPARCV9_MM     = 0x3
+	EF_SPARC_32PLUS   = 0x100
+	EF_SPARC_SUN_US1  = 0x200
+	EF_SPARC_HAL_R1   = 0x400
+	EF_SPARC_SUN_US3  = 0x800
+	EF_SPARC_LEDATA   = 0x800000
+	EF_SPARC_EXT_MASK = 0xffff00
+)
+
+func sparcFlags(flags uint32) []string {
+	parts := []string{[4]string{"tso", "pso", "rmo", "unknown memory model"}[flags&EF_SPARCV9_MM]}
+	return append(parts, flagNames(flags&^EF_SPARCV9_MM, []flagBit{
+		{EF_SPARC_32PLUS, "v8+"},
+		{EF_SPARC_SUN_US1, "ultrasparcI"},
+		{EF_SPARC_HAL_R1, "halr1"},
+		{EF_SPARC_SUN_US3, "ultrasparcIII"},
+		{EF_SPARC_LEDATA, "little endian data"},
+	})...)
+}
+
+// EF_S390_HIGH_GPRS marks 31-bit code that uses the upper halves of the
+// 64-bit registers.
+const EF_S390_HIGH_GPRS = 0x1
+
+var s390FlagNames = []flagBit{
+	{EF_S390_HIGH_GPRS, "highgprs"},
+}
+
+// LoongArch e_flags.
+const (
+	EF_LOONGARCH_ABI_MODIFIER_MASK = 0x07
+	EF_LOONGARCH_OBJABI_MASK       = 0xc0
+)
+
+func loongarchFlags(flags uint32) []string {
+	var parts []string
+	switch flags & EF_LOONGARCH_ABI_MODIFIER_MASK {
+	case 1:
+		parts = append(parts, "SOFT-FLOAT")
+	case 2:
+		parts = append(parts, "SINGLE-FLOAT")
+	case 3:
+		parts = append(parts, "DOUBLE-FLOAT")
+	default:
+		parts = append(parts, "unknown ABI modifier")
+	}
+	parts = append(parts, fmt.Sprintf("OBJ-v%d", flags&EF_LOONGARCH_OBJABI_MASK>>6))
+	if rest := flags &^ (EF_LOONGARCH_ABI_MODIFIER_MASK | EF_LOONGARCH_OBJABI_MASK); rest != 0 {
+		parts = append(parts, fmt.Sprintf("unknown flags %#x", rest))
+	}
+	return parts
+}
+
+// AVR e_flags.
+const (
+	EF_AVR_MACH               = 0x7f
+	EF_AVR_LINKRELAX_PREPARED = 0x80
+)
+
+func avrFlags(flags uint32) []string {
+	var parts []string
+	switch mach := flags & EF_AVR_MACH; {
+	case mach == 100:
+		parts = append(parts, "avrtiny")
+	case mach > 100 && mach <= 107:
+		parts = append(parts, fmt.Sprintf("xmega:%d", mach-100))
+	case mach != 0:
+		parts = append(parts, fmt.Sprintf("avr:%d", mach))
+	}
+	if flags&EF_AVR_LINKRELAX_PREPARED != 0 {
+		parts = append(parts, "link-relax")
+	}
+	if rest := flags &^ (EF_AVR_MACH | EF_AVR_LINKRELAX_PREPARED); rest != 0 {
+		parts = append(parts, fmt.Sprintf("unknown flags %#x", rest))
+	}
+	return parts
+}
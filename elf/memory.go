@@ -0,0 +1,154 @@
+package elf
+
+import (
+	"fmt"
+	"io"
+)
+
+// MaxRead is the most ReadAt reads at once. It keeps a length given by
+// the user, or the memory size of a segment, from forcing a huge
+// allocation for zero fill.
+const MaxRead = 64 << 20
+
+// ReadAt reads n bytes of the memory image at the virtual address vaddr,
+// as the PT_LOAD segments lay it out. Memory past the file contents of a
+// segment reads as zeros, like the .bss of a program, except in core
+// files: there it stands for pages the kernel did not dump, such as
+// unmodified code, which are read from the mapped file instead when its
+// contents were given to AttachMappedFile.
+//
+// If ReadAt cannot read all n bytes, it returns those before the first
+// unreadable address along with an error. Reads are limited to MaxRead
+// bytes.
+func (f *File) ReadAt(vaddr, n uint64) ([]byte, error) {
+	if n > MaxRead {
+		return nil, fmt.Errorf("cannot read %d bytes, the limit is %d", n, MaxRead)
+	}
+	out := make([]byte, 0, min(n, 1<<20))
+	for addr := vaddr; uint64(len(out)) < n; {
+		chunk, err := f.readSegment(addr, n-uint64(len(out)))
+		if err != nil {
+			return out, err
+		}
+		out = append(out, chunk...)
+		addr += uint64(len(chunk))
+	}
+	return out, nil
+}
+
+// readSegment reads up to n bytes at addr from the segment containing it.
+func (f *File) readSegment(addr, n uint64) ([]byte, error) {
+	for _, ph := range f.ProgramHeaders {
+		if ph.Type != PT_LOAD || addr < ph.VAddr || addr-ph.VAddr >= ph.MemSz {
+			continue
+		}
+		off := addr - ph.VAddr
+		n = min(n, ph.MemSz-off)
+		switch {
+		case off < ph.FileSz:
+			start := ph.Offset + off
+			end := start + min(n, ph.FileSz-off)
+			if end > uint64(len(f.Raw)) || end < start {
+				return nil, fmt.Errorf("memory at %#x is beyond the end of the file", addr)
+			}
+			return f.Raw[start:end], nil
+		case f.Type != ET_CORE:
+			return make([]byte, n), nil
+		}
+		return f.readMappedFile(addr, n)
+	}
+	return nil, fmt.Errorf("address %#x is not mapped", addr)
+}
+
+// AttachMappedFile supplies the contents of a file the process of a core
+// file had mapped, as NT_FILE names it, for ReadAt to read the pages the
+// core leaves out.
+func (f *File) AttachMappedFile(path string, data []byte) {
+	if f.attached == nil {
+		f.attached = make(map[string][]byte)
+	}
+	f.attached[path] = data
+}
+
+func (f *File) readMappedFile(addr, n uint64) ([]byte, error) {
+	for _, m := range f.fileMappings() {
+		if addr < m.Start || addr >= m.End {
+			continue
+		}
+		data, ok := f.attached[m.Path]
+		if !ok {
+			return nil, fmt.Errorf("memory at %#x is not in the core file but in %s", addr, m.Path)
+		}
+		pos := m.Offset + addr - m.Start
+		if pos >= uint64(len(data)) {
+			return nil, fmt.Errorf("memory at %#x is beyond the end of %s", addr, m.Path)
+		}
+		return data[pos : pos+min(n, m.End-addr, uint64(len(data))-pos)], nil
+	}
+	return nil, fmt.Errorf("memory at %#x is not in the core file", addr)
+}
+
+func (f *File) fileMappings() []MappedFile {
+	for i := range f.Notes {
+		n := &f.Notes[i]
+		if n.Name == "CORE" && n.Type == NT_FILE {
+			files, _ := f.mappedFiles(n.Desc)
+			return files
+		}
+	}
+	return nil
+}
+
+// Executable returns the path of the program the process ran, the mapped
+// file that holds its entry point.
+func (c *CoreDump) Executable() string {
+	for _, e := range c.Auxv {
+		if e.Type != AT_ENTRY {
+			continue
+		}
+		for _, m := range c.Files {
+			if e.Value >= m.Start && e.Value < m.End {
+				return m.Path
+			}
+		}
+	}
+	return ""
+}
+
+// DisplayMemory dumps n bytes of memory at vaddr. What could be read is
+// dumped even when ReadAt fails part way.
+func (f *File) DisplayMemory(w io.Writer, vaddr, n uint64) error {
+	data, err := f.ReadAt(vaddr, n)
+	fmt.Fprintf(w, "\nHex dump of memory at %#x:\n", vaddr)
+	writeHexLines(w, vaddr, data)
+	return err
+}
+
+// writeHexLines dumps data, which starts at addr, 16 bytes to a line.
+func writeHexLines(w io.Writer, addr uint64, data []byte) {
+	for i := 0; i < len(data); i += 16 {
+		fmt.Fprintf(w, "  0x%08x ", addr+uint64(i))
+
+		for j := 0; j < 16; j++ {
+			if i+j < len(data) {
+				fmt.Fprintf(w, "%02x", data[i+j])
+			} else {
+				fmt.Fprintf(w, "  ")
+			}
+			if j%4 == 3 {
+				fmt.Fprintf(w, " ")
+			}
+		}
+
+		fmt.Fprintf(w, " ")
+		for j := 0; j < 16 && i+j < len(data); j++ {
+			c := data[i+j]
+			if c >= 32 && c < 127 {
+				fmt.Fprintf(w, "%c", c)
+			} else {
+				fmt.Fprintf(w, ".")
+			}
+		}
+		fmt.Fprintf(w, "\n")
+	}
+}
@@ -0,0 +1,409 @@
+package elf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// Note types for the "GNU" owner.
+const (
+	NT_GNU_ABI_TAG         = 1
+	NT_GNU_HWCAP           = 2
+	NT_GNU_BUILD_ID        = 3
+	NT_GNU_GOLD_VERSION    = 4
+	NT_GNU_PROPERTY_TYPE_0 = 5
+)
+
+// Note types for the "Go" and "FDO" owners.
+const (
+	NT_GO_BUILD_ID            = 4
+	NT_FDO_PACKAGING_METADATA = 0xcafe1a7e
+)
+
+// Note types for the "CORE" and "LINUX" owners found in core files.
+const (
+	NT_PRSTATUS     = 1
+	NT_FPREGSET     = 2
+	NT_PRPSINFO     = 3
+	NT_TASKSTRUCT   = 4
+	NT_AUXV         = 6
+	NT_PRXFPREG     = 0x46e62b7f
+	NT_X86_XSTATE   = 0x202
+	NT_ARM_VFP      = 0x400
+	NT_ARM_TLS      = 0x401
+	NT_ARM_HW_BREAK = 0x402
+	NT_ARM_HW_WATCH = 0x403
+	NT_ARM_SVE      = 0x405
+	NT_ARM_PAC_MASK = 0x406
+	NT_SIGINFO      = 0x53494749
+	NT_FILE         = 0x46494c45
+)
+
+// GNU property types carried in NT_GNU_PROPERTY_TYPE_0 notes.
+const (
+	GNU_PROPERTY_STACK_SIZE            = 1
+	GNU_PROPERTY_NO_COPY_ON_PROTECTED  = 2
+	GNU_PROPERTY_AARCH64_FEATURE_1_AND = 0xc0000000
+	GNU_PROPERTY_X86_FEATURE_1_AND     = 0xc0000002
+	GNU_PROPERTY_X86_FEATURE_2_USED    = 0xc0010001
+	GNU_PROPERTY_X86_ISA_1_NEEDED      = 0xc0008002
+	GNU_PROPERTY_X86_FEATURE_2_NEEDED  = 0xc0008001
+	GNU_PROPERTY_X86_ISA_1_USED        = 0xc0010002
+	GNU_PROPERTY_X86_FEATURE_1_IBT     = 0x1
+	GNU_PROPERTY_X86_FEATURE_1_SHSTK   = 0x2
+	GNU_PROPERTY_AARCH64_FEATURE_1_BTI = 0x1
+	GNU_PROPERTY_AARCH64_FEATURE_1_PAC = 0x2
+	GNU_PROPERTY_AARCH64_FEATURE_1_GCS = 0x4
+)
+
+// Note is a single entry of a SHT_NOTE section or PT_NOTE segment. Section
+// names where the note was found; notes read from segments use "PT_NOTE".
+type Note struct {
+	Section string
+	Name    string
+	Type    uint32
+	Desc    []byte
+}
+
+// GNUProperty is one property of an NT_GNU_PROPERTY_TYPE_0 note.
+type GNUProperty struct {
+	Type uint32
+	Data []byte
+}
+
+// parseNotes reads the notes of the SHT_NOTE sections or, without section
+// headers, of the PT_NOTE segments. Notes that cannot be read are left out
+// with a warning.
+func (f *File) parseNotes() {
+	found := false
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type != SHT_NOTE {
+			continue
+		}
+		found = true
+
+		data, err := f.GetSectionData(sh)
+		if err != nil {
+			f.Warnings = append(f.Warnings, fmt.Sprintf("note section %s skipped: %v", sh.Name, err))
+			continue
+		}
+		f.Notes = append(f.Notes, f.walkNotes(data, sh.AddrAlign, sh.Name)...)
+	}
+
+	if found {
+		return
+	}
+
+	for _, ph := range f.ProgramHeaders {
+		if ph.Type != PT_NOTE {
+			continue
+		}
+		if ph.Offset > uint64(len(f.Raw)) || ph.FileSz > uint64(len(f.Raw))-ph.Offset {
+			f.Warnings = append(f.Warnings, "note segment skipped: segment data out of bounds")
+			continue
+		}
+		f.Notes = append(f.Notes, f.walkNotes(f.Raw[ph.Offset:ph.Offset+ph.FileSz], ph.Align, "PT_NOTE")...)
+	}
+}
+
+// walkNotes splits a note area into entries. The header is always three
+// 32-bit words; name and descriptor are padded to the area's alignment,
+// which is 8 for GNU property notes and 4 for everything else.
+func (f *File) walkNotes(data []byte, align uint64, source string) []Note {
+	if align != 8 {
+		align = 4
+	}
+
+	var notes []Note
+	off := uint64(0)
+	for off+12 <= uint64(len(data)) {
+		nameSz := uint64(f.ByteOrder.Uint32(data[off:]))
+		descSz := uint64(f.ByteOrder.Uint32(data[off+4:]))
+		typ := f.ByteOrder.Uint32(data[off+8:])
+
+		nameOff := off + 12
+		descOff := alignUp(nameOff+nameSz, align)
+		end := descOff + descSz
+		if nameOff+nameSz > uint64(len(data)) || end > uint64(len(data)) {
+			break
+		}
+
+		name := string(data[nameOff : nameOff+nameSz])
+		notes = append(notes, Note{
+			Section: source,
+			Name:    strings.TrimRight(name, "\x00"),
+			Type:    typ,
+			Desc:    data[descOff:end],
+		})
+
+		off = alignUp(end, align)
+	}
+	return notes
+}
+
+func alignUp(v, align uint64) uint64 {
+	if align <= 1 {
+		return v
+	}
+	return (v + align - 1) &^ (align - 1)
+}
+
+// BuildID returns the GNU build ID as a hex string, or "" if the file
+// carries none.
+func (f *File) BuildID() string {
+	for _, n := range f.Notes {
+		if n.Name == "GNU" && n.Type == NT_GNU_BUILD_ID {
+			return hex.EncodeToString(n.Desc)
+		}
+	}
+	return ""
+}
+
+// GNUProperties decodes the properties of an NT_GNU_PROPERTY_TYPE_0 note.
+// Each property is padded to 8 bytes in ELF64 and 4 bytes in ELF32.
+func (f *File) GNUProperties(n *Note) []GNUProperty {
+	align := uint64(8)
+	if f.Class == ELFCLASS32 {
+		align = 4
+	}
+
+	var props []GNUProperty
+	off := uint64(0)
+	for off+8 <= uint64(len(n.Desc)) {
+		typ := f.ByteOrder.Uint32(n.Desc[off:])
+		size := uint64(f.ByteOrder.Uint32(n.Desc[off+4:]))
+		if off+8+size > uint64(len(n.Desc)) {
+			break
+		}
+		props = append(props, GNUProperty{Type: typ, Data: n.Desc[off+8 : off+8+size]})
+		off = alignUp(off+8+size, align)
+	}
+	return props
+}
+
+// NoteTypeString names a note type. Type numbers are only unique per
+// owner, and core files reuse small numbers for process state.
+func (f *File) NoteTypeString(n *Note) string {
+	switch {
+	case n.Name == "GNU":
+		switch n.Type {
+		case NT_GNU_ABI_TAG:
+			return "NT_GNU_ABI_TAG (ABI version tag)"
+		case NT_GNU_HWCAP:
+			return "NT_GNU_HWCAP (DSO-supplied software HWCAP info)"
+		case NT_GNU_BUILD_ID:
+			return "NT_GNU_BUILD_ID (unique build ID bitstring)"
+		case NT_GNU_GOLD_VERSION:
+			return "NT_GNU_GOLD_VERSION (gold version)"
+		case NT_GNU_PROPERTY_TYPE_0:
+			return "NT_GNU_PROPERTY_TYPE_0"
+		}
+	case n.Name == "Go" && n.Type == NT_GO_BUILD_ID:
+		return "GO BUILDID"
+	case n.Name == "FDO" && n.Type == NT_FDO_PACKAGING_METADATA:
+		return "FDO_PACKAGING_METADATA"
+	case f.Type == ET_CORE:
+		switch n.Type {
+		case NT_PRSTATUS:
+			return "NT_PRSTATUS (prstatus structure)"
+		case NT_FPREGSET:
+			return "NT_FPREGSET (floating point registers)"
+		case NT_PRPSINFO:
+			return "NT_PRPSINFO (prpsinfo structure)"
+		case NT_TASKSTRUCT:
+			return "NT_TASKSTRUCT (task structure)"
+		case NT_AUXV:
+			return "NT_AUXV (auxiliary vector)"
+		case NT_PRXFPREG:
+			return "NT_PRXFPREG (user_xfpregs structure)"
+		case NT_X86_XSTATE:
+			return "NT_X86_XSTATE (x86 XSAVE extended state)"
+		case NT_ARM_VFP:
+			return "NT_ARM_VFP (arm VFP registers)"
+		case NT_ARM_TLS:
+			return "NT_ARM_TLS (AArch TLS registers)"
+		case NT_ARM_HW_BREAK:
+			return "NT_ARM_HW_BREAK (AArch hardware breakpoint registers)"
+		case NT_ARM_HW_WATCH:
+			return "NT_ARM_HW_WATCH (AArch hardware watchpoint registers)"
+		case NT_ARM_SVE:
+			return "NT_ARM_SVE (AArch SVE registers)"
+		case NT_ARM_PAC_MASK:
+			return "NT_ARM_PAC_MASK (AArch pointer authentication code masks)"
+		case NT_SIGINFO:
+			return "NT_SIGINFO (siginfo_t data)"
+		case NT_FILE:
+			return "NT_FILE (mapped files)"
+		}
+	}
+	return fmt.Sprintf("Unknown note type: (0x%08x)", n.Type)
+}
+
+// NoteDescription decodes the descriptor of well-known notes into a
+// human-readable string. It returns "" for notes it does not understand.
+func (f *File) NoteDescription(n *Note) string {
+	switch {
+	case n.Name == "GNU" && n.Type == NT_GNU_BUILD_ID:
+		return "Build ID: " + hex.EncodeToString(n.Desc)
+	case n.Name == "GNU" && n.Type == NT_GNU_ABI_TAG:
+		return f.abiTagString(n.Desc)
+	case n.Name == "GNU" && n.Type == NT_GNU_GOLD_VERSION:
+		return "Version: " + strings.TrimRight(string(n.Desc), "\x00")
+	case n.Name == "GNU" && n.Type == NT_GNU_PROPERTY_TYPE_0:
+		var parts []string
+		for _, p := range f.GNUProperties(n) {
+			parts = append(parts, f.gnuPropertyString(p))
+		}
+		return "Properties: " + strings.Join(parts, "\n\t")
+	case n.Name == "Go" && n.Type == NT_GO_BUILD_ID:
+		return fmt.Sprintf("Go build ID: %q", strings.TrimRight(string(n.Desc), "\x00"))
+	case n.Name == "FDO" && n.Type == NT_FDO_PACKAGING_METADATA:
+		return "Packaging Metadata: " + strings.TrimRight(string(n.Desc), "\x00")
+	case n.Name == "CORE" && f.Type == ET_CORE:
+		return f.coreNoteDescription(n)
+	}
+	return ""
+}
+
+func (f *File) abiTagString(desc []byte) string {
+	if len(desc) < 16 {
+		return ""
+	}
+	var os string
+	switch f.ByteOrder.Uint32(desc) {
+	case 0:
+		os = "Linux"
+	case 1:
+		os = "Hurd"
+	case 2:
+		os = "Solaris"
+	case 3:
+		os = "FreeBSD"
+	case 4:
+		os = "NetBSD"
+	case 5:
+		os = "Syllable"
+	default:
+		os = fmt.Sprintf("Unknown (%d)", f.ByteOrder.Uint32(desc))
+	}
+	return fmt.Sprintf("OS: %s, ABI: %d.%d.%d", os,
+		f.ByteOrder.Uint32(desc[4:]), f.ByteOrder.Uint32(desc[8:]), f.ByteOrder.Uint32(desc[12:]))
+}
+
+var x86Feature1Names = []struct {
+	bit  uint64
+	name string
+}{
+	{GNU_PROPERTY_X86_FEATURE_1_IBT, "IBT"},
+	{GNU_PROPERTY_X86_FEATURE_1_SHSTK, "SHSTK"},
+}
+
+var x86Feature2Names = []struct {
+	bit  uint64
+	name string
+}{
+	{0x1, "x86"},
+	{0x2, "x87"},
+	{0x4, "MMX"},
+	{0x8, "XMM"},
+	{0x10, "YMM"},
+	{0x20, "ZMM"},
+	{0x40, "FXSR"},
+	{0x80, "XSAVE"},
+	{0x100, "XSAVEOPT"},
+	{0x200, "XSAVEC"},
+	{0x400, "TMM"},
+	{0x800, "MASK"},
+}
+
+var x86ISANames = []struct {
+	bit  uint64
+	name string
+}{
+	{0x1, "x86-64-baseline"},
+	{0x2, "x86-64-v2"},
+	{0x4, "x86-64-v3"},
+	{0x8, "x86-64-v4"},
+}
+
+var aarch64Feature1Names = []struct {
+	bit  uint64
+	name string
+}{
+	{GNU_PROPERTY_AARCH64_FEATURE_1_BTI, "BTI"},
+	{GNU_PROPERTY_AARCH64_FEATURE_1_PAC, "PAC"},
+	{GNU_PROPERTY_AARCH64_FEATURE_1_GCS, "GCS"},
+}
+
+func (f *File) gnuPropertyString(p GNUProperty) string {
+	var v uint64
+	switch len(p.Data) {
+	case 4:
+		v = uint64(f.ByteOrder.Uint32(p.Data))
+	case 8:
+		v = f.ByteOrder.Uint64(p.Data)
+	}
+
+	bits := func(label string, names []struct {
+		bit  uint64
+		name string
+	}) string {
+		if v == 0 {
+			return label + ": <None>"
+		}
+		return label + ": " + strings.ReplaceAll(decodeBits(v, names), " ", ", ")
+	}
+
+	switch p.Type {
+	case GNU_PROPERTY_STACK_SIZE:
+		return fmt.Sprintf("stack size: %#x", v)
+	case GNU_PROPERTY_NO_COPY_ON_PROTECTED:
+		return "no copy on protected"
+	}
+
+	switch f.Machine {
+	case EM_X86_64, EM_386:
+		switch p.Type {
+		case GNU_PROPERTY_X86_FEATURE_1_AND:
+			return bits("x86 feature", x86Feature1Names)
+		case GNU_PROPERTY_X86_FEATURE_2_USED:
+			return bits("x86 feature used", x86Feature2Names)
+		case GNU_PROPERTY_X86_FEATURE_2_NEEDED:
+			return bits("x86 feature needed", x86Feature2Names)
+		case GNU_PROPERTY_X86_ISA_1_USED:
+			return bits("x86 ISA used", x86ISANames)
+		case GNU_PROPERTY_X86_ISA_1_NEEDED:
+			return bits("x86 ISA needed", x86ISANames)
+		}
+	case EM_AARCH64:
+		if p.Type == GNU_PROPERTY_AARCH64_FEATURE_1_AND {
+			return bits("AArch64 feature", aarch64Feature1Names)
+		}
+	}
+	return fmt.Sprintf("<unknown type %#x data: %x>", p.Type, p.Data)
+}
+
+// GNUPropertyValue returns the value of a GNU property of the given type
+// from any NT_GNU_PROPERTY_TYPE_0 note in the file.
+func (f *File) GNUPropertyValue(typ uint32) (uint64, bool) {
+	for i := range f.Notes {
+		n := &f.Notes[i]
+		if n.Name != "GNU" || n.Type != NT_GNU_PROPERTY_TYPE_0 {
+			continue
+		}
+		for _, p := range f.GNUProperties(n) {
+			if p.Type != typ {
+				continue
+			}
+			switch len(p.Data) {
+			case 4:
+				return uint64(f.ByteOrder.Uint32(p.Data)), true
+			case 8:
+				return f.ByteOrder.Uint64(p.Data), true
+			}
+		}
+	}
+	return 0, false
+}
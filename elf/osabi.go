@@ -0,0 +1,267 @@
+package elf
+
+import "fmt"
+
+// OS/ABI identification, e_ident[EI_OSABI].
+const (
+	ELFOSABI_NONE       = 0
+	ELFOSABI_SYSV       = 0
+	ELFOSABI_HPUX       = 1
+	ELFOSABI_NETBSD     = 2
+	ELFOSABI_GNU        = 3
+	ELFOSABI_LINUX      = 3
+	ELFOSABI_HURD       = 4
+	ELFOSABI_SOLARIS    = 6
+	ELFOSABI_AIX        = 7
+	ELFOSABI_IRIX       = 8
+	ELFOSABI_FREEBSD    = 9
+	ELFOSABI_TRU64      = 10
+	ELFOSABI_MODESTO    = 11
+	ELFOSABI_OPENBSD    = 12
+	ELFOSABI_OPENVMS    = 13
+	ELFOSABI_NSK        = 14
+	ELFOSABI_AROS       = 15
+	ELFOSABI_FENIXOS    = 16
+	ELFOSABI_CLOUDABI   = 17
+	ELFOSABI_OPENVOS    = 18
+	ELFOSABI_ARM        = 97
+	ELFOSABI_STANDALONE = 255
+
+	// Values in 64-254 are assigned per machine.
+	ELFOSABI_AMDGPU_HSA   = 64
+	ELFOSABI_AMDGPU_PAL   = 65
+	ELFOSABI_AMDGPU_MESA  = 66
+	ELFOSABI_C6000_ELFABI = 64
+	ELFOSABI_C6000_LINUX  = 65
+	ELFOSABI_ARM_FDPIC    = 65
+)
+
+var osabiNames = map[uint8]string{
+	ELFOSABI_SYSV:       "UNIX - System V",
+	ELFOSABI_HPUX:       "UNIX - HP-UX",
+	ELFOSABI_NETBSD:     "UNIX - NetBSD",
+	ELFOSABI_GNU:        "UNIX - GNU",
+	ELFOSABI_HURD:       "GNU/Hurd",
+	ELFOSABI_SOLARIS:    "UNIX - Solaris",
+	ELFOSABI_AIX:        "UNIX - AIX",
+	ELFOSABI_IRIX:       "UNIX - IRIX",
+	ELFOSABI_FREEBSD:    "UNIX - FreeBSD",
+	ELFOSABI_TRU64:      "UNIX - TRU64",
+	ELFOSABI_MODESTO:    "Novell - Modesto",
+	ELFOSABI_OPENBSD:    "UNIX - OpenBSD",
+	ELFOSABI_OPENVMS:    "VMS - OpenVMS",
+	ELFOSABI_NSK:        "HP - Non-Stop Kernel",
+	ELFOSABI_AROS:       "AROS",
+	ELFOSABI_FENIXOS:    "FenixOS",
+	ELFOSABI_CLOUDABI:   "Nuxi CloudABI",
+	ELFOSABI_OPENVOS:    "Stratus Technologies OpenVOS",
+	ELFOSABI_ARM:        "ARM",
+	ELFOSABI_STANDALONE: "Standalone App",
+}
+
+// OSABIString returns the name of an OS/ABI value. Values from 64 up are
+// reserved for the architecture, so machine is needed to decode them.
+func OSABIString(osabi uint8, machine uint16) string {
+	if osabi >= 64 {
+		switch machine {
+		case EM_AMDGPU:
+			switch osabi {
+			case ELFOSABI_AMDGPU_HSA:
+				return "AMD HSA"
+			case ELFOSABI_AMDGPU_PAL:
+				return "AMD PAL"
+			case ELFOSABI_AMDGPU_MESA:
+				return "AMD Mesa"
+			}
+		case EM_ARM:
+			if osabi == ELFOSABI_ARM_FDPIC {
+				return "ARM FDPIC"
+			}
+		case EM_TI_C6000:
+			switch osabi {
+			case ELFOSABI_C6000_ELFABI:
+				return "Bare-metal C6000"
+			case ELFOSABI_C6000_LINUX:
+				return "Linux C6000"
+			}
+		}
+	}
+	if name, ok := osabiNames[osabi]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", osabi)
+}
+
+// Section types in the OS specific range.
+const (
+	SHT_GNU_INCREMENTAL_INPUTS = 0x6fff4700
+	SHT_GNU_SFRAME             = 0x6ffffff4
+	SHT_GNU_ATTRIBUTES         = 0x6ffffff5
+	SHT_GNU_HASH               = 0x6ffffff6
+	SHT_GNU_LIBLIST            = 0x6ffffff7
+	SHT_CHECKSUM               = 0x6ffffff8
+	SHT_ANDROID_REL            = 0x60000001
+	SHT_ANDROID_RELA           = 0x60000002
+	SHT_ANDROID_RELR           = 0x6fffff00
+
+	SHT_LLVM_ODRTAB              = 0x6fff4c00
+	SHT_LLVM_LINKER_OPTIONS      = 0x6fff4c01
+	SHT_LLVM_ADDRSIG             = 0x6fff4c03
+	SHT_LLVM_DEPENDENT_LIBRARIES = 0x6fff4c04
+	SHT_LLVM_SYMPART             = 0x6fff4c05
+	SHT_LLVM_PART_EHDR           = 0x6fff4c06
+	SHT_LLVM_PART_PHDR           = 0x6fff4c07
+	SHT_LLVM_BB_ADDR_MAP_V0      = 0x6fff4c08
+	SHT_LLVM_CALL_GRAPH_PROFILE  = 0x6fff4c09
+	SHT_LLVM_BB_ADDR_MAP         = 0x6fff4c0a
+	SHT_LLVM_OFFLOADING          = 0x6fff4c0b
+	SHT_LLVM_LTO                 = 0x6fff4c0c
+
+	SHT_SUNW_ancillary = 0x6fffffee
+	SHT_SUNW_capchain  = 0x6fffffef
+	SHT_SUNW_capinfo   = 0x6ffffff0
+	SHT_SUNW_symsort   = 0x6ffffff1
+	SHT_SUNW_tlssort   = 0x6ffffff2
+	SHT_SUNW_LDYNSYM   = 0x6ffffff3
+	SHT_SUNW_dof       = 0x6ffffff4
+	SHT_SUNW_cap       = 0x6ffffff5
+	SHT_SUNW_SIGNATURE = 0x6ffffff6
+	SHT_SUNW_ANNOTATE  = 0x6ffffff7
+	SHT_SUNW_DEBUGSTR  = 0x6ffffff8
+	SHT_SUNW_DEBUG     = 0x6ffffff9
+	SHT_SUNW_move      = 0x6ffffffa
+	SHT_SUNW_COMDAT    = 0x6ffffffb
+	SHT_SUNW_syminfo   = 0x6ffffffc
+	SHT_SUNW_verdef    = 0x6ffffffd
+	SHT_SUNW_verneed   = 0x6ffffffe
+	SHT_SUNW_versym    = 0x6fffffff
+)
+
+// Segment types in the OS specific range.
+const (
+	PT_GNU_EH_FRAME = 0x6474e550
+	PT_GNU_STACK    = 0x6474e551
+	PT_GNU_RELRO    = 0x6474e552
+	PT_GNU_PROPERTY = 0x6474e553
+	PT_GNU_SFRAME   = 0x6474e554
+	PT_GNU_MBIND_LO = 0x6474e555
+	PT_GNU_MBIND_HI = 0x6474f554
+
+	PT_SUNW_UNWIND   = 0x6464e550
+	PT_SUNW_EH_FRAME = 0x6474e550
+	PT_SUNWBSS       = 0x6ffffffa
+	PT_SUNWSTACK     = 0x6ffffffb
+	PT_SUNWDTRACE    = 0x6ffffffc
+	PT_SUNWCAP       = 0x6ffffffd
+
+	PT_OPENBSD_MUTABLE   = 0x65a3dbe5
+	PT_OPENBSD_RANDOMIZE = 0x65a3dbe6
+	PT_OPENBSD_WXNEEDED  = 0x65a3dbe7
+	PT_OPENBSD_NOBTCFI   = 0x65a3dbe8
+	PT_OPENBSD_SYSCALLS  = 0x65a3dbe9
+	PT_OPENBSD_BOOTDATA  = 0x65a41be6
+)
+
+// The GNU assignments are shared by Linux, the BSDs and bare-metal
+// toolchains, so they are used for any OS/ABI that does not define its own.
+var gnuSectionTypes = map[uint32]string{
+	SHT_ANDROID_REL:              "ANDROID_REL",
+	SHT_ANDROID_RELA:             "ANDROID_RELA",
+	SHT_ANDROID_RELR:             "ANDROID_RELR",
+	SHT_GNU_INCREMENTAL_INPUTS:   "GNU_INCREMENTAL_INPUTS",
+	SHT_LLVM_ODRTAB:              "LLVM_ODRTAB",
+	SHT_LLVM_LINKER_OPTIONS:      "LLVM_LINKER_OPTIONS",
+	SHT_LLVM_ADDRSIG:             "LLVM_ADDRSIG",
+	SHT_LLVM_DEPENDENT_LIBRARIES: "LLVM_DEPENDENT_LIBRARIES",
+	SHT_LLVM_SYMPART:             "LLVM_SYMPART",
+	SHT_LLVM_PART_EHDR:           "LLVM_PART_EHDR",
+	SHT_LLVM_PART_PHDR:           "LLVM_PART_PHDR",
+	SHT_LLVM_BB_ADDR_MAP_V0:      "LLVM_BB_ADDR_MAP_V0",
+	SHT_LLVM_CALL_GRAPH_PROFILE:  "LLVM_CALL_GRAPH_PROFILE",
+	SHT_LLVM_BB_ADDR_MAP:         "LLVM_BB_ADDR_MAP",
+	SHT_LLVM_OFFLOADING:          "LLVM_OFFLOADING",
+	SHT_LLVM_LTO:                 "LLVM_LTO",
+	SHT_GNU_SFRAME:               "GNU_SFRAME",
+	SHT_GNU_ATTRIBUTES:           "GNU_ATTRIBUTES",
+	SHT_GNU_HASH:                 "GNU_HASH",
+	SHT_GNU_LIBLIST:              "GNU_LIBLIST",
+	SHT_CHECKSUM:                 "CHECKSUM",
+	SHT_GNU_verdef:               "VERDEF",
+	SHT_GNU_verneed:              "VERNEED",
+	SHT_GNU_versym:               "VERSYM",
+}
+
+var solarisSectionTypes = map[uint32]string{
+	SHT_SUNW_ancillary: "SUNW_ancillary",
+	SHT_SUNW_capchain:  "SUNW_capchain",
+	SHT_SUNW_capinfo:   "SUNW_capinfo",
+	SHT_SUNW_symsort:   "SUNW_symsort",
+	SHT_SUNW_tlssort:   "SUNW_tlssort",
+	SHT_SUNW_LDYNSYM:   "SUNW_LDYNSYM",
+	SHT_SUNW_dof:       "SUNW_dof",
+	SHT_SUNW_cap:       "SUNW_cap",
+	SHT_SUNW_SIGNATURE: "SUNW_SIGNATURE",
+	SHT_SUNW_ANNOTATE:  "SUNW_ANNOTATE",
+	SHT_SUNW_DEBUGSTR:  "SUNW_DEBUGSTR",
+	SHT_SUNW_DEBUG:     "SUNW_DEBUG",
+	SHT_SUNW_move:      "SUNW_move",
+	SHT_SUNW_COMDAT:    "SUNW_COMDAT",
+	SHT_SUNW_syminfo:   "SUNW_syminfo",
+	SHT_SUNW_verdef:    "SUNW_verdef",
+	SHT_SUNW_verneed:   "SUNW_verneed",
+	SHT_SUNW_versym:    "SUNW_versym",
+}
+
+var gnuSegmentTypes = map[uint32]string{
+	PT_GNU_EH_FRAME: "GNU_EH_FRAME",
+	PT_GNU_STACK:    "GNU_STACK",
+	PT_GNU_RELRO:    "GNU_RELRO",
+	PT_GNU_PROPERTY: "GNU_PROPERTY",
+	PT_GNU_SFRAME:   "GNU_SFRAME",
+}
+
+var solarisSegmentTypes = map[uint32]string{
+	PT_SUNW_UNWIND:   "SUNW_UNWIND",
+	PT_SUNW_EH_FRAME: "SUNW_EH_FRAME",
+	PT_SUNWBSS:       "SUNWBSS",
+	PT_SUNWSTACK:     "SUNWSTACK",
+	PT_SUNWDTRACE:    "SUNWDTRACE",
+	PT_SUNWCAP:       "SUNWCAP",
+}
+
+var openbsdSegmentTypes = map[uint32]string{
+	PT_OPENBSD_MUTABLE:   "OPENBSD_MUTABLE",
+	PT_OPENBSD_RANDOMIZE: "OPENBSD_RANDOMIZE",
+	PT_OPENBSD_WXNEEDED:  "OPENBSD_WXNEEDED",
+	PT_OPENBSD_NOBTCFI:   "OPENBSD_NOBTCFI",
+	PT_OPENBSD_SYSCALLS:  "OPENBSD_SYSCALLS",
+	PT_OPENBSD_BOOTDATA:  "OPENBSD_BOOTDATA",
+}
+
+func osSectionType(osabi uint8, t uint32) (string, bool) {
+	if osabi == ELFOSABI_SOLARIS {
+		name, ok := solarisSectionTypes[t]
+		return name, ok
+	}
+	name, ok := gnuSectionTypes[t]
+	return name, ok
+}
+
+func osSegmentType(osabi uint8, t uint32) (string, bool) {
+	switch osabi {
+	case ELFOSABI_SOLARIS:
+		name, ok := solarisSegmentTypes[t]
+		return name, ok
+	case ELFOSABI_OPENBSD:
+		if name, ok := openbsdSegmentTypes[t]; ok {
+			return name, true
+		}
+	}
+	if name, ok := gnuSegmentTypes[t]; ok {
+		return name, true
+	}
+	if t >= PT_GNU_MBIND_LO && t <= PT_GNU_MBIND_HI {
+		return fmt.Sprintf("GNU_MBIND+%#x", t-PT_GNU_MBIND_LO), true
+	}
+	return "", false
+}
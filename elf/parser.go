@@ -49,10 +49,20 @@ func Parse(data []byte) (*File, error) {
 		return nil, fmt.Errorf("failed to parse program headers: %w", err)
 	}
 
+	f.parseVersions()
+
 	if err := f.parseSymbols(); err != nil {
 		return nil, fmt.Errorf("failed to parse symbols: %w", err)
 	}
 
+	f.parseDynamic()
+
+	f.parseRelocations()
+
+	f.parseNotes()
+
+	f.parseAttributes()
+
 	return f, nil
 }
 
@@ -70,6 +80,7 @@ func (f *File) parseIdent(ident []byte) error {
 	f.Ident.Data = ident[EI_DATA]
 	f.Ident.Version = ident[EI_VERSION]
 	f.Ident.OSABI = ident[EI_OSABI]
+	f.Ident.ABIVersion = ident[EI_ABIVERSION]
 	f.Class = f.Ident.Class
 
 	switch f.Ident.Data {
@@ -99,16 +110,18 @@ func (f *File) parse32(data []byte) error {
 
 	f.Type = h.Type
 	f.Machine = h.Machine
+	f.Version = h.Version
 	f.Entry = uint64(h.Entry)
+	f.Flags = h.Flags
 
 	f.phoff = uint64(h.PhOff)
 	f.phentsize = uint16(h.PhEntSize)
-	f.phnum = uint16(h.PhNum)
+	f.phnum = uint32(h.PhNum)
 
 	f.shoff = uint64(h.ShOff)
 	f.shentsize = uint16(h.ShEntSize)
-	f.shnum = uint16(h.ShNum)
-	f.shstrndx = uint16(h.ShStrNdx)
+	f.shnum = uint32(h.ShNum)
+	f.shstrndx = uint32(h.ShStrNdx)
 
 	return nil
 }
@@ -128,70 +141,42 @@ func (f *File) parse64(data []byte) error {
 
 	f.Type = h.Type
 	f.Machine = h.Machine
+	f.Version = h.Version
 	f.Entry = h.Entry
+	f.Flags = h.Flags
 
 	f.phoff = h.PhOff
 	f.phentsize = h.PhEntSize
-	f.phnum = h.PhNum
+	f.phnum = uint32(h.PhNum)
 
 	f.shoff = h.ShOff
 	f.shentsize = h.ShEntSize
-	f.shnum = h.ShNum
-	f.shstrndx = h.ShStrNdx
+	f.shnum = uint32(h.ShNum)
+	f.shstrndx = uint32(h.ShStrNdx)
 
 	return nil
 }
 
 func (f *File) parseSectionHeaders() error {
-	if f.shoff == 0 || f.shnum == 0 {
+	if f.shoff == 0 {
 		return nil
 	}
 
-	f.SectionHeaders = make([]SectionHeader, f.shnum)
-
-	for i := 0; i < int(f.shnum); i++ {
-		offset := f.shoff + uint64(i)*uint64(f.shentsize)
-		if offset+uint64(f.shentsize) > uint64(len(f.Raw)) {
-			return fmt.Errorf("section header %d out of bounds", i)
-		}
+	if err := f.resolveExtendedNumbering(); err != nil {
+		return err
+	}
+	if f.shnum == 0 {
+		return nil
+	}
 
-		r := bytes.NewReader(f.Raw[offset:])
+	f.SectionHeaders = make([]SectionHeader, f.shnum)
 
-		if f.Class == ELFCLASS32 {
-			var sh SectionHeader32
-			if err := binary.Read(r, f.ByteOrder, &sh); err != nil {
-				return err
-			}
-			f.SectionHeaders[i] = SectionHeader{
-				Type:      sh.Type,
-				Flags:     uint64(sh.Flags),
-				Addr:      uint64(sh.Addr),
-				Offset:    uint64(sh.Offset),
-				Size:      uint64(sh.Size),
-				Link:      sh.Link,
-				Info:      sh.Info,
-				AddrAlign: uint64(sh.AddrAlign),
-				EntSize:   uint64(sh.EntSize),
-			}
-			f.SectionHeaders[i].nameIdx = sh.Name
-		} else {
-			var sh SectionHeader64
-			if err := binary.Read(r, f.ByteOrder, &sh); err != nil {
-				return err
-			}
-			f.SectionHeaders[i] = SectionHeader{
-				Type:      sh.Type,
-				Flags:     sh.Flags,
-				Addr:      sh.Addr,
-				Offset:    sh.Offset,
-				Size:      sh.Size,
-				Link:      sh.Link,
-				Info:      sh.Info,
-				AddrAlign: sh.AddrAlign,
-				EntSize:   sh.EntSize,
-			}
-			f.SectionHeaders[i].nameIdx = sh.Name
+	for i := range f.SectionHeaders {
+		sh, err := f.readSectionHeader(uint32(i))
+		if err != nil {
+			return err
 		}
+		f.SectionHeaders[i] = sh
 	}
 
 	if f.shstrndx < f.shnum {
@@ -209,11 +194,89 @@ func (f *File) parseSectionHeaders() error {
 	return nil
 }
 
+// resolveExtendedNumbering applies the rules for files with too many
+// sections or segments to count in the ELF header. Section 0 then holds the
+// real values: sh_size for the section count when e_shnum is 0, sh_link for
+// the string table index when e_shstrndx is SHN_XINDEX, and sh_info for
+// the segment count when e_phnum is PN_XNUM.
+func (f *File) resolveExtendedNumbering() error {
+	if f.shnum != 0 && f.shstrndx != SHN_XINDEX && f.phnum != PN_XNUM {
+		return nil
+	}
+
+	sh0, err := f.readSectionHeader(0)
+	if err != nil {
+		return err
+	}
+	if f.shnum == 0 {
+		if sh0.Size > uint64(len(f.Raw))/uint64(max(f.shentsize, 1)) {
+			return fmt.Errorf("section count %d out of bounds", sh0.Size)
+		}
+		f.shnum = uint32(sh0.Size)
+	}
+	if f.shstrndx == SHN_XINDEX {
+		f.shstrndx = sh0.Link
+	}
+	if f.phnum == PN_XNUM {
+		f.phnum = sh0.Info
+	}
+	return nil
+}
+
+func (f *File) readSectionHeader(i uint32) (SectionHeader, error) {
+	offset := f.shoff + uint64(i)*uint64(f.shentsize)
+	if offset+uint64(f.shentsize) > uint64(len(f.Raw)) {
+		return SectionHeader{}, fmt.Errorf("section header %d out of bounds", i)
+	}
+
+	r := bytes.NewReader(f.Raw[offset:])
+
+	if f.Class == ELFCLASS32 {
+		var sh SectionHeader32
+		if err := binary.Read(r, f.ByteOrder, &sh); err != nil {
+			return SectionHeader{}, err
+		}
+		return SectionHeader{
+			Type:      sh.Type,
+			Flags:     uint64(sh.Flags),
+			Addr:      uint64(sh.Addr),
+			Offset:    uint64(sh.Offset),
+			Size:      uint64(sh.Size),
+			Link:      sh.Link,
+			Info:      sh.Info,
+			AddrAlign: uint64(sh.AddrAlign),
+			EntSize:   uint64(sh.EntSize),
+			nameIdx:   sh.Name,
+		}, nil
+	}
+
+	var sh SectionHeader64
+	if err := binary.Read(r, f.ByteOrder, &sh); err != nil {
+		return SectionHeader{}, err
+	}
+	return SectionHeader{
+		Type:      sh.Type,
+		Flags:     sh.Flags,
+		Addr:      sh.Addr,
+		Offset:    sh.Offset,
+		Size:      sh.Size,
+		Link:      sh.Link,
+		Info:      sh.Info,
+		AddrAlign: sh.AddrAlign,
+		EntSize:   sh.EntSize,
+		nameIdx:   sh.Name,
+	}, nil
+}
+
 func (f *File) parseProgramHeaders() error {
 	if f.phoff == 0 || f.phnum == 0 {
 		return nil
 	}
 
+	if uint64(f.phnum) > uint64(len(f.Raw))/uint64(max(f.phentsize, 1)) {
+		return fmt.Errorf("program header count %d out of bounds", f.phnum)
+	}
+
 	f.ProgramHeaders = make([]ProgramHeader, f.phnum)
 
 	for i := 0; i < int(f.phnum); i++ {
@@ -292,79 +355,123 @@ func (f *File) GetSectionData(sh *SectionHeader) ([]byte, error) {
 }
 
 func (f *File) parseSymbols() error {
-	for _, sh := range f.SectionHeaders {
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
 		if sh.Type != SHT_SYMTAB && sh.Type != SHT_DYNSYM {
 			continue
 		}
 
-		data, err := f.GetSectionData(&sh)
+		syms, err := f.readSymbolTable(sh)
 		if err != nil {
 			return err
 		}
 
-		if sh.Link >= uint32(len(f.SectionHeaders)) {
-			continue
-		}
+		f.Symbols = append(f.Symbols, syms...)
+	}
 
-		strTab, err := f.GetSectionData(&f.SectionHeaders[sh.Link])
-		if err != nil {
-			return err
+	return nil
+}
+
+// readSymbolTable decodes every entry of a SHT_SYMTAB or SHT_DYNSYM section,
+// resolving names through the string table referenced by sh_link and
+// section indices beyond SHN_LORESERVE through its SHT_SYMTAB_SHNDX section.
+func (f *File) readSymbolTable(sh *SectionHeader) ([]Symbol, error) {
+	data, err := f.GetSectionData(sh)
+	if err != nil {
+		return nil, err
+	}
+
+	if sh.Link >= uint32(len(f.SectionHeaders)) {
+		return nil, nil
+	}
+
+	strTab, err := f.GetSectionData(&f.SectionHeaders[sh.Link])
+	if err != nil {
+		return nil, err
+	}
+
+	xindex, err := f.symtabShndx(sh)
+	if err != nil {
+		return nil, err
+	}
+
+	var symSize int
+	if f.Class == ELFCLASS32 {
+		symSize = 16
+	} else {
+		symSize = 24
+	}
+
+	var syms []Symbol
+	numSyms := int(sh.Size) / symSize
+	for i := 0; i < numSyms; i++ {
+		offset := i * symSize
+		if offset+symSize > len(data) {
+			break
 		}
 
-		var symSize int
+		r := bytes.NewReader(data[offset:])
+		var sym Symbol
+
 		if f.Class == ELFCLASS32 {
-			symSize = 16
+			var s Symbol32
+			if err := binary.Read(r, f.ByteOrder, &s); err != nil {
+				continue
+			}
+			sym = Symbol{
+				Value: uint64(s.Value),
+				Size:  uint64(s.Size),
+				Info:  s.Info,
+				Other: s.Other,
+				Shndx: s.Shndx,
+			}
+			if s.Name < uint32(len(strTab)) {
+				sym.Name = getString(strTab, s.Name)
+			}
 		} else {
-			symSize = 24
+			var s Symbol64
+			if err := binary.Read(r, f.ByteOrder, &s); err != nil {
+				continue
+			}
+			sym = Symbol{
+				Value: s.Value,
+				Size:  s.Size,
+				Info:  s.Info,
+				Other: s.Other,
+				Shndx: s.Shndx,
+			}
+			if s.Name < uint32(len(strTab)) {
+				sym.Name = getString(strTab, s.Name)
+			}
 		}
 
-		numSyms := int(sh.Size) / symSize
-		for i := 0; i < numSyms; i++ {
-			offset := i * symSize
-			if offset+symSize > len(data) {
-				break
-			}
+		if sym.Shndx == SHN_XINDEX && 4*i+4 <= len(xindex) {
+			sym.XIndex = f.ByteOrder.Uint32(xindex[4*i:])
+		}
 
-			r := bytes.NewReader(data[offset:])
-			var sym Symbol
+		syms = append(syms, sym)
+	}
 
-			if f.Class == ELFCLASS32 {
-				var s Symbol32
-				if err := binary.Read(r, f.ByteOrder, &s); err != nil {
-					continue
-				}
-				sym = Symbol{
-					Value: uint64(s.Value),
-					Size:  uint64(s.Size),
-					Info:  s.Info,
-					Other: s.Other,
-					Shndx: s.Shndx,
-				}
-				if s.Name < uint32(len(strTab)) {
-					sym.Name = getString(strTab, s.Name)
-				}
-			} else {
-				var s Symbol64
-				if err := binary.Read(r, f.ByteOrder, &s); err != nil {
-					continue
-				}
-				sym = Symbol{
-					Value: s.Value,
-					Size:  s.Size,
-					Info:  s.Info,
-					Other: s.Other,
-					Shndx: s.Shndx,
-				}
-				if s.Name < uint32(len(strTab)) {
-					sym.Name = getString(strTab, s.Name)
-				}
-			}
+	if sh.Type == SHT_DYNSYM {
+		f.applySymbolVersions(syms)
+	}
+
+	return syms, nil
+}
 
-			f.Symbols = append(f.Symbols, sym)
+// symtabShndx returns the contents of the SHT_SYMTAB_SHNDX section that
+// extends the symbol table symtab, or nil if there is none.
+func (f *File) symtabShndx(symtab *SectionHeader) ([]byte, error) {
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type != SHT_SYMTAB_SHNDX || sh.Link >= uint32(len(f.SectionHeaders)) {
+			continue
+		}
+		if &f.SectionHeaders[sh.Link] == symtab {
+			return f.GetSectionData(sh)
 		}
 	}
-
-	return nil
+	return nil, nil
 }
 
 type SectionHeader struct {
@@ -387,18 +494,35 @@ type File struct {
 	Class          uint8
 	Type           uint16
 	Machine        uint16
+	Version        uint32
 	Entry          uint64
+	Flags          uint32
 	ProgramHeaders []ProgramHeader
 	SectionHeaders []SectionHeader
 	Symbols        []Symbol
+	Dynamic        []DynamicEntry
+	Relocations    []RelocationSection
+	Notes          []Note
+	VersionDefs    []VersionDef
+	VersionNeeds   []VersionNeed
+	Attributes     []Attribute
 	StringTable    []byte
 	Raw            []byte
+
+	// Warnings describe the parts of the file that could not be parsed and
+	// were left out.
+	Warnings []string
 	
 	phoff     uint64
 	phentsize uint16
-	phnum     uint16
+	phnum     uint32
 	shoff     uint64
 	shentsize uint16
-	shnum     uint16
-	shstrndx  uint16
+	shnum     uint32
+	shstrndx  uint32
+	versym    []uint16
+	lineIndex *lineIndex
+	debugInfo *DebugInfo
+	debugInfoErr error
+	attached  map[string][]byte
 }
\ No newline at end of file
@@ -0,0 +1,94 @@
+package elf
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// The files in testdata are built from the C sources there, with the
+// commands given at the top of each source.
+func testFile(t *testing.T, name string) *File {
+	t.Helper()
+	f, err := Open(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		file     string
+		typ      uint16
+		entry    uint64
+		segments int
+		sections int
+	}{
+		{"prog", ET_EXEC, 0x4000f2, 3, 17},
+		{"prog-hardened", ET_DYN, 0x302, 9, 23},
+		{"libv1.so", ET_DYN, 0, 6, 19},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			f := testFile(t, tt.file)
+			if f.Class != ELFCLASS64 || f.Machine != EM_X86_64 {
+				t.Errorf("got class %d machine %d, want ELF64 x86-64", f.Class, f.Machine)
+			}
+			if f.Type != tt.typ || f.Entry != tt.entry {
+				t.Errorf("got type %d entry %#x, want type %d entry %#x", f.Type, f.Entry, tt.typ, tt.entry)
+			}
+			if len(f.ProgramHeaders) != tt.segments || len(f.SectionHeaders) != tt.sections {
+				t.Errorf("got %d segments and %d sections, want %d and %d",
+					len(f.ProgramHeaders), len(f.SectionHeaders), tt.segments, tt.sections)
+			}
+		})
+	}
+}
+
+func TestParseSkipsBadSections(t *testing.T) {
+	data, err := os.ReadFile("testdata/libv1.so")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f, err := Parse(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Turn .gnu.hash into a section of each type, running past the end of
+	// the file.
+	tests := []struct {
+		typ     uint32
+		machine uint16
+		warning string
+	}{
+		{SHT_RELA, EM_X86_64, "relocation section .gnu.hash skipped"},
+		{SHT_RELR, EM_X86_64, "relocation section .gnu.hash skipped"},
+		{SHT_GNU_versym, EM_X86_64, "version section .gnu.hash skipped"},
+		{SHT_GNU_verneed, EM_X86_64, "version section .gnu.hash skipped"},
+		{SHT_NOTE, EM_X86_64, "note section .gnu.hash skipped"},
+		{SHT_RISCV_ATTRIBUTES, EM_RISCV, "attributes section .gnu.hash skipped"},
+	}
+	for _, tt := range tests {
+		bad := append([]byte(nil), data...)
+		binary.LittleEndian.PutUint16(bad[0x12:], tt.machine) // e_machine
+		sh := bad[f.shoff+64:]
+		binary.LittleEndian.PutUint32(sh[0x04:], tt.typ) // sh_type
+		binary.LittleEndian.PutUint64(sh[0x20:], 1<<20)  // sh_size
+		g, err := Parse(bad)
+		if err != nil {
+			t.Errorf("%#x: %v", tt.typ, err)
+			continue
+		}
+		if len(g.Warnings) != 1 || !strings.HasPrefix(g.Warnings[0], tt.warning) {
+			t.Errorf("%#x: got warnings %q, want one starting with %q", tt.typ, g.Warnings, tt.warning)
+		}
+		if len(g.Dynamic) != len(f.Dynamic) || len(g.Symbols) != len(f.Symbols) {
+			t.Errorf("%#x: got %d dynamic entries and %d symbols, want %d and %d", tt.typ,
+				len(g.Dynamic), len(g.Symbols), len(f.Dynamic), len(f.Symbols))
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package elf
+
+// Section types in the processor specific range.
+const (
+	SHT_ARM_EXIDX          = 0x70000001
+	SHT_ARM_PREEMPTMAP     = 0x70000002
+	SHT_ARM_ATTRIBUTES     = 0x70000003
+	SHT_ARM_DEBUGOVERLAY   = 0x70000004
+	SHT_ARM_OVERLAYSECTION = 0x70000005
+
+	SHT_AARCH64_ATTRIBUTES             = 0x70000003
+	SHT_AARCH64_AUTH_RELR              = 0x70000004
+	SHT_AARCH64_MEMTAG_GLOBALS_STATIC  = 0x70000007
+	SHT_AARCH64_MEMTAG_GLOBALS_DYNAMIC = 0x70000008
+
+	SHT_X86_64_UNWIND = 0x70000001
+
+	SHT_MIPS_LIBLIST       = 0x70000000
+	SHT_MIPS_MSYM          = 0x70000001
+	SHT_MIPS_CONFLICT      = 0x70000002
+	SHT_MIPS_GPTAB         = 0x70000003
+	SHT_MIPS_UCODE         = 0x70000004
+	SHT_MIPS_DEBUG         = 0x70000005
+	SHT_MIPS_REGINFO       = 0x70000006
+	SHT_MIPS_PACKAGE       = 0x70000007
+	SHT_MIPS_PACKSYM       = 0x70000008
+	SHT_MIPS_RELD          = 0x70000009
+	SHT_MIPS_IFACE         = 0x7000000b
+	SHT_MIPS_CONTENT       = 0x7000000c
+	SHT_MIPS_OPTIONS       = 0x7000000d
+	SHT_MIPS_SHDR          = 0x70000010
+	SHT_MIPS_FDESC         = 0x70000011
+	SHT_MIPS_EXTSYM        = 0x70000012
+	SHT_MIPS_DENSE         = 0x70000013
+	SHT_MIPS_PDESC         = 0x70000014
+	SHT_MIPS_LOCSYM        = 0x70000015
+	SHT_MIPS_AUXSYM        = 0x70000016
+	SHT_MIPS_OPTSYM        = 0x70000017
+	SHT_MIPS_LOCSTR        = 0x70000018
+	SHT_MIPS_LINE          = 0x70000019
+	SHT_MIPS_RFDESC        = 0x7000001a
+	SHT_MIPS_DELTASYM      = 0x7000001b
+	SHT_MIPS_DELTAINST     = 0x7000001c
+	SHT_MIPS_DELTACLASS    = 0x7000001d
+	SHT_MIPS_DWARF         = 0x7000001e
+	SHT_MIPS_DELTADECL     = 0x7000001f
+	SHT_MIPS_SYMBOL_LIB    = 0x70000020
+	SHT_MIPS_EVENTS        = 0x70000021
+	SHT_MIPS_TRANSLATE     = 0x70000022
+	SHT_MIPS_PIXIE         = 0x70000023
+	SHT_MIPS_XLATE         = 0x70000024
+	SHT_MIPS_XLATE_DEBUG   = 0x70000025
+	SHT_MIPS_WHIRL         = 0x70000026
+	SHT_MIPS_EH_REGION     = 0x70000027
+	SHT_MIPS_XLATE_OLD     = 0x70000028
+	SHT_MIPS_PDR_EXCEPTION = 0x70000029
+	SHT_MIPS_ABIFLAGS      = 0x7000002a
+	SHT_MIPS_XHASH         = 0x7000002b
+
+	SHT_PARISC_EXT    = 0x70000000
+	SHT_PARISC_UNWIND = 0x70000001
+	SHT_PARISC_DOC    = 0x70000002
+
+	SHT_IA_64_EXT    = 0x70000000
+	SHT_IA_64_UNWIND = 0x70000001
+
+	SHT_C6000_UNWIND     = 0x70000001
+	SHT_C6000_PREEMPTMAP = 0x70000002
+	SHT_C6000_ATTRIBUTES = 0x70000003
+
+	SHT_MSP430_ATTRIBUTES = 0x70000003
+	SHT_CSKY_ATTRIBUTES   = 0x70000001
+	SHT_ARC_ATTRIBUTES    = 0x70000001
+)
+
+// Segment types in the processor specific range.
+const (
+	PT_ARM_ARCHEXT = 0x70000000
+	PT_ARM_EXIDX   = 0x70000001
+
+	PT_AARCH64_ARCHEXT    = 0x70000000
+	PT_AARCH64_MEMTAG_MTE = 0x70000002
+
+	PT_MIPS_REGINFO  = 0x70000000
+	PT_MIPS_RTPROC   = 0x70000001
+	PT_MIPS_OPTIONS  = 0x70000002
+	PT_MIPS_ABIFLAGS = 0x70000003
+
+	PT_RISCV_ATTRIBUTES = 0x70000003
+
+	PT_PARISC_ARCHEXT = 0x70000000
+	PT_PARISC_UNWIND  = 0x70000001
+
+	PT_IA_64_ARCHEXT = 0x70000000
+	PT_IA_64_UNWIND  = 0x70000001
+
+	PT_S390_PGSTE = 0x70000000
+
+	PT_C6000_PHATTR = 0x70000000
+)
+
+var mipsSectionTypes = map[uint32]string{
+	SHT_MIPS_LIBLIST:       "MIPS_LIBLIST",
+	SHT_MIPS_MSYM:          "MIPS_MSYM",
+	SHT_MIPS_CONFLICT:      "MIPS_CONFLICT",
+	SHT_MIPS_GPTAB:         "MIPS_GPTAB",
+	SHT_MIPS_UCODE:         "MIPS_UCODE",
+	SHT_MIPS_DEBUG:         "MIPS_DEBUG",
+	SHT_MIPS_REGINFO:       "MIPS_REGINFO",
+	SHT_MIPS_PACKAGE:       "MIPS_PACKAGE",
+	SHT_MIPS_PACKSYM:       "MIPS_PACKSYM",
+	SHT_MIPS_RELD:          "MIPS_RELD",
+	SHT_MIPS_IFACE:         "MIPS_IFACE",
+	SHT_MIPS_CONTENT:       "MIPS_CONTENT",
+	SHT_MIPS_OPTIONS:       "MIPS_OPTIONS",
+	SHT_MIPS_SHDR:          "MIPS_SHDR",
+	SHT_MIPS_FDESC:         "MIPS_FDESC",
+	SHT_MIPS_EXTSYM:        "MIPS_EXTSYM",
+	SHT_MIPS_DENSE:         "MIPS_DENSE",
+	SHT_MIPS_PDESC:         "MIPS_PDESC",
+	SHT_MIPS_LOCSYM:        "MIPS_LOCSYM",
+	SHT_MIPS_AUXSYM:        "MIPS_AUXSYM",
+	SHT_MIPS_OPTSYM:        "MIPS_OPTSYM",
+	SHT_MIPS_LOCSTR:        "MIPS_LOCSTR",
+	SHT_MIPS_LINE:          "MIPS_LINE",
+	SHT_MIPS_RFDESC:        "MIPS_RFDESC",
+	SHT_MIPS_DELTASYM:      "MIPS_DELTASYM",
+	SHT_MIPS_DELTAINST:     "MIPS_DELTAINST",
+	SHT_MIPS_DELTACLASS:    "MIPS_DELTACLASS",
+	SHT_MIPS_DWARF:         "MIPS_DWARF",
+	SHT_MIPS_DELTADECL:     "MIPS_DELTADECL",
+	SHT_MIPS_SYMBOL_LIB:    "MIPS_SYMBOL_LIB",
+	SHT_MIPS_EVENTS:        "MIPS_EVENTS",
+	SHT_MIPS_TRANSLATE:     "MIPS_TRANSLATE",
+	SHT_MIPS_PIXIE:         "MIPS_PIXIE",
+	SHT_MIPS_XLATE:         "MIPS_XLATE",
+	SHT_MIPS_XLATE_DEBUG:   "MIPS_XLATE_DEBUG",
+	SHT_MIPS_WHIRL:         "MIPS_WHIRL",
+	SHT_MIPS_EH_REGION:     "MIPS_EH_REGION",
+	SHT_MIPS_XLATE_OLD:     "MIPS_XLATE_OLD",
+	SHT_MIPS_PDR_EXCEPTION: "MIPS_PDR_EXCEPTION",
+	SHT_MIPS_ABIFLAGS:      "MIPS_ABIFLAGS",
+	SHT_MIPS_XHASH:         "MIPS_XHASH",
+}
+
+var x86_64SectionTypes = map[uint32]string{
+	SHT_X86_64_UNWIND: "X86_64_UNWIND",
+}
+
+var pariscSectionTypes = map[uint32]string{
+	SHT_PARISC_EXT:    "PARISC_EXT",
+	SHT_PARISC_UNWIND: "PARISC_UNWIND",
+	SHT_PARISC_DOC:    "PARISC_DOC",
+}
+
+// procSectionTypes holds the names of processor specific section types by
+// machine.
+var procSectionTypes = map[uint16]map[uint32]string{
+	EM_ARM: {
+		SHT_ARM_EXIDX:          "ARM_EXIDX",
+		SHT_ARM_PREEMPTMAP:     "ARM_PREEMPTMAP",
+		SHT_ARM_ATTRIBUTES:     "ARM_ATTRIBUTES",
+		SHT_ARM_DEBUGOVERLAY:   "ARM_DEBUGOVERLAY",
+		SHT_ARM_OVERLAYSECTION: "ARM_OVERLAYSECTION",
+	},
+	EM_AARCH64: {
+		SHT_AARCH64_ATTRIBUTES:             "AARCH64_ATTRIBUTES",
+		SHT_AARCH64_AUTH_RELR:              "AARCH64_AUTH_RELR",
+		SHT_AARCH64_MEMTAG_GLOBALS_STATIC:  "AARCH64_MEMTAG_GLOBALS_STATIC",
+		SHT_AARCH64_MEMTAG_GLOBALS_DYNAMIC: "AARCH64_MEMTAG_GLOBALS_DYNAMIC",
+	},
+	EM_X86_64:      x86_64SectionTypes,
+	EM_L1OM:        x86_64SectionTypes,
+	EM_K1OM:        x86_64SectionTypes,
+	EM_MIPS:        mipsSectionTypes,
+	EM_MIPS_RS3_LE: mipsSectionTypes,
+	EM_RISCV: {
+		SHT_RISCV_ATTRIBUTES: "RISCV_ATTRIBUTES",
+	},
+	EM_PARISC: pariscSectionTypes,
+	EM_IA_64: {
+		SHT_IA_64_EXT:    "IA_64_EXT",
+		SHT_IA_64_UNWIND: "IA_64_UNWIND",
+	},
+	EM_TI_C6000: {
+		SHT_C6000_UNWIND:     "C6000_UNWIND",
+		SHT_C6000_PREEMPTMAP: "C6000_PREEMPTMAP",
+		SHT_C6000_ATTRIBUTES: "C6000_ATTRIBUTES",
+	},
+	EM_MSP430: {
+		SHT_MSP430_ATTRIBUTES: "MSP430_ATTRIBUTES",
+	},
+	EM_CSKY: {
+		SHT_CSKY_ATTRIBUTES: "CSKY_ATTRIBUTES",
+	},
+	EM_ARC_COMPACT: {
+		SHT_ARC_ATTRIBUTES: "ARC_ATTRIBUTES",
+	},
+	EM_ARC_COMPACT2: {
+		SHT_ARC_ATTRIBUTES: "ARC_ATTRIBUTES",
+	},
+}
+
+// procSegmentTypes holds the names of processor specific segment types by
+// machine. They follow readelf, which leaves out the machine prefix for
+// the older ARM and MIPS types.
+var procSegmentTypes = map[uint16]map[uint32]string{
+	EM_ARM: {
+		PT_ARM_ARCHEXT: "ARM_ARCHEXT",
+		PT_ARM_EXIDX:   "EXIDX",
+	},
+	EM_AARCH64: {
+		PT_AARCH64_ARCHEXT:    "AARCH64_ARCHEXT",
+		PT_AARCH64_MEMTAG_MTE: "AARCH64_MEMTAG_MTE",
+	},
+	EM_MIPS: {
+		PT_MIPS_REGINFO:  "REGINFO",
+		PT_MIPS_RTPROC:   "RTPROC",
+		PT_MIPS_OPTIONS:  "OPTIONS",
+		PT_MIPS_ABIFLAGS: "ABIFLAGS",
+	},
+	EM_RISCV: {
+		PT_RISCV_ATTRIBUTES: "RISCV_ATTRIBUTES",
+	},
+	EM_PARISC: {
+		PT_PARISC_ARCHEXT: "PARISC_ARCHEXT",
+		PT_PARISC_UNWIND:  "PARISC_UNWIND",
+	},
+	EM_IA_64: {
+		PT_IA_64_ARCHEXT: "IA_64_ARCHEXT",
+		PT_IA_64_UNWIND:  "IA_64_UNWIND",
+	},
+	EM_S390: {
+		PT_S390_PGSTE: "S390_PGSTE",
+	},
+	EM_TI_C6000: {
+		PT_C6000_PHATTR: "C6000_PHATTR",
+	},
+}
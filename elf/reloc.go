@@ -0,0 +1,234 @@
+package elf
+
+import "fmt"
+
+// Relocation is a single decoded SHT_REL or SHT_RELA entry. Addend is zero
+// for SHT_REL entries, where the addend is stored at the relocated location.
+// SHT_RELR sections are expanded into one relative Relocation per address.
+type Relocation struct {
+	Offset   uint64
+	Info     uint64
+	Type     uint32
+	Sym      uint32
+	Addend   int64
+	SymName  string
+	SymValue uint64
+}
+
+// RelocationSection holds the entries of one relocation section. Target is
+// the index of the section the relocations apply to (sh_info, 0 for dynamic
+// relocations) and SymbolTable the index of the symbol table used to
+// resolve symbol references (sh_link).
+type RelocationSection struct {
+	Name        string
+	Type        uint32
+	Index       int
+	Offset      uint64
+	Target      uint32
+	SymbolTable uint32
+	Entries     []Relocation
+}
+
+func (rs *RelocationSection) HasAddend() bool {
+	return rs.Type == SHT_RELA
+}
+
+// parseRelocations reads the relocation sections. A section that cannot be
+// read is left out with a warning.
+func (f *File) parseRelocations() {
+	symTabs := make(map[uint32][]Symbol)
+	hasRelr := false
+
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type != SHT_REL && sh.Type != SHT_RELA && sh.Type != SHT_RELR {
+			continue
+		}
+
+		data, err := f.GetSectionData(sh)
+		if err != nil {
+			f.Warnings = append(f.Warnings, fmt.Sprintf("relocation section %s skipped: %v", sh.Name, err))
+			continue
+		}
+
+		if sh.Type == SHT_RELR {
+			hasRelr = true
+			f.Relocations = append(f.Relocations, RelocationSection{
+				Name:    sh.Name,
+				Type:    sh.Type,
+				Index:   i,
+				Offset:  sh.Offset,
+				Entries: f.decodeRelr(data),
+			})
+			continue
+		}
+
+		var syms []Symbol
+		if sh.Link != 0 && sh.Link < uint32(len(f.SectionHeaders)) {
+			var ok bool
+			if syms, ok = symTabs[sh.Link]; !ok {
+				if syms, err = f.readSymbolTable(&f.SectionHeaders[sh.Link]); err != nil {
+					f.Warnings = append(f.Warnings, fmt.Sprintf("relocation section %s skipped: %v", sh.Name, err))
+					continue
+				}
+				symTabs[sh.Link] = syms
+			}
+		}
+
+		rs := RelocationSection{
+			Name:        sh.Name,
+			Type:        sh.Type,
+			Index:       i,
+			Offset:      sh.Offset,
+			Target:      sh.Info,
+			SymbolTable: sh.Link,
+		}
+
+		entSize := f.relocEntrySize(sh.Type)
+		for off := 0; off+entSize <= len(data); off += entSize {
+			r := f.decodeRelocation(data[off:], sh.Type == SHT_RELA)
+			if r.Sym != 0 && int(r.Sym) < len(syms) {
+				sym := &syms[r.Sym]
+				r.SymName = sym.Name
+				r.SymValue = sym.Value
+				if ndx, ok := sym.SectionIndex(); ok && r.SymName == "" && sym.Type() == STT_SECTION && ndx < len(f.SectionHeaders) {
+					r.SymName = f.SectionHeaders[ndx].Name
+				}
+			}
+			rs.Entries = append(rs.Entries, r)
+		}
+
+		f.Relocations = append(f.Relocations, rs)
+	}
+
+	if !hasRelr {
+		f.parseDynamicRelr()
+	}
+}
+
+// parseDynamicRelr reads the packed relative relocations that DT_RELR and
+// DT_RELRSZ locate, for files whose section headers are stripped. Like
+// readelf -D, it names the table after its tag.
+func (f *File) parseDynamicRelr() {
+	addr, ok := f.DynamicValue(DT_RELR)
+	if !ok {
+		return
+	}
+	size, _ := f.DynamicValue(DT_RELRSZ)
+	wordSize := uint64(8)
+	if f.Class == ELFCLASS32 {
+		wordSize = 4
+	}
+	if ent, ok := f.DynamicValue(DT_RELRENT); ok && ent != wordSize {
+		return
+	}
+	off, ok := f.vaddrToOffset(addr)
+	if !ok || off > uint64(len(f.Raw)) || size > uint64(len(f.Raw))-off {
+		return
+	}
+	f.Relocations = append(f.Relocations, RelocationSection{
+		Name:    "RELR",
+		Type:    SHT_RELR,
+		Offset:  off,
+		Entries: f.decodeRelr(f.Raw[off : off+size]),
+	})
+}
+
+// decodeRelr expands a packed SHT_RELR table. An even entry is the address
+// of the next relocation; an odd entry is a bitmap whose bits 1..n-1 mark
+// which of the following n-1 words are relocated.
+func (f *File) decodeRelr(data []byte) []Relocation {
+	wordSize := 8
+	if f.Class == ELFCLASS32 {
+		wordSize = 4
+	}
+	relType := relativeRelocType(f.Machine)
+
+	var out []Relocation
+	var where uint64
+	for off := 0; off+wordSize <= len(data); off += wordSize {
+		var entry uint64
+		if f.Class == ELFCLASS32 {
+			entry = uint64(f.ByteOrder.Uint32(data[off:]))
+		} else {
+			entry = f.ByteOrder.Uint64(data[off:])
+		}
+
+		if entry&1 == 0 {
+			out = append(out, Relocation{Offset: entry, Type: relType})
+			where = entry + uint64(wordSize)
+			continue
+		}
+
+		bits := wordSize*8 - 1
+		for i := 0; i < bits; i++ {
+			if (entry>>(i+1))&1 != 0 {
+				out = append(out, Relocation{Offset: where + uint64(i*wordSize), Type: relType})
+			}
+		}
+		where += uint64(bits * wordSize)
+	}
+	return out
+}
+
+// relativeRelocType returns the machine's R_*_RELATIVE type, which is
+// what every SHT_RELR entry implies.
+func relativeRelocType(machine uint16) uint32 {
+	switch machine {
+	case EM_X86_64:
+		return 8
+	case EM_386:
+		return 8
+	case EM_ARM:
+		return 23
+	case EM_AARCH64:
+		return 1027
+	case EM_RISCV:
+		return 3
+	}
+	return 0
+}
+
+func (f *File) relocEntrySize(typ uint32) int {
+	if f.Class == ELFCLASS32 {
+		if typ == SHT_RELA {
+			return 12
+		}
+		return 8
+	}
+	if typ == SHT_RELA {
+		return 24
+	}
+	return 16
+}
+
+func (f *File) decodeRelocation(b []byte, rela bool) Relocation {
+	var r Relocation
+	if f.Class == ELFCLASS32 {
+		r.Offset = uint64(f.ByteOrder.Uint32(b))
+		r.Info = uint64(f.ByteOrder.Uint32(b[4:]))
+		r.Sym = uint32(r.Info >> 8)
+		r.Type = uint32(r.Info & 0xff)
+		if rela {
+			r.Addend = int64(int32(f.ByteOrder.Uint32(b[8:])))
+		}
+	} else {
+		r.Offset = f.ByteOrder.Uint64(b)
+		r.Info = f.ByteOrder.Uint64(b[8:])
+		r.Sym = uint32(r.Info >> 32)
+		r.Type = uint32(r.Info)
+		if rela {
+			r.Addend = int64(f.ByteOrder.Uint64(b[16:]))
+		}
+	}
+	return r
+}
+
+// RelocationTarget returns the name of the section a relocation section
+// applies to, or an empty string for dynamic relocations.
+func (f *File) RelocationTarget(rs *RelocationSection) string {
+	if rs.Target == 0 || rs.Target >= uint32(len(f.SectionHeaders)) {
+		return ""
+	}
+	return f.SectionHeaders[rs.Target].Name
+}
@@ -0,0 +1,127 @@
+package elf
+
+import (
+	"encoding/binary"
+	"os"
+	"reflect"
+	"testing"
+)
+
+// encodeRelr packs sorted, word aligned offsets into SHT_RELR entries the
+// way linkers do: an address, then bitmaps for the words following it.
+func encodeRelr(offsets []uint64, wordSize int, order binary.ByteOrder) []byte {
+	w := uint64(wordSize)
+	bits := w*8 - 1
+	var entries []uint64
+	for i := 0; i < len(offsets); {
+		entries = append(entries, offsets[i])
+		base := offsets[i] + w
+		i++
+		for {
+			var bitmap uint64
+			for ; i < len(offsets) && offsets[i]-base < bits*w; i++ {
+				bitmap |= 1 << ((offsets[i] - base) / w)
+			}
+			if bitmap == 0 {
+				break
+			}
+			entries = append(entries, bitmap<<1|1)
+			base += bits * w
+		}
+	}
+
+	out := make([]byte, len(entries)*wordSize)
+	for i, e := range entries {
+		if wordSize == 4 {
+			order.PutUint32(out[i*4:], uint32(e))
+		} else {
+			order.PutUint64(out[i*8:], e)
+		}
+	}
+	return out
+}
+
+func TestDecodeRelr(t *testing.T) {
+	run := func(start uint64, n int, stride uint64) []uint64 {
+		var out []uint64
+		for i := 0; i < n; i++ {
+			out = append(out, start+uint64(i)*stride)
+		}
+		return out
+	}
+
+	tests := []struct {
+		name    string
+		class   uint8
+		order   binary.ByteOrder
+		machine uint16
+		offsets []uint64
+	}{
+		{"single", ELFCLASS64, binary.LittleEndian, EM_X86_64, []uint64{0x3de8}},
+		{"run", ELFCLASS64, binary.LittleEndian, EM_X86_64, run(0x4000, 10, 8)},
+		{"two bitmaps", ELFCLASS64, binary.LittleEndian, EM_AARCH64, run(0x10000, 130, 8)},
+		{"gaps", ELFCLASS64, binary.BigEndian, EM_AARCH64, []uint64{0x1000, 0x1010, 0x1100, 0x1108, 0x5000}},
+		{"sparse", ELFCLASS64, binary.LittleEndian, EM_RISCV, run(0x2000, 20, 0x400)},
+		{"32-bit run", ELFCLASS32, binary.LittleEndian, EM_ARM, run(0x8000, 70, 4)},
+		{"32-bit gaps", ELFCLASS32, binary.BigEndian, EM_386, []uint64{0x100, 0x108, 0x17c, 0x180, 0x1000}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &File{Class: tt.class, ByteOrder: tt.order, Machine: tt.machine}
+			wordSize := 8
+			if tt.class == ELFCLASS32 {
+				wordSize = 4
+			}
+
+			var got []uint64
+			for _, r := range f.decodeRelr(encodeRelr(tt.offsets, wordSize, tt.order)) {
+				if r.Type != relativeRelocType(tt.machine) {
+					t.Errorf("offset %#x has type %d", r.Offset, r.Type)
+				}
+				got = append(got, r.Offset)
+			}
+			if !reflect.DeepEqual(got, tt.offsets) {
+				t.Errorf("got offsets %#x, want %#x", got, tt.offsets)
+			}
+		})
+	}
+}
+
+func TestDynamicRelr(t *testing.T) {
+	data, err := os.ReadFile("testdata/librelr.so")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []uint64{0x2000, 0x2008, 0x2010, 0x2018, 0x2020, 0x2028}
+
+	offsets := func(data []byte, name string) {
+		t.Helper()
+		f, err := Parse(data)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var got []uint64
+		for _, rs := range f.Relocations {
+			if rs.Type != SHT_RELR {
+				continue
+			}
+			if rs.Name != name {
+				t.Errorf("got RELR table %q, want %q", rs.Name, name)
+			}
+			for _, r := range rs.Entries {
+				got = append(got, r.Offset)
+			}
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got offsets %#x, want %#x", name, got, want)
+		}
+	}
+	offsets(data, ".relr.dyn")
+
+	// Without section headers the table is found through DT_RELR.
+	stripped := append([]byte(nil), data...)
+	binary.LittleEndian.PutUint64(stripped[0x28:], 0) // e_shoff
+	binary.LittleEndian.PutUint16(stripped[0x3c:], 0) // e_shnum
+	binary.LittleEndian.PutUint16(stripped[0x3e:], 0) // e_shstrndx
+	offsets(stripped, "RELR")
+}
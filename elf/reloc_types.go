@@ -0,0 +1,406 @@
+package elf
+
+import "fmt"
+
+// RelocationTypeString returns the name of relocation type t for the given
+// machine, e.g. R_X86_64_JUMP_SLOT.
+func RelocationTypeString(machine uint16, t uint32) string {
+	var names map[uint32]string
+	switch machine {
+	case EM_X86_64:
+		names = relocTypesX86_64
+	case EM_386:
+		names = relocTypes386
+	case EM_ARM:
+		names = relocTypesARM
+	case EM_AARCH64:
+		names = relocTypesAArch64
+	case EM_RISCV:
+		names = relocTypesRISCV
+	}
+	if name, ok := names[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("Unknown (%d)", t)
+}
+
+var relocTypesX86_64 = map[uint32]string{
+	0:  "R_X86_64_NONE",
+	1:  "R_X86_64_64",
+	2:  "R_X86_64_PC32",
+	3:  "R_X86_64_GOT32",
+	4:  "R_X86_64_PLT32",
+	5:  "R_X86_64_COPY",
+	6:  "R_X86_64_GLOB_DAT",
+	7:  "R_X86_64_JUMP_SLOT",
+	8:  "R_X86_64_RELATIVE",
+	9:  "R_X86_64_GOTPCREL",
+	10: "R_X86_64_32",
+	11: "R_X86_64_32S",
+	12: "R_X86_64_16",
+	13: "R_X86_64_PC16",
+	14: "R_X86_64_8",
+	15: "R_X86_64_PC8",
+	16: "R_X86_64_DTPMOD64",
+	17: "R_X86_64_DTPOFF64",
+	18: "R_X86_64_TPOFF64",
+	19: "R_X86_64_TLSGD",
+	20: "R_X86_64_TLSLD",
+	21: "R_X86_64_DTPOFF32",
+	22: "R_X86_64_GOTTPOFF",
+	23: "R_X86_64_TPOFF32",
+	24: "R_X86_64_PC64",
+	25: "R_X86_64_GOTOFF64",
+	26: "R_X86_64_GOTPC32",
+	27: "R_X86_64_GOT64",
+	28: "R_X86_64_GOTPCREL64",
+	29: "R_X86_64_GOTPC64",
+	30: "R_X86_64_GOTPLT64",
+	31: "R_X86_64_PLTOFF64",
+	32: "R_X86_64_SIZE32",
+	33: "R_X86_64_SIZE64",
+	34: "R_X86_64_GOTPC32_TLSDESC",
+	35: "R_X86_64_TLSDESC_CALL",
+	36: "R_X86_64_TLSDESC",
+	37: "R_X86_64_IRELATIVE",
+	38: "R_X86_64_RELATIVE64",
+	39: "R_X86_64_PC32_BND",
+	40: "R_X86_64_PLT32_BND",
+	41: "R_X86_64_GOTPCRELX",
+	42: "R_X86_64_REX_GOTPCRELX",
+}
+
+var relocTypes386 = map[uint32]string{
+	0:  "R_386_NONE",
+	1:  "R_386_32",
+	2:  "R_386_PC32",
+	3:  "R_386_GOT32",
+	4:  "R_386_PLT32",
+	5:  "R_386_COPY",
+	6:  "R_386_GLOB_DAT",
+	7:  "R_386_JUMP_SLOT",
+	8:  "R_386_RELATIVE",
+	9:  "R_386_GOTOFF",
+	10: "R_386_GOTPC",
+	11: "R_386_32PLT",
+	14: "R_386_TLS_TPOFF",
+	15: "R_386_TLS_IE",
+	16: "R_386_TLS_GOTIE",
+	17: "R_386_TLS_LE",
+	18: "R_386_TLS_GD",
+	19: "R_386_TLS_LDM",
+	20: "R_386_16",
+	21: "R_386_PC16",
+	22: "R_386_8",
+	23: "R_386_PC8",
+	24: "R_386_TLS_GD_32",
+	25: "R_386_TLS_GD_PUSH",
+	26: "R_386_TLS_GD_CALL",
+	27: "R_386_TLS_GD_POP",
+	28: "R_386_TLS_LDM_32",
+	29: "R_386_TLS_LDM_PUSH",
+	30: "R_386_TLS_LDM_CALL",
+	31: "R_386_TLS_LDM_POP",
+	32: "R_386_TLS_LDO_32",
+	33: "R_386_TLS_IE_32",
+	34: "R_386_TLS_LE_32",
+	35: "R_386_TLS_DTPMOD32",
+	36: "R_386_TLS_DTPOFF32",
+	37: "R_386_TLS_TPOFF32",
+	38: "R_386_SIZE32",
+	39: "R_386_TLS_GOTDESC",
+	40: "R_386_TLS_DESC_CALL",
+	41: "R_386_TLS_DESC",
+	42: "R_386_IRELATIVE",
+	43: "R_386_GOT32X",
+}
+
+var relocTypesARM = map[uint32]string{
+	0:   "R_ARM_NONE",
+	1:   "R_ARM_PC24",
+	2:   "R_ARM_ABS32",
+	3:   "R_ARM_REL32",
+	4:   "R_ARM_LDR_PC_G0",
+	5:   "R_ARM_ABS16",
+	6:   "R_ARM_ABS12",
+	7:   "R_ARM_THM_ABS5",
+	8:   "R_ARM_ABS8",
+	9:   "R_ARM_SBREL32",
+	10:  "R_ARM_THM_CALL",
+	11:  "R_ARM_THM_PC8",
+	12:  "R_ARM_BREL_ADJ",
+	13:  "R_ARM_TLS_DESC",
+	14:  "R_ARM_THM_SWI8",
+	15:  "R_ARM_XPC25",
+	16:  "R_ARM_THM_XPC22",
+	17:  "R_ARM_TLS_DTPMOD32",
+	18:  "R_ARM_TLS_DTPOFF32",
+	19:  "R_ARM_TLS_TPOFF32",
+	20:  "R_ARM_COPY",
+	21:  "R_ARM_GLOB_DAT",
+	22:  "R_ARM_JUMP_SLOT",
+	23:  "R_ARM_RELATIVE",
+	24:  "R_ARM_GOTOFF",
+	25:  "R_ARM_GOTPC",
+	26:  "R_ARM_GOT32",
+	27:  "R_ARM_PLT32",
+	28:  "R_ARM_CALL",
+	29:  "R_ARM_JUMP24",
+	30:  "R_ARM_THM_JUMP24",
+	31:  "R_ARM_BASE_ABS",
+	32:  "R_ARM_ALU_PCREL_7_0",
+	33:  "R_ARM_ALU_PCREL_15_8",
+	34:  "R_ARM_ALU_PCREL_23_15",
+	35:  "R_ARM_LDR_SBREL_11_10_NC",
+	36:  "R_ARM_ALU_SBREL_19_12_NC",
+	37:  "R_ARM_ALU_SBREL_27_20_CK",
+	38:  "R_ARM_TARGET1",
+	39:  "R_ARM_SBREL31",
+	40:  "R_ARM_V4BX",
+	41:  "R_ARM_TARGET2",
+	42:  "R_ARM_PREL31",
+	43:  "R_ARM_MOVW_ABS_NC",
+	44:  "R_ARM_MOVT_ABS",
+	45:  "R_ARM_MOVW_PREL_NC",
+	46:  "R_ARM_MOVT_PREL",
+	47:  "R_ARM_THM_MOVW_ABS_NC",
+	48:  "R_ARM_THM_MOVT_ABS",
+	49:  "R_ARM_THM_MOVW_PREL_NC",
+	50:  "R_ARM_THM_MOVT_PREL",
+	51:  "R_ARM_THM_JUMP19",
+	52:  "R_ARM_THM_JUMP6",
+	53:  "R_ARM_THM_ALU_PREL_11_0",
+	54:  "R_ARM_THM_PC12",
+	55:  "R_ARM_ABS32_NOI",
+	56:  "R_ARM_REL32_NOI",
+	57:  "R_ARM_ALU_PC_G0_NC",
+	58:  "R_ARM_ALU_PC_G0",
+	59:  "R_ARM_ALU_PC_G1_NC",
+	60:  "R_ARM_ALU_PC_G1",
+	61:  "R_ARM_ALU_PC_G2",
+	62:  "R_ARM_LDR_PC_G1",
+	63:  "R_ARM_LDR_PC_G2",
+	64:  "R_ARM_LDRS_PC_G0",
+	65:  "R_ARM_LDRS_PC_G1",
+	66:  "R_ARM_LDRS_PC_G2",
+	67:  "R_ARM_LDC_PC_G0",
+	68:  "R_ARM_LDC_PC_G1",
+	69:  "R_ARM_LDC_PC_G2",
+	70:  "R_ARM_ALU_SB_G0_NC",
+	71:  "R_ARM_ALU_SB_G0",
+	72:  "R_ARM_ALU_SB_G1_NC",
+	73:  "R_ARM_ALU_SB_G1",
+	74:  "R_ARM_ALU_SB_G2",
+	75:  "R_ARM_LDR_SB_G0",
+	76:  "R_ARM_LDR_SB_G1",
+	77:  "R_ARM_LDR_SB_G2",
+	78:  "R_ARM_LDRS_SB_G0",
+	79:  "R_ARM_LDRS_SB_G1",
+	80:  "R_ARM_LDRS_SB_G2",
+	81:  "R_ARM_LDC_SB_G0",
+	82:  "R_ARM_LDC_SB_G1",
+	83:  "R_ARM_LDC_SB_G2",
+	84:  "R_ARM_MOVW_BREL_NC",
+	85:  "R_ARM_MOVT_BREL",
+	86:  "R_ARM_MOVW_BREL",
+	87:  "R_ARM_THM_MOVW_BREL_NC",
+	88:  "R_ARM_THM_MOVT_BREL",
+	89:  "R_ARM_THM_MOVW_BREL",
+	90:  "R_ARM_TLS_GOTDESC",
+	91:  "R_ARM_TLS_CALL",
+	92:  "R_ARM_TLS_DESCSEQ",
+	93:  "R_ARM_THM_TLS_CALL",
+	94:  "R_ARM_PLT32_ABS",
+	95:  "R_ARM_GOT_ABS",
+	96:  "R_ARM_GOT_PREL",
+	97:  "R_ARM_GOT_BREL12",
+	98:  "R_ARM_GOTOFF12",
+	99:  "R_ARM_GOTRELAX",
+	100: "R_ARM_GNU_VTENTRY",
+	101: "R_ARM_GNU_VTINHERIT",
+	102: "R_ARM_THM_JUMP11",
+	103: "R_ARM_THM_JUMP8",
+	104: "R_ARM_TLS_GD32",
+	105: "R_ARM_TLS_LDM32",
+	106: "R_ARM_TLS_LDO32",
+	107: "R_ARM_TLS_IE32",
+	108: "R_ARM_TLS_LE32",
+	109: "R_ARM_TLS_LDO12",
+	110: "R_ARM_TLS_LE12",
+	111: "R_ARM_TLS_IE12GP",
+	112: "R_ARM_PRIVATE_0",
+	113: "R_ARM_PRIVATE_1",
+	114: "R_ARM_PRIVATE_2",
+	115: "R_ARM_PRIVATE_3",
+	116: "R_ARM_PRIVATE_4",
+	117: "R_ARM_PRIVATE_5",
+	118: "R_ARM_PRIVATE_6",
+	119: "R_ARM_PRIVATE_7",
+	120: "R_ARM_PRIVATE_8",
+	121: "R_ARM_PRIVATE_9",
+	122: "R_ARM_PRIVATE_10",
+	123: "R_ARM_PRIVATE_11",
+	124: "R_ARM_PRIVATE_12",
+	125: "R_ARM_PRIVATE_13",
+	126: "R_ARM_PRIVATE_14",
+	127: "R_ARM_PRIVATE_15",
+	128: "R_ARM_ME_TOO",
+	129: "R_ARM_THM_TLS_DESCSEQ16",
+	130: "R_ARM_THM_TLS_DESCSEQ32",
+	131: "R_ARM_THM_GOT_BREL12",
+	132: "R_ARM_THM_ALU_ABS_G0_NC",
+	133: "R_ARM_THM_ALU_ABS_G1_NC",
+	134: "R_ARM_THM_ALU_ABS_G2_NC",
+	135: "R_ARM_THM_ALU_ABS_G3",
+	160: "R_ARM_IRELATIVE",
+	249: "R_ARM_RXPC25",
+	250: "R_ARM_RSBREL32",
+	251: "R_ARM_THM_RPC22",
+	252: "R_ARM_RREL32",
+	253: "R_ARM_RABS32",
+	254: "R_ARM_RPC24",
+	255: "R_ARM_RBASE",
+}
+
+var relocTypesAArch64 = map[uint32]string{
+	0:    "R_AARCH64_NONE",
+	256:  "R_AARCH64_NULL",
+	257:  "R_AARCH64_ABS64",
+	258:  "R_AARCH64_ABS32",
+	259:  "R_AARCH64_ABS16",
+	260:  "R_AARCH64_PREL64",
+	261:  "R_AARCH64_PREL32",
+	262:  "R_AARCH64_PREL16",
+	263:  "R_AARCH64_MOVW_UABS_G0",
+	264:  "R_AARCH64_MOVW_UABS_G0_NC",
+	265:  "R_AARCH64_MOVW_UABS_G1",
+	266:  "R_AARCH64_MOVW_UABS_G1_NC",
+	267:  "R_AARCH64_MOVW_UABS_G2",
+	268:  "R_AARCH64_MOVW_UABS_G2_NC",
+	269:  "R_AARCH64_MOVW_UABS_G3",
+	270:  "R_AARCH64_MOVW_SABS_G0",
+	271:  "R_AARCH64_MOVW_SABS_G1",
+	272:  "R_AARCH64_MOVW_SABS_G2",
+	273:  "R_AARCH64_LD_PREL_LO19",
+	274:  "R_AARCH64_ADR_PREL_LO21",
+	275:  "R_AARCH64_ADR_PREL_PG_HI21",
+	276:  "R_AARCH64_ADR_PREL_PG_HI21_NC",
+	277:  "R_AARCH64_ADD_ABS_LO12_NC",
+	278:  "R_AARCH64_LDST8_ABS_LO12_NC",
+	279:  "R_AARCH64_TSTBR14",
+	280:  "R_AARCH64_CONDBR19",
+	282:  "R_AARCH64_JUMP26",
+	283:  "R_AARCH64_CALL26",
+	284:  "R_AARCH64_LDST16_ABS_LO12_NC",
+	285:  "R_AARCH64_LDST32_ABS_LO12_NC",
+	286:  "R_AARCH64_LDST64_ABS_LO12_NC",
+	299:  "R_AARCH64_LDST128_ABS_LO12_NC",
+	309:  "R_AARCH64_GOT_LD_PREL19",
+	310:  "R_AARCH64_LD64_GOTOFF_LO15",
+	311:  "R_AARCH64_ADR_GOT_PAGE",
+	312:  "R_AARCH64_LD64_GOT_LO12_NC",
+	313:  "R_AARCH64_LD64_GOTPAGE_LO15",
+	512:  "R_AARCH64_TLSGD_ADR_PREL21",
+	513:  "R_AARCH64_TLSGD_ADR_PAGE21",
+	514:  "R_AARCH64_TLSGD_ADD_LO12_NC",
+	515:  "R_AARCH64_TLSGD_MOVW_G1",
+	516:  "R_AARCH64_TLSGD_MOVW_G0_NC",
+	517:  "R_AARCH64_TLSLD_ADR_PREL21",
+	518:  "R_AARCH64_TLSLD_ADR_PAGE21",
+	539:  "R_AARCH64_TLSIE_MOVW_GOTTPREL_G1",
+	540:  "R_AARCH64_TLSIE_MOVW_GOTTPREL_G0_NC",
+	541:  "R_AARCH64_TLSIE_ADR_GOTTPREL_PAGE21",
+	542:  "R_AARCH64_TLSIE_LD64_GOTTPREL_LO12_NC",
+	543:  "R_AARCH64_TLSIE_LD_GOTTPREL_PREL19",
+	544:  "R_AARCH64_TLSLE_MOVW_TPREL_G2",
+	545:  "R_AARCH64_TLSLE_MOVW_TPREL_G1",
+	546:  "R_AARCH64_TLSLE_MOVW_TPREL_G1_NC",
+	547:  "R_AARCH64_TLSLE_MOVW_TPREL_G0",
+	548:  "R_AARCH64_TLSLE_MOVW_TPREL_G0_NC",
+	549:  "R_AARCH64_TLSLE_ADD_TPREL_HI12",
+	550:  "R_AARCH64_TLSLE_ADD_TPREL_LO12",
+	551:  "R_AARCH64_TLSLE_ADD_TPREL_LO12_NC",
+	560:  "R_AARCH64_TLSDESC_LD_PREL19",
+	561:  "R_AARCH64_TLSDESC_ADR_PREL21",
+	562:  "R_AARCH64_TLSDESC_ADR_PAGE21",
+	563:  "R_AARCH64_TLSDESC_LD64_LO12_NC",
+	564:  "R_AARCH64_TLSDESC_ADD_LO12_NC",
+	565:  "R_AARCH64_TLSDESC_OFF_G1",
+	566:  "R_AARCH64_TLSDESC_OFF_G0_NC",
+	567:  "R_AARCH64_TLSDESC_LDR",
+	568:  "R_AARCH64_TLSDESC_ADD",
+	569:  "R_AARCH64_TLSDESC_CALL",
+	570:  "R_AARCH64_TLSLE_LDST128_TPREL_LO12",
+	571:  "R_AARCH64_TLSLE_LDST128_TPREL_LO12_NC",
+	572:  "R_AARCH64_TLSLD_LDST128_DTPREL_LO12",
+	573:  "R_AARCH64_TLSLD_LDST128_DTPREL_LO12_NC",
+	1024: "R_AARCH64_COPY",
+	1025: "R_AARCH64_GLOB_DAT",
+	1026: "R_AARCH64_JUMP_SLOT",
+	1027: "R_AARCH64_RELATIVE",
+	1028: "R_AARCH64_TLS_DTPMOD64",
+	1029: "R_AARCH64_TLS_DTPREL64",
+	1030: "R_AARCH64_TLS_TPREL64",
+	1031: "R_AARCH64_TLSDESC",
+	1032: "R_AARCH64_IRELATIVE",
+}
+
+var relocTypesRISCV = map[uint32]string{
+	0:  "R_RISCV_NONE",
+	1:  "R_RISCV_32",
+	2:  "R_RISCV_64",
+	3:  "R_RISCV_RELATIVE",
+	4:  "R_RISCV_COPY",
+	5:  "R_RISCV_JUMP_SLOT",
+	6:  "R_RISCV_TLS_DTPMOD32",
+	7:  "R_RISCV_TLS_DTPMOD64",
+	8:  "R_RISCV_TLS_DTPREL32",
+	9:  "R_RISCV_TLS_DTPREL64",
+	10: "R_RISCV_TLS_TPREL32",
+	11: "R_RISCV_TLS_TPREL64",
+	12: "R_RISCV_TLSDESC",
+	16: "R_RISCV_BRANCH",
+	17: "R_RISCV_JAL",
+	18: "R_RISCV_CALL",
+	19: "R_RISCV_CALL_PLT",
+	20: "R_RISCV_GOT_HI20",
+	21: "R_RISCV_TLS_GOT_HI20",
+	22: "R_RISCV_TLS_GD_HI20",
+	23: "R_RISCV_PCREL_HI20",
+	24: "R_RISCV_PCREL_LO12_I",
+	25: "R_RISCV_PCREL_LO12_S",
+	26: "R_RISCV_HI20",
+	27: "R_RISCV_LO12_I",
+	28: "R_RISCV_LO12_S",
+	29: "R_RISCV_TPREL_HI20",
+	30: "R_RISCV_TPREL_LO12_I",
+	31: "R_RISCV_TPREL_LO12_S",
+	32: "R_RISCV_TPREL_ADD",
+	33: "R_RISCV_ADD8",
+	34: "R_RISCV_ADD16",
+	35: "R_RISCV_ADD32",
+	36: "R_RISCV_ADD64",
+	37: "R_RISCV_SUB8",
+	38: "R_RISCV_SUB16",
+	39: "R_RISCV_SUB32",
+	40: "R_RISCV_SUB64",
+	41: "R_RISCV_GOT32_PCREL",
+	43: "R_RISCV_ALIGN",
+	44: "R_RISCV_RVC_BRANCH",
+	45: "R_RISCV_RVC_JUMP",
+	51: "R_RISCV_RELAX",
+	52: "R_RISCV_SUB6",
+	53: "R_RISCV_SET6",
+	54: "R_RISCV_SET8",
+	55: "R_RISCV_SET16",
+	56: "R_RISCV_SET32",
+	57: "R_RISCV_32_PCREL",
+	58: "R_RISCV_IRELATIVE",
+	59: "R_RISCV_PLT32",
+	60: "R_RISCV_SET_ULEB128",
+	61: "R_RISCV_SUB_ULEB128",
+	62: "R_RISCV_TLSDESC_HI20",
+	63: "R_RISCV_TLSDESC_LOAD_LO12",
+	64: "R_RISCV_TLSDESC_ADD_LO12",
+	65: "R_RISCV_TLSDESC_CALL",
+}
@@ -0,0 +1,119 @@
+package elf
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// VersionRequirement is the highest version of one version family (GLIBC,
+// GLIBCXX, CXXABI, ...) that the file requires from a DT_NEEDED library,
+// together with the imported symbols bound to exactly that version. A
+// library without version requirements is reported with an empty Version.
+type VersionRequirement struct {
+	Library string
+	Family  string
+	Version string
+	Symbols []string
+}
+
+// MinimumVersions computes, per needed library and version family, the
+// newest version node the file depends on. The result answers which
+// library release is the oldest one the file can run against.
+func (f *File) MinimumVersions() []VersionRequirement {
+	var reqs []VersionRequirement
+	seen := make(map[string]bool)
+
+	for _, need := range f.VersionNeeds {
+		seen[need.File] = true
+
+		best := make(map[string]string)
+		var families []string
+		for _, v := range need.Versions {
+			family, _ := splitVersion(v.Name)
+			cur, ok := best[family]
+			if !ok {
+				families = append(families, family)
+			}
+			if !ok || CompareVersions(v.Name, cur) > 0 {
+				best[family] = v.Name
+			}
+		}
+		sort.Strings(families)
+
+		for _, family := range families {
+			reqs = append(reqs, VersionRequirement{
+				Library: need.File,
+				Family:  family,
+				Version: best[family],
+				Symbols: f.symbolsRequiring(need.File, best[family]),
+			})
+		}
+	}
+
+	for _, lib := range f.DynamicStrings(DT_NEEDED) {
+		if !seen[lib] {
+			seen[lib] = true
+			reqs = append(reqs, VersionRequirement{Library: lib})
+		}
+	}
+
+	return reqs
+}
+
+func (f *File) symbolsRequiring(library, version string) []string {
+	var names []string
+	dup := make(map[string]bool)
+	for _, sym := range f.Symbols {
+		if sym.Library != library || sym.Version != version || dup[sym.Name] {
+			continue
+		}
+		dup[sym.Name] = true
+		names = append(names, sym.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// splitVersion splits a version node name such as GLIBC_2.34 into its
+// family and numeric components. Names without a numeric suffix, such as
+// GLIBC_PRIVATE, form a family of their own.
+func splitVersion(name string) (string, []int) {
+	i := strings.LastIndex(name, "_")
+	if i < 0 {
+		return name, nil
+	}
+
+	var nums []int
+	for _, part := range strings.Split(name[i+1:], ".") {
+		n, err := strconv.Atoi(part)
+		if err != nil {
+			return name, nil
+		}
+		nums = append(nums, n)
+	}
+	return name[:i], nums
+}
+
+// CompareVersions orders two version node names of the same family by
+// their numeric components, returning -1, 0 or 1.
+func CompareVersions(a, b string) int {
+	_, av := splitVersion(a)
+	_, bv := splitVersion(b)
+	for i := 0; i < len(av) || i < len(bv); i++ {
+		var x, y int
+		if i < len(av) {
+			x = av[i]
+		}
+		if i < len(bv) {
+			y = bv[i]
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,222 @@
+package elf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"strings"
+)
+
+// RISC-V e_flags.
+const (
+	EF_RISCV_RVC              = 0x1
+	EF_RISCV_FLOAT_ABI        = 0x6
+	EF_RISCV_FLOAT_ABI_SOFT   = 0x0
+	EF_RISCV_FLOAT_ABI_SINGLE = 0x2
+	EF_RISCV_FLOAT_ABI_DOUBLE = 0x4
+	EF_RISCV_FLOAT_ABI_QUAD   = 0x6
+	EF_RISCV_RVE              = 0x8
+	EF_RISCV_TSO              = 0x10
+)
+
+// SHT_RISCV_ATTRIBUTES is the type of the .riscv.attributes section.
+const SHT_RISCV_ATTRIBUTES = 0x70000003
+
+// Build attribute tags of the "riscv" vendor.
+const (
+	Tag_File                     = 1
+	Tag_RISCV_stack_align        = 4
+	Tag_RISCV_arch               = 5
+	Tag_RISCV_unaligned_access   = 6
+	Tag_RISCV_priv_spec          = 8
+	Tag_RISCV_priv_spec_minor    = 10
+	Tag_RISCV_priv_spec_revision = 12
+	Tag_RISCV_atomic_abi         = 14
+	Tag_RISCV_x3_reg_usage       = 16
+)
+
+func riscvFlagsString(flags uint32) string {
+	var parts []string
+	if flags&EF_RISCV_RVC != 0 {
+		parts = append(parts, "RVC")
+	}
+	switch flags & EF_RISCV_FLOAT_ABI {
+	case EF_RISCV_FLOAT_ABI_SOFT:
+		parts = append(parts, "soft-float ABI")
+	case EF_RISCV_FLOAT_ABI_SINGLE:
+		parts = append(parts, "single-float ABI")
+	case EF_RISCV_FLOAT_ABI_DOUBLE:
+		parts = append(parts, "double-float ABI")
+	case EF_RISCV_FLOAT_ABI_QUAD:
+		parts = append(parts, "quad-float ABI")
+	}
+	if flags&EF_RISCV_RVE != 0 {
+		parts = append(parts, "RVE")
+	}
+	if flags&EF_RISCV_TSO != 0 {
+		parts = append(parts, "TSO")
+	}
+	if rest := flags &^ 0x1f; rest != 0 {
+		parts = append(parts, fmt.Sprintf("unknown flags %#x", rest))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Attribute is a single file-wide build attribute, such as the ISA string
+// recorded in .riscv.attributes. Tags with an odd number carry a string in
+// Str, the others an integer in Value.
+type Attribute struct {
+	Vendor string
+	Tag    uint64
+	Value  uint64
+	Str    string
+}
+
+// IsString reports whether the attribute carries a string.
+func (a Attribute) IsString() bool {
+	return a.Tag%2 == 1
+}
+
+// parseAttributes reads the RISC-V attributes sections. A section that
+// cannot be read is left out with a warning.
+func (f *File) parseAttributes() {
+	if f.Machine != EM_RISCV {
+		return
+	}
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type != SHT_RISCV_ATTRIBUTES {
+			continue
+		}
+		data, err := f.GetSectionData(sh)
+		if err != nil {
+			f.Warnings = append(f.Warnings, fmt.Sprintf("attributes section %s skipped: %v", sh.Name, err))
+			continue
+		}
+		f.Attributes = append(f.Attributes, f.walkAttributes(data)...)
+	}
+}
+
+// walkAttributes decodes an attributes section: a format version 'A'
+// followed by one subsection per vendor, each holding tagged groups of
+// attributes. Only the file-wide group is decoded; toolchains do not emit
+// per-section or per-symbol attributes. Decoding stops at the first
+// malformed entry.
+func (f *File) walkAttributes(data []byte) []Attribute {
+	if len(data) == 0 || data[0] != 'A' {
+		return nil
+	}
+	data = data[1:]
+
+	var attrs []Attribute
+	for len(data) >= 4 {
+		size := uint64(f.ByteOrder.Uint32(data))
+		if size < 4 || size > uint64(len(data)) {
+			break
+		}
+		sub := data[4:size]
+		data = data[size:]
+
+		nul := bytes.IndexByte(sub, 0)
+		if nul < 0 {
+			break
+		}
+		vendor := string(sub[:nul])
+		sub = sub[nul+1:]
+
+		for len(sub) > 0 {
+			tag, n := binary.Uvarint(sub)
+			if n <= 0 || len(sub) < n+4 {
+				break
+			}
+			size := uint64(f.ByteOrder.Uint32(sub[n:]))
+			if size < uint64(n+4) || size > uint64(len(sub)) {
+				break
+			}
+			body := sub[n+4 : size]
+			sub = sub[size:]
+			if tag == Tag_File {
+				attrs = append(attrs, decodeAttributes(vendor, body)...)
+			}
+		}
+	}
+	return attrs
+}
+
+func decodeAttributes(vendor string, data []byte) []Attribute {
+	var attrs []Attribute
+	for len(data) > 0 {
+		tag, n := binary.Uvarint(data)
+		if n <= 0 {
+			break
+		}
+		data = data[n:]
+
+		a := Attribute{Vendor: vendor, Tag: tag}
+		if a.IsString() {
+			nul := bytes.IndexByte(data, 0)
+			if nul < 0 {
+				break
+			}
+			a.Str = string(data[:nul])
+			data = data[nul+1:]
+		} else {
+			v, n := binary.Uvarint(data)
+			if n <= 0 {
+				break
+			}
+			a.Value = v
+			data = data[n:]
+		}
+		attrs = append(attrs, a)
+	}
+	return attrs
+}
+
+var riscvAttributeNames = map[uint64]string{
+	Tag_RISCV_stack_align:        "Tag_RISCV_stack_align",
+	Tag_RISCV_arch:               "Tag_RISCV_arch",
+	Tag_RISCV_unaligned_access:   "Tag_RISCV_unaligned_access",
+	Tag_RISCV_priv_spec:          "Tag_RISCV_priv_spec",
+	Tag_RISCV_priv_spec_minor:    "Tag_RISCV_priv_spec_minor",
+	Tag_RISCV_priv_spec_revision: "Tag_RISCV_priv_spec_revision",
+	Tag_RISCV_atomic_abi:         "Tag_RISCV_atomic_abi",
+	Tag_RISCV_x3_reg_usage:       "Tag_RISCV_x3_reg_usage",
+}
+
+// AttributeTagString returns the name of an attribute's tag, e.g.
+// Tag_RISCV_arch.
+func AttributeTagString(a Attribute) string {
+	if name, ok := riscvAttributeNames[a.Tag]; ok && a.Vendor == "riscv" {
+		return name
+	}
+	return fmt.Sprintf("Tag_unknown_%d", a.Tag)
+}
+
+// AttributeValueString describes an attribute's value the way readelf -A
+// does. String values are returned as they are, without quotes.
+func AttributeValueString(a Attribute) string {
+	if a.IsString() {
+		return a.Str
+	}
+	if a.Vendor == "riscv" {
+		switch a.Tag {
+		case Tag_RISCV_stack_align:
+			return fmt.Sprintf("%d-bytes", a.Value)
+		case Tag_RISCV_unaligned_access:
+			if a.Value == 0 {
+				return "No unaligned access"
+			}
+			return "Unaligned access"
+		case Tag_RISCV_atomic_abi:
+			if a.Value < 4 {
+				return [4]string{"UNKNOWN", "A6C", "A6S", "A7"}[a.Value]
+			}
+		case Tag_RISCV_x3_reg_usage:
+			if a.Value < 4 {
+				return [4]string{"UNKNOWN", "gp", "scs", "tmp"}[a.Value]
+			}
+		}
+	}
+	return fmt.Sprintf("%d", a.Value)
+}
@@ -0,0 +1,273 @@
+package elf
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+// RELRO levels.
+const (
+	RELRONone    = "none"
+	RELROPartial = "partial"
+	RELROFull    = "full"
+)
+
+// PIE kinds.
+const (
+	PIENone        = "no"
+	PIEExecutable  = "yes"
+	PIESharedObj   = "dso"
+	PIERelocatable = "rel"
+)
+
+// Security is the hardening a file was built with, as checked by tools
+// such as checksec.
+type Security struct {
+	// RELRO is "full" when the GNU_RELRO segment is present and symbols
+	// are bound at load time, so that the whole GOT can be made read-only,
+	// and "partial" when they are bound lazily.
+	RELRO string
+
+	// NX reports a non-executable stack: a GNU_STACK segment, or for
+	// relocatable objects a .note.GNU-stack section, without execute
+	// permission.
+	NX bool
+
+	// PIE tells position-independent executables from fixed-address ones,
+	// shared libraries and relocatable objects.
+	PIE string
+
+	// Canary reports code compiled with stack protection, which imports
+	// __stack_chk_fail.
+	Canary bool
+
+	// Fortified lists the checked variants of libc functions (__memcpy_chk
+	// and so on) that _FORTIFY_SOURCE made the file import. Fortifiable
+	// lists the unchecked functions with such a variant that it still
+	// imports. A statically linked file imports nothing, so the functions
+	// linked into it count instead; a C library that merely defines them
+	// is not itself fortified.
+	Fortified   []string
+	Fortifiable []string
+
+	RPath   string
+	RunPath string
+
+	// IBT and SHSTK are the x86 CET features, BTI and PAC the AArch64
+	// branch protection features, all marked in GNU property notes.
+	IBT   bool
+	SHSTK bool
+	BTI   bool
+	PAC   bool
+
+	// Stripped reports a file without a static symbol table, and
+	// DebugInfo one with DWARF debugging information.
+	Stripped  bool
+	DebugInfo bool
+}
+
+// fortifiable names the glibc functions that _FORTIFY_SOURCE replaces with
+// a __NAME_chk variant.
+var fortifiable = map[string]bool{
+	"confstr": true, "dprintf": true, "fdelt": true, "fgets": true, "fgets_unlocked": true,
+	"fgetws": true, "fgetws_unlocked": true, "fprintf": true, "fread": true, "fread_unlocked": true,
+	"fwprintf": true, "getcwd": true, "getdomainname": true, "getgroups": true, "gethostname": true,
+	"getlogin_r": true, "gets": true, "getwd": true, "longjmp": true, "mbsnrtowcs": true,
+	"mbsrtowcs": true, "mbstowcs": true, "memcpy": true, "memmove": true, "mempcpy": true,
+	"memset": true, "poll": true, "ppoll": true, "pread": true, "pread64": true, "printf": true,
+	"ptsname_r": true, "read": true, "readlink": true, "readlinkat": true, "realpath": true,
+	"recv": true, "recvfrom": true, "snprintf": true, "sprintf": true, "stpcpy": true,
+	"stpncpy": true, "strcat": true, "strcpy": true, "strncat": true, "strncpy": true,
+	"swprintf": true, "syslog": true, "ttyname_r": true, "vdprintf": true, "vfprintf": true,
+	"vfwprintf": true, "vprintf": true, "vsnprintf": true, "vsprintf": true, "vswprintf": true,
+	"vsyslog": true, "vwprintf": true, "wcpcpy": true, "wcpncpy": true, "wcrtomb": true,
+	"wcscat": true, "wcscpy": true, "wcsncat": true, "wcsncpy": true, "wcsnrtombs": true,
+	"wcsrtombs": true, "wcstombs": true, "wctomb": true, "wmemcpy": true, "wmemmove": true,
+	"wmempcpy": true, "wmemset": true, "wprintf": true,
+}
+
+// Security checks the hardening of the file.
+func (f *File) Security() *Security {
+	s := &Security{RELRO: RELRONone}
+
+	var relro, stack, interp bool
+	for _, ph := range f.ProgramHeaders {
+		switch ph.Type {
+		case PT_GNU_RELRO:
+			relro = true
+		case PT_GNU_STACK:
+			stack = true
+			s.NX = ph.Flags&PF_X == 0
+		case PT_INTERP:
+			interp = true
+		}
+	}
+
+	var bindNow, pie, needed bool
+	for _, d := range f.Dynamic {
+		switch d.Tag {
+		case DT_NEEDED:
+			needed = true
+		case DT_BIND_NOW:
+			bindNow = true
+		case DT_FLAGS:
+			bindNow = bindNow || d.Value&DF_BIND_NOW != 0
+		case DT_FLAGS_1:
+			bindNow = bindNow || d.Value&DF_1_NOW != 0
+			pie = d.Value&DF_1_PIE != 0
+		case DT_RPATH:
+			s.RPath = d.Str
+		case DT_RUNPATH:
+			s.RunPath = d.Str
+		}
+	}
+	if relro {
+		s.RELRO = RELROPartial
+		if bindNow {
+			s.RELRO = RELROFull
+		}
+	}
+
+	switch f.Type {
+	case ET_EXEC:
+		s.PIE = PIENone
+	case ET_DYN:
+		// Linkers before DF_1_PIE existed marked nothing, but only
+		// executables ask for a program interpreter.
+		s.PIE = PIESharedObj
+		if pie || interp {
+			s.PIE = PIEExecutable
+		}
+	case ET_REL:
+		s.PIE = PIERelocatable
+	}
+
+	s.Stripped = true
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		switch {
+		case sh.Type == SHT_SYMTAB:
+			s.Stripped = false
+		case sh.Name == ".debug_info" || sh.Name == ".zdebug_info":
+			s.DebugInfo = true
+		case sh.Name == ".note.GNU-stack" && !stack:
+			s.NX = sh.Flags&SHF_EXECINSTR == 0
+		}
+	}
+
+	fortified := make(map[string]bool)
+	unchecked := make(map[string]bool)
+	for i := range f.Symbols {
+		name := f.Symbols[i].Name
+		imported := f.Symbols[i].Shndx == SHN_UNDEF
+		used := imported || !needed
+		switch {
+		case name == "__stack_chk_fail" || name == "__stack_chk_fail_local" || name == "__stack_chk_guard":
+			s.Canary = s.Canary || used
+		case strings.HasPrefix(name, "__") && strings.HasSuffix(name, "_chk"):
+			if fn := strings.TrimSuffix(strings.TrimPrefix(name, "__"), "_chk"); fortifiable[fn] && used {
+				fortified[fn] = true
+			}
+		case fortifiable[name] && imported:
+			unchecked[name] = true
+		}
+	}
+	for fn := range fortified {
+		s.Fortified = append(s.Fortified, fn)
+	}
+	for fn := range unchecked {
+		if !fortified[fn] {
+			s.Fortifiable = append(s.Fortifiable, fn)
+		}
+	}
+	sort.Strings(s.Fortified)
+	sort.Strings(s.Fortifiable)
+
+	if v, ok := f.GNUPropertyValue(GNU_PROPERTY_X86_FEATURE_1_AND); ok {
+		s.IBT = v&GNU_PROPERTY_X86_FEATURE_1_IBT != 0
+		s.SHSTK = v&GNU_PROPERTY_X86_FEATURE_1_SHSTK != 0
+	}
+	if v, ok := f.GNUPropertyValue(GNU_PROPERTY_AARCH64_FEATURE_1_AND); ok {
+		s.BTI = v&GNU_PROPERTY_AARCH64_FEATURE_1_BTI != 0
+		s.PAC = v&GNU_PROPERTY_AARCH64_FEATURE_1_PAC != 0
+	}
+	return s
+}
+
+func (f *File) DisplaySecurity(w io.Writer) {
+	s := f.Security()
+
+	fmt.Fprintf(w, "\nSecurity features:\n")
+	row := func(name, value string) {
+		fmt.Fprintf(w, "  %-12s %s\n", name+":", value)
+	}
+
+	switch s.RELRO {
+	case RELROFull:
+		row("RELRO", "Full RELRO")
+	case RELROPartial:
+		row("RELRO", "Partial RELRO")
+	default:
+		row("RELRO", "No RELRO")
+	}
+	row("Stack", enabled(s.Canary, "Canary found", "No canary found"))
+	row("NX", enabled(s.NX, "NX enabled", "NX disabled"))
+	switch s.PIE {
+	case PIEExecutable:
+		row("PIE", "PIE enabled")
+	case PIESharedObj:
+		row("PIE", "DSO")
+	case PIERelocatable:
+		row("PIE", "REL")
+	default:
+		row("PIE", "No PIE")
+	}
+	fortify := "No"
+	if len(s.Fortified) > 0 {
+		fortify = "Yes"
+	}
+	row("FORTIFY", fmt.Sprintf("%s (%d fortified, %d fortifiable)", fortify, len(s.Fortified), len(s.Fortifiable)))
+	row("RPATH", orNone(s.RPath, "No RPATH"))
+	row("RUNPATH", orNone(s.RunPath, "No RUNPATH"))
+	switch f.Machine {
+	case EM_X86_64, EM_386:
+		row("IBT", enabled(s.IBT, "Enabled", "Disabled"))
+		row("SHSTK", enabled(s.SHSTK, "Enabled", "Disabled"))
+	case EM_AARCH64:
+		row("BTI", enabled(s.BTI, "Enabled", "Disabled"))
+		row("PAC", enabled(s.PAC, "Enabled", "Disabled"))
+	}
+	switch {
+	case !s.Stripped && s.DebugInfo:
+		row("Symbols", "Not stripped, with debug info")
+	case !s.Stripped:
+		row("Symbols", "Not stripped")
+	case s.DebugInfo:
+		row("Symbols", "Stripped, with debug info")
+	default:
+		row("Symbols", "Stripped")
+	}
+
+	if len(s.Fortified) > 0 {
+		fmt.Fprintf(w, "\nFortified functions: %s\n", strings.Join(s.Fortified, ", "))
+	}
+	if len(s.Fortifiable) > 0 {
+		fmt.Fprintf(w, "\nFortifiable functions: %s\n", strings.Join(s.Fortifiable, ", "))
+	}
+}
+
+func enabled(ok bool, yes, no string) string {
+	if ok {
+		return yes
+	}
+	return no
+}
+
+func orNone(s, none string) string {
+	if s == "" {
+		return none
+	}
+	return s
+}
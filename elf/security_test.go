@@ -0,0 +1,64 @@
+package elf
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSecurity(t *testing.T) {
+	tests := []struct {
+		file string
+		want Security
+	}{
+		{"prog", Security{RELRO: RELRONone, PIE: PIENone, DebugInfo: true}},
+		{"prog-hardened", Security{RELRO: RELROFull, NX: true, PIE: PIEExecutable, Canary: true,
+			IBT: true, SHSTK: true, DebugInfo: true}},
+		{"libv1.so", Security{RELRO: RELROPartial, NX: true, PIE: PIESharedObj, DebugInfo: true}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := testFile(t, tt.file).Security()
+			if !reflect.DeepEqual(*got, tt.want) {
+				t.Errorf("got %+v\nwant %+v", *got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSecurityImports(t *testing.T) {
+	symbols := func(shndx uint16) []Symbol {
+		return []Symbol{
+			{Name: "__stack_chk_fail", Shndx: shndx},
+			{Name: "__memcpy_chk", Shndx: shndx},
+			{Name: "strcpy", Shndx: SHN_UNDEF},
+		}
+	}
+	needed := []DynamicEntry{{Tag: DT_NEEDED, Str: "libc.so.6"}}
+	tests := []struct {
+		name        string
+		f           *File
+		canary      bool
+		fortified   []string
+		fortifiable []string
+	}{
+		// A program linked against libc imports the checked functions.
+		{"program", &File{Type: ET_DYN, Dynamic: needed, Symbols: symbols(SHN_UNDEF)},
+			true, []string{"memcpy"}, []string{"strcpy"}},
+		// libc defines them without being fortified itself.
+		{"libc", &File{Type: ET_DYN, Dynamic: needed, Symbols: symbols(12)},
+			false, nil, []string{"strcpy"}},
+		// A static program has them linked in.
+		{"static", &File{Type: ET_EXEC, Symbols: symbols(12)},
+			true, []string{"memcpy"}, []string{"strcpy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := tt.f.Security()
+			if s.Canary != tt.canary || !reflect.DeepEqual(s.Fortified, tt.fortified) ||
+				!reflect.DeepEqual(s.Fortifiable, tt.fortifiable) {
+				t.Errorf("got canary %v, fortified %q, fortifiable %q; want %v, %q, %q", s.Canary,
+					s.Fortified, s.Fortifiable, tt.canary, tt.fortified, tt.fortifiable)
+			}
+		})
+	}
+}
@@ -0,0 +1,300 @@
+package elf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Ways SizeBreakdown can attribute the size of a file.
+const (
+	SizeBySegments = "segments"
+	SizeBySections = "sections"
+	SizeBySymbols  = "symbols"
+)
+
+// SizeRow is the part of a file attributed to one name: how many bytes of
+// the file it takes and how much memory it takes when loaded.
+type SizeRow struct {
+	Name     string
+	FileSize uint64
+	VMSize   uint64
+
+	// BaseFileSize and BaseVMSize are the sizes in the baseline given to
+	// CompareSizes.
+	BaseFileSize uint64
+	BaseVMSize   uint64
+}
+
+// CompareSizes merges the breakdown of a baseline file into rows, matching
+// rows by name. Rows only in the baseline are added with zero sizes.
+func CompareSizes(rows, baseline []SizeRow) []SizeRow {
+	index := make(map[string]int, len(rows))
+	out := make([]SizeRow, len(rows))
+	for i, r := range rows {
+		out[i] = SizeRow{Name: r.Name, FileSize: r.FileSize, VMSize: r.VMSize}
+		index[r.Name] = i
+	}
+	for _, b := range baseline {
+		i, ok := index[b.Name]
+		if !ok {
+			i = len(out)
+			index[b.Name] = i
+			out = append(out, SizeRow{Name: b.Name})
+		}
+		out[i].BaseFileSize += b.FileSize
+		out[i].BaseVMSize += b.VMSize
+	}
+	return out
+}
+
+// sizeClaim is a range of file offsets or addresses that belongs to a
+// name.
+type sizeClaim struct {
+	name       string
+	start, end uint64
+}
+
+// sizeDomain attributes the bytes of either the file or the memory image.
+// Ranges are claimed tier by tier; a range claims only the bytes that no
+// earlier tier, nor an earlier range of its own tier, claimed.
+type sizeDomain struct {
+	covered [][2]uint64 // sorted and disjoint
+	sizes   map[string]uint64
+	order   *[]string // names in order of appearance, shared by the domains
+	limit   uint64    // the end of the domain, or 0 if it has none
+}
+
+func (d *sizeDomain) claim(tier []sizeClaim) {
+	sort.SliceStable(tier, func(i, j int) bool { return tier[i].start < tier[j].start })
+	var claimed [][2]uint64
+	var end uint64
+	for _, c := range tier {
+		if d.limit > 0 {
+			c.end = min(c.end, d.limit)
+		}
+		start := max(c.start, end)
+		if c.end <= start {
+			continue
+		}
+		end = c.end
+		// Subtract what earlier tiers hold.
+		i := sort.Search(len(d.covered), func(i int) bool { return d.covered[i][1] > start })
+		for ; start < c.end; i++ {
+			stop := c.end
+			if i < len(d.covered) {
+				stop = min(stop, d.covered[i][0])
+			}
+			if stop > start {
+				d.add(c.name, stop-start)
+				claimed = append(claimed, [2]uint64{start, stop})
+			}
+			if i >= len(d.covered) {
+				break
+			}
+			start = max(start, d.covered[i][1])
+		}
+	}
+	d.covered = mergeRanges(append(d.covered, claimed...))
+}
+
+func (d *sizeDomain) add(name string, n uint64) {
+	d.sizes[name] += n
+	*d.order = append(*d.order, name)
+}
+
+func (d *sizeDomain) total() uint64 {
+	var n uint64
+	for _, r := range d.covered {
+		n += r[1] - r[0]
+	}
+	return n
+}
+
+func mergeRanges(ranges [][2]uint64) [][2]uint64 {
+	sort.Slice(ranges, func(i, j int) bool { return ranges[i][0] < ranges[j][0] })
+	var out [][2]uint64
+	for _, r := range ranges {
+		if n := len(out); n > 0 && r[0] <= out[n-1][1] {
+			out[n-1][1] = max(out[n-1][1], r[1])
+			continue
+		}
+		out = append(out, r)
+	}
+	return out
+}
+
+// SizeBreakdown attributes the size of the file, and of its image in
+// memory, to its segments, sections or symbols. What the chosen items do
+// not cover is attributed to the headers, to "[section NAME]" for the rest
+// of a section when breaking down by symbol, to "[LOAD #N]" for the rest
+// of a loadable segment and to "[Unmapped]" for file bytes outside all of
+// them. The rows add up to the size of the file and to the memory size of
+// the loadable segments.
+//
+// Relocatable objects have no segments; their allocated sections are
+// taken to be loaded one after another.
+func (f *File) SizeBreakdown(mode string) ([]SizeRow, error) {
+	var order []string
+	file := &sizeDomain{sizes: make(map[string]uint64), order: &order, limit: uint64(len(f.Raw))}
+	vm := &sizeDomain{sizes: make(map[string]uint64), order: &order}
+
+	var loads []ProgramHeader
+	for _, ph := range f.ProgramHeaders {
+		if ph.Type == PT_LOAD {
+			loads = append(loads, ph)
+		}
+	}
+	// vmBase maps the address of an allocated section to the memory
+	// image; it only differs from the address without segments.
+	vmBase := make(map[int]uint64)
+	var next uint64
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		// Thread-local bss takes no room in the image; it is only a
+		// template for the storage of each thread.
+		if sh.Flags&SHF_ALLOC == 0 || (sh.Flags&SHF_TLS != 0 && sh.Type == SHT_NOBITS) {
+			continue
+		}
+		if len(loads) > 0 {
+			vmBase[i] = sh.Addr
+		} else {
+			vmBase[i] = next
+			next += sh.Size
+		}
+	}
+
+	switch mode {
+	case SizeBySegments:
+		var fileTier, vmTier []sizeClaim
+		for i, ph := range loads {
+			name := fmt.Sprintf("LOAD #%d [%s]", i, SegmentFlagsString(ph.Flags))
+			fileTier = append(fileTier, sizeClaim{name, ph.Offset, ph.Offset + ph.FileSz})
+			vmTier = append(vmTier, sizeClaim{name, ph.VAddr, ph.VAddr + ph.MemSz})
+		}
+		file.claim(fileTier)
+		vm.claim(vmTier)
+	case SizeBySymbols:
+		fileTier, vmTier := f.symbolClaims(vmBase)
+		file.claim(fileTier)
+		vm.claim(vmTier)
+		fallthrough
+	case SizeBySections:
+		var fileTier, vmTier []sizeClaim
+		for i := range f.SectionHeaders {
+			sh := &f.SectionHeaders[i]
+			if sh.Type == SHT_NULL {
+				continue
+			}
+			name := sh.Name
+			if mode == SizeBySymbols {
+				name = "[section " + sh.Name + "]"
+			}
+			if sh.Type != SHT_NOBITS {
+				fileTier = append(fileTier, sizeClaim{name, sh.Offset, sh.Offset + sh.Size})
+			}
+			if base, ok := vmBase[i]; ok {
+				vmTier = append(vmTier, sizeClaim{name, base, base + sh.Size})
+			}
+		}
+		file.claim(fileTier)
+		vm.claim(vmTier)
+	default:
+		return nil, fmt.Errorf("unknown size breakdown %q", mode)
+	}
+
+	headerSize := uint64(64)
+	if f.Class == ELFCLASS32 {
+		headerSize = 52
+	}
+	file.claim([]sizeClaim{
+		{"[ELF Header]", 0, headerSize},
+		{"[Program Headers]", f.phoff, f.phoff + uint64(f.phnum)*uint64(f.phentsize)},
+		{"[Section Headers]", f.shoff, f.shoff + uint64(f.shnum)*uint64(f.shentsize)},
+	})
+	var fileTier, vmTier []sizeClaim
+	for i, ph := range loads {
+		name := fmt.Sprintf("[LOAD #%d]", i)
+		fileTier = append(fileTier, sizeClaim{name, ph.Offset, ph.Offset + ph.FileSz})
+		vmTier = append(vmTier, sizeClaim{name, ph.VAddr, ph.VAddr + ph.MemSz})
+	}
+	file.claim(fileTier)
+	vm.claim(vmTier)
+
+	size := uint64(len(f.Raw))
+	if covered := file.total(); covered < size {
+		file.add("[Unmapped]", size-covered)
+	}
+
+	var rows []SizeRow
+	seen := make(map[string]bool)
+	for _, name := range order {
+		if !seen[name] {
+			seen[name] = true
+			rows = append(rows, SizeRow{Name: name, FileSize: file.sizes[name], VMSize: vm.sizes[name]})
+		}
+	}
+	return rows, nil
+}
+
+// symbolClaims returns the ranges of the sized symbols of the static
+// symbol table, or of the dynamic one in stripped files. Aliases share
+// the range of the first symbol at an address.
+func (f *File) symbolClaims(vmBase map[int]uint64) ([]sizeClaim, []sizeClaim) {
+	var table *SectionHeader
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+		if sh.Type == SHT_SYMTAB || (sh.Type == SHT_DYNSYM && table == nil) {
+			table = sh
+		}
+	}
+	if table == nil {
+		return nil, nil
+	}
+	syms, err := f.readSymbolTable(table)
+	if err != nil {
+		return nil, nil
+	}
+
+	var tlsBase uint64
+	for _, ph := range f.ProgramHeaders {
+		if ph.Type == PT_TLS {
+			tlsBase = ph.VAddr
+		}
+	}
+	var fileTier, vmTier []sizeClaim
+	for i := range syms {
+		s := &syms[i]
+		ndx, ok := s.SectionIndex()
+		if !ok || ndx >= len(f.SectionHeaders) || s.Size == 0 || s.Name == "" {
+			continue
+		}
+		switch s.Type() {
+		case STT_NOTYPE, STT_OBJECT, STT_FUNC, STT_TLS, STT_GNU_IFUNC:
+		default:
+			continue
+		}
+		sh := &f.SectionHeaders[ndx]
+		// Symbol values are addresses, except in relocatable objects
+		// where they are offsets into their section and for thread-local
+		// variables, which are offsets into the TLS segment.
+		off := s.Value
+		switch {
+		case f.Type == ET_REL:
+		case s.Type() == STT_TLS:
+			off = tlsBase + s.Value - sh.Addr
+		default:
+			off -= sh.Addr
+		}
+		if off >= sh.Size {
+			continue
+		}
+		size := min(s.Size, sh.Size-off)
+		if sh.Type != SHT_NOBITS {
+			fileTier = append(fileTier, sizeClaim{s.Name, sh.Offset + off, sh.Offset + off + size})
+		}
+		if base, ok := vmBase[ndx]; ok {
+			vmTier = append(vmTier, sizeClaim{s.Name, base + off, base + off + size})
+		}
+	}
+	return fileTier, vmTier
+}
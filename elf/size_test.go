@@ -0,0 +1,96 @@
+package elf
+
+import (
+	"testing"
+)
+
+func sizeRows(t *testing.T, f *File, mode string) map[string]SizeRow {
+	t.Helper()
+	rows, err := f.SizeBreakdown(mode)
+	if err != nil {
+		t.Fatal(err)
+	}
+	out := make(map[string]SizeRow)
+	for _, r := range rows {
+		if _, ok := out[r.Name]; ok {
+			t.Errorf("%s: row %q appears twice", mode, r.Name)
+		}
+		out[r.Name] = r
+	}
+	return out
+}
+
+func TestSizeBreakdown(t *testing.T) {
+	f := testFile(t, "prog")
+
+	// Every mode accounts for the whole file and the whole memory image.
+	for _, mode := range []string{SizeBySegments, SizeBySections, SizeBySymbols} {
+		var file, vm uint64
+		for _, r := range sizeRows(t, f, mode) {
+			file += r.FileSize
+			vm += r.VMSize
+		}
+		if file != uint64(len(f.Raw)) || vm != 0x129+0x48 {
+			t.Errorf("%s: got file size %d and VM size %d, want %d and %d", mode, file, vm, len(f.Raw), 0x129+0x48)
+		}
+	}
+
+	tests := []struct {
+		mode     string
+		name     string
+		file, vm uint64
+	}{
+		{SizeBySegments, "LOAD #0 [RE]", 0x129, 0x129},
+		{SizeBySegments, "LOAD #1 [RW]", 0x40, 0x48},
+		{SizeBySegments, "[Section Headers]", 17 * 64, 0},
+		{SizeBySections, ".text", 65, 65},
+		{SizeBySections, ".data", 64, 64},
+		{SizeBySections, "[ELF Header]", 64, 0},
+		{SizeBySections, "[Program Headers]", 3 * 56, 0},
+		{SizeBySymbols, "buffer", 64, 64},
+		{SizeBySymbols, "add", 10, 10},
+		{SizeBySymbols, "_start", 55, 55},
+		{SizeBySymbols, "counter", 0, 4},
+	}
+	rows := make(map[string]map[string]SizeRow)
+	for _, tt := range tests {
+		if rows[tt.mode] == nil {
+			rows[tt.mode] = sizeRows(t, f, tt.mode)
+		}
+		r, ok := rows[tt.mode][tt.name]
+		if !ok {
+			t.Errorf("%s: no row %q", tt.mode, tt.name)
+			continue
+		}
+		if r.FileSize != tt.file || r.VMSize != tt.vm {
+			t.Errorf("%s: %s is %d/%d bytes, want %d/%d", tt.mode, tt.name, r.FileSize, r.VMSize, tt.file, tt.vm)
+		}
+	}
+}
+
+func TestCompareSizes(t *testing.T) {
+	base, err := testFile(t, "libv1.so").SizeBreakdown(SizeBySymbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := testFile(t, "libv2.so").SizeBreakdown(SizeBySymbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	byName := make(map[string]SizeRow)
+	for _, r := range CompareSizes(rows, base) {
+		byName[r.Name] = r
+	}
+
+	want := []SizeRow{
+		{Name: "lib_new", FileSize: 6, VMSize: 6},
+		{Name: "lib_old", BaseFileSize: 6, BaseVMSize: 6},
+		{Name: "lib_scale", FileSize: 8, VMSize: 8, BaseFileSize: 4, BaseVMSize: 4},
+		{Name: "lib_norm", FileSize: 14, VMSize: 14, BaseFileSize: 14, BaseVMSize: 14},
+	}
+	for _, w := range want {
+		if got := byName[w.Name]; got != w {
+			t.Errorf("got %+v, want %+v", got, w)
+		}
+	}
+}
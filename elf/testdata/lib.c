@@ -0,0 +1,60 @@
+/*
+ * Two versions of a shared library for the ABI comparison tests:
+ *
+ *   gcc -g -O1 -shared -fPIC -nostdlib -Wl,--build-id=none,-z,noseparate-code \
+ *       -o libv1.so lib.c
+ *   gcc -g -O1 -shared -fPIC -nostdlib -DV2 -Wl,--build-id=none,-z,noseparate-code \
+ *       -o libv2.so lib.c
+ *
+ * Version 2 removes lib_old, adds lib_new, changes the parameters of
+ * lib_scale, inserts a member in struct point and renumbers an enum.
+ */
+struct point {
+	int x;
+#ifdef V2
+	int z;
+#endif
+	int y;
+};
+
+enum color {
+	RED,
+#ifdef V2
+	ORANGE,
+#endif
+	GREEN,
+};
+
+int lib_version = 1;
+
+int lib_norm(struct point *p)
+{
+	return p->x * p->x + p->y * p->y;
+}
+
+enum color lib_color(void)
+{
+	return GREEN;
+}
+
+#ifdef V2
+long lib_scale(long v, int by)
+{
+	return v * by;
+}
+
+int lib_new(void)
+{
+	return 2;
+}
+#else
+int lib_scale(int v)
+{
+	return v * 2;
+}
+
+int lib_old(void)
+{
+	return 1;
+}
+#endif
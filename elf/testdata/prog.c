@@ -0,0 +1,45 @@
+/*
+ * Test program for the elf package, built without a C library so that it
+ * stays small:
+ *
+ *   gcc -g -O1 -nostdlib -static -no-pie -fno-stack-protector \
+ *       -fcf-protection=none -fno-asynchronous-unwind-tables \
+ *       -Wl,--build-id=none,-z,norelro,-z,execstack,-z,noseparate-code \
+ *       -o prog prog.c
+ *   gcc -g -O1 -nostdlib -fPIE -pie -DHARDENED -fstack-protector-all \
+ *       -fcf-protection=full -fno-asynchronous-unwind-tables \
+ *       -Wl,--build-id=none,-z,relro,-z,now,-z,noexecstack,-z,noseparate-code \
+ *       -o prog-hardened prog.c
+ */
+int counter;
+char buffer[64] = "hello";
+
+static inline __attribute__((always_inline)) int twice(int x)
+{
+	counter += x;
+	return x * 2;
+}
+
+int add(int a, int b)
+{
+	return twice(a) + b;
+}
+
+#ifdef HARDENED
+void __stack_chk_fail(void)
+{
+	for (;;)
+		;
+}
+#endif
+
+void _start(void)
+{
+	char local[32];
+
+	for (int i = 0; i < 32; i++)
+		local[i] = buffer[i];
+	counter = add(local[0], local[1]);
+	for (;;)
+		;
+}
@@ -0,0 +1,13 @@
+/*
+ * A library whose relative relocations are packed into SHT_RELR:
+ *
+ *   gcc -shared -fPIC -nostdlib -Wl,-z,pack-relative-relocs \
+ *       -Wl,--build-id=none,-z,noseparate-code -o librelr.so relr.c
+ */
+static int a, b, c, d;
+static int *ptrs[] = {&a, &b, &c, &d, &a, &b};
+
+int *get(int i)
+{
+	return ptrs[i];
+}
@@ -5,15 +5,17 @@ import (
 )
 
 const (
-	EI_MAG0    = 0
-	EI_MAG1    = 1
-	EI_MAG2    = 2
-	EI_MAG3    = 3
-	EI_CLASS   = 4
-	EI_DATA    = 5
-	EI_VERSION = 6
-	EI_OSABI   = 7
-	EI_NIDENT  = 16
+	EI_MAG0       = 0
+	EI_MAG1       = 1
+	EI_MAG2       = 2
+	EI_MAG3       = 3
+	EI_CLASS      = 4
+	EI_DATA       = 5
+	EI_VERSION    = 6
+	EI_OSABI      = 7
+	EI_ABIVERSION = 8
+	EI_PAD        = 9
+	EI_NIDENT     = 16
 
 	ELFMAG0 = 0x7f
 	ELFMAG1 = 'E'
@@ -45,32 +47,58 @@ const (
 )
 
 const (
-	EM_NONE    = 0
-	EM_386     = 3
-	EM_ARM     = 40
-	EM_X86_64  = 62
-	EM_AARCH64 = 183
-)
+	SHT_NULL          = 0
+	SHT_PROGBITS      = 1
+	SHT_SYMTAB        = 2
+	SHT_STRTAB        = 3
+	SHT_RELA          = 4
+	SHT_HASH          = 5
+	SHT_DYNAMIC       = 6
+	SHT_NOTE          = 7
+	SHT_NOBITS        = 8
+	SHT_REL           = 9
+	SHT_SHLIB         = 10
+	SHT_DYNSYM        = 11
+	SHT_INIT_ARRAY    = 14
+	SHT_FINI_ARRAY    = 15
+	SHT_PREINIT_ARRAY = 16
+	SHT_GROUP         = 17
+	SHT_SYMTAB_SHNDX  = 18
+	SHT_RELR          = 19
 
-const (
-	SHT_NULL     = 0
-	SHT_PROGBITS = 1
-	SHT_SYMTAB   = 2
-	SHT_STRTAB   = 3
-	SHT_RELA     = 4
-	SHT_HASH     = 5
-	SHT_DYNAMIC  = 6
-	SHT_NOTE     = 7
-	SHT_NOBITS   = 8
-	SHT_REL      = 9
-	SHT_SHLIB    = 10
-	SHT_DYNSYM   = 11
+	SHT_LOOS   = 0x60000000
+	SHT_HIOS   = 0x6fffffff
+	SHT_LOPROC = 0x70000000
+	SHT_HIPROC = 0x7fffffff
+	SHT_LOUSER = 0x80000000
+	SHT_HIUSER = 0xffffffff
+
+	SHT_GNU_verdef  = 0x6ffffffd
+	SHT_GNU_verneed = 0x6ffffffe
+	SHT_GNU_versym  = 0x6fffffff
 )
 
 const (
-	SHF_WRITE     = 0x1
-	SHF_ALLOC     = 0x2
-	SHF_EXECINSTR = 0x4
+	SHF_WRITE            = 0x1
+	SHF_ALLOC            = 0x2
+	SHF_EXECINSTR        = 0x4
+	SHF_MERGE            = 0x10
+	SHF_STRINGS          = 0x20
+	SHF_INFO_LINK        = 0x40
+	SHF_LINK_ORDER       = 0x80
+	SHF_OS_NONCONFORMING = 0x100
+	SHF_GROUP            = 0x200
+	SHF_TLS              = 0x400
+	SHF_COMPRESSED       = 0x800
+	SHF_MASKOS           = 0x0ff00000
+	SHF_MASKPROC         = 0xf0000000
+
+	SHF_GNU_RETAIN = 0x200000
+	SHF_GNU_MBIND  = 0x01000000
+
+	SHF_X86_64_LARGE = 0x10000000
+	SHF_ARM_PURECODE = 0x20000000
+	SHF_EXCLUDE      = 0x80000000
 )
 
 const (
@@ -81,15 +109,66 @@ const (
 	PT_NOTE    = 4
 	PT_SHLIB   = 5
 	PT_PHDR    = 6
+	PT_TLS     = 7
+
+	// PN_XNUM in e_phnum means the count is held in sh_info of section 0.
+	PN_XNUM = 0xffff
+
+	PT_LOOS   = 0x60000000
+	PT_HIOS   = 0x6fffffff
+	PT_LOPROC = 0x70000000
+	PT_HIPROC = 0x7fffffff
+)
+
+const (
+	PF_X = 0x1
+	PF_W = 0x2
+	PF_R = 0x4
+)
+
+const (
+	SHN_UNDEF     = 0
+	SHN_LORESERVE = 0xff00
+	SHN_ABS       = 0xfff1
+	SHN_COMMON    = 0xfff2
+	SHN_XINDEX    = 0xffff
+)
+
+const (
+	STB_LOCAL  = 0
+	STB_GLOBAL = 1
+	STB_WEAK   = 2
+
+	// STB_GNU_UNIQUE marks a symbol that the dynamic linker binds to a
+	// single definition in the whole process.
+	STB_GNU_UNIQUE = 10
+
+	STT_NOTYPE  = 0
+	STT_OBJECT  = 1
+	STT_FUNC    = 2
+	STT_SECTION = 3
+	STT_FILE    = 4
+	STT_COMMON  = 5
+	STT_TLS     = 6
+
+	// STT_GNU_IFUNC marks a function whose address is chosen at load time
+	// by calling the resolver the symbol points to.
+	STT_GNU_IFUNC = 10
+
+	STV_DEFAULT   = 0
+	STV_INTERNAL  = 1
+	STV_HIDDEN    = 2
+	STV_PROTECTED = 3
 )
 
 type Ident struct {
-	Magic   [4]byte
-	Class   uint8
-	Data    uint8
-	Version uint8
-	OSABI   uint8
-	Pad     [8]byte
+	Magic      [4]byte
+	Class      uint8
+	Data       uint8
+	Version    uint8
+	OSABI      uint8
+	ABIVersion uint8
+	Pad        [7]byte
 }
 
 type Header32 struct {
@@ -210,6 +289,140 @@ type Symbol struct {
 	Info  uint8
 	Other uint8
 	Shndx uint16
+
+	// XIndex is the section index taken from the SHT_SYMTAB_SHNDX section
+	// when Shndx is SHN_XINDEX.
+	XIndex uint32
+
+	// Version information from .gnu.version, set for dynamic symbols only.
+	// Library names the DT_NEEDED entry a required version belongs to.
+	Version       string
+	VersionHidden bool
+	Library       string
+}
+
+// SectionIndex returns the index of the section the symbol is defined in.
+// It reports false for undefined symbols and for the reserved indices such
+// as SHN_ABS and SHN_COMMON.
+func (s *Symbol) SectionIndex() (int, bool) {
+	switch {
+	case s.Shndx == SHN_XINDEX:
+		return int(s.XIndex), s.XIndex != SHN_UNDEF
+	case s.Shndx == SHN_UNDEF || s.Shndx >= SHN_LORESERVE:
+		return 0, false
+	}
+	return int(s.Shndx), true
+}
+
+func (s *Symbol) Bind() uint8 {
+	return s.Info >> 4
+}
+
+func (s *Symbol) Type() uint8 {
+	return s.Info & 0xf
+}
+
+func (s *Symbol) Visibility() uint8 {
+	return s.Other & 0x3
+}
+
+func ClassString(c uint8) string {
+	switch c {
+	case ELFCLASS32:
+		return "ELF32"
+	case ELFCLASS64:
+		return "ELF64"
+	default:
+		return fmt.Sprintf("Invalid (%d)", c)
+	}
+}
+
+func DataString(d uint8) string {
+	switch d {
+	case ELFDATA2LSB:
+		return "2's complement, little endian"
+	case ELFDATA2MSB:
+		return "2's complement, big endian"
+	default:
+		return fmt.Sprintf("Invalid (%d)", d)
+	}
+}
+
+// sectionFlagLetters are the readelf letters of the generic section flags.
+var sectionFlagLetters = []struct {
+	flag   uint64
+	letter byte
+}{
+	{SHF_WRITE, 'W'},
+	{SHF_ALLOC, 'A'},
+	{SHF_EXECINSTR, 'X'},
+	{SHF_MERGE, 'M'},
+	{SHF_STRINGS, 'S'},
+	{SHF_INFO_LINK, 'I'},
+	{SHF_LINK_ORDER, 'L'},
+	{SHF_OS_NONCONFORMING, 'O'},
+	{SHF_GROUP, 'G'},
+	{SHF_TLS, 'T'},
+	{SHF_COMPRESSED, 'C'},
+	{SHF_EXCLUDE, 'E'},
+}
+
+// SectionFlagsString returns the readelf flag letters for sh_flags. Flags
+// in the OS and processor specific ranges are named according to osabi and
+// machine; those without a name print as 'o' and 'p', and undefined
+// generic flags as 'x'.
+func SectionFlagsString(machine uint16, osabi uint8, flags uint64) string {
+	s := ""
+	for _, l := range sectionFlagLetters {
+		if flags&l.flag != 0 {
+			s += string(l.letter)
+			flags &^= l.flag
+		}
+	}
+
+	gnu := osabi == ELFOSABI_NONE || osabi == ELFOSABI_GNU || osabi == ELFOSABI_FREEBSD
+	if gnu && flags&SHF_GNU_RETAIN != 0 {
+		s += "R"
+		flags &^= SHF_GNU_RETAIN
+	}
+	if gnu && flags&SHF_GNU_MBIND != 0 {
+		s += "D"
+		flags &^= SHF_GNU_MBIND
+	}
+	if machine == EM_X86_64 && flags&SHF_X86_64_LARGE != 0 {
+		s += "l"
+		flags &^= SHF_X86_64_LARGE
+	}
+	if machine == EM_ARM && flags&SHF_ARM_PURECODE != 0 {
+		s += "y"
+		flags &^= SHF_ARM_PURECODE
+	}
+
+	if flags&SHF_MASKOS != 0 {
+		s += "o"
+	}
+	if flags&SHF_MASKPROC != 0 {
+		s += "p"
+	}
+	if flags&^(SHF_MASKOS|SHF_MASKPROC) != 0 {
+		s += "x"
+	}
+	return s
+}
+
+// SegmentFlagsString returns the R/W/E letters for p_flags.
+func SegmentFlagsString(flags uint32) string {
+	s := ""
+	if flags&PF_R != 0 {
+		s += "R"
+	}
+	if flags&PF_W != 0 {
+		s += "W"
+	}
+	if flags&PF_X != 0 {
+		s += "E"
+	}
+	return s
 }
 
 func TypeString(t uint16) string {
@@ -224,29 +437,20 @@ func TypeString(t uint16) string {
 		return "DYN (Shared object file)"
 	case ET_CORE:
 		return "CORE (Core file)"
-	default:
-		return fmt.Sprintf("Unknown (%#x)", t)
 	}
-}
-
-func MachineString(m uint16) string {
-	switch m {
-	case EM_NONE:
-		return "None"
-	case EM_386:
-		return "Intel 80386"
-	case EM_ARM:
-		return "ARM"
-	case EM_X86_64:
-		return "AMD x86-64"
-	case EM_AARCH64:
-		return "ARM AARCH64"
-	default:
-		return fmt.Sprintf("Unknown (%d)", m)
+	switch {
+	case t >= ET_LOOS && t <= ET_HIOS:
+		return fmt.Sprintf("OS Specific: (%#x)", t)
+	case t >= ET_LOPROC:
+		return fmt.Sprintf("Processor Specific: (%#x)", t)
 	}
+	return fmt.Sprintf("Unknown (%#x)", t)
 }
 
-func SectionTypeString(t uint32) string {
+// SectionTypeString names a section type. Types in the OS and processor
+// specific ranges are resolved according to osabi and machine, since each
+// operating system and architecture assigns them differently.
+func SectionTypeString(machine uint16, osabi uint8, t uint32) string {
 	switch t {
 	case SHT_NULL:
 		return "NULL"
@@ -272,12 +476,39 @@ func SectionTypeString(t uint32) string {
 		return "SHLIB"
 	case SHT_DYNSYM:
 		return "DYNSYM"
-	default:
-		return fmt.Sprintf("Unknown (%#x)", t)
+	case SHT_INIT_ARRAY:
+		return "INIT_ARRAY"
+	case SHT_FINI_ARRAY:
+		return "FINI_ARRAY"
+	case SHT_PREINIT_ARRAY:
+		return "PREINIT_ARRAY"
+	case SHT_GROUP:
+		return "GROUP"
+	case SHT_SYMTAB_SHNDX:
+		return "SYMTAB SECTION INDICES"
+	case SHT_RELR:
+		return "RELR"
+	}
+	switch {
+	case t >= SHT_LOOS && t <= SHT_HIOS:
+		if name, ok := osSectionType(osabi, t); ok {
+			return name
+		}
+		return fmt.Sprintf("LOOS+%#x", t-SHT_LOOS)
+	case t >= SHT_LOPROC && t <= SHT_HIPROC:
+		if name, ok := procSectionTypes[machine][t]; ok {
+			return name
+		}
+		return fmt.Sprintf("LOPROC+%#x", t-SHT_LOPROC)
+	case t >= SHT_LOUSER:
+		return fmt.Sprintf("LOUSER+%#x", t-SHT_LOUSER)
 	}
+	return fmt.Sprintf("Unknown (%#x)", t)
 }
 
-func SegmentTypeString(t uint32) string {
+// SegmentTypeString names a segment type. Types in the OS and processor
+// specific ranges are resolved according to osabi and machine.
+func SegmentTypeString(machine uint16, osabi uint8, t uint32) string {
 	switch t {
 	case PT_NULL:
 		return "NULL"
@@ -293,7 +524,20 @@ func SegmentTypeString(t uint32) string {
 		return "SHLIB"
 	case PT_PHDR:
 		return "PHDR"
-	default:
-		return fmt.Sprintf("Unknown (%#x)", t)
+	case PT_TLS:
+		return "TLS"
+	}
+	switch {
+	case t >= PT_LOOS && t <= PT_HIOS:
+		if name, ok := osSegmentType(osabi, t); ok {
+			return name
+		}
+		return fmt.Sprintf("LOOS+%#x", t-PT_LOOS)
+	case t >= PT_LOPROC && t <= PT_HIPROC:
+		if name, ok := procSegmentTypes[machine][t]; ok {
+			return name
+		}
+		return fmt.Sprintf("LOPROC+%#x", t-PT_LOPROC)
 	}
+	return fmt.Sprintf("Unknown (%#x)", t)
 }
\ No newline at end of file
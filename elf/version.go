@@ -0,0 +1,214 @@
+package elf
+
+import "fmt"
+
+const (
+	VER_NDX_LOCAL  = 0
+	VER_NDX_GLOBAL = 1
+
+	VER_FLG_BASE = 0x1
+	VER_FLG_WEAK = 0x2
+	VER_FLG_INFO = 0x4
+
+	versymHidden = 0x8000
+)
+
+// VersionDef is an entry of .gnu.version_d. Names[0] is the version being
+// defined; any further names are its predecessors.
+type VersionDef struct {
+	Index uint16
+	Flags uint16
+	Hash  uint32
+	Names []string
+}
+
+// VersionNeed is an entry of .gnu.version_r listing the versions required
+// from one shared library.
+type VersionNeed struct {
+	File     string
+	Versions []VersionNeedAux
+}
+
+// VersionNeedAux is a single required version. Index is the value used in
+// .gnu.version to refer to it (vna_other).
+type VersionNeedAux struct {
+	Name  string
+	Hash  uint32
+	Flags uint16
+	Index uint16
+}
+
+// parseVersions reads the symbol versioning sections. A section that cannot
+// be read is left out with a warning.
+func (f *File) parseVersions() {
+	for i := range f.SectionHeaders {
+		sh := &f.SectionHeaders[i]
+
+		var err error
+		switch sh.Type {
+		case SHT_GNU_versym:
+			err = f.parseVersym(sh)
+		case SHT_GNU_verdef:
+			err = f.parseVerdef(sh)
+		case SHT_GNU_verneed:
+			err = f.parseVerneed(sh)
+		}
+		if err != nil {
+			f.Warnings = append(f.Warnings, fmt.Sprintf("version section %s skipped: %v", sh.Name, err))
+		}
+	}
+}
+
+func (f *File) parseVersym(sh *SectionHeader) error {
+	data, err := f.GetSectionData(sh)
+	if err != nil {
+		return err
+	}
+	f.versym = make([]uint16, len(data)/2)
+	for i := range f.versym {
+		f.versym[i] = f.ByteOrder.Uint16(data[i*2:])
+	}
+	return nil
+}
+
+func (f *File) linkedStrings(sh *SectionHeader) ([]byte, error) {
+	if sh.Link >= uint32(len(f.SectionHeaders)) {
+		return nil, fmt.Errorf("section %s has invalid string table link %d", sh.Name, sh.Link)
+	}
+	return f.GetSectionData(&f.SectionHeaders[sh.Link])
+}
+
+func (f *File) parseVerdef(sh *SectionHeader) error {
+	data, err := f.GetSectionData(sh)
+	if err != nil {
+		return err
+	}
+	strTab, err := f.linkedStrings(sh)
+	if err != nil {
+		return err
+	}
+
+	off := uint64(0)
+	for n := uint32(0); n < sh.Info || sh.Info == 0; n++ {
+		if off+20 > uint64(len(data)) {
+			break
+		}
+		d := data[off:]
+		def := VersionDef{
+			Flags: f.ByteOrder.Uint16(d[2:]),
+			Index: f.ByteOrder.Uint16(d[4:]),
+			Hash:  f.ByteOrder.Uint32(d[8:]),
+		}
+		cnt := f.ByteOrder.Uint16(d[6:])
+		auxOff := off + uint64(f.ByteOrder.Uint32(d[12:]))
+		for j := uint16(0); j < cnt && auxOff+8 <= uint64(len(data)); j++ {
+			a := data[auxOff:]
+			def.Names = append(def.Names, getString(strTab, f.ByteOrder.Uint32(a)))
+			next := f.ByteOrder.Uint32(a[4:])
+			if next == 0 {
+				break
+			}
+			auxOff += uint64(next)
+		}
+		f.VersionDefs = append(f.VersionDefs, def)
+
+		next := f.ByteOrder.Uint32(d[16:])
+		if next == 0 {
+			break
+		}
+		off += uint64(next)
+	}
+	return nil
+}
+
+func (f *File) parseVerneed(sh *SectionHeader) error {
+	data, err := f.GetSectionData(sh)
+	if err != nil {
+		return err
+	}
+	strTab, err := f.linkedStrings(sh)
+	if err != nil {
+		return err
+	}
+
+	off := uint64(0)
+	for n := uint32(0); n < sh.Info || sh.Info == 0; n++ {
+		if off+16 > uint64(len(data)) {
+			break
+		}
+		d := data[off:]
+		need := VersionNeed{File: getString(strTab, f.ByteOrder.Uint32(d[4:]))}
+		cnt := f.ByteOrder.Uint16(d[2:])
+		auxOff := off + uint64(f.ByteOrder.Uint32(d[8:]))
+		for j := uint16(0); j < cnt && auxOff+16 <= uint64(len(data)); j++ {
+			a := data[auxOff:]
+			need.Versions = append(need.Versions, VersionNeedAux{
+				Hash:  f.ByteOrder.Uint32(a),
+				Flags: f.ByteOrder.Uint16(a[4:]),
+				Index: f.ByteOrder.Uint16(a[6:]),
+				Name:  getString(strTab, f.ByteOrder.Uint32(a[8:])),
+			})
+			next := f.ByteOrder.Uint32(a[12:])
+			if next == 0 {
+				break
+			}
+			auxOff += uint64(next)
+		}
+		f.VersionNeeds = append(f.VersionNeeds, need)
+
+		next := f.ByteOrder.Uint32(d[12:])
+		if next == 0 {
+			break
+		}
+		off += uint64(next)
+	}
+	return nil
+}
+
+// versionName resolves a .gnu.version index to the version name and, for
+// required versions, the library expected to provide it.
+func (f *File) versionName(idx uint16) (name, file string) {
+	idx &^= versymHidden
+	if idx == VER_NDX_LOCAL || idx == VER_NDX_GLOBAL {
+		return "", ""
+	}
+	for _, need := range f.VersionNeeds {
+		for _, v := range need.Versions {
+			if v.Index == idx {
+				return v.Name, need.File
+			}
+		}
+	}
+	for _, def := range f.VersionDefs {
+		if def.Index == idx && len(def.Names) > 0 {
+			return def.Names[0], ""
+		}
+	}
+	return "", ""
+}
+
+// applySymbolVersions attaches version information from .gnu.version to the
+// entries of the dynamic symbol table.
+func (f *File) applySymbolVersions(syms []Symbol) {
+	for i := range syms {
+		if i >= len(f.versym) {
+			break
+		}
+		v := f.versym[i]
+		syms[i].Version, syms[i].Library = f.versionName(v)
+		syms[i].VersionHidden = v&versymHidden != 0
+	}
+}
+
+// VersionedName returns the symbol name with its version appended the way
+// the GNU tools print it: name@@VER for the default version of a defined
+// symbol and name@VER otherwise.
+func (s *Symbol) VersionedName() string {
+	if s.Version == "" {
+		return s.Name
+	}
+	if s.Shndx != SHN_UNDEF && !s.VersionHidden {
+		return s.Name + "@@" + s.Version
+	}
+	return s.Name + "@" + s.Version
+}
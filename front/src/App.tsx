@@ -1,5 +1,7 @@
 import { useCallback, useEffect, useState } from "react";
 import "./App.css";
+import { Addr2Line } from "./components/Addr2Line";
+import { CoreDump } from "./components/CoreDump";
 import { ELFHeader } from "./components/ELFHeader";
 import { FileUpload } from "./components/FileUpload";
 import { HexDump } from "./components/HexDump";
@@ -13,7 +15,13 @@ function App() {
 	const [fileBuffer, setFileBuffer] = useState<ArrayBuffer | null>(null);
 	const [error, setError] = useState<string | null>(null);
 	const [activeTab, setActiveTab] = useState<
-		"header" | "sections" | "segments" | "symbols" | "hex"
+		| "header"
+		| "sections"
+		| "segments"
+		| "symbols"
+		| "hex"
+		| "addr2line"
+		| "core"
 	>("header");
 	const [wasmLoading, setWasmLoading] = useState(true);
 
@@ -58,6 +66,12 @@ function App() {
 
 				{error && <div className="error-message">{error}</div>}
 
+				{elfData?.warnings?.map((warning, index) => (
+					<div key={`warning-${index}`} className="error-message">
+						{warning}
+					</div>
+				))}
+
 				{elfData && (
 					<div className="elf-content">
 						<nav className="tabs">
@@ -96,23 +110,45 @@ function App() {
 							>
 								Hex Dump
 							</button>
+							<button
+								type="button"
+								className={activeTab === "addr2line" ? "active" : ""}
+								onClick={() => setActiveTab("addr2line")}
+							>
+								Addr2Line
+							</button>
+							{elfData.core && (
+								<button
+									type="button"
+									className={activeTab === "core" ? "active" : ""}
+									onClick={() => setActiveTab("core")}
+								>
+									Core Dump
+								</button>
+							)}
 						</nav>
 
 						<div className="tab-content">
 							{activeTab === "header" && <ELFHeader data={elfData} />}
 							{activeTab === "sections" && (
-								<SectionHeaders sections={elfData.sectionHeaders} />
+								<SectionHeaders sections={elfData.sectionHeaders ?? []} />
 							)}
 							{activeTab === "segments" && (
-								<ProgramHeaders segments={elfData.programHeaders} />
+								<ProgramHeaders segments={elfData.programHeaders ?? []} />
 							)}
-							{activeTab === "symbols" && <Symbols symbols={elfData.symbols} sectionHeaders={elfData.sectionHeaders} />}
+							{activeTab === "symbols" && <Symbols symbols={elfData.symbols ?? []} />}
 							{activeTab === "hex" && fileBuffer && (
 								<HexDump
 									buffer={fileBuffer}
-									sections={elfData.sectionHeaders}
+									sections={elfData.sectionHeaders ?? []}
 								/>
 							)}
+							{activeTab === "addr2line" && fileBuffer && (
+								<Addr2Line buffer={fileBuffer} />
+							)}
+							{activeTab === "core" && elfData.core && (
+								<CoreDump core={elfData.core} />
+							)}
 						</div>
 					</div>
 				)}
@@ -0,0 +1,87 @@
+import type React from "react";
+import { useCallback, useState } from "react";
+import { addr2line, formatHex, type SourceLocation } from "../utils/wasm";
+
+interface Addr2LineProps {
+	buffer: ArrayBuffer;
+}
+
+function locationString(loc: SourceLocation): string {
+	if (!loc.file) {
+		return loc.function ? "??:?" : "??:0";
+	}
+	const line = loc.line ? String(loc.line) : "?";
+	return loc.column ? `${loc.file}:${line}:${loc.column}` : `${loc.file}:${line}`;
+}
+
+export const Addr2Line: React.FC<Addr2LineProps> = ({ buffer }) => {
+	const [input, setInput] = useState("");
+	const [locations, setLocations] = useState<SourceLocation[]>([]);
+	const [error, setError] = useState<string | null>(null);
+
+	const handleLookup = useCallback(async () => {
+		setError(null);
+		try {
+			// Pick the 0x-prefixed addresses out of pasted text such as a
+			// crash log, or take every word if there are none.
+			const addresses =
+				input.match(/0x[0-9a-fA-F]+/g) ?? input.split(/\s+/).filter(Boolean);
+			setLocations(await addr2line(buffer, addresses.join(" ")));
+		} catch (err) {
+			setError(err instanceof Error ? err.message : "Failed to look up addresses");
+			setLocations([]);
+		}
+	}, [buffer, input]);
+
+	return (
+		<div className="addr2line">
+			<h2>Addr2Line</h2>
+			<div style={{ marginBottom: "1rem" }}>
+				<label htmlFor="addr2line-input" style={{ display: "block", marginBottom: "0.5rem" }}>
+					Addresses (hexadecimal, or paste a backtrace):
+				</label>
+				<textarea
+					id="addr2line-input"
+					value={input}
+					onChange={(event) => setInput(event.target.value)}
+					rows={6}
+					className="mono"
+					style={{
+						width: "100%",
+						padding: "0.5rem",
+						fontSize: "0.875rem",
+						borderRadius: "4px",
+						border: "1px solid #ccc",
+					}}
+				/>
+				<button type="button" onClick={handleLookup} style={{ marginTop: "0.5rem" }}>
+					Look up
+				</button>
+			</div>
+
+			{error && <p style={{ color: "#c00" }}>Error: {error}</p>}
+			{locations.length > 0 && (
+				<div className="table-container">
+					<table>
+						<thead>
+							<tr>
+								<th>Address</th>
+								<th>Function</th>
+								<th>Location</th>
+							</tr>
+						</thead>
+						<tbody>
+							{locations.map((loc, index) => (
+								<tr key={`${loc.address}-${index}`}>
+									<td className="mono">{formatHex(loc.address, 16)}</td>
+									<td>{loc.function || "??"}</td>
+									<td className="mono">{locationString(loc)}</td>
+								</tr>
+							))}
+						</tbody>
+					</table>
+				</div>
+			)}
+		</div>
+	);
+};
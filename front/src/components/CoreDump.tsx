@@ -0,0 +1,114 @@
+import type React from "react";
+import { type CoreDump as CoreDumpInfo, formatHex } from "../utils/wasm";
+
+interface CoreDumpProps {
+	core: CoreDumpInfo;
+}
+
+export const CoreDump: React.FC<CoreDumpProps> = ({ core }) => {
+	const { process, signal } = core;
+	return (
+		<div className="core-dump">
+			<h2>Core Dump</h2>
+			<table>
+				<tbody>
+					{process && (
+						<>
+							<tr>
+								<td>
+									<strong>Process:</strong>
+								</td>
+								<td>
+									{process.name} (PID {process.pid}, PPID {process.ppid}, UID{" "}
+									{process.uid}, GID {process.gid}, state {process.state})
+								</td>
+							</tr>
+							<tr>
+								<td>
+									<strong>Command line:</strong>
+								</td>
+								<td className="mono">{process.args}</td>
+							</tr>
+						</>
+					)}
+					{signal && (
+						<tr>
+							<td>
+								<strong>Signal:</strong>
+							</td>
+							<td>{signal.description}</td>
+						</tr>
+					)}
+				</tbody>
+			</table>
+
+			<h3>Threads ({core.threads.length})</h3>
+			{core.threads.map((thread, index) => (
+				<details key={`thread-${thread.pid}`} open={index === 0}>
+					<summary>
+						Thread {thread.pid}
+						{thread.signalName && ` (${thread.signalName})`}
+					</summary>
+					<div className="table-container">
+						<table>
+							<tbody>
+								{thread.registers.map((reg) => (
+									<tr key={reg.name}>
+										<td className="mono">{reg.name}</td>
+										<td className="mono">{formatHex(reg.value, 16)}</td>
+									</tr>
+								))}
+							</tbody>
+						</table>
+					</div>
+				</details>
+			))}
+
+			{core.files.length > 0 && (
+				<>
+					<h3>Mapped Files</h3>
+					<div className="table-container">
+						<table>
+							<thead>
+								<tr>
+									<th>Start</th>
+									<th>End</th>
+									<th>Offset</th>
+									<th>Path</th>
+								</tr>
+							</thead>
+							<tbody>
+								{core.files.map((file, index) => (
+									<tr key={`file-${index}`}>
+										<td className="mono">{formatHex(file.start, 16)}</td>
+										<td className="mono">{formatHex(file.end, 16)}</td>
+										<td className="mono">{formatHex(file.offset)}</td>
+										<td className="mono">{file.path}</td>
+									</tr>
+								))}
+							</tbody>
+						</table>
+					</div>
+				</>
+			)}
+
+			{core.auxv.length > 0 && (
+				<>
+					<h3>Auxiliary Vector</h3>
+					<div className="table-container">
+						<table>
+							<tbody>
+								{core.auxv.map((entry, index) => (
+									<tr key={`auxv-${index}`}>
+										<td className="mono">{entry.typeName}</td>
+										<td className="mono">{formatHex(entry.value)}</td>
+									</tr>
+								))}
+							</tbody>
+						</table>
+					</div>
+				</>
+			)}
+		</div>
+	);
+};
@@ -1,17 +1,12 @@
 import type React from "react";
-import {
-	type ELFInfo,
-	getClassName,
-	getDataEncoding,
-	getMachineString,
-	getTypeString,
-} from "../utils/wasm";
+import { type ELFInfo, formatHex } from "../utils/wasm";
 
 interface ELFHeaderProps {
 	data: ELFInfo;
 }
 
 export const ELFHeader: React.FC<ELFHeaderProps> = ({ data }) => {
+	const header = data.header;
 	return (
 		<div className="elf-header">
 			<h2>ELF Header</h2>
@@ -22,52 +17,71 @@ export const ELFHeader: React.FC<ELFHeaderProps> = ({ data }) => {
 							<strong>Magic:</strong>
 						</td>
 						<td className="mono">
-							{data.ident.Magic.map((b) =>
-								b.toString(16).padStart(2, "0"),
-							).join(" ")}
+							{header.magic.match(/../g)?.join(" ")}
 						</td>
 					</tr>
 					<tr>
 						<td>
 							<strong>Class:</strong>
 						</td>
-						<td>{getClassName(data.class)}</td>
+						<td>{header.className}</td>
 					</tr>
 					<tr>
 						<td>
 							<strong>Data:</strong>
 						</td>
-						<td>{getDataEncoding(data.ident.Data)}</td>
+						<td>{header.dataName}</td>
 					</tr>
 					<tr>
 						<td>
 							<strong>Version:</strong>
 						</td>
-						<td>{data.ident.Version} (current)</td>
+						<td>{header.version} (current)</td>
 					</tr>
 					<tr>
 						<td>
 							<strong>OS/ABI:</strong>
 						</td>
-						<td>{data.ident.OSABI}</td>
+						<td>{header.osabiName}</td>
+					</tr>
+					<tr>
+						<td>
+							<strong>ABI Version:</strong>
+						</td>
+						<td>{header.abiVersion}</td>
 					</tr>
 					<tr>
 						<td>
 							<strong>Type:</strong>
 						</td>
-						<td>{getTypeString(data.type)}</td>
+						<td>{header.typeName}</td>
 					</tr>
 					<tr>
 						<td>
 							<strong>Machine:</strong>
 						</td>
-						<td>{getMachineString(data.machine)}</td>
+						<td>{header.machineName}</td>
+					</tr>
+					<tr>
+						<td>
+							<strong>File version:</strong>
+						</td>
+						<td className="mono">0x{header.fileVersion.toString(16)}</td>
 					</tr>
 					<tr>
 						<td>
 							<strong>Entry point address:</strong>
 						</td>
-						<td className="mono">0x{data.entry.toString(16)}</td>
+						<td className="mono">{formatHex(header.entry)}</td>
+					</tr>
+					<tr>
+						<td>
+							<strong>Flags:</strong>
+						</td>
+						<td>
+							<span className="mono">0x{header.flags.toString(16)}</span>
+							{header.flagNames && `, ${header.flagNames}`}
+						</td>
 					</tr>
 				</tbody>
 			</table>
@@ -1,6 +1,11 @@
 import type React from "react";
 import { useCallback, useEffect, useState } from "react";
-import { getHexDump, type SectionHeader } from "../utils/wasm";
+import {
+	formatHex,
+	getHexDump,
+	hexToBigInt,
+	type SectionHeader,
+} from "../utils/wasm";
 
 interface HexDumpProps {
 	buffer: ArrayBuffer;
@@ -9,15 +14,18 @@ interface HexDumpProps {
 
 export const HexDump: React.FC<HexDumpProps> = ({ buffer, sections }) => {
 	const [selectedSection, setSelectedSection] = useState<string>("");
+	const [decompress, setDecompress] = useState(false);
 	const [hexDump, setHexDump] = useState<string>("");
 	const [loading, setLoading] = useState(false);
 	const [error, setError] = useState<string | null>(null);
 
 	useEffect(() => {
 		// Select first non-null section by default
-		const firstSection = sections.find((s) => s.Name && s.Size > 0);
+		const firstSection = sections.find(
+			(s) => s.name && hexToBigInt(s.size) > 0n,
+		);
 		if (firstSection) {
-			setSelectedSection(firstSection.Name);
+			setSelectedSection(firstSection.name);
 		}
 	}, [sections]);
 
@@ -27,7 +35,7 @@ export const HexDump: React.FC<HexDumpProps> = ({ buffer, sections }) => {
 		setLoading(true);
 		setError(null);
 		try {
-			const dump = await getHexDump(buffer, selectedSection);
+			const dump = await getHexDump(buffer, selectedSection, decompress);
 			setHexDump(dump);
 		} catch (err) {
 			setError(err instanceof Error ? err.message : "Failed to load hex dump");
@@ -35,7 +43,7 @@ export const HexDump: React.FC<HexDumpProps> = ({ buffer, sections }) => {
 		} finally {
 			setLoading(false);
 		}
-	}, [buffer, selectedSection]);
+	}, [buffer, selectedSection, decompress]);
 
 	useEffect(() => {
 		if (selectedSection) {
@@ -50,6 +58,8 @@ export const HexDump: React.FC<HexDumpProps> = ({ buffer, sections }) => {
 		[],
 	);
 
+	const selected = sections.find((s) => s.name === selectedSection);
+
 	return (
 		<div className="hex-dump">
 			<h2>Hex Dump</h2>
@@ -69,13 +79,25 @@ export const HexDump: React.FC<HexDumpProps> = ({ buffer, sections }) => {
 					}}
 				>
 					{sections
-						.filter((s) => s.Name && s.Size > 0)
+						.filter((s) => s.name && hexToBigInt(s.size) > 0n)
 						.map((section, _index) => (
-							<option key={section.Name} value={section.Name}>
-								{section.Name} (0x{section.Size.toString(16)} bytes)
+							<option key={section.name} value={section.name}>
+								{section.name} ({formatHex(section.size)} bytes)
 							</option>
 						))}
 				</select>
+				{selected?.compression && (
+					<label style={{ marginLeft: "1rem" }}>
+						<input
+							type="checkbox"
+							checked={decompress}
+							onChange={(event) => setDecompress(event.target.checked)}
+							style={{ marginRight: "0.25rem" }}
+						/>
+						Decompress ({selected.compression},{" "}
+						{formatHex(selected.uncompressedSize ?? "0x0")} bytes)
+					</label>
+				)}
 			</div>
 
 			{loading && <p>Loading hex dump...</p>}
@@ -1,9 +1,5 @@
 import type React from "react";
-import {
-	formatSegmentFlags,
-	getSegmentTypeString,
-	type ProgramHeader,
-} from "../utils/wasm";
+import { formatHex, type ProgramHeader } from "../utils/wasm";
 
 interface ProgramHeadersProps {
 	segments: ProgramHeader[];
@@ -30,24 +26,24 @@ export const ProgramHeaders: React.FC<ProgramHeadersProps> = ({ segments }) => {
 					<tbody>
 						{segments.map((segment, index) => (
 							<tr key={`segment-${index}`}>
-								<td>{getSegmentTypeString(segment.Type)}</td>
+								<td>{segment.typeName}</td>
 								<td className="mono">
-									0x{segment.Offset.toString(16).padStart(16, "0")}
+									{formatHex(segment.offset, 16)}
 								</td>
 								<td className="mono">
-									0x{segment.VAddr.toString(16).padStart(16, "0")}
+									{formatHex(segment.vaddr, 16)}
 								</td>
 								<td className="mono">
-									0x{segment.PAddr.toString(16).padStart(16, "0")}
+									{formatHex(segment.paddr, 16)}
 								</td>
 								<td className="mono">
-									0x{segment.FileSz.toString(16).padStart(16, "0")}
+									{formatHex(segment.filesz, 16)}
 								</td>
 								<td className="mono">
-									0x{segment.MemSz.toString(16).padStart(16, "0")}
+									{formatHex(segment.memsz, 16)}
 								</td>
-								<td>{formatSegmentFlags(segment.Flags)}</td>
-								<td className="mono">0x{segment.Align.toString(16)}</td>
+								<td>{segment.flagNames}</td>
+								<td className="mono">{formatHex(segment.align)}</td>
 							</tr>
 						))}
 					</tbody>
@@ -6,40 +6,49 @@ import { SectionHeaders } from "./SectionHeaders";
 describe("SectionHeaders", () => {
 	const mockSections: SectionHeader[] = [
 		{
-			Name: ".text",
-			Type: 1,
-			Flags: 6,
-			Addr: 0x1000,
-			Offset: 0x1000,
-			Size: 0x500,
-			Link: 0,
-			Info: 0,
-			AddrAlign: 16,
-			EntSize: 0,
+			index: 1,
+			name: ".text",
+			type: 1,
+			typeName: "PROGBITS",
+			flags: "0x6",
+			flagNames: "AX",
+			addr: "0x1000",
+			offset: "0x1000",
+			size: "0x500",
+			link: 0,
+			info: 0,
+			addrAlign: "0x10",
+			entSize: "0x0",
 		},
 		{
-			Name: ".data",
-			Type: 1,
-			Flags: 3,
-			Addr: 0x2000,
-			Offset: 0x2000,
-			Size: 0x200,
-			Link: 0,
-			Info: 0,
-			AddrAlign: 4,
-			EntSize: 0,
+			index: 2,
+			name: ".data",
+			type: 1,
+			typeName: "PROGBITS",
+			flags: "0x3",
+			flagNames: "WA",
+			addr: "0x2000",
+			offset: "0x2000",
+			size: "0x200",
+			link: 0,
+			info: 0,
+			addrAlign: "0x4",
+			entSize: "0x0",
 		},
 		{
-			Name: ".bss",
-			Type: 8,
-			Flags: 3,
-			Addr: 0x3000,
-			Offset: 0x2200,
-			Size: 0x100,
-			Link: 0,
-			Info: 0,
-			AddrAlign: 4,
-			EntSize: 0,
+			index: 3,
+			name: ".bss",
+			type: 8,
+			typeName: "NOBITS",
+			flags: "0x3",
+			flagNames: "WA",
+			addr: "0x3000",
+			offset: "0x2200",
+			size: "0x100",
+			link: 0,
+			info: 0,
+			addrAlign: "0x4",
+			entSize: "0x0",
 		},
 	];
 
@@ -78,16 +87,19 @@ describe("SectionHeaders", () => {
 		const sectionsWithEmptyFlags = [
 			...mockSections,
 			{
-				Name: ".comment",
-				Type: 1,
-				Flags: 0,
-				Addr: 0,
-				Offset: 0x4000,
-				Size: 0x50,
-				Link: 0,
-				Info: 0,
-				AddrAlign: 1,
-				EntSize: 0,
+				index: 4,
+				name: ".comment",
+				type: 1,
+				typeName: "PROGBITS",
+				flags: "0x0",
+				flagNames: "",
+				addr: "0x0",
+				offset: "0x4000",
+				size: "0x50",
+				link: 0,
+				info: 0,
+				addrAlign: "0x1",
+				entSize: "0x0",
 			},
 		];
 
@@ -102,6 +114,69 @@ describe("SectionHeaders", () => {
 		expect(screen.queryByText(".comment")).not.toBeInTheDocument();
 	});
 
+	it("filters sections by a single flag letter", () => {
+		const sectionsWithCaseFlags = [
+			...mockSections,
+			{
+				index: 4,
+				name: ".ldata",
+				type: 1,
+				typeName: "PROGBITS",
+				flags: "0x10000003",
+				flagNames: "WAl",
+				addr: "0x4000",
+				offset: "0x2300",
+				size: "0x100",
+				link: 0,
+				info: 0,
+				addrAlign: "0x8",
+				entSize: "0x0",
+			},
+			{
+				index: 5,
+				name: ".ARM.exidx",
+				type: 0x70000001,
+				typeName: "ARM_EXIDX",
+				flags: "0x82",
+				flagNames: "AL",
+				addr: "0x5000",
+				offset: "0x2400",
+				size: "0x10",
+				link: 1,
+				info: 0,
+				addrAlign: "0x4",
+				entSize: "0x0",
+			},
+		];
+
+		render(<SectionHeaders sections={sectionsWithCaseFlags} />);
+
+		// Only the flags present in the file are offered.
+		const select = screen.getByRole("combobox");
+		const options = screen
+			.getAllByRole("option")
+			.map((option) => (option as HTMLOptionElement).value);
+		expect(options).toEqual(["", "W", "A", "X", "L", "l"]);
+
+		// "l" (large) and "L" (link order) are different flags.
+		fireEvent.change(select, { target: { value: "l" } });
+		expect(screen.getByText("Showing 1 of 5 sections")).toBeInTheDocument();
+		expect(screen.getByText(".ldata")).toBeInTheDocument();
+		expect(screen.queryByText(".ARM.exidx")).not.toBeInTheDocument();
+
+		fireEvent.change(select, { target: { value: "L" } });
+		expect(screen.getByText("Showing 1 of 5 sections")).toBeInTheDocument();
+		expect(screen.getByText(".ARM.exidx")).toBeInTheDocument();
+		expect(screen.queryByText(".ldata")).not.toBeInTheDocument();
+
+		fireEvent.change(select, { target: { value: "X" } });
+		expect(screen.getByText("Showing 1 of 5 sections")).toBeInTheDocument();
+		expect(screen.getByText(".text")).toBeInTheDocument();
+
+		fireEvent.change(select, { target: { value: "" } });
+		expect(screen.getByText("Showing 5 of 5 sections")).toBeInTheDocument();
+	});
+
 	it("displays flag key information", () => {
 		render(<SectionHeaders sections={mockSections} />);
 
@@ -1,8 +1,9 @@
 import type React from "react";
 import { useMemo, useState } from "react";
 import {
-	formatFlags,
-	getSectionTypeString,
+	compareHex,
+	formatHex,
+	hexToBigInt,
 	type SectionHeader,
 } from "../utils/wasm";
 
@@ -10,31 +11,60 @@ interface SectionHeadersProps {
 	sections: SectionHeader[];
 }
 
+// Flag letters as printed by readelf, in the order they appear in flagNames.
+const FLAG_NAMES: Record<string, string> = {
+	W: "write",
+	A: "alloc",
+	X: "execute",
+	M: "merge",
+	S: "strings",
+	I: "info",
+	L: "link order",
+	O: "extra OS processing required",
+	G: "group",
+	T: "TLS",
+	C: "compressed",
+	E: "exclude",
+	R: "retain",
+	D: "mbind",
+	l: "large",
+	y: "purecode",
+	o: "OS specific",
+	p: "processor specific",
+	x: "unknown",
+};
+
 export const SectionHeaders: React.FC<SectionHeadersProps> = ({ sections }) => {
 	const [sortByAddress, setSortByAddress] = useState(false);
 	const [showFlaggedOnly, setShowFlaggedOnly] = useState(false);
+	const [flagFilter, setFlagFilter] = useState("");
+
+	// Only offer the flags that occur in this file.
+	const presentFlags = useMemo(() => {
+		const seen = new Set(sections.flatMap((s) => [...s.flagNames]));
+		return Object.keys(FLAG_NAMES).filter((f) => seen.has(f));
+	}, [sections]);
 
 	const processedSections = useMemo(() => {
-		// First map sections to include their original indices
-		let filtered = sections.map((section, originalIndex) => ({
-			...section,
-			originalIndex,
-		}));
+		let filtered = sections;
 
 		// Apply flag filtering
 		if (showFlaggedOnly) {
-			filtered = filtered.filter(
-				(section) => formatFlags(section.Flags).trim() !== "",
+			filtered = filtered.filter((section) => section.flagNames !== "");
+		}
+		if (flagFilter) {
+			filtered = filtered.filter((section) =>
+				section.flagNames.includes(flagFilter),
 			);
 		}
 
 		// Apply address sorting
 		if (sortByAddress) {
-			filtered = [...filtered].sort((a, b) => a.Addr - b.Addr);
+			filtered = [...filtered].sort((a, b) => compareHex(a.addr, b.addr));
 		}
 
 		return filtered;
-	}, [sections, sortByAddress, showFlaggedOnly]);
+	}, [sections, sortByAddress, showFlaggedOnly, flagFilter]);
 
 	return (
 		<div className="section-headers">
@@ -65,6 +95,20 @@ export const SectionHeaders: React.FC<SectionHeadersProps> = ({ sections }) => {
 					/>
 					Show only sections with flags
 				</label>
+				<label style={{ display: "flex", alignItems: "center", gap: "0.5rem" }}>
+					Flag
+					<select
+						value={flagFilter}
+						onChange={(e) => setFlagFilter(e.target.value)}
+					>
+						<option value="">Any</option>
+						{presentFlags.map((flag) => (
+							<option key={flag} value={flag}>
+								{flag} ({FLAG_NAMES[flag]})
+							</option>
+						))}
+					</select>
+				</label>
 			</div>
 
 			<div className="table-container">
@@ -86,26 +130,18 @@ export const SectionHeaders: React.FC<SectionHeadersProps> = ({ sections }) => {
 					</thead>
 					<tbody>
 						{processedSections.map((section, index) => (
-							<tr key={`section-${section.Name || section.Addr}-${index}`}>
-								<td>[{section.originalIndex}]</td>
-								<td>{section.Name || "<no-name>"}</td>
-								<td>{getSectionTypeString(section.Type)}</td>
-								<td className="mono">
-									0x{section.Addr.toString(16).padStart(16, "0")}
-								</td>
-								<td className="mono">
-									0x{section.Offset.toString(16).padStart(8, "0")}
-								</td>
-								<td className="mono">
-									0x{section.Size.toString(16).padStart(16, "0")}
-								</td>
-								<td className="mono">
-									0x{section.EntSize.toString(16).padStart(16, "0")}
-								</td>
-								<td>{formatFlags(section.Flags)}</td>
-								<td>{section.Link}</td>
-								<td>{section.Info}</td>
-								<td>{section.AddrAlign}</td>
+							<tr key={`section-${section.name || section.addr}-${index}`}>
+								<td>[{section.index}]</td>
+								<td>{section.name || "<no-name>"}</td>
+								<td>{section.typeName}</td>
+								<td className="mono">{formatHex(section.addr, 16)}</td>
+								<td className="mono">{formatHex(section.offset, 8)}</td>
+								<td className="mono">{formatHex(section.size, 16)}</td>
+								<td className="mono">{formatHex(section.entSize, 16)}</td>
+								<td>{section.flagNames}</td>
+								<td>{section.link}</td>
+								<td>{section.info}</td>
+								<td>{hexToBigInt(section.addrAlign).toString()}</td>
 							</tr>
 						))}
 					</tbody>
@@ -120,6 +156,12 @@ export const SectionHeaders: React.FC<SectionHeadersProps> = ({ sections }) => {
 				<div style={{ marginTop: "0.5rem" }}>
 					<strong>Key to Flags:</strong>
 					<br />W (write), A (alloc), X (execute)
+					<div>
+						M (merge), S (strings), I (info), L (link order), O (extra OS
+						processing required), G (group), T (TLS), C (compressed), E
+						(exclude), R (retain), D (mbind), l (large), y (purecode), o (OS
+						specific), p (processor specific), x (unknown)
+					</div>
 				</div>
 			</div>
 		</div>
@@ -1,82 +1,20 @@
 import type React from "react";
 import { useState, useMemo } from "react";
-import type { ELFSymbol, SectionHeader } from "../utils/wasm";
+import { compareHex, type ELFSymbol, formatHex } from "../utils/wasm";
 
 interface SymbolsProps {
 	symbols: ELFSymbol[];
-	sectionHeaders: SectionHeader[];
 }
 
-function getSymbolType(info: number): string {
-	const type = info & 0xf;
-	switch (type) {
-		case 0:
-			return "NOTYPE";
-		case 1:
-			return "OBJECT";
-		case 2:
-			return "FUNC";
-		case 3:
-			return "SECTION";
-		case 4:
-			return "FILE";
-		case 5:
-			return "COMMON";
-		case 6:
-			return "TLS";
-		default:
-			return `<${type}>`;
+function symbolName(symbol: ELFSymbol): string {
+	if (!symbol.version) {
+		return symbol.name;
 	}
+	const sep = symbol.shndx !== 0 && !symbol.versionHidden ? "@@" : "@";
+	return `${symbol.name}${sep}${symbol.version}`;
 }
 
-function getSymbolBind(info: number): string {
-	const bind = info >> 4;
-	switch (bind) {
-		case 0:
-			return "LOCAL";
-		case 1:
-			return "GLOBAL";
-		case 2:
-			return "WEAK";
-		default:
-			return `<${bind}>`;
-	}
-}
-
-function getSymbolVisibility(other: number): string {
-	const vis = other & 0x3;
-	switch (vis) {
-		case 0:
-			return "DEFAULT";
-		case 1:
-			return "INTERNAL";
-		case 2:
-			return "HIDDEN";
-		case 3:
-			return "PROTECTED";
-		default:
-			return `<${vis}>`;
-	}
-}
-
-function getSectionIndex(shndx: number, sectionHeaders: SectionHeader[]): string {
-	switch (shndx) {
-		case 0:
-			return "UND";
-		case 0xfff1:
-			return "ABS";
-		case 0xfff2:
-			return "COMMON";
-		default:
-			// If shndx is a valid section index, return the section name
-			if (shndx > 0 && shndx < sectionHeaders.length) {
-				return sectionHeaders[shndx].Name || shndx.toString();
-			}
-			return shndx.toString();
-	}
-}
-
-export const Symbols: React.FC<SymbolsProps> = ({ symbols, sectionHeaders }) => {
+export const Symbols: React.FC<SymbolsProps> = ({ symbols }) => {
 	const [hideNotype, setHideNotype] = useState(false);
 	const [sortBy, setSortBy] = useState<"num" | "name" | "size">("num");
 	const [sortOrder, setSortOrder] = useState<"asc" | "desc">("asc");
@@ -91,7 +29,7 @@ export const Symbols: React.FC<SymbolsProps> = ({ symbols, sectionHeaders }) =>
 		// Filter out NOTYPE symbols if checkbox is checked
 		let filtered = withIndices;
 		if (hideNotype) {
-			filtered = withIndices.filter(item => getSymbolType(item.symbol.Info) !== "NOTYPE");
+			filtered = withIndices.filter(item => item.symbol.typeName !== "NOTYPE");
 		}
 
 		// Sort symbols
@@ -103,10 +41,10 @@ export const Symbols: React.FC<SymbolsProps> = ({ symbols, sectionHeaders }) =>
 					compareValue = a.originalIndex - b.originalIndex;
 					break;
 				case "name":
-					compareValue = (a.symbol.Name || "").localeCompare(b.symbol.Name || "");
+					compareValue = (a.symbol.name || "").localeCompare(b.symbol.name || "");
 					break;
 				case "size":
-					compareValue = a.symbol.Size - b.symbol.Size;
+					compareValue = compareHex(a.symbol.size, b.symbol.size);
 					break;
 			}
 
@@ -209,14 +147,14 @@ export const Symbols: React.FC<SymbolsProps> = ({ symbols, sectionHeaders }) =>
 							<tr key={`symbol-${item.originalIndex}`}>
 								<td>{item.originalIndex}:</td>
 								<td className="mono">
-									0x{item.symbol.Value.toString(16).padStart(16, "0")}
+									{formatHex(item.symbol.value, 16)}
 								</td>
-								<td>{item.symbol.Size}</td>
-								<td>{getSymbolType(item.symbol.Info)}</td>
-								<td>{item.symbol.Name || "<no-name>"}</td>
-								<td>{getSectionIndex(item.symbol.Shndx, sectionHeaders)}</td>
-								<td>{getSymbolVisibility(item.symbol.Other)}</td>
-								<td>{getSymbolBind(item.symbol.Info)}</td>
+								<td>{BigInt(item.symbol.size).toString()}</td>
+								<td>{item.symbol.typeName}</td>
+								<td>{item.symbol.name ? symbolName(item.symbol) : "<no-name>"}</td>
+								<td>{item.symbol.sectionName}</td>
+								<td>{item.symbol.visibilityName}</td>
+								<td>{item.symbol.bindName}</td>
 							</tr>
 						))}
 					</tbody>
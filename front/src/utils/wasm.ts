@@ -1,54 +1,233 @@
-export interface ELFIdent {
-	Magic: number[];
-	Class: number;
-	Data: number;
-	Version: number;
-	OSABI: number;
-	Pad: number[];
-}
+// Schema version understood by this frontend. Must match schema.Version on
+// the Go side; parseELF rejects data produced for a different version.
+export const SCHEMA_VERSION = 2;
 
-export interface ELFInfo {
-	ident: ELFIdent;
+// 64-bit quantities are sent as "0x"-prefixed hex strings because JS
+// numbers cannot represent every 64-bit address exactly.
+export type Hex = string;
+
+export interface ELFHeaderInfo {
+	magic: string;
 	class: number;
+	className: string;
+	data: number;
+	dataName: string;
+	version: number;
+	osabi: number;
+	osabiName: string;
+	abiVersion: number;
 	type: number;
+	typeName: string;
 	machine: number;
-	entry: number;
-	sectionHeaders: SectionHeader[];
-	programHeaders: ProgramHeader[];
-	symbols: ELFSymbol[];
+	machineName: string;
+	fileVersion: number;
+	entry: Hex;
+	flags: number;
+	flagNames: string;
+}
+
+export interface ELFInfo {
+	schemaVersion: number;
+	header: ELFHeaderInfo;
+	sectionHeaders?: SectionHeader[];
+	programHeaders?: ProgramHeader[];
+	symbols?: ELFSymbol[];
+	dynamic?: DynamicEntry[];
+	relocations?: RelocationSection[];
+	notes?: ELFNote[];
+	versionDefs?: VersionDef[];
+	versionNeeds?: VersionNeed[];
+	versionRequirements?: VersionRequirement[];
+	attributes?: Attribute[];
+	debugInfo?: CompileUnit[];
+	security?: Security;
+	core?: CoreDump;
+	warnings?: string[];
 }
 
 export interface SectionHeader {
-	Name: string;
-	Type: number;
-	Flags: number;
-	Addr: number;
-	Offset: number;
-	Size: number;
-	Link: number;
-	Info: number;
-	AddrAlign: number;
-	EntSize: number;
+	index: number;
+	name: string;
+	type: number;
+	typeName: string;
+	flags: Hex;
+	flagNames: string;
+	addr: Hex;
+	offset: Hex;
+	size: Hex;
+	link: number;
+	info: number;
+	addrAlign: Hex;
+	entSize: Hex;
+	compression?: string;
+	uncompressedSize?: Hex;
 }
 
 export interface ProgramHeader {
-	Type: number;
-	Flags: number;
-	Offset: number;
-	VAddr: number;
-	PAddr: number;
-	FileSz: number;
-	MemSz: number;
-	Align: number;
+	type: number;
+	typeName: string;
+	flags: number;
+	flagNames: string;
+	offset: Hex;
+	vaddr: Hex;
+	paddr: Hex;
+	filesz: Hex;
+	memsz: Hex;
+	align: Hex;
 }
 
 export interface ELFSymbol {
-	Name: string;
-	Value: number;
-	Size: number;
-	Info: number;
-	Other: number;
-	Shndx: number;
+	name: string;
+	value: Hex;
+	size: Hex;
+	type: number;
+	typeName: string;
+	bind: number;
+	bindName: string;
+	visibility: number;
+	visibilityName: string;
+	shndx: number;
+	sectionName: string;
+	version?: string;
+	versionHidden?: boolean;
+	library?: string;
+}
+
+export interface DynamicEntry {
+	tag: Hex;
+	tagName: string;
+	value: Hex;
+	valueString: string;
+}
+
+export interface Relocation {
+	offset: Hex;
+	info: Hex;
+	type: number;
+	typeName: string;
+	sym: number;
+	symName: string;
+	symValue: Hex;
+	addend: Hex;
+}
+
+export interface RelocationSection {
+	name: string;
+	type: number;
+	typeName: string;
+	offset: Hex;
+	target: number;
+	targetName: string;
+	symbolTable: number;
+	entries: Relocation[];
+}
+
+export interface ELFNote {
+	section: string;
+	owner: string;
+	type: number;
+	typeName: string;
+	// Descriptor bytes, hex encoded
+	desc: string;
+	description?: string;
+}
+
+export interface VersionDef {
+	index: number;
+	flags: number;
+	hash: number;
+	names: string[];
+}
+
+export interface VersionNeed {
+	file: string;
+	versions: {
+		name: string;
+		hash: number;
+		flags: number;
+		index: number;
+	}[];
+}
+
+export interface VersionRequirement {
+	library: string;
+	family: string;
+	version: string;
+	symbols: string[] | null;
+}
+
+export interface Attribute {
+	vendor: string;
+	tag: number;
+	tagName: string;
+	value: string;
+}
+
+export interface CompileUnit {
+	offset: Hex;
+	name: string;
+	compDir?: string;
+	producer?: string;
+	language?: number;
+	languageName?: string;
+	version: number;
+	functions?: DebugFunction[];
+	variables?: DebugVariable[];
+	structs?: DebugStruct[];
+}
+
+export interface DebugFunction {
+	name: string;
+	linkageName?: string;
+	low: Hex;
+	high: Hex;
+	external: boolean;
+	returnType: string;
+	params?: { name?: string; type: string }[];
+	variadic?: boolean;
+	prototype: string;
+	declFile?: string;
+	declLine?: number;
+}
+
+export interface DebugVariable {
+	name: string;
+	linkageName?: string;
+	type: string;
+	size: Hex;
+	address?: Hex;
+	tls?: boolean;
+	external: boolean;
+	declFile?: string;
+	declLine?: number;
+}
+
+export interface DebugStruct {
+	kind: string;
+	name: string;
+	typedef?: boolean;
+	size: Hex;
+	members: {
+		name?: string;
+		type: string;
+		offset: Hex;
+		size: Hex;
+		bitOffset?: number;
+		bitSize?: number;
+		holeBits?: number;
+	}[];
+	paddingBits?: number;
+	declFile?: string;
+	declLine?: number;
+}
+
+export interface SourceLocation {
+	address: Hex;
+	function?: string;
+	file: string;
+	line: number;
+	column?: number;
+	discriminator?: number;
 }
 
 declare global {
@@ -61,6 +240,11 @@ declare global {
 		getHexDump: (
 			buffer: ArrayBuffer,
 			sectionName: string,
+			decompress?: boolean,
+		) => { data?: string; error?: string };
+		addr2line: (
+			buffer: ArrayBuffer,
+			addresses: string,
 		) => { data?: string; error?: string };
 	}
 }
@@ -95,16 +279,24 @@ export async function parseELF(buffer: ArrayBuffer): Promise<ELFInfo> {
 	if (!result.data) {
 		throw new Error("No data returned from parseELF");
 	}
-	return JSON.parse(result.data);
+
+	const info: ELFInfo = JSON.parse(result.data);
+	if (info.schemaVersion !== SCHEMA_VERSION) {
+		throw new Error(
+			`Unsupported schema version ${info.schemaVersion} (expected ${SCHEMA_VERSION})`,
+		);
+	}
+	return info;
 }
 
 export async function getHexDump(
 	buffer: ArrayBuffer,
 	sectionName: string,
+	decompress = false,
 ): Promise<string> {
 	await initWasm();
 
-	const result = window.getHexDump(buffer, sectionName);
+	const result = window.getHexDump(buffer, sectionName, decompress);
 	if (result.error) {
 		throw new Error(result.error);
 	}
@@ -115,127 +307,91 @@ export async function getHexDump(
 	return result.data;
 }
 
-// Type helpers
-export function getClassName(classValue: number): string {
-	switch (classValue) {
-		case 1:
-			return "ELF32";
-		case 2:
-			return "ELF64";
-		default:
-			return `Unknown (${classValue})`;
-	}
-}
+export async function addr2line(
+	buffer: ArrayBuffer,
+	addresses: string,
+): Promise<SourceLocation[]> {
+	await initWasm();
 
-export function getDataEncoding(data: number): string {
-	switch (data) {
-		case 1:
-			return "2's complement, little endian";
-		case 2:
-			return "2's complement, big endian";
-		default:
-			return `Invalid (${data})`;
+	const result = window.addr2line(buffer, addresses);
+	if (result.error) {
+		throw new Error(result.error);
 	}
-}
 
-export function getTypeString(type: number): string {
-	switch (type) {
-		case 0:
-			return "NONE (No file type)";
-		case 1:
-			return "REL (Relocatable file)";
-		case 2:
-			return "EXEC (Executable file)";
-		case 3:
-			return "DYN (Shared object file)";
-		case 4:
-			return "CORE (Core file)";
-		default:
-			return `Unknown (0x${type.toString(16)})`;
+	if (!result.data) {
+		throw new Error("No data returned from addr2line");
 	}
+	return JSON.parse(result.data);
 }
 
-export function getMachineString(machine: number): string {
-	switch (machine) {
-		case 0:
-			return "None";
-		case 3:
-			return "Intel 80386";
-		case 40:
-			return "ARM";
-		case 62:
-			return "AMD x86-64";
-		case 183:
-			return "ARM AARCH64";
-		default:
-			return `Unknown (${machine})`;
+// Hex helpers
+export function hexToBigInt(value: Hex): bigint {
+	if (value.startsWith("-")) {
+		return -BigInt(value.slice(1));
 	}
+	return BigInt(value);
 }
 
-export function getSectionTypeString(type: number): string {
-	switch (type) {
-		case 0:
-			return "NULL";
-		case 1:
-			return "PROGBITS";
-		case 2:
-			return "SYMTAB";
-		case 3:
-			return "STRTAB";
-		case 4:
-			return "RELA";
-		case 5:
-			return "HASH";
-		case 6:
-			return "DYNAMIC";
-		case 7:
-			return "NOTE";
-		case 8:
-			return "NOBITS";
-		case 9:
-			return "REL";
-		case 10:
-			return "SHLIB";
-		case 11:
-			return "DYNSYM";
-		default:
-			return `Unknown (0x${type.toString(16)})`;
-	}
+export function formatHex(value: Hex, width = 0): string {
+	return `0x${hexToBigInt(value).toString(16).padStart(width, "0")}`;
 }
 
-export function getSegmentTypeString(type: number): string {
-	switch (type) {
-		case 0:
-			return "NULL";
-		case 1:
-			return "LOAD";
-		case 2:
-			return "DYNAMIC";
-		case 3:
-			return "INTERP";
-		case 4:
-			return "NOTE";
-		case 5:
-			return "SHLIB";
-		case 6:
-			return "PHDR";
-		default:
-			return `Unknown (0x${type.toString(16)})`;
-	}
+export function compareHex(a: Hex, b: Hex): number {
+	const x = hexToBigInt(a);
+	const y = hexToBigInt(b);
+	return x < y ? -1 : x > y ? 1 : 0;
 }
 
-export function formatFlags(flags: number): string {
-	let s = "";
-	if (flags & 0x1) s += "W";
-	if (flags & 0x2) s += "A";
-	if (flags & 0x4) s += "X";
-	return s || "  ";
+// Hardening of the file, as reported by checksec.
+export interface Security {
+	relro: "none" | "partial" | "full";
+	nx: boolean;
+	pie: "no" | "yes" | "dso" | "rel";
+	canary: boolean;
+	fortified: string[];
+	fortifiable: string[];
+	rpath?: string;
+	runpath?: string;
+	ibt: boolean;
+	shstk: boolean;
+	bti: boolean;
+	pac: boolean;
+	stripped: boolean;
+	debugInfo: boolean;
 }
 
-export function formatSegmentFlags(flags: number): string {
-	let s = "";
-	if (flags & 0x4) s += "R";
-	if (flags & 0x2) s += "W";
-	if (flags & 0x1) s += "E";
-	return s.padEnd(3);
+// Process state of a core file (ET_CORE) from its NT_PRSTATUS, NT_PRPSINFO,
+// NT_SIGINFO, NT_AUXV and NT_FILE notes.
+export interface CoreDump {
+	process?: {
+		pid: number;
+		ppid: number;
+		pgrp: number;
+		sid: number;
+		uid: number;
+		gid: number;
+		state: string;
+		name: string;
+		args: string;
+	};
+	signal?: {
+		signo: number;
+		name: string;
+		code: number;
+		codeName: string;
+		errno?: number;
+		addr?: Hex;
+		senderPid?: number;
+		senderUid?: number;
+		description: string;
+	};
+	threads: {
+		pid: number;
+		signal?: number;
+		signalName?: string;
+		registers: { name: string; value: Hex }[];
+	}[];
+	auxv: { type: number; typeName: string; value: Hex }[];
+	files: { start: Hex; end: Hex; offset: Hex; path: string }[];
+	pageSize?: Hex;
 }
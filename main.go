@@ -4,6 +4,7 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"os"
 
@@ -12,7 +13,13 @@ import (
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		var exit *cmd.ExitError
+		if !errors.As(err, &exit) {
+			exit = &cmd.ExitError{Code: 1, Err: err}
+		}
+		if exit.Err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", exit.Err)
+		}
+		os.Exit(exit.Code)
 	}
 }
\ No newline at end of file
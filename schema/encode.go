@@ -0,0 +1,147 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+)
+
+func WriteJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// WriteYAML emits v as YAML. The value is marshaled to JSON first so both
+// formats share field names and ordering; strings are written in the
+// double-quoted style, which is valid YAML for any JSON string.
+func WriteYAML(w io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	n, err := readNode(dec)
+	if err != nil {
+		return err
+	}
+
+	var buf strings.Builder
+	writeNode(&buf, n, 0)
+	_, err = io.WriteString(w, buf.String())
+	return err
+}
+
+type yamlNode struct {
+	scalar string
+	keys   []string
+	items  []*yamlNode
+	isMap  bool
+	isList bool
+}
+
+func readNode(dec *json.Decoder) (*yamlNode, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	switch t := tok.(type) {
+	case json.Delim:
+		n := &yamlNode{isMap: t == '{', isList: t == '['}
+		for dec.More() {
+			if n.isMap {
+				k, err := dec.Token()
+				if err != nil {
+					return nil, err
+				}
+				key, ok := k.(string)
+				if !ok {
+					return nil, fmt.Errorf("unexpected object key %v", k)
+				}
+				n.keys = append(n.keys, key)
+			}
+			child, err := readNode(dec)
+			if err != nil {
+				return nil, err
+			}
+			n.items = append(n.items, child)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, err
+		}
+		return n, nil
+	case string:
+		q, _ := json.Marshal(t)
+		return &yamlNode{scalar: string(q)}, nil
+	case json.Number:
+		return &yamlNode{scalar: t.String()}, nil
+	case bool:
+		return &yamlNode{scalar: fmt.Sprintf("%t", t)}, nil
+	case nil:
+		return &yamlNode{scalar: "null"}, nil
+	}
+	return nil, fmt.Errorf("unexpected JSON token %v", tok)
+}
+
+var plainKey = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func yamlKey(k string) string {
+	if plainKey.MatchString(k) {
+		return k
+	}
+	q, _ := json.Marshal(k)
+	return string(q)
+}
+
+func (n *yamlNode) inline() (string, bool) {
+	switch {
+	case n.isMap && len(n.items) == 0:
+		return "{}", true
+	case n.isList && len(n.items) == 0:
+		return "[]", true
+	case !n.isMap && !n.isList:
+		return n.scalar, true
+	}
+	return "", false
+}
+
+func writeNode(buf *strings.Builder, n *yamlNode, indent int) {
+	pad := strings.Repeat("  ", indent)
+
+	if s, ok := n.inline(); ok {
+		buf.WriteString(pad + s + "\n")
+		return
+	}
+
+	for i, child := range n.items {
+		prefix := pad + "- "
+		if n.isMap {
+			prefix = pad + yamlKey(n.keys[i]) + ":"
+		}
+
+		if s, ok := child.inline(); ok {
+			if n.isMap {
+				prefix += " "
+			}
+			buf.WriteString(prefix + s + "\n")
+			continue
+		}
+
+		if n.isMap {
+			buf.WriteString(prefix + "\n")
+			writeNode(buf, child, indent+1)
+			continue
+		}
+
+		// List items that are collections start on the dash line.
+		var sub strings.Builder
+		writeNode(&sub, child, indent+1)
+		buf.WriteString(prefix + strings.TrimPrefix(sub.String(), pad+"  "))
+	}
+}
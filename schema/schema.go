@@ -0,0 +1,1058 @@
+// Package schema defines the serialized form of a parsed ELF file shared by
+// the CLI's machine-readable output and the WebAssembly bridge.
+//
+// Every 64-bit quantity is encoded as a "0x"-prefixed hex string so that
+// JavaScript consumers do not lose precision, and every enumerated field is
+// accompanied by its decoded name.
+package schema
+
+import (
+	"encoding/hex"
+	"fmt"
+
+	"github.com/elfviewer/elfviewer/elf"
+)
+
+// Version is bumped whenever a field is renamed, removed or changes
+// meaning. Adding fields does not change the version.
+const Version = 2
+
+// Hex is a 64-bit value encoded as a hex string. Addresses, offsets and
+// sizes are encoded as Hex or SignedHex, which JavaScript cannot round to
+// the nearest double; counts and type codes stay numbers.
+type Hex uint64
+
+func (h Hex) MarshalJSON() ([]byte, error) {
+	return []byte(fmt.Sprintf(`"0x%x"`, uint64(h))), nil
+}
+
+// SignedHex is a signed 64-bit value, such as a relocation addend, encoded
+// as a hex string with an optional leading minus sign.
+type SignedHex int64
+
+func (h SignedHex) MarshalJSON() ([]byte, error) {
+	if h < 0 {
+		return []byte(fmt.Sprintf(`"-0x%x"`, uint64(-h))), nil
+	}
+	return []byte(fmt.Sprintf(`"0x%x"`, int64(h))), nil
+}
+
+type ELFInfo struct {
+	SchemaVersion int    `json:"schemaVersion"`
+	Header        Header `json:"header"`
+
+	SectionHeaders      []Section            `json:"sectionHeaders,omitempty"`
+	ProgramHeaders      []Segment            `json:"programHeaders,omitempty"`
+	Symbols             []Symbol             `json:"symbols,omitempty"`
+	Dynamic             []DynamicEntry       `json:"dynamic,omitempty"`
+	Relocations         []RelocationSection  `json:"relocations,omitempty"`
+	Notes               []Note               `json:"notes,omitempty"`
+	VersionDefs         []VersionDef         `json:"versionDefs,omitempty"`
+	VersionNeeds        []VersionNeed        `json:"versionNeeds,omitempty"`
+	VersionRequirements []VersionRequirement `json:"versionRequirements,omitempty"`
+	Attributes          []Attribute          `json:"attributes,omitempty"`
+	DebugInfo           []CompileUnit        `json:"debugInfo,omitempty"`
+	Security            *Security            `json:"security,omitempty"`
+	Core                *Core                `json:"core,omitempty"`
+	HexDump             *HexDump             `json:"hexDump,omitempty"`
+	MemoryDump          *MemoryDump          `json:"memoryDump,omitempty"`
+
+	// Warnings describe the parts of the file that could not be parsed.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type Header struct {
+	Magic       string `json:"magic"`
+	Class       uint8  `json:"class"`
+	ClassName   string `json:"className"`
+	Data        uint8  `json:"data"`
+	DataName    string `json:"dataName"`
+	Version     uint8  `json:"version"`
+	OSABI       uint8  `json:"osabi"`
+	OSABIName   string `json:"osabiName"`
+	ABIVersion  uint8  `json:"abiVersion"`
+	Type        uint16 `json:"type"`
+	TypeName    string `json:"typeName"`
+	Machine     uint16 `json:"machine"`
+	MachineName string `json:"machineName"`
+	FileVersion uint32 `json:"fileVersion"`
+	Entry       Hex    `json:"entry"`
+	Flags       uint32 `json:"flags"`
+	FlagNames   string `json:"flagNames"`
+}
+
+type Section struct {
+	Index     int    `json:"index"`
+	Name      string `json:"name"`
+	Type      uint32 `json:"type"`
+	TypeName  string `json:"typeName"`
+	Flags     Hex    `json:"flags"`
+	FlagNames string `json:"flagNames"`
+	Addr      Hex    `json:"addr"`
+	Offset    Hex    `json:"offset"`
+	Size      Hex    `json:"size"`
+	Link      uint32 `json:"link"`
+	Info      uint32 `json:"info"`
+	AddrAlign Hex    `json:"addrAlign"`
+	EntSize   Hex    `json:"entSize"`
+	// Compression and UncompressedSize are set for compressed sections.
+	Compression      string `json:"compression,omitempty"`
+	UncompressedSize Hex    `json:"uncompressedSize,omitempty"`
+}
+
+type Segment struct {
+	Type      uint32 `json:"type"`
+	TypeName  string `json:"typeName"`
+	Flags     uint32 `json:"flags"`
+	FlagNames string `json:"flagNames"`
+	Offset    Hex    `json:"offset"`
+	VAddr     Hex    `json:"vaddr"`
+	PAddr     Hex    `json:"paddr"`
+	FileSz    Hex    `json:"filesz"`
+	MemSz     Hex    `json:"memsz"`
+	Align     Hex    `json:"align"`
+}
+
+type Symbol struct {
+	Name           string `json:"name"`
+	Value          Hex    `json:"value"`
+	Size           Hex    `json:"size"`
+	Type           uint8  `json:"type"`
+	TypeName       string `json:"typeName"`
+	Bind           uint8  `json:"bind"`
+	BindName       string `json:"bindName"`
+	Visibility     uint8  `json:"visibility"`
+	VisibilityName string `json:"visibilityName"`
+	Shndx          uint32 `json:"shndx"`
+	SectionName    string `json:"sectionName"`
+	Version        string `json:"version,omitempty"`
+	VersionHidden  bool   `json:"versionHidden,omitempty"`
+	Library        string `json:"library,omitempty"`
+}
+
+type DynamicEntry struct {
+	Tag         Hex    `json:"tag"`
+	TagName     string `json:"tagName"`
+	Value       Hex    `json:"value"`
+	ValueString string `json:"valueString"`
+}
+
+type RelocationSection struct {
+	Name        string       `json:"name"`
+	Type        uint32       `json:"type"`
+	TypeName    string       `json:"typeName"`
+	Offset      Hex          `json:"offset"`
+	Target      uint32       `json:"target"`
+	TargetName  string       `json:"targetName"`
+	SymbolTable uint32       `json:"symbolTable"`
+	Entries     []Relocation `json:"entries"`
+}
+
+type Relocation struct {
+	Offset   Hex       `json:"offset"`
+	Info     Hex       `json:"info"`
+	Type     uint32    `json:"type"`
+	TypeName string    `json:"typeName"`
+	Sym      uint32    `json:"sym"`
+	SymName  string    `json:"symName"`
+	SymValue Hex       `json:"symValue"`
+	Addend   SignedHex `json:"addend"`
+}
+
+type Note struct {
+	Section     string `json:"section"`
+	Owner       string `json:"owner"`
+	Type        uint32 `json:"type"`
+	TypeName    string `json:"typeName"`
+	Desc        string `json:"desc"`
+	Description string `json:"description,omitempty"`
+}
+
+type VersionDef struct {
+	Index uint16   `json:"index"`
+	Flags uint16   `json:"flags"`
+	Hash  uint32   `json:"hash"`
+	Names []string `json:"names"`
+}
+
+type VersionNeed struct {
+	File     string           `json:"file"`
+	Versions []VersionNeedAux `json:"versions"`
+}
+
+type VersionNeedAux struct {
+	Name  string `json:"name"`
+	Hash  uint32 `json:"hash"`
+	Flags uint16 `json:"flags"`
+	Index uint16 `json:"index"`
+}
+
+type VersionRequirement struct {
+	Library string   `json:"library"`
+	Family  string   `json:"family"`
+	Version string   `json:"version"`
+	Symbols []string `json:"symbols"`
+}
+
+// Attribute is a build attribute such as the RISC-V ISA string. Value is
+// the decoded value; integer attributes without a symbolic meaning are
+// given in decimal.
+type Attribute struct {
+	Vendor  string `json:"vendor"`
+	Tag     uint64 `json:"tag"`
+	TagName string `json:"tagName"`
+	Value   string `json:"value"`
+}
+
+// HexDump is the contents of a single section, hex encoded.
+type HexDump struct {
+	Section string `json:"section"`
+	Address Hex    `json:"address"`
+	Offset  Hex    `json:"offset"`
+	Size    Hex    `json:"size"`
+	Data    string `json:"data"`
+	// Decompressed is set when Data holds the uncompressed contents of a
+	// compressed section.
+	Decompressed bool `json:"decompressed,omitempty"`
+}
+
+// MemoryDump is the memory image at a virtual address, hex encoded.
+type MemoryDump struct {
+	Address Hex    `json:"address"`
+	Size    Hex    `json:"size"`
+	Data    string `json:"data"`
+}
+
+// CompileUnit summarizes the DWARF debugging information of one
+// compilation unit.
+type CompileUnit struct {
+	Offset       Hex        `json:"offset"`
+	Name         string     `json:"name"`
+	CompDir      string     `json:"compDir,omitempty"`
+	Producer     string     `json:"producer,omitempty"`
+	Language     uint64     `json:"language,omitempty"`
+	LanguageName string     `json:"languageName,omitempty"`
+	Version      uint16     `json:"version"`
+	Functions    []Function `json:"functions,omitempty"`
+	Variables    []Variable `json:"variables,omitempty"`
+	Structs      []Struct   `json:"structs,omitempty"`
+}
+
+type Function struct {
+	Name        string      `json:"name"`
+	LinkageName string      `json:"linkageName,omitempty"`
+	Low         Hex         `json:"low"`
+	High        Hex         `json:"high"`
+	External    bool        `json:"external"`
+	ReturnType  string      `json:"returnType"`
+	Params      []Parameter `json:"params,omitempty"`
+	Variadic    bool        `json:"variadic,omitempty"`
+	Prototype   string      `json:"prototype"`
+	DeclFile    string      `json:"declFile,omitempty"`
+	DeclLine    int         `json:"declLine,omitempty"`
+}
+
+type Parameter struct {
+	Name string `json:"name,omitempty"`
+	Type string `json:"type"`
+}
+
+// Variable is a global or static variable. Address is the offset in the
+// thread-local storage block when TLS is set, and is omitted for
+// variables that only have a constant value.
+type Variable struct {
+	Name        string `json:"name"`
+	LinkageName string `json:"linkageName,omitempty"`
+	Type        string `json:"type"`
+	Size        Hex    `json:"size"`
+	Address     *Hex   `json:"address,omitempty"`
+	TLS         bool   `json:"tls,omitempty"`
+	External    bool   `json:"external"`
+	DeclFile    string `json:"declFile,omitempty"`
+	DeclLine    int    `json:"declLine,omitempty"`
+}
+
+// Struct is the layout of a structure, class or union. Holes and padding
+// are given in bits.
+type Struct struct {
+	Kind        string         `json:"kind"`
+	Name        string         `json:"name"`
+	Typedef     bool           `json:"typedef,omitempty"`
+	Size        Hex            `json:"size"`
+	Members     []StructMember `json:"members"`
+	PaddingBits uint64         `json:"paddingBits,omitempty"`
+	DeclFile    string         `json:"declFile,omitempty"`
+	DeclLine    int            `json:"declLine,omitempty"`
+}
+
+// StructMember is a data member or, with an empty name, a base class.
+type StructMember struct {
+	Name      string `json:"name,omitempty"`
+	Type      string `json:"type"`
+	Offset    Hex    `json:"offset"`
+	Size      Hex    `json:"size"`
+	BitOffset uint64 `json:"bitOffset,omitempty"`
+	BitSize   uint64 `json:"bitSize,omitempty"`
+	HoleBits  uint64 `json:"holeBits,omitempty"`
+}
+
+// Views selects which parts of the file are included in an ELFInfo. The
+// header is always present.
+type Views struct {
+	Sections            bool
+	Segments            bool
+	Symbols             bool
+	Dynamic             bool
+	Relocations         bool
+	Notes               bool
+	Versions            bool
+	VersionRequirements bool
+	Attributes          bool
+	DebugInfo           bool
+	Security            bool
+	Core                bool
+}
+
+// AllViews includes every part of the file.
+var AllViews = Views{
+	Sections:            true,
+	Segments:            true,
+	Symbols:             true,
+	Dynamic:             true,
+	Relocations:         true,
+	Notes:               true,
+	Versions:            true,
+	VersionRequirements: true,
+	Attributes:          true,
+	DebugInfo:           true,
+	Security:            true,
+	Core:                true,
+}
+
+func New(f *elf.File, v Views) *ELFInfo {
+	info := &ELFInfo{
+		SchemaVersion: Version,
+		Header:        newHeader(f),
+		Warnings:      f.Warnings,
+	}
+
+	if v.Sections {
+		info.SectionHeaders = newSections(f)
+	}
+	if v.Segments {
+		info.ProgramHeaders = newSegments(f)
+	}
+	if v.Symbols {
+		info.Symbols = newSymbols(f)
+	}
+	if v.Dynamic {
+		info.Dynamic = newDynamic(f)
+	}
+	if v.Relocations {
+		info.Relocations = newRelocations(f)
+	}
+	if v.Notes {
+		info.Notes = newNotes(f)
+	}
+	if v.Versions {
+		info.VersionDefs, info.VersionNeeds = newVersions(f)
+	}
+	if v.VersionRequirements {
+		info.VersionRequirements = newVersionRequirements(f)
+	}
+	if v.Attributes {
+		info.Attributes = newAttributes(f)
+	}
+	if v.DebugInfo {
+		info.DebugInfo = newDebugInfo(f)
+	}
+	if v.Security {
+		info.Security = newSecurity(f)
+	}
+	if v.Core {
+		info.Core = newCore(f)
+	}
+
+	return info
+}
+
+func newHeader(f *elf.File) Header {
+	return Header{
+		Magic:       hex.EncodeToString(f.Ident.Magic[:]),
+		Class:       f.Ident.Class,
+		ClassName:   elf.ClassString(f.Ident.Class),
+		Data:        f.Ident.Data,
+		DataName:    elf.DataString(f.Ident.Data),
+		Version:     f.Ident.Version,
+		OSABI:       f.Ident.OSABI,
+		OSABIName:   elf.OSABIString(f.Ident.OSABI, f.Machine),
+		ABIVersion:  f.Ident.ABIVersion,
+		Type:        f.Type,
+		TypeName:    elf.TypeString(f.Type),
+		Machine:     f.Machine,
+		MachineName: elf.MachineString(f.Machine),
+		FileVersion: f.Version,
+		Entry:       Hex(f.Entry),
+		Flags:       f.Flags,
+		FlagNames:   elf.HeaderFlagsString(f.Machine, f.Flags),
+	}
+}
+
+func newSections(f *elf.File) []Section {
+	out := make([]Section, len(f.SectionHeaders))
+	for i, sh := range f.SectionHeaders {
+		out[i] = Section{
+			Index:     i,
+			Name:      sh.Name,
+			Type:      sh.Type,
+			TypeName:  elf.SectionTypeString(f.Machine, f.Ident.OSABI, sh.Type),
+			Flags:     Hex(sh.Flags),
+			FlagNames: elf.SectionFlagsString(f.Machine, f.Ident.OSABI, sh.Flags),
+			Addr:      Hex(sh.Addr),
+			Offset:    Hex(sh.Offset),
+			Size:      Hex(sh.Size),
+			Link:      sh.Link,
+			Info:      sh.Info,
+			AddrAlign: Hex(sh.AddrAlign),
+			EntSize:   Hex(sh.EntSize),
+		}
+		if ch, err := f.CompressionHeader(&f.SectionHeaders[i]); err == nil && ch != nil {
+			out[i].Compression = elf.CompressionTypeString(ch.Type)
+			out[i].UncompressedSize = Hex(ch.Size)
+		}
+	}
+	return out
+}
+
+func newSegments(f *elf.File) []Segment {
+	out := make([]Segment, len(f.ProgramHeaders))
+	for i, ph := range f.ProgramHeaders {
+		out[i] = Segment{
+			Type:      ph.Type,
+			TypeName:  elf.SegmentTypeString(f.Machine, f.Ident.OSABI, ph.Type),
+			Flags:     ph.Flags,
+			FlagNames: elf.SegmentFlagsString(ph.Flags),
+			Offset:    Hex(ph.Offset),
+			VAddr:     Hex(ph.VAddr),
+			PAddr:     Hex(ph.PAddr),
+			FileSz:    Hex(ph.FileSz),
+			MemSz:     Hex(ph.MemSz),
+			Align:     Hex(ph.Align),
+		}
+	}
+	return out
+}
+
+func newSymbols(f *elf.File) []Symbol {
+	out := make([]Symbol, len(f.Symbols))
+	for i := range f.Symbols {
+		sym := &f.Symbols[i]
+		shndx := uint32(sym.Shndx)
+		section := elf.SectionIndexString(sym.Shndx)
+		if ndx, ok := sym.SectionIndex(); ok {
+			shndx = uint32(ndx)
+			if ndx < len(f.SectionHeaders) {
+				section = f.SectionHeaders[ndx].Name
+			}
+		}
+		out[i] = Symbol{
+			Name:           sym.Name,
+			Value:          Hex(sym.Value),
+			Size:           Hex(sym.Size),
+			Type:           sym.Type(),
+			TypeName:       elf.SymbolTypeString(sym.Type()),
+			Bind:           sym.Bind(),
+			BindName:       elf.SymbolBindString(sym.Bind()),
+			Visibility:     sym.Visibility(),
+			VisibilityName: elf.SymbolVisibilityString(sym.Visibility()),
+			Shndx:          shndx,
+			SectionName:    section,
+			Version:        sym.Version,
+			VersionHidden:  sym.VersionHidden,
+			Library:        sym.Library,
+		}
+	}
+	return out
+}
+
+func newDynamic(f *elf.File) []DynamicEntry {
+	out := make([]DynamicEntry, len(f.Dynamic))
+	for i, d := range f.Dynamic {
+		out[i] = DynamicEntry{
+			Tag:         Hex(d.Tag),
+			TagName:     elf.DynamicTagString(d.Tag),
+			Value:       Hex(d.Value),
+			ValueString: elf.DynamicValueString(d),
+		}
+	}
+	return out
+}
+
+func newRelocations(f *elf.File) []RelocationSection {
+	out := make([]RelocationSection, len(f.Relocations))
+	for i := range f.Relocations {
+		rs := &f.Relocations[i]
+		entries := make([]Relocation, len(rs.Entries))
+		for j, r := range rs.Entries {
+			entries[j] = Relocation{
+				Offset:   Hex(r.Offset),
+				Info:     Hex(r.Info),
+				Type:     r.Type,
+				TypeName: elf.RelocationTypeString(f.Machine, r.Type),
+				Sym:      r.Sym,
+				SymName:  r.SymName,
+				SymValue: Hex(r.SymValue),
+				Addend:   SignedHex(r.Addend),
+			}
+		}
+		out[i] = RelocationSection{
+			Name:        rs.Name,
+			Type:        rs.Type,
+			TypeName:    elf.SectionTypeString(f.Machine, f.Ident.OSABI, rs.Type),
+			Offset:      Hex(rs.Offset),
+			Target:      rs.Target,
+			TargetName:  f.RelocationTarget(rs),
+			SymbolTable: rs.SymbolTable,
+			Entries:     entries,
+		}
+	}
+	return out
+}
+
+func newNotes(f *elf.File) []Note {
+	out := make([]Note, len(f.Notes))
+	for i := range f.Notes {
+		n := &f.Notes[i]
+		out[i] = Note{
+			Section:     n.Section,
+			Owner:       n.Name,
+			Type:        n.Type,
+			TypeName:    f.NoteTypeString(n),
+			Desc:        hex.EncodeToString(n.Desc),
+			Description: f.NoteDescription(n),
+		}
+	}
+	return out
+}
+
+func newVersions(f *elf.File) ([]VersionDef, []VersionNeed) {
+	var defs []VersionDef
+	for _, d := range f.VersionDefs {
+		defs = append(defs, VersionDef{Index: d.Index, Flags: d.Flags, Hash: d.Hash, Names: d.Names})
+	}
+
+	var needs []VersionNeed
+	for _, n := range f.VersionNeeds {
+		need := VersionNeed{File: n.File}
+		for _, v := range n.Versions {
+			need.Versions = append(need.Versions, VersionNeedAux{
+				Name:  v.Name,
+				Hash:  v.Hash,
+				Flags: v.Flags,
+				Index: v.Index,
+			})
+		}
+		needs = append(needs, need)
+	}
+	return defs, needs
+}
+
+func newVersionRequirements(f *elf.File) []VersionRequirement {
+	var out []VersionRequirement
+	for _, r := range f.MinimumVersions() {
+		out = append(out, VersionRequirement{
+			Library: r.Library,
+			Family:  r.Family,
+			Version: r.Version,
+			Symbols: r.Symbols,
+		})
+	}
+	return out
+}
+
+func newAttributes(f *elf.File) []Attribute {
+	var out []Attribute
+	for _, a := range f.Attributes {
+		out = append(out, Attribute{
+			Vendor:  a.Vendor,
+			Tag:     a.Tag,
+			TagName: elf.AttributeTagString(a),
+			Value:   elf.AttributeValueString(a),
+		})
+	}
+	return out
+}
+
+// newDebugInfo summarizes the compilation units of the file. Like the text
+// view, a structure defined the same way in several units is only listed
+// under the first.
+func newDebugInfo(f *elf.File) []CompileUnit {
+	di, _ := f.DebugInfo()
+	if di == nil {
+		return nil
+	}
+	var out []CompileUnit
+	seen := make(map[string]bool)
+	for _, u := range di.Units {
+		if u.IsTypeUnit() {
+			continue
+		}
+		cu := CompileUnit{
+			Offset:   Hex(u.Offset),
+			Name:     u.Name(),
+			CompDir:  u.CompDir(),
+			Producer: u.Producer(),
+			Version:  u.Version,
+		}
+		if u.Root.Attr(elf.DW_AT_language) != nil {
+			cu.Language = u.Language()
+			cu.LanguageName = elf.LanguageString(cu.Language)
+		}
+		for _, fn := range di.Functions(u) {
+			out := Function{
+				Name:        fn.Name,
+				LinkageName: fn.LinkageName,
+				Low:         Hex(fn.Low),
+				High:        Hex(fn.High),
+				External:    fn.External,
+				ReturnType:  fn.ReturnType,
+				Variadic:    fn.Variadic,
+				Prototype:   fn.Prototype(),
+				DeclFile:    fn.DeclFile,
+				DeclLine:    fn.DeclLine,
+			}
+			for _, p := range fn.Params {
+				out.Params = append(out.Params, Parameter{Name: p.Name, Type: p.Type})
+			}
+			cu.Functions = append(cu.Functions, out)
+		}
+		for _, v := range di.Variables(u) {
+			out := Variable{
+				Name:        v.Name,
+				LinkageName: v.LinkageName,
+				Type:        v.Type,
+				Size:        Hex(v.Size),
+				TLS:         v.TLS,
+				External:    v.External,
+				DeclFile:    v.DeclFile,
+				DeclLine:    v.DeclLine,
+			}
+			if v.HasAddress {
+				addr := Hex(v.Address)
+				out.Address = &addr
+			}
+			cu.Variables = append(cu.Variables, out)
+		}
+		for _, l := range di.Structs(u) {
+			key := fmt.Sprintf("%s %s %d %d", l.Kind, l.Name, l.Size, len(l.Members))
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			out := Struct{
+				Kind:        l.Kind,
+				Name:        l.Name,
+				Typedef:     l.Typedef,
+				Size:        Hex(l.Size),
+				PaddingBits: l.PaddingBits,
+				DeclFile:    l.DeclFile,
+				DeclLine:    l.DeclLine,
+				Members:     make([]StructMember, 0, len(l.Members)),
+			}
+			for _, m := range l.Members {
+				out.Members = append(out.Members, StructMember{
+					Name:      m.Name,
+					Type:      m.Type,
+					Offset:    Hex(m.Offset),
+					Size:      Hex(m.Size),
+					BitOffset: m.BitOffset,
+					BitSize:   m.BitSize,
+					HoleBits:  m.HoleBits,
+				})
+			}
+			cu.Structs = append(cu.Structs, out)
+		}
+		out = append(out, cu)
+	}
+	return out
+}
+
+// NewHexDump returns the contents of a section. With decompress set,
+// compressed sections are returned uncompressed.
+func NewHexDump(f *elf.File, sectionName string, decompress bool) (*HexDump, error) {
+	sh := f.GetSection(sectionName)
+	if sh == nil {
+		return nil, fmt.Errorf("section %s not found", sectionName)
+	}
+
+	ch, err := f.CompressionHeader(sh)
+	if err != nil {
+		return nil, err
+	}
+	var data []byte
+	if decompress {
+		data, err = f.DecompressedSectionData(sh)
+	} else {
+		data, err = f.GetSectionData(sh)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &HexDump{
+		Section: sectionName,
+		Address: Hex(sh.Addr),
+		Offset:  Hex(sh.Offset),
+		Size:    Hex(len(data)),
+		Data:    hex.EncodeToString(data),
+
+		Decompressed: decompress && ch != nil,
+	}, nil
+}
+
+// NewMemoryDump returns n bytes of memory at vaddr, failing unless all of
+// them can be read.
+func NewMemoryDump(f *elf.File, vaddr, n uint64) (*MemoryDump, error) {
+	data, err := f.ReadAt(vaddr, n)
+	if err != nil {
+		return nil, err
+	}
+	return &MemoryDump{Address: Hex(vaddr), Size: Hex(len(data)), Data: hex.EncodeToString(data)}, nil
+}
+
+// SourceLocation is the source position of a code address. File is empty
+// when the address has no line information.
+type SourceLocation struct {
+	Address       Hex    `json:"address"`
+	Function      string `json:"function,omitempty"`
+	File          string `json:"file"`
+	Line          int    `json:"line"`
+	Column        int    `json:"column,omitempty"`
+	Discriminator uint64 `json:"discriminator,omitempty"`
+}
+
+// NewSourceLocations maps each address to its source location.
+func NewSourceLocations(f *elf.File, addrs []uint64) ([]SourceLocation, error) {
+	locs := make([]SourceLocation, 0, len(addrs))
+	for _, a := range addrs {
+		loc, err := f.SourceLine(a)
+		if err != nil {
+			return nil, err
+		}
+		locs = append(locs, SourceLocation{
+			Address:       Hex(loc.Address),
+			Function:      loc.Function,
+			File:          loc.File,
+			Line:          loc.Line,
+			Column:        loc.Column,
+			Discriminator: loc.Discriminator,
+		})
+	}
+	return locs, nil
+}
+
+// ABIReport lists the ABI changes between two builds of a library.
+type ABIReport struct {
+	Old      string      `json:"old"`
+	New      string      `json:"new"`
+	Breaking int         `json:"breaking"`
+	Changes  []ABIChange `json:"changes"`
+	Warnings []string    `json:"warnings,omitempty"`
+}
+
+type ABIChange struct {
+	Kind     string `json:"kind"`
+	Breaking bool   `json:"breaking"`
+	Subject  string `json:"subject"`
+	Message  string `json:"message"`
+}
+
+// NewABIReport converts the comparison of the files named oldName and
+// newName.
+func NewABIReport(oldName, newName string, r *elf.ABIReport) *ABIReport {
+	out := &ABIReport{
+		Old:      oldName,
+		New:      newName,
+		Breaking: r.Breaking(),
+		Changes:  make([]ABIChange, 0, len(r.Changes)),
+		Warnings: r.Warnings,
+	}
+	for _, c := range r.Changes {
+		out.Changes = append(out.Changes, ABIChange{
+			Kind:     c.Kind,
+			Breaking: c.Breaking,
+			Subject:  c.Subject,
+			Message:  c.Message,
+		})
+	}
+	return out
+}
+
+// FileDiff is the structural difference between two ELF files.
+type FileDiff struct {
+	Old      string      `json:"old"`
+	New      string      `json:"new"`
+	OldSize  Hex         `json:"oldSize"`
+	NewSize  Hex         `json:"newSize"`
+	Header   []FieldDiff `json:"header,omitempty"`
+	Sections []DiffEntry `json:"sections,omitempty"`
+	Segments []DiffEntry `json:"segments,omitempty"`
+	Symbols  []DiffEntry `json:"symbols,omitempty"`
+	Dynamic  []DiffEntry `json:"dynamic,omitempty"`
+	Notes    []DiffEntry `json:"notes,omitempty"`
+}
+
+type FieldDiff struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// DiffEntry is an added, removed or changed item. SizeDelta is in bytes.
+type DiffEntry struct {
+	Kind      string      `json:"kind"`
+	Name      string      `json:"name"`
+	Fields    []FieldDiff `json:"fields,omitempty"`
+	SizeDelta SignedHex   `json:"sizeDelta,omitempty"`
+}
+
+// NewFileDiff converts the comparison of the files named oldName and
+// newName.
+func NewFileDiff(oldName, newName string, d *elf.FileDiff) *FileDiff {
+	return &FileDiff{
+		Old:      oldName,
+		New:      newName,
+		OldSize:  Hex(d.OldSize),
+		NewSize:  Hex(d.NewSize),
+		Header:   newFieldDiffs(d.Header),
+		Sections: newDiffEntries(d.Sections),
+		Segments: newDiffEntries(d.Segments),
+		Symbols:  newDiffEntries(d.Symbols),
+		Dynamic:  newDiffEntries(d.Dynamic),
+		Notes:    newDiffEntries(d.Notes),
+	}
+}
+
+func newFieldDiffs(fields []elf.FieldDiff) []FieldDiff {
+	var out []FieldDiff
+	for _, f := range fields {
+		out = append(out, FieldDiff{Field: f.Field, Old: f.Old, New: f.New})
+	}
+	return out
+}
+
+func newDiffEntries(entries []elf.DiffEntry) []DiffEntry {
+	var out []DiffEntry
+	for _, e := range entries {
+		out = append(out, DiffEntry{
+			Kind:      e.Kind,
+			Name:      e.Name,
+			Fields:    newFieldDiffs(e.Fields),
+			SizeDelta: SignedHex(e.SizeDelta),
+		})
+	}
+	return out
+}
+
+// SizeReport attributes the size of a file and of its memory image. The
+// deltas are set when comparing with a baseline.
+type SizeReport struct {
+	DataSource string    `json:"dataSource"`
+	Baseline   string    `json:"baseline,omitempty"`
+	Rows       []SizeRow `json:"rows"`
+	Total      SizeRow   `json:"total"`
+}
+
+type SizeRow struct {
+	Name      string    `json:"name"`
+	FileSize  Hex       `json:"fileSize"`
+	VMSize    Hex       `json:"vmSize"`
+	FileDelta SignedHex `json:"fileDelta,omitempty"`
+	VMDelta   SignedHex `json:"vmDelta,omitempty"`
+}
+
+// NewSizeReport converts the rows of a size breakdown by dataSource,
+// compared with the file named baseline if it is not empty.
+func NewSizeReport(dataSource, baseline string, rows []elf.SizeRow, total elf.SizeRow) *SizeReport {
+	row := func(r elf.SizeRow) SizeRow {
+		out := SizeRow{Name: r.Name, FileSize: Hex(r.FileSize), VMSize: Hex(r.VMSize)}
+		if baseline != "" {
+			out.FileDelta = SignedHex(r.FileSize - r.BaseFileSize)
+			out.VMDelta = SignedHex(r.VMSize - r.BaseVMSize)
+		}
+		return out
+	}
+	report := &SizeReport{
+		DataSource: dataSource,
+		Baseline:   baseline,
+		Rows:       make([]SizeRow, 0, len(rows)),
+		Total:      row(total),
+	}
+	for _, r := range rows {
+		report.Rows = append(report.Rows, row(r))
+	}
+	return report
+}
+
+// Security is the hardening of the file. RELRO is "none", "partial" or
+// "full"; PIE is "no", "yes", "dso" for shared libraries or "rel" for
+// relocatable objects.
+type Security struct {
+	RELRO       string   `json:"relro"`
+	NX          bool     `json:"nx"`
+	PIE         string   `json:"pie"`
+	Canary      bool     `json:"canary"`
+	Fortified   []string `json:"fortified"`
+	Fortifiable []string `json:"fortifiable"`
+	RPath       string   `json:"rpath,omitempty"`
+	RunPath     string   `json:"runpath,omitempty"`
+	IBT         bool     `json:"ibt"`
+	SHSTK       bool     `json:"shstk"`
+	BTI         bool     `json:"bti"`
+	PAC         bool     `json:"pac"`
+	Stripped    bool     `json:"stripped"`
+	DebugInfo   bool     `json:"debugInfo"`
+}
+
+func newSecurity(f *elf.File) *Security {
+	s := f.Security()
+	return &Security{
+		RELRO:       s.RELRO,
+		NX:          s.NX,
+		PIE:         s.PIE,
+		Canary:      s.Canary,
+		Fortified:   append([]string{}, s.Fortified...),
+		Fortifiable: append([]string{}, s.Fortifiable...),
+		RPath:       s.RPath,
+		RunPath:     s.RunPath,
+		IBT:         s.IBT,
+		SHSTK:       s.SHSTK,
+		BTI:         s.BTI,
+		PAC:         s.PAC,
+		Stripped:    s.Stripped,
+		DebugInfo:   s.DebugInfo,
+	}
+}
+
+// Core is the state of the crashed process in a core file.
+type Core struct {
+	Process  *CoreProcess `json:"process,omitempty"`
+	Signal   *CoreSignal  `json:"signal,omitempty"`
+	Threads  []CoreThread `json:"threads"`
+	Auxv     []AuxvEntry  `json:"auxv"`
+	Files    []MappedFile `json:"files"`
+	PageSize Hex          `json:"pageSize,omitempty"`
+}
+
+type CoreProcess struct {
+	PID   uint32 `json:"pid"`
+	PPID  uint32 `json:"ppid"`
+	PGRP  uint32 `json:"pgrp"`
+	SID   uint32 `json:"sid"`
+	UID   uint32 `json:"uid"`
+	GID   uint32 `json:"gid"`
+	State string `json:"state"`
+	Name  string `json:"name"`
+	Args  string `json:"args"`
+}
+
+// CoreSignal is the signal that killed the process. Addr is set for
+// faults and the sender for signals sent by another process.
+type CoreSignal struct {
+	Signo       int     `json:"signo"`
+	Name        string  `json:"name"`
+	Code        int32   `json:"code"`
+	CodeName    string  `json:"codeName"`
+	Errno       int32   `json:"errno,omitempty"`
+	Addr        *Hex    `json:"addr,omitempty"`
+	SenderPID   *uint32 `json:"senderPid,omitempty"`
+	SenderUID   *uint32 `json:"senderUid,omitempty"`
+	Description string  `json:"description"`
+}
+
+type CoreThread struct {
+	PID        uint32     `json:"pid"`
+	Signal     int        `json:"signal,omitempty"`
+	SignalName string     `json:"signalName,omitempty"`
+	Registers  []Register `json:"registers"`
+}
+
+type Register struct {
+	Name  string `json:"name"`
+	Value Hex    `json:"value"`
+}
+
+type AuxvEntry struct {
+	Type     uint64 `json:"type"`
+	TypeName string `json:"typeName"`
+	Value    Hex    `json:"value"`
+}
+
+type MappedFile struct {
+	Start  Hex    `json:"start"`
+	End    Hex    `json:"end"`
+	Offset Hex    `json:"offset"`
+	Path   string `json:"path"`
+}
+
+// newCore returns nil for files other than core files.
+func newCore(f *elf.File) *Core {
+	c, err := f.CoreDump()
+	if err != nil {
+		return nil
+	}
+	out := &Core{
+		Threads:  make([]CoreThread, 0, len(c.Threads)),
+		Auxv:     make([]AuxvEntry, 0, len(c.Auxv)),
+		Files:    make([]MappedFile, 0, len(c.Files)),
+		PageSize: Hex(c.PageSize),
+	}
+	if p := c.Process; p != nil {
+		out.Process = &CoreProcess{
+			PID:   p.PID,
+			PPID:  p.PPID,
+			PGRP:  p.PGRP,
+			SID:   p.SID,
+			UID:   p.UID,
+			GID:   p.GID,
+			State: string(p.State),
+			Name:  p.Name,
+			Args:  p.Args,
+		}
+	}
+	if s := c.Signal; s != nil {
+		out.Signal = &CoreSignal{
+			Signo:       s.Signo,
+			Name:        elf.SignalString(s.Signo),
+			Code:        s.Code,
+			CodeName:    elf.SignalCodeString(s.Signo, s.Code),
+			Errno:       s.Errno,
+			Description: s.String(),
+		}
+		if s.HasAddr {
+			addr := Hex(s.Addr)
+			out.Signal.Addr = &addr
+		}
+		if s.HasSender {
+			pid, uid := s.SenderPID, s.SenderUID
+			out.Signal.SenderPID, out.Signal.SenderUID = &pid, &uid
+		}
+	}
+	for _, t := range c.Threads {
+		thread := CoreThread{PID: t.PID, Signal: t.Signal, Registers: make([]Register, 0, len(t.Registers))}
+		if t.Signal != 0 {
+			thread.SignalName = elf.SignalString(t.Signal)
+		}
+		for _, r := range t.Registers {
+			thread.Registers = append(thread.Registers, Register{Name: r.Name, Value: Hex(r.Value)})
+		}
+		out.Threads = append(out.Threads, thread)
+	}
+	for _, e := range c.Auxv {
+		out.Auxv = append(out.Auxv, AuxvEntry{Type: e.Type, TypeName: elf.AuxvTypeString(e.Type), Value: Hex(e.Value)})
+	}
+	for _, m := range c.Files {
+		out.Files = append(out.Files, MappedFile{Start: Hex(m.Start), End: Hex(m.End), Offset: Hex(m.Offset), Path: m.Path})
+	}
+	return out
+}
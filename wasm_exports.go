@@ -5,23 +5,15 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
+	"strconv"
 	"strings"
 	"syscall/js"
 
 	"github.com/elfviewer/elfviewer/elf"
+	"github.com/elfviewer/elfviewer/schema"
 )
 
-type ELFInfo struct {
-	Ident          elf.Ident           `json:"ident"`
-	Class          uint8               `json:"class"`
-	Type           uint16              `json:"type"`
-	Machine        uint16              `json:"machine"`
-	Entry          uint64              `json:"entry"`
-	SectionHeaders []elf.SectionHeader `json:"sectionHeaders"`
-	ProgramHeaders []elf.ProgramHeader `json:"programHeaders"`
-	Symbols        []elf.Symbol        `json:"symbols"`
-}
-
 func parseELF(this js.Value, args []js.Value) interface{} {
 	if len(args) != 1 {
 		return map[string]interface{}{
@@ -45,16 +37,7 @@ func parseELF(this js.Value, args []js.Value) interface{} {
 	}
 
 	// Convert to JSON-serializable structure
-	info := ELFInfo{
-		Ident:          elfFile.Ident,
-		Class:          elfFile.Class,
-		Type:           elfFile.Type,
-		Machine:        elfFile.Machine,
-		Entry:          elfFile.Entry,
-		SectionHeaders: elfFile.SectionHeaders,
-		ProgramHeaders: elfFile.ProgramHeaders,
-		Symbols:        elfFile.Symbols,
-	}
+	info := schema.New(elfFile, schema.AllViews)
 
 	result, err := json.Marshal(info)
 	if err != nil {
@@ -69,9 +52,9 @@ func parseELF(this js.Value, args []js.Value) interface{} {
 }
 
 func getHexDump(this js.Value, args []js.Value) interface{} {
-	if len(args) != 2 {
+	if len(args) != 2 && len(args) != 3 {
 		return map[string]interface{}{
-			"error": "Expected 2 arguments: data, sectionName",
+			"error": "Expected 2 or 3 arguments: data, sectionName[, decompress]",
 		}
 	}
 
@@ -83,6 +66,7 @@ func getHexDump(this js.Value, args []js.Value) interface{} {
 	js.CopyBytesToGo(data, uint8Array)
 
 	sectionName := args[1].String()
+	decompress := len(args) == 3 && args[2].Truthy()
 
 	// Parse ELF
 	elfFile, err := elf.Parse(data)
@@ -94,7 +78,7 @@ func getHexDump(this js.Value, args []js.Value) interface{} {
 
 	// Get hex dump using a string writer
 	var buf strings.Builder
-	if err := elfFile.DisplayHexDump(&buf, sectionName); err != nil {
+	if err := elfFile.DisplayHexDump(&buf, sectionName, decompress); err != nil {
 		return map[string]interface{}{
 			"error": err.Error(),
 		}
@@ -105,10 +89,64 @@ func getHexDump(this js.Value, args []js.Value) interface{} {
 	}
 }
 
+// addr2line maps the whitespace-separated hex addresses in its second
+// argument to source locations, returned as a JSON array.
+func addr2line(this js.Value, args []js.Value) interface{} {
+	if len(args) != 2 {
+		return map[string]interface{}{
+			"error": "Expected 2 arguments: data, addresses",
+		}
+	}
+
+	arrayBuffer := args[0]
+	uint8Array := js.Global().Get("Uint8Array").New(arrayBuffer)
+	length := uint8Array.Get("length").Int()
+	data := make([]byte, length)
+	js.CopyBytesToGo(data, uint8Array)
+
+	var addrs []uint64
+	for _, field := range strings.Fields(args[1].String()) {
+		hex := strings.TrimPrefix(strings.TrimPrefix(field, "0x"), "0X")
+		a, err := strconv.ParseUint(hex, 16, 64)
+		if err != nil {
+			return map[string]interface{}{
+				"error": fmt.Sprintf("invalid address %q", field),
+			}
+		}
+		addrs = append(addrs, a)
+	}
+
+	elfFile, err := elf.Parse(data)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	locs, err := schema.NewSourceLocations(elfFile, addrs)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	result, err := json.Marshal(locs)
+	if err != nil {
+		return map[string]interface{}{
+			"error": err.Error(),
+		}
+	}
+
+	return map[string]interface{}{
+		"data": string(result),
+	}
+}
+
 func main() {
 	js.Global().Set("parseELF", js.FuncOf(parseELF))
 	js.Global().Set("getHexDump", js.FuncOf(getHexDump))
+	js.Global().Set("addr2line", js.FuncOf(addr2line))
 
 	// Keep the Go program running
 	select {}
-}
\ No newline at end of file
+}